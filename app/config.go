@@ -12,6 +12,7 @@ import (
 
 	"isley/config"
 	"isley/handlers"
+	"isley/storage"
 )
 
 // Config bundles everything NewEngine needs. Each caller (production
@@ -105,6 +106,12 @@ type Config struct {
 	// tests can write fixture logs without touching the repo's logs/.
 	LogsDir string
 
+	// Storage holds plant photos, their variants and the latest stream
+	// frames. Defaults to local disk under UploadDir when nil. Production
+	// builds it from ISLEY_STORAGE_BACKEND (see storage.ConfigFromEnv) so
+	// uploads can live in an S3-compatible bucket instead.
+	Storage storage.Store
+
 	// BackupService, if non-nil, is wired into the engine instead of
 	// having NewEngine construct one from DB+DataDir. Used by tests that
 	// want a handle to the service so they can deterministically flip
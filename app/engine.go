@@ -24,6 +24,7 @@ import (
 	"isley/config"
 	"isley/handlers"
 	"isley/logger"
+	"isley/storage"
 	"isley/utils"
 )
 
@@ -31,7 +32,7 @@ import (
 // fields on Config. Centralised so NewEngine, production main, and
 // tests all see the same fallback rules: UploadDir → "uploads",
 // StreamDir → <UploadDir>/streams, FrameDir → <StreamDir>, LogsDir →
-// "logs". Returning a copy keeps the input Config immutable from the
+// "logs", Storage → local disk under UploadDir. Returning a copy keeps the input Config immutable from the
 // caller's perspective.
 //
 // Exported so production main can read the resolved FrameDir before
@@ -53,6 +54,9 @@ func ResolvePathDefaults(cfg Config) Config {
 	if cfg.LogsDir == "" {
 		cfg.LogsDir = handlers.DefaultLogsDir
 	}
+	if cfg.Storage == nil {
+		cfg.Storage = storage.NewLocal(cfg.UploadDir)
+	}
 	return cfg
 }
 
@@ -101,7 +105,7 @@ func NewEngine(cfg Config) (*gin.Engine, error) {
 	}
 	r.SetHTMLTemplate(templ)
 
-	registerStaticRoutes(r, cfg.Assets)
+	registerStaticRoutes(r, cfg.Assets, cfg.Storage)
 
 	store := cookie.NewStore(cfg.SessionSecret)
 	store.Options(sessions.Options{
@@ -115,6 +119,7 @@ func NewEngine(cfg Config) (*gin.Engine, error) {
 	r.Use(dbMiddleware(cfg.DB))
 	r.Use(configStoreMiddleware(configStore))
 	r.Use(pathDirsMiddleware(cfg.UploadDir, cfg.StreamDir, cfg.LogsDir))
+	r.Use(storageMiddleware(cfg.Storage))
 
 	backupSvc := cfg.BackupService
 	if backupSvc == nil {
@@ -350,6 +355,16 @@ func pathDirsMiddleware(uploadDir, streamDir, logsDir string) gin.HandlerFunc {
 	}
 }
 
+// storageMiddleware injects the per-engine storage.Store that plant
+// photos and stream frames are kept in. Handlers retrieve it via
+// handlers.StorageFromContext(c).
+func storageMiddleware(s storage.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		handlers.SetStorageOnContext(c, s)
+		c.Next()
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Templates and static assets
 // ---------------------------------------------------------------------------
@@ -380,6 +395,7 @@ func buildFuncMap(store *config.Store) template.FuncMap {
 		"upper":     strings.ToUpper,
 		"lower":     strings.ToLower,
 		"hasPrefix": strings.HasPrefix,
+		"isVideo":   utils.IsVideoPath,
 		"default": func(val interface{}, def string) string {
 			if str, ok := val.(string); ok && str != "" {
 				return str
@@ -412,6 +428,22 @@ func buildFuncMap(store *config.Store) template.FuncMap {
 			}
 			return t.Format(utils.LayoutDateTime)
 		},
+		// Harvest weights are stored in grams and shown in the configured
+		// weight unit; forms multiply by gramsPerWeightUnit before saving.
+		"weightUnit": func() string {
+			return store.UnitPrefs().WeightUnit()
+		},
+		"displayWeight": func(grams float64) float64 {
+			return store.UnitPrefs().DisplayWeight(grams)
+		},
+		"gramsPerWeightUnit": func() float64 {
+			return store.UnitPrefs().GramsPerWeightUnit()
+		},
+		// percent turns a fraction (0.25) into a percentage (25).
+		"percent": func(f float64) float64 { return f * 100 },
+		// decimal formats f with a fixed number of decimal places. Unlike
+		// printf it takes optional (*float64) values straight from a with.
+		"decimal": func(f float64, places int) string { return strconv.FormatFloat(f, 'f', places, 64) },
 		"isZeroDate": func(t time.Time) bool {
 			return utils.IsZeroDate(t)
 		},
@@ -485,10 +517,16 @@ func buildFuncMap(store *config.Store) template.FuncMap {
 }
 
 // registerStaticRoutes wires up /static/, /fonts/, /uploads, and /favicon.ico.
-// Uploads remain on the live filesystem (./uploads); everything else comes
-// from cfg.Assets.
-func registerStaticRoutes(r *gin.Engine, assets fs.FS) {
-	r.Static("/uploads", "./uploads")
+// Uploads are served from the live filesystem (./uploads) or, with a
+// non-local store, from the store with ./uploads as the fallback for
+// locally generated files; everything else comes from cfg.Assets.
+func registerStaticRoutes(r *gin.Engine, assets fs.FS, store storage.Store) {
+	if _, local := storage.LocalPath(store, ""); local {
+		r.Static("/uploads", "./uploads")
+	} else {
+		r.GET("/uploads/*filepath", handlers.ServeStoredUpload(store, "./uploads"))
+		r.HEAD("/uploads/*filepath", handlers.ServeStoredUpload(store, "./uploads"))
+	}
 
 	r.GET("/static/*filepath", func(c *gin.Context) {
 		filePath := fmt.Sprintf("web/static%s", c.Param("filepath"))
@@ -47,6 +47,9 @@ func registerPublicRoutes(r *gin.Engine, cfg Config) {
 
 	r.GET("/logout", handlers.HandleLogout)
 	r.GET("/health", handleHealth)
+	// The calendar feed checks its own credentials: calendar apps can't
+	// log in or send headers, so it also accepts a token in the URL.
+	r.GET("/calendar.ics", handlers.CalendarFeed)
 
 	if cfg.GuestMode {
 		routes.AddBasicRoutes(r.Group("/"), cfg.Version)
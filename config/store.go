@@ -4,6 +4,7 @@ import (
 	"sync"
 
 	"isley/model/types"
+	"isley/utils"
 )
 
 // Store carries the runtime-mutable configuration the application reads
@@ -34,11 +35,17 @@ type Store struct {
 	guestMode          int
 	streamGrabEnabled  int
 	streamGrabInterval int
+	dailyPhotoEnabled  int
+	dailyPhotoTime     string
+	notifyWebhookURL   string
+	publicURL          string
+	taskReminderTime   string
 	apiKey             string
 	apiIngestEnabled   int
 	logLevel           string
 	maxBackupSize      int64
 	timezone           string
+	units              utils.UnitPrefs
 	cannadbEnabled     int
 	cannadbBaseURL     string
 }
@@ -52,6 +59,8 @@ const (
 	defaultAPIIngestEnabled = 1
 	defaultLogLevel         = "info"
 	defaultMaxBackupSize    = int64(5 * 1024 * 1024 * 1024) // 5 GB
+	defaultDailyPhotoTime   = "12:00"
+	defaultTaskReminderTime = "08:00"
 )
 
 // NewStore returns a Store seeded with the package-level defaults.
@@ -61,6 +70,8 @@ func NewStore() *Store {
 		apiIngestEnabled: defaultAPIIngestEnabled,
 		logLevel:         defaultLogLevel,
 		maxBackupSize:    defaultMaxBackupSize,
+		dailyPhotoTime:   defaultDailyPhotoTime,
+		taskReminderTime: defaultTaskReminderTime,
 	}
 }
 
@@ -236,6 +247,74 @@ func (s *Store) SetMaxBackupSize(v int64) {
 	s.maxBackupSize = v
 }
 
+func (s *Store) DailyPhotoEnabled() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.dailyPhotoEnabled
+}
+
+func (s *Store) SetDailyPhotoEnabled(v int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dailyPhotoEnabled = v
+}
+
+// DailyPhotoTime is the HH:MM time of day, in the app timezone, at which
+// the daily plant photo is taken.
+func (s *Store) DailyPhotoTime() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.dailyPhotoTime
+}
+
+func (s *Store) SetDailyPhotoTime(v string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dailyPhotoTime = v
+}
+
+// NotifyWebhookURL is where notifications such as task reminders are
+// posted. Empty means notifications are off.
+func (s *Store) NotifyWebhookURL() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.notifyWebhookURL
+}
+
+func (s *Store) SetNotifyWebhookURL(v string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notifyWebhookURL = v
+}
+
+// PublicURL is the address Isley is reached at, used to link calendar
+// events back to it. Empty means links are left out.
+func (s *Store) PublicURL() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.publicURL
+}
+
+func (s *Store) SetPublicURL(v string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.publicURL = v
+}
+
+// TaskReminderTime is the HH:MM time of day, in the app timezone, at
+// which the daily task reminder is sent.
+func (s *Store) TaskReminderTime() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.taskReminderTime
+}
+
+func (s *Store) SetTaskReminderTime(v string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.taskReminderTime = v
+}
+
 func (s *Store) Timezone() string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -248,6 +327,18 @@ func (s *Store) SetTimezone(v string) {
 	s.timezone = v
 }
 
+func (s *Store) UnitPrefs() utils.UnitPrefs {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.units
+}
+
+func (s *Store) SetUnitPrefs(v utils.UnitPrefs) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.units = v
+}
+
 // ----------------------------------------------------------------------
 // Slice getters/setters
 //
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
@@ -18,6 +19,7 @@ import (
 	"isley/config"
 	"isley/logger"
 	"isley/model"
+	"isley/storage"
 
 	"github.com/gin-gonic/gin"
 )
@@ -34,6 +36,7 @@ type BackupManifest struct {
 	Tables        int    `json:"tables"`
 	Files         int    `json:"files"`
 	IncludeImages bool   `json:"include_images"`
+	SkipVariants  bool   `json:"skip_variants,omitempty"`
 	SensorDays    int    `json:"sensor_days"` // 0 = all history
 }
 
@@ -41,26 +44,57 @@ type BackupManifest struct {
 // inside the archive. Each key is a table name mapped to a slice of
 // row-maps so the format is driver-agnostic.
 type BackupPayload struct {
-	Manifest       BackupManifest           `json:"manifest"`
-	Settings       []map[string]interface{} `json:"settings"`
-	APIKeys        []map[string]interface{} `json:"api_keys"`
-	Zones          []map[string]interface{} `json:"zones"`
-	Breeders       []map[string]interface{} `json:"breeder"`
-	Sensors        []map[string]interface{} `json:"sensors"`
-	SensorData     []map[string]interface{} `json:"sensor_data"`
-	RollingAvgs    []map[string]interface{} `json:"rolling_averages"`
-	Strains        []map[string]interface{} `json:"strain"`
-	StrainLineage  []map[string]interface{} `json:"strain_lineage"`
-	PlantStatuses  []map[string]interface{} `json:"plant_status"`
-	Plants         []map[string]interface{} `json:"plant"`
-	PlantStatusLog []map[string]interface{} `json:"plant_status_log"`
-	Metrics        []map[string]interface{} `json:"metric"`
-	ActivityMetric []map[string]interface{} `json:"activity_metric"`
-	PlantMeasure   []map[string]interface{} `json:"plant_measurements"`
-	Activities     []map[string]interface{} `json:"activity"`
-	PlantActivity  []map[string]interface{} `json:"plant_activity"`
-	PlantImages    []map[string]interface{} `json:"plant_images"`
-	Streams        []map[string]interface{} `json:"streams"`
+	Manifest            BackupManifest           `json:"manifest"`
+	Settings            []map[string]interface{} `json:"settings"`
+	APIKeys             []map[string]interface{} `json:"api_keys"`
+	Zones               []map[string]interface{} `json:"zones"`
+	Breeders            []map[string]interface{} `json:"breeder"`
+	Sensors             []map[string]interface{} `json:"sensors"`
+	SensorData          []map[string]interface{} `json:"sensor_data"`
+	RollingAvgs         []map[string]interface{} `json:"rolling_averages"`
+	Strains             []map[string]interface{} `json:"strain"`
+	StrainLineage       []map[string]interface{} `json:"strain_lineage"`
+	PlantStatuses       []map[string]interface{} `json:"plant_status"`
+	Plants              []map[string]interface{} `json:"plant"`
+	PlantStatusLog      []map[string]interface{} `json:"plant_status_log"`
+	Metrics             []map[string]interface{} `json:"metric"`
+	ActivityMetric      []map[string]interface{} `json:"activity_metric"`
+	PlantMeasure        []map[string]interface{} `json:"plant_measurements"`
+	Activities          []map[string]interface{} `json:"activity"`
+	PlantActivity       []map[string]interface{} `json:"plant_activity"`
+	PlantImages         []map[string]interface{} `json:"plant_images"`
+	Streams             []map[string]interface{} `json:"streams"`
+	VirtualSensors      []map[string]interface{} `json:"virtual_sensors"`
+	SensorCalibrations  []map[string]interface{} `json:"sensor_calibrations"`
+	SensorFilters       []map[string]interface{} `json:"sensor_filters"`
+	SensorQuarantine    []map[string]interface{} `json:"sensor_data_quarantine"`
+	Timelapses          []map[string]interface{} `json:"timelapses"`
+	ImageTags           []map[string]interface{} `json:"image_tags"`
+	PlantImageTags      []map[string]interface{} `json:"plant_image_tags"`
+	Harvests            []map[string]interface{} `json:"harvests"`
+	HarvestDryLogs      []map[string]interface{} `json:"harvest_dry_logs"`
+	CureJars            []map[string]interface{} `json:"cure_jars"`
+	CureJarLogs         []map[string]interface{} `json:"cure_jar_logs"`
+	HarvestYields       []map[string]interface{} `json:"harvest_yields"`
+	GrowRuns            []map[string]interface{} `json:"grow_runs"`
+	NutrientProducts    []map[string]interface{} `json:"nutrient_products"`
+	NutrientRecipes     []map[string]interface{} `json:"nutrient_recipes"`
+	RecipeItems         []map[string]interface{} `json:"nutrient_recipe_items"`
+	FeedingSchedules    []map[string]interface{} `json:"feeding_schedules"`
+	ScheduleWeeks       []map[string]interface{} `json:"feeding_schedule_weeks"`
+	ActivityNutrients   []map[string]interface{} `json:"activity_nutrients"`
+	Tasks               []map[string]interface{} `json:"tasks"`
+	TaskCompletions     []map[string]interface{} `json:"task_completions"`
+	SeedLots            []map[string]interface{} `json:"seed_lots"`
+	GerminationAttempts []map[string]interface{} `json:"germination_attempts"`
+	BreedingProjects    []map[string]interface{} `json:"breeding_projects"`
+	Pollinations        []map[string]interface{} `json:"pollinations"`
+	PhenoCriteria       []map[string]interface{} `json:"pheno_criteria"`
+	PhenoScores         []map[string]interface{} `json:"pheno_scores"`
+	StrainReviews       []map[string]interface{} `json:"strain_reviews"`
+	StrainReviewTags    []map[string]interface{} `json:"strain_review_tags"`
+	StrainCannadbLocks  []map[string]interface{} `json:"strain_cannadb_locks"`
+	BreederCannadbLocks []map[string]interface{} `json:"breeder_cannadb_locks"`
 }
 
 // BackupFileInfo is returned by the list endpoint.
@@ -103,8 +137,9 @@ const insertBatchSize = 5000
 // CreateBackup kicks off an async backup job and returns immediately.
 // Query params:
 //
-//	?images=true|false  — include uploaded images (default false)
-//	?sensor_days=N      — include only last N days of sensor_data (0 = all, -1 = none)
+//	?images=true|false   — include uploaded images (default false)
+//	?variants=true|false — with images, include generated thumbnails (default true)
+//	?sensor_days=N       — include only last N days of sensor_data (0 = all, -1 = none)
 func CreateBackup(c *gin.Context) {
 	fieldLogger := logger.Log.WithField("handler", "CreateBackup")
 
@@ -115,15 +150,18 @@ func CreateBackup(c *gin.Context) {
 	}
 
 	includeImages := c.DefaultQuery("images", "false") == "true"
+	skipVariants := c.DefaultQuery("variants", "true") == "false"
 	sensorDays := 0 // default: all
 	if sd := c.Query("sensor_days"); sd != "" {
 		fmt.Sscanf(sd, "%d", &sensorDays)
 	}
 
-	fieldLogger.Infof("Starting async backup: images=%v sensor_days=%d", includeImages, sensorDays)
+	files := StorageFromContext(c)
+	uploadDir, streamDir := UploadDirFromContext(c), StreamDirFromContext(c)
+	fieldLogger.Infof("Starting async backup: images=%v variants=%v sensor_days=%d", includeImages, !skipVariants, sensorDays)
 
 	go func() {
-		filename, err := runBackup(svc, includeImages, sensorDays)
+		filename, err := runBackup(svc, files, uploadDir, streamDir, includeImages, skipVariants, sensorDays)
 		svc.CompleteBackup(filename, err)
 		if err != nil {
 			fieldLogger.WithError(err).Error("Async backup failed")
@@ -147,8 +185,9 @@ func GetRestoreStatus(c *gin.Context) {
 // The archive contents are produced by BuildBackupArchive (which is
 // unit-tested directly); runBackup adds the production-only concerns:
 // reading the VERSION file, naming the output, and writing it under
-// <DataDir>/backups/. Returns the produced filename (empty on error).
-func runBackup(svc *BackupService, includeImages bool, sensorDays int) (string, error) {
+// <DataDir>/backups/. Image files are read from files and from the
+// local uploadDir. Returns the produced filename (empty on error).
+func runBackup(svc *BackupService, files storage.Store, uploadDir, streamDir string, includeImages, skipVariants bool, sensorDays int) (string, error) {
 	fieldLogger := logger.Log.WithField("handler", "runBackup")
 
 	version := "unknown"
@@ -157,10 +196,13 @@ func runBackup(svc *BackupService, includeImages bool, sensorDays int) (string,
 	}
 
 	archive, manifest, err := BuildBackupArchive(svc.DB(), BuildArchiveOptions{
-		IncludeImages: includeImages,
-		SensorDays:    sensorDays,
-		Version:       version,
-		UploadsDir:    "uploads",
+		IncludeImages:     includeImages,
+		SkipImageVariants: skipVariants,
+		SensorDays:        sensorDays,
+		Version:           version,
+		UploadsDir:        uploadDir,
+		StreamDir:         streamDir,
+		Storage:           files,
 	})
 	if err != nil {
 		return "", err
@@ -563,13 +605,14 @@ func ImportBackup(c *gin.Context) {
 	// reach into the per-engine Store after the request returns. The Store
 	// itself is still passed so the post-restore reload populates the live
 	// engine's view of settings.
-	go runRestore(svc, payload, body, maxBackupSize, ConfigStoreFromContext(c))
+	go runRestore(svc, payload, body, maxBackupSize, ConfigStoreFromContext(c), StorageFromContext(c))
 
 	c.JSON(http.StatusAccepted, gin.H{"message": T(c, "api_restore_started")})
 }
 
 // runRestore performs the actual database restore work in a background goroutine.
-func runRestore(svc *BackupService, payload BackupPayload, zipBody []byte, maxBackupSize int64, store *config.Store) {
+// Upload files in the archive are written to files.
+func runRestore(svc *BackupService, payload BackupPayload, zipBody []byte, maxBackupSize int64, store *config.Store, files storage.Store) {
 	fieldLogger := logger.Log.WithField("handler", "runRestore")
 
 	var (
@@ -595,15 +638,46 @@ func runRestore(svc *BackupService, payload BackupPayload, zipBody []byte, maxBa
 
 	// Tables in deletion order (children first) to satisfy FK constraints.
 	truncateOrder := []string{
+		"task_completions",
+		"tasks",
+		"activity_nutrients",
 		"plant_activity",
 		"plant_measurements",
 		"plant_status_log",
+		"harvest_yields",
+		"cure_jar_logs",
+		"cure_jars",
+		"harvest_dry_logs",
+		"harvests",
+		"plant_image_tags",
+		"image_tags",
 		"plant_images",
+		"timelapses",
 		"streams",
+		"breeder_cannadb_locks",
+		"strain_cannadb_locks",
+		"strain_review_tags",
+		"strain_reviews",
+		"pheno_scores",
+		"pheno_criteria",
+		"pollinations",
+		"breeding_projects",
+		"germination_attempts",
+		"seed_lots",
 		"strain_lineage",
 		"plant",
+		"feeding_schedule_weeks",
+		"feeding_schedules",
+		"nutrient_recipe_items",
+		"nutrient_recipes",
+		"nutrient_products",
+		"grow_runs",
 		"sensor_data",
 		"rolling_averages",
+		"virtual_sensors",
+		"sensor_data_quarantine",
+		"sensor_filters",
+		"sensor_calibrations",
 		"sensors",
 		"strain",
 		"activity_metric",
@@ -630,16 +704,47 @@ func runRestore(svc *BackupService, payload BackupPayload, zipBody []byte, maxBa
 		{"activity", payload.Activities},
 		{"activity_metric", payload.ActivityMetric},
 		{"sensors", payload.Sensors},
+		{"sensor_calibrations", payload.SensorCalibrations},
+		{"sensor_filters", payload.SensorFilters},
+		{"sensor_data_quarantine", payload.SensorQuarantine},
+		{"virtual_sensors", payload.VirtualSensors},
 		{"sensor_data", payload.SensorData},
 		{"rolling_averages", payload.RollingAvgs},
 		{"strain", payload.Strains},
 		{"strain_lineage", payload.StrainLineage},
+		{"seed_lots", payload.SeedLots},
+		{"germination_attempts", payload.GerminationAttempts},
+		{"grow_runs", payload.GrowRuns},
+		{"nutrient_products", payload.NutrientProducts},
+		{"nutrient_recipes", payload.NutrientRecipes},
+		{"nutrient_recipe_items", payload.RecipeItems},
+		{"feeding_schedules", payload.FeedingSchedules},
+		{"feeding_schedule_weeks", payload.ScheduleWeeks},
 		{"plant", payload.Plants},
 		{"plant_status_log", payload.PlantStatusLog},
 		{"plant_measurements", payload.PlantMeasure},
 		{"plant_activity", payload.PlantActivity},
+		{"activity_nutrients", payload.ActivityNutrients},
+		{"tasks", payload.Tasks},
+		{"task_completions", payload.TaskCompletions},
+		{"breeding_projects", payload.BreedingProjects},
+		{"pollinations", payload.Pollinations},
+		{"pheno_criteria", payload.PhenoCriteria},
+		{"pheno_scores", payload.PhenoScores},
+		{"strain_reviews", payload.StrainReviews},
+		{"strain_review_tags", payload.StrainReviewTags},
+		{"strain_cannadb_locks", payload.StrainCannadbLocks},
+		{"breeder_cannadb_locks", payload.BreederCannadbLocks},
 		{"plant_images", payload.PlantImages},
+		{"image_tags", payload.ImageTags},
+		{"plant_image_tags", payload.PlantImageTags},
+		{"harvests", payload.Harvests},
+		{"harvest_dry_logs", payload.HarvestDryLogs},
+		{"cure_jars", payload.CureJars},
+		{"cure_jar_logs", payload.CureJarLogs},
+		{"harvest_yields", payload.HarvestYields},
 		{"streams", payload.Streams},
+		{"timelapses", payload.Timelapses},
 	}
 
 	// Count tables with data for progress tracking
@@ -820,6 +925,37 @@ func runRestore(svc *BackupService, payload BackupPayload, zipBody []byte, maxBa
 			"strain", "strain_lineage", "plant_status", "plant",
 			"plant_status_log", "metric", "plant_measurements",
 			"activity", "activity_metric", "plant_activity", "plant_images", "streams",
+			"virtual_sensors",
+			"sensor_calibrations",
+			"sensor_filters",
+			"sensor_data_quarantine",
+			"timelapses",
+			"image_tags",
+			"plant_image_tags",
+			"harvests",
+			"harvest_dry_logs",
+			"cure_jars",
+			"cure_jar_logs",
+			"harvest_yields",
+			"grow_runs",
+			"nutrient_products",
+			"nutrient_recipes",
+			"nutrient_recipe_items",
+			"feeding_schedules",
+			"feeding_schedule_weeks",
+			"activity_nutrients",
+			"tasks",
+			"task_completions",
+			"seed_lots",
+			"germination_attempts",
+			"breeding_projects",
+			"pollinations",
+			"pheno_criteria",
+			"pheno_scores",
+			"strain_reviews",
+			"strain_review_tags",
+			"strain_cannadb_locks",
+			"breeder_cannadb_locks",
 		}
 		for _, tbl := range seqTables {
 			q := fmt.Sprintf(
@@ -835,8 +971,6 @@ func runRestore(svc *BackupService, payload BackupPayload, zipBody []byte, maxBa
 	// ---- extract upload files ---------------------------------------------
 	svc.UpdateRestoreProgress("extracting", "", 0, 0, 0, totalTablesWithData)
 
-	uploadsDir := "uploads"
-
 	// Re-open the zip from the in-memory body (the original zr may be stale
 	// since we're in a different goroutine context, but we kept zipBody).
 	zr2, err := zip.NewReader(bytes.NewReader(zipBody), int64(len(zipBody)))
@@ -845,27 +979,32 @@ func runRestore(svc *BackupService, payload BackupPayload, zipBody []byte, maxBa
 	} else {
 		hasUploads := false
 		for _, zf := range zr2.File {
-			if strings.HasPrefix(zf.Name, "uploads/") && !strings.HasSuffix(zf.Name, "/") {
+			if strings.HasPrefix(zf.Name, backupUploadsPrefix) && !strings.HasSuffix(zf.Name, "/") {
 				hasUploads = true
 				break
 			}
 		}
 
 		if hasUploads {
-			if err := os.RemoveAll(uploadsDir); err != nil {
-				fieldLogger.WithError(err).Warn("Could not clean existing uploads dir")
+			// Object storage is left as is: restored files overwrite their
+			// keys and anything else stays in the bucket.
+			if uploadsDir, ok := storage.LocalPath(files, ""); ok {
+				if err := os.RemoveAll(uploadsDir); err != nil {
+					fieldLogger.WithError(err).Warn("Could not clean existing uploads dir")
+				}
 			}
 
+			ctx := context.Background()
 			var extractedBytes int64
 			extractLimit := maxBackupSize
 			extractAborted := false
 
 			for _, zf := range zr2.File {
-				if !strings.HasPrefix(zf.Name, "uploads/") || strings.HasSuffix(zf.Name, "/") {
+				if !strings.HasPrefix(zf.Name, backupUploadsPrefix) || strings.HasSuffix(zf.Name, "/") {
 					continue
 				}
-				dest := filepath.Join(".", zf.Name)
-				if !strings.HasPrefix(filepath.Clean(dest), "uploads") {
+				key, err := storage.CleanKey(strings.TrimPrefix(zf.Name, backupUploadsPrefix))
+				if err != nil {
 					fieldLogger.Warnf("Skipping suspicious zip entry: %s", zf.Name)
 					continue
 				}
@@ -878,41 +1017,32 @@ func runRestore(svc *BackupService, payload BackupPayload, zipBody []byte, maxBa
 					break
 				}
 
-				if err := os.MkdirAll(filepath.Dir(dest), os.ModePerm); err != nil {
-					fieldLogger.WithError(err).Errorf("Failed to create dir for %s", dest)
-					continue
-				}
-
 				rc, err := zf.Open()
 				if err != nil {
 					fieldLogger.WithError(err).Errorf("Failed to open zip entry %s", zf.Name)
 					continue
 				}
 
-				out, err := os.Create(dest)
-				if err != nil {
-					rc.Close()
-					fieldLogger.WithError(err).Errorf("Failed to create file %s", dest)
-					continue
-				}
-
 				// Use a limited reader to enforce the cap even if the declared
 				// size in the zip header is spoofed (decompression bomb defense).
 				remaining := extractLimit - extractedBytes
-				written, copyErr := io.Copy(out, io.LimitReader(rc, remaining+1))
-				out.Close()
+				data, readErr := io.ReadAll(io.LimitReader(rc, remaining+1))
 				rc.Close()
 
-				if written > remaining {
+				if int64(len(data)) > remaining {
 					fieldLogger.Errorf("Extraction limit exceeded during write of %s", zf.Name)
-					os.Remove(dest)
 					extractAborted = true
 					break
 				}
 
-				extractedBytes += written
-				if copyErr != nil {
-					fieldLogger.WithError(copyErr).Errorf("Failed to write file %s", dest)
+				extractedBytes += int64(len(data))
+				if readErr != nil {
+					fieldLogger.WithError(readErr).Errorf("Failed to read zip entry %s", zf.Name)
+					continue
+				}
+				if err := storage.PutBytes(ctx, files, key, data); err != nil {
+					fieldLogger.WithError(err).Errorf("Failed to write file %s", key)
+					continue
 				}
 				filesRestored++
 			}
@@ -968,6 +1098,26 @@ func dumpTable(db *sql.DB, table string) ([]map[string]interface{}, error) {
 	return result, rows.Err()
 }
 
+// redactBackupRow blanks secrets that must not leave the server in a
+// backup. Stream passwords are stored in plain text so they can be sent
+// to the camera; a restored stream needs its password re-entered.
+// Legacy stream URLs may still embed credentials, so those are stripped
+// of their password too.
+func redactBackupRow(table string, row map[string]interface{}) {
+	if table != "streams" {
+		return
+	}
+	if _, ok := row["password"]; ok {
+		row["password"] = ""
+	}
+	if raw, ok := row["url"].(string); ok {
+		if parsed, err := url.Parse(raw); err == nil && parsed.User != nil {
+			parsed.User = url.User(parsed.User.Username())
+			row["url"] = parsed.String()
+		}
+	}
+}
+
 // normaliseValue converts sql-driver types to JSON-safe primitives.
 func normaliseValue(v interface{}) interface{} {
 	switch val := v.(type) {
@@ -8,20 +8,27 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"isley/logger"
 	"isley/model"
+	"isley/storage"
+	"isley/utils"
 )
 
 // BuildArchiveOptions tunes BuildBackupArchive. Production code sets
 // these from runtime config; tests construct them by hand.
 type BuildArchiveOptions struct {
-	// IncludeImages walks UploadsDir and embeds every file under uploads/.
+	// IncludeImages embeds every upload file under uploads/.
 	IncludeImages bool
 
+	// SkipImageVariants leaves the generated thumbnail and medium image
+	// variants out of an image backup. They are rebuilt on first view.
+	SkipImageVariants bool
+
 	// SensorDays controls sensor_data filtering:
 	//   0  → include all rows (default)
 	//   N  → include only the last N days
@@ -31,10 +38,20 @@ type BuildArchiveOptions struct {
 	// Version is baked into the manifest. Defaults to "unknown" if empty.
 	Version string
 
-	// UploadsDir is the directory walked when IncludeImages is true.
-	// Defaults to "uploads" relative to the working directory.
+	// UploadsDir is the local upload directory walked when IncludeImages
+	// is true. Defaults to "uploads" relative to the working directory.
 	UploadsDir string
 
+	// Storage, when set, is listed for the image files so image backups
+	// work with object storage too. UploadsDir is walked as well, since
+	// timelapse videos, logos and decorated images are still written to
+	// local disk; a key present in both is taken from Storage.
+	Storage storage.Store
+
+	// StreamDir is the grabber's frame root, whose archive/ subtree is
+	// left out of image backups. Defaults to DefaultStreamDir(UploadsDir).
+	StreamDir string
+
 	// Now feeds the manifest CreatedAt timestamp. Zero values default
 	// to time.Now() so production callers don't have to pass it.
 	Now time.Time
@@ -78,10 +95,30 @@ func BuildBackupArchive(db *sql.DB, opts BuildArchiveOptions) ([]byte, BackupMan
 		{"zones", &payload.Zones},
 		{"breeder", &payload.Breeders},
 		{"sensors", &payload.Sensors},
+		{"sensor_calibrations", &payload.SensorCalibrations},
+		{"sensor_filters", &payload.SensorFilters},
+		{"sensor_data_quarantine", &payload.SensorQuarantine},
+		{"virtual_sensors", &payload.VirtualSensors},
 		{"rolling_averages", &payload.RollingAvgs},
 		{"plant_status", &payload.PlantStatuses},
 		{"strain", &payload.Strains},
 		{"strain_lineage", &payload.StrainLineage},
+		{"seed_lots", &payload.SeedLots},
+		{"germination_attempts", &payload.GerminationAttempts},
+		{"breeding_projects", &payload.BreedingProjects},
+		{"pollinations", &payload.Pollinations},
+		{"pheno_criteria", &payload.PhenoCriteria},
+		{"pheno_scores", &payload.PhenoScores},
+		{"strain_reviews", &payload.StrainReviews},
+		{"strain_review_tags", &payload.StrainReviewTags},
+		{"strain_cannadb_locks", &payload.StrainCannadbLocks},
+		{"breeder_cannadb_locks", &payload.BreederCannadbLocks},
+		{"grow_runs", &payload.GrowRuns},
+		{"nutrient_products", &payload.NutrientProducts},
+		{"nutrient_recipes", &payload.NutrientRecipes},
+		{"nutrient_recipe_items", &payload.RecipeItems},
+		{"feeding_schedules", &payload.FeedingSchedules},
+		{"feeding_schedule_weeks", &payload.ScheduleWeeks},
 		{"metric", &payload.Metrics},
 		{"activity", &payload.Activities},
 		{"activity_metric", &payload.ActivityMetric},
@@ -89,8 +126,19 @@ func BuildBackupArchive(db *sql.DB, opts BuildArchiveOptions) ([]byte, BackupMan
 		{"plant_status_log", &payload.PlantStatusLog},
 		{"plant_measurements", &payload.PlantMeasure},
 		{"plant_activity", &payload.PlantActivity},
+		{"activity_nutrients", &payload.ActivityNutrients},
+		{"tasks", &payload.Tasks},
+		{"task_completions", &payload.TaskCompletions},
 		{"plant_images", &payload.PlantImages},
+		{"image_tags", &payload.ImageTags},
+		{"plant_image_tags", &payload.PlantImageTags},
+		{"harvests", &payload.Harvests},
+		{"harvest_dry_logs", &payload.HarvestDryLogs},
+		{"cure_jars", &payload.CureJars},
+		{"cure_jar_logs", &payload.CureJarLogs},
+		{"harvest_yields", &payload.HarvestYields},
 		{"streams", &payload.Streams},
+		{"timelapses", &payload.Timelapses},
 	}
 
 	tableCount := 0
@@ -99,6 +147,9 @@ func BuildBackupArchive(db *sql.DB, opts BuildArchiveOptions) ([]byte, BackupMan
 		if err != nil {
 			return nil, BackupManifest{}, fmt.Errorf("dump %s: %w", tq.name, err)
 		}
+		for _, row := range rows {
+			redactBackupRow(tq.name, row)
+		}
 		*tq.dest = rows
 		if len(rows) > 0 {
 			tableCount++
@@ -122,15 +173,21 @@ func BuildBackupArchive(db *sql.DB, opts BuildArchiveOptions) ([]byte, BackupMan
 		}
 	}
 
-	fileCount := 0
+	streamDir := opts.StreamDir
+	if streamDir == "" {
+		streamDir = DefaultStreamDir(uploadsDir)
+	}
+	ctx := context.Background()
+
+	var uploads []backupUpload
 	if opts.IncludeImages {
-		_ = filepath.Walk(uploadsDir, func(path string, info os.FileInfo, err error) error {
-			if err == nil && !info.IsDir() {
-				fileCount++
-			}
-			return nil
-		})
+		var err error
+		uploads, err = listBackupUploads(ctx, opts, uploadsDir, frameArchivePrefix(uploadsDir, streamDir))
+		if err != nil {
+			return nil, BackupManifest{}, fmt.Errorf("list uploads: %w", err)
+		}
 	}
+	fileCount := len(uploads)
 
 	payload.Manifest = BackupManifest{
 		Version:       version,
@@ -139,6 +196,7 @@ func BuildBackupArchive(db *sql.DB, opts BuildArchiveOptions) ([]byte, BackupMan
 		Tables:        tableCount,
 		Files:         fileCount,
 		IncludeImages: opts.IncludeImages,
+		SkipVariants:  opts.IncludeImages && opts.SkipImageVariants,
 		SensorDays:    opts.SensorDays,
 	}
 
@@ -158,24 +216,8 @@ func BuildBackupArchive(db *sql.DB, opts BuildArchiveOptions) ([]byte, BackupMan
 		return nil, BackupManifest{}, fmt.Errorf("write backup.json: %w", err)
 	}
 
-	if opts.IncludeImages {
-		err := filepath.Walk(uploadsDir, func(path string, info os.FileInfo, walkErr error) error {
-			if walkErr != nil || info.IsDir() {
-				return walkErr
-			}
-			fw, err := zw.Create(path)
-			if err != nil {
-				return err
-			}
-			f, err := os.Open(path)
-			if err != nil {
-				return err
-			}
-			defer f.Close()
-			_, err = io.Copy(fw, f)
-			return err
-		})
-		if err != nil {
+	for _, up := range uploads {
+		if err := addBackupUpload(ctx, zw, up); err != nil {
 			return nil, BackupManifest{}, fmt.Errorf("add uploads: %w", err)
 		}
 	}
@@ -188,6 +230,92 @@ func BuildBackupArchive(db *sql.DB, opts BuildArchiveOptions) ([]byte, BackupMan
 	return buf.Bytes(), payload.Manifest, nil
 }
 
+// backupUploadsPrefix roots upload files inside a backup archive;
+// restore stores each entry under the key that follows it.
+const backupUploadsPrefix = "uploads/"
+
+// frameArchivePrefix returns the key prefix, relative to uploadsDir, of
+// the stream frame archive under streamDir. Archived frames are raw
+// capture history that can run to gigabytes, so image backups carry the
+// rendered timelapses but not the frames they were built from. It is
+// empty when the archive lives outside uploadsDir and so never shows up
+// in a listing of it.
+func frameArchivePrefix(uploadsDir, streamDir string) string {
+	rel, err := filepath.Rel(uploadsDir, filepath.Join(streamDir, "archive"))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return filepath.ToSlash(rel) + "/"
+}
+
+// skipUploadKey reports whether an image backup leaves out the file
+// stored under key: always the frame archive, and image variants when
+// asked to.
+func skipUploadKey(key, archivePrefix string, opts BuildArchiveOptions) bool {
+	if archivePrefix != "" && strings.HasPrefix(key, archivePrefix) {
+		return true
+	}
+	if !opts.SkipImageVariants {
+		return false
+	}
+	dirs := strings.Split(key, "/")
+	return slices.Contains(dirs[:len(dirs)-1], utils.ImageVariantDirName)
+}
+
+// backupUpload is one file an image backup carries, and the store it is
+// read from.
+type backupUpload struct {
+	files storage.Store
+	key   string
+}
+
+// listBackupUploads lists the files an image backup carries: everything
+// in opts.Storage, then whatever only exists in the local uploadsDir.
+// A local store rooted at uploadsDir is walked once.
+func listBackupUploads(ctx context.Context, opts BuildArchiveOptions, uploadsDir, archivePrefix string) ([]backupUpload, error) {
+	local := storage.Store(storage.NewLocal(uploadsDir))
+	stores := []storage.Store{local}
+	if opts.Storage != nil {
+		stores = []storage.Store{opts.Storage}
+		if root, ok := storage.LocalPath(opts.Storage, ""); !ok || filepath.Clean(root) != filepath.Clean(uploadsDir) {
+			stores = append(stores, local)
+		}
+	}
+
+	var uploads []backupUpload
+	seen := map[string]bool{}
+	for _, files := range stores {
+		err := files.List(ctx, "", func(obj storage.Object) error {
+			if seen[obj.Key] || skipUploadKey(obj.Key, archivePrefix, opts) {
+				return nil
+			}
+			seen[obj.Key] = true
+			uploads = append(uploads, backupUpload{files: files, key: obj.Key})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return uploads, nil
+}
+
+// addBackupUpload copies one upload into the archive under
+// backupUploadsPrefix.
+func addBackupUpload(ctx context.Context, zw *zip.Writer, up backupUpload) error {
+	fw, err := zw.Create(backupUploadsPrefix + up.key)
+	if err != nil {
+		return err
+	}
+	rc, err := up.files.Get(ctx, up.key)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_, err = io.Copy(fw, rc)
+	return err
+}
+
 // ParseBackupArchive reads a zip archive produced by BuildBackupArchive
 // and returns the parsed BackupPayload. Returns a wrapped error if the
 // bytes are not a valid zip, lack a backup.json entry, or contain
@@ -238,15 +366,46 @@ func ApplyBackupToDB(ctx context.Context, db *sql.DB, payload BackupPayload) err
 
 	// Tables in deletion order (children first) to satisfy FK constraints.
 	truncateOrder := []string{
+		"task_completions",
+		"tasks",
+		"activity_nutrients",
 		"plant_activity",
 		"plant_measurements",
 		"plant_status_log",
+		"harvest_yields",
+		"cure_jar_logs",
+		"cure_jars",
+		"harvest_dry_logs",
+		"harvests",
+		"plant_image_tags",
+		"image_tags",
 		"plant_images",
+		"timelapses",
 		"streams",
+		"breeder_cannadb_locks",
+		"strain_cannadb_locks",
+		"strain_review_tags",
+		"strain_reviews",
+		"pheno_scores",
+		"pheno_criteria",
+		"pollinations",
+		"breeding_projects",
+		"germination_attempts",
+		"seed_lots",
 		"strain_lineage",
 		"plant",
+		"feeding_schedule_weeks",
+		"feeding_schedules",
+		"nutrient_recipe_items",
+		"nutrient_recipes",
+		"nutrient_products",
+		"grow_runs",
 		"sensor_data",
 		"rolling_averages",
+		"virtual_sensors",
+		"sensor_data_quarantine",
+		"sensor_filters",
+		"sensor_calibrations",
 		"sensors",
 		"strain",
 		"activity_metric",
@@ -273,6 +432,10 @@ func ApplyBackupToDB(ctx context.Context, db *sql.DB, payload BackupPayload) err
 		{"activity", payload.Activities},
 		{"activity_metric", payload.ActivityMetric},
 		{"sensors", payload.Sensors},
+		{"sensor_calibrations", payload.SensorCalibrations},
+		{"sensor_filters", payload.SensorFilters},
+		{"sensor_data_quarantine", payload.SensorQuarantine},
+		{"virtual_sensors", payload.VirtualSensors},
 		// rolling_averages BEFORE sensor_data: there's an AFTER INSERT
 		// trigger on sensor_data that does INSERT OR REPLACE INTO
 		// rolling_averages, so it would clobber a regular INSERT into
@@ -285,12 +448,39 @@ func ApplyBackupToDB(ctx context.Context, db *sql.DB, payload BackupPayload) err
 		{"sensor_data", payload.SensorData},
 		{"strain", payload.Strains},
 		{"strain_lineage", payload.StrainLineage},
+		{"seed_lots", payload.SeedLots},
+		{"germination_attempts", payload.GerminationAttempts},
+		{"grow_runs", payload.GrowRuns},
+		{"nutrient_products", payload.NutrientProducts},
+		{"nutrient_recipes", payload.NutrientRecipes},
+		{"nutrient_recipe_items", payload.RecipeItems},
+		{"feeding_schedules", payload.FeedingSchedules},
+		{"feeding_schedule_weeks", payload.ScheduleWeeks},
 		{"plant", payload.Plants},
 		{"plant_status_log", payload.PlantStatusLog},
 		{"plant_measurements", payload.PlantMeasure},
 		{"plant_activity", payload.PlantActivity},
+		{"activity_nutrients", payload.ActivityNutrients},
+		{"tasks", payload.Tasks},
+		{"task_completions", payload.TaskCompletions},
+		{"breeding_projects", payload.BreedingProjects},
+		{"pollinations", payload.Pollinations},
+		{"pheno_criteria", payload.PhenoCriteria},
+		{"pheno_scores", payload.PhenoScores},
+		{"strain_reviews", payload.StrainReviews},
+		{"strain_review_tags", payload.StrainReviewTags},
+		{"strain_cannadb_locks", payload.StrainCannadbLocks},
+		{"breeder_cannadb_locks", payload.BreederCannadbLocks},
 		{"plant_images", payload.PlantImages},
+		{"image_tags", payload.ImageTags},
+		{"plant_image_tags", payload.PlantImageTags},
+		{"harvests", payload.Harvests},
+		{"harvest_dry_logs", payload.HarvestDryLogs},
+		{"cure_jars", payload.CureJars},
+		{"cure_jar_logs", payload.CureJarLogs},
+		{"harvest_yields", payload.HarvestYields},
 		{"streams", payload.Streams},
+		{"timelapses", payload.Timelapses},
 	}
 
 	tx, err := db.BeginTx(ctx, nil)
@@ -349,6 +539,37 @@ func ApplyBackupToDB(ctx context.Context, db *sql.DB, payload BackupPayload) err
 			"strain", "strain_lineage", "plant_status", "plant",
 			"plant_status_log", "metric", "plant_measurements",
 			"activity", "activity_metric", "plant_activity", "plant_images", "streams",
+			"virtual_sensors",
+			"sensor_calibrations",
+			"sensor_filters",
+			"sensor_data_quarantine",
+			"timelapses",
+			"image_tags",
+			"plant_image_tags",
+			"harvests",
+			"harvest_dry_logs",
+			"cure_jars",
+			"cure_jar_logs",
+			"harvest_yields",
+			"grow_runs",
+			"nutrient_products",
+			"nutrient_recipes",
+			"nutrient_recipe_items",
+			"feeding_schedules",
+			"feeding_schedule_weeks",
+			"activity_nutrients",
+			"tasks",
+			"task_completions",
+			"seed_lots",
+			"germination_attempts",
+			"breeding_projects",
+			"pollinations",
+			"pheno_criteria",
+			"pheno_scores",
+			"strain_reviews",
+			"strain_review_tags",
+			"strain_cannadb_locks",
+			"breeder_cannadb_locks",
 		}
 		for _, tbl := range seqTables {
 			q := fmt.Sprintf(
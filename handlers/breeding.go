@@ -0,0 +1,804 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"isley/logger"
+	"isley/model/types"
+	"isley/utils"
+)
+
+// MaxGenerationLength caps a pollination's generation label.
+const MaxGenerationLength = 20
+
+// pollinateActivityName is the activity logged on the female when a
+// pollination is logged. Migration 035 adds it.
+const pollinateActivityName = "Pollinate"
+
+// generationPattern matches the labels the generation suggestions
+// understand: filial (F1, F2, ...), backcross (BX1, ...) and selfed (S1,
+// ...) generations.
+var generationPattern = regexp.MustCompile(`^(F|BX|S)([0-9]+)$`)
+
+const pollinationColumns = `p.id, p.project_id, p.female_plant_id, COALESCE(fp.name, ''), p.female_strain_id, COALESCE(fs.name, ''),
+	p.male_plant_id, COALESCE(mp.name, ''), p.male_strain_id, COALESCE(ms.name, ''), p.pollen_source,
+	p.pollination_date, p.generation, p.notes, p.seed_strain_id, COALESCE(ss.name, ''), p.seeds_harvested, p.harvest_date`
+
+const pollinationJoins = `FROM pollinations p
+	LEFT JOIN plant fp ON fp.id = p.female_plant_id
+	LEFT JOIN strain fs ON fs.id = p.female_strain_id
+	LEFT JOIN plant mp ON mp.id = p.male_plant_id
+	LEFT JOIN strain ms ON ms.id = p.male_strain_id
+	LEFT JOIN strain ss ON ss.id = p.seed_strain_id`
+
+// loadPollinations loads the pollinations matching where (a condition on
+// p, or ""), oldest first.
+func loadPollinations(db *sql.DB, where string, args ...interface{}) ([]types.Pollination, error) {
+	if where != "" {
+		where = "WHERE " + where
+	}
+	rows, err := db.Query(fmt.Sprintf("SELECT %s %s %s ORDER BY p.pollination_date, p.id", pollinationColumns, pollinationJoins, where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	pollinations := []types.Pollination{}
+	for rows.Next() {
+		var p types.Pollination
+		var project, female, femaleStrain, male, maleStrain, seedStrain, seeds sql.NullInt64
+		var harvested sql.NullTime
+		if err := rows.Scan(&p.ID, &project, &female, &p.FemalePlantName, &femaleStrain, &p.FemaleStrainName,
+			&male, &p.MalePlantName, &maleStrain, &p.MaleStrainName, &p.PollenSource,
+			&p.Date, &p.Generation, &p.Notes, &seedStrain, &p.SeedStrainName, &seeds, &harvested); err != nil {
+			return nil, err
+		}
+		p.ProjectID = nullIntPtr(project)
+		p.FemalePlantID = nullIntPtr(female)
+		p.FemaleStrainID = nullIntPtr(femaleStrain)
+		p.MalePlantID = nullIntPtr(male)
+		p.MaleStrainID = nullIntPtr(maleStrain)
+		p.SeedStrainID = nullIntPtr(seedStrain)
+		p.SeedsHarvested = nullIntPtr(seeds)
+		p.Date = utils.AsLocal(p.Date)
+		p.HarvestDate = nullLocalTimePtr(harvested)
+		pollinations = append(pollinations, p)
+	}
+	return pollinations, rows.Err()
+}
+
+// LoadBreedingOverview loads every breeding project with its pollinations,
+// and the pollinations not filed under a project.
+func LoadBreedingOverview(db *sql.DB) (types.BreedingOverview, error) {
+	overview := types.BreedingOverview{Projects: []types.BreedingProject{}, Unassigned: []types.Pollination{}}
+	rows, err := db.Query(`SELECT id, name, goal, notes FROM breeding_projects ORDER BY name, id`)
+	if err != nil {
+		return overview, err
+	}
+	defer rows.Close()
+	index := map[int]int{}
+	for rows.Next() {
+		p := types.BreedingProject{Pollinations: []types.Pollination{}}
+		if err := rows.Scan(&p.ID, &p.Name, &p.Goal, &p.Notes); err != nil {
+			return overview, err
+		}
+		index[p.ID] = len(overview.Projects)
+		overview.Projects = append(overview.Projects, p)
+	}
+	if err := rows.Err(); err != nil {
+		return overview, err
+	}
+
+	pollinations, err := loadPollinations(db, "")
+	if err != nil {
+		return overview, err
+	}
+	for _, p := range pollinations {
+		if p.ProjectID != nil {
+			if i, ok := index[*p.ProjectID]; ok {
+				overview.Projects[i].Pollinations = append(overview.Projects[i].Pollinations, p)
+				continue
+			}
+		}
+		overview.Unassigned = append(overview.Unassigned, p)
+	}
+	return overview, nil
+}
+
+// parseGeneration splits a label such as "F2" or "bx1" into its kind and
+// number.
+func parseGeneration(label string) (string, int, bool) {
+	m := generationPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(label)))
+	if m == nil {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(m[2])
+	if err != nil {
+		return "", 0, false
+	}
+	return m[1], n, true
+}
+
+// strainCross is how a strain bred here came about: the generation label
+// and parent strains of the pollination its seeds were harvested from.
+type strainCross struct {
+	generation string
+	parents    map[int]bool
+}
+
+// loadStrainCross returns the cross a strain's seeds came from, or nil for
+// a strain that wasn't bred here.
+func loadStrainCross(tx *sql.Tx, strainID *int) (*strainCross, error) {
+	if strainID == nil {
+		return nil, nil
+	}
+	var generation string
+	var female, male sql.NullInt64
+	err := tx.QueryRow(`SELECT generation, female_strain_id, male_strain_id FROM pollinations
+		WHERE seed_strain_id = $1 ORDER BY id DESC LIMIT 1`, *strainID).Scan(&generation, &female, &male)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cross := &strainCross{generation: generation, parents: map[int]bool{}}
+	for _, parent := range []sql.NullInt64{female, male} {
+		if parent.Valid {
+			cross.parents[int(parent.Int64)] = true
+		}
+	}
+	return cross, nil
+}
+
+// suggestGeneration works out the generation of a cross from its parent
+// strains. Selfing a plant gives S1, or the next S generation of a selfed
+// strain; crossing siblings of an Fn strain gives Fn+1; crossing an Fn or
+// BXn strain back to one of its parents gives the next backcross; any
+// other cross of two strains is an F1. It returns "" when the parents
+// don't say, such as siblings of a strain that wasn't bred here.
+func suggestGeneration(tx *sql.Tx, femaleStrain, maleStrain *int, selfed bool) (string, error) {
+	female, err := loadStrainCross(tx, femaleStrain)
+	if err != nil {
+		return "", err
+	}
+	if selfed {
+		if female != nil {
+			if kind, n, ok := parseGeneration(female.generation); ok && kind == "S" {
+				return fmt.Sprintf("S%d", n+1), nil
+			}
+		}
+		return "S1", nil
+	}
+	if femaleStrain == nil || maleStrain == nil {
+		return "F1", nil
+	}
+	if *femaleStrain == *maleStrain {
+		if female != nil {
+			if kind, n, ok := parseGeneration(female.generation); ok && kind == "F" {
+				return fmt.Sprintf("F%d", n+1), nil
+			}
+		}
+		return "", nil
+	}
+	male, err := loadStrainCross(tx, maleStrain)
+	if err != nil {
+		return "", err
+	}
+	for _, pair := range []struct {
+		cross *strainCross
+		other int
+	}{{female, *maleStrain}, {male, *femaleStrain}} {
+		if pair.cross == nil || !pair.cross.parents[pair.other] {
+			continue
+		}
+		switch kind, n, ok := parseGeneration(pair.cross.generation); {
+		case ok && kind == "BX":
+			return fmt.Sprintf("BX%d", n+1), nil
+		case ok && kind == "F":
+			return "BX1", nil
+		}
+	}
+	return "F1", nil
+}
+
+// breedingParam parses the :id route parameter.
+func breedingParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_request")
+		return 0, false
+	}
+	return id, true
+}
+
+// GetBreedingOverview returns every breeding project with its
+// pollinations, and the pollinations not filed under a project.
+func GetBreedingOverview(c *gin.Context) {
+	overview, err := LoadBreedingOverview(DBFromContext(c))
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "GetBreedingOverview").Error("Failed to load breeding projects")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusOK, overview)
+}
+
+// breedingProjectInput is the body of the project create and update
+// requests.
+type breedingProjectInput struct {
+	Name  string `json:"name"`
+	Goal  string `json:"goal"`
+	Notes string `json:"notes"`
+}
+
+func (in *breedingProjectInput) validate() error {
+	in.Name = strings.TrimSpace(in.Name)
+	if err := utils.ValidateRequiredString("name", in.Name, utils.MaxNameLength); err != nil {
+		return err
+	}
+	if err := utils.ValidateStringLength("goal", in.Goal, utils.MaxNotesLength); err != nil {
+		return err
+	}
+	return utils.ValidateStringLength("notes", in.Notes, utils.MaxNotesLength)
+}
+
+// bindBreedingProjectInput binds and validates a project body. It sends
+// the error response itself.
+func bindBreedingProjectInput(c *gin.Context) (breedingProjectInput, bool) {
+	var in breedingProjectInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		apiBadRequest(c, "api_invalid_input")
+		return in, false
+	}
+	if err := in.validate(); err != nil {
+		apiBadRequest(c, err.Error())
+		return in, false
+	}
+	return in, true
+}
+
+// AddBreedingProject creates a breeding project.
+func AddBreedingProject(c *gin.Context) {
+	in, ok := bindBreedingProjectInput(c)
+	if !ok {
+		return
+	}
+	var id int
+	err := DBFromContext(c).QueryRow(`INSERT INTO breeding_projects (name, goal, notes) VALUES ($1, $2, $3) RETURNING id`,
+		in.Name, in.Goal, in.Notes).Scan(&id)
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "AddBreedingProject").Error("Failed to create breeding project")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id, "message": T(c, "api_breeding_project_saved")})
+}
+
+// UpdateBreedingProject replaces a project's name, goal and notes.
+func UpdateBreedingProject(c *gin.Context) {
+	id, ok := breedingParam(c)
+	if !ok {
+		return
+	}
+	in, ok := bindBreedingProjectInput(c)
+	if !ok {
+		return
+	}
+	result, err := DBFromContext(c).Exec(`UPDATE breeding_projects SET name = $1, goal = $2, notes = $3, update_dt = CURRENT_TIMESTAMP
+		WHERE id = $4`, in.Name, in.Goal, in.Notes, id)
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "UpdateBreedingProject").Error("Failed to update breeding project")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		apiNotFound(c, "api_breeding_project_not_found")
+		return
+	}
+	apiOK(c, "api_breeding_project_saved")
+}
+
+// DeleteBreedingProject deletes a project. Its pollinations are kept,
+// no longer filed under a project.
+func DeleteBreedingProject(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "DeleteBreedingProject")
+	id, ok := breedingParam(c)
+	if !ok {
+		return
+	}
+	tx, err := DBFromContext(c).Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+	if _, err := tx.Exec(`UPDATE pollinations SET project_id = NULL WHERE project_id = $1`, id); err != nil {
+		fieldLogger.WithError(err).Error("Failed to unfile pollinations")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	result, err := tx.Exec(`DELETE FROM breeding_projects WHERE id = $1`, id)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to delete breeding project")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		apiNotFound(c, "api_breeding_project_not_found")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	apiOK(c, "api_breeding_project_deleted")
+}
+
+// pollinationInput is the body of the pollination create and update
+// requests. The pollen comes from MalePlantID, which may be the female
+// itself when selfing, or from PollenSource when it comes from a plant
+// not on file. A blank Generation is filled in from the parents.
+type pollinationInput struct {
+	ProjectID     *int   `json:"project_id"`
+	FemalePlantID int    `json:"female_plant_id"`
+	MalePlantID   *int   `json:"male_plant_id"`
+	PollenSource  string `json:"pollen_source"`
+	Date          string `json:"pollination_date"`
+	Generation    string `json:"generation"`
+	Notes         string `json:"notes"`
+}
+
+func (in *pollinationInput) validate() error {
+	if in.ProjectID != nil && *in.ProjectID <= 0 {
+		in.ProjectID = nil
+	}
+	if in.MalePlantID != nil && *in.MalePlantID <= 0 {
+		in.MalePlantID = nil
+	}
+	in.PollenSource = strings.TrimSpace(in.PollenSource)
+	in.Generation = strings.TrimSpace(in.Generation)
+	if in.FemalePlantID <= 0 {
+		return errors.New("female_plant_id is required")
+	}
+	if in.MalePlantID == nil && in.PollenSource == "" {
+		return errors.New("male_plant_id or pollen_source is required")
+	}
+	if err := utils.ValidateStringLength("pollen_source", in.PollenSource, utils.MaxNameLength); err != nil {
+		return err
+	}
+	if err := utils.ValidateStringLength("generation", in.Generation, MaxGenerationLength); err != nil {
+		return err
+	}
+	if err := utils.ValidateStringLength("notes", in.Notes, utils.MaxNotesLength); err != nil {
+		return err
+	}
+	return validateRequiredDate("pollination_date", in.Date)
+}
+
+// bindPollinationInput binds and validates a pollination body. It sends
+// the error response itself.
+func bindPollinationInput(c *gin.Context) (pollinationInput, bool) {
+	var in pollinationInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		apiBadRequest(c, "api_invalid_input")
+		return in, false
+	}
+	if err := in.validate(); err != nil {
+		apiBadRequest(c, err.Error())
+		return in, false
+	}
+	return in, true
+}
+
+// pollinationParents looks up the parent plants' names and current
+// strains. It returns the locale key of the reason they can't be used.
+func pollinationParents(tx *sql.Tx, in pollinationInput) (femaleName, maleName string, femaleStrain, maleStrain *int, errKey string, err error) {
+	if in.ProjectID != nil {
+		found, err := rowExists(tx, "breeding_projects", *in.ProjectID)
+		if err != nil || !found {
+			return "", "", nil, nil, "api_breeding_project_not_found", err
+		}
+	}
+	var strain sql.NullInt64
+	err = tx.QueryRow(`SELECT name, strain_id FROM plant WHERE id = $1`, in.FemalePlantID).Scan(&femaleName, &strain)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", nil, nil, "api_plant_not_found", nil
+	}
+	if err != nil {
+		return "", "", nil, nil, "", err
+	}
+	femaleStrain = nullIntPtr(strain)
+	if in.MalePlantID == nil {
+		return femaleName, in.PollenSource, femaleStrain, nil, "", nil
+	}
+	err = tx.QueryRow(`SELECT name, strain_id FROM plant WHERE id = $1`, *in.MalePlantID).Scan(&maleName, &strain)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", nil, nil, "api_plant_not_found", nil
+	}
+	if err != nil {
+		return "", "", nil, nil, "", err
+	}
+	return femaleName, maleName, femaleStrain, nullIntPtr(strain), "", nil
+}
+
+// AddPollination logs a pollination and a Pollinate activity on the
+// female. A blank generation is worked out from the parents.
+func AddPollination(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "AddPollination")
+	in, ok := bindPollinationInput(c)
+	if !ok {
+		return
+	}
+	// A bare date takes the current time of day, so the activity sorts
+	// with the rest of the day's activities.
+	if len(in.Date) == len(utils.LayoutDate) {
+		in.Date += taskToday(c).Format(" 15:04:05")
+	}
+
+	db := DBFromContext(c)
+	tx, err := db.Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+	_, maleName, femaleStrain, maleStrain, errKey, err := pollinationParents(tx, in)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to look up parents")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if errKey == "api_plant_not_found" {
+		apiNotFound(c, errKey)
+		return
+	}
+	if errKey != "" {
+		apiBadRequest(c, errKey)
+		return
+	}
+	selfed := in.MalePlantID != nil && *in.MalePlantID == in.FemalePlantID
+	if in.Generation == "" {
+		if in.Generation, err = suggestGeneration(tx, femaleStrain, maleStrain, selfed); err != nil {
+			fieldLogger.WithError(err).Error("Failed to work out generation")
+			apiInternalError(c, "api_database_error")
+			return
+		}
+	}
+
+	var id int
+	err = tx.QueryRow(`INSERT INTO pollinations (project_id, female_plant_id, female_strain_id, male_plant_id, male_strain_id,
+			pollen_source, pollination_date, generation, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`,
+		in.ProjectID, in.FemalePlantID, femaleStrain, in.MalePlantID, maleStrain,
+		in.PollenSource, in.Date, in.Generation, in.Notes).Scan(&id)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to create pollination")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	activityID, err := lockedActivityID(tx, pollinateActivityName)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to find pollinate activity")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	note := "× " + maleName
+	if in.Generation != "" {
+		note += " (" + in.Generation + ")"
+	}
+	if in.Notes != "" {
+		note += "\n" + in.Notes
+	}
+	if err := createPlantActivity(tx, in.FemalePlantID, activityID, note, in.Date, nil, nil); err != nil {
+		fieldLogger.WithError(err).Error("Failed to log pollinate activity")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit pollination")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	ConfigStoreFromContext(c).SetActivities(GetActivities(db))
+	c.JSON(http.StatusCreated, gin.H{"id": id, "generation": in.Generation, "message": T(c, "api_pollination_saved")})
+}
+
+// UpdatePollination replaces a pollination's details. Once its seeds are
+// harvested the parents are fixed, since the seed strain's lineage was
+// set from them.
+func UpdatePollination(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "UpdatePollination")
+	id, ok := breedingParam(c)
+	if !ok {
+		return
+	}
+	in, ok := bindPollinationInput(c)
+	if !ok {
+		return
+	}
+
+	tx, err := DBFromContext(c).Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+	var female, male, seedStrain sql.NullInt64
+	err = tx.QueryRow(`SELECT female_plant_id, male_plant_id, seed_strain_id FROM pollinations WHERE id = $1`, id).Scan(&female, &male, &seedStrain)
+	if errors.Is(err, sql.ErrNoRows) {
+		apiNotFound(c, "api_pollination_not_found")
+		return
+	}
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to load pollination")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	_, _, femaleStrain, maleStrain, errKey, err := pollinationParents(tx, in)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to look up parents")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if errKey == "api_plant_not_found" {
+		apiNotFound(c, errKey)
+		return
+	}
+	if errKey != "" {
+		apiBadRequest(c, errKey)
+		return
+	}
+	samePlant := func(stored sql.NullInt64, plant *int) bool {
+		return (plant == nil && !stored.Valid) || (plant != nil && stored.Valid && int(stored.Int64) == *plant)
+	}
+	if seedStrain.Valid && (!samePlant(female, &in.FemalePlantID) || !samePlant(male, in.MalePlantID)) {
+		apiBadRequest(c, "api_pollination_already_harvested")
+		return
+	}
+	selfed := in.MalePlantID != nil && *in.MalePlantID == in.FemalePlantID
+	if in.Generation == "" {
+		if in.Generation, err = suggestGeneration(tx, femaleStrain, maleStrain, selfed); err != nil {
+			fieldLogger.WithError(err).Error("Failed to work out generation")
+			apiInternalError(c, "api_database_error")
+			return
+		}
+	}
+
+	// The parents' strains are only taken again when the parents change,
+	// so the cross keeps the strains they had on the day.
+	query := `UPDATE pollinations SET project_id = $1, pollen_source = $2, pollination_date = $3, generation = $4, notes = $5,
+		update_dt = CURRENT_TIMESTAMP WHERE id = $6`
+	args := []interface{}{in.ProjectID, in.PollenSource, in.Date, in.Generation, in.Notes, id}
+	if !samePlant(female, &in.FemalePlantID) || !samePlant(male, in.MalePlantID) {
+		query = `UPDATE pollinations SET project_id = $1, pollen_source = $2, pollination_date = $3, generation = $4, notes = $5,
+			female_plant_id = $6, female_strain_id = $7, male_plant_id = $8, male_strain_id = $9,
+			update_dt = CURRENT_TIMESTAMP WHERE id = $10`
+		args = []interface{}{in.ProjectID, in.PollenSource, in.Date, in.Generation, in.Notes,
+			in.FemalePlantID, femaleStrain, in.MalePlantID, maleStrain, id}
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		fieldLogger.WithError(err).Error("Failed to update pollination")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit pollination")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"generation": in.Generation, "message": T(c, "api_pollination_saved")})
+}
+
+// DeletePollination deletes a pollination. A strain harvested from it is
+// kept.
+func DeletePollination(c *gin.Context) {
+	id, ok := breedingParam(c)
+	if !ok {
+		return
+	}
+	result, err := DBFromContext(c).Exec(`DELETE FROM pollinations WHERE id = $1`, id)
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "DeletePollination").Error("Failed to delete pollination")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		apiNotFound(c, "api_pollination_not_found")
+		return
+	}
+	apiOK(c, "api_pollination_deleted")
+}
+
+// seedHarvestInput is the body of the seed harvest request. The new
+// strain goes under BreederID, or under a new breeder named NewBreeder.
+type seedHarvestInput struct {
+	StrainName  string `json:"strain_name"`
+	BreederID   *int   `json:"breeder_id"`
+	NewBreeder  string `json:"new_breeder"`
+	Seeds       int    `json:"seeds"`
+	HarvestDate string `json:"harvest_date"`
+	Feminized   bool   `json:"feminized"`
+}
+
+func (in *seedHarvestInput) validate() error {
+	in.StrainName = strings.TrimSpace(in.StrainName)
+	in.NewBreeder = strings.TrimSpace(in.NewBreeder)
+	if in.BreederID != nil && *in.BreederID <= 0 {
+		in.BreederID = nil
+	}
+	if err := utils.ValidateRequiredString("strain_name", in.StrainName, utils.MaxNameLength); err != nil {
+		return err
+	}
+	if in.BreederID == nil {
+		if err := utils.ValidateRequiredString("new_breeder", in.NewBreeder, utils.MaxNameLength); err != nil {
+			return err
+		}
+	}
+	if in.Seeds < 1 || in.Seeds > MaxSeedsPerLot {
+		return errSeedCount
+	}
+	return validateRequiredDate("harvest_date", in.HarvestDate)
+}
+
+// HarvestPollinationSeeds records the seeds harvested from a pollination
+// as a new strain. The strain's lineage is set from the parent strains,
+// or the pollen source when the pollen came from a plant not on file, and
+// the seeds go into a seed lot of it. Its indica/sativa split is the
+// parents' average, and it is an autoflower only when both parents are.
+func HarvestPollinationSeeds(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "HarvestPollinationSeeds")
+	id, ok := breedingParam(c)
+	if !ok {
+		return
+	}
+	var in seedHarvestInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		apiBadRequest(c, "api_invalid_input")
+		return
+	}
+	if err := in.validate(); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
+
+	db := DBFromContext(c)
+	tx, err := db.Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+	var female, male, seedStrain sql.NullInt64
+	var pollenSource, project string
+	err = tx.QueryRow(`SELECT p.female_strain_id, p.male_strain_id, p.pollen_source, p.seed_strain_id, COALESCE(bp.name, '')
+		FROM pollinations p
+		LEFT JOIN breeding_projects bp ON bp.id = p.project_id
+		WHERE p.id = $1`, id).Scan(&female, &male, &pollenSource, &seedStrain, &project)
+	if errors.Is(err, sql.ErrNoRows) {
+		apiNotFound(c, "api_pollination_not_found")
+		return
+	}
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to load pollination")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if seedStrain.Valid {
+		apiBadRequest(c, "api_pollination_already_harvested")
+		return
+	}
+
+	var breederID int
+	newBreeder := in.BreederID == nil
+	if newBreeder {
+		if err := tx.QueryRow(`INSERT INTO breeder (name) VALUES ($1) RETURNING id`, in.NewBreeder).Scan(&breederID); err != nil {
+			fieldLogger.WithError(err).Error("Failed to insert new breeder")
+			apiInternalError(c, "api_failed_to_add_new_breeder")
+			return
+		}
+	} else {
+		breederID = *in.BreederID
+		if found, err := rowExists(tx, "breeder", breederID); err != nil || !found {
+			apiBadRequest(c, "api_breeder_not_found")
+			return
+		}
+	}
+
+	var parents []lineageParent
+	seen := map[int]bool{}
+	indica, autoflower, known := 0, true, 0
+	for _, parent := range []sql.NullInt64{female, male} {
+		if !parent.Valid || seen[int(parent.Int64)] {
+			continue
+		}
+		strainID := int(parent.Int64)
+		seen[strainID] = true
+		var name string
+		var parentIndica, parentAuto int
+		err := tx.QueryRow(`SELECT name, indica, autoflower FROM strain WHERE id = $1`, strainID).Scan(&name, &parentIndica, &parentAuto)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			fieldLogger.WithError(err).Error("Failed to load parent strain")
+			apiInternalError(c, "api_database_error")
+			return
+		}
+		parents = append(parents, lineageParent{ParentName: name, ParentStrainID: &strainID})
+		indica += parentIndica
+		autoflower = autoflower && parentAuto != 0
+		known++
+	}
+	if !male.Valid && pollenSource != "" {
+		parents = append(parents, lineageParent{ParentName: pollenSource})
+	}
+	if known == 0 {
+		indica, autoflower, known = 50, false, 1
+	}
+	indica /= known
+	autoflowerInt := 0
+	if autoflower {
+		autoflowerInt = 1
+	}
+
+	var strainID int
+	err = tx.QueryRow(`INSERT INTO strain (name, breeder_id, indica, sativa, autoflower, seed_count, description)
+		VALUES ($1, $2, $3, $4, $5, 0, '') RETURNING id`,
+		in.StrainName, breederID, indica, 100-indica, autoflowerInt).Scan(&strainID)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to insert strain")
+		apiInternalError(c, "api_failed_to_add_strain")
+		return
+	}
+	if err := replaceStrainLineage(tx, strainID, parents); err != nil {
+		fieldLogger.WithError(err).Error("Failed to set lineage")
+		apiInternalError(c, "api_failed_to_update_lineage")
+		return
+	}
+	var lotID int
+	err = tx.QueryRow(`INSERT INTO seed_lots (strain_id, source, purchase_date, pack_size, seeds_left, feminized)
+		VALUES ($1, $2, $3, $4, $4, $5) RETURNING id`,
+		strainID, project, in.HarvestDate, in.Seeds, in.Feminized).Scan(&lotID)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to create seed lot")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if err := adjustSeedCount(tx, strainID, in.Seeds); err != nil {
+		fieldLogger.WithError(err).Error("Failed to update seed count")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	_, err = tx.Exec(`UPDATE pollinations SET seed_strain_id = $1, seeds_harvested = $2, harvest_date = $3, update_dt = CURRENT_TIMESTAMP
+		WHERE id = $4`, strainID, in.Seeds, in.HarvestDate, id)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to record seed harvest")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit seed harvest")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	store := ConfigStoreFromContext(c)
+	if newBreeder {
+		store.SetBreeders(GetBreeders(db))
+	}
+	store.SetStrains(GetStrains(db, StrainSortName))
+	c.JSON(http.StatusCreated, gin.H{"id": strainID, "seed_lot_id": lotID, "message": T(c, "api_pollination_seeds_harvested")})
+}
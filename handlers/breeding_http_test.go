@@ -0,0 +1,311 @@
+package handlers_test
+
+// HTTP-layer tests for handlers/breeding.go: breeding projects,
+// pollinations, generation labels and harvesting seeds as a new strain.
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"isley/tests/testutil"
+)
+
+type pollinationResponse struct {
+	ID             int    `json:"id"`
+	FemalePlantID  *int   `json:"female_plant_id"`
+	FemaleStrainID *int   `json:"female_strain_id"`
+	MaleStrainID   *int   `json:"male_strain_id"`
+	PollenSource   string `json:"pollen_source"`
+	Generation     string `json:"generation"`
+	SeedStrainID   *int   `json:"seed_strain_id"`
+	SeedStrainName string `json:"seed_strain_name"`
+	SeedsHarvested *int   `json:"seeds_harvested"`
+}
+
+type breedingOverviewResponse struct {
+	Projects []struct {
+		ID           int                   `json:"id"`
+		Name         string                `json:"name"`
+		Pollinations []pollinationResponse `json:"pollinations"`
+	} `json:"projects"`
+	Unassigned []pollinationResponse `json:"unassigned"`
+}
+
+func getBreedingOverview(t *testing.T, c *testutil.Client) breedingOverviewResponse {
+	t.Helper()
+	resp := c.Get("/breeding/projects")
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var got breedingOverviewResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	return got
+}
+
+// pollinate logs a pollination and returns its id and generation.
+func pollinate(t *testing.T, c *testutil.Client, apiKey string, body map[string]interface{}) (int, string) {
+	t.Helper()
+	if _, ok := body["pollination_date"]; !ok {
+		body["pollination_date"] = "2026-06-01"
+	}
+	resp := harvestRequest(t, c, http.MethodPost, "/pollinations", apiKey, body)
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created struct {
+		ID         int    `json:"id"`
+		Generation string `json:"generation"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	return created.ID, created.Generation
+}
+
+// harvestSeeds harvests a pollination's seeds as strainName and returns
+// the new strain's id.
+func harvestSeeds(t *testing.T, c *testutil.Client, apiKey string, pollinationID, breederID int, strainName string, seeds int) int {
+	t.Helper()
+	return createNutrientRow(t, c, apiKey, "/pollinations/"+strconv.Itoa(pollinationID)+"/harvest", map[string]interface{}{
+		"strain_name": strainName, "breeder_id": breederID, "seeds": seeds, "harvest_date": "2026-07-20",
+	})
+}
+
+type lineageRow struct {
+	name     string
+	parentID sql.NullInt64
+}
+
+func strainLineageRows(t *testing.T, db *sql.DB, strainID int) []lineageRow {
+	t.Helper()
+	rows, err := db.Query(`SELECT parent_name, parent_strain_id FROM strain_lineage WHERE strain_id = $1 ORDER BY parent_name`, strainID)
+	require.NoError(t, err)
+	defer rows.Close()
+	var got []lineageRow
+	for rows.Next() {
+		var r lineageRow
+		require.NoError(t, rows.Scan(&r.name, &r.parentID))
+		got = append(got, r)
+	}
+	require.NoError(t, rows.Err())
+	return got
+}
+
+// ---------------------------------------------------------------------------
+// Pollinating and harvesting seeds
+// ---------------------------------------------------------------------------
+
+func TestBreedingHTTP_HarvestCreatesStrainWithLineage(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "breeding-key")
+	breederID := testutil.SeedBreeder(t, db, "B")
+	mother := testutil.SeedStrain(t, db, breederID, "Mother Strain")
+	father := testutil.SeedStrain(t, db, breederID, "Father Strain")
+	testutil.MustExec(t, db, `UPDATE strain SET indica = 80, sativa = 20 WHERE id = $1`, mother)
+	zoneID := testutil.SeedZone(t, db, "Z")
+	female := testutil.SeedPlant(t, db, "Girl", mother, zoneID)
+	male := testutil.SeedPlant(t, db, "Boy", father, zoneID)
+	c := server.NewClient(t)
+
+	projectID := createNutrientRow(t, c, apiKey, "/breeding/projects", map[string]interface{}{
+		"name": " Purple line ", "goal": "Purple and frosty",
+	})
+	pollinationID, generation := pollinate(t, c, apiKey, map[string]interface{}{
+		"project_id": projectID, "female_plant_id": female, "male_plant_id": male,
+	})
+	assert.Equal(t, "F1", generation, "a cross of two strains is an F1")
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM plant_activity WHERE plant_id = $1 AND activity_id = $2`,
+		female, testutil.ActivityIDByName(t, db, "Pollinate")), "the pollination is logged on the female")
+
+	strainID := harvestSeeds(t, c, apiKey, pollinationID, breederID, "Purple F1", 20)
+	assert.Equal(t, []lineageRow{
+		{"Father Strain", sql.NullInt64{Int64: int64(father), Valid: true}},
+		{"Mother Strain", sql.NullInt64{Int64: int64(mother), Valid: true}},
+	}, strainLineageRows(t, db, strainID))
+	assert.Equal(t, 20, seedCountOf(t, db, strainID))
+	assert.Equal(t, 65, testutil.CountRows(t, db, `SELECT indica FROM strain WHERE id = $1`, strainID), "the split is the parents' average")
+
+	lots := getStrainSeedLots(t, c, strainID)
+	require.Len(t, lots, 1)
+	assert.Equal(t, 20, lots[0].PackSize)
+	assert.Equal(t, "Purple line", lots[0].Source, "the lot comes from the project")
+	assert.False(t, lots[0].Feminized)
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, "/pollinations/"+strconv.Itoa(pollinationID)+"/harvest", apiKey, map[string]interface{}{
+		"strain_name": "Again", "breeder_id": breederID, "seeds": 5, "harvest_date": "2026-07-21",
+	}), http.StatusBadRequest)
+
+	overview := getBreedingOverview(t, c)
+	require.Len(t, overview.Projects, 1)
+	assert.Equal(t, "Purple line", overview.Projects[0].Name)
+	require.Len(t, overview.Projects[0].Pollinations, 1)
+	got := overview.Projects[0].Pollinations[0]
+	require.NotNil(t, got.SeedStrainID)
+	assert.Equal(t, strainID, *got.SeedStrainID)
+	assert.Equal(t, "Purple F1", got.SeedStrainName)
+	require.NotNil(t, got.SeedsHarvested)
+	assert.Equal(t, 20, *got.SeedsHarvested)
+	assert.Empty(t, overview.Unassigned)
+
+	resp := c.Get("/breeding")
+	body, err := io.ReadAll(resp.Body)
+	testutil.DrainAndClose(resp)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "Purple line")
+	assert.Contains(t, string(body), "Purple F1")
+	assert.NotContains(t, string(body), "seed-harvest-form", "guests can't harvest")
+
+	// Once harvested the parents are fixed, but the rest can change.
+	update := map[string]interface{}{
+		"project_id": projectID, "female_plant_id": female, "male_plant_id": female,
+		"pollination_date": "2026-06-02", "generation": "F1",
+	}
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/pollinations/"+strconv.Itoa(pollinationID), apiKey, update), http.StatusBadRequest)
+	update["male_plant_id"] = male
+	update["notes"] = "Dusted two colas"
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/pollinations/"+strconv.Itoa(pollinationID), apiKey, update), http.StatusOK)
+
+	// Deleting the project keeps its pollinations.
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodDelete, "/breeding/projects/"+strconv.Itoa(projectID), apiKey, nil), http.StatusOK)
+	overview = getBreedingOverview(t, c)
+	assert.Empty(t, overview.Projects)
+	require.Len(t, overview.Unassigned, 1)
+	assert.Equal(t, pollinationID, overview.Unassigned[0].ID)
+}
+
+func TestBreedingHTTP_GenerationsFollowTheLine(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "breeding-key")
+	breederID := testutil.SeedBreeder(t, db, "B")
+	mother := testutil.SeedStrain(t, db, breederID, "Mother Strain")
+	father := testutil.SeedStrain(t, db, breederID, "Father Strain")
+	zoneID := testutil.SeedZone(t, db, "Z")
+	c := server.NewClient(t)
+
+	f1Cross, _ := pollinate(t, c, apiKey, map[string]interface{}{
+		"female_plant_id": testutil.SeedPlant(t, db, "Girl", mother, zoneID),
+		"male_plant_id":   testutil.SeedPlant(t, db, "Boy", father, zoneID),
+	})
+	f1 := harvestSeeds(t, c, apiKey, f1Cross, breederID, "Line F1", 10)
+	sister := testutil.SeedPlant(t, db, "F1 sister", f1, zoneID)
+	brother := testutil.SeedPlant(t, db, "F1 brother", f1, zoneID)
+
+	f2Cross, generation := pollinate(t, c, apiKey, map[string]interface{}{"female_plant_id": sister, "male_plant_id": brother})
+	assert.Equal(t, "F2", generation, "siblings of an F1 give an F2")
+	f2 := harvestSeeds(t, c, apiKey, f2Cross, breederID, "Line F2", 10)
+	assert.Equal(t, []lineageRow{{"Line F1", sql.NullInt64{Int64: int64(f1), Valid: true}}}, strainLineageRows(t, db, f2),
+		"a sibling cross has the one parent strain")
+
+	f2Sister := testutil.SeedPlant(t, db, "F2 sister", f2, zoneID)
+	f2Brother := testutil.SeedPlant(t, db, "F2 brother", f2, zoneID)
+	_, generation = pollinate(t, c, apiKey, map[string]interface{}{"female_plant_id": f2Sister, "male_plant_id": f2Brother})
+	assert.Equal(t, "F3", generation)
+
+	bxCross, generation := pollinate(t, c, apiKey, map[string]interface{}{
+		"female_plant_id": sister, "male_plant_id": testutil.SeedPlant(t, db, "Father again", father, zoneID),
+	})
+	assert.Equal(t, "BX1", generation, "an F1 crossed back to a parent is a backcross")
+	bx1 := harvestSeeds(t, c, apiKey, bxCross, breederID, "Line BX1", 10)
+	_, generation = pollinate(t, c, apiKey, map[string]interface{}{
+		"female_plant_id": testutil.SeedPlant(t, db, "BX1 girl", bx1, zoneID),
+		"male_plant_id":   testutil.SeedPlant(t, db, "Father thrice", father, zoneID),
+	})
+	assert.Equal(t, "BX2", generation)
+
+	_, generation = pollinate(t, c, apiKey, map[string]interface{}{"female_plant_id": sister, "male_plant_id": sister})
+	assert.Equal(t, "S1", generation, "a plant pollinated with its own pollen is selfed")
+
+	_, generation = pollinate(t, c, apiKey, map[string]interface{}{
+		"female_plant_id": sister, "male_plant_id": brother, "generation": "F2 (keeper hunt)",
+	})
+	assert.Equal(t, "F2 (keeper hunt)", generation, "a label given is kept")
+
+	// Pollen from a plant not on file goes into the lineage by name.
+	outside, generation := pollinate(t, c, apiKey, map[string]interface{}{
+		"female_plant_id": sister, "pollen_source": "Friend's Chem male",
+	})
+	assert.Equal(t, "F1", generation)
+
+	// Signed in, a pollination not yet harvested offers the harvest form.
+	testutil.SeedAdmin(t, db, "breeding-pw")
+	resp := server.LoginAsAdmin(t, "breeding-pw").Get("/breeding")
+	body, err := io.ReadAll(resp.Body)
+	testutil.DrainAndClose(resp)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), `data-id="`+strconv.Itoa(outside)+`"`)
+	assert.Contains(t, string(body), "Line F1 × Friend&#39;s Chem male F1")
+
+	outsideStrain := harvestSeeds(t, c, apiKey, outside, breederID, "Line x Chem", 10)
+	assert.Equal(t, []lineageRow{
+		{"Friend's Chem male", sql.NullInt64{}},
+		{"Line F1", sql.NullInt64{Int64: int64(f1), Valid: true}},
+	}, strainLineageRows(t, db, outsideStrain))
+}
+
+func TestBreedingHTTP_Validation(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "breeding-key")
+	strainID := testutil.SeedStrain(t, db, testutil.SeedBreeder(t, db, "B"), "S")
+	zoneID := testutil.SeedZone(t, db, "Z")
+	female := testutil.SeedPlant(t, db, "Girl", strainID, zoneID)
+	c := server.NewClient(t)
+
+	for name, tc := range map[string]struct {
+		body map[string]interface{}
+		want int
+	}{
+		"no pollen":       {map[string]interface{}{"female_plant_id": female, "pollination_date": "2026-06-01"}, http.StatusBadRequest},
+		"no female":       {map[string]interface{}{"pollen_source": "x", "pollination_date": "2026-06-01"}, http.StatusBadRequest},
+		"no date":         {map[string]interface{}{"female_plant_id": female, "pollen_source": "x"}, http.StatusBadRequest},
+		"long generation": {map[string]interface{}{"female_plant_id": female, "pollen_source": "x", "pollination_date": "2026-06-01", "generation": "F1 F1 F1 F1 F1 F1 F1 F1"}, http.StatusBadRequest},
+		"unknown female":  {map[string]interface{}{"female_plant_id": 9999, "pollen_source": "x", "pollination_date": "2026-06-01"}, http.StatusNotFound},
+		"unknown male":    {map[string]interface{}{"female_plant_id": female, "male_plant_id": 9999, "pollination_date": "2026-06-01"}, http.StatusNotFound},
+		"unknown project": {map[string]interface{}{"female_plant_id": female, "pollen_source": "x", "pollination_date": "2026-06-01", "project_id": 9999}, http.StatusBadRequest},
+	} {
+		resp := harvestRequest(t, c, http.MethodPost, "/pollinations", apiKey, tc.body)
+		testutil.DrainAndClose(resp)
+		assert.Equal(t, tc.want, resp.StatusCode, name)
+	}
+
+	pollinationID, _ := pollinate(t, c, apiKey, map[string]interface{}{"female_plant_id": female, "pollen_source": "x"})
+	harvestPath := "/pollinations/" + strconv.Itoa(pollinationID) + "/harvest"
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, harvestPath, apiKey, map[string]interface{}{
+		"strain_name": "New", "seeds": 5, "harvest_date": "2026-07-20",
+	}), http.StatusBadRequest)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, harvestPath, apiKey, map[string]interface{}{
+		"strain_name": "New", "breeder_id": 9999, "seeds": 5, "harvest_date": "2026-07-20",
+	}), http.StatusBadRequest)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, harvestPath, apiKey, map[string]interface{}{
+		"strain_name": "New", "new_breeder": "Home Grown", "seeds": 0, "harvest_date": "2026-07-20",
+	}), http.StatusBadRequest)
+	createNutrientRow(t, c, apiKey, harvestPath, map[string]interface{}{
+		"strain_name": "New", "new_breeder": "Home Grown", "seeds": 5, "harvest_date": "2026-07-20",
+	})
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM breeder WHERE name = 'Home Grown'`))
+
+	// Deleting the female keeps the cross and the strain it had.
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodDelete, "/plant/delete/"+strconv.Itoa(female), apiKey, nil), http.StatusOK)
+	overview := getBreedingOverview(t, c)
+	require.Len(t, overview.Unassigned, 1)
+	assert.Nil(t, overview.Unassigned[0].FemalePlantID)
+	require.NotNil(t, overview.Unassigned[0].FemaleStrainID)
+	assert.Equal(t, strainID, *overview.Unassigned[0].FemaleStrainID)
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodDelete, "/pollinations/"+strconv.Itoa(pollinationID), apiKey, nil), http.StatusOK)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodDelete, "/pollinations/"+strconv.Itoa(pollinationID), apiKey, nil), http.StatusNotFound)
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain WHERE name = 'New'`), "the harvested strain is kept")
+}
@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+
+	"isley/logger"
+	"isley/model/types"
+	"isley/utils"
+)
+
+// calendarTokenSetting holds the bcrypt hash of the calendar feed token.
+// Empty means no token has been issued.
+const calendarTokenSetting = "calendar_token"
+
+// calendarFlowerStatus is the stage whose first entry marks a plant's
+// flip, matching deriveEstimatedHarvestDate.
+const calendarFlowerStatus = "Flower"
+
+// calendarPlant is a plant with what the feed needs to date its events.
+type calendarPlant struct {
+	id         int
+	name       string
+	zoneID     int
+	growRunID  int
+	startDT    time.Time
+	cycleTime  int
+	autoflower bool
+	runFlip    *time.Time
+	harvested  *time.Time
+	history    []types.Status
+	living     bool
+}
+
+// calendarAuthorized reports whether the request may read the feed: guest
+// mode, a logged-in session, an API key in the X-API-KEY header, or the
+// calendar token in the token query parameter. Calendar apps can't send
+// headers, so they use the token; API keys stay out of URLs, which end up
+// in logs and subscription lists, because they grant far more than the
+// read-only feed.
+func calendarAuthorized(c *gin.Context) (bool, error) {
+	if ConfigStoreFromContext(c).GuestMode() == 1 {
+		return true, nil
+	}
+	if loggedIn, ok := sessions.Default(c).Get("logged_in").(bool); ok && loggedIn {
+		return true, nil
+	}
+	db := DBFromContext(c)
+	if key := c.GetHeader("X-API-KEY"); key != "" {
+		return VerifyAPIKey(db, key)
+	}
+	token := c.Query("token")
+	if token == "" {
+		return false, nil
+	}
+	stored, err := GetSetting(db, calendarTokenSetting)
+	if err != nil || stored == "" {
+		return false, err
+	}
+	match, _ := CheckAPIKey(token, stored)
+	return match, nil
+}
+
+// optionalIDQuery parses an optional positive ID query parameter.
+func optionalIDQuery(c *gin.Context, name string) (int, bool) {
+	v := c.Query(name)
+	if v == "" {
+		return 0, true
+	}
+	id, err := strconv.Atoi(v)
+	return id, err == nil && id > 0
+}
+
+// CalendarFeed serves the grow as an iCalendar feed: every stage change,
+// each plant's flip and harvest, estimated harvests of living plants and
+// tasks due over the next MaxTaskHorizonDays. The zone and plant query
+// parameters narrow it to one zone or plant.
+func CalendarFeed(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "CalendarFeed")
+	ok, err := calendarAuthorized(c)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to check calendar credentials")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if !ok {
+		apiError(c, http.StatusUnauthorized, "api_calendar_unauthorized")
+		return
+	}
+	zoneID, zoneOK := optionalIDQuery(c, "zone")
+	plantID, plantOK := optionalIDQuery(c, "plant")
+	if !zoneOK || !plantOK {
+		apiBadRequest(c, "api_invalid_request")
+		return
+	}
+
+	db := DBFromContext(c)
+	lcl := utils.TranslationService.GetTranslations(utils.GetLanguage(c))
+	events, err := buildCalendarEvents(db, lcl, taskToday(c), zoneID, plantID)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to build calendar")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	// Links point at the configured public URL. The request's own host
+	// can't be trusted to name this server, so without one they are left
+	// out.
+	base := ConfigStoreFromContext(c).PublicURL()
+	for i := range events {
+		if base == "" {
+			events[i].URL = ""
+		} else if events[i].URL != "" {
+			events[i].URL = base + events[i].URL
+		}
+	}
+
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Content-Disposition", `inline; filename="isley.ics"`)
+	c.Status(http.StatusOK)
+	if err := utils.WriteCalendar(c.Writer, "Isley", events, time.Now()); err != nil {
+		fieldLogger.WithError(err).Warn("Failed to write calendar")
+	}
+}
+
+// loadCalendarPlants returns the plants in the zone and plant filter (0
+// for any) with their full status history.
+func loadCalendarPlants(db *sql.DB, zoneID, plantID int) ([]*calendarPlant, error) {
+	rows, err := db.Query(`SELECT p.id, p.name, COALESCE(p.zone_id, 0), COALESCE(p.grow_run_id, 0), p.start_dt,
+			COALESCE(s.cycle_time, 0), COALESCE(s.autoflower, 0), r.flip_date, h.harvest_date
+		FROM plant p
+		LEFT JOIN strain s ON s.id = p.strain_id
+		LEFT JOIN grow_runs r ON r.id = p.grow_run_id
+		LEFT JOIN harvests h ON h.plant_id = p.id
+		WHERE ($1 = 0 OR p.zone_id = $1) AND ($2 = 0 OR p.id = $2)
+		ORDER BY p.id`, zoneID, plantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var plants []*calendarPlant
+	byID := map[int]*calendarPlant{}
+	for rows.Next() {
+		p := &calendarPlant{}
+		var flip, harvested sql.NullTime
+		if err := rows.Scan(&p.id, &p.name, &p.zoneID, &p.growRunID, &p.startDT,
+			&p.cycleTime, &p.autoflower, &flip, &harvested); err != nil {
+			return nil, err
+		}
+		p.startDT = utils.AsLocal(p.startDT)
+		p.runFlip, p.harvested = nullLocalTimePtr(flip), nullLocalTimePtr(harvested)
+		plants = append(plants, p)
+		byID[p.id] = p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(`SELECT psl.id, psl.plant_id, psl.status_id, ps.status, ps.active, psl.date
+		FROM plant_status_log psl
+		JOIN plant_status ps ON ps.id = psl.status_id
+		ORDER BY psl.plant_id, psl.date, psl.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s types.Status
+		var plant int
+		var active bool
+		if err := rows.Scan(&s.ID, &plant, &s.StatusID, &s.Status, &active, &s.Date); err != nil {
+			return nil, err
+		}
+		p, ok := byID[plant]
+		if !ok {
+			continue
+		}
+		s.Date = utils.AsLocal(s.Date)
+		p.history = append(p.history, s)
+		p.living = active
+	}
+	return plants, rows.Err()
+}
+
+// buildCalendarEvents collects the feed's events for the zone and plant
+// filter (0 for any), with summaries from lcl. Event URLs are paths.
+func buildCalendarEvents(db *sql.DB, lcl map[string]string, today time.Time, zoneID, plantID int) ([]utils.CalendarEvent, error) {
+	plants, err := loadCalendarPlants(db, zoneID, plantID)
+	if err != nil {
+		return nil, err
+	}
+	var events []utils.CalendarEvent
+	add := func(p *calendarPlant, uid string, date time.Time, summary, category string) {
+		events = append(events, utils.CalendarEvent{
+			UID:      uid + "@isley",
+			Date:     date,
+			Summary:  p.name + ": " + summary,
+			Category: category,
+			URL:      "/plant/" + strconv.Itoa(p.id),
+		})
+	}
+	inScope := map[int]*calendarPlant{}
+	for _, p := range plants {
+		inScope[p.id] = p
+		flipped := false
+		for _, s := range p.history {
+			uid := "status-" + strconv.Itoa(int(s.ID))
+			if s.Status == calendarFlowerStatus && !flipped {
+				flipped = true
+				add(p, uid, s.Date, lcl["calendar_flip"], lcl["calendar_milestone"])
+				continue
+			}
+			add(p, uid, s.Date, s.Status, lcl["calendar_stage"])
+		}
+		if !flipped && p.living && p.runFlip != nil {
+			add(p, "flip-"+strconv.Itoa(p.id), *p.runFlip, lcl["calendar_flip"], lcl["calendar_milestone"])
+		}
+		if p.harvested != nil {
+			add(p, "harvest-"+strconv.Itoa(p.id), *p.harvested, lcl["calendar_harvest"], lcl["calendar_milestone"])
+		} else if p.living {
+			if est := deriveEstimatedHarvestDate(p.history, p.startDT, p.cycleTime, p.autoflower); !est.IsZero() {
+				add(p, "estimate-"+strconv.Itoa(p.id), est, lcl["calendar_estimated_harvest"], lcl["calendar_milestone"])
+			}
+		}
+	}
+
+	due, err := LoadDueTasks(db, today, MaxTaskHorizonDays)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := LoadTasks(db)
+	if err != nil {
+		return nil, err
+	}
+	byID := map[int]types.Task{}
+	for _, t := range tasks {
+		byID[t.ID] = t
+	}
+	filtered := zoneID != 0 || plantID != 0
+	for _, d := range due {
+		t := byID[d.TaskID]
+		if filtered && !calendarTaskInScope(t, d, inScope, zoneID, plantID) {
+			continue
+		}
+		e := utils.CalendarEvent{
+			UID:         "task-" + strconv.Itoa(d.TaskID) + "-" + strconv.Itoa(d.PlantID) + "@isley",
+			Date:        d.DueDate,
+			Summary:     d.Title,
+			Description: d.Notes,
+			Category:    lcl["tasks_title"],
+			URL:         "/tasks",
+		}
+		if name := d.PlantName; name != "" || d.TargetName != "" {
+			if name == "" {
+				name = d.TargetName
+			}
+			e.Summary = name + ": " + d.Title
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// calendarTaskInScope reports whether a due task belongs in a feed
+// filtered to a zone or plant: it is for a plant in the filter, for the
+// filtered zone, or for a run holding a plant in the filter.
+func calendarTaskInScope(t types.Task, d types.DueTask, inScope map[int]*calendarPlant, zoneID, plantID int) bool {
+	switch {
+	case d.PlantID != 0:
+		return inScope[d.PlantID] != nil
+	case t.PlantID != nil:
+		return inScope[*t.PlantID] != nil
+	case t.ZoneID != nil:
+		if zoneID != 0 {
+			return *t.ZoneID == zoneID
+		}
+		p := inScope[plantID]
+		return p != nil && p.zoneID == *t.ZoneID
+	case t.GrowRunID != nil:
+		for _, p := range inScope {
+			if p.growRunID == *t.GrowRunID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CreateCalendarToken issues a new calendar feed token, replacing any
+// earlier one, and returns the feed URL path carrying it. Like API keys,
+// only a hash is kept, so the URL is shown once.
+func CreateCalendarToken(c *gin.Context) {
+	token := GenerateAPIKey()
+	hash := HashAPIKey(token)
+	if token == "" || hash == "" {
+		apiInternalError(c, "api_failed_to_save_settings")
+		return
+	}
+	if err := UpdateSetting(DBFromContext(c), nil, calendarTokenSetting, hash); err != nil {
+		apiInternalError(c, "api_failed_to_save_settings")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": T(c, "api_calendar_token_created"),
+		"token":   token,
+		"path":    "/calendar.ics?token=" + token,
+	})
+}
+
+// RevokeCalendarToken invalidates the calendar feed token.
+func RevokeCalendarToken(c *gin.Context) {
+	if err := UpdateSetting(DBFromContext(c), nil, calendarTokenSetting, ""); err != nil {
+		apiInternalError(c, "api_failed_to_save_settings")
+		return
+	}
+	apiOK(c, "api_calendar_token_revoked")
+}
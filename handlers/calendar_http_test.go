@@ -0,0 +1,257 @@
+package handlers_test
+
+// HTTP-layer tests for handlers/calendar.go: who may read the feed, the
+// calendar token lifecycle, the events it carries and its filters.
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"isley/config"
+	"isley/tests/testutil"
+)
+
+// getCalendar fetches the feed at path and returns its events, each a
+// map of property name to unfolded value. Parameters such as
+// VALUE=DATE are dropped from the names.
+func getCalendar(t *testing.T, c *testutil.Client, path string) []map[string]string {
+	t.Helper()
+	resp := c.Get(path)
+	body, err := io.ReadAll(resp.Body)
+	testutil.DrainAndClose(resp)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/calendar")
+
+	var events []map[string]string
+	var current map[string]string
+	for _, line := range strings.Split(strings.ReplaceAll(string(body), "\r\n ", ""), "\r\n") {
+		switch line {
+		case "BEGIN:VEVENT":
+			current = map[string]string{}
+			continue
+		case "END:VEVENT":
+			events = append(events, current)
+			current = nil
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if current == nil || !ok {
+			continue
+		}
+		name, _, _ = strings.Cut(name, ";")
+		current[name] = value
+	}
+	return events
+}
+
+// findEvent returns the event with the given summary, or nil.
+func findEvent(events []map[string]string, summary string) map[string]string {
+	for _, e := range events {
+		if e["SUMMARY"] == summary {
+			return e
+		}
+	}
+	return nil
+}
+
+// icsDay is the iCalendar date n days from today.
+func icsDay(n int) string {
+	return time.Now().AddDate(0, 0, n).Format("20060102")
+}
+
+// issueCalendarToken creates a calendar token and returns the feed path
+// that carries it.
+func issueCalendarToken(t *testing.T, c *testutil.Client, apiKey string) string {
+	t.Helper()
+	resp := c.APIPostJSON(t, "/settings/calendar-token", apiKey, nil)
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var body struct {
+		Token string `json:"token"`
+		Path  string `json:"path"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.NotEmpty(t, body.Token)
+	assert.Equal(t, "/calendar.ics?token="+body.Token, body.Path)
+	return body.Path
+}
+
+func calendarStatus(t *testing.T, c *testutil.Client, path string) int {
+	t.Helper()
+	resp := c.Get(path)
+	testutil.DrainAndClose(resp)
+	return resp.StatusCode
+}
+
+// ---------------------------------------------------------------------------
+// Credentials
+// ---------------------------------------------------------------------------
+
+func TestCalendarHTTP_Credentials(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db)
+	apiKey := testutil.SeedAPIKey(t, db, "calendar-key")
+	testutil.SeedAdmin(t, db, "calendar-pw")
+	c := server.NewClient(t)
+
+	assert.Equal(t, http.StatusUnauthorized, calendarStatus(t, c, "/calendar.ics"))
+	assert.Equal(t, http.StatusUnauthorized, calendarStatus(t, c, "/calendar.ics?key=wrong"))
+	assert.Equal(t, http.StatusUnauthorized, calendarStatus(t, c, "/calendar.ics?token=wrong"), "no token has been issued")
+	assert.Equal(t, http.StatusUnauthorized, calendarStatus(t, c, "/calendar.ics?key="+apiKey), "API keys are header-only")
+	resp := c.APIGet(t, "/calendar.ics", apiKey)
+	testutil.DrainAndClose(resp)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, http.StatusOK, calendarStatus(t, server.LoginAsAdmin(t, "calendar-pw"), "/calendar.ics"))
+
+	first := issueCalendarToken(t, c, apiKey)
+	assert.Equal(t, http.StatusOK, calendarStatus(t, c, first))
+	var stored string
+	require.NoError(t, db.QueryRow(`SELECT value FROM settings WHERE name = 'calendar_token'`).Scan(&stored))
+	assert.NotContains(t, first, stored, "only a hash of the token is kept")
+
+	second := issueCalendarToken(t, c, apiKey)
+	assert.Equal(t, http.StatusUnauthorized, calendarStatus(t, c, first), "a new token replaces the old one")
+	assert.Equal(t, http.StatusOK, calendarStatus(t, c, second))
+
+	resp = c.APIDelete(t, "/settings/calendar-token", apiKey)
+	testutil.DrainAndClose(resp)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, http.StatusUnauthorized, calendarStatus(t, c, second))
+
+	resp, err := c.Do(testutil.APIReq(t, http.MethodPost, c.BaseURL+"/settings/calendar-token", "", nil, ""))
+	require.NoError(t, err)
+	testutil.DrainAndClose(resp)
+	assert.NotEqual(t, http.StatusOK, resp.StatusCode, "issuing a token needs credentials")
+
+	store := config.NewStore()
+	store.SetGuestMode(1)
+	guest := testutil.NewTestServer(t, testutil.NewTestDB(t), testutil.WithGuestMode(), testutil.WithConfigStore(store))
+	assert.Equal(t, http.StatusOK, calendarStatus(t, guest.NewClient(t), "/calendar.ics"), "guest mode opens the feed like the other views")
+}
+
+func TestCalendarHTTP_LinksNeedPublicURL(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db)
+	apiKey := testutil.SeedAPIKey(t, db, "calendar-links-key")
+	plantID := testutil.SeedPlant(t, db, "Solo", testutil.SeedStrain(t, db, testutil.SeedBreeder(t, db, "B"), "S"), testutil.SeedZone(t, db, "Tent"))
+	testutil.MustExec(t, db, `INSERT INTO plant_status_log (plant_id, status_id, date) VALUES ($1, $2, $3)`,
+		plantID, plantStatusID(t, db, "Veg"), testutil.DaysAgo(3))
+
+	c := server.NewClient(t)
+	req := testutil.APIReq(t, http.MethodGet, c.BaseURL+"/calendar.ics", apiKey, nil, "")
+	req.Host = "attacker.example"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	testutil.DrainAndClose(resp)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "SUMMARY:Solo: Veg")
+	assert.NotContains(t, string(body), "URL:", "without a public URL the feed carries no links")
+	assert.NotContains(t, string(body), "attacker.example")
+}
+
+// ---------------------------------------------------------------------------
+// Events and filters
+// ---------------------------------------------------------------------------
+
+func TestCalendarHTTP_EventsAndFilters(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	store := config.NewStore()
+	store.SetPublicURL("https://isley.example.com")
+	server := testutil.NewTestServer(t, db, testutil.WithConfigStore(store))
+	apiKey := testutil.SeedAPIKey(t, db, "calendar-events-key")
+	zoneID, early, late, veg := seedTaskPlants(t, db)
+	otherZone := testutil.SeedZone(t, db, "Closet")
+	var strainID int
+	require.NoError(t, db.QueryRow(`SELECT strain_id FROM plant WHERE id = $1`, early).Scan(&strainID))
+	elsewhere := testutil.SeedPlant(t, db, "Elsewhere", strainID, otherZone)
+	testutil.MustExec(t, db, `UPDATE strain SET cycle_time = 60 WHERE id = $1`, strainID)
+	testutil.MustExec(t, db, `INSERT INTO plant_status_log (plant_id, status_id, date) VALUES ($1, $2, $3)`,
+		elsewhere, plantStatusID(t, db, "Veg"), testutil.DaysAgo(3))
+	testutil.MustExec(t, db, `INSERT INTO harvests (plant_id, harvest_date) VALUES ($1, $2)`, late, testutil.DaysAgo(1))
+
+	c := server.NewClient(t)
+	runID := createGrowRun(t, c, apiKey, map[string]interface{}{
+		"name": "Spring", "zone_id": zoneID, "start_date": "2026-01-01",
+		"flip_date": time.Now().AddDate(0, 0, 5).Format("2006-01-02"),
+	})
+	assignGrowRunPlants(t, c, apiKey, runID, veg)
+	createTask(t, c, apiKey, map[string]interface{}{
+		"title": "Defoliate", "zone_id": zoneID, "schedule": "stage",
+		"stage_status_id": plantStatusID(t, db, "Flower"), "stage_day": 21,
+	})
+	createTask(t, c, apiKey, map[string]interface{}{
+		"title": "Check runoff", "plant_id": veg, "due_date": time.Now().AddDate(0, 0, 2).Format("2006-01-02"),
+	})
+	createTask(t, c, apiKey, map[string]interface{}{
+		"title": "Order soil", "plant_id": veg, "due_date": time.Now().AddDate(0, 0, 200).Format("2006-01-02"),
+	})
+
+	feed := issueCalendarToken(t, c, apiKey)
+	events := getCalendar(t, c, feed)
+	expect := map[string]string{
+		"Early: Veg":               icsDay(-60),
+		"Early: Flip to flower":    icsDay(-25),
+		"Early: Estimated harvest": icsDay(35),
+		"Late: Veg":                icsDay(-60),
+		"Late: Flip to flower":     icsDay(-10),
+		"Late: Harvest":            icsDay(-1),
+		"Young: Veg":               icsDay(-60),
+		"Young: Flip to flower":    icsDay(5),
+		"Elsewhere: Veg":           icsDay(-3),
+		"Early: Defoliate":         icsDay(-5),
+		"Late: Defoliate":          icsDay(10),
+		"Young: Check runoff":      icsDay(2),
+	}
+	for summary, day := range expect {
+		if e := findEvent(events, summary); assert.NotNil(t, e, summary) {
+			assert.Equal(t, day, e["DTSTART"], summary)
+		}
+	}
+	assert.Len(t, events, len(expect), "no estimate for a harvested plant, and tasks past the horizon are left out")
+
+	flip := findEvent(events, "Early: Flip to flower")
+	assert.Equal(t, "Milestone", flip["CATEGORIES"])
+	assert.Equal(t, "https://isley.example.com/plant/"+strconv.Itoa(early), flip["URL"], "links use the configured public URL")
+	assert.Equal(t, "Stage", findEvent(events, "Early: Veg")["CATEGORIES"])
+	assert.Equal(t, "Tasks", findEvent(events, "Young: Check runoff")["CATEGORIES"])
+	assert.Equal(t, findEvent(events, "Early: Flip to flower")["UID"],
+		findEvent(getCalendar(t, c, feed), "Early: Flip to flower")["UID"],
+		"UIDs are stable across fetches")
+
+	events = getCalendar(t, c, feed+"&zone="+strconv.Itoa(otherZone))
+	require.Len(t, events, 1, "the Tent's plants and tasks are filtered out")
+	assert.Equal(t, "Elsewhere: Veg", events[0]["SUMMARY"])
+
+	events = getCalendar(t, c, feed+"&plant="+strconv.Itoa(veg))
+	assert.NotNil(t, findEvent(events, "Young: Check runoff"))
+	assert.NotNil(t, findEvent(events, "Young: Flip to flower"))
+	assert.Nil(t, findEvent(events, "Early: Defoliate"), "the zone task only applies to the filtered plant")
+	assert.Len(t, events, 3)
+
+	events = getCalendar(t, c, feed+"&plant="+strconv.Itoa(early))
+	assert.NotNil(t, findEvent(events, "Early: Defoliate"))
+	assert.Nil(t, findEvent(events, "Late: Defoliate"))
+
+	events = getCalendar(t, c, feed+"&lang=de")
+	assert.NotNil(t, findEvent(events, "Early: Umstellung auf Blüte"), "summaries follow the language")
+
+	assert.Equal(t, http.StatusBadRequest, calendarStatus(t, c, feed+"&zone=abc"))
+}
@@ -142,7 +142,7 @@ func CannadbImportHandler(c *gin.Context) {
 
 	// Refresh in-memory caches so the UI reflects the import immediately.
 	store.SetBreeders(GetBreeders(db))
-	store.SetStrains(GetStrains(db))
+	store.SetStrains(GetStrains(db, StrainSortName))
 
 	fieldLogger.WithField("strain_id", strainID).WithField("uri", req.URI).Info("Imported strain from CannaDB")
 	c.JSON(http.StatusOK, gin.H{
@@ -199,13 +199,21 @@ func mapCannadbStrain(rec *cannadbRecord, val *cannadbStrainValue) types.Strain
 	}
 }
 
-// importCannadbBreeder resolves the strain's breeder (full record when a
-// breeder AT-URI is present, else the breederName fallback) and upserts it
+// importCannadbBreeder resolves the strain's breeder and upserts it
 // locally, returning the local breeder id.
 func importCannadbBreeder(db *sql.DB, baseURL string, val *cannadbStrainValue) (int, error) {
-	name := strings.TrimSpace(val.BreederName)
-	breederURI := ""
-	indexedAt := ""
+	name, breederURI, indexedAt, err := resolveCannadbBreeder(baseURL, val)
+	if err != nil {
+		return 0, err
+	}
+	return upsertCannadbBreeder(db, name, breederURI, indexedAt)
+}
+
+// resolveCannadbBreeder works out the strain's breeder: the full record when
+// a breeder AT-URI is present, else the breederName fallback. uri and
+// indexedAt are empty for the fallback.
+func resolveCannadbBreeder(baseURL string, val *cannadbStrainValue) (name, uri, indexedAt string, err error) {
+	name = strings.TrimSpace(val.BreederName)
 
 	if val.Breeder != "" {
 		rec, bval, err := cannadbGetBreeder(baseURL, val.Breeder)
@@ -214,11 +222,11 @@ func importCannadbBreeder(db *sql.DB, baseURL string, val *cannadbStrainValue) (
 			// to breederName on RecordNotFound, surface other errors.
 			var apiErr *cannadbError
 			if !errors.As(err, &apiErr) || apiErr.HTTPStatus != http.StatusNotFound {
-				return 0, err
+				return "", "", "", err
 			}
 		} else if strings.TrimSpace(bval.Name) != "" {
 			name = strings.TrimSpace(bval.Name)
-			breederURI = rec.URI
+			uri = rec.URI
 			indexedAt = rec.IndexedAt
 		}
 	}
@@ -226,18 +234,26 @@ func importCannadbBreeder(db *sql.DB, baseURL string, val *cannadbStrainValue) (
 	if name == "" {
 		name = "Unknown Breeder"
 	}
-
-	return upsertCannadbBreeder(db, name, breederURI, indexedAt)
+	return name, uri, indexedAt, nil
 }
 
 // upsertCannadbBreeder finds an existing breeder by CannaDB URI, then by name,
-// inserting a new one if neither matches. Returns the breeder id.
-func upsertCannadbBreeder(db *sql.DB, name, uri, indexedAt string) (int, error) {
+// inserting a new one if neither matches. Returns the breeder id. A breeder
+// whose name was edited locally keeps it.
+func upsertCannadbBreeder(db cannadbQuerier, name, uri, indexedAt string) (int, error) {
 	var id int
 
 	if uri != "" {
 		err := db.QueryRow("SELECT id FROM breeder WHERE cannadb_uri = $1", uri).Scan(&id)
 		if err == nil {
+			locks, lerr := loadCannadbLocks(db, cannadbBreederLocks, id)
+			if lerr != nil {
+				return 0, lerr
+			}
+			if locks[cannadbFieldName] {
+				_, uerr := db.Exec("UPDATE breeder SET cannadb_indexed_at = $1 WHERE id = $2", indexedAt, id)
+				return id, uerr
+			}
 			_, uerr := db.Exec("UPDATE breeder SET name = $1, cannadb_indexed_at = $2 WHERE id = $3", name, indexedAt, id)
 			return id, uerr
 		}
@@ -304,29 +320,73 @@ func upsertCannadbStrain(db *sql.DB, breederID int, s types.Strain) (int, error)
 
 // replaceCannadbLineage rewrites the strain's lineage from the CannaDB
 // parentNames (display fallbacks). Parent strains are not recursively imported
-// in v1, so parent_strain_id is left NULL.
+// in v1, so parent_strain_id is left NULL unless a parent of the same name
+// was already linked to a local strain, in which case the link is kept.
 func replaceCannadbLineage(db *sql.DB, strainID int, parentNames []string) error {
 	tx, err := db.Begin()
 	if err != nil {
 		return err
 	}
-	if _, err := tx.Exec("DELETE FROM strain_lineage WHERE strain_id = $1", strainID); err != nil {
+	if err := writeCannadbLineage(tx, strainID, parentNames); err != nil {
 		tx.Rollback()
 		return err
 	}
-	for _, name := range parentNames {
-		name = strings.TrimSpace(name)
-		if name == "" {
-			continue
+	return tx.Commit()
+}
+
+// writeCannadbLineage is replaceCannadbLineage inside the caller's
+// transaction.
+func writeCannadbLineage(tx *sql.Tx, strainID int, parentNames []string) error {
+	links, err := lineageLinks(tx, strainID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM strain_lineage WHERE strain_id = $1", strainID); err != nil {
+		return err
+	}
+	for _, name := range cleanParentNames(parentNames) {
+		var link interface{}
+		if id, ok := links[strings.ToLower(name)]; ok {
+			link = id
 		}
 		if _, err := tx.Exec(
-			"INSERT INTO strain_lineage (strain_id, parent_name, parent_strain_id) VALUES ($1, $2, NULL)",
-			strainID, name); err != nil {
-			tx.Rollback()
+			"INSERT INTO strain_lineage (strain_id, parent_name, parent_strain_id) VALUES ($1, $2, $3)",
+			strainID, name, link); err != nil {
 			return err
 		}
 	}
-	return tx.Commit()
+	return nil
+}
+
+// lineageLinks maps the lower-cased names of a strain's linked parents to
+// the local strains they are linked to.
+func lineageLinks(tx *sql.Tx, strainID int) (map[string]int, error) {
+	rows, err := tx.Query("SELECT parent_name, parent_strain_id FROM strain_lineage WHERE strain_id = $1 AND parent_strain_id IS NOT NULL", strainID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	links := map[string]int{}
+	for rows.Next() {
+		var name string
+		var id int
+		if err := rows.Scan(&name, &id); err != nil {
+			return nil, err
+		}
+		links[strings.ToLower(name)] = id
+	}
+	return links, rows.Err()
+}
+
+// cleanParentNames trims parentNames and drops the empty ones.
+func cleanParentNames(parentNames []string) []string {
+	names := make([]string, 0, len(parentNames))
+	for _, name := range parentNames {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
 }
 
 // nullableStr returns nil for empty strings so NULL is stored instead of "",
@@ -0,0 +1,646 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"isley/logger"
+)
+
+// ---------------------------------------------------------------------------
+// CannaDB re-sync.
+//
+// Imported strains and breeders keep the indexedAt of the record they came
+// from. A sync re-fetches every imported record and, where indexedAt has
+// moved on, diffs it against the local row field by field. Fields edited
+// locally are locked and left alone; the rest are updated, and the lineage
+// is rebuilt from the record's parentNames.
+// ---------------------------------------------------------------------------
+
+// Synced fields, as named in diffs and locks. Breeders only sync their name.
+const (
+	cannadbFieldName        = "name"
+	cannadbFieldBreeder     = "breeder"
+	cannadbFieldRatio       = "indica_sativa"
+	cannadbFieldAutoflower  = "autoflower"
+	cannadbFieldDescription = "description"
+	cannadbFieldShortDesc   = "short_desc"
+	cannadbFieldCycleTime   = "cycle_time"
+	cannadbFieldURL         = "url"
+	cannadbFieldLineage     = "lineage"
+)
+
+// cannadbStrainFields is every synced strain field, in diff order.
+var cannadbStrainFields = []string{
+	cannadbFieldName, cannadbFieldBreeder, cannadbFieldRatio, cannadbFieldAutoflower,
+	cannadbFieldDescription, cannadbFieldShortDesc, cannadbFieldCycleTime, cannadbFieldURL,
+	cannadbFieldLineage,
+}
+
+// cannadbLockTable is where the locked fields of one kind of record live.
+type cannadbLockTable struct {
+	records     string // strain or breeder
+	table       string
+	column      string
+	fields      []string
+	notFoundKey string
+}
+
+var (
+	cannadbStrainLocks  = cannadbLockTable{"strain", "strain_cannadb_locks", "strain_id", cannadbStrainFields, "api_strain_not_found"}
+	cannadbBreederLocks = cannadbLockTable{"breeder", "breeder_cannadb_locks", "breeder_id", []string{cannadbFieldName}, "api_breeder_not_found"}
+)
+
+// cannadbQuerier is satisfied by both *sql.DB and *sql.Tx, so the lock
+// helpers can run inside the edit they guard.
+type cannadbQuerier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// loadCannadbLocks returns the locked fields of a record.
+func loadCannadbLocks(q interface {
+	Query(string, ...interface{}) (*sql.Rows, error)
+}, locks cannadbLockTable, id int) (map[string]bool, error) {
+	rows, err := q.Query(fmt.Sprintf("SELECT field FROM %s WHERE %s = $1", locks.table, locks.column), id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	locked := map[string]bool{}
+	for rows.Next() {
+		var field string
+		if err := rows.Scan(&field); err != nil {
+			return nil, err
+		}
+		locked[field] = true
+	}
+	return locked, rows.Err()
+}
+
+// lockCannadbFields locks fields of a record so a sync leaves them alone.
+// Records that were not imported from CannaDB are skipped.
+func lockCannadbFields(q interface {
+	Exec(string, ...interface{}) (sql.Result, error)
+}, locks cannadbLockTable, id int, fields ...string) error {
+	query := fmt.Sprintf(`INSERT INTO %s (%s, field)
+		SELECT id, CAST($2 AS TEXT) FROM %s WHERE id = $1 AND cannadb_uri IS NOT NULL
+		ON CONFLICT (%s, field) DO NOTHING`, locks.table, locks.column, locks.records, locks.column)
+	for _, field := range fields {
+		if _, err := q.Exec(query, id, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cannadbSyncedStrain is a strain's synced fields, either as stored or as
+// CannaDB has them.
+type cannadbSyncedStrain struct {
+	BreederID        int
+	Name             string
+	Breeder          string
+	BreederURI       string
+	Indica           int
+	Sativa           int
+	Autoflower       bool
+	Description      string
+	ShortDescription string
+	CycleTime        int
+	Url              string
+	Lineage          []string
+}
+
+// value formats a field for a diff.
+func (s cannadbSyncedStrain) value(field string) string {
+	switch field {
+	case cannadbFieldName:
+		return s.Name
+	case cannadbFieldBreeder:
+		return s.Breeder
+	case cannadbFieldRatio:
+		return fmt.Sprintf("%d/%d", s.Indica, s.Sativa)
+	case cannadbFieldAutoflower:
+		return strconv.FormatBool(s.Autoflower)
+	case cannadbFieldDescription:
+		return s.Description
+	case cannadbFieldShortDesc:
+		return s.ShortDescription
+	case cannadbFieldCycleTime:
+		return strconv.Itoa(s.CycleTime)
+	case cannadbFieldURL:
+		return s.Url
+	case cannadbFieldLineage:
+		return strings.Join(s.Lineage, " × ")
+	}
+	return ""
+}
+
+// loadCannadbSyncedStrain reads a strain's synced fields.
+func loadCannadbSyncedStrain(q cannadbQuerier, id int) (cannadbSyncedStrain, error) {
+	var s cannadbSyncedStrain
+	err := q.QueryRow(`
+		SELECT s.breeder_id, s.name, COALESCE(b.name, ''), COALESCE(b.cannadb_uri, ''), s.indica, s.sativa, s.autoflower, s.description,
+		       COALESCE(s.short_desc, ''), COALESCE(s.cycle_time, 0), COALESCE(s.url, '')
+		FROM strain s
+		LEFT JOIN breeder b ON b.id = s.breeder_id
+		WHERE s.id = $1`, id).Scan(
+		&s.BreederID, &s.Name, &s.Breeder, &s.BreederURI, &s.Indica, &s.Sativa, &s.Autoflower, &s.Description,
+		&s.ShortDescription, &s.CycleTime, &s.Url)
+	if err != nil {
+		return s, err
+	}
+	s.Lineage, err = loadLineageNames(q, id)
+	return s, err
+}
+
+// loadLineageNames returns the parent names of a strain in the order they
+// were added.
+func loadLineageNames(q cannadbQuerier, strainID int) ([]string, error) {
+	rows, err := q.Query("SELECT parent_name FROM strain_lineage WHERE strain_id = $1 ORDER BY id", strainID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// lockEditedCannadbStrain locks the fields of an imported strain that a
+// local edit changes, so a later sync doesn't undo the edit. Lineage is
+// edited on its own and left out.
+func lockEditedCannadbStrain(q cannadbQuerier, id int, edited cannadbSyncedStrain) error {
+	current, err := loadCannadbSyncedStrain(q, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var fields []string
+	for _, field := range cannadbStrainFields {
+		switch field {
+		case cannadbFieldLineage:
+			continue
+		case cannadbFieldBreeder:
+			if edited.BreederID != current.BreederID {
+				fields = append(fields, field)
+			}
+		default:
+			if edited.value(field) != current.value(field) {
+				fields = append(fields, field)
+			}
+		}
+	}
+	return lockCannadbFields(q, cannadbStrainLocks, id, fields...)
+}
+
+// lockEditedCannadbLineage locks the lineage of an imported strain when a
+// local edit replaces its parents with different ones.
+func lockEditedCannadbLineage(q cannadbQuerier, strainID int, parentNames []string) error {
+	current, err := loadLineageNames(q, strainID)
+	if err != nil || slices.Equal(current, parentNames) {
+		return err
+	}
+	return lockCannadbFields(q, cannadbStrainLocks, strainID, cannadbFieldLineage)
+}
+
+// lockCannadbLineageEntry locks the lineage of the strain a lineage entry
+// belongs to, unless the entry keeps its parent name keepName.
+func lockCannadbLineageEntry(q cannadbQuerier, lineageID int, keepName string) error {
+	var strainID int
+	var name string
+	err := q.QueryRow("SELECT strain_id, parent_name FROM strain_lineage WHERE id = $1", lineageID).Scan(&strainID, &name)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && name == keepName {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return lockCannadbFields(q, cannadbStrainLocks, strainID, cannadbFieldLineage)
+}
+
+// lockEditedCannadbBreeder locks the name of an imported breeder when a
+// local edit renames it.
+func lockEditedCannadbBreeder(q cannadbQuerier, id int, name string) error {
+	var current string
+	err := q.QueryRow("SELECT name FROM breeder WHERE id = $1", id).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && current == name {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return lockCannadbFields(q, cannadbBreederLocks, id, cannadbFieldName)
+}
+
+// cannadbFieldChange is a field that differs between the local row and the
+// CannaDB record. A locked change is not applied.
+type cannadbFieldChange struct {
+	Field    string `json:"field"`
+	Current  string `json:"current"`
+	Incoming string `json:"incoming"`
+	Locked   bool   `json:"locked"`
+}
+
+// diffCannadbStrain lists the fields that differ between current and
+// incoming.
+func diffCannadbStrain(current, incoming cannadbSyncedStrain, locked map[string]bool) []cannadbFieldChange {
+	changes := []cannadbFieldChange{}
+	for _, field := range cannadbStrainFields {
+		from, to := current.value(field), incoming.value(field)
+		if field == cannadbFieldBreeder {
+			// The same CannaDB breeder is no change even when one side was
+			// renamed; that is synced on the breeder itself. Otherwise
+			// breeders are matched case-insensitively, as on import.
+			if current.BreederURI != "" && current.BreederURI == incoming.BreederURI || strings.EqualFold(from, to) {
+				continue
+			}
+		} else if from == to {
+			continue
+		}
+		changes = append(changes, cannadbFieldChange{Field: field, Current: from, Incoming: to, Locked: locked[field]})
+	}
+	return changes
+}
+
+// cannadbRecordSync is an imported strain or breeder whose CannaDB record
+// has changed since it was last synced.
+type cannadbRecordSync struct {
+	ID        int                  `json:"id"`
+	Name      string               `json:"name"`
+	URI       string               `json:"uri"`
+	IndexedAt string               `json:"indexed_at"`
+	Changes   []cannadbFieldChange `json:"changes"`
+}
+
+// cannadbSyncFailure is a record that could not be fetched or updated.
+type cannadbSyncFailure struct {
+	Kind  string `json:"kind"`
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// cannadbSyncReport is the outcome of a sync. Applied is false for a
+// preview. Only records with changed fields are listed.
+type cannadbSyncReport struct {
+	Applied  bool                 `json:"applied"`
+	Checked  int                  `json:"checked"`
+	Strains  []cannadbRecordSync  `json:"strains"`
+	Breeders []cannadbRecordSync  `json:"breeders"`
+	Failed   []cannadbSyncFailure `json:"failed"`
+}
+
+// cannadbSyncSelection limits a sync to some records. An empty selection
+// syncs everything.
+type cannadbSyncSelection struct {
+	StrainIDs  []int `json:"strain_ids"`
+	BreederIDs []int `json:"breeder_ids"`
+}
+
+func (s cannadbSyncSelection) includes(ids []int, id int) bool {
+	return len(s.StrainIDs) == 0 && len(s.BreederIDs) == 0 || slices.Contains(ids, id)
+}
+
+// listCannadbRecords returns the imported rows of records (strain or
+// breeder) with the indexedAt they were last synced at.
+func listCannadbRecords(db *sql.DB, records string) ([]cannadbRecordSync, error) {
+	rows, err := db.Query(fmt.Sprintf(`SELECT id, name, cannadb_uri, COALESCE(cannadb_indexed_at, '')
+		FROM %s WHERE cannadb_uri IS NOT NULL ORDER BY name, id`, records))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []cannadbRecordSync
+	for rows.Next() {
+		var r cannadbRecordSync
+		if err := rows.Scan(&r.ID, &r.Name, &r.URI, &r.IndexedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// syncCannadb checks every selected imported breeder and strain against
+// CannaDB. With apply set, unlocked changes are saved and indexedAt moves on;
+// otherwise nothing is written.
+func syncCannadb(db *sql.DB, baseURL string, apply bool, sel cannadbSyncSelection) (cannadbSyncReport, error) {
+	report := cannadbSyncReport{
+		Applied:  apply,
+		Strains:  []cannadbRecordSync{},
+		Breeders: []cannadbRecordSync{},
+		Failed:   []cannadbSyncFailure{},
+	}
+
+	breeders, err := listCannadbRecords(db, "breeder")
+	if err != nil {
+		return report, err
+	}
+	for _, b := range breeders {
+		if !sel.includes(sel.BreederIDs, b.ID) {
+			continue
+		}
+		report.Checked++
+		synced, changed, err := syncCannadbBreeder(db, baseURL, b, apply)
+		if err != nil {
+			report.Failed = append(report.Failed, cannadbSyncFailure{Kind: "breeder", ID: b.ID, Name: b.Name, Error: err.Error()})
+		} else if changed && len(synced.Changes) > 0 {
+			report.Breeders = append(report.Breeders, synced)
+		}
+	}
+
+	strains, err := listCannadbRecords(db, "strain")
+	if err != nil {
+		return report, err
+	}
+	for _, s := range strains {
+		if !sel.includes(sel.StrainIDs, s.ID) {
+			continue
+		}
+		report.Checked++
+		synced, changed, err := syncCannadbStrain(db, baseURL, s, apply)
+		if err != nil {
+			report.Failed = append(report.Failed, cannadbSyncFailure{Kind: "strain", ID: s.ID, Name: s.Name, Error: err.Error()})
+		} else if changed && len(synced.Changes) > 0 {
+			report.Strains = append(report.Strains, synced)
+		}
+	}
+	return report, nil
+}
+
+// syncCannadbBreeder re-fetches an imported breeder. changed is false when
+// its indexedAt has not moved on.
+func syncCannadbBreeder(db *sql.DB, baseURL string, b cannadbRecordSync, apply bool) (cannadbRecordSync, bool, error) {
+	rec, val, err := cannadbGetBreeder(baseURL, b.URI)
+	if err != nil {
+		return b, false, err
+	}
+	if rec.IndexedAt == b.IndexedAt {
+		return b, false, nil
+	}
+	locked, err := loadCannadbLocks(db, cannadbBreederLocks, b.ID)
+	if err != nil {
+		return b, false, err
+	}
+
+	name := b.Name
+	b.IndexedAt = rec.IndexedAt
+	b.Changes = []cannadbFieldChange{}
+	if incoming := strings.TrimSpace(val.Name); incoming != "" && incoming != b.Name {
+		b.Changes = append(b.Changes, cannadbFieldChange{Field: cannadbFieldName, Current: b.Name, Incoming: incoming, Locked: locked[cannadbFieldName]})
+		if !locked[cannadbFieldName] {
+			name = incoming
+		}
+	}
+	if apply {
+		if _, err := db.Exec("UPDATE breeder SET name = $1, cannadb_indexed_at = $2 WHERE id = $3", name, rec.IndexedAt, b.ID); err != nil {
+			return b, false, err
+		}
+	}
+	return b, true, nil
+}
+
+// syncCannadbStrain re-fetches an imported strain and its breeder. changed
+// is false when its indexedAt has not moved on.
+func syncCannadbStrain(db *sql.DB, baseURL string, s cannadbRecordSync, apply bool) (cannadbRecordSync, bool, error) {
+	rec, val, err := cannadbGetStrain(baseURL, s.URI)
+	if err != nil {
+		return s, false, err
+	}
+	if rec.IndexedAt == s.IndexedAt {
+		return s, false, nil
+	}
+	if val.Name == "" {
+		return s, false, errors.New("cannadb: strain record has no name")
+	}
+	breederName, breederURI, breederIndexedAt, err := resolveCannadbBreeder(baseURL, val)
+	if err != nil {
+		return s, false, err
+	}
+	current, err := loadCannadbSyncedStrain(db, s.ID)
+	if err != nil {
+		return s, false, err
+	}
+	locked, err := loadCannadbLocks(db, cannadbStrainLocks, s.ID)
+	if err != nil {
+		return s, false, err
+	}
+
+	mapped := mapCannadbStrain(rec, val)
+	incoming := cannadbSyncedStrain{
+		Name:             mapped.Name,
+		Breeder:          breederName,
+		BreederURI:       breederURI,
+		Indica:           mapped.Indica,
+		Sativa:           mapped.Sativa,
+		Autoflower:       mapped.Autoflower,
+		Description:      mapped.Description,
+		ShortDescription: mapped.ShortDescription,
+		CycleTime:        mapped.CycleTime,
+		Url:              mapped.Url,
+		Lineage:          cleanParentNames(val.ParentNames),
+	}
+	s.IndexedAt = rec.IndexedAt
+	s.Changes = diffCannadbStrain(current, incoming, locked)
+	if !apply {
+		return s, true, nil
+	}
+
+	// Every write for the record shares one transaction, so a failure part
+	// way leaves indexedAt where it was and the next sync retries it.
+	tx, err := db.Begin()
+	if err != nil {
+		return s, false, err
+	}
+	defer tx.Rollback() // no-op after Commit
+
+	merged := current
+	relineage := false
+	for _, change := range s.Changes {
+		if change.Locked {
+			continue
+		}
+		switch change.Field {
+		case cannadbFieldName:
+			merged.Name = incoming.Name
+		case cannadbFieldBreeder:
+			if merged.BreederID, err = upsertCannadbBreeder(tx, breederName, breederURI, breederIndexedAt); err != nil {
+				return s, false, err
+			}
+		case cannadbFieldRatio:
+			merged.Indica, merged.Sativa = incoming.Indica, incoming.Sativa
+		case cannadbFieldAutoflower:
+			merged.Autoflower = incoming.Autoflower
+		case cannadbFieldDescription:
+			merged.Description = incoming.Description
+		case cannadbFieldShortDesc:
+			merged.ShortDescription = incoming.ShortDescription
+		case cannadbFieldCycleTime:
+			merged.CycleTime = incoming.CycleTime
+		case cannadbFieldURL:
+			merged.Url = incoming.Url
+		case cannadbFieldLineage:
+			relineage = true
+		}
+	}
+	autoflower := 0
+	if merged.Autoflower {
+		autoflower = 1
+	}
+	if _, err := tx.Exec(`
+		UPDATE strain
+		SET name = $1, breeder_id = $2, indica = $3, sativa = $4, autoflower = $5,
+		    description = $6, short_desc = $7, cycle_time = $8, url = $9, cannadb_indexed_at = $10
+		WHERE id = $11`,
+		merged.Name, merged.BreederID, merged.Indica, merged.Sativa, autoflower,
+		merged.Description, merged.ShortDescription, merged.CycleTime, merged.Url, rec.IndexedAt, s.ID); err != nil {
+		return s, false, err
+	}
+	if relineage {
+		if err := writeCannadbLineage(tx, s.ID, incoming.Lineage); err != nil {
+			return s, false, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return s, false, err
+	}
+	return s, true, nil
+}
+
+// CannadbSyncPreviewHandler reports what a sync would change without
+// saving anything.
+// GET /strains/cannadb/sync
+func CannadbSyncPreviewHandler(c *gin.Context) {
+	if !cannadbEnabled(c) {
+		apiBadRequest(c, "api_cannadb_disabled")
+		return
+	}
+	report, err := syncCannadb(DBFromContext(c), ConfigStoreFromContext(c).CannadbBaseURL(), false, cannadbSyncSelection{})
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "CannadbSyncPreviewHandler").Error("Failed to check CannaDB records")
+		apiInternalError(c, "api_cannadb_sync_failed")
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// CannadbSyncHandler applies CannaDB changes to the selected records, or to
+// every imported record when none are selected.
+// POST /strains/cannadb/sync  {"strain_ids": [..], "breeder_ids": [..]}
+func CannadbSyncHandler(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "CannadbSyncHandler")
+
+	if !cannadbEnabled(c) {
+		apiBadRequest(c, "api_cannadb_disabled")
+		return
+	}
+	var sel cannadbSyncSelection
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&sel); err != nil {
+			apiBadRequest(c, "api_invalid_request_payload")
+			return
+		}
+	}
+
+	db := DBFromContext(c)
+	store := ConfigStoreFromContext(c)
+	report, err := syncCannadb(db, store.CannadbBaseURL(), true, sel)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to sync CannaDB records")
+		apiInternalError(c, "api_cannadb_sync_failed")
+		return
+	}
+	store.SetBreeders(GetBreeders(db))
+	store.SetStrains(GetStrains(db, StrainSortName))
+
+	fieldLogger.WithField("checked", report.Checked).WithField("strains", len(report.Strains)).
+		WithField("breeders", len(report.Breeders)).WithField("failed", len(report.Failed)).Info("Synced CannaDB records")
+	c.JSON(http.StatusOK, gin.H{"report": report, "message": T(c, "api_cannadb_synced")})
+}
+
+// cannadbLockParams reads the record id and field of a lock route.
+func cannadbLockParams(c *gin.Context, locks cannadbLockTable) (int, string, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_request")
+		return 0, "", false
+	}
+	field := c.Param("field")
+	if !slices.Contains(locks.fields, field) {
+		apiBadRequest(c, "api_cannadb_invalid_field")
+		return 0, "", false
+	}
+	var uri sql.NullString
+	err = DBFromContext(c).QueryRow(fmt.Sprintf("SELECT cannadb_uri FROM %s WHERE id = $1", locks.records), id).Scan(&uri)
+	if errors.Is(err, sql.ErrNoRows) {
+		apiNotFound(c, locks.notFoundKey)
+		return 0, "", false
+	}
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "cannadbLockParams").Error("Failed to look up record")
+		apiInternalError(c, "api_database_error")
+		return 0, "", false
+	}
+	if !uri.Valid {
+		apiBadRequest(c, "api_cannadb_not_imported")
+		return 0, "", false
+	}
+	return id, field, true
+}
+
+func setCannadbLock(c *gin.Context, locks cannadbLockTable, lock bool) {
+	id, field, ok := cannadbLockParams(c, locks)
+	if !ok {
+		return
+	}
+	db := DBFromContext(c)
+	var err error
+	if lock {
+		err = lockCannadbFields(db, locks, id, field)
+	} else {
+		_, err = db.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s = $1 AND field = $2", locks.table, locks.column), id, field)
+	}
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "setCannadbLock").Error("Failed to update lock")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if lock {
+		apiOK(c, "api_cannadb_field_locked")
+	} else {
+		apiOK(c, "api_cannadb_field_unlocked")
+	}
+}
+
+// LockStrainCannadbField keeps a strain field as it is on future syncs.
+// PUT /strains/:id/cannadb-locks/:field
+func LockStrainCannadbField(c *gin.Context) { setCannadbLock(c, cannadbStrainLocks, true) }
+
+// UnlockStrainCannadbField lets syncs update a strain field again.
+// DELETE /strains/:id/cannadb-locks/:field
+func UnlockStrainCannadbField(c *gin.Context) { setCannadbLock(c, cannadbStrainLocks, false) }
+
+// LockBreederCannadbField keeps a breeder field as it is on future syncs.
+// PUT /breeders/:id/cannadb-locks/:field
+func LockBreederCannadbField(c *gin.Context) { setCannadbLock(c, cannadbBreederLocks, true) }
+
+// UnlockBreederCannadbField lets syncs update a breeder field again.
+// DELETE /breeders/:id/cannadb-locks/:field
+func UnlockBreederCannadbField(c *gin.Context) { setCannadbLock(c, cannadbBreederLocks, false) }
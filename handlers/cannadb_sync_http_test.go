@@ -0,0 +1,249 @@
+package handlers_test
+
+// HTTP-layer tests for handlers/cannadb_sync.go (CannaDB re-sync) against a
+// stub CannaDB server: the field-level diff, per-field locks set by local
+// edits or by hand, and applying the unlocked changes.
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"isley/config"
+	"isley/tests/testutil"
+)
+
+const (
+	stubStrainURI  = "at://did:plc:stub/org.cannadb.strain/gelato"
+	stubBreederURI = "at://did:plc:stub/org.cannadb.breeder/cookies"
+)
+
+// stubCannadb serves getStrain and getBreeder from records that tests can
+// change between calls. Unknown URIs are RecordNotFound.
+type stubCannadb struct {
+	mu      sync.Mutex
+	records map[string]map[string]interface{}
+}
+
+func newStubCannadb(t *testing.T) (*stubCannadb, *httptest.Server) {
+	t.Helper()
+	stub := &stubCannadb{records: map[string]map[string]interface{}{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.mu.Lock()
+		rec, ok := stub.records[r.URL.Query().Get("uri")]
+		var body []byte
+		if ok {
+			body, _ = json.Marshal(rec)
+		}
+		stub.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"RecordNotFound"}`))
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return stub, srv
+}
+
+func (s *stubCannadb) set(uri, indexedAt string, value map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[uri] = map[string]interface{}{"uri": uri, "indexedAt": indexedAt, "value": value}
+}
+
+func newCannadbServer(t *testing.T) (*testutil.TestServer, *stubCannadb, *testutil.Client, string) {
+	t.Helper()
+	db := testutil.NewTestDB(t)
+	stub, srv := newStubCannadb(t)
+	store := config.NewStore()
+	store.SetCannadbEnabled(1)
+	store.SetCannadbBaseURL(srv.URL)
+	server := testutil.NewTestServer(t, db, testutil.WithConfigStore(store))
+	apiKey := testutil.SeedAPIKey(t, db, "cannadb-sync-key")
+	return server, stub, server.NewClient(t), apiKey
+}
+
+type cannadbSyncResponse struct {
+	Applied bool `json:"applied"`
+	Checked int  `json:"checked"`
+	Strains []struct {
+		ID      int `json:"id"`
+		Changes []struct {
+			Field    string `json:"field"`
+			Current  string `json:"current"`
+			Incoming string `json:"incoming"`
+			Locked   bool   `json:"locked"`
+		} `json:"changes"`
+	} `json:"strains"`
+	Breeders []struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"breeders"`
+	Failed []struct {
+		Kind string `json:"kind"`
+		Name string `json:"name"`
+	} `json:"failed"`
+}
+
+func previewCannadbSync(t *testing.T, c *testutil.Client, apiKey string) cannadbSyncResponse {
+	t.Helper()
+	resp := harvestRequest(t, c, http.MethodGet, "/strains/cannadb/sync", apiKey, nil)
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var got cannadbSyncResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	return got
+}
+
+func importStubStrain(t *testing.T, c *testutil.Client, apiKey string) int {
+	t.Helper()
+	resp := harvestRequest(t, c, http.MethodPost, "/strains/cannadb/import", apiKey, map[string]interface{}{"uri": stubStrainURI})
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var got struct {
+		ID int `json:"id"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	return got.ID
+}
+
+func gelato(shortDesc string, sativa int, parents ...string) map[string]interface{} {
+	return map[string]interface{}{
+		"name": "Gelato", "breeder": stubBreederURI, "shortDescription": shortDesc,
+		"description": "Dessert strain.", "indicaSativa": sativa, "parentNames": parents,
+	}
+}
+
+func TestCannadbSyncHTTP_AppliesUnlockedChanges(t *testing.T) {
+	t.Parallel()
+
+	server, stub, c, apiKey := newCannadbServer(t)
+	db := server.DB
+	stub.set(stubBreederURI, "2026-01-01T00:00:00Z", map[string]interface{}{"name": "Cookies"})
+	stub.set(stubStrainURI, "2026-01-01T00:00:00Z", gelato("Sweet", 45, "Sunset Sherbet", "Thin Mint GSC"))
+	strainID := importStubStrain(t, c, apiKey)
+
+	preview := previewCannadbSync(t, c, apiKey)
+	assert.Equal(t, 2, preview.Checked)
+	assert.Empty(t, preview.Strains, "nothing changed on CannaDB yet")
+
+	// A local edit locks the edited field; an untouched field stays synced.
+	var breederID int
+	require.NoError(t, db.QueryRow(`SELECT breeder_id FROM strain WHERE id = $1`, strainID).Scan(&breederID))
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/strains/"+strconv.Itoa(strainID), apiKey, map[string]interface{}{
+		"name": "Gelato", "breeder_id": breederID, "indica": 55, "sativa": 45, "description": "Dessert strain.",
+		"short_desc": "My own notes", "seed_count": 0, "cycle_time": 0,
+	}), http.StatusOK)
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain_cannadb_locks WHERE strain_id = $1`, strainID))
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain_cannadb_locks WHERE strain_id = $1 AND field = 'short_desc'`, strainID))
+
+	// Linking a parent to a local strain survives the lineage rebuild.
+	parentID := testutil.SeedStrain(t, db, breederID, "Sunset Sherbet")
+	testutil.MustExec(t, db, `UPDATE strain_lineage SET parent_strain_id = $1 WHERE strain_id = $2 AND parent_name = 'Sunset Sherbet'`, parentID, strainID)
+
+	stub.set(stubBreederURI, "2026-02-01T00:00:00Z", map[string]interface{}{"name": "Cookies Fam"})
+	stub.set(stubStrainURI, "2026-02-01T00:00:00Z", gelato("Sweeter", 40, "Sunset Sherbet", "Thin Mint Cookies"))
+
+	preview = previewCannadbSync(t, c, apiKey)
+	require.Len(t, preview.Breeders, 1)
+	assert.Equal(t, "Cookies", preview.Breeders[0].Name)
+	require.Len(t, preview.Strains, 1)
+	changes := map[string][3]interface{}{}
+	for _, ch := range preview.Strains[0].Changes {
+		changes[ch.Field] = [3]interface{}{ch.Current, ch.Incoming, ch.Locked}
+	}
+	assert.Equal(t, map[string][3]interface{}{
+		"short_desc":    {"My own notes", "Sweeter", true},
+		"indica_sativa": {"55/45", "60/40", false},
+		"lineage":       {"Sunset Sherbet × Thin Mint GSC", "Sunset Sherbet × Thin Mint Cookies", false},
+	}, changes, "the breeder rename shows on the breeder, not as a strain change")
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain WHERE id = $1 AND sativa = 45`, strainID), "a preview writes nothing")
+
+	resp := harvestRequest(t, c, http.MethodPost, "/strains/cannadb/sync", apiKey, nil)
+	testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain
+		WHERE id = $1 AND sativa = 40 AND indica = 60 AND short_desc = 'My own notes' AND cannadb_indexed_at = '2026-02-01T00:00:00Z'`, strainID))
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM breeder WHERE id = $1 AND name = 'Cookies Fam'`, breederID))
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain_lineage WHERE strain_id = $1 AND parent_name = 'Thin Mint Cookies'`, strainID))
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain_lineage WHERE strain_id = $1 AND parent_strain_id = $2`, strainID, parentID))
+	assert.Empty(t, previewCannadbSync(t, c, apiKey).Strains, "synced records are up to date")
+
+	// Unlocking lets the next sync take the CannaDB value.
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodDelete, "/strains/"+strconv.Itoa(strainID)+"/cannadb-locks/short_desc", apiKey, nil), http.StatusOK)
+	stub.set(stubStrainURI, "2026-03-01T00:00:00Z", gelato("Sweeter", 40, "Sunset Sherbet", "Thin Mint Cookies"))
+	resp = harvestRequest(t, c, http.MethodPost, "/strains/cannadb/sync", apiKey, map[string]interface{}{"strain_ids": []int{strainID}})
+	testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain WHERE id = $1 AND short_desc = 'Sweeter'`, strainID))
+}
+
+func TestCannadbSyncHTTP_LocksAndFailures(t *testing.T) {
+	t.Parallel()
+
+	server, stub, c, apiKey := newCannadbServer(t)
+	db := server.DB
+	stub.set(stubBreederURI, "2026-01-01T00:00:00Z", map[string]interface{}{"name": "Cookies"})
+	stub.set(stubStrainURI, "2026-01-01T00:00:00Z", gelato("Sweet", 45, "Sunset Sherbet"))
+	strainID := importStubStrain(t, c, apiKey)
+	localID := testutil.SeedStrain(t, db, testutil.SeedBreeder(t, db, "Local"), "Homegrown")
+
+	// An edit that fails leaves nothing locked.
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/strains/"+strconv.Itoa(strainID), apiKey, map[string]interface{}{
+		"name": "Gelato", "breeder_id": 9999, "indica": 55, "sativa": 45, "description": "Dessert strain.",
+		"short_desc": "My own notes", "seed_count": 0, "cycle_time": 0,
+	}), http.StatusInternalServerError)
+	assert.Equal(t, 0, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain_cannadb_locks WHERE strain_id = $1`, strainID))
+
+	// Renaming an imported breeder locks its name; a sync keeps the rename.
+	var breederID int
+	require.NoError(t, db.QueryRow(`SELECT breeder_id FROM strain WHERE id = $1`, strainID).Scan(&breederID))
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/breeders/"+strconv.Itoa(breederID), apiKey,
+		map[string]interface{}{"breeder_name": "Cookies SF"}), http.StatusOK)
+
+	// Editing the lineage by hand locks it.
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/strains/"+strconv.Itoa(strainID)+"/lineage", apiKey,
+		map[string]interface{}{"parents": []map[string]interface{}{{"parent_name": "Sunset Sherbet"}, {"parent_name": "OG"}}}), http.StatusOK)
+
+	stub.set(stubBreederURI, "2026-02-01T00:00:00Z", map[string]interface{}{"name": "Cookies Fam"})
+	stub.set(stubStrainURI, "2026-02-01T00:00:00Z", gelato("Sweet", 45, "Sunset Sherbet", "Thin Mint GSC"))
+	resp := harvestRequest(t, c, http.MethodPost, "/strains/cannadb/sync", apiKey, nil)
+	testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM breeder WHERE id = $1 AND name = 'Cookies SF'`, breederID))
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain_lineage WHERE strain_id = $1 AND parent_name = 'OG'`, strainID))
+
+	locksPath := func(id int, field string) string {
+		return "/strains/" + strconv.Itoa(id) + "/cannadb-locks/" + field
+	}
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, locksPath(strainID, "url"), apiKey, nil), http.StatusOK)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, locksPath(strainID, "url"), apiKey, nil), http.StatusOK)
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain_cannadb_locks WHERE strain_id = $1 AND field = 'url'`, strainID))
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, locksPath(strainID, "seed_count"), apiKey, nil), http.StatusBadRequest)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, locksPath(localID, "name"), apiKey, nil), http.StatusBadRequest)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, locksPath(9999, "name"), apiKey, nil), http.StatusNotFound)
+
+	// Editing a strain that was never imported locks nothing.
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/strains/"+strconv.Itoa(localID)+"/lineage", apiKey,
+		map[string]interface{}{"parents": []map[string]interface{}{{"parent_name": "Mystery"}}}), http.StatusOK)
+	assert.Equal(t, 0, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain_cannadb_locks WHERE strain_id = $1`, localID))
+
+	// A record that has gone from CannaDB is reported, and the rest still sync.
+	stub.mu.Lock()
+	delete(stub.records, stubStrainURI)
+	stub.mu.Unlock()
+	preview := previewCannadbSync(t, c, apiKey)
+	require.Len(t, preview.Failed, 1)
+	assert.Equal(t, "strain", preview.Failed[0].Kind)
+	assert.Equal(t, "Gelato", preview.Failed[0].Name)
+}
@@ -169,6 +169,12 @@ const (
 	// DefaultStreamGrabIntervalMs is the default stream grab interval in
 	// milliseconds, used when the stored setting is unparseable.
 	DefaultStreamGrabIntervalMs = 3000
+	// DefaultDailyPhotoTime is the time of day (HH:MM, app timezone) the
+	// daily plant photo is taken when none has been configured.
+	DefaultDailyPhotoTime = "12:00"
+	// DefaultTaskReminderTime is the time of day (HH:MM, app timezone) the
+	// task reminder is sent when none has been configured.
+	DefaultTaskReminderTime = "08:00"
 	// MaxSensorScanResponseBytes caps the size of an inbound sensor-API
 	// response body (AC Infinity, EcoWitt). The HTTPTimeout values bound
 	// the wall clock, but a slow-drip server (notably user-supplied
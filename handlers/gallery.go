@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"isley/logger"
+	"isley/model"
+	"isley/model/types"
+	"isley/utils"
+)
+
+// GalleryImage is one photo in the cross-plant gallery, with the plant's
+// stage when it was taken derived from plant_status_log.
+type GalleryImage struct {
+	ID          uint             `json:"id"`
+	ImagePath   string           `json:"image_path"`
+	Description string           `json:"image_description"`
+	ImageDate   time.Time        `json:"image_date"`
+	PlantID     uint             `json:"plant_id"`
+	PlantName   string           `json:"plant_name"`
+	StrainID    uint             `json:"strain_id"`
+	StrainName  string           `json:"strain_name"`
+	ZoneName    string           `json:"zone_name"`
+	StageID     int              `json:"stage_id"`
+	Stage       string           `json:"stage"`
+	Tags        []types.ImageTag `json:"tags"`
+}
+
+// GalleryFilters captures the optional gallery filters. All fields default
+// to "no filter"; a photo matches TagIDs when it carries any of them.
+type GalleryFilters struct {
+	TagIDs   []int
+	StrainID *int
+	ZoneID   *int
+	StageID  *int
+	From     *time.Time
+	To       *time.Time
+}
+
+// GalleryPage is the paginated result returned by QueryGallery.
+type GalleryPage struct {
+	Images     []GalleryImage
+	Total      int
+	Page       int
+	PageSize   int
+	TotalPages int
+}
+
+// galleryPageSize is the number of photos rendered per page on /gallery.
+const galleryPageSize = 48
+
+// ParseGalleryFilters reads filter values from the request query string.
+// Like ParseActivityLogFilters, malformed values are ignored rather than
+// rejected so stale bookmarks still load.
+func ParseGalleryFilters(c *gin.Context) GalleryFilters {
+	var f GalleryFilters
+
+	for _, raw := range c.QueryArray("tag_id") {
+		for _, tok := range strings.Split(raw, ",") {
+			if id, err := strconv.Atoi(strings.TrimSpace(tok)); err == nil && id > 0 {
+				f.TagIDs = append(f.TagIDs, id)
+			}
+		}
+	}
+	positive := func(key string) *int {
+		if id, err := strconv.Atoi(strings.TrimSpace(c.Query(key))); err == nil && id > 0 {
+			return &id
+		}
+		return nil
+	}
+	f.StrainID = positive("strain_id")
+	f.ZoneID = positive("zone_id")
+	f.StageID = positive("status_id")
+
+	loc := AppTimeLocation(ConfigStoreFromContext(c).Timezone())
+	if v := strings.TrimSpace(c.Query("from")); v != "" {
+		if t, err := time.ParseInLocation(utils.LayoutDate, v, loc); err == nil {
+			f.From = &t
+		}
+	}
+	if v := strings.TrimSpace(c.Query("to")); v != "" {
+		if t, err := time.ParseInLocation(utils.LayoutDate, v, loc); err == nil {
+			// inclusive end-of-day
+			end := t.Add(24*time.Hour - time.Second)
+			f.To = &end
+		}
+	}
+	return f
+}
+
+// galleryStageExpr is the status a photo's plant was in when it was
+// taken: the latest plant_status_log entry on or before image_date, or
+// NULL when the photo predates the history. SQLite stores these dates in
+// mixed text layouts, so both sides are normalised through julianday
+// before they are compared.
+func galleryStageExpr() string {
+	if model.IsPostgres() {
+		return `(SELECT l.status_id FROM plant_status_log l
+		WHERE l.plant_id = pi.plant_id AND l.date <= pi.image_date
+		ORDER BY l.date DESC, l.id DESC LIMIT 1)`
+	}
+	return `(SELECT l.status_id FROM plant_status_log l
+		WHERE l.plant_id = pi.plant_id
+		  AND julianday(substr(l.date, 1, 19)) <= julianday(substr(pi.image_date, 1, 19))
+		ORDER BY julianday(substr(l.date, 1, 19)) DESC, l.id DESC LIMIT 1)`
+}
+
+// buildGalleryQuery assembles the FROM/WHERE clause shared by the count
+// and page queries, along with its arguments. Timelapse videos attached
+// to plants are not photos and are left out.
+func buildGalleryQuery(f GalleryFilters) (string, []interface{}) {
+	var args []interface{}
+	next := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where := []string{"pi.image_path NOT LIKE '%.mp4'", "pi.image_path NOT LIKE '%.webm'"}
+	if len(f.TagIDs) > 0 {
+		phs := make([]string, len(f.TagIDs))
+		for i, id := range f.TagIDs {
+			phs[i] = next(id)
+		}
+		where = append(where, "pi.id IN (SELECT image_id FROM plant_image_tags WHERE tag_id IN ("+strings.Join(phs, ",")+"))")
+	}
+	if f.StrainID != nil {
+		where = append(where, "p.strain_id = "+next(*f.StrainID))
+	}
+	if f.ZoneID != nil {
+		where = append(where, "p.zone_id = "+next(*f.ZoneID))
+	}
+	if f.StageID != nil {
+		where = append(where, galleryStageExpr()+" = "+next(*f.StageID))
+	}
+	if f.From != nil {
+		where = append(where, "pi.image_date >= "+next(*f.From))
+	}
+	if f.To != nil {
+		where = append(where, "pi.image_date <= "+next(*f.To))
+	}
+
+	return `
+FROM plant_images pi
+JOIN plant       p ON p.id = pi.plant_id
+LEFT JOIN strain s ON s.id = p.strain_id
+LEFT JOIN zones  z ON z.id = p.zone_id
+WHERE ` + strings.Join(where, " AND "), args
+}
+
+// QueryGallery fetches a page of gallery photos. `page` is 1-indexed;
+// pageSize<=0 falls back to galleryPageSize. A page past the end is
+// clamped to the last one.
+func QueryGallery(db *sql.DB, f GalleryFilters, page, pageSize int) (GalleryPage, error) {
+	if pageSize <= 0 {
+		pageSize = galleryPageSize
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	from, args := buildGalleryQuery(f)
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*)"+from, args...).Scan(&total); err != nil {
+		return GalleryPage{}, err
+	}
+	totalPages := max(1, (total+pageSize-1)/pageSize)
+	page = min(page, totalPages)
+
+	query := fmt.Sprintf(`
+SELECT g.id, g.image_path, g.image_description, g.image_date,
+       g.plant_id, g.plant_name, g.strain_id, g.strain_name, g.zone_name,
+       COALESCE(g.stage_id, 0), COALESCE(ps.status, '')
+FROM (
+    SELECT pi.id, pi.image_path, pi.image_description, pi.image_date,
+           p.id AS plant_id, COALESCE(p.name, '') AS plant_name,
+           COALESCE(s.id, 0) AS strain_id, COALESCE(s.name, '') AS strain_name,
+           COALESCE(z.name, '') AS zone_name,
+           %s AS stage_id%s
+) g
+LEFT JOIN plant_status ps ON ps.id = g.stage_id
+ORDER BY g.image_date DESC, g.id DESC
+LIMIT %d OFFSET %d`, galleryStageExpr(), from, pageSize, (page-1)*pageSize)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return GalleryPage{}, err
+	}
+	defer rows.Close()
+
+	var images []GalleryImage
+	for rows.Next() {
+		var img GalleryImage
+		if err := rows.Scan(&img.ID, &img.ImagePath, &img.Description, &img.ImageDate,
+			&img.PlantID, &img.PlantName, &img.StrainID, &img.StrainName, &img.ZoneName,
+			&img.StageID, &img.Stage); err != nil {
+			return GalleryPage{}, err
+		}
+		img.ImageDate = utils.AsLocal(img.ImageDate)
+		img.ImagePath = "/" + strings.ReplaceAll(img.ImagePath, "\\", "/")
+		images = append(images, img)
+	}
+	if err := rows.Err(); err != nil {
+		return GalleryPage{}, err
+	}
+
+	ids := make([]uint, len(images))
+	for i := range images {
+		ids[i] = images[i].ID
+	}
+	tags, err := loadImageTags(db, ids)
+	if err != nil {
+		return GalleryPage{}, err
+	}
+	for i := range images {
+		images[i].Tags = tags[images[i].ID]
+	}
+
+	return GalleryPage{
+		Images:     images,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// ListGalleryImages is the JSON endpoint behind the gallery: a page of
+// photos across all plants matching the filters.
+func ListGalleryImages(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("handler", "ListGalleryImages")
+
+	page := 1
+	if n, err := strconv.Atoi(c.Query("page")); err == nil && n > 0 {
+		page = n
+	}
+	pageSize := galleryPageSize
+	if n, err := strconv.Atoi(c.Query("page_size")); err == nil && n > 0 && n <= 1000 {
+		pageSize = n
+	}
+
+	result, err := QueryGallery(DBFromContext(c), ParseGalleryFilters(c), page, pageSize)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to query gallery")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	images := result.Images
+	if images == nil {
+		images = []GalleryImage{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"images":      images,
+		"total":       result.Total,
+		"page":        result.Page,
+		"page_size":   result.PageSize,
+		"total_pages": result.TotalPages,
+	})
+}
+
+// GalleryPageContext is the template context for a /gallery render.
+type GalleryPageContext struct {
+	Filters GalleryFilters
+	Result  GalleryPage
+	Tags    []types.ImageTag
+	// FromStr / ToStr / StrainIDStr / ZoneIDStr / StageIDStr re-surface the
+	// raw query values for the filter form, as on /activities.
+	FromStr     string
+	ToStr       string
+	StrainIDStr string
+	ZoneIDStr   string
+	StageIDStr  string
+	TagIDSet    map[int]bool
+}
+
+// BuildGalleryPageContext assembles the template context for a single
+// /gallery render.
+func BuildGalleryPageContext(db *sql.DB, filters GalleryFilters, page int, rawQuery map[string][]string) (GalleryPageContext, error) {
+	result, err := QueryGallery(db, filters, page, galleryPageSize)
+	if err != nil {
+		return GalleryPageContext{}, err
+	}
+	tags, err := loadImageTagCounts(db)
+	if err != nil {
+		return GalleryPageContext{}, err
+	}
+
+	ctx := GalleryPageContext{
+		Filters:  filters,
+		Result:   result,
+		Tags:     tags,
+		TagIDSet: make(map[int]bool),
+	}
+	for _, id := range filters.TagIDs {
+		ctx.TagIDSet[id] = true
+	}
+	first := func(key string) string {
+		if v, ok := rawQuery[key]; ok && len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	ctx.FromStr = first("from")
+	ctx.ToStr = first("to")
+	ctx.StrainIDStr = first("strain_id")
+	ctx.ZoneIDStr = first("zone_id")
+	ctx.StageIDStr = first("status_id")
+	return ctx, nil
+}
@@ -0,0 +1,343 @@
+package handlers_test
+
+// HTTP-layer tests for handlers/image_tags.go and handlers/gallery.go:
+// tagging photos via PUT /plant/images/:imageID/tags and the cross-plant
+// search behind GET /gallery/images.
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"isley/handlers"
+	"isley/tests/testutil"
+)
+
+// putImageTags sends PUT /plant/images/:imageID/tags and returns the
+// response. Callers must close the body.
+func putImageTags(t *testing.T, c *testutil.Client, apiKey string, imageID int, tags ...string) *http.Response {
+	t.Helper()
+	resp, err := c.Do(testutil.APIReq(t, http.MethodPut, c.BaseURL+"/plant/images/"+strconv.Itoa(imageID)+"/tags", apiKey,
+		testutil.JSONBody(t, map[string]interface{}{"tags": tags}), "application/json"))
+	require.NoError(t, err)
+	return resp
+}
+
+type galleryResponse struct {
+	Images []struct {
+		ID      int    `json:"id"`
+		PlantID int    `json:"plant_id"`
+		Stage   string `json:"stage"`
+		Tags    []struct {
+			Name string `json:"name"`
+		} `json:"tags"`
+	} `json:"images"`
+	Total int `json:"total"`
+}
+
+// galleryIDs fetches /gallery/images with the given query and returns the
+// image IDs, sorted.
+func galleryIDs(t *testing.T, c *testutil.Client, query string) []int {
+	t.Helper()
+	resp := c.Get("/gallery/images?" + query)
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var got galleryResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	ids := make([]int, 0, len(got.Images))
+	for _, img := range got.Images {
+		ids = append(ids, img.ID)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// ---------------------------------------------------------------------------
+// NormalizeImageTags
+// ---------------------------------------------------------------------------
+
+func TestNormalizeImageTags(t *testing.T) {
+	t.Parallel()
+
+	got, errKey := handlers.NormalizeImageTags([]string{" Pest ", "trichomes", "pest", "", "Leaf  Spot"})
+	assert.Empty(t, errKey)
+	assert.Equal(t, []string{"leaf spot", "pest", "trichomes"}, got)
+
+	_, errKey = handlers.NormalizeImageTags([]string{"this tag name is far too long to be accepted"})
+	assert.Equal(t, "api_image_tag_too_long", errKey)
+
+	many := make([]string, handlers.MaxTagsPerImage+1)
+	for i := range many {
+		many[i] = "tag" + strconv.Itoa(i)
+	}
+	_, errKey = handlers.NormalizeImageTags(many)
+	assert.Equal(t, "api_too_many_image_tags", errKey)
+}
+
+// ---------------------------------------------------------------------------
+// SetPlantImageTags
+// ---------------------------------------------------------------------------
+
+func TestImageTagsHTTP_SetReplacesAndCreates(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	uploadDir := t.TempDir()
+	server := testutil.NewTestServer(t, db, testutil.WithUploadDir(uploadDir), testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "image-tags-key")
+	imageID := seedProgressionImage(t, db, uploadDir, seedImagePlant(t, db), "a.jpg", "2026-01-10")
+	c := server.NewClient(t)
+
+	resp := putImageTags(t, c, apiKey, imageID, "Pest", "spider mites")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	testutil.DrainAndClose(resp)
+
+	resp = putImageTags(t, c, apiKey, imageID, "trichomes", "spider mites")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	testutil.DrainAndClose(resp)
+
+	var names []string
+	rows, err := db.Query(`SELECT t.name FROM plant_image_tags pit JOIN image_tags t ON t.id = pit.tag_id
+		WHERE pit.image_id = $1 ORDER BY t.name`, imageID)
+	require.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		names = append(names, name)
+	}
+	assert.Equal(t, []string{"spider mites", "trichomes"}, names)
+
+	// The custom tag was created once and the built-in tags are listed.
+	resp = c.Get("/image-tags")
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var tags []struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&tags))
+	counts := map[string]int{}
+	for _, tag := range tags {
+		counts[tag.Name] = tag.Count
+	}
+	assert.Equal(t, map[string]int{
+		"deficiency": 0, "harvest": 0, "pest": 0, "spider mites": 1, "training": 0, "trichomes": 1,
+	}, counts)
+}
+
+func TestImageTagsHTTP_Validation(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	uploadDir := t.TempDir()
+	server := testutil.NewTestServer(t, db, testutil.WithUploadDir(uploadDir))
+	apiKey := testutil.SeedAPIKey(t, db, "image-tags-invalid-key")
+	imageID := seedProgressionImage(t, db, uploadDir, seedImagePlant(t, db), "a.jpg", "2026-01-10")
+	c := server.NewClient(t)
+
+	resp := putImageTags(t, c, apiKey, imageID+100, "pest")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	testutil.DrainAndClose(resp)
+
+	resp = putImageTags(t, c, apiKey, imageID, "this tag name is far too long to be accepted")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	testutil.DrainAndClose(resp)
+
+	resp = putImageTags(t, c, "", imageID, "pest")
+	assert.NotEqual(t, http.StatusOK, resp.StatusCode, "tagging requires authentication")
+	testutil.DrainAndClose(resp)
+}
+
+func TestImageTagsHTTP_DeleteImageRemovesTags(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	uploadDir := t.TempDir()
+	server := testutil.NewTestServer(t, db, testutil.WithUploadDir(uploadDir))
+	apiKey := testutil.SeedAPIKey(t, db, "image-tags-delete-key")
+	imageID := seedProgressionImage(t, db, uploadDir, seedImagePlant(t, db), "a.jpg", "2026-01-10")
+	c := server.NewClient(t)
+
+	resp := putImageTags(t, c, apiKey, imageID, "pest")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	testutil.DrainAndClose(resp)
+
+	resp = c.APIDelete(t, "/plant/images/"+strconv.Itoa(imageID)+"/delete", apiKey)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	testutil.DrainAndClose(resp)
+
+	var n int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM plant_image_tags`).Scan(&n))
+	assert.Zero(t, n)
+}
+
+// ---------------------------------------------------------------------------
+// ListGalleryImages
+// ---------------------------------------------------------------------------
+
+func TestGalleryHTTP_Filters(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	uploadDir := t.TempDir()
+	server := testutil.NewTestServer(t, db, testutil.WithUploadDir(uploadDir), testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "gallery-key")
+
+	breederID := testutil.SeedBreeder(t, db, "B")
+	strainA := testutil.SeedStrain(t, db, breederID, "Strain A")
+	strainB := testutil.SeedStrain(t, db, breederID, "Strain B")
+	zoneA := testutil.SeedZone(t, db, "Tent A")
+	zoneB := testutil.SeedZone(t, db, "Tent B")
+	plantA := testutil.SeedPlant(t, db, "Plant A", strainA, zoneA)
+	plantB := testutil.SeedPlant(t, db, "Plant B", strainB, zoneB)
+
+	var vegID, flowerID int
+	require.NoError(t, db.QueryRow(`SELECT id FROM plant_status WHERE status = 'Veg'`).Scan(&vegID))
+	require.NoError(t, db.QueryRow(`SELECT id FROM plant_status WHERE status = 'Flower'`).Scan(&flowerID))
+	testutil.MustExec(t, db, `INSERT INTO plant_status_log (plant_id, status_id, date) VALUES ($1, $2, '2026-01-01')`, plantA, vegID)
+	testutil.MustExec(t, db, `INSERT INTO plant_status_log (plant_id, status_id, date) VALUES ($1, $2, '2026-02-01')`, plantA, flowerID)
+	testutil.MustExec(t, db, `INSERT INTO plant_status_log (plant_id, status_id, date) VALUES ($1, $2, '2026-01-01')`, plantB, vegID)
+
+	vegA := seedProgressionImage(t, db, uploadDir, plantA, "a1.jpg", "2026-01-15")
+	flowerA := seedProgressionImage(t, db, uploadDir, plantA, "a2.jpg", "2026-02-10")
+	vegB := seedProgressionImage(t, db, uploadDir, plantB, "b1.jpg", "2026-02-12")
+	testutil.MustExec(t, db, `INSERT INTO plant_images (plant_id, image_path, image_description, image_order, image_date)
+		VALUES ($1, 'uploads/plants/t.mp4', '', 0, '2026-02-01')`, plantA)
+
+	c := server.NewClient(t)
+	for _, tc := range []struct {
+		id   int
+		tags []string
+	}{
+		{vegA, []string{"training"}},
+		{flowerA, []string{"trichomes", "pest"}},
+		{vegB, []string{"pest"}},
+	} {
+		resp := putImageTags(t, c, apiKey, tc.id, tc.tags...)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		testutil.DrainAndClose(resp)
+	}
+	tagID := func(name string) string {
+		var id int
+		require.NoError(t, db.QueryRow(`SELECT id FROM image_tags WHERE name = $1`, name).Scan(&id))
+		return strconv.Itoa(id)
+	}
+
+	assert.Equal(t, []int{vegA, flowerA, vegB}, galleryIDs(t, c, ""), "videos are left out")
+	assert.Equal(t, []int{flowerA, vegB}, galleryIDs(t, c, "tag_id="+tagID("pest")))
+	assert.Equal(t, []int{vegA, flowerA}, galleryIDs(t, c, "tag_id="+tagID("training")+"&tag_id="+tagID("trichomes")))
+	assert.Equal(t, []int{vegB}, galleryIDs(t, c, "strain_id="+strconv.Itoa(strainB)))
+	assert.Equal(t, []int{vegA, flowerA}, galleryIDs(t, c, "zone_id="+strconv.Itoa(zoneA)))
+	assert.Equal(t, []int{vegA, vegB}, galleryIDs(t, c, "status_id="+strconv.Itoa(vegID)))
+	assert.Equal(t, []int{flowerA}, galleryIDs(t, c, "status_id="+strconv.Itoa(flowerID)))
+	assert.Equal(t, []int{flowerA, vegB}, galleryIDs(t, c, "from=2026-02-01"))
+	assert.Equal(t, []int{vegA, flowerA}, galleryIDs(t, c, "to=2026-02-10"))
+	assert.Equal(t, []int{flowerA}, galleryIDs(t, c, "tag_id="+tagID("pest")+"&zone_id="+strconv.Itoa(zoneA)))
+}
+
+func TestGalleryHTTP_StageAndPagination(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	uploadDir := t.TempDir()
+	server := testutil.NewTestServer(t, db, testutil.WithUploadDir(uploadDir), testutil.WithGuestMode())
+	plantID := seedImagePlant(t, db)
+
+	var flowerID int
+	require.NoError(t, db.QueryRow(`SELECT id FROM plant_status WHERE status = 'Flower'`).Scan(&flowerID))
+	testutil.MustExec(t, db, `INSERT INTO plant_status_log (plant_id, status_id, date) VALUES ($1, $2, '2026-01-05')`, plantID, flowerID)
+
+	seedProgressionImage(t, db, uploadDir, plantID, "early.jpg", "2026-01-02")
+	seedProgressionImage(t, db, uploadDir, plantID, "mid.jpg", "2026-01-06")
+	latest := seedProgressionImage(t, db, uploadDir, plantID, "late.jpg", "2026-01-09")
+
+	c := server.NewClient(t)
+	resp := c.Get("/gallery/images?page_size=2")
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var got galleryResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, 3, got.Total)
+	require.Len(t, got.Images, 2)
+	assert.Equal(t, latest, got.Images[0].ID, "newest photos come first")
+	assert.Equal(t, "Flower", got.Images[0].Stage)
+
+	resp2 := c.Get("/gallery/images?page_size=2&page=2")
+	defer testutil.DrainAndClose(resp2)
+	var page2 galleryResponse
+	require.NoError(t, json.NewDecoder(resp2.Body).Decode(&page2))
+	require.Len(t, page2.Images, 1)
+	assert.Empty(t, page2.Images[0].Stage, "photos before the first status entry have no stage")
+}
+
+func TestGalleryHTTP_StageFilterPaginates(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	plantID := seedImagePlant(t, db)
+
+	var vegID, flowerID int
+	require.NoError(t, db.QueryRow(`SELECT id FROM plant_status WHERE status = 'Veg'`).Scan(&vegID))
+	require.NoError(t, db.QueryRow(`SELECT id FROM plant_status WHERE status = 'Flower'`).Scan(&flowerID))
+	// Status dates arrive from the UI as datetime-local strings while photo
+	// dates are written as time.Time, so the two are stored differently.
+	testutil.MustExec(t, db, `INSERT INTO plant_status_log (plant_id, status_id, date) VALUES ($1, $2, '2026-03-01T08:00')`, plantID, vegID)
+	testutil.MustExec(t, db, `INSERT INTO plant_status_log (plant_id, status_id, date) VALUES ($1, $2, '2026-03-10T18:00')`, plantID, flowerID)
+	photo := func(at time.Time) int {
+		var id int
+		require.NoError(t, db.QueryRow(`INSERT INTO plant_images (plant_id, image_path, image_description, image_order, image_date)
+			VALUES ($1, 'uploads/plants/p.jpg', '', 0, $2) RETURNING id`, plantID, at).Scan(&id))
+		return id
+	}
+	vegEarly := photo(time.Date(2026, 3, 5, 12, 0, 0, 0, time.UTC))
+	vegLate := photo(time.Date(2026, 3, 10, 10, 0, 0, 0, time.UTC))
+	flowerEarly := photo(time.Date(2026, 3, 10, 20, 0, 0, 0, time.UTC))
+	flowerLate := photo(time.Date(2026, 3, 12, 9, 0, 0, 0, time.UTC))
+
+	c := server.NewClient(t)
+	page := func(query string) galleryResponse {
+		resp := c.Get("/gallery/images?" + query)
+		defer testutil.DrainAndClose(resp)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var got galleryResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		return got
+	}
+
+	flower := "status_id=" + strconv.Itoa(flowerID) + "&page_size=1"
+	first := page(flower)
+	assert.Equal(t, 2, first.Total)
+	require.Len(t, first.Images, 1)
+	assert.Equal(t, flowerLate, first.Images[0].ID)
+	assert.Equal(t, "Flower", first.Images[0].Stage)
+	second := page(flower + "&page=2")
+	require.Len(t, second.Images, 1)
+	assert.Equal(t, flowerEarly, second.Images[0].ID, "the same-day photo after the flip is in flower")
+	clamped := page(flower + "&page=9")
+	require.Len(t, clamped.Images, 1)
+	assert.Equal(t, flowerEarly, clamped.Images[0].ID, "a page past the end shows the last page")
+
+	assert.Equal(t, []int{vegEarly, vegLate}, galleryIDs(t, c, "status_id="+strconv.Itoa(vegID)))
+}
+
+func TestGalleryHTTP_PageRenders(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	uploadDir := t.TempDir()
+	server := testutil.NewTestServer(t, db, testutil.WithUploadDir(uploadDir), testutil.WithGuestMode())
+	seedProgressionImage(t, db, uploadDir, seedImagePlant(t, db), "a.jpg", "2026-01-10")
+
+	c := server.NewClient(t)
+	resp := c.Get("/gallery?tag_id=1&from=2026-01-01")
+	defer testutil.DrainAndClose(resp)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
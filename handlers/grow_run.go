@@ -0,0 +1,784 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"isley/logger"
+	"isley/model"
+	"isley/model/types"
+	"isley/utils"
+)
+
+// Grow run stages, in the order a run moves through them.
+const (
+	GrowRunStageVeg    = "veg"
+	GrowRunStageFlower = "flower"
+)
+
+const growRunColumns = `r.id, r.name, r.zone_id, COALESCE(z.name, ''), r.start_date, r.flip_date, r.end_date,
+	r.light_watts, r.canopy_area, r.notes,
+	(SELECT COUNT(*) FROM plant p WHERE p.grow_run_id = r.id)`
+
+// GrowRunCandidate is a plant offered for assignment on the run page.
+type GrowRunCandidate struct {
+	types.GrowRunPlant
+	ZoneName string
+	Assigned bool
+}
+
+// GrowRunPageContext is everything views/run.html renders.
+type GrowRunPageContext struct {
+	Report *types.GrowRunReport
+	// Candidates are the plants that may be assigned to the run: those in
+	// no run and those already in this one.
+	Candidates []GrowRunCandidate
+	// ZoneID is the run's zone, 0 when it has none, for preselecting the
+	// zone picker.
+	ZoneID int
+}
+
+// BuildGrowRunPageContext loads a run's report and assignable plants for
+// the run page. Report is nil when the run doesn't exist.
+func BuildGrowRunPageContext(c *gin.Context, runID string) (GrowRunPageContext, error) {
+	var ctx GrowRunPageContext
+	id, err := strconv.Atoi(runID)
+	if err != nil {
+		return ctx, err
+	}
+	db := DBFromContext(c)
+	store := ConfigStoreFromContext(c)
+	ctx.Report, err = LoadGrowRunReport(db, id, store.UnitPrefs(), AppTimeLocation(store.Timezone()), time.Now())
+	if err != nil || ctx.Report == nil {
+		return ctx, err
+	}
+	if ctx.Report.Run.ZoneID != nil {
+		ctx.ZoneID = *ctx.Report.Run.ZoneID
+	}
+	ctx.Candidates, err = loadGrowRunCandidates(db, id)
+	return ctx, err
+}
+
+// GrowRunsPageContext is everything views/runs.html renders.
+type GrowRunsPageContext struct {
+	Runs []types.GrowRunComparison
+	// ZoneID is the zone filter, 0 for every zone.
+	ZoneID int
+}
+
+// BuildGrowRunsPageContext loads the run comparison for the runs page,
+// filtered to the zone_id query parameter when one is given.
+func BuildGrowRunsPageContext(c *gin.Context) (GrowRunsPageContext, error) {
+	var ctx GrowRunsPageContext
+	zoneID, err := growRunZoneFilter(c)
+	if err != nil {
+		return ctx, err
+	}
+	if zoneID != nil {
+		ctx.ZoneID = *zoneID
+	}
+	store := ConfigStoreFromContext(c)
+	ctx.Runs, err = CompareGrowRuns(DBFromContext(c), zoneID, store.UnitPrefs(), AppTimeLocation(store.Timezone()), time.Now())
+	return ctx, err
+}
+
+// growRunZoneFilter parses the optional zone_id query parameter.
+func growRunZoneFilter(c *gin.Context) (*int, error) {
+	v := c.Query("zone_id")
+	if v == "" {
+		return nil, nil
+	}
+	id, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// loadGrowRuns loads the runs matching where (a condition on r, or ""),
+// oldest first.
+func loadGrowRuns(db *sql.DB, where string, args ...interface{}) ([]types.GrowRun, error) {
+	query := `SELECT ` + growRunColumns + ` FROM grow_runs r LEFT JOIN zones z ON z.id = r.zone_id`
+	if where != "" {
+		query += " WHERE " + where
+	}
+	rows, err := db.Query(query+" ORDER BY r.start_date, r.id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []types.GrowRun{}
+	for rows.Next() {
+		var r types.GrowRun
+		var zoneID sql.NullInt64
+		var flip, end sql.NullTime
+		var watts, area sql.NullFloat64
+		if err := rows.Scan(&r.ID, &r.Name, &zoneID, &r.ZoneName, &r.StartDate, &flip, &end,
+			&watts, &area, &r.Notes, &r.PlantCount); err != nil {
+			return nil, err
+		}
+		r.ZoneID = nullIntPtr(zoneID)
+		r.StartDate = utils.AsLocal(r.StartDate)
+		r.FlipDate, r.EndDate = nullLocalTimePtr(flip), nullLocalTimePtr(end)
+		r.LightWatts, r.CanopyArea = nullFloatPtr(watts), nullFloatPtr(area)
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
+
+// nullLocalTimePtr converts a nullable naive-local column to a pointer.
+func nullLocalTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	v := utils.AsLocal(t.Time)
+	return &v
+}
+
+// LoadGrowRunReport loads a run with its plants, yield figures, strain
+// breakdown and per-stage zone climate. Readings are converted to prefs,
+// stages are cut at midnight in loc, and a stage still under way ends at
+// now. It returns nil, nil when the run doesn't exist.
+func LoadGrowRunReport(db *sql.DB, runID int, prefs utils.UnitPrefs, loc *time.Location, now time.Time) (*types.GrowRunReport, error) {
+	runs, err := loadGrowRuns(db, "r.id = $1", runID)
+	if err != nil || len(runs) == 0 {
+		return nil, err
+	}
+	report := &types.GrowRunReport{Run: runs[0]}
+	if report.Plants, err = loadGrowRunPlants(db, runID); err != nil {
+		return nil, err
+	}
+	report.Metrics = computeGrowRunMetrics(report.Run, report.Plants, now)
+	report.Strains = growRunStrains(report.Plants, report.Metrics.YieldGrams)
+	if report.Stages, err = growRunStages(db, report.Run, prefs, loc, now); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// plantStatusOrderExpr orders plant_status_log rows by date on either
+// driver, for picking a plant's current status.
+func plantStatusOrderExpr() string {
+	if model.IsPostgres() {
+		return "EXTRACT(EPOCH FROM psl.date)"
+	}
+	return "strftime('%s', psl.date)"
+}
+
+// loadGrowRunPlants loads the plants assigned to a run with their current
+// status and recorded yield.
+func loadGrowRunPlants(db *sql.DB, runID int) ([]types.GrowRunPlant, error) {
+	rows, err := db.Query(fmt.Sprintf(`SELECT p.id, p.name, COALESCE(s.id, 0), COALESCE(s.name, ''),
+			COALESCE((SELECT ps.status FROM plant_status_log psl
+				JOIN plant_status ps ON ps.id = psl.status_id
+				WHERE psl.plant_id = p.id
+				ORDER BY %s DESC, psl.id DESC LIMIT 1), ''),
+			COALESCE(p.harvest_weight, 0)
+		FROM plant p
+		LEFT JOIN strain s ON s.id = p.strain_id
+		WHERE p.grow_run_id = $1
+		ORDER BY p.name, p.id`, plantStatusOrderExpr()), runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	plants := []types.GrowRunPlant{}
+	for rows.Next() {
+		var p types.GrowRunPlant
+		if err := rows.Scan(&p.ID, &p.Name, &p.StrainID, &p.StrainName, &p.Status, &p.HarvestWeight); err != nil {
+			return nil, err
+		}
+		plants = append(plants, p)
+	}
+	return plants, rows.Err()
+}
+
+// loadGrowRunCandidates loads the plants that are in no run or in runID,
+// marking the ones already assigned.
+func loadGrowRunCandidates(db *sql.DB, runID int) ([]GrowRunCandidate, error) {
+	rows, err := db.Query(`SELECT p.id, p.name, COALESCE(s.id, 0), COALESCE(s.name, ''), COALESCE(z.name, ''),
+			COALESCE(p.harvest_weight, 0), COALESCE(p.grow_run_id, 0)
+		FROM plant p
+		LEFT JOIN strain s ON s.id = p.strain_id
+		LEFT JOIN zones z ON z.id = p.zone_id
+		WHERE p.grow_run_id IS NULL OR p.grow_run_id = $1
+		ORDER BY p.name, p.id`, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := []GrowRunCandidate{}
+	for rows.Next() {
+		var cand GrowRunCandidate
+		var assigned int
+		if err := rows.Scan(&cand.ID, &cand.Name, &cand.StrainID, &cand.StrainName, &cand.ZoneName,
+			&cand.HarvestWeight, &assigned); err != nil {
+			return nil, err
+		}
+		cand.Assigned = assigned == runID
+		candidates = append(candidates, cand)
+	}
+	return candidates, rows.Err()
+}
+
+// growRunEnd is the last day a run covers: its end date, or today in loc
+// while it is still under way.
+func growRunEnd(run types.GrowRun, loc *time.Location, now time.Time) time.Time {
+	if run.EndDate != nil {
+		return *run.EndDate
+	}
+	n := now.In(loc)
+	return time.Date(n.Year(), n.Month(), n.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// computeGrowRunMetrics totals the yield of a run's plants and derives
+// the per-plant, per-watt and per-square-metre figures and stage lengths.
+func computeGrowRunMetrics(run types.GrowRun, plants []types.GrowRunPlant, now time.Time) types.GrowRunMetrics {
+	var m types.GrowRunMetrics
+	for _, p := range plants {
+		if p.HarvestWeight > 0 {
+			m.YieldGrams += p.HarvestWeight
+			m.HarvestedPlants++
+		}
+	}
+	m.YieldGrams = roundQuantity(m.YieldGrams)
+
+	end := growRunEnd(run, time.Local, now)
+	m.TotalDays = max(daysBetween(run.StartDate, end), 0)
+	if run.FlipDate != nil {
+		m.VegDays = max(daysBetween(run.StartDate, *run.FlipDate), 0)
+		m.FlowerDays = max(daysBetween(*run.FlipDate, end), 0)
+	} else {
+		m.VegDays = m.TotalDays
+	}
+
+	if m.YieldGrams <= 0 {
+		return m
+	}
+	m.GramsPerPlant = harvestRatio(m.YieldGrams, float64(m.HarvestedPlants))
+	if run.LightWatts != nil && *run.LightWatts > 0 {
+		m.GramsPerWatt = harvestRatio(m.YieldGrams, *run.LightWatts)
+	}
+	if run.CanopyArea != nil && *run.CanopyArea > 0 {
+		m.GramsPerSqM = harvestRatio(m.YieldGrams, *run.CanopyArea)
+	}
+	return m
+}
+
+// growRunStrains breaks a run's yield down by strain, largest first.
+func growRunStrains(plants []types.GrowRunPlant, total float64) []types.GrowRunStrain {
+	byID := map[int]*types.GrowRunStrain{}
+	strains := []*types.GrowRunStrain{}
+	for _, p := range plants {
+		s, ok := byID[p.StrainID]
+		if !ok {
+			s = &types.GrowRunStrain{StrainID: p.StrainID, StrainName: p.StrainName}
+			byID[p.StrainID] = s
+			strains = append(strains, s)
+		}
+		s.Plants++
+		s.YieldGrams += p.HarvestWeight
+	}
+
+	out := make([]types.GrowRunStrain, 0, len(strains))
+	for _, s := range strains {
+		s.YieldGrams = roundQuantity(s.YieldGrams)
+		s.GramsPerPlant = harvestRatio(s.YieldGrams, float64(s.Plants))
+		if total > 0 {
+			s.Share = harvestRatio(s.YieldGrams, total)
+		}
+		out = append(out, *s)
+	}
+	slices.SortStableFunc(out, func(a, b types.GrowRunStrain) int {
+		switch {
+		case a.YieldGrams > b.YieldGrams:
+			return -1
+		case a.YieldGrams < b.YieldGrams:
+			return 1
+		}
+		return strings.Compare(a.StrainName, b.StrainName)
+	})
+	return out
+}
+
+// growRunStages splits a run into its veg and flower stages and averages
+// the zone's temperature and VPD sensors over each from the hourly
+// rollups. A run that hasn't flipped has only a veg stage.
+func growRunStages(db *sql.DB, run types.GrowRun, prefs utils.UnitPrefs, loc *time.Location, now time.Time) ([]types.GrowRunStage, error) {
+	day := func(t time.Time) time.Time { return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc) }
+	end := day(growRunEnd(run, loc, now)).AddDate(0, 0, 1)
+	if run.EndDate == nil && now.Before(end) {
+		end = now
+	}
+
+	stages := []types.GrowRunStage{{Stage: GrowRunStageVeg, Start: run.StartDate, End: growRunEnd(run, loc, now)}}
+	if run.FlipDate != nil {
+		stages[0].End = *run.FlipDate
+		stages = append(stages, types.GrowRunStage{Stage: GrowRunStageFlower, Start: *run.FlipDate, End: growRunEnd(run, loc, now)})
+	}
+
+	temp, vpd, err := zoneClimateSensors(db, run.ZoneID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range stages {
+		st := &stages[i]
+		st.Days = max(daysBetween(st.Start, st.End), 0)
+		from, to := day(st.Start), day(st.End)
+		if i == len(stages)-1 {
+			to = end
+		}
+		if !to.After(from) {
+			continue
+		}
+		if st.Temperature, err = hourlyReadingStats(db, temp, from, to, prefs); err != nil {
+			return nil, err
+		}
+		if st.VPD, err = hourlyReadingStats(db, vpd, from, to, prefs); err != nil {
+			return nil, err
+		}
+	}
+	return stages, nil
+}
+
+// zoneClimateSensors finds the sensors a zone's climate is read from: the
+// temperature sensor chosen as its VPD input, or else its first
+// temperature sensor, and its derived VPD sensor. Either is nil when the
+// zone has none.
+func zoneClimateSensors(db *sql.DB, zoneID *int) (temp, vpd *harvestSensor, err error) {
+	if zoneID == nil {
+		return nil, nil, nil
+	}
+	scan := func(query string, args ...interface{}) (*harvestSensor, error) {
+		var s harvestSensor
+		err := db.QueryRow(query, args...).Scan(&s.id, &s.name, &s.unit)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &s, nil
+	}
+
+	temp, err = scan(`SELECT s.id, s.name, s.unit FROM zones z JOIN sensors s ON s.id = z.vpd_temp_sensor_id
+		WHERE z.id = $1`, *zoneID)
+	if err == nil && temp == nil {
+		temp, err = scan(`SELECT id, name, unit FROM sensors
+			WHERE zone_id = $1 AND LOWER(type) LIKE '%temp%'
+			ORDER BY id LIMIT 1`, *zoneID)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	vpd, err = scan(`SELECT id, name, unit FROM sensors WHERE source = 'derived' AND device = $1 AND type = 'VPD'`,
+		strconv.Itoa(*zoneID))
+	if err != nil {
+		return nil, nil, err
+	}
+	return temp, vpd, nil
+}
+
+// hourlyReadingStats summarises a sensor over [from, to) from
+// sensor_data_hourly, weighting each bucket's average by its sample
+// count. It gives nil when the sensor is nil or has no buckets in range.
+func hourlyReadingStats(db *sql.DB, s *harvestSensor, from, to time.Time, prefs utils.UnitPrefs) (*types.ReadingStats, error) {
+	if s == nil {
+		return nil, nil
+	}
+	// Buckets are hours of sensor_data.create_dt, which is UTC.
+	var avg, lo, hi sql.NullFloat64
+	var count sql.NullInt64
+	err := db.QueryRow(`SELECT SUM(avg_val * sample_count) / SUM(sample_count), MIN(min_val), MAX(max_val), SUM(sample_count)
+		FROM sensor_data_hourly
+		WHERE sensor_id = $1 AND bucket >= $2 AND bucket < $3`,
+		s.id, from.UTC().Format(utils.LayoutDB), to.UTC().Format(utils.LayoutDB)).Scan(&avg, &lo, &hi, &count)
+	if err != nil {
+		return nil, err
+	}
+	if !count.Valid || count.Int64 == 0 {
+		return nil, nil
+	}
+	stats := &types.ReadingStats{SensorName: s.name, Count: int(count.Int64)}
+	stats.Avg, stats.Unit = prefs.Convert(roundChartValue(avg.Float64), s.unit)
+	stats.Min, _ = prefs.Convert(lo.Float64, s.unit)
+	stats.Max, _ = prefs.Convert(hi.Float64, s.unit)
+	return stats, nil
+}
+
+// CompareGrowRuns builds the run-to-run comparison, optionally for one
+// zone, newest first. Each run is compared with the run before it in the
+// same zone.
+func CompareGrowRuns(db *sql.DB, zoneID *int, prefs utils.UnitPrefs, loc *time.Location, now time.Time) ([]types.GrowRunComparison, error) {
+	var runs []types.GrowRun
+	var err error
+	if zoneID != nil {
+		runs, err = loadGrowRuns(db, "r.zone_id = $1", *zoneID)
+	} else {
+		runs, err = loadGrowRuns(db, "")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]types.GrowRunComparison, 0, len(runs))
+	previous := map[int]int{} // zone ID -> index in out of its latest run
+	for _, run := range runs {
+		plants, err := loadGrowRunPlants(db, run.ID)
+		if err != nil {
+			return nil, err
+		}
+		row := types.GrowRunComparison{Run: run, Metrics: computeGrowRunMetrics(run, plants, now)}
+		if row.Stages, err = growRunStages(db, run, prefs, loc, now); err != nil {
+			return nil, err
+		}
+		if run.ZoneID != nil {
+			if i, ok := previous[*run.ZoneID]; ok {
+				prev := out[i]
+				row.PreviousRunID = prev.Run.ID
+				row.YieldChange = growRunChange(row.Metrics.YieldGrams, prev.Metrics.YieldGrams)
+				row.GramsPerWattChange = growRunChange(row.Metrics.GramsPerWatt, prev.Metrics.GramsPerWatt)
+			}
+			previous[*run.ZoneID] = len(out)
+		}
+		out = append(out, row)
+	}
+	slices.Reverse(out)
+	return out, nil
+}
+
+// growRunChange is the fractional change from prev to cur, nil when
+// either is missing.
+func growRunChange(cur, prev float64) *float64 {
+	if cur <= 0 || prev <= 0 {
+		return nil
+	}
+	v := harvestRatio(cur-prev, prev)
+	return &v
+}
+
+// ListGrowRuns returns every run, oldest first, optionally filtered by
+// the zone_id query parameter.
+func ListGrowRuns(c *gin.Context) {
+	zoneID, err := growRunZoneFilter(c)
+	if err != nil {
+		apiBadRequest(c, "api_invalid_zone_id")
+		return
+	}
+	var runs []types.GrowRun
+	if zoneID != nil {
+		runs, err = loadGrowRuns(DBFromContext(c), "r.zone_id = $1", *zoneID)
+	} else {
+		runs, err = loadGrowRuns(DBFromContext(c), "")
+	}
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "ListGrowRuns").Error("Failed to load grow runs")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusOK, runs)
+}
+
+// GetGrowRunReport returns a run's report.
+func GetGrowRunReport(c *gin.Context) {
+	runID, ok := growRunParam(c)
+	if !ok {
+		return
+	}
+	store := ConfigStoreFromContext(c)
+	report, err := LoadGrowRunReport(DBFromContext(c), runID, store.UnitPrefs(), AppTimeLocation(store.Timezone()), time.Now())
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "GetGrowRunReport").Error("Failed to load grow run report")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if report == nil {
+		apiNotFound(c, "api_grow_run_not_found")
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// CompareGrowRunsHandler returns the run-to-run comparison, optionally
+// for the zone given by the zone_id query parameter.
+func CompareGrowRunsHandler(c *gin.Context) {
+	zoneID, err := growRunZoneFilter(c)
+	if err != nil {
+		apiBadRequest(c, "api_invalid_zone_id")
+		return
+	}
+	store := ConfigStoreFromContext(c)
+	rows, err := CompareGrowRuns(DBFromContext(c), zoneID, store.UnitPrefs(), AppTimeLocation(store.Timezone()), time.Now())
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "CompareGrowRunsHandler").Error("Failed to compare grow runs")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// growRunParam parses the :id route parameter, sending a 400 when it is
+// malformed.
+func growRunParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_request")
+		return 0, false
+	}
+	return id, true
+}
+
+// growRunInput is the body of the run create and update requests.
+type growRunInput struct {
+	Name       string   `json:"name"`
+	ZoneID     *int     `json:"zone_id"`
+	StartDate  string   `json:"start_date"`
+	FlipDate   string   `json:"flip_date"`
+	EndDate    string   `json:"end_date"`
+	LightWatts *float64 `json:"light_watts"`
+	CanopyArea *float64 `json:"canopy_area"`
+	Notes      string   `json:"notes"`
+}
+
+func (in *growRunInput) validate() error {
+	in.Name = strings.TrimSpace(in.Name)
+	if err := utils.ValidateRequiredString("name", in.Name, utils.MaxNameLength); err != nil {
+		return err
+	}
+	if err := utils.ValidateStringLength("notes", in.Notes, utils.MaxNotesLength); err != nil {
+		return err
+	}
+	if err := validateRequiredDate("start_date", in.StartDate); err != nil {
+		return err
+	}
+	if in.FlipDate != "" {
+		if err := utils.ValidateDate("flip_date", in.FlipDate); err != nil {
+			return err
+		}
+	}
+	if in.EndDate != "" {
+		if err := utils.ValidateDate("end_date", in.EndDate); err != nil {
+			return err
+		}
+	}
+	// Dates are validated as YYYY-MM-DD, so they order as strings.
+	if in.FlipDate != "" && in.FlipDate < in.StartDate {
+		return errors.New("flip_date must not be before start_date")
+	}
+	if in.EndDate != "" && (in.EndDate < in.StartDate || (in.FlipDate != "" && in.EndDate < in.FlipDate)) {
+		return errors.New("end_date must not be before start_date or flip_date")
+	}
+	if in.ZoneID != nil && *in.ZoneID <= 0 {
+		in.ZoneID = nil
+	}
+	return validateNonNegative(map[string]*float64{
+		"light_watts": in.LightWatts,
+		"canopy_area": in.CanopyArea,
+	})
+}
+
+// bindGrowRunInput binds and validates a run body, checking that its zone
+// exists. It sends the error response itself.
+func bindGrowRunInput(c *gin.Context) (growRunInput, bool) {
+	var in growRunInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		apiBadRequest(c, "api_invalid_input")
+		return in, false
+	}
+	if err := in.validate(); err != nil {
+		apiBadRequest(c, err.Error())
+		return in, false
+	}
+	if in.ZoneID != nil {
+		var id int
+		err := DBFromContext(c).QueryRow("SELECT id FROM zones WHERE id = $1", *in.ZoneID).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			apiBadRequest(c, "api_zone_not_found")
+			return in, false
+		}
+		if err != nil {
+			logger.Log.WithError(err).Error("Failed to look up zone")
+			apiInternalError(c, "api_database_error")
+			return in, false
+		}
+	}
+	return in, true
+}
+
+// nullDateArg passes an optional YYYY-MM-DD date to the database.
+func nullDateArg(v string) interface{} {
+	if v == "" {
+		return nil
+	}
+	return v
+}
+
+// AddGrowRun creates a grow run.
+func AddGrowRun(c *gin.Context) {
+	in, ok := bindGrowRunInput(c)
+	if !ok {
+		return
+	}
+	var id int
+	err := DBFromContext(c).QueryRow(`INSERT INTO grow_runs (name, zone_id, start_date, flip_date, end_date,
+			light_watts, canopy_area, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+		in.Name, in.ZoneID, in.StartDate, nullDateArg(in.FlipDate), nullDateArg(in.EndDate),
+		in.LightWatts, in.CanopyArea, in.Notes).Scan(&id)
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "AddGrowRun").Error("Failed to create grow run")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id, "message": T(c, "api_grow_run_saved")})
+}
+
+// UpdateGrowRun replaces a grow run's details.
+func UpdateGrowRun(c *gin.Context) {
+	runID, ok := growRunParam(c)
+	if !ok {
+		return
+	}
+	in, ok := bindGrowRunInput(c)
+	if !ok {
+		return
+	}
+	res, err := DBFromContext(c).Exec(`UPDATE grow_runs SET name = $1, zone_id = $2, start_date = $3, flip_date = $4,
+			end_date = $5, light_watts = $6, canopy_area = $7, notes = $8, update_dt = CURRENT_TIMESTAMP
+		WHERE id = $9`,
+		in.Name, in.ZoneID, in.StartDate, nullDateArg(in.FlipDate), nullDateArg(in.EndDate),
+		in.LightWatts, in.CanopyArea, in.Notes, runID)
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "UpdateGrowRun").Error("Failed to update grow run")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		apiNotFound(c, "api_grow_run_not_found")
+		return
+	}
+	apiOK(c, "api_grow_run_saved")
+}
+
+// DeleteGrowRun deletes a grow run. Its plants are kept and left
+// unassigned.
+func DeleteGrowRun(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "DeleteGrowRun")
+	runID, ok := growRunParam(c)
+	if !ok {
+		return
+	}
+
+	tx, err := DBFromContext(c).Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+	if _, err := tx.Exec("UPDATE plant SET grow_run_id = NULL WHERE grow_run_id = $1", runID); err != nil {
+		fieldLogger.WithError(err).Error("Failed to unassign plants")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	for _, q := range []string{
+		"DELETE FROM task_completions WHERE task_id IN (SELECT id FROM tasks WHERE grow_run_id = $1)",
+		"DELETE FROM tasks WHERE grow_run_id = $1",
+	} {
+		if _, err := tx.Exec(q, runID); err != nil {
+			fieldLogger.WithError(err).Error("Failed to delete run tasks")
+			apiInternalError(c, "api_database_error")
+			return
+		}
+	}
+	res, err := tx.Exec("DELETE FROM grow_runs WHERE id = $1", runID)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to delete grow run")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		apiNotFound(c, "api_grow_run_not_found")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit grow run delete")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	apiOK(c, "api_grow_run_deleted")
+}
+
+// SetGrowRunPlants makes plant_ids the run's plants: listed plants are
+// moved into the run (out of any other) and plants no longer listed are
+// unassigned.
+func SetGrowRunPlants(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "SetGrowRunPlants")
+	runID, ok := growRunParam(c)
+	if !ok {
+		return
+	}
+	var in struct {
+		PlantIDs []int `json:"plant_ids"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		apiBadRequest(c, "api_invalid_input")
+		return
+	}
+	slices.Sort(in.PlantIDs)
+	in.PlantIDs = slices.Compact(in.PlantIDs)
+
+	db := DBFromContext(c)
+	var exists int
+	if err := db.QueryRow("SELECT id FROM grow_runs WHERE id = $1", runID).Scan(&exists); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			apiNotFound(c, "api_grow_run_not_found")
+			return
+		}
+		fieldLogger.WithError(err).Error("Failed to look up grow run")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+	if _, err := tx.Exec("UPDATE plant SET grow_run_id = NULL WHERE grow_run_id = $1", runID); err != nil {
+		fieldLogger.WithError(err).Error("Failed to unassign plants")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	for _, plantID := range in.PlantIDs {
+		res, err := tx.Exec("UPDATE plant SET grow_run_id = $1 WHERE id = $2", runID, plantID)
+		if err != nil {
+			fieldLogger.WithError(err).WithField("plantID", plantID).Error("Failed to assign plant")
+			apiInternalError(c, "api_database_error")
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			apiBadRequest(c, "api_plant_not_found")
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit plant assignment")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	apiOK(c, "api_grow_run_plants_saved")
+}
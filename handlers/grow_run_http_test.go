@@ -0,0 +1,441 @@
+package handlers_test
+
+// HTTP-layer tests for handlers/grow_run.go: run CRUD, plant assignment,
+// the run report (yield, per-strain breakdown, per-stage climate from the
+// hourly rollups) and the run-to-run comparison.
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"isley/tests/testutil"
+)
+
+type growRunReport struct {
+	Run struct {
+		ID         int     `json:"id"`
+		Name       string  `json:"name"`
+		ZoneID     *int    `json:"zone_id"`
+		FlipDate   *string `json:"flip_date"`
+		EndDate    *string `json:"end_date"`
+		PlantCount int     `json:"plant_count"`
+	} `json:"run"`
+	Metrics struct {
+		YieldGrams      float64 `json:"yield_grams"`
+		HarvestedPlants int     `json:"harvested_plants"`
+		GramsPerPlant   float64 `json:"grams_per_plant"`
+		GramsPerWatt    float64 `json:"grams_per_watt"`
+		GramsPerSqM     float64 `json:"grams_per_sqm"`
+		VegDays         int     `json:"veg_days"`
+		FlowerDays      int     `json:"flower_days"`
+		TotalDays       int     `json:"total_days"`
+	} `json:"metrics"`
+	Plants []struct {
+		ID     int    `json:"id"`
+		Status string `json:"status"`
+	} `json:"plants"`
+	Strains []struct {
+		StrainName    string  `json:"strain_name"`
+		Plants        int     `json:"plants"`
+		YieldGrams    float64 `json:"yield_grams"`
+		GramsPerPlant float64 `json:"grams_per_plant"`
+		Share         float64 `json:"share"`
+	} `json:"strains"`
+	Stages []growRunStage `json:"stages"`
+}
+
+type growRunStage struct {
+	Stage       string `json:"stage"`
+	Days        int    `json:"days"`
+	Temperature *struct {
+		Unit  string  `json:"unit"`
+		Avg   float64 `json:"avg"`
+		Min   float64 `json:"min"`
+		Max   float64 `json:"max"`
+		Count int     `json:"count"`
+	} `json:"temperature"`
+	VPD *struct {
+		Avg float64 `json:"avg"`
+	} `json:"vpd"`
+}
+
+// createGrowRun posts a run and returns its ID.
+func createGrowRun(t *testing.T, c *testutil.Client, apiKey string, body map[string]interface{}) int {
+	t.Helper()
+	resp := harvestRequest(t, c, http.MethodPost, "/runs", apiKey, body)
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created struct {
+		ID int `json:"id"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	require.NotZero(t, created.ID)
+	return created.ID
+}
+
+func getGrowRunReport(t *testing.T, c *testutil.Client, runID int) growRunReport {
+	t.Helper()
+	resp := c.Get("/runs/" + strconv.Itoa(runID) + "/report")
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var got growRunReport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	return got
+}
+
+func assignGrowRunPlants(t *testing.T, c *testutil.Client, apiKey string, runID int, plantIDs ...int) {
+	t.Helper()
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/runs/"+strconv.Itoa(runID)+"/plants", apiKey,
+		map[string]interface{}{"plant_ids": plantIDs}), http.StatusOK)
+}
+
+func plantGrowRunID(t *testing.T, db *sql.DB, plantID int) sql.NullInt64 {
+	t.Helper()
+	var id sql.NullInt64
+	require.NoError(t, db.QueryRow("SELECT grow_run_id FROM plant WHERE id = $1", plantID).Scan(&id))
+	return id
+}
+
+// ---------------------------------------------------------------------------
+// AddGrowRun / UpdateGrowRun
+// ---------------------------------------------------------------------------
+
+func TestGrowRunHTTP_CreateUpdateAndValidate(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "grow-run-crud-key")
+	zoneID := testutil.SeedZone(t, db, "Tent A")
+	c := server.NewClient(t)
+
+	runID := createGrowRun(t, c, apiKey, map[string]interface{}{
+		"name": " Spring 2026 ", "zone_id": zoneID, "start_date": "2026-01-01",
+	})
+	got := getGrowRunReport(t, c, runID)
+	assert.Equal(t, "Spring 2026", got.Run.Name)
+	require.NotNil(t, got.Run.ZoneID)
+	assert.Equal(t, zoneID, *got.Run.ZoneID)
+	assert.Nil(t, got.Run.FlipDate)
+	assert.Nil(t, got.Run.EndDate)
+	require.Len(t, got.Stages, 1, "a run that hasn't flipped is all veg")
+	assert.Equal(t, "veg", got.Stages[0].Stage)
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/runs/"+strconv.Itoa(runID), apiKey, map[string]interface{}{
+		"name": "Spring 2026", "zone_id": 0, "start_date": "2026-01-01", "flip_date": "2026-02-01", "end_date": "2026-03-01",
+	}), http.StatusOK)
+	got = getGrowRunReport(t, c, runID)
+	assert.Nil(t, got.Run.ZoneID, "zone 0 clears the zone")
+	assert.NotNil(t, got.Run.FlipDate)
+	assert.Equal(t, 31, got.Metrics.VegDays)
+	assert.Equal(t, 28, got.Metrics.FlowerDays)
+	assert.Equal(t, 59, got.Metrics.TotalDays)
+	require.Len(t, got.Stages, 2)
+	assert.Equal(t, "flower", got.Stages[1].Stage)
+
+	cases := []struct {
+		name string
+		body map[string]interface{}
+		want int
+	}{
+		{"missing name", map[string]interface{}{"start_date": "2026-01-01"}, http.StatusBadRequest},
+		{"missing start", map[string]interface{}{"name": "R"}, http.StatusBadRequest},
+		{"flip before start", map[string]interface{}{"name": "R", "start_date": "2026-01-10", "flip_date": "2026-01-01"}, http.StatusBadRequest},
+		{"end before flip", map[string]interface{}{"name": "R", "start_date": "2026-01-01", "flip_date": "2026-02-01", "end_date": "2026-01-20"}, http.StatusBadRequest},
+		{"negative watts", map[string]interface{}{"name": "R", "start_date": "2026-01-01", "light_watts": -1}, http.StatusBadRequest},
+		{"unknown zone", map[string]interface{}{"name": "R", "start_date": "2026-01-01", "zone_id": 999}, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, "/runs", apiKey, tc.body), tc.want)
+		})
+	}
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/runs/9999", apiKey,
+		map[string]interface{}{"name": "R", "start_date": "2026-01-01"}), http.StatusNotFound)
+	missing := c.Get("/runs/9999/report")
+	defer testutil.DrainAndClose(missing)
+	assert.Equal(t, http.StatusNotFound, missing.StatusCode)
+}
+
+// ---------------------------------------------------------------------------
+// SetGrowRunPlants / LoadGrowRunReport
+// ---------------------------------------------------------------------------
+
+func TestGrowRunHTTP_ReportTotalsYieldByStrain(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "grow-run-report-key")
+	breederID := testutil.SeedBreeder(t, db, "B")
+	blue := testutil.SeedStrain(t, db, breederID, "Blue")
+	gold := testutil.SeedStrain(t, db, breederID, "Gold")
+	zoneID := testutil.SeedZone(t, db, "Tent A")
+	p1 := testutil.SeedPlant(t, db, "Blue 1", blue, zoneID)
+	p2 := testutil.SeedPlant(t, db, "Blue 2", blue, zoneID)
+	p3 := testutil.SeedPlant(t, db, "Gold 1", gold, zoneID)
+	p4 := testutil.SeedPlant(t, db, "Gold 2", gold, zoneID)
+	for id, grams := range map[int]float64{p1: 100, p2: 60, p3: 240} {
+		testutil.MustExec(t, db, "UPDATE plant SET harvest_weight = $1 WHERE id = $2", grams, id)
+	}
+	testutil.MustExec(t, db, `INSERT INTO plant_status_log (plant_id, status_id, date) VALUES ($1, $2, '2026-03-01')`,
+		p1, plantStatusID(t, db, "Success"))
+	c := server.NewClient(t)
+
+	other := createGrowRun(t, c, apiKey, map[string]interface{}{"name": "Other", "start_date": "2025-06-01"})
+	assignGrowRunPlants(t, c, apiKey, other, p3)
+
+	runID := createGrowRun(t, c, apiKey, map[string]interface{}{
+		"name": "Spring", "zone_id": zoneID, "start_date": "2026-01-01", "flip_date": "2026-02-01",
+		"end_date": "2026-03-01", "light_watts": 400, "canopy_area": 1.2,
+	})
+	assignGrowRunPlants(t, c, apiKey, runID, p1, p2, p3, p4, p1)
+	assert.False(t, plantGrowRunID(t, db, p3).Int64 == int64(other), "assigning moves a plant out of its old run")
+
+	got := getGrowRunReport(t, c, runID)
+	assert.Equal(t, 4, got.Run.PlantCount)
+	require.Len(t, got.Plants, 4)
+	assert.Equal(t, "Success", got.Plants[0].Status)
+	assert.Equal(t, 400.0, got.Metrics.YieldGrams)
+	assert.Equal(t, 3, got.Metrics.HarvestedPlants)
+	assert.Equal(t, 133.333, got.Metrics.GramsPerPlant)
+	assert.Equal(t, 1.0, got.Metrics.GramsPerWatt)
+	assert.Equal(t, 333.333, got.Metrics.GramsPerSqM)
+
+	require.Len(t, got.Strains, 2)
+	assert.Equal(t, "Gold", got.Strains[0].StrainName, "strains are ordered by yield")
+	assert.Equal(t, 2, got.Strains[0].Plants)
+	assert.Equal(t, 240.0, got.Strains[0].YieldGrams)
+	assert.Equal(t, 120.0, got.Strains[0].GramsPerPlant)
+	assert.Equal(t, 0.6, got.Strains[0].Share)
+	assert.Equal(t, 160.0, got.Strains[1].YieldGrams)
+	assert.Equal(t, 0.4, got.Strains[1].Share)
+
+	// Replacing the list unassigns plants that are left out.
+	assignGrowRunPlants(t, c, apiKey, runID, p3)
+	assert.False(t, plantGrowRunID(t, db, p1).Valid)
+	assert.Equal(t, 1, getGrowRunReport(t, c, runID).Run.PlantCount)
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/runs/"+strconv.Itoa(runID)+"/plants", apiKey,
+		map[string]interface{}{"plant_ids": []int{p3, 9999}}), http.StatusBadRequest)
+	assert.Equal(t, int64(runID), plantGrowRunID(t, db, p3).Int64, "a failed assignment changes nothing")
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/runs/9999/plants", apiKey,
+		map[string]interface{}{"plant_ids": []int{p3}}), http.StatusNotFound)
+}
+
+func TestGrowRunHTTP_StageClimateFromHourlyRollups(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "grow-run-climate-key")
+	zoneID := testutil.SeedZone(t, db, "Tent A")
+	tempID := testutil.SeedSensor(t, db, "test", "tent", "temperature")
+	vpdID := testutil.SeedSensor(t, db, "derived", strconv.Itoa(zoneID), "VPD")
+	testutil.MustExec(t, db, "UPDATE sensors SET unit = '°C', zone_id = $1 WHERE id = $2", zoneID, tempID)
+	testutil.MustExec(t, db, "UPDATE sensors SET unit = 'kPa', zone_id = $1 WHERE id = $2", zoneID, vpdID)
+	testutil.MustExec(t, db, "UPDATE zones SET vpd_temp_sensor_id = $1 WHERE id = $2", tempID, zoneID)
+	for _, b := range []struct {
+		sensor        int
+		bucket        string
+		avg, min, max float64
+		samples       int
+	}{
+		{tempID, "2025-12-20 12:00:00", 40, 40, 40, 10}, // before the run, ignored
+		{tempID, "2026-01-10 12:00:00", 24, 22, 26, 4},
+		{tempID, "2026-01-11 12:00:00", 20, 18, 21, 12},
+		{tempID, "2026-02-10 12:00:00", 26, 25, 27, 6},
+		{vpdID, "2026-01-10 12:00:00", 0.9, 0.8, 1.0, 6},
+		{vpdID, "2026-02-10 12:00:00", 1.3, 1.2, 1.4, 6},
+		{tempID, "2026-03-05 12:00:00", 40, 40, 40, 10}, // after the run, ignored
+	} {
+		testutil.MustExec(t, db, `INSERT INTO sensor_data_hourly (sensor_id, bucket, min_val, max_val, avg_val, sample_count)
+			VALUES ($1, $2, $3, $4, $5, $6)`, b.sensor, b.bucket, b.min, b.max, b.avg, b.samples)
+	}
+	c := server.NewClient(t)
+
+	runID := createGrowRun(t, c, apiKey, map[string]interface{}{
+		"name": "Spring", "zone_id": zoneID, "start_date": "2026-01-01", "flip_date": "2026-02-01", "end_date": "2026-03-01",
+	})
+	got := getGrowRunReport(t, c, runID)
+	require.Len(t, got.Stages, 2)
+
+	veg := got.Stages[0]
+	assert.Equal(t, 31, veg.Days)
+	require.NotNil(t, veg.Temperature)
+	assert.Equal(t, "°C", veg.Temperature.Unit)
+	assert.Equal(t, 21.0, veg.Temperature.Avg, "bucket averages are weighted by sample count")
+	assert.Equal(t, 18.0, veg.Temperature.Min)
+	assert.Equal(t, 26.0, veg.Temperature.Max)
+	assert.Equal(t, 16, veg.Temperature.Count)
+	require.NotNil(t, veg.VPD)
+	assert.Equal(t, 0.9, veg.VPD.Avg)
+
+	flower := got.Stages[1]
+	require.NotNil(t, flower.Temperature)
+	assert.Equal(t, 26.0, flower.Temperature.Avg)
+	require.NotNil(t, flower.VPD)
+	assert.Equal(t, 1.3, flower.VPD.Avg)
+
+	// Without a zone there is nothing to read the climate from.
+	bare := createGrowRun(t, c, apiKey, map[string]interface{}{"name": "Bare", "start_date": "2026-01-01", "end_date": "2026-03-01"})
+	stages := getGrowRunReport(t, c, bare).Stages
+	require.Len(t, stages, 1)
+	assert.Nil(t, stages[0].Temperature)
+	assert.Nil(t, stages[0].VPD)
+}
+
+// ---------------------------------------------------------------------------
+// CompareGrowRuns
+// ---------------------------------------------------------------------------
+
+func TestGrowRunHTTP_CompareAgainstPreviousRunInZone(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "grow-run-compare-key")
+	strainID := testutil.SeedStrain(t, db, testutil.SeedBreeder(t, db, "B"), "S")
+	tentA := testutil.SeedZone(t, db, "Tent A")
+	tentB := testutil.SeedZone(t, db, "Tent B")
+	c := server.NewClient(t)
+
+	runs := []struct {
+		name, start string
+		zone        int
+		grams       float64
+	}{
+		{"A1", "2025-01-01", tentA, 200},
+		{"B1", "2025-03-01", tentB, 500},
+		{"A2", "2025-06-01", tentA, 300},
+	}
+	ids := map[string]int{}
+	for _, r := range runs {
+		id := createGrowRun(t, c, apiKey, map[string]interface{}{
+			"name": r.name, "zone_id": r.zone, "start_date": r.start, "end_date": r.start[:4] + "-12-31", "light_watts": 100,
+		})
+		plantID := testutil.SeedPlant(t, db, r.name+" plant", strainID, r.zone)
+		testutil.MustExec(t, db, "UPDATE plant SET harvest_weight = $1 WHERE id = $2", r.grams, plantID)
+		assignGrowRunPlants(t, c, apiKey, id, plantID)
+		ids[r.name] = id
+	}
+
+	type comparison struct {
+		Run struct {
+			Name string `json:"name"`
+		} `json:"run"`
+		PreviousRunID      int      `json:"previous_run_id"`
+		YieldChange        *float64 `json:"yield_change"`
+		GramsPerWattChange *float64 `json:"grams_per_watt_change"`
+	}
+	fetch := func(path string) []comparison {
+		resp := c.Get(path)
+		defer testutil.DrainAndClose(resp)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var rows []comparison
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&rows))
+		return rows
+	}
+
+	all := fetch("/runs/compare")
+	require.Len(t, all, 3)
+	assert.Equal(t, "A2", all[0].Run.Name, "newest first")
+	assert.Equal(t, ids["A1"], all[0].PreviousRunID, "compared within the zone, not with B1")
+	require.NotNil(t, all[0].YieldChange)
+	assert.Equal(t, 0.5, *all[0].YieldChange)
+	require.NotNil(t, all[0].GramsPerWattChange)
+	assert.Equal(t, 0.5, *all[0].GramsPerWattChange)
+	assert.Zero(t, all[1].PreviousRunID, "B1 is the first run in its zone")
+	assert.Nil(t, all[1].YieldChange)
+
+	zoneOnly := fetch("/runs/compare?zone_id=" + strconv.Itoa(tentB))
+	require.Len(t, zoneOnly, 1)
+	assert.Equal(t, "B1", zoneOnly[0].Run.Name)
+
+	bad := c.Get("/runs/compare?zone_id=abc")
+	defer testutil.DrainAndClose(bad)
+	assert.Equal(t, http.StatusBadRequest, bad.StatusCode)
+
+	list := c.Get("/runs/list?zone_id=" + strconv.Itoa(tentA))
+	defer testutil.DrainAndClose(list)
+	require.Equal(t, http.StatusOK, list.StatusCode)
+	var listed []struct {
+		Name string `json:"name"`
+	}
+	require.NoError(t, json.NewDecoder(list.Body).Decode(&listed))
+	require.Len(t, listed, 2)
+	assert.Equal(t, "A1", listed[0].Name, "the list is oldest first")
+}
+
+// ---------------------------------------------------------------------------
+// Deletes
+// ---------------------------------------------------------------------------
+
+func TestGrowRunHTTP_DeleteKeepsPlantsAndZoneDeleteKeepsRuns(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db)
+	apiKey := testutil.SeedAPIKey(t, db, "grow-run-delete-key")
+	plantID := seedImagePlant(t, db)
+	zoneID := testutil.SeedZone(t, db, "Old tent")
+	c := server.NewClient(t)
+
+	runID := createGrowRun(t, c, apiKey, map[string]interface{}{"name": "R", "start_date": "2026-01-01"})
+	assignGrowRunPlants(t, c, apiKey, runID, plantID)
+	expectHarvestStatus(t, c.APIDelete(t, "/runs/"+strconv.Itoa(runID), apiKey), http.StatusOK)
+	assert.False(t, plantGrowRunID(t, db, plantID).Valid, "the plant is kept, unassigned")
+	expectHarvestStatus(t, c.APIDelete(t, "/runs/"+strconv.Itoa(runID), apiKey), http.StatusNotFound)
+
+	historic := createGrowRun(t, c, apiKey, map[string]interface{}{"name": "H", "zone_id": zoneID, "start_date": "2025-01-01"})
+	expectHarvestStatus(t, c.APIDelete(t, "/zones/"+strconv.Itoa(zoneID), apiKey), http.StatusOK)
+	var zone sql.NullInt64
+	require.NoError(t, db.QueryRow("SELECT zone_id FROM grow_runs WHERE id = $1", historic).Scan(&zone))
+	assert.False(t, zone.Valid, "the run outlives its zone")
+}
+
+// ---------------------------------------------------------------------------
+// Pages
+// ---------------------------------------------------------------------------
+
+func TestGrowRunHTTP_PagesRender(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "grow-run-page-key")
+	testutil.SeedAdmin(t, db, "grow-run-page-pw")
+	plantID := seedImagePlant(t, db)
+	testutil.MustExec(t, db, `INSERT INTO plant_status_log (plant_id, status_id, date) VALUES ($1, $2, '2026-01-01')`,
+		plantID, plantStatusID(t, db, "Veg"))
+	c := server.NewClient(t)
+
+	runID := createGrowRun(t, c, apiKey, map[string]interface{}{
+		"name": "Tent A Spring", "start_date": "2026-01-01", "flip_date": "2026-02-01",
+	})
+	assignGrowRunPlants(t, c, apiKey, runID, plantID)
+
+	admin := server.LoginAsAdmin(t, "grow-run-page-pw")
+	for _, client := range []*testutil.Client{c, admin} {
+		for _, path := range []string{"/runs", "/runs/" + strconv.Itoa(runID), "/plant/" + strconv.Itoa(plantID)} {
+			resp := client.Get(path)
+			body, err := io.ReadAll(resp.Body)
+			testutil.DrainAndClose(resp)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, resp.StatusCode, path)
+			assert.Contains(t, string(body), "Tent A Spring", path)
+		}
+	}
+
+	missing := c.Get("/runs/9999")
+	defer testutil.DrainAndClose(missing)
+	assert.Equal(t, http.StatusOK, missing.StatusCode)
+}
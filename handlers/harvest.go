@@ -0,0 +1,966 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"isley/logger"
+	"isley/model/types"
+	"isley/utils"
+)
+
+// MaxYieldGrades bounds how many grades one harvest's yield may be split
+// into.
+const MaxYieldGrades = 20
+
+// harvestDeletes removes a plant's harvest and everything recorded under
+// it, children first. $1 is the plant ID. Production SQLite runs without
+// foreign-key enforcement, so the cascades in the schema can't be relied
+// on.
+var harvestDeletes = []struct {
+	table string
+	query string
+}{
+	{"harvest_yields", "DELETE FROM harvest_yields WHERE harvest_id IN (SELECT id FROM harvests WHERE plant_id = $1)"},
+	{"cure_jar_logs", "DELETE FROM cure_jar_logs WHERE jar_id IN (SELECT cj.id FROM cure_jars cj JOIN harvests h ON h.id = cj.harvest_id WHERE h.plant_id = $1)"},
+	{"cure_jars", "DELETE FROM cure_jars WHERE harvest_id IN (SELECT id FROM harvests WHERE plant_id = $1)"},
+	{"harvest_dry_logs", "DELETE FROM harvest_dry_logs WHERE harvest_id IN (SELECT id FROM harvests WHERE plant_id = $1)"},
+	{"harvests", "DELETE FROM harvests WHERE plant_id = $1"},
+}
+
+// HarvestPageContext is everything views/harvest.html renders.
+type HarvestPageContext struct {
+	Plant   types.Plant
+	Harvest *types.Harvest
+	// DefaultDate pre-fills the harvest date of a new record: the day the
+	// plant left flower, or today.
+	DefaultDate string
+	// TempSensorID and HumiditySensorID are the linked dry-room sensors,
+	// 0 when none, for preselecting the sensor pickers.
+	TempSensorID     int
+	HumiditySensorID int
+	// Reviews are the smoke reports written on the plant, newest first.
+	Reviews []types.StrainReview
+}
+
+// BuildHarvestPageContext loads a plant and its harvest for the harvest
+// page. Harvest is nil when none has been recorded yet.
+func BuildHarvestPageContext(c *gin.Context, plantID string) (HarvestPageContext, error) {
+	db := DBFromContext(c)
+	store := ConfigStoreFromContext(c)
+	ctx := HarvestPageContext{Plant: GetPlant(db, plantID)}
+
+	id, err := strconv.Atoi(plantID)
+	if err != nil {
+		return ctx, err
+	}
+	ctx.Harvest, err = LoadPlantHarvest(db, id, store.UnitPrefs(), AppTimeLocation(store.Timezone()))
+	if err != nil {
+		return ctx, err
+	}
+	if h := ctx.Harvest; h != nil {
+		if h.DryTempSensorID != nil {
+			ctx.TempSensorID = *h.DryTempSensorID
+		}
+		if h.DryHumiditySensorID != nil {
+			ctx.HumiditySensorID = *h.DryHumiditySensorID
+		}
+	}
+	if ctx.Reviews, err = LoadPlantReviews(db, id); err != nil {
+		return ctx, err
+	}
+	ctx.DefaultDate = time.Now().Format(utils.LayoutDate)
+	if !ctx.Plant.HarvestDate.IsZero() {
+		ctx.DefaultDate = ctx.Plant.HarvestDate.Format(utils.LayoutDate)
+	}
+	return ctx, nil
+}
+
+// GetPlantHarvest returns a plant's harvest with its drying log, cure
+// jars, graded yield, derived metrics and dry-room conditions.
+func GetPlantHarvest(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "GetPlantHarvest")
+
+	plantID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_plant_id")
+		return
+	}
+	store := ConfigStoreFromContext(c)
+	h, err := LoadPlantHarvest(DBFromContext(c), plantID, store.UnitPrefs(), AppTimeLocation(store.Timezone()))
+	if err != nil {
+		fieldLogger.WithError(err).WithField("plantID", plantID).Error("Failed to load harvest")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if h == nil {
+		apiNotFound(c, "api_harvest_not_found")
+		return
+	}
+	c.JSON(http.StatusOK, h)
+}
+
+// LoadPlantHarvest loads the harvest of plantID with everything recorded
+// under it. Sensor readings are converted to prefs and bucketed into days
+// in loc. It returns nil, nil when the plant has no harvest.
+func LoadPlantHarvest(db *sql.DB, plantID int, prefs utils.UnitPrefs, loc *time.Location) (*types.Harvest, error) {
+	var h types.Harvest
+	var wet, trim, dry, watts, area sql.NullFloat64
+	var tempSensor, humSensor sql.NullInt64
+	var startDT time.Time
+	var plantWeight float64
+	err := db.QueryRow(`SELECT h.id, h.plant_id, h.harvest_date, h.wet_weight, h.trim_weight, h.dry_weight,
+			h.light_watts, h.canopy_area, h.dry_temp_sensor_id, h.dry_humidity_sensor_id, h.notes,
+			p.start_dt, COALESCE(p.harvest_weight, 0)
+		FROM harvests h JOIN plant p ON p.id = h.plant_id
+		WHERE h.plant_id = $1`, plantID).Scan(&h.ID, &h.PlantID, &h.HarvestDate, &wet, &trim, &dry,
+		&watts, &area, &tempSensor, &humSensor, &h.Notes, &startDT, &plantWeight)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	h.HarvestDate = utils.AsLocal(h.HarvestDate)
+	h.WetWeight, h.TrimWeight, h.DryWeight = nullFloatPtr(wet), nullFloatPtr(trim), nullFloatPtr(dry)
+	h.LightWatts, h.CanopyArea = nullFloatPtr(watts), nullFloatPtr(area)
+	h.DryTempSensorID, h.DryHumiditySensorID = nullIntPtr(tempSensor), nullIntPtr(humSensor)
+
+	if h.DryLogs, err = loadHarvestDryLogs(db, h.ID); err != nil {
+		return nil, err
+	}
+	if h.Jars, err = loadCureJars(db, h.ID); err != nil {
+		return nil, err
+	}
+	if h.Yields, err = loadHarvestYields(db, h.ID); err != nil {
+		return nil, err
+	}
+
+	h.Metrics = computeHarvestMetrics(&h, gradedYield(h.Yields), utils.AsLocal(startDT), plantWeight)
+	if n := len(h.DryLogs); n > 0 {
+		h.Metrics.DryDays = daysBetween(h.HarvestDate, h.DryLogs[n-1].Date)
+	}
+	if err := attachDryConditions(db, &h, prefs, loc); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+func loadHarvestDryLogs(db *sql.DB, harvestID int) ([]types.HarvestDryLog, error) {
+	rows, err := db.Query("SELECT id, date, weight, notes FROM harvest_dry_logs WHERE harvest_id = $1 ORDER BY date, id", harvestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	logs := []types.HarvestDryLog{}
+	for rows.Next() {
+		var l types.HarvestDryLog
+		if err := rows.Scan(&l.ID, &l.Date, &l.Weight, &l.Notes); err != nil {
+			return nil, err
+		}
+		l.Date = utils.AsLocal(l.Date)
+		logs = append(logs, l)
+	}
+	return logs, rows.Err()
+}
+
+func loadCureJars(db *sql.DB, harvestID int) ([]types.CureJar, error) {
+	rows, err := db.Query("SELECT id, name, weight, sealed_date, notes FROM cure_jars WHERE harvest_id = $1 ORDER BY sealed_date, id", harvestID)
+	if err != nil {
+		return nil, err
+	}
+	jars := []types.CureJar{}
+	index := map[int]int{}
+	for rows.Next() {
+		var j types.CureJar
+		var weight sql.NullFloat64
+		if err := rows.Scan(&j.ID, &j.Name, &weight, &j.SealedDate, &j.Notes); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		j.Weight = nullFloatPtr(weight)
+		j.SealedDate = utils.AsLocal(j.SealedDate)
+		j.Logs = []types.CureJarLog{}
+		index[j.ID] = len(jars)
+		jars = append(jars, j)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(jars) == 0 {
+		return jars, err
+	}
+
+	logRows, err := db.Query(`SELECT l.id, l.jar_id, l.date, l.burped, l.humidity, l.notes FROM cure_jar_logs l
+		JOIN cure_jars j ON j.id = l.jar_id
+		WHERE j.harvest_id = $1 ORDER BY l.date, l.id`, harvestID)
+	if err != nil {
+		return nil, err
+	}
+	defer logRows.Close()
+	for logRows.Next() {
+		var l types.CureJarLog
+		var humidity sql.NullFloat64
+		if err := logRows.Scan(&l.ID, &l.JarID, &l.Date, &l.Burped, &humidity, &l.Notes); err != nil {
+			return nil, err
+		}
+		l.Humidity = nullFloatPtr(humidity)
+		l.Date = utils.AsLocal(l.Date)
+		if i, ok := index[l.JarID]; ok {
+			jars[i].Logs = append(jars[i].Logs, l)
+		}
+	}
+	return jars, logRows.Err()
+}
+
+func loadHarvestYields(db *sql.DB, harvestID int) ([]types.HarvestYield, error) {
+	rows, err := db.Query("SELECT id, grade, weight FROM harvest_yields WHERE harvest_id = $1 ORDER BY id", harvestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	yields := []types.HarvestYield{}
+	for rows.Next() {
+		var y types.HarvestYield
+		if err := rows.Scan(&y.ID, &y.Grade, &y.Weight); err != nil {
+			return nil, err
+		}
+		yields = append(yields, y)
+	}
+	return yields, rows.Err()
+}
+
+// gradedYield totals the graded yield, or nil when no grades are recorded.
+func gradedYield(yields []types.HarvestYield) *float64 {
+	if len(yields) == 0 {
+		return nil
+	}
+	var total float64
+	for _, y := range yields {
+		total += y.Weight
+	}
+	return &total
+}
+
+// computeHarvestMetrics derives the yield figures of h. The yield is the
+// graded total when grades are recorded, else the dry weight, else
+// fallback (the plant's harvest_weight, for plants harvested before
+// harvest tracking existed).
+func computeHarvestMetrics(h *types.Harvest, graded *float64, startDT time.Time, fallback float64) types.HarvestMetrics {
+	var m types.HarvestMetrics
+	switch {
+	case graded != nil:
+		m.YieldGrams = *graded
+	case h.DryWeight != nil:
+		m.YieldGrams = *h.DryWeight
+	default:
+		m.YieldGrams = fallback
+	}
+	m.YieldGrams = roundQuantity(m.YieldGrams)
+
+	if h.WetWeight != nil && *h.WetWeight > 0 && h.DryWeight != nil {
+		m.DryWetRatio = harvestRatio(*h.DryWeight, *h.WetWeight)
+	}
+	if !startDT.IsZero() && !h.HarvestDate.IsZero() {
+		m.GrowDays = daysBetween(startDT, h.HarvestDate)
+	}
+	if m.YieldGrams <= 0 {
+		return m
+	}
+	if m.GrowDays > 0 {
+		m.GramsPerDay = harvestRatio(m.YieldGrams, float64(m.GrowDays))
+	}
+	if h.LightWatts != nil && *h.LightWatts > 0 {
+		m.GramsPerWatt = harvestRatio(m.YieldGrams, *h.LightWatts)
+	}
+	if h.CanopyArea != nil && *h.CanopyArea > 0 {
+		m.GramsPerSqM = harvestRatio(m.YieldGrams, *h.CanopyArea)
+	}
+	return m
+}
+
+func harvestRatio(num, den float64) float64 {
+	return roundQuantity(num / den)
+}
+
+// daysBetween counts the calendar days from a to b, ignoring the time of
+// day so DST changes can't shift the result.
+func daysBetween(a, b time.Time) int {
+	da := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	db := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(db.Sub(da).Hours() / 24)
+}
+
+// attachPlantHarvestMetrics fills in the Harvest metrics of every plant in
+// the list that has a harvest record.
+func attachPlantHarvestMetrics(db *sql.DB, plants []types.PlantListResponse) error {
+	if len(plants) == 0 {
+		return nil
+	}
+	phs := make([]string, len(plants))
+	args := make([]interface{}, len(plants))
+	byID := make(map[int]int, len(plants))
+	for i, p := range plants {
+		phs[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = p.ID
+		byID[p.ID] = i
+	}
+	rows, err := db.Query(`SELECT h.plant_id, h.harvest_date, h.wet_weight, h.dry_weight, h.light_watts, h.canopy_area,
+			p.start_dt, (SELECT SUM(y.weight) FROM harvest_yields y WHERE y.harvest_id = h.id)
+		FROM harvests h JOIN plant p ON p.id = h.plant_id
+		WHERE h.plant_id IN (`+strings.Join(phs, ",")+`)`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var h types.Harvest
+		var wet, dry, watts, area, graded sql.NullFloat64
+		var startDT time.Time
+		if err := rows.Scan(&h.PlantID, &h.HarvestDate, &wet, &dry, &watts, &area, &startDT, &graded); err != nil {
+			return err
+		}
+		h.HarvestDate = utils.AsLocal(h.HarvestDate)
+		h.WetWeight, h.DryWeight = nullFloatPtr(wet), nullFloatPtr(dry)
+		h.LightWatts, h.CanopyArea = nullFloatPtr(watts), nullFloatPtr(area)
+		i, ok := byID[h.PlantID]
+		if !ok {
+			continue
+		}
+		m := computeHarvestMetrics(&h, nullFloatPtr(graded), utils.AsLocal(startDT), plants[i].HarvestWeight)
+		plants[i].Harvest = &m
+	}
+	return rows.Err()
+}
+
+// harvestSensor is a dry-room sensor as its readings are summarised.
+type harvestSensor struct {
+	id   int
+	name string
+	unit string
+}
+
+// attachDryConditions fills in the dry-room conditions of h: the average
+// temperature and humidity on each dry-log day, and the average, minimum
+// and maximum from the harvest date to the last dry log. Harvests without
+// linked sensors or dry logs are left alone.
+func attachDryConditions(db *sql.DB, h *types.Harvest, prefs utils.UnitPrefs, loc *time.Location) error {
+	if len(h.DryLogs) == 0 || (h.DryTempSensorID == nil && h.DryHumiditySensorID == nil) {
+		return nil
+	}
+	temp, err := lookupHarvestSensor(db, h.DryTempSensorID)
+	if err != nil {
+		return err
+	}
+	hum, err := lookupHarvestSensor(db, h.DryHumiditySensorID)
+	if err != nil {
+		return err
+	}
+
+	day := func(t time.Time) time.Time { return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc) }
+	from := day(h.HarvestDate)
+	to := day(h.DryLogs[len(h.DryLogs)-1].Date).AddDate(0, 0, 1)
+
+	conditions := &types.DryConditions{}
+	if conditions.Temperature, err = sensorReadingStats(db, temp, from, to, prefs); err != nil {
+		return err
+	}
+	if conditions.Humidity, err = sensorReadingStats(db, hum, from, to, prefs); err != nil {
+		return err
+	}
+	if conditions.Temperature == nil && conditions.Humidity == nil {
+		return nil
+	}
+	h.DryConditions = conditions
+
+	for i := range h.DryLogs {
+		start := day(h.DryLogs[i].Date)
+		end := start.AddDate(0, 0, 1)
+		if conditions.Temperature != nil {
+			stats, err := sensorReadingStats(db, temp, start, end, prefs)
+			if err != nil {
+				return err
+			}
+			if stats != nil {
+				avg := math.Round(stats.Avg*10) / 10
+				h.DryLogs[i].AvgTemperature = &avg
+			}
+		}
+		if conditions.Humidity != nil {
+			stats, err := sensorReadingStats(db, hum, start, end, prefs)
+			if err != nil {
+				return err
+			}
+			if stats != nil {
+				avg := math.Round(stats.Avg*10) / 10
+				h.DryLogs[i].AvgHumidity = &avg
+			}
+		}
+	}
+	return nil
+}
+
+// lookupHarvestSensor loads the name and unit of a linked sensor. A nil
+// id, or a sensor that no longer exists, gives nil.
+func lookupHarvestSensor(db *sql.DB, id *int) (*harvestSensor, error) {
+	if id == nil {
+		return nil, nil
+	}
+	s := harvestSensor{id: *id}
+	err := db.QueryRow("SELECT name, unit FROM sensors WHERE id = $1", *id).Scan(&s.name, &s.unit)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// sensorReadingStats summarises a sensor's readings in [from, to). It
+// returns nil when the sensor is nil or has no readings in the window.
+func sensorReadingStats(db *sql.DB, s *harvestSensor, from, to time.Time, prefs utils.UnitPrefs) (*types.ReadingStats, error) {
+	if s == nil {
+		return nil, nil
+	}
+	// sensor_data.create_dt is written as UTC.
+	var avg, lo, hi sql.NullFloat64
+	var count int
+	err := db.QueryRow(`SELECT AVG(value), MIN(value), MAX(value), COUNT(*) FROM sensor_data
+		WHERE sensor_id = $1 AND create_dt >= $2 AND create_dt < $3`,
+		s.id, from.UTC().Format(utils.LayoutDB), to.UTC().Format(utils.LayoutDB)).Scan(&avg, &lo, &hi, &count)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	stats := &types.ReadingStats{SensorName: s.name, Count: count}
+	stats.Avg, stats.Unit = prefs.Convert(roundChartValue(avg.Float64), s.unit)
+	stats.Min, _ = prefs.Convert(lo.Float64, s.unit)
+	stats.Max, _ = prefs.Convert(hi.Float64, s.unit)
+	return stats, nil
+}
+
+func nullIntPtr(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int64)
+	return &v
+}
+
+// syncPlantHarvestWeight copies the harvest's final yield to
+// plant.harvest_weight, which the plant pages and lists show: the graded
+// total when grades are recorded, otherwise the dry weight. With neither
+// the plant's weight is left as it was.
+func syncPlantHarvestWeight(db *sql.DB, plantID int) error {
+	var harvestID int
+	var dry sql.NullFloat64
+	err := db.QueryRow("SELECT id, dry_weight FROM harvests WHERE plant_id = $1", plantID).Scan(&harvestID, &dry)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var graded sql.NullFloat64
+	if err := db.QueryRow("SELECT SUM(weight) FROM harvest_yields WHERE harvest_id = $1", harvestID).Scan(&graded); err != nil {
+		return err
+	}
+	weight := graded
+	if !weight.Valid {
+		weight = dry
+	}
+	if !weight.Valid {
+		return nil
+	}
+	_, err = db.Exec("UPDATE plant SET harvest_weight = $1 WHERE id = $2", roundQuantity(weight.Float64), plantID)
+	return err
+}
+
+// harvestIDForPlant returns the ID of plantID's harvest, sending a 404
+// when there is none. ok is false once a response has been written.
+func harvestIDForPlant(c *gin.Context, plantID int) (int, bool) {
+	var id int
+	err := DBFromContext(c).QueryRow("SELECT id FROM harvests WHERE plant_id = $1", plantID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		apiNotFound(c, "api_harvest_not_found")
+		return 0, false
+	}
+	if err != nil {
+		logger.Log.WithError(err).WithField("plantID", plantID).Error("Failed to look up harvest")
+		apiInternalError(c, "api_database_error")
+		return 0, false
+	}
+	return id, true
+}
+
+// SaveHarvest creates or updates a plant's harvest record and keeps
+// plant.harvest_weight in step with it.
+func SaveHarvest(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "SaveHarvest")
+
+	plantID, ok := plantIDParam(c)
+	if !ok {
+		return
+	}
+	var input struct {
+		HarvestDate         string   `json:"harvest_date"`
+		WetWeight           *float64 `json:"wet_weight"`
+		TrimWeight          *float64 `json:"trim_weight"`
+		DryWeight           *float64 `json:"dry_weight"`
+		LightWatts          *float64 `json:"light_watts"`
+		CanopyArea          *float64 `json:"canopy_area"`
+		DryTempSensorID     *int     `json:"dry_temp_sensor_id"`
+		DryHumiditySensorID *int     `json:"dry_humidity_sensor_id"`
+		Notes               string   `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apiBadRequest(c, "api_invalid_input")
+		return
+	}
+	if err := validateRequiredDate("harvest_date", input.HarvestDate); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
+	if err := validateNonNegative(map[string]*float64{
+		"wet_weight":  input.WetWeight,
+		"trim_weight": input.TrimWeight,
+		"dry_weight":  input.DryWeight,
+		"light_watts": input.LightWatts,
+		"canopy_area": input.CanopyArea,
+	}); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
+	if err := utils.ValidateStringLength("notes", input.Notes, utils.MaxNotesLength); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
+
+	db := DBFromContext(c)
+	var exists int
+	if err := db.QueryRow("SELECT id FROM plant WHERE id = $1", plantID).Scan(&exists); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			apiNotFound(c, "api_plant_not_found")
+			return
+		}
+		fieldLogger.WithError(err).Error("Failed to look up plant")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	// A cleared sensor select submits 0.
+	for _, id := range []**int{&input.DryTempSensorID, &input.DryHumiditySensorID} {
+		if *id != nil && **id <= 0 {
+			*id = nil
+		}
+		if *id == nil {
+			continue
+		}
+		if err := db.QueryRow("SELECT id FROM sensors WHERE id = $1", **id).Scan(&exists); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				apiBadRequest(c, "api_sensor_not_found")
+				return
+			}
+			fieldLogger.WithError(err).Error("Failed to look up sensor")
+			apiInternalError(c, "api_database_error")
+			return
+		}
+	}
+
+	var harvestID int
+	err := db.QueryRow("SELECT id FROM harvests WHERE plant_id = $1", plantID).Scan(&harvestID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		err = db.QueryRow(`INSERT INTO harvests (plant_id, harvest_date, wet_weight, trim_weight, dry_weight,
+				light_watts, canopy_area, dry_temp_sensor_id, dry_humidity_sensor_id, notes)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`,
+			plantID, input.HarvestDate, input.WetWeight, input.TrimWeight, input.DryWeight,
+			input.LightWatts, input.CanopyArea, input.DryTempSensorID, input.DryHumiditySensorID, input.Notes).Scan(&harvestID)
+	case err == nil:
+		_, err = db.Exec(`UPDATE harvests SET harvest_date = $1, wet_weight = $2, trim_weight = $3, dry_weight = $4,
+				light_watts = $5, canopy_area = $6, dry_temp_sensor_id = $7, dry_humidity_sensor_id = $8,
+				notes = $9, update_dt = CURRENT_TIMESTAMP
+			WHERE id = $10`,
+			input.HarvestDate, input.WetWeight, input.TrimWeight, input.DryWeight,
+			input.LightWatts, input.CanopyArea, input.DryTempSensorID, input.DryHumiditySensorID, input.Notes, harvestID)
+	}
+	if err != nil {
+		fieldLogger.WithError(err).WithField("plantID", plantID).Error("Failed to save harvest")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if err := syncPlantHarvestWeight(db, plantID); err != nil {
+		fieldLogger.WithError(err).WithField("plantID", plantID).Error("Failed to update plant harvest weight")
+	}
+	c.JSON(http.StatusOK, gin.H{"id": harvestID, "message": T(c, "api_harvest_saved")})
+}
+
+// DeleteHarvest removes a plant's harvest record with its drying log,
+// cure jars and graded yield. plant.harvest_weight is left as it was.
+func DeleteHarvest(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "DeleteHarvest")
+
+	plantID, ok := plantIDParam(c)
+	if !ok {
+		return
+	}
+	if _, ok := harvestIDForPlant(c, plantID); !ok {
+		return
+	}
+
+	tx, err := DBFromContext(c).Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+	for _, d := range harvestDeletes {
+		if _, err := tx.Exec(d.query, plantID); err != nil {
+			fieldLogger.WithError(err).WithField("table", d.table).Error("Failed to delete records")
+			apiInternalError(c, "api_database_error")
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit harvest delete")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	apiOK(c, "api_harvest_deleted")
+}
+
+// AddHarvestDryLog records a weigh-in while the harvest dries.
+func AddHarvestDryLog(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "AddHarvestDryLog")
+
+	plantID, ok := plantIDParam(c)
+	if !ok {
+		return
+	}
+	var input struct {
+		Date   string   `json:"date"`
+		Weight *float64 `json:"weight"`
+		Notes  string   `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || input.Weight == nil {
+		apiBadRequest(c, "api_invalid_input")
+		return
+	}
+	if err := validateRequiredDate("date", input.Date); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
+	if err := validateNonNegative(map[string]*float64{"weight": input.Weight}); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
+	if err := utils.ValidateStringLength("notes", input.Notes, utils.MaxNotesLength); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
+	harvestID, ok := harvestIDForPlant(c, plantID)
+	if !ok {
+		return
+	}
+
+	var id int
+	if err := DBFromContext(c).QueryRow("INSERT INTO harvest_dry_logs (harvest_id, date, weight, notes) VALUES ($1, $2, $3, $4) RETURNING id",
+		harvestID, input.Date, *input.Weight, input.Notes).Scan(&id); err != nil {
+		fieldLogger.WithError(err).WithField("plantID", plantID).Error("Failed to save dry log")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id, "message": T(c, "api_dry_log_saved")})
+}
+
+// DeleteHarvestDryLog removes one drying-log entry.
+func DeleteHarvestDryLog(c *gin.Context) {
+	deleteHarvestRow(c, "DeleteHarvestDryLog", "DELETE FROM harvest_dry_logs WHERE id = $1", "api_dry_log_deleted")
+}
+
+// deleteHarvestRow runs a single-row delete keyed by the :id parameter.
+func deleteHarvestRow(c *gin.Context, handler, query, okKey string) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_request")
+		return
+	}
+	res, err := DBFromContext(c).Exec(query, id)
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", handler).Error("Failed to delete record")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		apiNotFound(c, "api_record_not_found")
+		return
+	}
+	apiOK(c, okKey)
+}
+
+// cureJarInput is the body of the cure jar create and update requests.
+type cureJarInput struct {
+	Name       string   `json:"name"`
+	Weight     *float64 `json:"weight"`
+	SealedDate string   `json:"sealed_date"`
+	Notes      string   `json:"notes"`
+}
+
+func (in cureJarInput) validate() error {
+	if err := utils.ValidateRequiredString("name", in.Name, utils.MaxNameLength); err != nil {
+		return err
+	}
+	if err := validateRequiredDate("sealed_date", in.SealedDate); err != nil {
+		return err
+	}
+	if err := validateNonNegative(map[string]*float64{"weight": in.Weight}); err != nil {
+		return err
+	}
+	return utils.ValidateStringLength("notes", in.Notes, utils.MaxNotesLength)
+}
+
+// AddCureJar adds a cure jar to a plant's harvest.
+func AddCureJar(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "AddCureJar")
+
+	plantID, ok := plantIDParam(c)
+	if !ok {
+		return
+	}
+	var input cureJarInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apiBadRequest(c, "api_invalid_input")
+		return
+	}
+	if err := input.validate(); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
+	harvestID, ok := harvestIDForPlant(c, plantID)
+	if !ok {
+		return
+	}
+
+	var id int
+	if err := DBFromContext(c).QueryRow("INSERT INTO cure_jars (harvest_id, name, weight, sealed_date, notes) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		harvestID, strings.TrimSpace(input.Name), input.Weight, input.SealedDate, input.Notes).Scan(&id); err != nil {
+		fieldLogger.WithError(err).WithField("plantID", plantID).Error("Failed to save cure jar")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id, "message": T(c, "api_cure_jar_saved")})
+}
+
+// UpdateCureJar edits a cure jar.
+func UpdateCureJar(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "UpdateCureJar")
+
+	jarID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_request")
+		return
+	}
+	var input cureJarInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apiBadRequest(c, "api_invalid_input")
+		return
+	}
+	if err := input.validate(); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
+
+	res, err := DBFromContext(c).Exec("UPDATE cure_jars SET name = $1, weight = $2, sealed_date = $3, notes = $4 WHERE id = $5",
+		strings.TrimSpace(input.Name), input.Weight, input.SealedDate, input.Notes, jarID)
+	if err != nil {
+		fieldLogger.WithError(err).WithField("jarID", jarID).Error("Failed to update cure jar")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		apiNotFound(c, "api_cure_jar_not_found")
+		return
+	}
+	apiOK(c, "api_cure_jar_saved")
+}
+
+// DeleteCureJar removes a cure jar and its log.
+func DeleteCureJar(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "DeleteCureJar")
+
+	jarID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_request")
+		return
+	}
+	tx, err := DBFromContext(c).Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+	if _, err := tx.Exec("DELETE FROM cure_jar_logs WHERE jar_id = $1", jarID); err != nil {
+		fieldLogger.WithError(err).Error("Failed to delete cure jar logs")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	res, err := tx.Exec("DELETE FROM cure_jars WHERE id = $1", jarID)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to delete cure jar")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		apiNotFound(c, "api_cure_jar_not_found")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit cure jar delete")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	apiOK(c, "api_cure_jar_deleted")
+}
+
+// AddCureJarLog records a jar check: a burp and/or an RH reading.
+func AddCureJarLog(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "AddCureJarLog")
+
+	jarID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_request")
+		return
+	}
+	var input struct {
+		Date     string   `json:"date"`
+		Burped   bool     `json:"burped"`
+		Humidity *float64 `json:"humidity"`
+		Notes    string   `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apiBadRequest(c, "api_invalid_input")
+		return
+	}
+	if err := validateRequiredDate("date", input.Date); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
+	if input.Humidity != nil {
+		if err := utils.ValidateFiniteFloat64("humidity", *input.Humidity); err != nil || *input.Humidity < 0 || *input.Humidity > 100 {
+			apiBadRequest(c, "api_invalid_humidity")
+			return
+		}
+	}
+	if err := utils.ValidateStringLength("notes", input.Notes, utils.MaxNotesLength); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
+
+	db := DBFromContext(c)
+	var exists int
+	if err := db.QueryRow("SELECT id FROM cure_jars WHERE id = $1", jarID).Scan(&exists); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			apiNotFound(c, "api_cure_jar_not_found")
+			return
+		}
+		fieldLogger.WithError(err).Error("Failed to look up cure jar")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	var id int
+	if err := db.QueryRow("INSERT INTO cure_jar_logs (jar_id, date, burped, humidity, notes) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		jarID, input.Date, input.Burped, input.Humidity, input.Notes).Scan(&id); err != nil {
+		fieldLogger.WithError(err).WithField("jarID", jarID).Error("Failed to save cure jar log")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id, "message": T(c, "api_jar_log_saved")})
+}
+
+// DeleteCureJarLog removes one jar check.
+func DeleteCureJarLog(c *gin.Context) {
+	deleteHarvestRow(c, "DeleteCureJarLog", "DELETE FROM cure_jar_logs WHERE id = $1", "api_jar_log_deleted")
+}
+
+// SetHarvestYields replaces the graded yield of a plant's harvest. The
+// grades' total becomes the plant's harvest weight; an empty list clears
+// the grades and falls back to the dry weight.
+func SetHarvestYields(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "SetHarvestYields")
+
+	plantID, ok := plantIDParam(c)
+	if !ok {
+		return
+	}
+	var input struct {
+		Yields []struct {
+			Grade  string  `json:"grade"`
+			Weight float64 `json:"weight"`
+		} `json:"yields"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apiBadRequest(c, "api_invalid_input")
+		return
+	}
+	if len(input.Yields) > MaxYieldGrades {
+		apiBadRequest(c, "api_too_many_yield_grades")
+		return
+	}
+	for i := range input.Yields {
+		y := &input.Yields[i]
+		y.Grade = strings.TrimSpace(y.Grade)
+		if err := utils.ValidateRequiredString("grade", y.Grade, utils.MaxNameLength); err != nil {
+			apiBadRequest(c, err.Error())
+			return
+		}
+		if err := validateNonNegative(map[string]*float64{"weight": &y.Weight}); err != nil {
+			apiBadRequest(c, err.Error())
+			return
+		}
+	}
+	harvestID, ok := harvestIDForPlant(c, plantID)
+	if !ok {
+		return
+	}
+
+	db := DBFromContext(c)
+	tx, err := db.Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+	if _, err := tx.Exec("DELETE FROM harvest_yields WHERE harvest_id = $1", harvestID); err != nil {
+		fieldLogger.WithError(err).Error("Failed to clear harvest yields")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	for _, y := range input.Yields {
+		if _, err := tx.Exec("INSERT INTO harvest_yields (harvest_id, grade, weight) VALUES ($1, $2, $3)", harvestID, y.Grade, y.Weight); err != nil {
+			fieldLogger.WithError(err).Error("Failed to save harvest yield")
+			apiInternalError(c, "api_database_error")
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit harvest yields")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if err := syncPlantHarvestWeight(db, plantID); err != nil {
+		fieldLogger.WithError(err).WithField("plantID", plantID).Error("Failed to update plant harvest weight")
+	}
+	apiOK(c, "api_harvest_yields_saved")
+}
@@ -0,0 +1,467 @@
+package handlers_test
+
+// HTTP-layer tests for handlers/harvest.go: the harvest record, drying
+// log with dry-room sensor averages, cure jars, graded yield, and the
+// derived metrics surfaced on GET /plants/harvested.
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"isley/tests/testutil"
+)
+
+type harvestSummary struct {
+	ID        int      `json:"id"`
+	WetWeight *float64 `json:"wet_weight"`
+	DryWeight *float64 `json:"dry_weight"`
+	DryLogs   []struct {
+		Weight         float64  `json:"weight"`
+		AvgTemperature *float64 `json:"avg_temperature"`
+		AvgHumidity    *float64 `json:"avg_humidity"`
+	} `json:"dry_logs"`
+	Jars []struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+		Logs []struct {
+			Burped   bool     `json:"burped"`
+			Humidity *float64 `json:"humidity"`
+		} `json:"logs"`
+	} `json:"jars"`
+	Yields  []struct{ Grade string } `json:"yields"`
+	Metrics struct {
+		YieldGrams   float64 `json:"yield_grams"`
+		DryWetRatio  float64 `json:"dry_wet_ratio"`
+		GrowDays     int     `json:"grow_days"`
+		DryDays      int     `json:"dry_days"`
+		GramsPerDay  float64 `json:"grams_per_day"`
+		GramsPerWatt float64 `json:"grams_per_watt"`
+		GramsPerSqM  float64 `json:"grams_per_sqm"`
+	} `json:"metrics"`
+	DryConditions *struct {
+		Temperature *struct {
+			Unit  string  `json:"unit"`
+			Avg   float64 `json:"avg"`
+			Min   float64 `json:"min"`
+			Max   float64 `json:"max"`
+			Count int     `json:"count"`
+		} `json:"temperature"`
+		Humidity *struct {
+			Avg float64 `json:"avg"`
+		} `json:"humidity"`
+	} `json:"dry_conditions"`
+}
+
+// harvestRequest sends an API-key request with a JSON body.
+func harvestRequest(t *testing.T, c *testutil.Client, method, path, apiKey string, body interface{}) *http.Response {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		reader = testutil.JSONBody(t, body)
+	}
+	resp, err := c.Do(testutil.APIReq(t, method, c.BaseURL+path, apiKey, reader, "application/json"))
+	require.NoError(t, err)
+	return resp
+}
+
+func expectHarvestStatus(t *testing.T, resp *http.Response, want int) {
+	t.Helper()
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, want, resp.StatusCode)
+}
+
+func getHarvestSummary(t *testing.T, c *testutil.Client, plantID int) harvestSummary {
+	t.Helper()
+	resp := c.Get("/plant/" + strconv.Itoa(plantID) + "/harvest/summary")
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var got harvestSummary
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	return got
+}
+
+func plantHarvestWeight(t *testing.T, db *sql.DB, plantID int) float64 {
+	t.Helper()
+	var w float64
+	require.NoError(t, db.QueryRow("SELECT harvest_weight FROM plant WHERE id = $1", plantID).Scan(&w))
+	return w
+}
+
+// ---------------------------------------------------------------------------
+// SaveHarvest / GetPlantHarvest
+// ---------------------------------------------------------------------------
+
+func TestHarvestHTTP_SaveComputesMetricsAndSyncsWeight(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "harvest-save-key")
+	plantID := seedImagePlant(t, db) // starts 2026-01-01
+	c := server.NewClient(t)
+	base := "/plant/" + strconv.Itoa(plantID) + "/harvest"
+
+	missing := c.Get(base + "/summary")
+	defer testutil.DrainAndClose(missing)
+	assert.Equal(t, http.StatusNotFound, missing.StatusCode)
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, base, apiKey, map[string]interface{}{
+		"harvest_date": "2026-03-01",
+		"wet_weight":   500,
+		"trim_weight":  60,
+		"dry_weight":   120,
+		"light_watts":  100,
+		"canopy_area":  0.5,
+	}), http.StatusOK)
+
+	got := getHarvestSummary(t, c, plantID)
+	assert.Equal(t, 120.0, got.Metrics.YieldGrams)
+	assert.Equal(t, 0.24, got.Metrics.DryWetRatio)
+	assert.Equal(t, 59, got.Metrics.GrowDays)
+	assert.Equal(t, 2.034, got.Metrics.GramsPerDay)
+	assert.Equal(t, 1.2, got.Metrics.GramsPerWatt)
+	assert.Equal(t, 240.0, got.Metrics.GramsPerSqM)
+	assert.Equal(t, 120.0, plantHarvestWeight(t, db, plantID))
+
+	// Saving again updates the same record; clearing a weight clears it.
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, base, apiKey, map[string]interface{}{
+		"harvest_date": "2026-03-01",
+		"dry_weight":   110,
+	}), http.StatusOK)
+	again := getHarvestSummary(t, c, plantID)
+	assert.Equal(t, got.ID, again.ID)
+	assert.Nil(t, again.WetWeight)
+	assert.Zero(t, again.Metrics.DryWetRatio)
+	assert.Zero(t, again.Metrics.GramsPerWatt)
+	assert.Equal(t, 110.0, plantHarvestWeight(t, db, plantID))
+}
+
+func TestHarvestHTTP_SaveValidation(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db)
+	apiKey := testutil.SeedAPIKey(t, db, "harvest-validate-key")
+	plantID := seedImagePlant(t, db)
+	c := server.NewClient(t)
+	base := "/plant/" + strconv.Itoa(plantID) + "/harvest"
+
+	cases := []struct {
+		name string
+		path string
+		body map[string]interface{}
+		want int
+	}{
+		{"missing date", base, map[string]interface{}{"dry_weight": 10}, http.StatusBadRequest},
+		{"bad date", base, map[string]interface{}{"harvest_date": "soon"}, http.StatusBadRequest},
+		{"negative weight", base, map[string]interface{}{"harvest_date": "2026-03-01", "wet_weight": -1}, http.StatusBadRequest},
+		{"unknown sensor", base, map[string]interface{}{"harvest_date": "2026-03-01", "dry_temp_sensor_id": 999}, http.StatusBadRequest},
+		{"unknown plant", "/plant/9999/harvest", map[string]interface{}{"harvest_date": "2026-03-01"}, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, tc.path, apiKey, tc.body), tc.want)
+		})
+	}
+
+	// Children need a harvest to hang off.
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, base+"/dry-logs", apiKey,
+		map[string]interface{}{"date": "2026-03-02", "weight": 10}), http.StatusNotFound)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, base+"/yields", apiKey,
+		map[string]interface{}{"yields": []interface{}{}}), http.StatusNotFound)
+}
+
+// ---------------------------------------------------------------------------
+// SetHarvestYields
+// ---------------------------------------------------------------------------
+
+func TestHarvestHTTP_GradedYieldDrivesHarvestWeight(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "harvest-yield-key")
+	plantID := seedImagePlant(t, db)
+	c := server.NewClient(t)
+	base := "/plant/" + strconv.Itoa(plantID) + "/harvest"
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, base, apiKey,
+		map[string]interface{}{"harvest_date": "2026-03-01", "dry_weight": 120}), http.StatusOK)
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, base+"/yields", apiKey, map[string]interface{}{
+		"yields": []map[string]interface{}{{"grade": "A", "weight": 80}, {"grade": " Smalls ", "weight": 30.5}},
+	}), http.StatusOK)
+	got := getHarvestSummary(t, c, plantID)
+	require.Len(t, got.Yields, 2)
+	assert.Equal(t, "Smalls", got.Yields[1].Grade)
+	assert.Equal(t, 110.5, got.Metrics.YieldGrams)
+	assert.Equal(t, 110.5, plantHarvestWeight(t, db, plantID))
+
+	// Blank grades and negative weights are rejected without touching
+	// the saved grades.
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, base+"/yields", apiKey, map[string]interface{}{
+		"yields": []map[string]interface{}{{"grade": " ", "weight": 1}},
+	}), http.StatusBadRequest)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, base+"/yields", apiKey, map[string]interface{}{
+		"yields": []map[string]interface{}{{"grade": "A", "weight": -1}},
+	}), http.StatusBadRequest)
+	assert.Len(t, getHarvestSummary(t, c, plantID).Yields, 2)
+
+	// Clearing the grades falls back to the dry weight.
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, base+"/yields", apiKey,
+		map[string]interface{}{"yields": []interface{}{}}), http.StatusOK)
+	assert.Equal(t, 120.0, getHarvestSummary(t, c, plantID).Metrics.YieldGrams)
+	assert.Equal(t, 120.0, plantHarvestWeight(t, db, plantID))
+}
+
+// ---------------------------------------------------------------------------
+// Drying log + dry-room sensors
+// ---------------------------------------------------------------------------
+
+func TestHarvestHTTP_DryLogAveragesDryRoomSensors(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "harvest-dry-key")
+	plantID := seedImagePlant(t, db)
+	c := server.NewClient(t)
+	base := "/plant/" + strconv.Itoa(plantID) + "/harvest"
+
+	tempID := testutil.SeedSensor(t, db, "test", "dryroom", "temperature")
+	humID := testutil.SeedSensor(t, db, "test", "dryroom", "humidity")
+	testutil.MustExec(t, db, "UPDATE sensors SET unit = '°C' WHERE id = $1", tempID)
+	testutil.MustExec(t, db, "UPDATE sensors SET unit = '%' WHERE id = $1", humID)
+	for _, r := range []struct {
+		sensor int
+		value  float64
+		at     string
+	}{
+		{tempID, 30, "2026-02-20 12:00:00"}, // before the dry, ignored
+		{tempID, 20, "2026-03-02 10:00:00"},
+		{tempID, 22, "2026-03-02 14:00:00"},
+		{tempID, 18, "2026-03-03 12:00:00"},
+		{humID, 60, "2026-03-02 12:00:00"},
+	} {
+		testutil.MustExec(t, db, "INSERT INTO sensor_data (sensor_id, value, create_dt) VALUES ($1, $2, $3)", r.sensor, r.value, r.at)
+	}
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, base, apiKey, map[string]interface{}{
+		"harvest_date":           "2026-03-01",
+		"wet_weight":             500,
+		"dry_temp_sensor_id":     tempID,
+		"dry_humidity_sensor_id": humID,
+	}), http.StatusOK)
+	for _, log := range []map[string]interface{}{
+		{"date": "2026-03-03", "weight": 300},
+		{"date": "2026-03-02", "weight": 400, "notes": "stems bend"},
+	} {
+		expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, base+"/dry-logs", apiKey, log), http.StatusCreated)
+	}
+
+	got := getHarvestSummary(t, c, plantID)
+	require.Len(t, got.DryLogs, 2)
+	assert.Equal(t, 400.0, got.DryLogs[0].Weight, "logs are ordered by date")
+	require.NotNil(t, got.DryLogs[0].AvgTemperature)
+	assert.Equal(t, 21.0, *got.DryLogs[0].AvgTemperature)
+	require.NotNil(t, got.DryLogs[0].AvgHumidity)
+	assert.Equal(t, 60.0, *got.DryLogs[0].AvgHumidity)
+	require.NotNil(t, got.DryLogs[1].AvgTemperature)
+	assert.Equal(t, 18.0, *got.DryLogs[1].AvgTemperature)
+	assert.Nil(t, got.DryLogs[1].AvgHumidity)
+	assert.Equal(t, 2, got.Metrics.DryDays)
+
+	require.NotNil(t, got.DryConditions)
+	require.NotNil(t, got.DryConditions.Temperature)
+	assert.Equal(t, "°C", got.DryConditions.Temperature.Unit)
+	assert.Equal(t, 20.0, got.DryConditions.Temperature.Avg)
+	assert.Equal(t, 18.0, got.DryConditions.Temperature.Min)
+	assert.Equal(t, 22.0, got.DryConditions.Temperature.Max)
+	assert.Equal(t, 3, got.DryConditions.Temperature.Count)
+
+	// Deleting a linked sensor unlinks it rather than orphaning the id.
+	expectHarvestStatus(t, c.APIDelete(t, "/sensors/delete/"+strconv.Itoa(humID), apiKey), http.StatusOK)
+	var linked sql.NullInt64
+	require.NoError(t, db.QueryRow("SELECT dry_humidity_sensor_id FROM harvests WHERE plant_id = $1", plantID).Scan(&linked))
+	assert.False(t, linked.Valid)
+}
+
+// ---------------------------------------------------------------------------
+// Cure jars
+// ---------------------------------------------------------------------------
+
+func TestHarvestHTTP_CureJarsAndBurpLog(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "harvest-jar-key")
+	plantID := seedImagePlant(t, db)
+	c := server.NewClient(t)
+	base := "/plant/" + strconv.Itoa(plantID) + "/harvest"
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, base, apiKey,
+		map[string]interface{}{"harvest_date": "2026-03-01"}), http.StatusOK)
+
+	resp := harvestRequest(t, c, http.MethodPost, base+"/jars", apiKey,
+		map[string]interface{}{"name": "Jar 1", "sealed_date": "2026-03-10", "weight": 55})
+	var created struct {
+		ID int `json:"id"`
+	}
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	testutil.DrainAndClose(resp)
+	jarPath := "/harvest/jars/" + strconv.Itoa(created.ID)
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, jarPath+"/logs", apiKey,
+		map[string]interface{}{"date": "2026-03-11", "burped": true, "humidity": 64}), http.StatusCreated)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, jarPath+"/logs", apiKey,
+		map[string]interface{}{"date": "2026-03-12", "humidity": 150}), http.StatusBadRequest)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, "/harvest/jars/9999/logs", apiKey,
+		map[string]interface{}{"date": "2026-03-12"}), http.StatusNotFound)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, jarPath, apiKey,
+		map[string]interface{}{"name": "Jar A", "sealed_date": "2026-03-10"}), http.StatusOK)
+
+	got := getHarvestSummary(t, c, plantID)
+	require.Len(t, got.Jars, 1)
+	assert.Equal(t, "Jar A", got.Jars[0].Name)
+	require.Len(t, got.Jars[0].Logs, 1)
+	assert.True(t, got.Jars[0].Logs[0].Burped)
+	require.NotNil(t, got.Jars[0].Logs[0].Humidity)
+	assert.Equal(t, 64.0, *got.Jars[0].Logs[0].Humidity)
+
+	expectHarvestStatus(t, c.APIDelete(t, jarPath, apiKey), http.StatusOK)
+	var logs int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM cure_jar_logs").Scan(&logs))
+	assert.Zero(t, logs, "deleting a jar removes its log")
+	expectHarvestStatus(t, c.APIDelete(t, jarPath, apiKey), http.StatusNotFound)
+}
+
+// ---------------------------------------------------------------------------
+// Deletes
+// ---------------------------------------------------------------------------
+
+func TestHarvestHTTP_DeleteRemovesEverything(t *testing.T) {
+	t.Parallel()
+
+	for _, viaPlant := range []bool{false, true} {
+		t.Run("viaPlant="+strconv.FormatBool(viaPlant), func(t *testing.T) {
+			t.Parallel()
+
+			db := testutil.NewTestDB(t)
+			server := testutil.NewTestServer(t, db)
+			apiKey := testutil.SeedAPIKey(t, db, "harvest-delete-key")
+			plantID := seedImagePlant(t, db)
+			c := server.NewClient(t)
+			base := "/plant/" + strconv.Itoa(plantID) + "/harvest"
+
+			expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, base, apiKey,
+				map[string]interface{}{"harvest_date": "2026-03-01", "dry_weight": 100}), http.StatusOK)
+			expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, base+"/dry-logs", apiKey,
+				map[string]interface{}{"date": "2026-03-02", "weight": 300}), http.StatusCreated)
+			expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, base+"/jars", apiKey,
+				map[string]interface{}{"name": "J", "sealed_date": "2026-03-10"}), http.StatusCreated)
+			var jarID int
+			require.NoError(t, db.QueryRow("SELECT id FROM cure_jars").Scan(&jarID))
+			expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, "/harvest/jars/"+strconv.Itoa(jarID)+"/logs", apiKey,
+				map[string]interface{}{"date": "2026-03-11", "burped": true}), http.StatusCreated)
+			expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, base+"/yields", apiKey,
+				map[string]interface{}{"yields": []map[string]interface{}{{"grade": "A", "weight": 90}}}), http.StatusOK)
+
+			if viaPlant {
+				expectHarvestStatus(t, c.APIDelete(t, "/plant/delete/"+strconv.Itoa(plantID), apiKey), http.StatusOK)
+			} else {
+				expectHarvestStatus(t, c.APIDelete(t, base, apiKey), http.StatusOK)
+				assert.Equal(t, 90.0, plantHarvestWeight(t, db, plantID), "the plant keeps its last yield")
+			}
+			for _, table := range []string{"harvests", "harvest_dry_logs", "cure_jars", "cure_jar_logs", "harvest_yields"} {
+				var n int
+				require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM "+table).Scan(&n))
+				assert.Zero(t, n, table)
+			}
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// HarvestedPlantsHandler + harvest page
+// ---------------------------------------------------------------------------
+
+func TestHarvestHTTP_HarvestedPlantsIncludeMetrics(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db)
+	apiKey := testutil.SeedAPIKey(t, db, "harvest-list-key")
+	testutil.SeedAdmin(t, db, "harvest-list-pw")
+	plantID := seedImagePlant(t, db)
+	other := testutil.SeedPlant(t, db, "No record", 1, 1)
+	for _, id := range []int{plantID, other} {
+		testutil.MustExec(t, db, `INSERT INTO plant_status_log (plant_id, status_id, date) VALUES ($1, $2, '2026-03-01')`,
+			id, plantStatusID(t, db, "Success"))
+	}
+
+	c := server.NewClient(t)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/plant/"+strconv.Itoa(plantID)+"/harvest", apiKey,
+		map[string]interface{}{"harvest_date": "2026-03-01", "wet_weight": 400, "dry_weight": 100, "light_watts": 200}), http.StatusOK)
+
+	admin := server.LoginAsAdmin(t, "harvest-list-pw")
+	resp := admin.Get("/plants/harvested")
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var plants []struct {
+		ID      int `json:"id"`
+		Harvest *struct {
+			DryWetRatio  float64 `json:"dry_wet_ratio"`
+			GramsPerDay  float64 `json:"grams_per_day"`
+			GramsPerWatt float64 `json:"grams_per_watt"`
+		} `json:"harvest"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&plants))
+	require.Len(t, plants, 2)
+	for _, p := range plants {
+		if p.ID != plantID {
+			assert.Nil(t, p.Harvest, "plants without a harvest record carry no metrics")
+			continue
+		}
+		require.NotNil(t, p.Harvest)
+		assert.Equal(t, 0.25, p.Harvest.DryWetRatio)
+		assert.Equal(t, 1.695, p.Harvest.GramsPerDay)
+		assert.Equal(t, 0.5, p.Harvest.GramsPerWatt)
+	}
+}
+
+func TestHarvestHTTP_PageRenders(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "harvest-page-key")
+	plantID := seedImagePlant(t, db)
+	c := server.NewClient(t)
+	path := "/plant/" + strconv.Itoa(plantID) + "/harvest"
+
+	empty := c.Get(path)
+	defer testutil.DrainAndClose(empty)
+	require.Equal(t, http.StatusOK, empty.StatusCode)
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, path, apiKey,
+		map[string]interface{}{"harvest_date": "2026-03-01", "wet_weight": 400, "dry_weight": 100}), http.StatusOK)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, path+"/jars", apiKey,
+		map[string]interface{}{"name": "Jar Alpha", "sealed_date": "2026-03-10"}), http.StatusCreated)
+
+	resp := c.Get(path)
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "Jar Alpha")
+	assert.Contains(t, string(body), "0.25")
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"isley/logger"
+	"isley/model/types"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// MaxImageTagLength bounds a single tag name.
+	MaxImageTagLength = 32
+	// MaxTagsPerImage bounds how many tags one photo may carry.
+	MaxTagsPerImage = 10
+)
+
+// NormalizeImageTags trims, lowercases and de-duplicates tag names,
+// dropping empty ones. It returns an error key when a name is too long or
+// there are too many tags.
+func NormalizeImageTags(names []string) ([]string, string) {
+	return normalizeTags(names, MaxImageTagLength, MaxTagsPerImage, "api_image_tag_too_long", "api_too_many_image_tags")
+}
+
+// normalizeTags trims, lowercases, de-duplicates and sorts tag names,
+// dropping empty ones. It returns tooLongKey when a name is longer than
+// maxLen and tooManyKey when more than maxCount are left.
+func normalizeTags(names []string, maxLen, maxCount int, tooLongKey, tooManyKey string) ([]string, string) {
+	seen := map[string]bool{}
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.Join(strings.Fields(name), " "))
+		if name == "" || seen[name] {
+			continue
+		}
+		if len([]rune(name)) > maxLen {
+			return nil, tooLongKey
+		}
+		seen[name] = true
+		out = append(out, name)
+	}
+	if len(out) > maxCount {
+		return nil, tooManyKey
+	}
+	sort.Strings(out)
+	return out, ""
+}
+
+// ListImageTags returns every tag with the number of photos carrying it.
+func ListImageTags(c *gin.Context) {
+	tags, err := loadImageTagCounts(DBFromContext(c))
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "ListImageTags").Error("Failed to list image tags")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusOK, tags)
+}
+
+// loadImageTagCounts lists all tags alphabetically with their usage.
+func loadImageTagCounts(db *sql.DB) ([]types.ImageTag, error) {
+	rows, err := db.Query(`SELECT t.id, t.name, COUNT(pit.id) FROM image_tags t
+		LEFT JOIN plant_image_tags pit ON pit.tag_id = t.id
+		GROUP BY t.id, t.name ORDER BY t.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tags := []types.ImageTag{}
+	for rows.Next() {
+		var tag types.ImageTag
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// SetPlantImageTags replaces the tags on a photo. Unknown tag names are
+// created on the fly.
+func SetPlantImageTags(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "SetPlantImageTags")
+
+	imageID, err := strconv.Atoi(c.Param("imageID"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_image_id")
+		return
+	}
+	var input struct {
+		Tags []string `json:"tags"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apiBadRequest(c, "api_invalid_request")
+		return
+	}
+	names, errKey := NormalizeImageTags(input.Tags)
+	if errKey != "" {
+		apiBadRequest(c, errKey)
+		return
+	}
+
+	db := DBFromContext(c)
+	var exists int
+	if err := db.QueryRow("SELECT id FROM plant_images WHERE id = $1", imageID).Scan(&exists); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			apiNotFound(c, "api_image_not_found")
+			return
+		}
+		fieldLogger.WithError(err).Error("Failed to look up image")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	tags, err := replaceImageTags(db, imageID, names)
+	if err != nil {
+		fieldLogger.WithError(err).WithField("imageID", imageID).Error("Failed to save image tags")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tags": tags, "message": T(c, "api_image_tags_saved")})
+}
+
+// replaceImageTags sets the tags of imageID to names in one transaction,
+// creating missing tags, and returns the resulting tags.
+func replaceImageTags(db *sql.DB, imageID int, names []string) ([]types.ImageTag, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() // no-op after Commit
+
+	if _, err := tx.Exec("DELETE FROM plant_image_tags WHERE image_id = $1", imageID); err != nil {
+		return nil, err
+	}
+	tags := make([]types.ImageTag, 0, len(names))
+	for _, name := range names {
+		tag := types.ImageTag{Name: name}
+		err := tx.QueryRow("SELECT id FROM image_tags WHERE name = $1", name).Scan(&tag.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = tx.QueryRow("INSERT INTO image_tags (name) VALUES ($1) RETURNING id", name).Scan(&tag.ID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("tag %q: %w", name, err)
+		}
+		if _, err := tx.Exec("INSERT INTO plant_image_tags (image_id, tag_id) VALUES ($1, $2)", imageID, tag.ID); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, tx.Commit()
+}
+
+// loadImageTags returns the tags of each of the given images, keyed by
+// image ID and sorted by name.
+func loadImageTags(db *sql.DB, imageIDs []uint) (map[uint][]types.ImageTag, error) {
+	out := map[uint][]types.ImageTag{}
+	if len(imageIDs) == 0 {
+		return out, nil
+	}
+	phs := make([]string, len(imageIDs))
+	args := make([]interface{}, len(imageIDs))
+	for i, id := range imageIDs {
+		phs[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+	rows, err := db.Query(`SELECT pit.image_id, t.id, t.name FROM plant_image_tags pit
+		JOIN image_tags t ON t.id = pit.tag_id
+		WHERE pit.image_id IN (`+strings.Join(phs, ",")+`) ORDER BY t.name`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var imageID uint
+		var tag types.ImageTag
+		if err := rows.Scan(&imageID, &tag.ID, &tag.Name); err != nil {
+			return nil, err
+		}
+		out[imageID] = append(out[imageID], tag)
+	}
+	return out, rows.Err()
+}
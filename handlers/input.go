@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"isley/utils"
+)
+
+// plantIDParam parses the :plantID route parameter, sending a 400 when it
+// is malformed.
+func plantIDParam(c *gin.Context) (int, bool) {
+	plantID, err := strconv.Atoi(c.Param("plantID"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_plant_id")
+		return 0, false
+	}
+	return plantID, true
+}
+
+// validateNonNegative checks that every supplied weight, volume or other
+// measured quantity is a finite, non-negative number. Nil entries are
+// optional fields that were left out.
+func validateNonNegative(values map[string]*float64) error {
+	for _, field := range slices.Sorted(maps.Keys(values)) {
+		v := values[field]
+		if v == nil {
+			continue
+		}
+		if err := utils.ValidateFiniteFloat64(field, *v); err != nil {
+			return err
+		}
+		if *v < 0 {
+			return fmt.Errorf("%s must not be negative", field)
+		}
+	}
+	return nil
+}
+
+// validateRequiredDate checks a required date field.
+func validateRequiredDate(field, value string) error {
+	if err := utils.ValidateRequiredString(field, value, utils.MaxNameLength); err != nil {
+		return err
+	}
+	return utils.ValidateDate(field, value)
+}
+
+// roundQuantity trims float noise from weights, ratios and averages.
+func roundQuantity(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
@@ -101,9 +101,22 @@ func AddLineageHandler(c *gin.Context) {
 	}
 
 	db := DBFromContext(c)
+	tx, err := db.Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_internal_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+
+	if err := lockCannadbFields(tx, cannadbStrainLocks, strainID, cannadbFieldLineage); err != nil {
+		fieldLogger.WithError(err).Error("Failed to lock CannaDB lineage")
+		apiInternalError(c, "api_failed_to_add_lineage")
+		return
+	}
 
 	var id int
-	err = db.QueryRow(`
+	err = tx.QueryRow(`
 		INSERT INTO strain_lineage (strain_id, parent_name, parent_strain_id)
 		VALUES ($1, $2, $3) RETURNING id`,
 		strainID, req.ParentName, req.ParentStrainID).Scan(&id)
@@ -113,6 +126,12 @@ func AddLineageHandler(c *gin.Context) {
 		return
 	}
 
+	if err = tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit lineage transaction")
+		apiInternalError(c, "api_internal_error")
+		return
+	}
+
 	c.JSON(http.StatusCreated, gin.H{"id": id})
 }
 
@@ -127,8 +146,21 @@ func DeleteLineageHandler(c *gin.Context) {
 	}
 
 	db := DBFromContext(c)
+	tx, err := db.Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_internal_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+
+	if err := lockCannadbLineageEntry(tx, lineageID, ""); err != nil {
+		fieldLogger.WithError(err).Error("Failed to lock CannaDB lineage")
+		apiInternalError(c, "api_failed_to_delete_lineage")
+		return
+	}
 
-	result, err := db.Exec(`DELETE FROM strain_lineage WHERE id = $1`, lineageID)
+	result, err := tx.Exec(`DELETE FROM strain_lineage WHERE id = $1`, lineageID)
 	if err != nil {
 		fieldLogger.WithError(err).Error("Failed to delete lineage entry")
 		apiInternalError(c, "api_failed_to_delete_lineage")
@@ -141,6 +173,12 @@ func DeleteLineageHandler(c *gin.Context) {
 		return
 	}
 
+	if err = tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit lineage transaction")
+		apiInternalError(c, "api_internal_error")
+		return
+	}
+
 	apiOK(c, "api_lineage_deleted")
 }
 
@@ -170,8 +208,21 @@ func UpdateLineageHandler(c *gin.Context) {
 	}
 
 	db := DBFromContext(c)
+	tx, err := db.Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_internal_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
 
-	_, err = db.Exec(`
+	if err := lockCannadbLineageEntry(tx, lineageID, req.ParentName); err != nil {
+		fieldLogger.WithError(err).Error("Failed to lock CannaDB lineage")
+		apiInternalError(c, "api_failed_to_update_lineage")
+		return
+	}
+
+	_, err = tx.Exec(`
 		UPDATE strain_lineage
 		SET parent_name = $1, parent_strain_id = $2
 		WHERE id = $3`,
@@ -182,9 +233,43 @@ func UpdateLineageHandler(c *gin.Context) {
 		return
 	}
 
+	if err = tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit lineage transaction")
+		apiInternalError(c, "api_internal_error")
+		return
+	}
+
 	apiOK(c, "api_lineage_updated")
 }
 
+// lineageParent is one parent in a strain's lineage. ParentStrainID links
+// the parent to a strain on file when there is one.
+type lineageParent struct {
+	ParentName     string `json:"parent_name"`
+	ParentStrainID *int   `json:"parent_strain_id"`
+}
+
+// replaceStrainLineage replaces all lineage entries for a strain with
+// parents. Entries with an empty or invalid name are skipped.
+func replaceStrainLineage(tx *sql.Tx, strainID int, parents []lineageParent) error {
+	if _, err := tx.Exec(`DELETE FROM strain_lineage WHERE strain_id = $1`, strainID); err != nil {
+		return err
+	}
+	for _, p := range parents {
+		if err := utils.ValidateRequiredString("parent_name", p.ParentName, utils.MaxNameLength); err != nil {
+			continue
+		}
+		_, err := tx.Exec(`
+			INSERT INTO strain_lineage (strain_id, parent_name, parent_strain_id)
+			VALUES ($1, $2, $3)`,
+			strainID, p.ParentName, p.ParentStrainID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // SetLineageHandler replaces all lineage entries for a strain (bulk operation)
 func SetLineageHandler(c *gin.Context) {
 	fieldLogger := logger.Log.WithField("func", "SetLineageHandler")
@@ -196,10 +281,7 @@ func SetLineageHandler(c *gin.Context) {
 	}
 
 	var req struct {
-		Parents []struct {
-			ParentName     string `json:"parent_name"`
-			ParentStrainID *int   `json:"parent_strain_id"`
-		} `json:"parents"`
+		Parents []lineageParent `json:"parents"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -216,30 +298,24 @@ func SetLineageHandler(c *gin.Context) {
 		return
 	}
 
-	// Delete existing lineage
-	_, err = tx.Exec(`DELETE FROM strain_lineage WHERE strain_id = $1`, strainID)
-	if err != nil {
+	var names []string
+	for _, p := range req.Parents {
+		if utils.ValidateRequiredString("parent_name", p.ParentName, utils.MaxNameLength) == nil {
+			names = append(names, p.ParentName)
+		}
+	}
+	if err := lockEditedCannadbLineage(tx, strainID, names); err != nil {
 		tx.Rollback()
-		fieldLogger.WithError(err).Error("Failed to clear existing lineage")
+		fieldLogger.WithError(err).Error("Failed to lock CannaDB lineage")
 		apiInternalError(c, "api_failed_to_update_lineage")
 		return
 	}
 
-	// Insert new entries
-	for _, p := range req.Parents {
-		if err := utils.ValidateRequiredString("parent_name", p.ParentName, utils.MaxNameLength); err != nil {
-			continue // skip entries with empty or invalid names
-		}
-		_, err = tx.Exec(`
-			INSERT INTO strain_lineage (strain_id, parent_name, parent_strain_id)
-			VALUES ($1, $2, $3)`,
-			strainID, p.ParentName, p.ParentStrainID)
-		if err != nil {
-			tx.Rollback()
-			fieldLogger.WithError(err).Error("Failed to insert lineage entry")
-			apiInternalError(c, "api_failed_to_add_lineage")
-			return
-		}
+	if err := replaceStrainLineage(tx, strainID, req.Parents); err != nil {
+		tx.Rollback()
+		fieldLogger.WithError(err).Error("Failed to replace lineage")
+		apiInternalError(c, "api_failed_to_update_lineage")
+		return
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"isley/logger"
+	"isley/utils"
+)
+
+// validateHTTPURL accepts an empty URL (the feature is off) or an
+// absolute http(s) URL.
+func validateHTTPURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("URL must be an http or https URL")
+	}
+	return nil
+}
+
+// TestNotification sends a test message to the webhook in the request
+// body, so a URL can be checked before it is saved.
+func TestNotification(c *gin.Context) {
+	var in struct {
+		URL string `json:"url"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		apiBadRequest(c, "api_invalid_input")
+		return
+	}
+	in.URL = strings.TrimSpace(in.URL)
+	if in.URL == "" || validateHTTPURL(in.URL) != nil {
+		apiBadRequest(c, "api_invalid_webhook_url")
+		return
+	}
+	n := utils.Notification{Title: "Isley", Message: T(c, "notify_test_message")}
+	if err := utils.SendWebhook(c.Request.Context(), httpClient, in.URL, n); err != nil {
+		logger.Log.WithError(err).WithField("func", "TestNotification").Warn("Test notification failed")
+		apiBadRequest(c, "api_notification_failed")
+		return
+	}
+	apiOK(c, "api_notification_sent")
+}
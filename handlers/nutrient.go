@@ -0,0 +1,856 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"isley/logger"
+	"isley/model/types"
+	"isley/utils"
+)
+
+// NutrientUnits are the units a product can be dosed in: ml for liquids,
+// g for powders.
+var NutrientUnits = []string{"ml", "g"}
+
+// MaxScheduleWeek bounds the week numbers of a feeding schedule.
+const MaxScheduleWeek = 52
+
+// NutrientsPageContext is everything views/nutrients.html renders.
+type NutrientsPageContext struct {
+	Products  []types.NutrientProduct
+	Recipes   []types.NutrientRecipe
+	Schedules []types.FeedingSchedule
+}
+
+// BuildNutrientsPageContext loads the nutrient library for the nutrients
+// page.
+func BuildNutrientsPageContext(c *gin.Context) (NutrientsPageContext, error) {
+	var ctx NutrientsPageContext
+	db := DBFromContext(c)
+	var err error
+	if ctx.Products, err = LoadNutrientProducts(db); err != nil {
+		return ctx, err
+	}
+	if ctx.Recipes, err = LoadNutrientRecipes(db); err != nil {
+		return ctx, err
+	}
+	ctx.Schedules, err = LoadFeedingSchedules(db)
+	return ctx, err
+}
+
+// LoadNutrientProducts returns the product library sorted by name.
+func LoadNutrientProducts(db *sql.DB) ([]types.NutrientProduct, error) {
+	rows, err := db.Query(`SELECT id, name, brand, npk_n, npk_p, npk_k, dose_per_l, unit, notes
+		FROM nutrient_products ORDER BY LOWER(name), id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []types.NutrientProduct{}
+	for rows.Next() {
+		var p types.NutrientProduct
+		var dose sql.NullFloat64
+		if err := rows.Scan(&p.ID, &p.Name, &p.Brand, &p.N, &p.P, &p.K, &dose, &p.Unit, &p.Notes); err != nil {
+			return nil, err
+		}
+		p.DosePerL = nullFloatPtr(dose)
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
+
+// LoadNutrientRecipes returns every recipe with its products, sorted by
+// name.
+func LoadNutrientRecipes(db *sql.DB) ([]types.NutrientRecipe, error) {
+	return loadNutrientRecipes(db, 0)
+}
+
+// LoadNutrientRecipe returns one recipe with its products, or nil when it
+// doesn't exist.
+func LoadNutrientRecipe(db *sql.DB, recipeID int) (*types.NutrientRecipe, error) {
+	recipes, err := loadNutrientRecipes(db, recipeID)
+	if err != nil || len(recipes) == 0 {
+		return nil, err
+	}
+	return &recipes[0], nil
+}
+
+// loadNutrientRecipes loads the recipe recipeID, or every recipe when it
+// is 0.
+func loadNutrientRecipes(db *sql.DB, recipeID int) ([]types.NutrientRecipe, error) {
+	where := ""
+	var args []interface{}
+	if recipeID > 0 {
+		where = " WHERE id = $1"
+		args = append(args, recipeID)
+	}
+	rows, err := db.Query(`SELECT id, name, target_ec, target_ph, notes FROM nutrient_recipes`+where+`
+		ORDER BY LOWER(name), id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipes := []types.NutrientRecipe{}
+	idx := make(map[int]int)
+	for rows.Next() {
+		r := types.NutrientRecipe{Items: []types.NutrientRecipeItem{}}
+		var ec, ph sql.NullFloat64
+		if err := rows.Scan(&r.ID, &r.Name, &ec, &ph, &r.Notes); err != nil {
+			return nil, err
+		}
+		r.TargetEC, r.TargetPH = nullFloatPtr(ec), nullFloatPtr(ph)
+		idx[r.ID] = len(recipes)
+		recipes = append(recipes, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	where = ""
+	if recipeID > 0 {
+		where = " WHERE i.recipe_id = $1"
+	}
+	rows, err = db.Query(`SELECT i.recipe_id, i.product_id, p.name, p.unit, i.amount_per_l
+		FROM nutrient_recipe_items i
+		JOIN nutrient_products p ON p.id = i.product_id`+where+`
+		ORDER BY LOWER(p.name), i.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var rid int
+		var item types.NutrientRecipeItem
+		if err := rows.Scan(&rid, &item.ProductID, &item.ProductName, &item.Unit, &item.AmountPerL); err != nil {
+			return nil, err
+		}
+		if i, ok := idx[rid]; ok {
+			recipes[i].Items = append(recipes[i].Items, item)
+		}
+	}
+	return recipes, rows.Err()
+}
+
+// LoadFeedingSchedules returns every schedule with its weeks, sorted by
+// name. Weeks are ordered by stage and then week.
+func LoadFeedingSchedules(db *sql.DB) ([]types.FeedingSchedule, error) {
+	rows, err := db.Query(`SELECT s.id, s.name, s.notes,
+			(SELECT COUNT(*) FROM plant p WHERE p.feeding_schedule_id = s.id)
+		FROM feeding_schedules s ORDER BY LOWER(s.name), s.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []types.FeedingSchedule{}
+	idx := make(map[int]int)
+	for rows.Next() {
+		s := types.FeedingSchedule{Weeks: []types.FeedingScheduleWeek{}}
+		if err := rows.Scan(&s.ID, &s.Name, &s.Notes, &s.PlantCount); err != nil {
+			return nil, err
+		}
+		idx[s.ID] = len(schedules)
+		schedules = append(schedules, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	rows, err = db.Query(`SELECT w.schedule_id, w.status_id, ps.status, w.week, w.recipe_id, r.name
+		FROM feeding_schedule_weeks w
+		JOIN plant_status ps ON ps.id = w.status_id
+		JOIN nutrient_recipes r ON r.id = w.recipe_id
+		ORDER BY w.schedule_id, ps.status_order, ps.id, w.week`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sid int
+		var w types.FeedingScheduleWeek
+		if err := rows.Scan(&sid, &w.StatusID, &w.Status, &w.Week, &w.RecipeID, &w.RecipeName); err != nil {
+			return nil, err
+		}
+		if i, ok := idx[sid]; ok {
+			schedules[i].Weeks = append(schedules[i].Weeks, w)
+		}
+	}
+	return schedules, rows.Err()
+}
+
+// LoadFeedingPlan works out what a plant's schedule says to feed on date:
+// the stage is the plant's latest status on or before date, the week
+// counts from the day it entered that stage, and a schedule that stops
+// short of that week keeps feeding its last week for the stage. It
+// returns nil when the plant doesn't exist.
+func LoadFeedingPlan(db *sql.DB, plantID int, date time.Time) (*types.FeedingPlan, error) {
+	var scheduleID sql.NullInt64
+	var scheduleName sql.NullString
+	err := db.QueryRow(`SELECT p.feeding_schedule_id, s.name FROM plant p
+		LEFT JOIN feeding_schedules s ON s.id = p.feeding_schedule_id
+		WHERE p.id = $1`, plantID).Scan(&scheduleID, &scheduleName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	plan := &types.FeedingPlan{}
+	if scheduleName.Valid {
+		plan.ScheduleID, plan.ScheduleName = int(scheduleID.Int64), scheduleName.String
+	}
+
+	// Status logs are few per plant, so the stage is picked here rather
+	// than comparing naive dates in SQL.
+	rows, err := db.Query(`SELECT psl.status_id, ps.status, psl.date FROM plant_status_log psl
+		JOIN plant_status ps ON ps.id = psl.status_id
+		WHERE psl.plant_id = $1 ORDER BY psl.date, psl.id`, plantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	// Stored dates are naive local wall clocks, so whole days are compared
+	// on their digits.
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	plan.Date = utils.AsLocal(day)
+	var since time.Time
+	for rows.Next() {
+		var statusID int
+		var status string
+		var at time.Time
+		if err := rows.Scan(&statusID, &status, &at); err != nil {
+			return nil, err
+		}
+		at = time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+		if at.After(day) {
+			break
+		}
+		plan.StatusID, plan.Status, since = statusID, status, at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	if plan.StatusID == 0 {
+		return plan, nil
+	}
+	plan.Week = daysBetween(since, day)/7 + 1
+	if plan.ScheduleID == 0 {
+		return plan, nil
+	}
+
+	var recipeID int
+	err = db.QueryRow(`SELECT recipe_id FROM feeding_schedule_weeks
+		WHERE schedule_id = $1 AND status_id = $2 AND week <= $3
+		ORDER BY week DESC LIMIT 1`, plan.ScheduleID, plan.StatusID, plan.Week).Scan(&recipeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return plan, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	plan.Recipe, err = LoadNutrientRecipe(db, recipeID)
+	return plan, err
+}
+
+// ListNutrientProducts returns the product library.
+func ListNutrientProducts(c *gin.Context) {
+	products, err := LoadNutrientProducts(DBFromContext(c))
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "ListNutrientProducts").Error("Failed to load nutrient products")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusOK, products)
+}
+
+// ListNutrientRecipes returns every recipe with its products.
+func ListNutrientRecipes(c *gin.Context) {
+	recipes, err := LoadNutrientRecipes(DBFromContext(c))
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "ListNutrientRecipes").Error("Failed to load nutrient recipes")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusOK, recipes)
+}
+
+// ListFeedingSchedules returns every feeding schedule with its weeks.
+func ListFeedingSchedules(c *gin.Context) {
+	schedules, err := LoadFeedingSchedules(DBFromContext(c))
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "ListFeedingSchedules").Error("Failed to load feeding schedules")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusOK, schedules)
+}
+
+// GetPlantFeedingPlan returns what the plant's schedule says to feed on
+// the date query parameter (YYYY-MM-DD, default today).
+func GetPlantFeedingPlan(c *gin.Context) {
+	plantID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_plant_id")
+		return
+	}
+	loc := AppTimeLocation(ConfigStoreFromContext(c).Timezone())
+	date := time.Now().In(loc)
+	if v := c.Query("date"); v != "" {
+		if date, err = time.Parse(utils.LayoutDate, v); err != nil {
+			apiBadRequest(c, "date must be in YYYY-MM-DD format")
+			return
+		}
+	}
+	plan, err := LoadFeedingPlan(DBFromContext(c), plantID, date)
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "GetPlantFeedingPlan").Error("Failed to load feeding plan")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if plan == nil {
+		apiNotFound(c, "api_plant_not_found")
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// nutrientParam parses the :id route parameter, sending a 400 when it is
+// malformed.
+func nutrientParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_request")
+		return 0, false
+	}
+	return id, true
+}
+
+// rowExists reports whether table has a row with id. table is always a
+// constant supplied by the caller.
+func rowExists(q interface {
+	QueryRow(string, ...interface{}) *sql.Row
+}, table string, id int) (bool, error) {
+	var found int
+	err := q.QueryRow(fmt.Sprintf("SELECT id FROM %s WHERE id = $1", table), id).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// validateNutrientRange checks that value is a number within [lo, hi].
+func validateNutrientRange(field string, value, lo, hi float64) error {
+	if err := utils.ValidateFiniteFloat64(field, value); err != nil {
+		return err
+	}
+	if value < lo || value > hi {
+		return fmt.Errorf("%s must be between %g and %g", field, lo, hi)
+	}
+	return nil
+}
+
+// nutrientProductInput is the body of the product create and update
+// requests.
+type nutrientProductInput struct {
+	Name     string   `json:"name"`
+	Brand    string   `json:"brand"`
+	N        float64  `json:"n"`
+	P        float64  `json:"p"`
+	K        float64  `json:"k"`
+	DosePerL *float64 `json:"dose_per_l"`
+	Unit     string   `json:"unit"`
+	Notes    string   `json:"notes"`
+}
+
+func (in *nutrientProductInput) validate() error {
+	in.Name = strings.TrimSpace(in.Name)
+	in.Brand = strings.TrimSpace(in.Brand)
+	if err := utils.ValidateRequiredString("name", in.Name, utils.MaxNameLength); err != nil {
+		return err
+	}
+	if err := utils.ValidateStringLength("brand", in.Brand, utils.MaxNameLength); err != nil {
+		return err
+	}
+	if err := utils.ValidateStringLength("notes", in.Notes, utils.MaxNotesLength); err != nil {
+		return err
+	}
+	if in.Unit == "" {
+		in.Unit = NutrientUnits[0]
+	}
+	if !slices.Contains(NutrientUnits, in.Unit) {
+		return fmt.Errorf("unit must be one of %s", strings.Join(NutrientUnits, ", "))
+	}
+	for _, v := range []struct {
+		field string
+		value float64
+	}{{"n", in.N}, {"p", in.P}, {"k", in.K}} {
+		if err := validateNutrientRange(v.field, v.value, 0, 100); err != nil {
+			return err
+		}
+	}
+	return validateNonNegative(map[string]*float64{"dose_per_l": in.DosePerL})
+}
+
+func bindNutrientProductInput(c *gin.Context) (nutrientProductInput, bool) {
+	var in nutrientProductInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		apiBadRequest(c, "api_invalid_input")
+		return in, false
+	}
+	if err := in.validate(); err != nil {
+		apiBadRequest(c, err.Error())
+		return in, false
+	}
+	return in, true
+}
+
+// AddNutrientProduct adds a product to the nutrient library.
+func AddNutrientProduct(c *gin.Context) {
+	in, ok := bindNutrientProductInput(c)
+	if !ok {
+		return
+	}
+	var id int
+	err := DBFromContext(c).QueryRow(`INSERT INTO nutrient_products (name, brand, npk_n, npk_p, npk_k, dose_per_l, unit, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+		in.Name, in.Brand, in.N, in.P, in.K, in.DosePerL, in.Unit, in.Notes).Scan(&id)
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "AddNutrientProduct").Error("Failed to create nutrient product")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id, "message": T(c, "api_nutrient_product_saved")})
+}
+
+// UpdateNutrientProduct replaces a product's details. Recipes keep their
+// per-litre amounts; recorded feeds keep the name and unit they were
+// recorded with.
+func UpdateNutrientProduct(c *gin.Context) {
+	id, ok := nutrientParam(c)
+	if !ok {
+		return
+	}
+	in, ok := bindNutrientProductInput(c)
+	if !ok {
+		return
+	}
+	res, err := DBFromContext(c).Exec(`UPDATE nutrient_products SET name = $1, brand = $2, npk_n = $3, npk_p = $4,
+			npk_k = $5, dose_per_l = $6, unit = $7, notes = $8, update_dt = CURRENT_TIMESTAMP
+		WHERE id = $9`,
+		in.Name, in.Brand, in.N, in.P, in.K, in.DosePerL, in.Unit, in.Notes, id)
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "UpdateNutrientProduct").Error("Failed to update nutrient product")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		apiNotFound(c, "api_nutrient_product_not_found")
+		return
+	}
+	apiOK(c, "api_nutrient_product_saved")
+}
+
+// DeleteNutrientProduct removes a product from the library and from every
+// recipe. Recorded feeds keep their amounts under the product's name.
+func DeleteNutrientProduct(c *gin.Context) {
+	id, ok := nutrientParam(c)
+	if !ok {
+		return
+	}
+	deleteNutrientRow(c, "DeleteNutrientProduct", id, []string{
+		"DELETE FROM nutrient_recipe_items WHERE product_id = $1",
+		"UPDATE activity_nutrients SET product_id = NULL WHERE product_id = $1",
+	}, "DELETE FROM nutrient_products WHERE id = $1", "api_nutrient_product_not_found", "api_nutrient_product_deleted")
+}
+
+// deleteNutrientRow runs the cleanup statements and then the delete in one
+// transaction, answering 404 when the delete matched nothing. Production
+// SQLite runs without foreign keys, so references are cleared explicitly.
+func deleteNutrientRow(c *gin.Context, handler string, id int, cleanup []string, query, notFoundKey, okKey string) {
+	fieldLogger := logger.Log.WithField("func", handler)
+	tx, err := DBFromContext(c).Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+	for _, q := range cleanup {
+		if _, err := tx.Exec(q, id); err != nil {
+			fieldLogger.WithError(err).Error("Failed to clear references")
+			apiInternalError(c, "api_database_error")
+			return
+		}
+	}
+	res, err := tx.Exec(query, id)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to delete row")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		apiNotFound(c, notFoundKey)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit delete")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	apiOK(c, okKey)
+}
+
+// nutrientRecipeInput is the body of the recipe create and update
+// requests. Items replace the recipe's products wholesale.
+type nutrientRecipeInput struct {
+	Name     string   `json:"name"`
+	TargetEC *float64 `json:"target_ec"`
+	TargetPH *float64 `json:"target_ph"`
+	Notes    string   `json:"notes"`
+	Items    []struct {
+		ProductID  int     `json:"product_id"`
+		AmountPerL float64 `json:"amount_per_l"`
+	} `json:"items"`
+}
+
+func (in *nutrientRecipeInput) validate() error {
+	in.Name = strings.TrimSpace(in.Name)
+	if err := utils.ValidateRequiredString("name", in.Name, utils.MaxNameLength); err != nil {
+		return err
+	}
+	if err := utils.ValidateStringLength("notes", in.Notes, utils.MaxNotesLength); err != nil {
+		return err
+	}
+	if in.TargetEC != nil {
+		if err := validateNutrientRange("target_ec", *in.TargetEC, 0, 20); err != nil {
+			return err
+		}
+	}
+	if in.TargetPH != nil {
+		if err := validateNutrientRange("target_ph", *in.TargetPH, 0, 14); err != nil {
+			return err
+		}
+	}
+	seen := make(map[int]bool, len(in.Items))
+	for _, item := range in.Items {
+		if item.ProductID <= 0 {
+			return errors.New("items need a product_id")
+		}
+		if seen[item.ProductID] {
+			return errors.New("items must not list a product twice")
+		}
+		seen[item.ProductID] = true
+		if err := utils.ValidateFiniteFloat64("amount_per_l", item.AmountPerL); err != nil {
+			return err
+		}
+		if item.AmountPerL <= 0 {
+			return errors.New("amount_per_l must be greater than zero")
+		}
+	}
+	return nil
+}
+
+// saveNutrientRecipe creates the recipe (id 0) or replaces recipe id and
+// its items. It sends the response itself.
+func saveNutrientRecipe(c *gin.Context, id int) {
+	fieldLogger := logger.Log.WithField("func", "saveNutrientRecipe")
+	var in nutrientRecipeInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		apiBadRequest(c, "api_invalid_input")
+		return
+	}
+	if err := in.validate(); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
+
+	tx, err := DBFromContext(c).Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+
+	if id == 0 {
+		err = tx.QueryRow(`INSERT INTO nutrient_recipes (name, target_ec, target_ph, notes)
+			VALUES ($1, $2, $3, $4) RETURNING id`, in.Name, in.TargetEC, in.TargetPH, in.Notes).Scan(&id)
+	} else {
+		var res sql.Result
+		res, err = tx.Exec(`UPDATE nutrient_recipes SET name = $1, target_ec = $2, target_ph = $3, notes = $4,
+				update_dt = CURRENT_TIMESTAMP
+			WHERE id = $5`, in.Name, in.TargetEC, in.TargetPH, in.Notes, id)
+		if err == nil {
+			if n, _ := res.RowsAffected(); n == 0 {
+				apiNotFound(c, "api_nutrient_recipe_not_found")
+				return
+			}
+			_, err = tx.Exec("DELETE FROM nutrient_recipe_items WHERE recipe_id = $1", id)
+		}
+	}
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to save nutrient recipe")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	for _, item := range in.Items {
+		found, err := rowExists(tx, "nutrient_products", item.ProductID)
+		if err != nil {
+			fieldLogger.WithError(err).Error("Failed to look up nutrient product")
+			apiInternalError(c, "api_database_error")
+			return
+		}
+		if !found {
+			apiBadRequest(c, "api_nutrient_product_not_found")
+			return
+		}
+		if _, err := tx.Exec(`INSERT INTO nutrient_recipe_items (recipe_id, product_id, amount_per_l)
+			VALUES ($1, $2, $3)`, id, item.ProductID, item.AmountPerL); err != nil {
+			fieldLogger.WithError(err).Error("Failed to save recipe item")
+			apiInternalError(c, "api_database_error")
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit nutrient recipe")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if c.Request.Method == http.MethodPost {
+		c.JSON(http.StatusCreated, gin.H{"id": id, "message": T(c, "api_nutrient_recipe_saved")})
+		return
+	}
+	apiOK(c, "api_nutrient_recipe_saved")
+}
+
+// AddNutrientRecipe creates a recipe.
+func AddNutrientRecipe(c *gin.Context) {
+	saveNutrientRecipe(c, 0)
+}
+
+// UpdateNutrientRecipe replaces a recipe and its products. Feeds already
+// recorded from it keep the amounts they were recorded with.
+func UpdateNutrientRecipe(c *gin.Context) {
+	if id, ok := nutrientParam(c); ok {
+		saveNutrientRecipe(c, id)
+	}
+}
+
+// DeleteNutrientRecipe deletes a recipe and drops it from every schedule.
+// Recorded feeds keep their amounts.
+func DeleteNutrientRecipe(c *gin.Context) {
+	id, ok := nutrientParam(c)
+	if !ok {
+		return
+	}
+	deleteNutrientRow(c, "DeleteNutrientRecipe", id, []string{
+		"DELETE FROM nutrient_recipe_items WHERE recipe_id = $1",
+		"DELETE FROM feeding_schedule_weeks WHERE recipe_id = $1",
+		"UPDATE plant_activity SET feed_recipe_id = NULL WHERE feed_recipe_id = $1",
+	}, "DELETE FROM nutrient_recipes WHERE id = $1", "api_nutrient_recipe_not_found", "api_nutrient_recipe_deleted")
+}
+
+// feedingScheduleInput is the body of the schedule create and update
+// requests. Weeks replace the schedule's weeks wholesale.
+type feedingScheduleInput struct {
+	Name  string `json:"name"`
+	Notes string `json:"notes"`
+	Weeks []struct {
+		StatusID int `json:"status_id"`
+		Week     int `json:"week"`
+		RecipeID int `json:"recipe_id"`
+	} `json:"weeks"`
+}
+
+func (in *feedingScheduleInput) validate() error {
+	in.Name = strings.TrimSpace(in.Name)
+	if err := utils.ValidateRequiredString("name", in.Name, utils.MaxNameLength); err != nil {
+		return err
+	}
+	if err := utils.ValidateStringLength("notes", in.Notes, utils.MaxNotesLength); err != nil {
+		return err
+	}
+	type slot struct{ status, week int }
+	seen := make(map[slot]bool, len(in.Weeks))
+	for _, w := range in.Weeks {
+		if w.StatusID <= 0 || w.RecipeID <= 0 {
+			return errors.New("weeks need a status_id and a recipe_id")
+		}
+		if w.Week < 1 || w.Week > MaxScheduleWeek {
+			return fmt.Errorf("week must be between 1 and %d", MaxScheduleWeek)
+		}
+		if seen[slot{w.StatusID, w.Week}] {
+			return errors.New("weeks must not repeat a stage and week")
+		}
+		seen[slot{w.StatusID, w.Week}] = true
+	}
+	return nil
+}
+
+// saveFeedingSchedule creates the schedule (id 0) or replaces schedule id
+// and its weeks. It sends the response itself.
+func saveFeedingSchedule(c *gin.Context, id int) {
+	fieldLogger := logger.Log.WithField("func", "saveFeedingSchedule")
+	var in feedingScheduleInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		apiBadRequest(c, "api_invalid_input")
+		return
+	}
+	if err := in.validate(); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
+
+	tx, err := DBFromContext(c).Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+
+	if id == 0 {
+		err = tx.QueryRow(`INSERT INTO feeding_schedules (name, notes) VALUES ($1, $2) RETURNING id`,
+			in.Name, in.Notes).Scan(&id)
+	} else {
+		var res sql.Result
+		res, err = tx.Exec(`UPDATE feeding_schedules SET name = $1, notes = $2, update_dt = CURRENT_TIMESTAMP
+			WHERE id = $3`, in.Name, in.Notes, id)
+		if err == nil {
+			if n, _ := res.RowsAffected(); n == 0 {
+				apiNotFound(c, "api_feeding_schedule_not_found")
+				return
+			}
+			_, err = tx.Exec("DELETE FROM feeding_schedule_weeks WHERE schedule_id = $1", id)
+		}
+	}
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to save feeding schedule")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	for _, w := range in.Weeks {
+		for _, ref := range []struct {
+			table  string
+			id     int
+			errKey string
+		}{
+			{"plant_status", w.StatusID, "api_status_not_found"},
+			{"nutrient_recipes", w.RecipeID, "api_nutrient_recipe_not_found"},
+		} {
+			found, err := rowExists(tx, ref.table, ref.id)
+			if err != nil {
+				fieldLogger.WithError(err).WithField("table", ref.table).Error("Failed to look up schedule reference")
+				apiInternalError(c, "api_database_error")
+				return
+			}
+			if !found {
+				apiBadRequest(c, ref.errKey)
+				return
+			}
+		}
+		if _, err := tx.Exec(`INSERT INTO feeding_schedule_weeks (schedule_id, status_id, week, recipe_id)
+			VALUES ($1, $2, $3, $4)`, id, w.StatusID, w.Week, w.RecipeID); err != nil {
+			fieldLogger.WithError(err).Error("Failed to save schedule week")
+			apiInternalError(c, "api_database_error")
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit feeding schedule")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if c.Request.Method == http.MethodPost {
+		c.JSON(http.StatusCreated, gin.H{"id": id, "message": T(c, "api_feeding_schedule_saved")})
+		return
+	}
+	apiOK(c, "api_feeding_schedule_saved")
+}
+
+// AddFeedingSchedule creates a feeding schedule.
+func AddFeedingSchedule(c *gin.Context) {
+	saveFeedingSchedule(c, 0)
+}
+
+// UpdateFeedingSchedule replaces a feeding schedule and its weeks.
+func UpdateFeedingSchedule(c *gin.Context) {
+	if id, ok := nutrientParam(c); ok {
+		saveFeedingSchedule(c, id)
+	}
+}
+
+// DeleteFeedingSchedule deletes a schedule. Plants following it are left
+// without one.
+func DeleteFeedingSchedule(c *gin.Context) {
+	id, ok := nutrientParam(c)
+	if !ok {
+		return
+	}
+	deleteNutrientRow(c, "DeleteFeedingSchedule", id, []string{
+		"DELETE FROM feeding_schedule_weeks WHERE schedule_id = $1",
+		"UPDATE plant SET feeding_schedule_id = NULL WHERE feeding_schedule_id = $1",
+	}, "DELETE FROM feeding_schedules WHERE id = $1", "api_feeding_schedule_not_found", "api_feeding_schedule_deleted")
+}
+
+// SetPlantFeedingSchedule puts a plant on a schedule, or takes it off when
+// schedule_id is null or 0.
+func SetPlantFeedingSchedule(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "SetPlantFeedingSchedule")
+	plantID, err := strconv.Atoi(c.Param("plantID"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_plant_id")
+		return
+	}
+	var in struct {
+		ScheduleID *int `json:"schedule_id"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		apiBadRequest(c, "api_invalid_input")
+		return
+	}
+	if in.ScheduleID != nil && *in.ScheduleID <= 0 {
+		in.ScheduleID = nil
+	}
+
+	db := DBFromContext(c)
+	if in.ScheduleID != nil {
+		found, err := rowExists(db, "feeding_schedules", *in.ScheduleID)
+		if err != nil {
+			fieldLogger.WithError(err).Error("Failed to look up feeding schedule")
+			apiInternalError(c, "api_database_error")
+			return
+		}
+		if !found {
+			apiBadRequest(c, "api_feeding_schedule_not_found")
+			return
+		}
+	}
+	res, err := db.Exec("UPDATE plant SET feeding_schedule_id = $1 WHERE id = $2", in.ScheduleID, plantID)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to set feeding schedule")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		apiNotFound(c, "api_plant_not_found")
+		return
+	}
+	apiOK(c, "api_feeding_schedule_assigned")
+}
@@ -0,0 +1,503 @@
+package handlers_test
+
+// HTTP-layer tests for handlers/nutrient.go and the feed side of
+// handlers/plant_activity.go: the product/recipe/schedule library, the
+// feeding plan a plant's schedule gives for a day, and recording feeds as
+// structured amounts.
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"isley/tests/testutil"
+)
+
+type feedingPlanResponse struct {
+	ScheduleName string `json:"schedule_name"`
+	Status       string `json:"status"`
+	Week         int    `json:"week"`
+	Recipe       *struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"recipe"`
+}
+
+type feedNutrientRow struct {
+	ProductID sql.NullInt64
+	Name      string
+	Unit      string
+	Amount    float64
+}
+
+// createNutrientRow posts to a nutrient library endpoint and returns the
+// new row's ID.
+func createNutrientRow(t *testing.T, c *testutil.Client, apiKey, path string, body map[string]interface{}) int {
+	t.Helper()
+	resp := harvestRequest(t, c, http.MethodPost, path, apiKey, body)
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created struct {
+		ID int `json:"id"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	require.NotZero(t, created.ID)
+	return created.ID
+}
+
+// seedFeedRecipe creates two products and a "Bloom" recipe using 2 ml/L
+// of the first and 0.5 g/L of the second.
+func seedFeedRecipe(t *testing.T, c *testutil.Client, apiKey string) (recipeID, baseID, boostID int) {
+	t.Helper()
+	baseID = createNutrientRow(t, c, apiKey, "/nutrients/products", map[string]interface{}{
+		"name": "Base B", "brand": "Acme", "n": 1, "p": 5, "k": 4, "dose_per_l": 2,
+	})
+	boostID = createNutrientRow(t, c, apiKey, "/nutrients/products", map[string]interface{}{
+		"name": "PK Boost", "p": 13, "k": 14, "unit": "g",
+	})
+	recipeID = createNutrientRow(t, c, apiKey, "/nutrients/recipes", map[string]interface{}{
+		"name": "Bloom", "target_ec": 1.8, "target_ph": 6.2,
+		"items": []map[string]interface{}{
+			{"product_id": baseID, "amount_per_l": 2},
+			{"product_id": boostID, "amount_per_l": 0.5},
+		},
+	})
+	return recipeID, baseID, boostID
+}
+
+func getFeedingPlan(t *testing.T, c *testutil.Client, plantID int, date string) feedingPlanResponse {
+	t.Helper()
+	resp := c.Get("/plant/" + strconv.Itoa(plantID) + "/feeding-plan?date=" + date)
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var plan feedingPlanResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&plan))
+	return plan
+}
+
+func feedActivityID(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	var id int
+	require.NoError(t, db.QueryRow(`SELECT id FROM activity WHERE is_feeding = $1 ORDER BY id LIMIT 1`, true).Scan(&id))
+	return id
+}
+
+func latestPlantActivityID(t *testing.T, db *sql.DB, plantID int) int {
+	t.Helper()
+	var id int
+	require.NoError(t, db.QueryRow(`SELECT MAX(id) FROM plant_activity WHERE plant_id = $1`, plantID).Scan(&id))
+	return id
+}
+
+func feedNutrients(t *testing.T, db *sql.DB, activityID int) []feedNutrientRow {
+	t.Helper()
+	rows, err := db.Query(`SELECT product_id, product_name, unit, amount FROM activity_nutrients
+		WHERE plant_activity_id = $1 ORDER BY id`, activityID)
+	require.NoError(t, err)
+	defer rows.Close()
+	var got []feedNutrientRow
+	for rows.Next() {
+		var r feedNutrientRow
+		require.NoError(t, rows.Scan(&r.ProductID, &r.Name, &r.Unit, &r.Amount))
+		got = append(got, r)
+	}
+	require.NoError(t, rows.Err())
+	return got
+}
+
+// ---------------------------------------------------------------------------
+// Products / recipes / schedules
+// ---------------------------------------------------------------------------
+
+func TestNutrientHTTP_ProductCRUDAndValidation(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "nutrient-product-key")
+	c := server.NewClient(t)
+
+	id := createNutrientRow(t, c, apiKey, "/nutrients/products", map[string]interface{}{
+		"name": " Grow A ", "n": 3, "p": 1, "k": 2, "dose_per_l": 1.5,
+	})
+
+	resp := c.Get("/nutrients/products")
+	var products []struct {
+		ID       int      `json:"id"`
+		Name     string   `json:"name"`
+		N        float64  `json:"n"`
+		DosePerL *float64 `json:"dose_per_l"`
+		Unit     string   `json:"unit"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&products))
+	testutil.DrainAndClose(resp)
+	require.Len(t, products, 1)
+	assert.Equal(t, "Grow A", products[0].Name, "names are trimmed")
+	assert.Equal(t, "ml", products[0].Unit, "unit defaults to ml")
+	require.NotNil(t, products[0].DosePerL)
+	assert.InDelta(t, 1.5, *products[0].DosePerL, 0.001)
+
+	path := "/nutrients/products/" + strconv.Itoa(id)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, path, apiKey,
+		map[string]interface{}{"name": "Grow A", "n": 4, "unit": "g"}), http.StatusOK)
+	var n float64
+	var unit string
+	require.NoError(t, db.QueryRow(`SELECT npk_n, unit FROM nutrient_products WHERE id = $1`, id).Scan(&n, &unit))
+	assert.InDelta(t, 4, n, 0.001)
+	assert.Equal(t, "g", unit)
+
+	for name, body := range map[string]map[string]interface{}{
+		"missing name":  {"name": " "},
+		"unknown unit":  {"name": "X", "unit": "oz"},
+		"npk over 100":  {"name": "X", "k": 120},
+		"negative dose": {"name": "X", "dose_per_l": -1},
+	} {
+		resp := harvestRequest(t, c, http.MethodPost, "/nutrients/products", apiKey, body)
+		testutil.DrainAndClose(resp)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+	}
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/nutrients/products/9999", apiKey,
+		map[string]interface{}{"name": "X"}), http.StatusNotFound)
+	expectHarvestStatus(t, c.APIDelete(t, "/nutrients/products/9999", apiKey), http.StatusNotFound)
+}
+
+func TestNutrientHTTP_RecipesAndSchedules(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "nutrient-recipe-key")
+	c := server.NewClient(t)
+
+	recipeID, baseID, boostID := seedFeedRecipe(t, c, apiKey)
+
+	resp := c.Get("/nutrients/recipes")
+	var recipes []struct {
+		Name     string   `json:"name"`
+		TargetEC *float64 `json:"target_ec"`
+		Items    []struct {
+			ProductName string  `json:"product_name"`
+			Unit        string  `json:"unit"`
+			AmountPerL  float64 `json:"amount_per_l"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&recipes))
+	testutil.DrainAndClose(resp)
+	require.Len(t, recipes, 1)
+	require.NotNil(t, recipes[0].TargetEC)
+	assert.InDelta(t, 1.8, *recipes[0].TargetEC, 0.001)
+	require.Len(t, recipes[0].Items, 2)
+	assert.Equal(t, "g", recipes[0].Items[1].Unit)
+
+	// Updating replaces the recipe's products.
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/nutrients/recipes/"+strconv.Itoa(recipeID), apiKey,
+		map[string]interface{}{"name": "Bloom", "items": []map[string]interface{}{{"product_id": boostID, "amount_per_l": 1}}}),
+		http.StatusOK)
+	var items int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM nutrient_recipe_items WHERE recipe_id = $1`, recipeID).Scan(&items))
+	assert.Equal(t, 1, items)
+
+	for name, tc := range map[string]struct {
+		body map[string]interface{}
+		want int
+	}{
+		"ph out of range":  {map[string]interface{}{"name": "X", "target_ph": 15}, http.StatusBadRequest},
+		"repeated product": {map[string]interface{}{"name": "X", "items": []map[string]interface{}{{"product_id": baseID, "amount_per_l": 1}, {"product_id": baseID, "amount_per_l": 2}}}, http.StatusBadRequest},
+		"zero amount":      {map[string]interface{}{"name": "X", "items": []map[string]interface{}{{"product_id": baseID, "amount_per_l": 0}}}, http.StatusBadRequest},
+		"unknown product":  {map[string]interface{}{"name": "X", "items": []map[string]interface{}{{"product_id": 9999, "amount_per_l": 1}}}, http.StatusBadRequest},
+		"missing name":     {map[string]interface{}{"name": " "}, http.StatusBadRequest},
+	} {
+		resp := harvestRequest(t, c, http.MethodPost, "/nutrients/recipes", apiKey, tc.body)
+		testutil.DrainAndClose(resp)
+		assert.Equal(t, tc.want, resp.StatusCode, name)
+	}
+
+	vegID := plantStatusID(t, db, "Veg")
+	scheduleID := createNutrientRow(t, c, apiKey, "/nutrients/schedules", map[string]interface{}{
+		"name": "Standard", "weeks": []map[string]interface{}{{"status_id": vegID, "week": 1, "recipe_id": recipeID}},
+	})
+	resp = c.Get("/nutrients/schedules")
+	var schedules []struct {
+		ID    int `json:"id"`
+		Weeks []struct {
+			Status     string `json:"status"`
+			Week       int    `json:"week"`
+			RecipeName string `json:"recipe_name"`
+		} `json:"weeks"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&schedules))
+	testutil.DrainAndClose(resp)
+	require.Len(t, schedules, 1)
+	require.Len(t, schedules[0].Weeks, 1)
+	assert.Equal(t, "Veg", schedules[0].Weeks[0].Status)
+	assert.Equal(t, "Bloom", schedules[0].Weeks[0].RecipeName)
+
+	for name, weeks := range map[string][]map[string]interface{}{
+		"week zero":      {{"status_id": vegID, "week": 0, "recipe_id": recipeID}},
+		"week too late":  {{"status_id": vegID, "week": 53, "recipe_id": recipeID}},
+		"repeated week":  {{"status_id": vegID, "week": 2, "recipe_id": recipeID}, {"status_id": vegID, "week": 2, "recipe_id": recipeID}},
+		"unknown stage":  {{"status_id": 9999, "week": 1, "recipe_id": recipeID}},
+		"unknown recipe": {{"status_id": vegID, "week": 1, "recipe_id": 9999}},
+	} {
+		resp := harvestRequest(t, c, http.MethodPut, "/nutrients/schedules/"+strconv.Itoa(scheduleID), apiKey,
+			map[string]interface{}{"name": "Standard", "weeks": weeks})
+		testutil.DrainAndClose(resp)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+	}
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/nutrients/schedules/9999", apiKey,
+		map[string]interface{}{"name": "X"}), http.StatusNotFound)
+}
+
+func TestNutrientHTTP_DeletesClearReferences(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db)
+	apiKey := testutil.SeedAPIKey(t, db, "nutrient-delete-key")
+	plantID := seedImagePlant(t, db)
+	c := server.NewClient(t)
+
+	recipeID, baseID, _ := seedFeedRecipe(t, c, apiKey)
+	scheduleID := createNutrientRow(t, c, apiKey, "/nutrients/schedules", map[string]interface{}{
+		"name": "Standard", "weeks": []map[string]interface{}{{"status_id": plantStatusID(t, db, "Veg"), "week": 1, "recipe_id": recipeID}},
+	})
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/plant/"+strconv.Itoa(plantID)+"/feeding-schedule", apiKey,
+		map[string]interface{}{"schedule_id": scheduleID}), http.StatusOK)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, "/plantActivity", apiKey, map[string]interface{}{
+		"plant_id": plantID, "activity_id": feedActivityID(t, db), "date": "2026-03-01T09:00:00",
+		"feeding": map[string]interface{}{"recipe_id": recipeID, "volume_l": 1},
+	}), http.StatusCreated)
+	activityID := latestPlantActivityID(t, db, plantID)
+
+	// A deleted product leaves recorded feeds with its name and amount.
+	expectHarvestStatus(t, c.APIDelete(t, "/nutrients/products/"+strconv.Itoa(baseID), apiKey), http.StatusOK)
+	feed := feedNutrients(t, db, activityID)
+	require.Len(t, feed, 2)
+	assert.False(t, feed[0].ProductID.Valid)
+	assert.Equal(t, "Base B", feed[0].Name)
+	var items int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM nutrient_recipe_items WHERE product_id = $1`, baseID).Scan(&items))
+	assert.Zero(t, items)
+
+	// A deleted recipe leaves its schedule weeks and feeds behind.
+	expectHarvestStatus(t, c.APIDelete(t, "/nutrients/recipes/"+strconv.Itoa(recipeID), apiKey), http.StatusOK)
+	var feedRecipe sql.NullInt64
+	require.NoError(t, db.QueryRow(`SELECT feed_recipe_id FROM plant_activity WHERE id = $1`, activityID).Scan(&feedRecipe))
+	assert.False(t, feedRecipe.Valid)
+	var weeks int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM feeding_schedule_weeks WHERE schedule_id = $1`, scheduleID).Scan(&weeks))
+	assert.Zero(t, weeks)
+
+	// A deleted schedule unassigns its plants.
+	expectHarvestStatus(t, c.APIDelete(t, "/nutrients/schedules/"+strconv.Itoa(scheduleID), apiKey), http.StatusOK)
+	var schedule sql.NullInt64
+	require.NoError(t, db.QueryRow(`SELECT feeding_schedule_id FROM plant WHERE id = $1`, plantID).Scan(&schedule))
+	assert.False(t, schedule.Valid)
+	expectHarvestStatus(t, c.APIDelete(t, "/nutrients/schedules/"+strconv.Itoa(scheduleID), apiKey), http.StatusNotFound)
+}
+
+// ---------------------------------------------------------------------------
+// Feeding plan
+// ---------------------------------------------------------------------------
+
+func TestNutrientHTTP_FeedingPlanFollowsStageAndWeek(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "nutrient-plan-key")
+	plantID := seedImagePlant(t, db)
+	vegID := plantStatusID(t, db, "Veg")
+	flowerID := plantStatusID(t, db, "Flower")
+	testutil.MustExec(t, db, `INSERT INTO plant_status_log (plant_id, status_id, date) VALUES ($1, $2, '2026-01-01 08:00:00')`, plantID, vegID)
+	testutil.MustExec(t, db, `INSERT INTO plant_status_log (plant_id, status_id, date) VALUES ($1, $2, '2026-02-01 20:00:00')`, plantID, flowerID)
+	c := server.NewClient(t)
+
+	vegRecipe := createNutrientRow(t, c, apiKey, "/nutrients/recipes", map[string]interface{}{"name": "Grow"})
+	earlyBloom := createNutrientRow(t, c, apiKey, "/nutrients/recipes", map[string]interface{}{"name": "Early bloom"})
+	lateBloom := createNutrientRow(t, c, apiKey, "/nutrients/recipes", map[string]interface{}{"name": "Late bloom"})
+	scheduleID := createNutrientRow(t, c, apiKey, "/nutrients/schedules", map[string]interface{}{
+		"name": "Standard", "weeks": []map[string]interface{}{
+			{"status_id": vegID, "week": 1, "recipe_id": vegRecipe},
+			{"status_id": flowerID, "week": 1, "recipe_id": earlyBloom},
+			{"status_id": flowerID, "week": 3, "recipe_id": lateBloom},
+		},
+	})
+
+	// Without a schedule the plan still reports the stage.
+	plan := getFeedingPlan(t, c, plantID, "2026-01-10")
+	assert.Equal(t, "Veg", plan.Status)
+	assert.Equal(t, 2, plan.Week)
+	assert.Nil(t, plan.Recipe)
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/plant/"+strconv.Itoa(plantID)+"/feeding-schedule", apiKey,
+		map[string]interface{}{"schedule_id": scheduleID}), http.StatusOK)
+
+	for _, tc := range []struct {
+		date, status string
+		week         int
+		recipe       string
+	}{
+		{"2026-01-10", "Veg", 2, "Grow"},
+		{"2026-02-01", "Flower", 1, "Early bloom"},
+		{"2026-02-14", "Flower", 2, "Early bloom"},
+		{"2026-02-15", "Flower", 3, "Late bloom"},
+		{"2026-04-01", "Flower", 9, "Late bloom"},
+	} {
+		plan := getFeedingPlan(t, c, plantID, tc.date)
+		assert.Equal(t, "Standard", plan.ScheduleName, tc.date)
+		assert.Equal(t, tc.status, plan.Status, tc.date)
+		assert.Equal(t, tc.week, plan.Week, tc.date)
+		require.NotNil(t, plan.Recipe, tc.date)
+		assert.Equal(t, tc.recipe, plan.Recipe.Name, tc.date)
+	}
+
+	before := getFeedingPlan(t, c, plantID, "2025-12-31")
+	assert.Empty(t, before.Status, "no stage before the first status")
+	assert.Nil(t, before.Recipe)
+
+	bad := c.Get("/plant/" + strconv.Itoa(plantID) + "/feeding-plan?date=01/10/2026")
+	testutil.DrainAndClose(bad)
+	assert.Equal(t, http.StatusBadRequest, bad.StatusCode)
+	missing := c.Get("/plant/9999/feeding-plan")
+	testutil.DrainAndClose(missing)
+	assert.Equal(t, http.StatusNotFound, missing.StatusCode)
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/plant/"+strconv.Itoa(plantID)+"/feeding-schedule", apiKey,
+		map[string]interface{}{"schedule_id": 9999}), http.StatusBadRequest)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/plant/9999/feeding-schedule", apiKey,
+		map[string]interface{}{"schedule_id": scheduleID}), http.StatusNotFound)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/plant/"+strconv.Itoa(plantID)+"/feeding-schedule", apiKey,
+		map[string]interface{}{"schedule_id": nil}), http.StatusOK)
+	assert.Nil(t, getFeedingPlan(t, c, plantID, "2026-01-10").Recipe, "clearing the schedule drops the recipe")
+}
+
+// ---------------------------------------------------------------------------
+// Recording feeds
+// ---------------------------------------------------------------------------
+
+func TestNutrientHTTP_FeedRecordsScaledAmounts(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db)
+	apiKey := testutil.SeedAPIKey(t, db, "nutrient-feed-key")
+	plantID := seedImagePlant(t, db)
+	feedID := feedActivityID(t, db)
+	c := server.NewClient(t)
+
+	recipeID, baseID, boostID := seedFeedRecipe(t, c, apiKey)
+
+	// Recipe and volume alone scale the recipe to the volume.
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, "/plantActivity", apiKey, map[string]interface{}{
+		"plant_id": plantID, "activity_id": feedID, "date": "2026-03-01T09:00:00",
+		"feeding": map[string]interface{}{"recipe_id": recipeID, "volume_l": 2.5},
+	}), http.StatusCreated)
+	activityID := latestPlantActivityID(t, db, plantID)
+	feed := feedNutrients(t, db, activityID)
+	require.Len(t, feed, 2)
+	assert.Equal(t, "Base B", feed[0].Name)
+	assert.Equal(t, "ml", feed[0].Unit)
+	assert.InDelta(t, 5, feed[0].Amount, 0.001)
+	assert.Equal(t, "g", feed[1].Unit)
+	assert.InDelta(t, 1.25, feed[1].Amount, 0.001)
+	var volume sql.NullFloat64
+	require.NoError(t, db.QueryRow(`SELECT feed_volume_l FROM plant_activity WHERE id = $1`, activityID).Scan(&volume))
+	assert.InDelta(t, 2.5, volume.Float64, 0.001)
+
+	// Editing without a feeding keeps the feed.
+	edit := map[string]interface{}{"id": activityID, "activity_id": feedID, "date": "2026-03-01T09:00:00", "note": "tweaked"}
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, "/plantActivity/edit", apiKey, edit), http.StatusOK)
+	assert.Len(t, feedNutrients(t, db, activityID), 2)
+
+	// Editing with explicit amounts replaces them; zero amounts are dropped.
+	edit["feeding"] = map[string]interface{}{"recipe_id": recipeID, "volume_l": 2, "nutrients": []map[string]interface{}{
+		{"product_id": baseID, "amount": 3}, {"product_id": boostID, "amount": 0},
+	}}
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, "/plantActivity/edit", apiKey, edit), http.StatusOK)
+	feed = feedNutrients(t, db, activityID)
+	require.Len(t, feed, 1)
+	assert.InDelta(t, 3, feed[0].Amount, 0.001)
+
+	for name, feeding := range map[string]map[string]interface{}{
+		"unknown product": {"nutrients": []map[string]interface{}{{"product_id": 9999, "amount": 1}}},
+		"unknown recipe":  {"recipe_id": 9999, "volume_l": 1},
+		"negative volume": {"volume_l": -1},
+	} {
+		resp := harvestRequest(t, c, http.MethodPost, "/plantActivity", apiKey, map[string]interface{}{
+			"plant_id": plantID, "activity_id": feedID, "date": "2026-03-02T09:00:00", "feeding": feeding,
+		})
+		testutil.DrainAndClose(resp)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+	}
+
+	// Feeding several plants at once records the feed on each.
+	otherID := testutil.SeedPlant(t, db, "Plant 2", testutil.SeedStrain(t, db, testutil.SeedBreeder(t, db, "B2"), "S2"), testutil.SeedZone(t, db, "Z2"))
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, "/record-multi-activity", apiKey, map[string]interface{}{
+		"plant_ids": []int{plantID, otherID}, "activity_id": feedID, "date": "2026-03-03T09:00:00",
+		"feeding": map[string]interface{}{"recipe_id": recipeID, "volume_l": 1},
+	}), http.StatusOK)
+	assert.Len(t, feedNutrients(t, db, latestPlantActivityID(t, db, otherID)), 2)
+
+	expectHarvestStatus(t, c.APIDelete(t, "/plantActivity/delete/"+strconv.Itoa(activityID), apiKey), http.StatusOK)
+	assert.Empty(t, feedNutrients(t, db, activityID))
+
+	expectHarvestStatus(t, c.APIDelete(t, "/plant/delete/"+strconv.Itoa(otherID), apiKey), http.StatusOK)
+	var orphans int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM activity_nutrients n
+		WHERE NOT EXISTS (SELECT 1 FROM plant_activity pa WHERE pa.id = n.plant_activity_id)`).Scan(&orphans))
+	assert.Zero(t, orphans, "deleting a plant removes its feeds")
+}
+
+// ---------------------------------------------------------------------------
+// Pages
+// ---------------------------------------------------------------------------
+
+func TestNutrientHTTP_PagesRender(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "nutrient-page-key")
+	testutil.SeedAdmin(t, db, "nutrient-page-pw")
+	plantID := seedImagePlant(t, db)
+	vegID := plantStatusID(t, db, "Veg")
+	testutil.MustExec(t, db, `INSERT INTO plant_status_log (plant_id, status_id, date) VALUES ($1, $2, '2026-01-01')`, plantID, vegID)
+	c := server.NewClient(t)
+
+	recipeID, _, _ := seedFeedRecipe(t, c, apiKey)
+	scheduleID := createNutrientRow(t, c, apiKey, "/nutrients/schedules", map[string]interface{}{
+		"name": "Standard", "weeks": []map[string]interface{}{{"status_id": vegID, "week": 1, "recipe_id": recipeID}},
+	})
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/plant/"+strconv.Itoa(plantID)+"/feeding-schedule", apiKey,
+		map[string]interface{}{"schedule_id": scheduleID}), http.StatusOK)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, "/plantActivity", apiKey, map[string]interface{}{
+		"plant_id": plantID, "activity_id": feedActivityID(t, db), "date": "2026-01-05T09:00:00",
+		"feeding": map[string]interface{}{"recipe_id": recipeID, "volume_l": 4},
+	}), http.StatusCreated)
+
+	admin := server.LoginAsAdmin(t, "nutrient-page-pw")
+	for _, client := range []*testutil.Client{c, admin} {
+		resp := client.Get("/nutrients")
+		body, err := io.ReadAll(resp.Body)
+		testutil.DrainAndClose(resp)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, string(body), "PK Boost")
+		assert.Contains(t, string(body), "Standard")
+
+		resp = client.Get("/plant/" + strconv.Itoa(plantID))
+		body, err = io.ReadAll(resp.Body)
+		testutil.DrainAndClose(resp)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, string(body), "Standard", "the plant shows its schedule")
+		assert.Contains(t, string(body), "PK Boost 2 g", "the notebook shows the feed")
+	}
+}
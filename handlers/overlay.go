@@ -13,12 +13,21 @@ import (
 
 // GetOverlayData returns a JSON snapshot of living plants (with linked sensor
 // readings embedded) and grouped sensor data, suitable for a live stream overlay.
-// Requires API key authentication.
+// Readings are converted to the configured display units. Requires API key
+// authentication.
 func GetOverlayData(c *gin.Context) {
 	db := DBFromContext(c)
+	prefs := UnitPrefsFromContext(c)
+	plants := GetOverlayPlants(db)
+	for i := range plants {
+		for j := range plants[i].LinkedSensors {
+			s := &plants[i].LinkedSensors[j]
+			s.Value, s.Unit = prefs.Convert(s.Value, s.Unit)
+		}
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"plants":  GetOverlayPlants(db),
-		"sensors": GetGroupedSensorsWithLatestReading(db, SensorCacheServiceFromContext(c)),
+		"plants":  plants,
+		"sensors": GroupedSensorsInUnits(GetGroupedSensorsWithLatestReading(db, SensorCacheServiceFromContext(c)), prefs),
 	})
 }
 
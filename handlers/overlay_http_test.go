@@ -14,7 +14,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"isley/config"
 	"isley/tests/testutil"
+	"isley/utils"
 )
 
 // TestOverlayHTTP_EmptyDBReturnsEmptyArrays verifies the response shape
@@ -99,3 +101,51 @@ func TestOverlayHTTP_IncludesLivingPlantWithLinkedSensor(t *testing.T) {
 	require.Len(t, got.Plants[0].LinkedSensors, 1, "linked_sensors should carry the one wired-up sensor")
 	assert.Equal(t, "Tent A Temp", got.Plants[0].LinkedSensors[0]["name"])
 }
+
+// TestOverlayHTTP_ConvertsMixedUnitZone seeds a zone with one °F and one
+// °C temperature sensor and verifies that, with the metric unit system
+// configured, both come back from /api/overlay in °C.
+func TestOverlayHTTP_ConvertsMixedUnitZone(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	store := config.NewStore()
+	store.SetUnitPrefs(utils.UnitPrefs{System: utils.UnitSystemMetric})
+	server := testutil.NewTestServer(t, db, testutil.WithConfigStore(store))
+
+	const apiKey = "overlay-units-key"
+	testutil.SeedAPIKey(t, db, apiKey)
+
+	testutil.MustExec(t, db, `INSERT INTO zones (id, name) VALUES (1, 'Z')`)
+	testutil.MustExec(t, db, `INSERT INTO sensors (id, name, zone_id, source, device, type, unit, visibility)
+	      VALUES (1, 'ACI Temp', 1, 'acinfinity', 'D', 'temp', '°F', 'zone')`)
+	testutil.MustExec(t, db, `INSERT INTO sensors (id, name, zone_id, source, device, type, unit, visibility)
+	      VALUES (2, 'EC Temp', 1, 'ecowitt', 'D', 'temp2', 'C', 'zone')`)
+	testutil.MustExec(t, db, `INSERT INTO sensor_data (sensor_id, value) VALUES (1, 77)`)
+	testutil.MustExec(t, db, `INSERT INTO sensor_data (sensor_id, value) VALUES (2, 24.5)`)
+
+	c := server.NewClient(t)
+	resp, err := c.Do(testutil.APIReq(t, http.MethodGet, c.BaseURL+"/api/overlay", apiKey, nil, ""))
+	require.NoError(t, err)
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var got struct {
+		Sensors map[string]map[string][]map[string]interface{} `json:"sensors"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	sensors := got.Sensors["Z"]["D"]
+	require.Len(t, sensors, 2)
+	values := map[string]float64{}
+	for _, s := range sensors {
+		assert.Equal(t, "°C", s["unit"], "every temperature in the zone should share one unit")
+		values[s["name"].(string)] = s["value"].(float64)
+	}
+	assert.InDelta(t, 25.0, values["ACI Temp"], 0.001)
+	assert.InDelta(t, 24.5, values["EC Temp"], 0.001)
+
+	// The stored reading is untouched.
+	var stored float64
+	require.NoError(t, db.QueryRow(`SELECT value FROM sensor_data WHERE sensor_id = 1`).Scan(&stored))
+	assert.Equal(t, 77.0, stored)
+}
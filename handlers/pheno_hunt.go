@@ -0,0 +1,464 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"isley/logger"
+	"isley/model/types"
+	"isley/utils"
+)
+
+// MaxPhenoScore is the top of the 1–10 pheno hunt score scale.
+const MaxPhenoScore = 10
+
+// MaxPhenoWeight caps a criterion's weight.
+const MaxPhenoWeight = 100
+
+// phenoHuntImages is how many of each plant's latest photos the pheno hunt
+// comparison shows.
+const phenoHuntImages = 3
+
+// LoadPhenoCriteria returns the scoring criteria in display order.
+func LoadPhenoCriteria(db *sql.DB) ([]types.PhenoCriterion, error) {
+	rows, err := db.Query(`SELECT id, name, weight, sort_order FROM pheno_criteria ORDER BY sort_order, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	criteria := []types.PhenoCriterion{}
+	for rows.Next() {
+		var cr types.PhenoCriterion
+		if err := rows.Scan(&cr.ID, &cr.Name, &cr.Weight, &cr.SortOrder); err != nil {
+			return nil, err
+		}
+		criteria = append(criteria, cr)
+	}
+	return criteria, rows.Err()
+}
+
+// phenoScore is the average of a plant's scores weighted by their
+// criteria. Unscored criteria are left out rather than counted as zero,
+// so a half-scored plant is not ranked below the rest. It returns nil when
+// nothing has been scored.
+func phenoScore(criteria []types.PhenoCriterion, scores map[int]int) *float64 {
+	var sum, weights float64
+	for _, cr := range criteria {
+		score, ok := scores[cr.ID]
+		if !ok {
+			continue
+		}
+		sum += float64(score) * cr.Weight
+		weights += cr.Weight
+	}
+	if weights == 0 {
+		return nil
+	}
+	score := math.Round(sum/weights*100) / 100
+	return &score
+}
+
+// loadPhenoPlant fills in a plant's status, flags, yield, latest
+// measurement of each metric and latest photos.
+func loadPhenoPlant(db *sql.DB, p *types.PhenoPlant, prefs utils.UnitPrefs) error {
+	err := db.QueryRow(fmt.Sprintf(`SELECT COALESCE((SELECT ps.status FROM plant_status_log psl
+				JOIN plant_status ps ON ps.id = psl.status_id
+				WHERE psl.plant_id = p.id
+				ORDER BY %s DESC, psl.id DESC LIMIT 1), ''),
+			p.start_dt, p.clone, p.keeper, p.pheno_keeper, COALESCE(p.harvest_weight, 0)
+		FROM plant p WHERE p.id = $1`, plantStatusOrderExpr()), p.ID).
+		Scan(&p.Status, &p.StartDT, &p.Clone, &p.Keeper, &p.PhenoKeeper, &p.HarvestWeight)
+	if err != nil {
+		return err
+	}
+	p.StartDT = utils.AsLocal(p.StartDT)
+
+	rows, err := db.Query(`SELECT m.id, m.metric_id, me.name, COALESCE(me.unit, ''), m.value, m.date
+		FROM plant_measurements m
+		JOIN metric me ON me.id = m.metric_id
+		WHERE m.plant_id = $1
+		ORDER BY m.date DESC, m.id DESC`, p.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	p.Measurements = []types.Measurement{}
+	seen := map[int]bool{}
+	for rows.Next() {
+		var m types.Measurement
+		if err := rows.Scan(&m.ID, &m.MetricID, &m.Name, &m.Unit, &m.Value, &m.Date); err != nil {
+			return err
+		}
+		if seen[m.MetricID] {
+			continue
+		}
+		seen[m.MetricID] = true
+		m.Date = utils.AsLocal(m.Date)
+		m.DisplayValue, m.DisplayUnit = prefs.Convert(m.Value, m.Unit)
+		p.Measurements = append(p.Measurements, m)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	sort.Slice(p.Measurements, func(i, j int) bool { return p.Measurements[i].Name < p.Measurements[j].Name })
+
+	images, err := db.Query(fmt.Sprintf(`SELECT id FROM plant_images WHERE plant_id = $1
+		ORDER BY image_date DESC, id DESC LIMIT %d`, phenoHuntImages), p.ID)
+	if err != nil {
+		return err
+	}
+	defer images.Close()
+	p.ImageIDs = []int{}
+	for images.Next() {
+		var id int
+		if err := images.Scan(&id); err != nil {
+			return err
+		}
+		p.ImageIDs = append(p.ImageIDs, id)
+	}
+	return images.Err()
+}
+
+// LoadPhenoHunt compares the plants of a strain side by side, best scored
+// first and unscored plants last. It returns sql.ErrNoRows when the strain
+// does not exist.
+func LoadPhenoHunt(db *sql.DB, strainID int, prefs utils.UnitPrefs) (types.PhenoHunt, error) {
+	hunt := types.PhenoHunt{StrainID: strainID, Plants: []types.PhenoPlant{}}
+	if err := db.QueryRow(`SELECT name FROM strain WHERE id = $1`, strainID).Scan(&hunt.StrainName); err != nil {
+		return hunt, err
+	}
+	criteria, err := LoadPhenoCriteria(db)
+	if err != nil {
+		return hunt, err
+	}
+	hunt.Criteria = criteria
+
+	siblings, err := GetPlantsByStrain(db, strainID)
+	if err != nil {
+		return hunt, err
+	}
+	scores := map[int]map[int]int{}
+	rows, err := db.Query(`SELECT ps.plant_id, ps.criterion_id, ps.score
+		FROM pheno_scores ps
+		JOIN plant p ON p.id = ps.plant_id
+		WHERE p.strain_id = $1`, strainID)
+	if err != nil {
+		return hunt, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var plantID, criterionID, score int
+		if err := rows.Scan(&plantID, &criterionID, &score); err != nil {
+			return hunt, err
+		}
+		if scores[plantID] == nil {
+			scores[plantID] = map[int]int{}
+		}
+		scores[plantID][criterionID] = score
+	}
+	if err := rows.Err(); err != nil {
+		return hunt, err
+	}
+
+	for _, sibling := range siblings {
+		p := types.PhenoPlant{ID: int(sibling.ID), Name: sibling.Name, Scores: scores[int(sibling.ID)]}
+		if p.Scores == nil {
+			p.Scores = map[int]int{}
+		}
+		if err := loadPhenoPlant(db, &p, prefs); err != nil {
+			return hunt, err
+		}
+		p.Score = phenoScore(criteria, p.Scores)
+		hunt.Plants = append(hunt.Plants, p)
+	}
+	// Siblings come sorted by name, so ties keep that order.
+	sort.SliceStable(hunt.Plants, func(i, j int) bool {
+		a, b := hunt.Plants[i].Score, hunt.Plants[j].Score
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return *a > *b
+	})
+	return hunt, nil
+}
+
+// GetPhenoHunt returns the pheno hunt comparison for a strain.
+func GetPhenoHunt(c *gin.Context) {
+	strainID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_strain_id")
+		return
+	}
+	hunt, err := LoadPhenoHunt(DBFromContext(c), strainID, UnitPrefsFromContext(c))
+	if errors.Is(err, sql.ErrNoRows) {
+		apiNotFound(c, "api_strain_not_found")
+		return
+	}
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "GetPhenoHunt").Error("Failed to load pheno hunt")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusOK, hunt)
+}
+
+func phenoCriterionParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_request")
+		return 0, false
+	}
+	return id, true
+}
+
+// GetPhenoCriteria returns the scoring criteria.
+func GetPhenoCriteria(c *gin.Context) {
+	criteria, err := LoadPhenoCriteria(DBFromContext(c))
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "GetPhenoCriteria").Error("Failed to load pheno criteria")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusOK, criteria)
+}
+
+type phenoCriterionInput struct {
+	Name      string  `json:"name"`
+	Weight    float64 `json:"weight"`
+	SortOrder int     `json:"sort_order"`
+}
+
+func (in *phenoCriterionInput) validate() error {
+	in.Name = strings.TrimSpace(in.Name)
+	if err := utils.ValidateRequiredString("name", in.Name, utils.MaxNameLength); err != nil {
+		return err
+	}
+	if err := utils.ValidateFiniteFloat64("weight", in.Weight); err != nil {
+		return err
+	}
+	if in.Weight <= 0 || in.Weight > MaxPhenoWeight {
+		return fmt.Errorf("weight must be greater than 0 and at most %d", MaxPhenoWeight)
+	}
+	return nil
+}
+
+// bindPhenoCriterionInput binds and validates a criterion body. It sends
+// the error response itself.
+func bindPhenoCriterionInput(c *gin.Context) (phenoCriterionInput, bool) {
+	var in phenoCriterionInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		apiBadRequest(c, "api_invalid_input")
+		return in, false
+	}
+	if err := in.validate(); err != nil {
+		apiBadRequest(c, err.Error())
+		return in, false
+	}
+	return in, true
+}
+
+// AddPhenoCriterion adds a scoring criterion.
+func AddPhenoCriterion(c *gin.Context) {
+	in, ok := bindPhenoCriterionInput(c)
+	if !ok {
+		return
+	}
+	var id int
+	err := DBFromContext(c).QueryRow(`INSERT INTO pheno_criteria (name, weight, sort_order) VALUES ($1, $2, $3) RETURNING id`,
+		in.Name, in.Weight, in.SortOrder).Scan(&id)
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "AddPhenoCriterion").Error("Failed to create pheno criterion")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id, "message": T(c, "api_pheno_criterion_saved")})
+}
+
+// UpdatePhenoCriterion replaces a criterion's name, weight and order.
+// Changing the weight re-weights every plant's score.
+func UpdatePhenoCriterion(c *gin.Context) {
+	id, ok := phenoCriterionParam(c)
+	if !ok {
+		return
+	}
+	in, ok := bindPhenoCriterionInput(c)
+	if !ok {
+		return
+	}
+	result, err := DBFromContext(c).Exec(`UPDATE pheno_criteria SET name = $1, weight = $2, sort_order = $3 WHERE id = $4`,
+		in.Name, in.Weight, in.SortOrder, id)
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "UpdatePhenoCriterion").Error("Failed to update pheno criterion")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		apiNotFound(c, "api_pheno_criterion_not_found")
+		return
+	}
+	apiOK(c, "api_pheno_criterion_saved")
+}
+
+// DeletePhenoCriterion deletes a criterion and every score given for it.
+func DeletePhenoCriterion(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "DeletePhenoCriterion")
+	id, ok := phenoCriterionParam(c)
+	if !ok {
+		return
+	}
+	tx, err := DBFromContext(c).Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+	if _, err := tx.Exec(`DELETE FROM pheno_scores WHERE criterion_id = $1`, id); err != nil {
+		fieldLogger.WithError(err).Error("Failed to delete pheno scores")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	result, err := tx.Exec(`DELETE FROM pheno_criteria WHERE id = $1`, id)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to delete pheno criterion")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		apiNotFound(c, "api_pheno_criterion_not_found")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	apiOK(c, "api_pheno_criterion_deleted")
+}
+
+type phenoScoresInput struct {
+	Scores []struct {
+		CriterionID int  `json:"criterion_id"`
+		Score       *int `json:"score"`
+	} `json:"scores"`
+}
+
+func (in *phenoScoresInput) validate() error {
+	seen := map[int]bool{}
+	for _, s := range in.Scores {
+		if seen[s.CriterionID] {
+			return fmt.Errorf("criterion %d is scored twice", s.CriterionID)
+		}
+		seen[s.CriterionID] = true
+		if s.Score != nil && (*s.Score < 0 || *s.Score > MaxPhenoScore) {
+			return fmt.Errorf("score must be between 1 and %d, or 0 to clear it", MaxPhenoScore)
+		}
+	}
+	return nil
+}
+
+// SetPhenoScores records a plant's scores. Criteria left out keep their
+// score; a null or 0 score clears it.
+func SetPhenoScores(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "SetPhenoScores")
+	plantID, ok := plantIDParam(c)
+	if !ok {
+		return
+	}
+	var in phenoScoresInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		apiBadRequest(c, "api_invalid_input")
+		return
+	}
+	if err := in.validate(); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
+
+	tx, err := DBFromContext(c).Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+	if exists, err := rowExists(tx, "plant", plantID); err != nil {
+		fieldLogger.WithError(err).Error("Failed to look up plant")
+		apiInternalError(c, "api_database_error")
+		return
+	} else if !exists {
+		apiNotFound(c, "api_plant_not_found")
+		return
+	}
+	for _, s := range in.Scores {
+		if exists, err := rowExists(tx, "pheno_criteria", s.CriterionID); err != nil {
+			fieldLogger.WithError(err).Error("Failed to look up pheno criterion")
+			apiInternalError(c, "api_database_error")
+			return
+		} else if !exists {
+			apiNotFound(c, "api_pheno_criterion_not_found")
+			return
+		}
+		if _, err := tx.Exec(`DELETE FROM pheno_scores WHERE plant_id = $1 AND criterion_id = $2`, plantID, s.CriterionID); err != nil {
+			fieldLogger.WithError(err).Error("Failed to clear pheno score")
+			apiInternalError(c, "api_database_error")
+			return
+		}
+		if s.Score == nil || *s.Score == 0 {
+			continue
+		}
+		if _, err := tx.Exec(`INSERT INTO pheno_scores (plant_id, criterion_id, score) VALUES ($1, $2, $3)`,
+			plantID, s.CriterionID, *s.Score); err != nil {
+			fieldLogger.WithError(err).Error("Failed to save pheno score")
+			apiInternalError(c, "api_database_error")
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	apiOK(c, "api_pheno_scores_saved")
+}
+
+// SetPhenoKeeper marks or unmarks a plant as the pick of its pheno hunt.
+// With track_clones set, a new pick is also marked as a keeper mother so
+// cuttings taken from it show up in its clone family tree.
+func SetPhenoKeeper(c *gin.Context) {
+	plantID, ok := plantIDParam(c)
+	if !ok {
+		return
+	}
+	var in struct {
+		Keeper      bool `json:"keeper"`
+		TrackClones bool `json:"track_clones"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		apiBadRequest(c, "api_invalid_request")
+		return
+	}
+	query := `UPDATE plant SET pheno_keeper = $1 WHERE id = $2`
+	if in.Keeper && in.TrackClones {
+		query = `UPDATE plant SET pheno_keeper = $1, keeper = $1 WHERE id = $2`
+	}
+	res, err := DBFromContext(c).Exec(query, in.Keeper, plantID)
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "SetPhenoKeeper").Error("Failed to update pheno keeper")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		apiNotFound(c, "api_plant_not_found")
+		return
+	}
+	apiOK(c, "api_keeper_saved")
+}
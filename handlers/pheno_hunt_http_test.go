@@ -0,0 +1,207 @@
+package handlers_test
+
+// HTTP-layer tests for handlers/pheno_hunt.go: scoring criteria, weighted
+// plant scores, the sibling comparison and marking the keeper.
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"isley/tests/testutil"
+)
+
+type phenoHuntResponse struct {
+	StrainName string `json:"strain_name"`
+	Criteria   []struct {
+		ID     int     `json:"id"`
+		Name   string  `json:"name"`
+		Weight float64 `json:"weight"`
+	} `json:"criteria"`
+	Plants []struct {
+		ID           int         `json:"id"`
+		Name         string      `json:"name"`
+		Scores       map[int]int `json:"scores"`
+		Score        *float64    `json:"score"`
+		PhenoKeeper  bool        `json:"pheno_keeper"`
+		Keeper       bool        `json:"keeper"`
+		Measurements []struct {
+			Name  string  `json:"name"`
+			Value float64 `json:"value"`
+		} `json:"measurements"`
+		ImageIDs []int `json:"image_ids"`
+	} `json:"plants"`
+}
+
+func getPhenoHunt(t *testing.T, c *testutil.Client, strainID int) phenoHuntResponse {
+	t.Helper()
+	resp := c.Get("/strains/" + strconv.Itoa(strainID) + "/pheno-hunt")
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var got phenoHuntResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	return got
+}
+
+// phenoCriterionID looks up a criterion seeded by the migration.
+func phenoCriterionID(t *testing.T, hunt phenoHuntResponse, name string) int {
+	t.Helper()
+	for _, cr := range hunt.Criteria {
+		if cr.Name == name {
+			return cr.ID
+		}
+	}
+	t.Fatalf("criterion %q not found", name)
+	return 0
+}
+
+func scorePlant(t *testing.T, c *testutil.Client, apiKey string, plantID int, scores map[int]interface{}) {
+	t.Helper()
+	body := []map[string]interface{}{}
+	for id, score := range scores {
+		body = append(body, map[string]interface{}{"criterion_id": id, "score": score})
+	}
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/plant/"+strconv.Itoa(plantID)+"/pheno-scores", apiKey,
+		map[string]interface{}{"scores": body}), http.StatusOK)
+}
+
+func TestPhenoHuntHTTP_WeightedScoresRankSiblings(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "pheno-key")
+	breederID := testutil.SeedBreeder(t, db, "B")
+	strainID := testutil.SeedStrain(t, db, breederID, "Pack of Ten")
+	otherStrain := testutil.SeedStrain(t, db, breederID, "Other")
+	zoneID := testutil.SeedZone(t, db, "Z")
+	one := testutil.SeedPlant(t, db, "Pheno 1", strainID, zoneID)
+	two := testutil.SeedPlant(t, db, "Pheno 2", strainID, zoneID)
+	testutil.SeedPlant(t, db, "Pheno 3", strainID, zoneID)
+	testutil.SeedPlant(t, db, "Not a sibling", otherStrain, zoneID)
+	testutil.MustExec(t, db, `INSERT INTO plant_measurements (plant_id, metric_id, value, date) VALUES ($1, 1, 10, '2026-05-01'), ($1, 1, 14, '2026-05-08')`, two)
+	testutil.MustExec(t, db, `INSERT INTO plant_images (plant_id, image_path, image_description, image_order, image_date) VALUES ($1, '/a.jpg', '', 0, '2026-05-08')`, two)
+	c := server.NewClient(t)
+
+	hunt := getPhenoHunt(t, c, strainID)
+	assert.Equal(t, "Pack of Ten", hunt.StrainName)
+	require.Len(t, hunt.Criteria, 5, "vigor, structure, terpene, yield and resistance out of the box")
+	vigor := phenoCriterionID(t, hunt, "Vigor")
+	terpene := phenoCriterionID(t, hunt, "Terpene")
+
+	scorePlant(t, c, apiKey, one, map[int]interface{}{vigor: 9, terpene: 6})
+	scorePlant(t, c, apiKey, two, map[int]interface{}{vigor: 6, terpene: 9})
+
+	hunt = getPhenoHunt(t, c, strainID)
+	require.Len(t, hunt.Plants, 3, "only plants of the strain are compared")
+	assert.Equal(t, []string{"Pheno 1", "Pheno 2", "Pheno 3"}, []string{hunt.Plants[0].Name, hunt.Plants[1].Name, hunt.Plants[2].Name},
+		"equal scores keep name order and unscored plants come last")
+	require.NotNil(t, hunt.Plants[0].Score)
+	assert.InDelta(t, 7.5, *hunt.Plants[0].Score, 0.001, "unscored criteria are left out of the average")
+	assert.Nil(t, hunt.Plants[2].Score)
+	require.Len(t, hunt.Plants[1].Measurements, 1, "only the latest reading of each metric")
+	assert.Equal(t, 14.0, hunt.Plants[1].Measurements[0].Value)
+	assert.Len(t, hunt.Plants[1].ImageIDs, 1)
+
+	// Weighting terpene twice as much puts Pheno 2 ahead: (6 + 2*9) / 3 = 8.
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/pheno/criteria/"+strconv.Itoa(terpene), apiKey,
+		map[string]interface{}{"name": "Terpene", "weight": 2, "sort_order": 3}), http.StatusOK)
+	hunt = getPhenoHunt(t, c, strainID)
+	assert.Equal(t, two, hunt.Plants[0].ID)
+	require.NotNil(t, hunt.Plants[0].Score)
+	assert.InDelta(t, 8, *hunt.Plants[0].Score, 0.001)
+
+	// A null score clears it, and other criteria keep theirs.
+	scorePlant(t, c, apiKey, two, map[int]interface{}{terpene: nil})
+	hunt = getPhenoHunt(t, c, strainID)
+	assert.Equal(t, one, hunt.Plants[0].ID)
+	assert.Equal(t, map[int]int{vigor: 6}, hunt.Plants[1].Scores)
+
+	// Deleting a criterion drops its scores.
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodDelete, "/pheno/criteria/"+strconv.Itoa(vigor), apiKey, nil), http.StatusOK)
+	assert.Equal(t, 0, testutil.CountRows(t, db, `SELECT COUNT(*) FROM pheno_scores WHERE criterion_id = $1`, vigor))
+	assert.Len(t, getPhenoHunt(t, c, strainID).Criteria, 4)
+
+	// Deleting a plant drops its scores.
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodDelete, "/plant/delete/"+strconv.Itoa(one), apiKey, nil), http.StatusOK)
+	assert.Equal(t, 0, testutil.CountRows(t, db, `SELECT COUNT(*) FROM pheno_scores WHERE plant_id = $1`, one))
+}
+
+func TestPhenoHuntHTTP_KeeperCanTrackClones(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "pheno-key")
+	strainID := testutil.SeedStrain(t, db, testutil.SeedBreeder(t, db, "B"), "S")
+	zoneID := testutil.SeedZone(t, db, "Z")
+	pick := testutil.SeedPlant(t, db, "Pick", strainID, zoneID)
+	other := testutil.SeedPlant(t, db, "Other", strainID, zoneID)
+	c := server.NewClient(t)
+
+	keeperPath := func(id int) string { return "/plant/" + strconv.Itoa(id) + "/pheno-keeper" }
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, keeperPath(other), apiKey,
+		map[string]interface{}{"keeper": true}), http.StatusOK)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, keeperPath(pick), apiKey,
+		map[string]interface{}{"keeper": true, "track_clones": true}), http.StatusOK)
+
+	flags := map[int][2]bool{}
+	for _, p := range getPhenoHunt(t, c, strainID).Plants {
+		flags[p.ID] = [2]bool{p.PhenoKeeper, p.Keeper}
+	}
+	assert.Equal(t, [2]bool{true, true}, flags[pick], "tracking clones keeps the pick as a mother")
+	assert.Equal(t, [2]bool{true, false}, flags[other])
+
+	// Unmarking the pick leaves it as a mother.
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, keeperPath(pick), apiKey,
+		map[string]interface{}{"keeper": false, "track_clones": true}), http.StatusOK)
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM plant WHERE id = $1 AND keeper AND NOT pheno_keeper`, pick))
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, keeperPath(9999), apiKey,
+		map[string]interface{}{"keeper": true}), http.StatusNotFound)
+}
+
+func TestPhenoHuntHTTP_Validation(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "pheno-key")
+	strainID := testutil.SeedStrain(t, db, testutil.SeedBreeder(t, db, "B"), "S")
+	plantID := testutil.SeedPlant(t, db, "P", strainID, testutil.SeedZone(t, db, "Z"))
+	c := server.NewClient(t)
+	criterion := phenoCriterionID(t, getPhenoHunt(t, c, strainID), "Yield")
+
+	for name, tc := range map[string]map[string]interface{}{
+		"no name":     {"name": " ", "weight": 1},
+		"zero weight": {"name": "Smell", "weight": 0},
+		"huge weight": {"name": "Smell", "weight": 1000},
+	} {
+		resp := harvestRequest(t, c, http.MethodPost, "/pheno/criteria", apiKey, tc)
+		testutil.DrainAndClose(resp)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+	}
+	id := createNutrientRow(t, c, apiKey, "/pheno/criteria", map[string]interface{}{"name": " Smell ", "weight": 0.5, "sort_order": 6})
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM pheno_criteria WHERE id = $1 AND name = 'Smell'`, id))
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/pheno/criteria/9999", apiKey,
+		map[string]interface{}{"name": "X", "weight": 1}), http.StatusNotFound)
+
+	scoresPath := "/plant/" + strconv.Itoa(plantID) + "/pheno-scores"
+	score := func(criterionID, value int) map[string]interface{} {
+		return map[string]interface{}{"scores": []map[string]interface{}{{"criterion_id": criterionID, "score": value}}}
+	}
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, scoresPath, apiKey, score(criterion, 11)), http.StatusBadRequest)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, scoresPath, apiKey, score(9999, 5)), http.StatusNotFound)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/plant/9999/pheno-scores", apiKey, score(criterion, 5)), http.StatusNotFound)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, scoresPath, apiKey, map[string]interface{}{
+		"scores": []map[string]interface{}{{"criterion_id": criterion, "score": 5}, {"criterion_id": criterion, "score": 6}},
+	}), http.StatusBadRequest)
+
+	resp := c.Get("/strains/9999/pheno-hunt")
+	testutil.DrainAndClose(resp)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
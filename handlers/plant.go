@@ -10,6 +10,7 @@ import (
 	"isley/model/types"
 	"isley/utils"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -35,6 +36,8 @@ func AddPlant(c *gin.Context) {
 		Clone              int    `json:"clone"`
 		ParentID           int    `json:"parent_id"`
 		DecrementSeedCount bool   `json:"decrement_seed_count"`
+		SeedLotID          *int   `json:"seed_lot_id"`
+		GerminationID      *int   `json:"germination_id"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -52,6 +55,18 @@ func AddPlant(c *gin.Context) {
 		return
 	}
 
+	if input.SeedLotID != nil && *input.SeedLotID <= 0 {
+		input.SeedLotID = nil
+	}
+	if input.GerminationID != nil && *input.GerminationID <= 0 {
+		input.GerminationID = nil
+	}
+	// Seed lots belong to an existing strain.
+	if input.StrainID == nil && (input.SeedLotID != nil || input.GerminationID != nil) {
+		apiBadRequest(c, "api_invalid_seed_lot")
+		return
+	}
+
 	db := DBFromContext(c)
 	store := ConfigStoreFromContext(c)
 
@@ -88,8 +103,22 @@ func AddPlant(c *gin.Context) {
 	}
 	defer tx.Rollback() // no-op after Commit
 
+	// A plant started from a seed lot takes its seed from the lot, which
+	// keeps the strain's seed_count in step; the checkbox only applies
+	// otherwise.
+	seedLot, germination, errKey, err := takePlantSeed(tx, *input.StrainID, input.SeedLotID, input.GerminationID)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to take seed from lot")
+		apiInternalError(c, "api_failed_to_create_plant")
+		return
+	}
+	if errKey != "" {
+		apiBadRequest(c, errKey)
+		return
+	}
+
 	plantID := 0
-	err = tx.QueryRow("INSERT INTO plant (name, zone_id, strain_id, description, clone, parent_plant_id, start_dt, sensors) VALUES ($1, $2, $3, '', $4, NULLIF($5, 0), $6, '[]') RETURNING id", input.Name, *input.ZoneID, *input.StrainID, input.Clone, input.ParentID, input.Date).Scan(&plantID)
+	err = tx.QueryRow("INSERT INTO plant (name, zone_id, strain_id, description, clone, parent_plant_id, start_dt, sensors, seed_lot_id, germination_id) VALUES ($1, $2, $3, '', $4, NULLIF($5, 0), $6, '[]', $7, $8) RETURNING id", input.Name, *input.ZoneID, *input.StrainID, input.Clone, input.ParentID, input.Date, seedLot, germination).Scan(&plantID)
 	if err != nil {
 		fieldLogger.WithError(err).Error("Failed to insert plant")
 		apiInternalError(c, "api_failed_to_create_plant")
@@ -100,14 +129,8 @@ func AddPlant(c *gin.Context) {
 		return
 	}
 
-	if input.DecrementSeedCount {
-		var query string
-		if model.IsPostgres() {
-			query = "UPDATE strain SET seed_count = GREATEST(0, seed_count - 1) WHERE id = $1"
-		} else {
-			query = "UPDATE strain SET seed_count = MAX(0, seed_count - 1) WHERE id = $1"
-		}
-		if _, err := tx.Exec(query, *input.StrainID); err != nil {
+	if input.DecrementSeedCount && seedLot == nil {
+		if err := adjustSeedCount(tx, *input.StrainID, -1); err != nil {
 			fieldLogger.WithError(err).Error("Failed to decrement seed count")
 			apiInternalError(c, "api_failed_to_create_plant")
 			return
@@ -289,7 +312,7 @@ func CreateNewStrain(db *sql.DB, store *config.Store, newStrain *struct {
 	}
 
 	if store != nil {
-		store.SetStrains(GetStrains(db))
+		store.SetStrains(GetStrains(db, StrainSortName))
 	}
 
 	return id, nil
@@ -325,12 +348,21 @@ func DeletePlantById(db *sql.DB, id string) error {
 		table string
 		query string
 	}{
+		{"plant_image_tags", "DELETE FROM plant_image_tags WHERE image_id IN (SELECT id FROM plant_images WHERE plant_id = $1)"},
 		{"plant_images", "DELETE FROM plant_images WHERE plant_id = $1"},
 		{"plant_measurements", "DELETE FROM plant_measurements WHERE plant_id = $1"},
+		{"activity_nutrients", "DELETE FROM activity_nutrients WHERE plant_activity_id IN (SELECT id FROM plant_activity WHERE plant_id = $1)"},
 		{"plant_activity", "DELETE FROM plant_activity WHERE plant_id = $1"},
 		{"plant_status_log", "DELETE FROM plant_status_log WHERE plant_id = $1"},
+		{"task_completions", "DELETE FROM task_completions WHERE plant_id = $1 OR task_id IN (SELECT id FROM tasks WHERE plant_id = $1)"},
+		{"tasks", "DELETE FROM tasks WHERE plant_id = $1"},
+		{"pollinations", "UPDATE pollinations SET female_plant_id = NULL WHERE female_plant_id = $1"},
+		{"pollinations", "UPDATE pollinations SET male_plant_id = NULL WHERE male_plant_id = $1"},
+		{"pheno_scores", "DELETE FROM pheno_scores WHERE plant_id = $1"},
+		{"strain_reviews", "UPDATE strain_reviews SET plant_id = NULL WHERE plant_id = $1"},
 		{"plant", "DELETE FROM plant WHERE id = $1"},
 	}
+	deletes = slices.Concat(harvestDeletes, deletes)
 	for _, d := range deletes {
 		if _, err := tx.Exec(d.query, id); err != nil {
 			fieldLogger.WithError(err).WithField("table", d.table).Error("Failed to delete records")
@@ -377,26 +409,34 @@ func GetPlant(db *sql.DB, id string) types.Plant {
 			   COALESCE(s.url, ''),
 			   s.autoflower,
 			   COALESCE(p.parent_plant_id, 0),
-			   COALESCE(p2.name, '') AS parent_name
+			   COALESCE(p2.name, '') AS parent_name,
+			   COALESCE(p.grow_run_id, 0),
+			   COALESCE(gr.name, '') AS grow_run_name,
+			   COALESCE(p.feeding_schedule_id, 0),
+			   COALESCE(fs.name, '') AS feeding_schedule_name,
+			   p.keeper
 		FROM plant p
 		LEFT OUTER JOIN plant p2 ON COALESCE(p.parent_plant_id, 0) = p2.id
+		LEFT OUTER JOIN grow_runs gr ON gr.id = p.grow_run_id
+		LEFT OUTER JOIN feeding_schedules fs ON fs.id = p.feeding_schedule_id
 		LEFT OUTER JOIN strain s ON p.strain_id = s.id
 		LEFT OUTER JOIN breeder b ON b.id = s.breeder_id
 		LEFT OUTER JOIN zones z ON p.zone_id = z.id
 		WHERE p.id = $1`, orderByExpr, orderByExpr)
 
 	var plantID uint
-	var name, description, strainName, breederName, zoneName, status, sensors, strainURL, parentName string
-	var isClone, autoflower bool
+	var name, description, strainName, breederName, zoneName, status, sensors, strainURL, parentName, growRunName, scheduleName string
+	var isClone, autoflower, keeper bool
 	var startDT time.Time
 	var zoneID, statusID, strainID, cycleTime int
 	var harvestWeight float64
-	var parentID uint
+	var parentID, growRunID, scheduleID uint
 
 	err := db.QueryRow(query, id).Scan(&plantID, &name, &description, &isClone, &startDT,
 		&strainName, &breederName, &zoneName, &zoneID, &status, &statusID,
 		&sensors, &strainID, &harvestWeight, &cycleTime, &strainURL,
-		&autoflower, &parentID, &parentName)
+		&autoflower, &parentID, &parentName, &growRunID, &growRunName,
+		&scheduleID, &scheduleName, &keeper)
 	if err != nil {
 		fieldLogger.WithError(err).Error("Failed to query plant")
 		return plant
@@ -434,7 +474,9 @@ func GetPlant(db *sql.DB, id string) types.Plant {
 		IsClone: isClone, StartDT: startDT, HarvestWeight: harvestWeight,
 		HarvestDate: harvestDate, CycleTime: cycleTime, StrainUrl: strainURL,
 		EstHarvestDate: estHarvestDate, Autoflower: autoflower,
-		ParentID: parentID, ParentName: parentName,
+		ParentID: parentID, ParentName: parentName, Keeper: keeper,
+		GrowRunID: growRunID, GrowRunName: growRunName,
+		ScheduleID: scheduleID, ScheduleName: scheduleName,
 	}
 	return plant
 }
@@ -614,7 +656,7 @@ func loadPlantMeasurements(db *sql.DB, plantID uint) []types.Measurement {
 	fieldLogger := logger.Log.WithField("func", "loadPlantMeasurements")
 
 	rows, err := db.Query(fmt.Sprintf(`
-		SELECT m.id, m.metric_id, me.name, m.value, m.date
+		SELECT m.id, m.metric_id, me.name, COALESCE(me.unit, ''), m.value, m.date
 		FROM plant_measurements m
 		LEFT OUTER JOIN metric me ON me.id = m.metric_id
 		WHERE m.plant_id = $1
@@ -630,14 +672,17 @@ func loadPlantMeasurements(db *sql.DB, plantID uint) []types.Measurement {
 	for rows.Next() {
 		var id uint
 		var metricID int
-		var name string
+		var name, unit string
 		var value float64
 		var date time.Time
-		if err := rows.Scan(&id, &metricID, &name, &value, &date); err != nil {
+		if err := rows.Scan(&id, &metricID, &name, &unit, &value, &date); err != nil {
 			fieldLogger.WithError(err).Error("Failed to scan measurement")
 			continue
 		}
-		measurements = append(measurements, types.Measurement{ID: id, MetricID: metricID, Name: name, Value: value, Date: utils.AsLocal(date)})
+		measurements = append(measurements, types.Measurement{
+			ID: id, MetricID: metricID, Name: name, Value: value, Unit: unit,
+			DisplayValue: value, DisplayUnit: unit, Date: utils.AsLocal(date),
+		})
 	}
 	return measurements
 }
@@ -725,7 +770,11 @@ func loadPlantActivities(db *sql.DB, plantID uint) []types.PlantActivity {
 			activities[i].Measurements = append(activities[i].Measurements, measurement)
 		}
 	}
+	rows.Close()
 
+	if err := attachActivityFeedings(db, plantID, activities); err != nil {
+		fieldLogger.WithError(err).Error("Failed to load activity feedings")
+	}
 	return activities
 }
 
@@ -760,9 +809,12 @@ func loadPlantStatusHistory(db *sql.DB, plantID uint) []types.Status {
 func loadPlantImages(db *sql.DB, plantID uint) (types.PlantImage, []types.PlantImage) {
 	fieldLogger := logger.Log.WithField("func", "loadPlantImages")
 
-	// Latest image
+	// Latest image. Attached timelapse videos can't serve as the hero
+	// background, so only stills are considered.
 	var latestImage types.PlantImage
-	err := db.QueryRow("SELECT id, image_path, image_description, image_order, image_date FROM plant_images WHERE plant_id = $1 ORDER BY image_date DESC LIMIT 1", plantID).Scan(
+	err := db.QueryRow(`SELECT id, image_path, image_description, image_order, image_date FROM plant_images
+		WHERE plant_id = $1 AND image_path NOT LIKE '%.mp4' AND image_path NOT LIKE '%.webm'
+		ORDER BY image_date DESC LIMIT 1`, plantID).Scan(
 		&latestImage.ID, &latestImage.ImagePath, &latestImage.ImageDescription, &latestImage.ImageOrder, &latestImage.ImageDate)
 	if err != nil {
 		fieldLogger.WithError(err).Error("Failed to query latest image")
@@ -801,6 +853,18 @@ func loadPlantImages(db *sql.DB, plantID uint) (types.PlantImage, []types.PlantI
 			ImageDate: utils.AsLocal(imageDate), CreatedAt: time.Now(), UpdatedAt: time.Now(),
 		})
 	}
+
+	ids := make([]uint, len(images))
+	for i := range images {
+		ids[i] = images[i].ID
+	}
+	tags, err := loadImageTags(db, ids)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to query image tags")
+	}
+	for i := range images {
+		images[i].Tags = tags[images[i].ID]
+	}
 	return latestImage, images
 }
 
@@ -1251,6 +1315,9 @@ func HarvestedPlantsHandler(c *gin.Context) {
 		apiInternalError(c, "api_failed_to_retrieve_plants")
 		return
 	}
+	if err := attachPlantHarvestMetrics(db, plants); err != nil {
+		logger.Log.WithError(err).Error("Failed to load harvest metrics")
+	}
 
 	c.JSON(http.StatusOK, plants)
 }
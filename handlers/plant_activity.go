@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
 	"isley/logger"
+	"isley/model/types"
 	"isley/utils"
 	"net/http"
 	"strconv"
@@ -42,9 +44,210 @@ func deleteAllActivityMeasurements(tx *sql.Tx, plantActivityID int) error {
 	return err
 }
 
+// Errors saveActivityFeeding returns for references that don't exist, so
+// handlers can answer 400 rather than 500.
+var (
+	errUnknownFeedRecipe  = errors.New("unknown nutrient recipe")
+	errUnknownFeedProduct = errors.New("unknown nutrient product")
+)
+
+// feedingInput is the structured part of a feed: the recipe it was mixed
+// from, the volume in litres and the amount of each product used, in the
+// product's unit. When Nutrients is empty the recipe is scaled to VolumeL.
+type feedingInput struct {
+	RecipeID  *int     `json:"recipe_id"`
+	VolumeL   *float64 `json:"volume_l"`
+	Nutrients []struct {
+		ProductID int     `json:"product_id"`
+		Amount    float64 `json:"amount"`
+	} `json:"nutrients"`
+}
+
+func (in *feedingInput) validate() error {
+	if in == nil {
+		return nil
+	}
+	if in.RecipeID != nil && *in.RecipeID <= 0 {
+		in.RecipeID = nil
+	}
+	if err := validateNonNegative(map[string]*float64{"volume_l": in.VolumeL}); err != nil {
+		return err
+	}
+	for _, n := range in.Nutrients {
+		if n.ProductID <= 0 {
+			return errors.New("nutrients need a product_id")
+		}
+		if err := validateNonNegative(map[string]*float64{"amount": &n.Amount}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// feedingErrorKey maps the reference errors of saveActivityFeeding to
+// their locale keys.
+func feedingErrorKey(err error) (string, bool) {
+	switch {
+	case errors.Is(err, errUnknownFeedRecipe):
+		return "api_nutrient_recipe_not_found", true
+	case errors.Is(err, errUnknownFeedProduct):
+		return "api_nutrient_product_not_found", true
+	}
+	return "", false
+}
+
+// saveActivityFeeding stores the feed details of a plant activity. Product
+// names and units are copied so the record outlives the product.
+// The transaction is expected to be managed by the caller
+func saveActivityFeeding(tx *sql.Tx, plantActivityID int, feeding *feedingInput) error {
+	if feeding == nil {
+		return nil
+	}
+	type amount struct {
+		productID int
+		value     float64
+	}
+	var amounts []amount
+	for _, n := range feeding.Nutrients {
+		amounts = append(amounts, amount{n.ProductID, n.Amount})
+	}
+
+	if feeding.RecipeID != nil {
+		found, err := rowExists(tx, "nutrient_recipes", *feeding.RecipeID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return errUnknownFeedRecipe
+		}
+		if len(amounts) == 0 && feeding.VolumeL != nil {
+			rows, err := tx.Query("SELECT product_id, amount_per_l FROM nutrient_recipe_items WHERE recipe_id = $1 ORDER BY id", *feeding.RecipeID)
+			if err != nil {
+				return err
+			}
+			for rows.Next() {
+				var a amount
+				if err := rows.Scan(&a.productID, &a.value); err != nil {
+					rows.Close()
+					return err
+				}
+				a.value = roundQuantity(a.value * *feeding.VolumeL)
+				amounts = append(amounts, a)
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE plant_activity SET feed_recipe_id = $1, feed_volume_l = $2 WHERE id = $3",
+		feeding.RecipeID, feeding.VolumeL, plantActivityID); err != nil {
+		return err
+	}
+	for _, a := range amounts {
+		if a.value == 0 {
+			continue
+		}
+		var name, unit string
+		err := tx.QueryRow("SELECT name, unit FROM nutrient_products WHERE id = $1", a.productID).Scan(&name, &unit)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errUnknownFeedProduct
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO activity_nutrients (plant_activity_id, product_id, product_name, unit, amount)
+			VALUES ($1, $2, $3, $4, $5)`, plantActivityID, a.productID, name, unit, a.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteActivityFeeding removes the feed details of a plant activity.
+// The transaction is expected to be managed by the caller
+func deleteActivityFeeding(tx *sql.Tx, plantActivityID int) error {
+	if _, err := tx.Exec("DELETE FROM activity_nutrients WHERE plant_activity_id = $1", plantActivityID); err != nil {
+		return err
+	}
+	_, err := tx.Exec("UPDATE plant_activity SET feed_recipe_id = NULL, feed_volume_l = NULL WHERE id = $1", plantActivityID)
+	return err
+}
+
+// attachActivityFeedings fills in the Feeding of each of a plant's
+// activities that has feed details recorded.
+func attachActivityFeedings(db *sql.DB, plantID uint, activities []types.PlantActivity) error {
+	if len(activities) == 0 {
+		return nil
+	}
+	idx := make(map[uint]int, len(activities))
+	for i, a := range activities {
+		idx[a.ID] = i
+	}
+	feeding := func(id uint) *types.Feeding {
+		i, ok := idx[id]
+		if !ok {
+			return nil
+		}
+		if activities[i].Feeding == nil {
+			activities[i].Feeding = &types.Feeding{Nutrients: []types.FeedNutrient{}}
+		}
+		return activities[i].Feeding
+	}
+
+	rows, err := db.Query(`SELECT pa.id, pa.feed_recipe_id, COALESCE(r.name, ''), pa.feed_volume_l
+		FROM plant_activity pa
+		LEFT JOIN nutrient_recipes r ON r.id = pa.feed_recipe_id
+		WHERE pa.plant_id = $1 AND (pa.feed_recipe_id IS NOT NULL OR pa.feed_volume_l IS NOT NULL)`, plantID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id uint
+		var recipeID sql.NullInt64
+		var recipeName string
+		var volume sql.NullFloat64
+		if err := rows.Scan(&id, &recipeID, &recipeName, &volume); err != nil {
+			return err
+		}
+		if f := feeding(id); f != nil {
+			f.RecipeID, f.RecipeName, f.VolumeL = nullIntPtr(recipeID), recipeName, nullFloatPtr(volume)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	rows, err = db.Query(`SELECT n.plant_activity_id, n.product_id, n.product_name, n.unit, n.amount
+		FROM activity_nutrients n
+		JOIN plant_activity pa ON pa.id = n.plant_activity_id
+		WHERE pa.plant_id = $1
+		ORDER BY n.id`, plantID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id uint
+		var productID sql.NullInt64
+		var n types.FeedNutrient
+		if err := rows.Scan(&id, &productID, &n.Name, &n.Unit, &n.Amount); err != nil {
+			return err
+		}
+		n.ProductID = nullIntPtr(productID)
+		if f := feeding(id); f != nil {
+			f.Nutrients = append(f.Nutrients, n)
+		}
+	}
+	return rows.Err()
+}
+
 // createPlantActivity creates a new plant activity and its linked measurements within a transaction.
 // The transaction is expected to be managed by the caller
-func createPlantActivity(tx *sql.Tx, plantID int, activityID int, note string, date string, measurements []measurementInput) error {
+func createPlantActivity(tx *sql.Tx, plantID int, activityID int, note string, date string, measurements []measurementInput, feeding *feedingInput) error {
 	var activityLogID int
 	err := tx.QueryRow(`
 		INSERT INTO plant_activity (plant_id, activity_id, note, date)
@@ -58,7 +261,7 @@ func createPlantActivity(tx *sql.Tx, plantID int, activityID int, note string, d
 		return err
 	}
 
-	return nil
+	return saveActivityFeeding(tx, activityLogID, feeding)
 }
 
 func CreatePlantActivity(c *gin.Context) {
@@ -72,6 +275,7 @@ func CreatePlantActivity(c *gin.Context) {
 		Note         string             `json:"note"`
 		Date         string             `json:"date"`
 		Measurements []measurementInput `json:"measurements"`
+		Feeding      *feedingInput      `json:"feeding"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -88,6 +292,10 @@ func CreatePlantActivity(c *gin.Context) {
 		apiBadRequest(c, err.Error())
 		return
 	}
+	if err := input.Feeding.validate(); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
 
 	fieldLogger = logger.Log.WithFields(logrus.Fields{
 		"plant_id":    input.PlantID,
@@ -106,7 +314,11 @@ func CreatePlantActivity(c *gin.Context) {
 	}
 	defer tx.Rollback()
 
-	if err := createPlantActivity(tx, input.PlantID, input.ActivityID, input.Note, input.Date, input.Measurements); err != nil {
+	if err := createPlantActivity(tx, input.PlantID, input.ActivityID, input.Note, input.Date, input.Measurements, input.Feeding); err != nil {
+		if key, ok := feedingErrorKey(err); ok {
+			apiBadRequest(c, key)
+			return
+		}
 		fieldLogger.WithError(err).Error("Failed to create plant activity")
 		apiInternalError(c, "api_failed_to_create_activity")
 		return
@@ -133,6 +345,9 @@ func EditActivity(c *gin.Context) {
 		ActivityID   uint               `json:"activity_id"`
 		Note         string             `json:"note"`
 		Measurements []measurementInput `json:"measurements"`
+		// Feeding replaces the feed details when it is present; leaving it
+		// out keeps them.
+		Feeding *feedingInput `json:"feeding"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -149,6 +364,10 @@ func EditActivity(c *gin.Context) {
 		apiBadRequest(c, err.Error())
 		return
 	}
+	if err := input.Feeding.validate(); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
 
 	logger.Log.WithFields(logrus.Fields{
 		"id":          input.ID,
@@ -190,6 +409,22 @@ func EditActivity(c *gin.Context) {
 		return
 	}
 
+	if input.Feeding != nil {
+		err := deleteActivityFeeding(tx, int(input.ID))
+		if err == nil {
+			err = saveActivityFeeding(tx, int(input.ID), input.Feeding)
+		}
+		if key, ok := feedingErrorKey(err); ok {
+			apiBadRequest(c, key)
+			return
+		}
+		if err != nil {
+			fieldLogger.WithError(err).Error("Failed to save activity feeding")
+			apiInternalError(c, "api_failed_to_update_activity")
+			return
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		fieldLogger.WithError(err).Error("Failed to commit transaction")
 		apiInternalError(c, "api_failed_to_commit_tx")
@@ -231,6 +466,12 @@ func DeleteActivity(c *gin.Context) {
 		return
 	}
 
+	if err := deleteActivityFeeding(tx, activityID); err != nil {
+		fieldLogger.WithError(err).Error("Failed to delete activity feeding")
+		apiInternalError(c, "api_failed_to_delete_activity")
+		return
+	}
+
 	// Then delete the activity itself
 	_, err = tx.Exec("DELETE FROM plant_activity WHERE id = $1", activityID)
 	if err != nil {
@@ -260,6 +501,7 @@ func RecordMultiPlantActivity(c *gin.Context) {
 		Note         string             `json:"note"`
 		Date         string             `json:"date"`
 		Measurements []measurementInput `json:"measurements"`
+		Feeding      *feedingInput      `json:"feeding"`
 	}
 
 	if err := c.BindJSON(&request); err != nil {
@@ -282,6 +524,10 @@ func RecordMultiPlantActivity(c *gin.Context) {
 		apiBadRequest(c, err.Error())
 		return
 	}
+	if err := request.Feeding.validate(); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
 
 	fieldLogger.WithFields(logrus.Fields{
 		"activity_id": request.ActivityID,
@@ -301,7 +547,11 @@ func RecordMultiPlantActivity(c *gin.Context) {
 	defer tx.Rollback()
 
 	for _, plantID := range request.PlantIDs {
-		if err := createPlantActivity(tx, plantID, request.ActivityID, request.Note, request.Date, request.Measurements); err != nil {
+		if err := createPlantActivity(tx, plantID, request.ActivityID, request.Note, request.Date, request.Measurements, request.Feeding); err != nil {
+			if key, ok := feedingErrorKey(err); ok {
+				apiBadRequest(c, key)
+				return
+			}
 			fieldLogger.WithError(err).WithField("plant_id", plantID).Error("Failed to create activity for plant")
 			apiInternalError(c, "api_failed_to_save_activity")
 			return
@@ -93,7 +93,7 @@ func ParseActivityLogFilters(c *gin.Context) (ActivityLogFilters, error) {
 		}
 	}
 
-	loc := appTimeLocation(ConfigStoreFromContext(c).Timezone())
+	loc := AppTimeLocation(ConfigStoreFromContext(c).Timezone())
 	if v := strings.TrimSpace(c.Query("from")); v != "" {
 		if t, err := time.ParseInLocation(utils.LayoutDate, v, loc); err == nil {
 			f.From = &t
@@ -335,11 +335,11 @@ func ListAllActivities(c *gin.Context) {
 	})
 }
 
-// appTimeLocation returns the supplied timezone identifier resolved to
+// AppTimeLocation returns the supplied timezone identifier resolved to
 // a *time.Location, falling back to time.Local when the input is empty
 // or unparseable. Mirrors the defensive pattern used by the
 // "formatDateTime" template helper.
-func appTimeLocation(tz string) *time.Location {
+func AppTimeLocation(tz string) *time.Location {
 	if tz != "" {
 		if loc, err := time.LoadLocation(tz); err == nil {
 			return loc
@@ -352,7 +352,7 @@ func appTimeLocation(tz string) *time.Location {
 // row in the supplied timezone, matching the "formatDateTime" template
 // helper. Pass the empty string to render in time.Local.
 func formatActivityDate(t time.Time, tz string) string {
-	return t.In(appTimeLocation(tz)).Format(utils.LayoutDateTime)
+	return t.In(AppTimeLocation(tz)).Format(utils.LayoutDateTime)
 }
 
 // exportFilenameSuffix returns a short descriptor appended to export filenames
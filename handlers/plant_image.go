@@ -8,11 +8,13 @@ import (
 	"github.com/sirupsen/logrus"
 	"io"
 	"isley/logger"
+	"isley/storage"
 	"isley/utils"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -54,6 +56,8 @@ func UploadPlantImages(c *gin.Context) {
 	dates := form.Value["dates[]"]
 
 	db := DBFromContext(c)
+	store := StorageFromContext(c)
+	stripMetadata := ConfigStoreFromContext(c).GuestMode() == 1
 	imageIDs := make([]int, 0)
 	// Process each uploaded file
 	for index, fileHeader := range files {
@@ -95,41 +99,52 @@ func UploadPlantImages(c *gin.Context) {
 		savePath := filepath.Join(UploadDirFromContext(c), "plants", fileName)
 		fileLogger = fileLogger.WithField("savePath", savePath)
 
-		// Create the uploads directory if it doesn't exist
-		err = os.MkdirAll(filepath.Dir(savePath), os.ModePerm)
+		data, err := io.ReadAll(io.LimitReader(file, MaxPlantImageFileSize+1))
 		if err != nil {
-			fileLogger.WithError(err).Error("Failed to create directory")
-			apiInternalError(c, "api_failed_to_create_directory")
+			fileLogger.WithError(err).Error("Failed to read uploaded file")
+			apiInternalError(c, "api_failed_to_process_file")
 			return
 		}
+		meta := utils.ReadImageMetadata(data)
 
-		// Save the file to the filesystem
-		out, err := os.Create(savePath)
+		// Photos can be viewed publicly in guest mode, so drop their
+		// location and camera metadata before they are stored.
+		data, err = utils.NormalizeUploadedImage(data, stripMetadata)
 		if err != nil {
-			fileLogger.WithError(err).Error("Failed to create file on filesystem")
-			apiInternalError(c, "api_failed_to_save_file")
+			fileLogger.WithError(err).Error("Failed to normalize uploaded image")
+			apiBadRequest(c, "api_failed_to_process_file")
 			return
 		}
-		defer out.Close()
-		_, err = io.Copy(out, file)
-		if err != nil {
-			fileLogger.WithError(err).Error("Failed to save file to disk")
+
+		// Save the file and its variants to storage. The database keeps
+		// the upload-rooted path whichever backend holds the bytes.
+		key := uploadKey(c, savePath)
+		if err := storage.PutBytes(c.Request.Context(), store, key, data); err != nil {
+			fileLogger.WithError(err).Error("Failed to save file to storage")
 			apiInternalError(c, "api_failed_to_save_file")
 			return
 		}
+		if err := putImageVariants(c.Request.Context(), store, key, data); err != nil {
+			// Variants are regenerated on first view, so this isn't fatal.
+			fileLogger.WithError(err).Warn("Failed to generate image variants")
+		}
 
-		// Parse description and date
+		// Parse description and date. Without a date, the photo's own
+		// capture time is used when it has one.
 		description := ""
 		if index < len(descriptions) {
 			description = descriptions[index]
 		}
 		imageDate := time.Now()
-		if index < len(dates) {
+		if !meta.DateTimeOriginal.IsZero() {
+			imageDate = meta.DateTimeOriginal
+		}
+		if index < len(dates) && dates[index] != "" {
 			parsedDate, err := time.Parse(utils.LayoutDate, dates[index])
 			if err == nil {
 				imageDate = parsedDate
 			} else {
-				fileLogger.WithError(err).Warn("Failed to parse image date, using current time as fallback")
+				fileLogger.WithError(err).Warn("Failed to parse image date, using capture or current time as fallback")
 			}
 		}
 
@@ -182,15 +197,24 @@ func DeletePlantImage(c *gin.Context) {
 	}
 	fileLogger = logger.Log.WithField("imagePath", imagePath)
 
-	// Delete the image from the filesystem
-	err = os.Remove(imagePath)
-	if err != nil && !os.IsNotExist(err) {
-		fileLogger.WithError(err).Error("Failed to delete file from filesystem")
+	// Delete the image and its variants from storage
+	store := StorageFromContext(c)
+	key := uploadKey(c, imagePath)
+	if err := store.Delete(c.Request.Context(), key); err != nil {
+		fileLogger.WithError(err).Error("Failed to delete file from storage")
 		apiInternalError(c, "api_failed_to_delete_file")
 		return
 	}
+	for _, variant := range utils.ImageVariantNames() {
+		if err := store.Delete(c.Request.Context(), imageVariantKey(key, variant)); err != nil {
+			fileLogger.WithError(err).WithField("variant", variant).Warn("Failed to delete image variant")
+		}
+	}
 
-	// Delete the image record from the database
+	// Delete the image record and its tags from the database
+	if _, err := db.Exec("DELETE FROM plant_image_tags WHERE image_id = $1", imageID); err != nil {
+		fileLogger.WithError(err).Warn("Failed to delete image tags")
+	}
 	_, err = db.Exec("DELETE FROM plant_images WHERE id = $1", imageID)
 	if err != nil {
 		fileLogger.WithError(err).Error("Failed to delete image record from database")
@@ -201,3 +225,104 @@ func DeletePlantImage(c *gin.Context) {
 	fileLogger.Info("Image deleted successfully")
 	apiOK(c, "api_image_deleted")
 }
+
+// GetPlantImageVariant serves a scaled variant ("thumb" or "medium") of a
+// plant image, generating it on first request for images uploaded before
+// variants existed. Videos and images that can't be decoded fall back to
+// the original file.
+func GetPlantImageVariant(c *gin.Context) {
+	fileLogger := logger.Log.WithFields(logrus.Fields{
+		"handler": "GetPlantImageVariant",
+	})
+
+	imageID, err := strconv.Atoi(c.Param("imageID"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_image_id")
+		return
+	}
+	variant := c.Param("variant")
+	if !utils.IsImageVariant(variant) {
+		apiBadRequest(c, "api_invalid_image_variant")
+		return
+	}
+
+	var imagePath string
+	err = DBFromContext(c).QueryRow("SELECT image_path FROM plant_images WHERE id = $1", imageID).Scan(&imagePath)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apiNotFound(c, "api_image_not_found")
+		} else {
+			fileLogger.WithError(err).Error("Failed to query database for image")
+			apiInternalError(c, "api_database_query_error")
+		}
+		return
+	}
+
+	// Paths saved on Windows hosts use backslashes.
+	imagePath = filepath.FromSlash(strings.ReplaceAll(imagePath, "\\", "/"))
+	if utils.IsVideoPath(imagePath) {
+		c.Redirect(http.StatusFound, "/"+filepath.ToSlash(imagePath))
+		return
+	}
+	store := StorageFromContext(c)
+	if _, local := storage.LocalPath(store, ""); !local {
+		serveStoredImageVariant(c, store, uploadKey(c, imagePath), variant)
+		return
+	}
+	path, err := utils.EnsureImageVariant(imagePath, variant)
+	if err != nil {
+		if _, statErr := os.Stat(imagePath); statErr != nil {
+			apiNotFound(c, "api_image_not_found")
+			return
+		}
+		fileLogger.WithError(err).WithField("imageID", imageID).Warn("Failed to generate image variant, serving original")
+		path = imagePath
+	}
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.File(path)
+}
+
+// serveStoredImageVariant is GetPlantImageVariant for object storage:
+// the variant is read from the store, or built from the original and
+// stored for next time when it is missing.
+func serveStoredImageVariant(c *gin.Context, store storage.Store, key, variant string) {
+	fileLogger := logger.Log.WithFields(logrus.Fields{
+		"handler": "GetPlantImageVariant",
+		"key":     key,
+	})
+	ctx := c.Request.Context()
+
+	data, err := storage.ReadAll(ctx, store, imageVariantKey(key, variant))
+	if err != nil {
+		if !storage.IsNotExist(err) {
+			fileLogger.WithError(err).Error("Failed to read image variant from storage")
+			apiInternalError(c, "api_failed_to_process_file")
+			return
+		}
+		original, err := storage.ReadAll(ctx, store, key)
+		if err != nil {
+			if storage.IsNotExist(err) {
+				apiNotFound(c, "api_image_not_found")
+			} else {
+				fileLogger.WithError(err).Error("Failed to read image from storage")
+				apiInternalError(c, "api_failed_to_process_file")
+			}
+			return
+		}
+		variants, err := utils.EncodeImageVariants(original)
+		if err != nil {
+			fileLogger.WithError(err).Warn("Failed to generate image variant, serving original")
+			c.Header("Cache-Control", "public, max-age=86400")
+			c.Data(http.StatusOK, http.DetectContentType(original), original)
+			return
+		}
+		for name, encoded := range variants {
+			if err := storage.PutBytes(ctx, store, imageVariantKey(key, name), encoded); err != nil {
+				fileLogger.WithError(err).Warn("Failed to store image variant")
+			}
+		}
+		data = variants[variant]
+	}
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, "image/jpeg", data)
+}
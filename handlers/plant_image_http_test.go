@@ -8,18 +8,30 @@ package handlers_test
 //   - auth gating across both endpoints
 //   - upload with no files (handler should still respond 200 with an
 //     empty ids array)
+//   - EXIF handling: capture date fallback and guest-mode stripping
+//   - the thumb/medium variant route
 
 import (
 	"bytes"
+	"database/sql"
+	"encoding/binary"
 	"encoding/json"
+	"image"
+	"image/color"
+	"image/jpeg"
 	"mime/multipart"
 	"net/http"
+	"os"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"isley/config"
 	"isley/tests/testutil"
+	"isley/utils"
 )
 
 // ---------------------------------------------------------------------------
@@ -55,6 +67,77 @@ func TestPlantImageHTTP_AuthGating(t *testing.T) {
 	}
 }
 
+// exifPhoto returns a w×h JPEG carrying an EXIF block with the given
+// orientation, a DateTimeOriginal of taken (EXIF layout) and a GPS IFD —
+// the shape of a typical phone photo.
+func exifPhoto(t *testing.T, w, h, orientation int, taken string) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{G: 200, A: 255})
+		}
+	}
+	var enc bytes.Buffer
+	require.NoError(t, jpeg.Encode(&enc, img, nil))
+
+	// Little-endian TIFF: IFD0 at 8, the EXIF IFD at 50, the GPS IFD at
+	// 68 and the date string at 86.
+	le := binary.LittleEndian
+	tiff := make([]byte, 86)
+	copy(tiff, "II")
+	le.PutUint16(tiff[2:], 42)
+	le.PutUint32(tiff[4:], 8)
+	entry := func(at int, tag, typ uint16, count, value uint32) {
+		le.PutUint16(tiff[at:], tag)
+		le.PutUint16(tiff[at+2:], typ)
+		le.PutUint32(tiff[at+4:], count)
+		le.PutUint32(tiff[at+8:], value)
+	}
+	le.PutUint16(tiff[8:], 3)
+	entry(10, 0x0112, 3, 1, uint32(orientation))
+	entry(22, 0x8769, 4, 1, 50)
+	entry(34, 0x8825, 4, 1, 68)
+	le.PutUint16(tiff[50:], 1)
+	entry(52, 0x9003, 2, 20, 86)
+	le.PutUint16(tiff[68:], 1)
+	entry(70, 0x0001, 2, 2, uint32('N'))
+	tiff = append(tiff, append([]byte(taken), 0)...)
+
+	app1 := append([]byte("Exif\x00\x00"), tiff...)
+	out := []byte{0xFF, 0xD8, 0xFF, 0xE1, byte((len(app1) + 2) >> 8), byte(len(app1) + 2)}
+	out = append(out, app1...)
+	return append(out, enc.Bytes()[2:]...)
+}
+
+// uploadPhoto posts a single photo to /plant/:plantID/images/upload and
+// returns the new image's id. An empty date leaves the date field blank.
+func uploadPhoto(t *testing.T, c *testutil.Client, plantID int, apiKey string, photo []byte, date string) int {
+	t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	fw, err := w.CreateFormFile("images[]", "photo.jpg")
+	require.NoError(t, err)
+	_, err = fw.Write(photo)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteField("descriptions[]", ""))
+	require.NoError(t, w.WriteField("dates[]", date))
+	require.NoError(t, w.Close())
+
+	resp, err := c.Do(testutil.APIReq(t, http.MethodPost, c.BaseURL+"/plant/"+strconv.Itoa(plantID)+"/images/upload",
+		apiKey, &buf, w.FormDataContentType()))
+	require.NoError(t, err)
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var got struct {
+		IDs []int `json:"ids"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	require.Len(t, got.IDs, 1)
+	return got.IDs[0]
+}
+
 // ---------------------------------------------------------------------------
 // UploadPlantImages — extra branches
 // ---------------------------------------------------------------------------
@@ -133,3 +216,109 @@ func TestPlantImageHTTP_Delete_NonNumericImageID(t *testing.T) {
 	defer testutil.DrainAndClose(resp)
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 }
+
+// ---------------------------------------------------------------------------
+// EXIF handling
+// ---------------------------------------------------------------------------
+
+// seedImagePlant seeds a plant to attach photos to.
+func seedImagePlant(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	strainID := testutil.SeedStrain(t, db, testutil.SeedBreeder(t, db, "B"), "S")
+	return testutil.SeedPlant(t, db, "Plant 1", strainID, testutil.SeedZone(t, db, "Z"))
+}
+
+func TestPlantImageHTTP_Upload_DateFromEXIF(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithUploadDir(t.TempDir()))
+	apiKey := testutil.SeedAPIKey(t, db, "img-exif-date-key")
+	plantID := seedImagePlant(t, db)
+	c := server.NewClient(t)
+
+	photo := exifPhoto(t, 16, 8, 1, "2026:03:14 09:26:53")
+	exifID := uploadPhoto(t, c, plantID, apiKey, photo, "")
+	datedID := uploadPhoto(t, c, plantID, apiKey, photo, "2026-04-25")
+
+	imageDate := func(id int) time.Time {
+		var got time.Time
+		require.NoError(t, db.QueryRow(`SELECT image_date FROM plant_images WHERE id = $1`, id).Scan(&got))
+		return utils.AsLocal(got)
+	}
+	assert.Equal(t, "2026-03-14 09:26:53", imageDate(exifID).Format("2006-01-02 15:04:05"),
+		"a blank date falls back to the capture time")
+	assert.Equal(t, "2026-04-25", imageDate(datedID).Format("2006-01-02"), "an explicit date wins")
+
+	// Outside guest mode the original is stored untouched.
+	var path string
+	require.NoError(t, db.QueryRow(`SELECT image_path FROM plant_images WHERE id = $1`, exifID).Scan(&path))
+	stored, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, photo, stored)
+}
+
+func TestPlantImageHTTP_Upload_GuestModeStripsMetadata(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	store := config.NewStore()
+	store.SetGuestMode(1)
+	server := testutil.NewTestServer(t, db, testutil.WithUploadDir(t.TempDir()), testutil.WithConfigStore(store))
+	apiKey := testutil.SeedAPIKey(t, db, "img-exif-guest-key")
+	plantID := seedImagePlant(t, db)
+	c := server.NewClient(t)
+
+	id := uploadPhoto(t, c, plantID, apiKey, exifPhoto(t, 16, 8, 6, "2026:03:14 09:26:53"), "")
+
+	var path string
+	require.NoError(t, db.QueryRow(`SELECT image_path FROM plant_images WHERE id = $1`, id).Scan(&path))
+	stored, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(stored), "Exif\x00\x00", "GPS and camera metadata must be removed")
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(stored))
+	require.NoError(t, err)
+	assert.Equal(t, [2]int{8, 16}, [2]int{cfg.Width, cfg.Height}, "orientation is applied before EXIF is dropped")
+}
+
+// ---------------------------------------------------------------------------
+// GetPlantImageVariant
+// ---------------------------------------------------------------------------
+
+func TestPlantImageHTTP_Variant(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithUploadDir(t.TempDir()), testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "img-variant-key")
+	plantID := seedImagePlant(t, db)
+	c := server.NewClient(t)
+
+	id := uploadPhoto(t, c, plantID, apiKey, exifPhoto(t, 1000, 600, 1, "2026:03:14 09:26:53"), "")
+	var path string
+	require.NoError(t, db.QueryRow(`SELECT image_path FROM plant_images WHERE id = $1`, id).Scan(&path))
+
+	resp := c.Get("/plant/images/" + strconv.Itoa(id) + "/thumb")
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	cfg, err := jpeg.DecodeConfig(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, [2]int{400, 240}, [2]int{cfg.Width, cfg.Height})
+
+	bad := c.Get("/plant/images/" + strconv.Itoa(id) + "/huge")
+	defer testutil.DrainAndClose(bad)
+	assert.Equal(t, http.StatusBadRequest, bad.StatusCode)
+
+	missing := c.Get("/plant/images/9999/thumb")
+	defer testutil.DrainAndClose(missing)
+	assert.Equal(t, http.StatusNotFound, missing.StatusCode)
+
+	// Deleting the image takes its variants with it.
+	del := c.APIDelete(t, "/plant/images/"+strconv.Itoa(id)+"/delete", apiKey)
+	defer testutil.DrainAndClose(del)
+	require.Equal(t, http.StatusOK, del.StatusCode)
+	for _, variant := range []string{utils.ImageVariantThumb, utils.ImageVariantMedium} {
+		_, err := os.Stat(utils.ImageVariantPath(path, variant))
+		assert.Truef(t, os.IsNotExist(err), "%s variant should be removed", variant)
+	}
+}
@@ -0,0 +1,440 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"isley/logger"
+	"isley/model/types"
+	"isley/utils"
+)
+
+// MaxClonesPerBatch caps how many cuttings one "take clones" request
+// creates.
+const MaxClonesPerBatch = 50
+
+// cloneActivityName is the activity logged on a mother when cuttings are
+// taken from it. Migration 033 adds it.
+const cloneActivityName = "Clone"
+
+// cloneStartStatus is the stage cuttings start in unless another is
+// chosen; the first active stage stands in when it has been removed.
+const cloneStartStatus = "Seedling"
+
+// cloneFailedStatus is the status that marks a cutting as failed.
+const cloneFailedStatus = "Dead"
+
+// clonePlants is every plant as a clone tree node, with each plant's
+// parent and children.
+type clonePlants struct {
+	nodes    map[int]*types.CloneNode
+	parent   map[int]int
+	children map[int][]int
+}
+
+// loadClonePlants loads every plant with its strain and current status.
+// Children are listed in start order.
+func loadClonePlants(db *sql.DB) (*clonePlants, error) {
+	rows, err := db.Query(`SELECT p.id, p.name, COALESCE(s.name, ''), COALESCE(p.parent_plant_id, 0),
+			p.clone, p.keeper, p.start_dt
+		FROM plant p
+		LEFT JOIN strain s ON s.id = p.strain_id
+		ORDER BY p.start_dt, p.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cp := &clonePlants{nodes: map[int]*types.CloneNode{}, parent: map[int]int{}, children: map[int][]int{}}
+	var order []int
+	for rows.Next() {
+		n := &types.CloneNode{}
+		var parent int
+		if err := rows.Scan(&n.ID, &n.Name, &n.StrainName, &parent, &n.Clone, &n.Keeper, &n.StartDT); err != nil {
+			return nil, err
+		}
+		n.StartDT = utils.AsLocal(n.StartDT)
+		cp.nodes[n.ID] = n
+		cp.parent[n.ID] = parent
+		order = append(order, n.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, id := range order {
+		if parent := cp.parent[id]; cp.nodes[parent] != nil && parent != id {
+			cp.children[parent] = append(cp.children[parent], id)
+		}
+	}
+
+	// Rows come in date order, so the last one per plant is its current
+	// status.
+	rows, err = db.Query(`SELECT psl.plant_id, ps.status, ps.active
+		FROM plant_status_log psl
+		JOIN plant_status ps ON ps.id = psl.status_id
+		ORDER BY psl.plant_id, psl.date, psl.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var status string
+		var active bool
+		if err := rows.Scan(&id, &status, &active); err != nil {
+			return nil, err
+		}
+		if n := cp.nodes[id]; n != nil {
+			n.Status, n.Living = status, active
+		}
+	}
+	return cp, rows.Err()
+}
+
+// root returns the first mother above plantID and how many generations
+// down from it the plant is. A parent loop stops the climb at the plant
+// where it closes.
+func (cp *clonePlants) root(plantID int) (int, int) {
+	seen := map[int]bool{plantID: true}
+	id, generation := plantID, 0
+	for {
+		parent := cp.parent[id]
+		if cp.nodes[parent] == nil || seen[parent] {
+			return id, generation
+		}
+		seen[parent] = true
+		id = parent
+		generation++
+	}
+}
+
+// tree fills in the children of id and their generations, returning the
+// node. seen guards against parent loops.
+func (cp *clonePlants) tree(id, generation int, seen map[int]bool) *types.CloneNode {
+	n := cp.nodes[id]
+	seen[id] = true
+	n.Generation = generation
+	n.Children = []*types.CloneNode{}
+	for _, child := range cp.children[id] {
+		if !seen[child] {
+			n.Children = append(n.Children, cp.tree(child, generation+1, seen))
+		}
+	}
+	return n
+}
+
+// stats summarises the cuttings taken from id.
+func (cp *clonePlants) stats(id int) types.MotherStats {
+	n := cp.nodes[id]
+	s := types.MotherStats{
+		PlantID: n.ID, Name: n.Name, StrainName: n.StrainName, Status: n.Status,
+		Living: n.Living, Keeper: n.Keeper,
+	}
+	for _, child := range cp.children[id] {
+		c := cp.nodes[child]
+		s.ClonesTaken++
+		if c.Status == cloneFailedStatus {
+			s.ClonesFailed++
+		}
+		if s.LastTaken == nil || c.StartDT.After(*s.LastTaken) {
+			started := c.StartDT
+			s.LastTaken = &started
+		}
+	}
+	if s.ClonesTaken > 0 {
+		rate := math.Round(float64(s.ClonesTaken-s.ClonesFailed)/float64(s.ClonesTaken)*1000) / 1000
+		s.SuccessRate = &rate
+	}
+	seen := map[int]bool{id: true}
+	queue := append([]int(nil), cp.children[id]...)
+	for len(queue) > 0 {
+		child := queue[0]
+		queue = queue[1:]
+		if seen[child] {
+			continue
+		}
+		seen[child] = true
+		s.Descendants++
+		queue = append(queue, cp.children[child]...)
+	}
+	return s
+}
+
+// LoadCloneFamily returns the clone family tree plantID belongs to, or
+// nil when the plant doesn't exist.
+func LoadCloneFamily(db *sql.DB, plantID int) (*types.CloneFamily, error) {
+	cp, err := loadClonePlants(db)
+	if err != nil {
+		return nil, err
+	}
+	if cp.nodes[plantID] == nil {
+		return nil, nil
+	}
+	root, generation := cp.root(plantID)
+	return &types.CloneFamily{
+		PlantID:    plantID,
+		Generation: generation,
+		Root:       cp.tree(root, 0, map[int]bool{}),
+		Mother:     cp.stats(plantID),
+	}, nil
+}
+
+// LoadMotherStats returns the clone stats of every plant that cuttings
+// have been taken from or that is flagged as a keeper: keepers first,
+// then by name.
+func LoadMotherStats(db *sql.DB) ([]types.MotherStats, error) {
+	cp, err := loadClonePlants(db)
+	if err != nil {
+		return nil, err
+	}
+	mothers := []types.MotherStats{}
+	for id, n := range cp.nodes {
+		if n.Keeper || len(cp.children[id]) > 0 {
+			mothers = append(mothers, cp.stats(id))
+		}
+	}
+	sort.Slice(mothers, func(i, j int) bool {
+		if mothers[i].Keeper != mothers[j].Keeper {
+			return mothers[i].Keeper
+		}
+		if mothers[i].Name != mothers[j].Name {
+			return mothers[i].Name < mothers[j].Name
+		}
+		return mothers[i].PlantID < mothers[j].PlantID
+	})
+	return mothers, nil
+}
+
+// GetPlantLineage returns the clone family tree of the plant in the path.
+func GetPlantLineage(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "GetPlantLineage")
+	plantID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_plant_id")
+		return
+	}
+	family, err := LoadCloneFamily(DBFromContext(c), plantID)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to load clone family")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if family == nil {
+		apiNotFound(c, "api_plant_not_found")
+		return
+	}
+	c.JSON(http.StatusOK, family)
+}
+
+// ListMotherStats returns LoadMotherStats as JSON.
+func ListMotherStats(c *gin.Context) {
+	mothers, err := LoadMotherStats(DBFromContext(c))
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "ListMotherStats").Error("Failed to load mothers")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusOK, mothers)
+}
+
+// cloneBatchInput is the body of the take clones request. ZoneID and
+// StatusID default to the mother's zone and cloneStartStatus;
+// cuttings are named "<NamePrefix> #<n>", numbered on from the mother's
+// earlier cuttings, with the mother's name as the default prefix.
+type cloneBatchInput struct {
+	Count      int    `json:"count"`
+	Date       string `json:"date"`
+	ZoneID     *int   `json:"zone_id"`
+	StatusID   *int   `json:"status_id"`
+	NamePrefix string `json:"name_prefix"`
+	Note       string `json:"note"`
+}
+
+// lockedActivityID returns the id of the named built-in activity, adding
+// it back as a locked activity if it has gone missing.
+func lockedActivityID(tx *sql.Tx, name string) (int, error) {
+	var id int
+	err := tx.QueryRow(`SELECT id FROM activity WHERE name = $1`, name).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = tx.QueryRow(`INSERT INTO activity (name, lock) VALUES ($1, TRUE) RETURNING id`, name).Scan(&id)
+	}
+	return id, err
+}
+
+// TakeClones creates a batch of cuttings from the plant in the path:
+// child plants of the same strain linked to it as their mother, each
+// starting in the chosen stage, with a Clone activity logged on the
+// mother.
+func TakeClones(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "TakeClones")
+	motherID, ok := plantIDParam(c)
+	if !ok {
+		return
+	}
+	var in cloneBatchInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		apiBadRequest(c, "api_invalid_request")
+		return
+	}
+	if in.Count < 1 || in.Count > MaxClonesPerBatch {
+		apiBadRequest(c, "api_invalid_clone_count")
+		return
+	}
+	in.NamePrefix = strings.TrimSpace(in.NamePrefix)
+	if err := utils.ValidateStringLength("name_prefix", in.NamePrefix, utils.MaxNameLength); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
+	if err := utils.ValidateStringLength("note", in.Note, utils.MaxNotesLength); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
+	if err := utils.ValidateDate("date", in.Date); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
+	// A bare date takes the current time of day, so the clone activity
+	// sorts with the rest of the day's activities.
+	now := taskToday(c)
+	switch {
+	case in.Date == "":
+		in.Date = now.Format(utils.LayoutDB)
+	case len(in.Date) == len(utils.LayoutDate):
+		in.Date += now.Format(" 15:04:05")
+	}
+
+	db := DBFromContext(c)
+	var name string
+	var strainID, zoneID sql.NullInt64
+	err := db.QueryRow(`SELECT name, strain_id, zone_id FROM plant WHERE id = $1`, motherID).Scan(&name, &strainID, &zoneID)
+	if errors.Is(err, sql.ErrNoRows) {
+		apiNotFound(c, "api_plant_not_found")
+		return
+	}
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to load mother")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if in.ZoneID == nil && zoneID.Valid {
+		zone := int(zoneID.Int64)
+		in.ZoneID = &zone
+	}
+	if in.ZoneID == nil {
+		apiBadRequest(c, "api_zone_not_found")
+		return
+	}
+	if found, err := rowExists(db, "zones", *in.ZoneID); err != nil || !found {
+		apiBadRequest(c, "api_zone_not_found")
+		return
+	}
+	if in.StatusID == nil {
+		var status int
+		err := db.QueryRow(`SELECT id FROM plant_status WHERE active = 1
+			ORDER BY CASE WHEN status = $1 THEN 0 ELSE 1 END, status_order, id LIMIT 1`, cloneStartStatus).Scan(&status)
+		if err != nil {
+			fieldLogger.WithError(err).Error("Failed to find first stage")
+			apiInternalError(c, "api_database_error")
+			return
+		}
+		in.StatusID = &status
+	} else if found, err := rowExists(db, "plant_status", *in.StatusID); err != nil || !found {
+		apiBadRequest(c, "api_status_not_found")
+		return
+	}
+	if in.NamePrefix == "" {
+		in.NamePrefix = name
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+
+	activityID, err := lockedActivityID(tx, cloneActivityName)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to find clone activity")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	var taken int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM plant WHERE parent_plant_id = $1`, motherID).Scan(&taken); err != nil {
+		fieldLogger.WithError(err).Error("Failed to count cuttings")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	ids := make([]int, 0, in.Count)
+	names := make([]string, 0, in.Count)
+	for i := 1; i <= in.Count; i++ {
+		cutting := in.NamePrefix + " #" + strconv.Itoa(taken+i)
+		var id int
+		err := tx.QueryRow(`INSERT INTO plant (name, zone_id, strain_id, description, clone, parent_plant_id, start_dt, sensors)
+			VALUES ($1, $2, $3, '', 1, $4, $5, '[]') RETURNING id`,
+			cutting, *in.ZoneID, strainID, motherID, in.Date).Scan(&id)
+		if err != nil {
+			fieldLogger.WithError(err).Error("Failed to insert cutting")
+			apiInternalError(c, "api_failed_to_create_plant")
+			return
+		}
+		if _, err := tx.Exec(`INSERT INTO plant_status_log (plant_id, status_id, date) VALUES ($1, $2, $3)`, id, *in.StatusID, in.Date); err != nil {
+			fieldLogger.WithError(err).Error("Failed to insert cutting status")
+			apiInternalError(c, "api_failed_to_create_plant")
+			return
+		}
+		ids = append(ids, id)
+		names = append(names, cutting)
+	}
+	note := strings.Join(names, ", ")
+	if in.Note != "" {
+		note = in.Note + "\n" + note
+	}
+	if err := createPlantActivity(tx, motherID, activityID, note, in.Date, nil, nil); err != nil {
+		fieldLogger.WithError(err).Error("Failed to log clone activity")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit transaction")
+		apiInternalError(c, "api_failed_to_create_plant")
+		return
+	}
+	ConfigStoreFromContext(c).SetActivities(GetActivities(db))
+	c.JSON(http.StatusCreated, gin.H{"ids": ids, "message": T(c, "api_clones_taken")})
+}
+
+// SetPlantKeeper flags or unflags the plant in the path as a keeper
+// mother.
+func SetPlantKeeper(c *gin.Context) {
+	plantID, ok := plantIDParam(c)
+	if !ok {
+		return
+	}
+	var in struct {
+		Keeper bool `json:"keeper"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		apiBadRequest(c, "api_invalid_request")
+		return
+	}
+	res, err := DBFromContext(c).Exec(`UPDATE plant SET keeper = $1 WHERE id = $2`, in.Keeper, plantID)
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "SetPlantKeeper").Error("Failed to update keeper")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		apiNotFound(c, "api_plant_not_found")
+		return
+	}
+	apiOK(c, "api_keeper_saved")
+}
@@ -0,0 +1,211 @@
+package handlers_test
+
+// HTTP-layer tests for handlers/plant_lineage.go: taking clones, the
+// keeper flag, the clone family tree and per-mother clone stats.
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"isley/tests/testutil"
+)
+
+type cloneNodeResponse struct {
+	ID         int                 `json:"id"`
+	Name       string              `json:"name"`
+	Status     string              `json:"status"`
+	Keeper     bool                `json:"keeper"`
+	Generation int                 `json:"generation"`
+	Children   []cloneNodeResponse `json:"children"`
+}
+
+type motherStatsResponse struct {
+	PlantID      int      `json:"plant_id"`
+	Name         string   `json:"name"`
+	Keeper       bool     `json:"keeper"`
+	ClonesTaken  int      `json:"clones_taken"`
+	ClonesFailed int      `json:"clones_failed"`
+	SuccessRate  *float64 `json:"success_rate"`
+	Descendants  int      `json:"descendants"`
+}
+
+type cloneFamilyResponse struct {
+	PlantID    int                 `json:"plant_id"`
+	Generation int                 `json:"generation"`
+	Root       cloneNodeResponse   `json:"root"`
+	Mother     motherStatsResponse `json:"mother"`
+}
+
+// takeClones posts a clone batch for motherID and returns the new
+// plants' IDs.
+func takeClones(t *testing.T, c *testutil.Client, apiKey string, motherID int, body map[string]interface{}) []int {
+	t.Helper()
+	resp := harvestRequest(t, c, http.MethodPost, "/plant/"+strconv.Itoa(motherID)+"/clones", apiKey, body)
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created struct {
+		IDs []int `json:"ids"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	return created.IDs
+}
+
+func getCloneFamily(t *testing.T, c *testutil.Client, plantID int) cloneFamilyResponse {
+	t.Helper()
+	resp := c.Get("/plant/" + strconv.Itoa(plantID) + "/lineage")
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var got cloneFamilyResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	return got
+}
+
+// ---------------------------------------------------------------------------
+// TakeClones
+// ---------------------------------------------------------------------------
+
+func TestPlantLineageHTTP_TakeClones(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "clone-take-key")
+	motherID := seedImagePlant(t, db)
+	otherZone := testutil.SeedZone(t, db, "Clone dome")
+	vegID := plantStatusID(t, db, "Veg")
+	c := server.NewClient(t)
+
+	ids := takeClones(t, c, apiKey, motherID, map[string]interface{}{"count": 2, "date": "2026-05-01"})
+	require.Len(t, ids, 2)
+	more := takeClones(t, c, apiKey, motherID, map[string]interface{}{
+		"count": 1, "date": "2026-05-08", "zone_id": otherZone, "status_id": vegID, "note": "Under the dome",
+	})
+	require.Len(t, more, 1)
+
+	var name, start string
+	var clone, parent, zone, strain, motherStrain int
+	require.NoError(t, db.QueryRow(`SELECT strain_id FROM plant WHERE id = $1`, motherID).Scan(&motherStrain))
+	for i, id := range append(ids, more...) {
+		require.NoError(t, db.QueryRow(`SELECT name, clone, parent_plant_id, zone_id, strain_id, start_dt FROM plant WHERE id = $1`, id).
+			Scan(&name, &clone, &parent, &zone, &strain, &start))
+		assert.Equal(t, "Plant 1 #"+strconv.Itoa(i+1), name, "cuttings are numbered on from earlier batches")
+		assert.Equal(t, 1, clone)
+		assert.Equal(t, motherID, parent)
+		assert.Equal(t, motherStrain, strain)
+	}
+	assert.Equal(t, otherZone, zone)
+	assert.Equal(t, "2026-05-08", start[:10])
+
+	var status string
+	require.NoError(t, db.QueryRow(`SELECT ps.status FROM plant_status_log psl JOIN plant_status ps ON ps.id = psl.status_id
+		WHERE psl.plant_id = $1`, ids[0]).Scan(&status))
+	assert.Equal(t, "Seedling", status, "cuttings start in the first stage by default")
+	require.NoError(t, db.QueryRow(`SELECT ps.status FROM plant_status_log psl JOIN plant_status ps ON ps.id = psl.status_id
+		WHERE psl.plant_id = $1`, more[0]).Scan(&status))
+	assert.Equal(t, "Veg", status)
+
+	cloneActivity := testutil.ActivityIDByName(t, db, "Clone")
+	assert.Equal(t, 2, testutil.CountRows(t, db, `SELECT COUNT(*) FROM plant_activity WHERE plant_id = $1 AND activity_id = $2`, motherID, cloneActivity),
+		"each batch logs a clone activity on the mother")
+	var note string
+	require.NoError(t, db.QueryRow(`SELECT note FROM plant_activity WHERE plant_id = $1 AND activity_id = $2 AND note LIKE 'Under%'`,
+		motherID, cloneActivity).Scan(&note))
+	assert.Equal(t, "Under the dome\nPlant 1 #3", note)
+
+	custom := takeClones(t, c, apiKey, ids[0], map[string]interface{}{"count": 1, "name_prefix": " F2 "})
+	require.NoError(t, db.QueryRow(`SELECT name FROM plant WHERE id = $1`, custom[0]).Scan(&name))
+	assert.Equal(t, "F2 #1", name)
+
+	path := "/plant/" + strconv.Itoa(motherID) + "/clones"
+	for label, body := range map[string]map[string]interface{}{
+		"no count":       {},
+		"too many":       {"count": 51},
+		"unknown zone":   {"count": 1, "zone_id": 9999},
+		"unknown status": {"count": 1, "status_id": 9999},
+		"bad date":       {"count": 1, "date": "soon"},
+	} {
+		resp := harvestRequest(t, c, http.MethodPost, path, apiKey, body)
+		testutil.DrainAndClose(resp)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, label)
+	}
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, "/plant/9999/clones", apiKey, map[string]interface{}{"count": 1}), http.StatusNotFound)
+}
+
+// ---------------------------------------------------------------------------
+// Family tree, keeper flag and mother stats
+// ---------------------------------------------------------------------------
+
+func TestPlantLineageHTTP_FamilyTreeAndMotherStats(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "clone-family-key")
+	motherID := seedImagePlant(t, db)
+	c := server.NewClient(t)
+
+	cuts := takeClones(t, c, apiKey, motherID, map[string]interface{}{"count": 3, "date": "2026-05-01"})
+	grand := takeClones(t, c, apiKey, cuts[0], map[string]interface{}{"count": 2, "date": "2026-06-01"})
+	testutil.MustExec(t, db, `INSERT INTO plant_status_log (plant_id, status_id, date) VALUES ($1, $2, '2026-05-10 12:00:00')`,
+		cuts[2], plantStatusID(t, db, "Dead"))
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/plant/"+strconv.Itoa(motherID)+"/keeper", apiKey,
+		map[string]interface{}{"keeper": true}), http.StatusOK)
+
+	family := getCloneFamily(t, c, grand[1])
+	assert.Equal(t, 2, family.Generation)
+	assert.Equal(t, motherID, family.Root.ID, "the tree starts at the first mother")
+	assert.True(t, family.Root.Keeper)
+	require.Len(t, family.Root.Children, 3)
+	first := family.Root.Children[0]
+	assert.Equal(t, cuts[0], first.ID)
+	assert.Equal(t, 1, first.Generation)
+	require.Len(t, first.Children, 2)
+	assert.Equal(t, 2, first.Children[1].Generation)
+	assert.Equal(t, "Dead", family.Root.Children[2].Status)
+	assert.Zero(t, family.Mother.ClonesTaken, "the plant itself has no cuttings")
+
+	family = getCloneFamily(t, c, motherID)
+	assert.Zero(t, family.Generation)
+	assert.Equal(t, 3, family.Mother.ClonesTaken)
+	assert.Equal(t, 1, family.Mother.ClonesFailed)
+	if assert.NotNil(t, family.Mother.SuccessRate) {
+		assert.InDelta(t, 0.667, *family.Mother.SuccessRate, 0.001)
+	}
+	assert.Equal(t, 5, family.Mother.Descendants)
+
+	resp := c.Get("/mothers/list")
+	var mothers []motherStatsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&mothers))
+	testutil.DrainAndClose(resp)
+	require.Len(t, mothers, 2, "the mother and the cutting clones were taken from")
+	assert.Equal(t, motherID, mothers[0].PlantID, "keepers come first")
+	assert.Equal(t, cuts[0], mothers[1].PlantID)
+	if assert.NotNil(t, mothers[1].SuccessRate) {
+		assert.Equal(t, 1.0, *mothers[1].SuccessRate)
+	}
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/plant/"+strconv.Itoa(motherID)+"/keeper", apiKey,
+		map[string]interface{}{"keeper": false}), http.StatusOK)
+	assert.False(t, getCloneFamily(t, c, motherID).Root.Keeper)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/plant/9999/keeper", apiKey,
+		map[string]interface{}{"keeper": true}), http.StatusNotFound)
+
+	resp = c.Get("/plant/9999/lineage")
+	testutil.DrainAndClose(resp)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	for _, path := range []string{"/mothers", "/plant/" + strconv.Itoa(motherID)} {
+		resp := c.Get(path)
+		body, err := io.ReadAll(resp.Body)
+		testutil.DrainAndClose(resp)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode, path)
+		assert.Contains(t, string(body), "Plant 1", path)
+	}
+}
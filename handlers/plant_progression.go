@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"isley/logger"
+	"isley/model/types"
+	"isley/utils"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// progressionWeek is the spacing of the boundaries a progression strip
+// samples photos at.
+const progressionWeek = 7 * 24 * time.Hour
+
+// ProgressionWeek is one slot of a plant's week-by-week progression.
+// Week 1 starts on the plant's start date, matching CurrentWeek. Image is
+// nil when no photo was taken within half a week of the boundary.
+type ProgressionWeek struct {
+	Week     int               `json:"week"`
+	Boundary time.Time         `json:"boundary"`
+	Image    *types.PlantImage `json:"image,omitempty"`
+}
+
+// loadPlantProgression returns the plant's start date and, for each week
+// boundary up to its latest photo, the still closest to that boundary.
+// Each photo can only fall in one week's window, so no photo is repeated.
+func loadPlantProgression(db *sql.DB, plantID int) (time.Time, []ProgressionWeek, error) {
+	var startDT time.Time
+	if err := db.QueryRow("SELECT start_dt FROM plant WHERE id = $1", plantID).Scan(&startDT); err != nil {
+		return time.Time{}, nil, err
+	}
+	startDT = utils.AsLocal(startDT)
+
+	rows, err := db.Query(`SELECT id, image_path, image_description, image_order, image_date FROM plant_images
+		WHERE plant_id = $1 ORDER BY image_date, id`, plantID)
+	if err != nil {
+		return startDT, nil, err
+	}
+	defer rows.Close()
+
+	best := map[int]types.PlantImage{}
+	last := -1
+	for rows.Next() {
+		var img types.PlantImage
+		if err := rows.Scan(&img.ID, &img.ImagePath, &img.ImageDescription, &img.ImageOrder, &img.ImageDate); err != nil {
+			return startDT, nil, err
+		}
+		if utils.IsVideoPath(img.ImagePath) {
+			continue
+		}
+		img.PlantID = uint(plantID)
+		img.ImagePath = "/" + strings.ReplaceAll(img.ImagePath, "\\", "/")
+		img.ImageDate = utils.AsLocal(img.ImageDate)
+
+		// Each boundary owns the half-open window of half a week either
+		// side of it.
+		offset := img.ImageDate.Sub(startDT) + progressionWeek/2
+		if offset < 0 {
+			continue
+		}
+		slot := int(offset / progressionWeek)
+		if cur, ok := best[slot]; !ok || weekDistance(startDT, slot, img.ImageDate) < weekDistance(startDT, slot, cur.ImageDate) {
+			best[slot] = img
+		}
+		last = max(last, slot)
+	}
+	if err := rows.Err(); err != nil {
+		return startDT, nil, err
+	}
+
+	weeks := make([]ProgressionWeek, 0, last+1)
+	for slot := 0; slot <= last; slot++ {
+		week := ProgressionWeek{Week: slot + 1, Boundary: startDT.Add(time.Duration(slot) * progressionWeek)}
+		if img, ok := best[slot]; ok {
+			week.Image = &img
+		}
+		weeks = append(weeks, week)
+	}
+	return startDT, weeks, nil
+}
+
+// weekDistance is how far t lies from the given week boundary.
+func weekDistance(start time.Time, slot int, t time.Time) time.Duration {
+	d := t.Sub(start.Add(time.Duration(slot) * progressionWeek))
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// GetPlantProgression returns a plant's week-by-week progression.
+func GetPlantProgression(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "GetPlantProgression")
+
+	plantID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_plant_id")
+		return
+	}
+	startDT, weeks, err := loadPlantProgression(DBFromContext(c), plantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			apiNotFound(c, "api_plant_not_found")
+			return
+		}
+		fieldLogger.WithError(err).Error("Failed to load plant progression")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"plant_id": plantID, "start_date": startDT, "weeks": weeks})
+}
+
+// ExportPlantProgression composes the selected weeks of a plant's
+// progression into a single image, optionally titled and watermarked
+// through the same overlay pipeline as decorated photos. Without a week
+// list every week that has a photo is included.
+func ExportPlantProgression(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "ExportPlantProgression")
+
+	plantID, err := strconv.Atoi(c.Param("plantID"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_plant_id")
+		return
+	}
+	var input struct {
+		Weeks     []int  `json:"weeks"`
+		Title     string `json:"title"`
+		Logo      string `json:"logo"`
+		Font      string `json:"font"`
+		TextColor string `json:"text_color"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apiBadRequest(c, "api_invalid_request")
+		return
+	}
+
+	_, weeks, err := loadPlantProgression(DBFromContext(c), plantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			apiNotFound(c, "api_plant_not_found")
+			return
+		}
+		fieldLogger.WithError(err).Error("Failed to load plant progression")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	wanted := map[int]bool{}
+	for _, w := range input.Weeks {
+		wanted[w] = true
+	}
+	var frames []utils.ProgressionFrame
+	for _, week := range weeks {
+		if week.Image == nil || (len(wanted) > 0 && !wanted[week.Week]) {
+			continue
+		}
+		original := filepath.FromSlash(strings.TrimPrefix(week.Image.ImagePath, "/"))
+		if err := ensureLocalUpload(c, original); err != nil {
+			fieldLogger.WithError(err).WithField("image", original).Debug("Image not fetched from storage")
+		}
+		// The medium variant is already upright and far quicker to decode.
+		path, err := utils.EnsureImageVariant(original, utils.ImageVariantMedium)
+		if err != nil {
+			path = original
+		}
+		frames = append(frames, utils.ProgressionFrame{
+			ImagePath: path,
+			Label:     fmt.Sprintf("%s %d · %s", T(c, "progression_week"), week.Week, week.Image.ImageDate.Format(utils.LayoutDate)),
+		})
+	}
+	if len(frames) == 0 {
+		apiBadRequest(c, "api_no_progression_images")
+		return
+	}
+	if len(frames) > utils.MaxProgressionFrames {
+		apiBadRequest(c, "api_too_many_progression_weeks")
+		return
+	}
+
+	outputPath := filepath.Join(UploadDirFromContext(c), "plants", "progression", fmt.Sprintf("plant_%d_progression.png", plantID))
+	if err := os.MkdirAll(filepath.Dir(outputPath), os.ModePerm); err != nil {
+		fieldLogger.WithError(err).Error("Failed to create directory")
+		apiInternalError(c, "api_failed_to_create_directory")
+		return
+	}
+	err = utils.ComposeProgressionStrip(utils.ProgressionStripRequest{
+		Frames:     frames,
+		OutputPath: outputPath,
+		Font:       input.Font,
+		Title:      input.Title,
+		Logo:       input.Logo,
+		TextColor:  input.TextColor,
+	})
+	switch {
+	case errors.Is(err, utils.ErrInvalidFont):
+		apiBadRequest(c, "api_invalid_font")
+	case errors.Is(err, utils.ErrInvalidLogoPath):
+		apiBadRequest(c, "api_invalid_logo_path")
+	case errors.Is(err, utils.ErrInvalidTextColor):
+		apiBadRequest(c, "api_invalid_text_color")
+	case err != nil:
+		fieldLogger.WithError(err).Error("Failed to compose progression strip")
+		apiInternalError(c, "api_failed_to_process_file")
+	default:
+		c.JSON(http.StatusOK, gin.H{"outputPath": filepath.ToSlash(outputPath), "message": T(c, "api_progression_exported")})
+	}
+}
@@ -0,0 +1,136 @@
+package handlers_test
+
+// HTTP-layer tests for handlers/plant_progression.go: the week-by-week
+// photo selection behind GET /plant/:id/progression and the composed
+// strip from POST /plant/:plantID/progression/export.
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"isley/tests/testutil"
+)
+
+// seedProgressionImage writes a small photo for plantID under uploadDir
+// and records it with the given image_date.
+func seedProgressionImage(t *testing.T, db *sql.DB, uploadDir string, plantID int, name, date string) int {
+	t.Helper()
+	path := filepath.Join(uploadDir, "plants", name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, exifPhoto(t, 40, 30, 1, "2026:01:01 00:00:00"), 0o644))
+	var id int
+	require.NoError(t, db.QueryRow(`INSERT INTO plant_images (plant_id, image_path, image_description, image_order, image_date)
+		VALUES ($1, $2, '', 0, $3) RETURNING id`, plantID, path, date).Scan(&id))
+	return id
+}
+
+type progressionResponse struct {
+	Weeks []struct {
+		Week  int `json:"week"`
+		Image *struct {
+			ID int `json:"id"`
+		} `json:"image"`
+	} `json:"weeks"`
+}
+
+// ---------------------------------------------------------------------------
+// GetPlantProgression
+// ---------------------------------------------------------------------------
+
+func TestPlantProgressionHTTP_PicksClosestPerWeek(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	uploadDir := t.TempDir()
+	server := testutil.NewTestServer(t, db, testutil.WithUploadDir(uploadDir), testutil.WithGuestMode())
+	plantID := seedImagePlant(t, db) // starts 2026-01-01
+
+	seedProgressionImage(t, db, uploadDir, plantID, "before.jpg", "2025-12-20")     // before week 1's window
+	day2 := seedProgressionImage(t, db, uploadDir, plantID, "d2.jpg", "2026-01-02") // week 1, 1 day off
+	seedProgressionImage(t, db, uploadDir, plantID, "d3.jpg", "2026-01-03")         // week 1, 2 days off
+	day7 := seedProgressionImage(t, db, uploadDir, plantID, "d7.jpg", "2026-01-07") // week 2, 1 day early
+	seedProgressionImage(t, db, uploadDir, plantID, "d9.jpg", "2026-01-09")         // week 2, 1 day late: tie keeps the earlier
+	day24 := seedProgressionImage(t, db, uploadDir, plantID, "d24.jpg", "2026-01-24")
+	testutil.MustExec(t, db, `INSERT INTO plant_images (plant_id, image_path, image_description, image_order, image_date)
+		VALUES ($1, 'uploads/plants/t.mp4', '', 0, '2026-01-15')`, plantID)
+
+	c := server.NewClient(t)
+	resp := c.Get("/plant/" + strconv.Itoa(plantID) + "/progression")
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var got progressionResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	require.Len(t, got.Weeks, 4)
+	picked := make([]int, len(got.Weeks))
+	for i, w := range got.Weeks {
+		assert.Equal(t, i+1, w.Week)
+		if w.Image != nil {
+			picked[i] = w.Image.ID
+		}
+	}
+	// Week 3 only has the timelapse video, which never counts.
+	assert.Equal(t, []int{day2, day7, 0, day24}, picked)
+
+	missing := c.Get("/plant/9999/progression")
+	defer testutil.DrainAndClose(missing)
+	assert.Equal(t, http.StatusNotFound, missing.StatusCode)
+}
+
+// ---------------------------------------------------------------------------
+// ExportPlantProgression
+// ---------------------------------------------------------------------------
+
+func TestPlantProgressionHTTP_Export(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	uploadDir := t.TempDir()
+	server := testutil.NewTestServer(t, db, testutil.WithUploadDir(uploadDir))
+	apiKey := testutil.SeedAPIKey(t, db, "progression-export-key")
+	plantID := seedImagePlant(t, db)
+	for i, date := range []string{"2026-01-01", "2026-01-08", "2026-01-15"} {
+		seedProgressionImage(t, db, uploadDir, plantID, "w"+strconv.Itoa(i)+".jpg", date)
+	}
+	c := server.NewClient(t)
+	export := func(plant int, body map[string]interface{}) *http.Response {
+		return c.APIPostJSON(t, "/plant/"+strconv.Itoa(plant)+"/progression/export", apiKey, body)
+	}
+
+	resp := export(plantID, map[string]interface{}{"weeks": []int{1, 3}, "title": "Plant 1"})
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var got struct {
+		OutputPath string `json:"outputPath"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	data, err := os.ReadFile(filepath.FromSlash(got.OutputPath))
+	require.NoError(t, err)
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 8+480+8+480+8, cfg.Width, "two 4:3 tiles at the maximum tile width")
+
+	for name, tc := range map[string]struct {
+		plant  int
+		body   map[string]interface{}
+		status int
+	}{
+		"no photos in the selection": {plantID, map[string]interface{}{"weeks": []int{9}}, http.StatusBadRequest},
+		"unknown font":               {plantID, map[string]interface{}{"font": "fonts/Nope.ttf"}, http.StatusBadRequest},
+		"unknown plant":              {9999, map[string]interface{}{}, http.StatusNotFound},
+	} {
+		resp := export(tc.plant, tc.body)
+		assert.Equal(t, tc.status, resp.StatusCode, name)
+		testutil.DrainAndClose(resp)
+	}
+}
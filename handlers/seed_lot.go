@@ -0,0 +1,740 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"isley/logger"
+	model "isley/model"
+	"isley/model/types"
+	"isley/utils"
+)
+
+// MaxSeedsPerLot caps a lot's pack size.
+const MaxSeedsPerLot = 10000
+
+// Seed lot and germination validation errors. Their text is the locale
+// key the handlers respond with.
+var (
+	errSeedPackSize       = errors.New("api_invalid_pack_size")
+	errSeedsLeft          = errors.New("api_invalid_seeds_left")
+	errSeedCount          = errors.New("api_invalid_seed_count")
+	errSproutedCount      = errors.New("api_invalid_sprouted_count")
+	errSproutDateNoSprout = errors.New("api_sprout_date_needs_sprouted")
+	errSproutBeforeStart  = errors.New("api_sprout_date_before_start")
+)
+
+const seedLotColumns = `l.id, l.strain_id, s.name, COALESCE(s.breeder_id, 0), COALESCE(b.name, ''),
+	l.source, l.purchase_date, l.pack_size, l.seeds_left, l.cost, l.feminized, l.notes,
+	(SELECT COUNT(*) FROM plant p WHERE p.seed_lot_id = l.id)`
+
+const seedLotJoins = `FROM seed_lots l
+	JOIN strain s ON s.id = l.strain_id
+	LEFT JOIN breeder b ON b.id = s.breeder_id`
+
+// loadSeedLots loads the lots matching where (a condition on l or s, or
+// ""), each with its germination attempts and stats, ordered by strain.
+func loadSeedLots(db *sql.DB, where string, args ...interface{}) ([]types.SeedLot, error) {
+	if where != "" {
+		where = "WHERE " + where
+	}
+	rows, err := db.Query(fmt.Sprintf("SELECT %s %s %s ORDER BY s.name, l.id", seedLotColumns, seedLotJoins, where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	lots := []types.SeedLot{}
+	index := map[int]int{}
+	for rows.Next() {
+		var l types.SeedLot
+		var purchased sql.NullTime
+		var cost sql.NullFloat64
+		if err := rows.Scan(&l.ID, &l.StrainID, &l.StrainName, &l.BreederID, &l.Breeder,
+			&l.Source, &purchased, &l.PackSize, &l.SeedsLeft, &cost, &l.Feminized, &l.Notes, &l.PlantsStarted); err != nil {
+			return nil, err
+		}
+		l.PurchaseDate = nullLocalTimePtr(purchased)
+		l.Cost = nullFloatPtr(cost)
+		l.Attempts = []types.GerminationAttempt{}
+		index[l.ID] = len(lots)
+		lots = append(lots, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(fmt.Sprintf(`SELECT g.id, g.seed_lot_id, g.start_date, g.seeds, g.sprouted, g.sprout_date,
+			g.method, g.notes, (SELECT COUNT(*) FROM plant p WHERE p.germination_id = g.id)
+		FROM germination_attempts g
+		JOIN seed_lots l ON l.id = g.seed_lot_id
+		JOIN strain s ON s.id = l.strain_id
+		%s
+		ORDER BY g.start_date, g.id`, where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a types.GerminationAttempt
+		var sprouted sql.NullInt64
+		var sproutDate sql.NullTime
+		if err := rows.Scan(&a.ID, &a.SeedLotID, &a.StartDate, &a.Seeds, &sprouted, &sproutDate,
+			&a.Method, &a.Notes, &a.PlantsStarted); err != nil {
+			return nil, err
+		}
+		a.StartDate = utils.AsLocal(a.StartDate)
+		a.Sprouted = nullIntPtr(sprouted)
+		a.SproutDate = nullLocalTimePtr(sproutDate)
+		a.Outcome = germinationOutcome(a)
+		if a.SproutDate != nil {
+			days := daysBetween(a.StartDate, *a.SproutDate)
+			a.DaysToSprout = &days
+		}
+		if i, ok := index[a.SeedLotID]; ok {
+			lots[i].Attempts = append(lots[i].Attempts, a)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range lots {
+		lots[i].Stats = germinationStats(lots[i].Attempts)
+	}
+	return lots, nil
+}
+
+// LoadStrainSeedLots loads a strain's seed lots for the strain page.
+func LoadStrainSeedLots(db *sql.DB, strainID int) ([]types.SeedLot, error) {
+	return loadSeedLots(db, "l.strain_id = $1", strainID)
+}
+
+// germinationOutcome describes how an attempt went from how many of its
+// seeds sprouted.
+func germinationOutcome(a types.GerminationAttempt) string {
+	switch {
+	case a.Sprouted == nil:
+		return types.GerminationPending
+	case *a.Sprouted == 0:
+		return types.GerminationFailed
+	case *a.Sprouted < a.Seeds:
+		return types.GerminationPartial
+	}
+	return types.GerminationSprouted
+}
+
+// germinationStats totals attempts; see types.GerminationStats.
+func germinationStats(attempts []types.GerminationAttempt) types.GerminationStats {
+	var st types.GerminationStats
+	days, timed := 0, 0
+	for _, a := range attempts {
+		st.Attempts++
+		if a.Sprouted == nil {
+			st.Pending++
+			continue
+		}
+		st.SeedsSown += a.Seeds
+		st.Sprouted += *a.Sprouted
+		if a.DaysToSprout != nil {
+			days += *a.DaysToSprout
+			timed++
+		}
+	}
+	if st.SeedsSown > 0 {
+		rate := float64(st.Sprouted) / float64(st.SeedsSown)
+		st.Rate = &rate
+	}
+	if timed > 0 {
+		avg := float64(days) / float64(timed)
+		st.AvgDaysToSprout = &avg
+	}
+	return st
+}
+
+// LoadSeedBank loads every seed lot with its germination stats, and the
+// same stats per breeder, breeders in name order.
+func LoadSeedBank(db *sql.DB) (types.SeedBank, error) {
+	lots, err := loadSeedLots(db, "")
+	if err != nil {
+		return types.SeedBank{}, err
+	}
+	bank := types.SeedBank{Lots: lots, Breeders: []types.BreederGermination{}}
+	attempts := map[int][]types.GerminationAttempt{}
+	index := map[int]int{}
+	for _, l := range lots {
+		i, ok := index[l.BreederID]
+		if !ok {
+			i = len(bank.Breeders)
+			index[l.BreederID] = i
+			bank.Breeders = append(bank.Breeders, types.BreederGermination{BreederID: l.BreederID, Breeder: l.Breeder})
+		}
+		bank.Breeders[i].Lots++
+		attempts[l.BreederID] = append(attempts[l.BreederID], l.Attempts...)
+	}
+	for i := range bank.Breeders {
+		bank.Breeders[i].Stats = germinationStats(attempts[bank.Breeders[i].BreederID])
+	}
+	sort.SliceStable(bank.Breeders, func(i, j int) bool {
+		return strings.ToLower(bank.Breeders[i].Breeder) < strings.ToLower(bank.Breeders[j].Breeder)
+	})
+	return bank, nil
+}
+
+// adjustSeedCount moves a strain's seed_count by delta, never below zero.
+func adjustSeedCount(tx *sql.Tx, strainID, delta int) error {
+	query := "UPDATE strain SET seed_count = MAX(0, seed_count + $1) WHERE id = $2"
+	if model.IsPostgres() {
+		query = "UPDATE strain SET seed_count = GREATEST(0, seed_count + $1) WHERE id = $2"
+	}
+	_, err := tx.Exec(query, delta, strainID)
+	return err
+}
+
+// takePlantSeed resolves where a new plant of strainID comes from. A plant
+// started from a germination attempt takes one of the attempt's sprouted
+// seeds, which already left the lot when they were sown. A plant started
+// straight from a lot takes a seed out of it, and out of the strain's
+// seed_count. It returns the lot and attempt to record on the plant, or
+// the locale key of the reason the seed can't be taken.
+func takePlantSeed(tx *sql.Tx, strainID int, seedLotID, germinationID *int) (lotArg, germinationArg interface{}, errKey string, err error) {
+	if germinationID != nil {
+		var lot, lotStrain, seeds, started int
+		var sprouted sql.NullInt64
+		err = tx.QueryRow(`SELECT g.seed_lot_id, l.strain_id, g.seeds, g.sprouted,
+				(SELECT COUNT(*) FROM plant p WHERE p.germination_id = g.id)
+			FROM germination_attempts g
+			JOIN seed_lots l ON l.id = g.seed_lot_id
+			WHERE g.id = $1`, *germinationID).Scan(&lot, &lotStrain, &seeds, &sprouted, &started)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && (lotStrain != strainID || (seedLotID != nil && *seedLotID != lot))) {
+			return nil, nil, "api_invalid_seed_lot", nil
+		}
+		if err != nil {
+			return nil, nil, "", err
+		}
+		available := seeds
+		if sprouted.Valid {
+			available = int(sprouted.Int64)
+		}
+		if started >= available {
+			return nil, nil, "api_germination_all_started", nil
+		}
+		return lot, *germinationID, "", nil
+	}
+	if seedLotID == nil {
+		return nil, nil, "", nil
+	}
+	var lotStrain int
+	err = tx.QueryRow("SELECT strain_id FROM seed_lots WHERE id = $1", *seedLotID).Scan(&lotStrain)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && lotStrain != strainID) {
+		return nil, nil, "api_invalid_seed_lot", nil
+	}
+	if err != nil {
+		return nil, nil, "", err
+	}
+	// The seeds_left guard makes the decrement itself the check, so two
+	// plants started at once can't both take the last seed.
+	result, err := tx.Exec(`UPDATE seed_lots SET seeds_left = seeds_left - 1, update_dt = CURRENT_TIMESTAMP
+		WHERE id = $1 AND seeds_left > 0`, *seedLotID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, nil, "api_seed_lot_empty", nil
+	}
+	if err = adjustSeedCount(tx, strainID, -1); err != nil {
+		return nil, nil, "", err
+	}
+	return *seedLotID, nil, "", nil
+}
+
+// seedParam parses the :id route parameter.
+func seedParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_request")
+		return 0, false
+	}
+	return id, true
+}
+
+// ListStrainSeedLots returns a strain's seed lots with their germination
+// attempts and stats.
+func ListStrainSeedLots(c *gin.Context) {
+	strainID, ok := seedParam(c)
+	if !ok {
+		return
+	}
+	db := DBFromContext(c)
+	if found, err := rowExists(db, "strain", strainID); err != nil || !found {
+		if err != nil {
+			logger.Log.WithError(err).WithField("func", "ListStrainSeedLots").Error("Failed to look up strain")
+			apiInternalError(c, "api_database_error")
+			return
+		}
+		apiNotFound(c, "api_strain_not_found")
+		return
+	}
+	lots, err := LoadStrainSeedLots(db, strainID)
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "ListStrainSeedLots").Error("Failed to load seed lots")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusOK, lots)
+}
+
+// GetSeedBank returns every seed lot with germination-rate stats per lot
+// and per breeder.
+func GetSeedBank(c *gin.Context) {
+	bank, err := LoadSeedBank(DBFromContext(c))
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "GetSeedBank").Error("Failed to load seed lots")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusOK, bank)
+}
+
+// seedLotInput is the body of the lot create and update requests.
+// SeedsLeft defaults to the pack size on create and to the current count
+// on update.
+type seedLotInput struct {
+	Source       string   `json:"source"`
+	PurchaseDate string   `json:"purchase_date"`
+	PackSize     int      `json:"pack_size"`
+	SeedsLeft    *int     `json:"seeds_left"`
+	Cost         *float64 `json:"cost"`
+	Feminized    bool     `json:"feminized"`
+	Notes        string   `json:"notes"`
+}
+
+func (in *seedLotInput) validate() error {
+	in.Source = strings.TrimSpace(in.Source)
+	if err := utils.ValidateStringLength("source", in.Source, utils.MaxNameLength); err != nil {
+		return err
+	}
+	if err := utils.ValidateStringLength("notes", in.Notes, utils.MaxNotesLength); err != nil {
+		return err
+	}
+	if in.PurchaseDate != "" {
+		if err := utils.ValidateDate("purchase_date", in.PurchaseDate); err != nil {
+			return err
+		}
+	}
+	if in.PackSize < 1 || in.PackSize > MaxSeedsPerLot {
+		return errSeedPackSize
+	}
+	if in.SeedsLeft != nil && (*in.SeedsLeft < 0 || *in.SeedsLeft > in.PackSize) {
+		return errSeedsLeft
+	}
+	return validateNonNegative(map[string]*float64{"cost": in.Cost})
+}
+
+// bindSeedLotInput binds and validates a lot body. It sends the error
+// response itself.
+func bindSeedLotInput(c *gin.Context) (seedLotInput, bool) {
+	var in seedLotInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		apiBadRequest(c, "api_invalid_input")
+		return in, false
+	}
+	if err := in.validate(); err != nil {
+		apiBadRequest(c, err.Error())
+		return in, false
+	}
+	return in, true
+}
+
+// AddSeedLot adds a seed lot to a strain. Its seeds are added to the
+// strain's seed_count.
+func AddSeedLot(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "AddSeedLot")
+	strainID, ok := seedParam(c)
+	if !ok {
+		return
+	}
+	in, ok := bindSeedLotInput(c)
+	if !ok {
+		return
+	}
+	left := in.PackSize
+	if in.SeedsLeft != nil {
+		left = *in.SeedsLeft
+	}
+
+	tx, err := DBFromContext(c).Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+	found, err := rowExists(tx, "strain", strainID)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to look up strain")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if !found {
+		apiNotFound(c, "api_strain_not_found")
+		return
+	}
+	var id int
+	err = tx.QueryRow(`INSERT INTO seed_lots (strain_id, source, purchase_date, pack_size, seeds_left, cost, feminized, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+		strainID, in.Source, nullDateArg(in.PurchaseDate), in.PackSize, left, in.Cost, in.Feminized, in.Notes).Scan(&id)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to create seed lot")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if err := adjustSeedCount(tx, strainID, left); err != nil {
+		fieldLogger.WithError(err).Error("Failed to update seed count")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit seed lot")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id, "message": T(c, "api_seed_lot_saved")})
+}
+
+// UpdateSeedLot replaces a lot's details. A change to the seeds left is
+// carried over to the strain's seed_count.
+func UpdateSeedLot(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "UpdateSeedLot")
+	lotID, ok := seedParam(c)
+	if !ok {
+		return
+	}
+	in, ok := bindSeedLotInput(c)
+	if !ok {
+		return
+	}
+
+	tx, err := DBFromContext(c).Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+	var strainID, left int
+	err = tx.QueryRow("SELECT strain_id, seeds_left FROM seed_lots WHERE id = $1", lotID).Scan(&strainID, &left)
+	if errors.Is(err, sql.ErrNoRows) {
+		apiNotFound(c, "api_seed_lot_not_found")
+		return
+	}
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to look up seed lot")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	newLeft := left
+	if in.SeedsLeft != nil {
+		newLeft = *in.SeedsLeft
+	}
+	if newLeft > in.PackSize {
+		apiBadRequest(c, errSeedsLeft.Error())
+		return
+	}
+	if _, err := tx.Exec(`UPDATE seed_lots SET source = $1, purchase_date = $2, pack_size = $3, seeds_left = $4,
+			cost = $5, feminized = $6, notes = $7, update_dt = CURRENT_TIMESTAMP
+		WHERE id = $8`,
+		in.Source, nullDateArg(in.PurchaseDate), in.PackSize, newLeft, in.Cost, in.Feminized, in.Notes, lotID); err != nil {
+		fieldLogger.WithError(err).Error("Failed to update seed lot")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if err := adjustSeedCount(tx, strainID, newLeft-left); err != nil {
+		fieldLogger.WithError(err).Error("Failed to update seed count")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit seed lot")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	apiOK(c, "api_seed_lot_saved")
+}
+
+// DeleteSeedLot deletes a lot and its germination attempts, taking its
+// remaining seeds off the strain's seed_count. Plants started from it are
+// kept.
+func DeleteSeedLot(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "DeleteSeedLot")
+	lotID, ok := seedParam(c)
+	if !ok {
+		return
+	}
+
+	tx, err := DBFromContext(c).Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+	var strainID, left int
+	err = tx.QueryRow("SELECT strain_id, seeds_left FROM seed_lots WHERE id = $1", lotID).Scan(&strainID, &left)
+	if errors.Is(err, sql.ErrNoRows) {
+		apiNotFound(c, "api_seed_lot_not_found")
+		return
+	}
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to look up seed lot")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	for _, q := range []string{
+		"UPDATE plant SET seed_lot_id = NULL, germination_id = NULL WHERE seed_lot_id = $1",
+		"DELETE FROM germination_attempts WHERE seed_lot_id = $1",
+		"DELETE FROM seed_lots WHERE id = $1",
+	} {
+		if _, err := tx.Exec(q, lotID); err != nil {
+			fieldLogger.WithError(err).Error("Failed to delete seed lot")
+			apiInternalError(c, "api_database_error")
+			return
+		}
+	}
+	if err := adjustSeedCount(tx, strainID, -left); err != nil {
+		fieldLogger.WithError(err).Error("Failed to update seed count")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit seed lot delete")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	apiOK(c, "api_seed_lot_deleted")
+}
+
+// germinationInput is the body of the germination create and update
+// requests. Seeds is fixed once the attempt is created; Sprouted and
+// SproutDate record its outcome.
+type germinationInput struct {
+	StartDate  string `json:"start_date"`
+	Seeds      int    `json:"seeds"`
+	Sprouted   *int   `json:"sprouted"`
+	SproutDate string `json:"sprout_date"`
+	Method     string `json:"method"`
+	Notes      string `json:"notes"`
+}
+
+// validate checks the body against an attempt of seeds seeds.
+func (in *germinationInput) validate(seeds int) error {
+	in.Method = strings.TrimSpace(in.Method)
+	if err := utils.ValidateStringLength("method", in.Method, utils.MaxNameLength); err != nil {
+		return err
+	}
+	if err := utils.ValidateStringLength("notes", in.Notes, utils.MaxNotesLength); err != nil {
+		return err
+	}
+	if err := validateRequiredDate("start_date", in.StartDate); err != nil {
+		return err
+	}
+	if in.Sprouted != nil && (*in.Sprouted < 0 || *in.Sprouted > seeds) {
+		return errSproutedCount
+	}
+	if in.SproutDate != "" {
+		if err := utils.ValidateDate("sprout_date", in.SproutDate); err != nil {
+			return err
+		}
+		if in.Sprouted == nil || *in.Sprouted == 0 {
+			return errSproutDateNoSprout
+		}
+		// Every accepted date layout starts with the YYYY-MM-DD digits.
+		if in.SproutDate[:10] < in.StartDate[:10] {
+			return errSproutBeforeStart
+		}
+	}
+	return nil
+}
+
+// AddGerminationAttempt sows seeds from a lot, taking them out of the lot
+// and the strain's seed_count.
+func AddGerminationAttempt(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "AddGerminationAttempt")
+	lotID, ok := seedParam(c)
+	if !ok {
+		return
+	}
+	var in germinationInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		apiBadRequest(c, "api_invalid_input")
+		return
+	}
+	if in.Seeds < 1 || in.Seeds > MaxSeedsPerLot {
+		apiBadRequest(c, errSeedCount.Error())
+		return
+	}
+	if err := in.validate(in.Seeds); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
+
+	tx, err := DBFromContext(c).Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+	var strainID, left int
+	err = tx.QueryRow("SELECT strain_id, seeds_left FROM seed_lots WHERE id = $1", lotID).Scan(&strainID, &left)
+	if errors.Is(err, sql.ErrNoRows) {
+		apiNotFound(c, "api_seed_lot_not_found")
+		return
+	}
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to look up seed lot")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if in.Seeds > left {
+		apiBadRequest(c, "api_not_enough_seeds")
+		return
+	}
+	var id int
+	err = tx.QueryRow(`INSERT INTO germination_attempts (seed_lot_id, start_date, seeds, sprouted, sprout_date, method, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		lotID, in.StartDate, in.Seeds, in.Sprouted, nullDateArg(in.SproutDate), in.Method, in.Notes).Scan(&id)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to create germination attempt")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if _, err := tx.Exec("UPDATE seed_lots SET seeds_left = seeds_left - $1, update_dt = CURRENT_TIMESTAMP WHERE id = $2", in.Seeds, lotID); err != nil {
+		fieldLogger.WithError(err).Error("Failed to update seed lot")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if err := adjustSeedCount(tx, strainID, -in.Seeds); err != nil {
+		fieldLogger.WithError(err).Error("Failed to update seed count")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit germination attempt")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id, "message": T(c, "api_germination_saved")})
+}
+
+// UpdateGerminationAttempt updates an attempt's details and records its
+// outcome. Fewer seeds can't be marked sprouted than plants were started
+// from it.
+func UpdateGerminationAttempt(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "UpdateGerminationAttempt")
+	id, ok := seedParam(c)
+	if !ok {
+		return
+	}
+	var in germinationInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		apiBadRequest(c, "api_invalid_input")
+		return
+	}
+	db := DBFromContext(c)
+	var seeds, started int
+	err := db.QueryRow(`SELECT seeds, (SELECT COUNT(*) FROM plant p WHERE p.germination_id = g.id)
+		FROM germination_attempts g WHERE g.id = $1`, id).Scan(&seeds, &started)
+	if errors.Is(err, sql.ErrNoRows) {
+		apiNotFound(c, "api_germination_not_found")
+		return
+	}
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to look up germination attempt")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if err := in.validate(seeds); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
+	if in.Sprouted != nil && *in.Sprouted < started {
+		apiBadRequest(c, "api_germination_plants_started")
+		return
+	}
+	if _, err := db.Exec(`UPDATE germination_attempts SET start_date = $1, sprouted = $2, sprout_date = $3,
+			method = $4, notes = $5, update_dt = CURRENT_TIMESTAMP
+		WHERE id = $6`,
+		in.StartDate, in.Sprouted, nullDateArg(in.SproutDate), in.Method, in.Notes, id); err != nil {
+		fieldLogger.WithError(err).Error("Failed to update germination attempt")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	apiOK(c, "api_germination_saved")
+}
+
+// DeleteGerminationAttempt deletes an attempt entered by mistake, putting
+// its seeds back in the lot and the strain's seed_count. Plants started
+// from it are kept, and the seeds they grew from stay used.
+func DeleteGerminationAttempt(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "DeleteGerminationAttempt")
+	id, ok := seedParam(c)
+	if !ok {
+		return
+	}
+
+	tx, err := DBFromContext(c).Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+	var lotID, strainID, seeds, started int
+	err = tx.QueryRow(`SELECT g.seed_lot_id, l.strain_id, g.seeds,
+			(SELECT COUNT(*) FROM plant p WHERE p.germination_id = g.id)
+		FROM germination_attempts g
+		JOIN seed_lots l ON l.id = g.seed_lot_id
+		WHERE g.id = $1`, id).Scan(&lotID, &strainID, &seeds, &started)
+	if errors.Is(err, sql.ErrNoRows) {
+		apiNotFound(c, "api_germination_not_found")
+		return
+	}
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to look up germination attempt")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	for _, q := range []string{
+		"UPDATE plant SET germination_id = NULL WHERE germination_id = $1",
+		"DELETE FROM germination_attempts WHERE id = $1",
+	} {
+		if _, err := tx.Exec(q, id); err != nil {
+			fieldLogger.WithError(err).Error("Failed to delete germination attempt")
+			apiInternalError(c, "api_database_error")
+			return
+		}
+	}
+	returned := max(seeds-started, 0)
+	if _, err := tx.Exec("UPDATE seed_lots SET seeds_left = seeds_left + $1, update_dt = CURRENT_TIMESTAMP WHERE id = $2", returned, lotID); err != nil {
+		fieldLogger.WithError(err).Error("Failed to update seed lot")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if err := adjustSeedCount(tx, strainID, returned); err != nil {
+		fieldLogger.WithError(err).Error("Failed to update seed count")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit germination attempt delete")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	apiOK(c, "api_germination_deleted")
+}
@@ -0,0 +1,359 @@
+package handlers_test
+
+// HTTP-layer tests for handlers/seed_lot.go: seed lots, germination
+// attempts, starting plants from a lot and germination-rate stats.
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"isley/tests/testutil"
+)
+
+type germinationStatsResponse struct {
+	Attempts        int      `json:"attempts"`
+	Pending         int      `json:"pending"`
+	SeedsSown       int      `json:"seeds_sown"`
+	Sprouted        int      `json:"sprouted"`
+	Rate            *float64 `json:"rate"`
+	AvgDaysToSprout *float64 `json:"avg_days_to_sprout"`
+}
+
+type seedLotResponse struct {
+	ID            int                      `json:"id"`
+	StrainID      int                      `json:"strain_id"`
+	Breeder       string                   `json:"breeder"`
+	Source        string                   `json:"source"`
+	PackSize      int                      `json:"pack_size"`
+	SeedsLeft     int                      `json:"seeds_left"`
+	Cost          *float64                 `json:"cost"`
+	Feminized     bool                     `json:"feminized"`
+	PlantsStarted int                      `json:"plants_started"`
+	Stats         germinationStatsResponse `json:"stats"`
+	Attempts      []struct {
+		ID            int    `json:"id"`
+		Seeds         int    `json:"seeds"`
+		Sprouted      *int   `json:"sprouted"`
+		Outcome       string `json:"outcome"`
+		DaysToSprout  *int   `json:"days_to_sprout"`
+		PlantsStarted int    `json:"plants_started"`
+	} `json:"attempts"`
+}
+
+func seedCountOf(t *testing.T, db *sql.DB, strainID int) int {
+	t.Helper()
+	return testutil.CountRows(t, db, `SELECT seed_count FROM strain WHERE id = $1`, strainID)
+}
+
+func seedsLeftOf(t *testing.T, db *sql.DB, lotID int) int {
+	t.Helper()
+	return testutil.CountRows(t, db, `SELECT seeds_left FROM seed_lots WHERE id = $1`, lotID)
+}
+
+func getStrainSeedLots(t *testing.T, c *testutil.Client, strainID int) []seedLotResponse {
+	t.Helper()
+	resp := c.Get("/strains/" + strconv.Itoa(strainID) + "/seed-lots")
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var lots []seedLotResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&lots))
+	return lots
+}
+
+// startPlant posts a new plant of strainID with extra fields merged in and
+// returns the response.
+func startPlant(t *testing.T, c *testutil.Client, db *sql.DB, apiKey string, strainID, zoneID int, extra map[string]interface{}) *http.Response {
+	t.Helper()
+	body := map[string]interface{}{
+		"name":      "Seedling",
+		"zone_id":   zoneID,
+		"strain_id": strainID,
+		"status_id": plantStatusID(t, db, "Germinating"),
+		"date":      "2026-05-10",
+	}
+	for k, v := range extra {
+		body[k] = v
+	}
+	return harvestRequest(t, c, http.MethodPost, "/plants", apiKey, body)
+}
+
+// ---------------------------------------------------------------------------
+// Lots, germinations and the strain's seed count
+// ---------------------------------------------------------------------------
+
+func TestSeedLotHTTP_SeedCountFollowsLots(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "seed-lot-key")
+	strainID := testutil.SeedStrain(t, db, testutil.SeedBreeder(t, db, "B"), "S")
+	zoneID := testutil.SeedZone(t, db, "Z")
+	c := server.NewClient(t)
+	lotsPath := "/strains/" + strconv.Itoa(strainID) + "/seed-lots"
+
+	lotID := createNutrientRow(t, c, apiKey, lotsPath, map[string]interface{}{
+		"source": " Seed shop ", "purchase_date": "2026-03-01", "pack_size": 10, "cost": 45.5, "feminized": true,
+	})
+	assert.Equal(t, 15, seedCountOf(t, db, strainID), "the pack is added to the 5 seeds already counted")
+
+	attemptID := createNutrientRow(t, c, apiKey, "/seed-lots/"+strconv.Itoa(lotID)+"/germinations", map[string]interface{}{
+		"start_date": "2026-05-01", "seeds": 4, "method": "Paper towel",
+	})
+	assert.Equal(t, 6, seedsLeftOf(t, db, lotID))
+	assert.Equal(t, 11, seedCountOf(t, db, strainID))
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/germinations/"+strconv.Itoa(attemptID), apiKey, map[string]interface{}{
+		"start_date": "2026-05-01", "sprouted": 2, "sprout_date": "2026-05-04", "method": "Paper towel",
+	}), http.StatusOK)
+
+	// Plants from the germination use its sprouted seeds; a plant straight
+	// from the lot takes a new seed.
+	for i := 0; i < 2; i++ {
+		expectHarvestStatus(t, startPlant(t, c, db, apiKey, strainID, zoneID, map[string]interface{}{
+			"germination_id": attemptID, "decrement_seed_count": true,
+		}), http.StatusOK)
+	}
+	expectHarvestStatus(t, startPlant(t, c, db, apiKey, strainID, zoneID, map[string]interface{}{"germination_id": attemptID}),
+		http.StatusBadRequest)
+	assert.Equal(t, 11, seedCountOf(t, db, strainID), "sprouted seeds already left the lot")
+	expectHarvestStatus(t, startPlant(t, c, db, apiKey, strainID, zoneID, map[string]interface{}{
+		"seed_lot_id": lotID, "decrement_seed_count": true,
+	}), http.StatusOK)
+	assert.Equal(t, 5, seedsLeftOf(t, db, lotID))
+	assert.Equal(t, 10, seedCountOf(t, db, strainID), "a plant from a lot takes one seed, not two")
+	assert.Equal(t, 3, testutil.CountRows(t, db, `SELECT COUNT(*) FROM plant WHERE seed_lot_id = $1`, lotID))
+
+	lots := getStrainSeedLots(t, c, strainID)
+	require.Len(t, lots, 1)
+	lot := lots[0]
+	assert.Equal(t, "Seed shop", lot.Source)
+	assert.Equal(t, 3, lot.PlantsStarted)
+	if assert.NotNil(t, lot.Cost) {
+		assert.Equal(t, 45.5, *lot.Cost)
+	}
+	require.Len(t, lot.Attempts, 1)
+	assert.Equal(t, "partial", lot.Attempts[0].Outcome)
+	assert.Equal(t, 2, lot.Attempts[0].PlantsStarted)
+	if assert.NotNil(t, lot.Attempts[0].DaysToSprout) {
+		assert.Equal(t, 3, *lot.Attempts[0].DaysToSprout)
+	}
+	if assert.NotNil(t, lot.Stats.Rate) {
+		assert.Equal(t, 0.5, *lot.Stats.Rate)
+	}
+
+	// Correcting the seeds left carries the difference to the strain.
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/seed-lots/"+strconv.Itoa(lotID), apiKey, map[string]interface{}{
+		"source": "Seed shop", "pack_size": 10, "seeds_left": 3, "feminized": false,
+	}), http.StatusOK)
+	assert.Equal(t, 8, seedCountOf(t, db, strainID))
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodDelete, "/germinations/"+strconv.Itoa(attemptID), apiKey, nil), http.StatusOK)
+	assert.Equal(t, 5, seedsLeftOf(t, db, lotID), "deleting a germination puts back the seeds no plant was started from")
+	assert.Equal(t, 10, seedCountOf(t, db, strainID))
+	assert.Zero(t, testutil.CountRows(t, db, `SELECT COUNT(*) FROM plant WHERE germination_id IS NOT NULL`))
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodDelete, "/seed-lots/"+strconv.Itoa(lotID), apiKey, nil), http.StatusOK)
+	assert.Equal(t, 5, seedCountOf(t, db, strainID))
+	assert.Equal(t, 3, testutil.CountRows(t, db, `SELECT COUNT(*) FROM plant WHERE strain_id = $1 AND seed_lot_id IS NULL`, strainID),
+		"plants started from the lot are kept")
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodDelete, "/seed-lots/"+strconv.Itoa(lotID), apiKey, nil), http.StatusNotFound)
+}
+
+func TestSeedLotHTTP_DeleteGerminationKeepsStartedSeeds(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "germination-delete-key")
+	strainID := testutil.SeedStrain(t, db, testutil.SeedBreeder(t, db, "B"), "S")
+	zoneID := testutil.SeedZone(t, db, "Z")
+	c := server.NewClient(t)
+
+	lotID := createNutrientRow(t, c, apiKey, "/strains/"+strconv.Itoa(strainID)+"/seed-lots", map[string]interface{}{"pack_size": 5})
+	attemptID := createNutrientRow(t, c, apiKey, "/seed-lots/"+strconv.Itoa(lotID)+"/germinations", map[string]interface{}{
+		"start_date": "2026-05-01", "seeds": 3, "sprouted": 1, "sprout_date": "2026-05-03",
+	})
+	expectHarvestStatus(t, startPlant(t, c, db, apiKey, strainID, zoneID, map[string]interface{}{"germination_id": attemptID}),
+		http.StatusOK)
+	assert.Equal(t, 2, seedsLeftOf(t, db, lotID))
+	assert.Equal(t, 7, seedCountOf(t, db, strainID))
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodDelete, "/germinations/"+strconv.Itoa(attemptID), apiKey, nil), http.StatusOK)
+	assert.Equal(t, 4, seedsLeftOf(t, db, lotID), "the seed the plant grew from stays used")
+	assert.Equal(t, 9, seedCountOf(t, db, strainID))
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM plant WHERE strain_id = $1`, strainID), "the plant is kept")
+}
+
+func TestSeedLotHTTP_Validation(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "seed-lot-validation-key")
+	breederID := testutil.SeedBreeder(t, db, "B")
+	strainID := testutil.SeedStrain(t, db, breederID, "S")
+	otherStrain := testutil.SeedStrain(t, db, breederID, "Other")
+	zoneID := testutil.SeedZone(t, db, "Z")
+	c := server.NewClient(t)
+	lotsPath := "/strains/" + strconv.Itoa(strainID) + "/seed-lots"
+
+	lotID := createNutrientRow(t, c, apiKey, lotsPath, map[string]interface{}{"pack_size": 3, "seeds_left": 1})
+	assert.Equal(t, 6, seedCountOf(t, db, strainID), "only the seeds left are counted")
+	germinationsPath := "/seed-lots/" + strconv.Itoa(lotID) + "/germinations"
+
+	for label, body := range map[string]map[string]interface{}{
+		"no pack size":        {},
+		"pack too large":      {"pack_size": 10001},
+		"more left than pack": {"pack_size": 3, "seeds_left": 4},
+		"negative cost":       {"pack_size": 3, "cost": -1},
+		"bad date":            {"pack_size": 3, "purchase_date": "spring"},
+	} {
+		resp := harvestRequest(t, c, http.MethodPost, lotsPath, apiKey, body)
+		testutil.DrainAndClose(resp)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, label)
+	}
+	resp := harvestRequest(t, c, http.MethodPost, lotsPath, apiKey, map[string]interface{}{"pack_size": 3, "seeds_left": 4})
+	var failure struct {
+		Error string `json:"error"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&failure))
+	testutil.DrainAndClose(resp)
+	assert.Equal(t, "Seeds left must be between 0 and the pack size", failure.Error, "validation errors are translated")
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, "/strains/9999/seed-lots", apiKey,
+		map[string]interface{}{"pack_size": 3}), http.StatusNotFound)
+
+	for label, body := range map[string]map[string]interface{}{
+		"no seeds":                   {"start_date": "2026-05-01"},
+		"more than left":             {"start_date": "2026-05-01", "seeds": 2},
+		"no start date":              {"seeds": 1},
+		"too many sprouted":          {"start_date": "2026-05-01", "seeds": 1, "sprouted": 2},
+		"sprout before start":        {"start_date": "2026-05-01", "seeds": 1, "sprouted": 1, "sprout_date": "2026-04-30"},
+		"sprout date, none sprouted": {"start_date": "2026-05-01", "seeds": 1, "sprouted": 0, "sprout_date": "2026-05-03"},
+	} {
+		resp := harvestRequest(t, c, http.MethodPost, germinationsPath, apiKey, body)
+		testutil.DrainAndClose(resp)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, label)
+	}
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, "/seed-lots/9999/germinations", apiKey,
+		map[string]interface{}{"start_date": "2026-05-01", "seeds": 1}), http.StatusNotFound)
+
+	// The lot's last seed goes to a plant; after that the lot is empty.
+	for label, extra := range map[string]map[string]interface{}{
+		"lot of another strain": {"seed_lot_id": lotID, "strain_id": otherStrain},
+		"unknown lot":           {"seed_lot_id": 9999},
+		"unknown germination":   {"germination_id": 9999},
+		"lot with a new strain": {"seed_lot_id": lotID, "strain_id": nil, "new_strain": map[string]interface{}{"name": "New", "breeder_id": breederID}},
+	} {
+		resp := startPlant(t, c, db, apiKey, strainID, zoneID, extra)
+		testutil.DrainAndClose(resp)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, label)
+	}
+	expectHarvestStatus(t, startPlant(t, c, db, apiKey, strainID, zoneID, map[string]interface{}{"seed_lot_id": lotID}), http.StatusOK)
+	expectHarvestStatus(t, startPlant(t, c, db, apiKey, strainID, zoneID, map[string]interface{}{"seed_lot_id": lotID}), http.StatusBadRequest)
+	assert.Zero(t, seedsLeftOf(t, db, lotID))
+	assert.Equal(t, 5, seedCountOf(t, db, strainID))
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/germinations/9999", apiKey,
+		map[string]interface{}{"start_date": "2026-05-01"}), http.StatusNotFound)
+	resp = c.Get("/strains/9999/seed-lots")
+	testutil.DrainAndClose(resp)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+// ---------------------------------------------------------------------------
+// Germination rates per lot and per breeder
+// ---------------------------------------------------------------------------
+
+func TestSeedLotHTTP_SeedBankStats(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "seed-bank-key")
+	testutil.SeedAdmin(t, db, "seed-bank-pw")
+	zeta := testutil.SeedBreeder(t, db, "Zeta Seeds")
+	alpha := testutil.SeedBreeder(t, db, "Alpha Genetics")
+	zetaStrain := testutil.SeedStrain(t, db, zeta, "Zeta Kush")
+	alphaStrain := testutil.SeedStrain(t, db, alpha, "Alpha Haze")
+	c := server.NewClient(t)
+
+	lot := func(strainID, pack int) int {
+		return createNutrientRow(t, c, apiKey, "/strains/"+strconv.Itoa(strainID)+"/seed-lots", map[string]interface{}{"pack_size": pack})
+	}
+	sow := func(lotID int, body map[string]interface{}) {
+		createNutrientRow(t, c, apiKey, "/seed-lots/"+strconv.Itoa(lotID)+"/germinations", body)
+	}
+	zetaA, zetaB, alphaLot := lot(zetaStrain, 10), lot(zetaStrain, 5), lot(alphaStrain, 5)
+	sow(zetaA, map[string]interface{}{"start_date": "2026-05-01", "seeds": 4, "sprouted": 4, "sprout_date": "2026-05-03"})
+	sow(zetaA, map[string]interface{}{"start_date": "2026-05-01", "seeds": 2, "sprouted": 0})
+	sow(zetaB, map[string]interface{}{"start_date": "2026-05-02", "seeds": 4, "sprouted": 2, "sprout_date": "2026-05-08"})
+	sow(alphaLot, map[string]interface{}{"start_date": "2026-05-02", "seeds": 3})
+
+	resp := c.Get("/seeds/stats")
+	var bank struct {
+		Breeders []struct {
+			Breeder string                   `json:"breeder"`
+			Lots    int                      `json:"lots"`
+			Stats   germinationStatsResponse `json:"stats"`
+		} `json:"breeders"`
+		Lots []seedLotResponse `json:"lots"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&bank))
+	testutil.DrainAndClose(resp)
+
+	require.Len(t, bank.Breeders, 2)
+	assert.Equal(t, "Alpha Genetics", bank.Breeders[0].Breeder, "breeders in name order")
+	assert.Equal(t, 1, bank.Breeders[0].Stats.Pending)
+	assert.Nil(t, bank.Breeders[0].Stats.Rate, "no outcome recorded yet")
+	zetaStats := bank.Breeders[1]
+	assert.Equal(t, 2, zetaStats.Lots)
+	assert.Equal(t, 10, zetaStats.Stats.SeedsSown)
+	assert.Equal(t, 6, zetaStats.Stats.Sprouted)
+	if assert.NotNil(t, zetaStats.Stats.Rate) {
+		assert.InDelta(t, 0.6, *zetaStats.Stats.Rate, 0.001)
+	}
+	if assert.NotNil(t, zetaStats.Stats.AvgDaysToSprout) {
+		assert.InDelta(t, 4.0, *zetaStats.Stats.AvgDaysToSprout, 0.001, "(2 + 6) / 2 timed attempts")
+	}
+
+	require.Len(t, bank.Lots, 3)
+	byID := map[int]seedLotResponse{}
+	for _, l := range bank.Lots {
+		byID[l.ID] = l
+	}
+	if assert.NotNil(t, byID[zetaA].Stats.Rate) {
+		assert.InDelta(t, 0.667, *byID[zetaA].Stats.Rate, 0.001)
+	}
+	assert.Equal(t, "failed", byID[zetaA].Attempts[1].Outcome)
+	assert.Equal(t, "sprouted", byID[zetaA].Attempts[0].Outcome)
+	assert.Equal(t, "pending", byID[alphaLot].Attempts[0].Outcome)
+	assert.Equal(t, 4, byID[zetaA].SeedsLeft)
+	assert.Equal(t, "Zeta Seeds", byID[zetaB].Breeder)
+
+	for _, path := range []string{"/seeds", "/strain/" + strconv.Itoa(zetaStrain)} {
+		resp := c.Get(path)
+		body, err := io.ReadAll(resp.Body)
+		testutil.DrainAndClose(resp)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode, path)
+		assert.Contains(t, string(body), "Zeta", path)
+		assert.Contains(t, string(body), "67%", path)
+	}
+
+	// Signed in, a pending germination offers its outcome form.
+	resp = server.LoginAsAdmin(t, "seed-bank-pw").Get("/strain/" + strconv.Itoa(alphaStrain))
+	body, err := io.ReadAll(resp.Body)
+	testutil.DrainAndClose(resp)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "germination-outcome-form")
+	assert.Contains(t, string(body), `data-start-date="2026-05-02"`)
+}
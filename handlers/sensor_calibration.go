@@ -0,0 +1,444 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"isley/logger"
+	"isley/model"
+	"isley/model/types"
+	"isley/utils"
+)
+
+// calibrationInput is the body of POST /sensors/:id/calibration. Scale and
+// offset are used for the linear method; the four reference readings for
+// two_point. Posting {"method":"linear"} alone resets the sensor.
+type calibrationInput struct {
+	Method      string   `json:"method"`
+	Scale       *float64 `json:"scale"`
+	Offset      float64  `json:"offset"`
+	RawLow      *float64 `json:"raw_low"`
+	RefLow      *float64 `json:"ref_low"`
+	RawHigh     *float64 `json:"raw_high"`
+	RefHigh     *float64 `json:"ref_high"`
+	Retroactive bool     `json:"retroactive"`
+	Note        string   `json:"note"`
+}
+
+// calibrationQuerier is satisfied by both *sql.DB and *sql.Tx.
+type calibrationQuerier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// loadCalibrationHistory returns every calibration recorded for sensorID,
+// oldest first.
+func loadCalibrationHistory(q calibrationQuerier, sensorID int) ([]types.SensorCalibration, error) {
+	rows, err := q.Query(`
+		SELECT id, sensor_id, method, scale, offset_value, raw_low, ref_low, raw_high, ref_high,
+		       retroactive, last_data_id, note, create_dt
+		FROM sensor_calibrations WHERE sensor_id = $1 ORDER BY id`, sensorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []types.SensorCalibration{}
+	for rows.Next() {
+		var c types.SensorCalibration
+		var rawLow, refLow, rawHigh, refHigh sql.NullFloat64
+		if err := rows.Scan(&c.ID, &c.SensorID, &c.Method, &c.Scale, &c.Offset, &rawLow, &refLow, &rawHigh, &refHigh,
+			&c.Retroactive, &c.LastDataID, &c.Note, &c.CreateDT); err != nil {
+			return nil, err
+		}
+		c.RawLow, c.RefLow = nullFloatPtr(rawLow), nullFloatPtr(refLow)
+		c.RawHigh, c.RefHigh = nullFloatPtr(rawHigh), nullFloatPtr(refHigh)
+		history = append(history, c)
+	}
+	return history, rows.Err()
+}
+
+func nullFloatPtr(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
+
+// GetSensorCalibrationHandler returns a sensor's active calibration and the
+// history of changes, newest first.
+func GetSensorCalibrationHandler(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "GetSensorCalibrationHandler")
+
+	sensorID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || sensorID <= 0 {
+		apiBadRequest(c, "api_invalid_request")
+		return
+	}
+
+	db := DBFromContext(c)
+	history, err := loadCalibrationHistory(db, sensorID)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to load calibration history")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	active := types.IdentityCalibration
+	active.SensorID = sensorID
+	if len(history) > 0 {
+		active = history[len(history)-1]
+	}
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+
+	c.JSON(http.StatusOK, gin.H{"calibration": active, "history": history})
+}
+
+// SetSensorCalibrationHandler records a new calibration for a sensor. It
+// applies to readings ingested from now on; with retroactive set, stored
+// readings are also rewritten from whatever calibration they were stored
+// under to the new one, and the sensor's hourly rollups are rebuilt.
+func SetSensorCalibrationHandler(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "SetSensorCalibrationHandler")
+
+	sensorID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || sensorID <= 0 {
+		apiBadRequest(c, "api_invalid_request")
+		return
+	}
+
+	var input calibrationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apiBadRequest(c, "api_invalid_payload")
+		return
+	}
+	cal, errKey := buildCalibration(input)
+	if errKey != "" {
+		apiBadRequest(c, errKey)
+		return
+	}
+	cal.SensorID = sensorID
+
+	db := DBFromContext(c)
+	var source string
+	if err := db.QueryRow("SELECT source FROM sensors WHERE id = $1", sensorID).Scan(&source); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			apiNotFound(c, "api_sensor_not_found")
+			return
+		}
+		fieldLogger.WithError(err).Error("Failed to look up sensor")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	// Virtual sensors are computed from already-calibrated inputs.
+	if source == types.VirtualSource {
+		apiBadRequest(c, "api_calibration_virtual_sensor")
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op once the tx is committed
+
+	if err := tx.QueryRow("SELECT COALESCE(MAX(id), 0) FROM sensor_data WHERE sensor_id = $1", sensorID).Scan(&cal.LastDataID); err != nil {
+		fieldLogger.WithError(err).Error("Failed to read latest sensor data id")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	var updated int64
+	var history []types.SensorCalibration
+	if cal.Retroactive {
+		history, err = loadCalibrationHistory(tx, sensorID)
+		if err != nil {
+			fieldLogger.WithError(err).Error("Failed to load calibration history")
+			apiInternalError(c, "api_database_error")
+			return
+		}
+		for _, seg := range storedCalibrationSegments(history, cal.LastDataID) {
+			if seg.cal.Scale == cal.Scale && seg.cal.Offset == cal.Offset {
+				continue
+			}
+			mul, add := recalibration(seg.cal, cal)
+			res, err := tx.Exec(
+				"UPDATE sensor_data SET value = value * $1 + $2 WHERE sensor_id = $3 AND id > $4 AND id <= $5",
+				mul, add, sensorID, seg.afterID, seg.throughID,
+			)
+			if err != nil {
+				fieldLogger.WithError(err).Error("Failed to recalibrate stored readings")
+				apiInternalError(c, "api_database_error")
+				return
+			}
+			n, _ := res.RowsAffected()
+			updated += n
+		}
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO sensor_calibrations
+			(sensor_id, method, scale, offset_value, raw_low, ref_low, raw_high, ref_high, retroactive, last_data_id, note)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id, create_dt`,
+		sensorID, cal.Method, cal.Scale, cal.Offset, cal.RawLow, cal.RefLow, cal.RawHigh, cal.RefHigh,
+		cal.Retroactive, cal.LastDataID, cal.Note,
+	).Scan(&cal.ID, &cal.CreateDT)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to save calibration")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	recalibrated := updated > 0
+	if cal.Retroactive {
+		changed, err := recalibrateSensorRollups(tx, sensorID, history, cal, updated > 0)
+		if err != nil {
+			fieldLogger.WithError(err).Error("Failed to recalibrate hourly rollups")
+			apiInternalError(c, "api_database_error")
+			return
+		}
+		recalibrated = recalibrated || changed
+	}
+
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit calibration")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	if recalibrated {
+		cache := SensorCacheServiceFromContext(c)
+		cache.DataReset()
+		cache.GroupedReset()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      T(c, "api_calibration_saved"),
+		"calibration":  cal,
+		"rows_updated": updated,
+	})
+}
+
+// buildCalibration validates input and derives the scale and offset it
+// describes. It returns a translation key when the input is unusable.
+func buildCalibration(input calibrationInput) (types.SensorCalibration, string) {
+	if err := utils.ValidateStringLength("note", input.Note, utils.MaxNotesLength); err != nil {
+		return types.SensorCalibration{}, err.Error()
+	}
+	cal := types.SensorCalibration{
+		Method:      input.Method,
+		Retroactive: input.Retroactive,
+		Note:        input.Note,
+	}
+	finite := func(v float64) bool { return !math.IsNaN(v) && !math.IsInf(v, 0) }
+
+	switch input.Method {
+	case types.CalibrationLinear:
+		cal.Scale = 1
+		if input.Scale != nil {
+			cal.Scale = *input.Scale
+		}
+		cal.Offset = input.Offset
+
+	case types.CalibrationTwoPoint:
+		if input.RawLow == nil || input.RefLow == nil || input.RawHigh == nil || input.RefHigh == nil {
+			return cal, "api_calibration_two_point_invalid"
+		}
+		for _, v := range []float64{*input.RawLow, *input.RefLow, *input.RawHigh, *input.RefHigh} {
+			if !finite(v) {
+				return cal, "api_calibration_two_point_invalid"
+			}
+		}
+		if *input.RawHigh == *input.RawLow {
+			return cal, "api_calibration_two_point_invalid"
+		}
+		cal.Scale = (*input.RefHigh - *input.RefLow) / (*input.RawHigh - *input.RawLow)
+		cal.Offset = *input.RefLow - cal.Scale**input.RawLow
+		cal.RawLow, cal.RefLow = input.RawLow, input.RefLow
+		cal.RawHigh, cal.RefHigh = input.RawHigh, input.RefHigh
+
+	default:
+		return cal, "api_invalid_calibration"
+	}
+
+	// A zero scale would flatten every reading and can't be undone later.
+	if !finite(cal.Scale) || !finite(cal.Offset) || cal.Scale == 0 {
+		return cal, "api_invalid_calibration"
+	}
+	return cal, ""
+}
+
+// calibrationSegment is a run of sensor_data ids, (afterID, throughID], whose
+// stored values all carry the same calibration.
+type calibrationSegment struct {
+	afterID   int
+	throughID int
+	cal       types.SensorCalibration
+}
+
+// storedCalibrationSegments works out which calibration each stored reading
+// of a sensor currently carries, for ids up to maxID.
+//
+// A reading with id x was stored under the newest entry whose LastDataID is
+// below x, unless a later retroactive entry (LastDataID >= x) rewrote it. The
+// latest of those events wins; readings older than any entry are raw.
+func storedCalibrationSegments(history []types.SensorCalibration, maxID int) []calibrationSegment {
+	bounds := []int{0, maxID}
+	for _, h := range history {
+		if h.LastDataID > 0 && h.LastDataID < maxID {
+			bounds = append(bounds, h.LastDataID)
+		}
+	}
+	sort.Ints(bounds)
+
+	var segs []calibrationSegment
+	for i := 1; i < len(bounds); i++ {
+		lo, hi := bounds[i-1], bounds[i]
+		if hi <= lo {
+			continue
+		}
+		eff := types.IdentityCalibration
+		for _, h := range history {
+			if h.LastDataID < hi || (h.Retroactive && h.LastDataID >= hi) {
+				eff = h
+			}
+		}
+		if n := len(segs); n > 0 && segs[n-1].cal.Scale == eff.Scale && segs[n-1].cal.Offset == eff.Offset {
+			segs[n-1].throughID = hi
+			continue
+		}
+		segs = append(segs, calibrationSegment{afterID: lo, throughID: hi, cal: eff})
+	}
+	return segs
+}
+
+// recalibration returns the mul and add that turn a value stored under
+// from into the value cal gives the same raw reading:
+// raw = (v - o0) / s0, so v' = raw*s1 + o1 = v*(s1/s0) + (o1 - o0*s1/s0).
+func recalibration(from, cal types.SensorCalibration) (mul, add float64) {
+	mul = cal.Scale / from.Scale
+	return mul, cal.Offset - from.Offset*mul
+}
+
+// calibrationPeriod is a span of time, (after, through], whose readings
+// were all stored under the same calibration. A zero after or through
+// leaves that end open.
+type calibrationPeriod struct {
+	after   time.Time
+	through time.Time
+	cal     types.SensorCalibration
+}
+
+// storedCalibrationPeriods is storedCalibrationSegments by time, for hourly
+// rollups whose raw readings have been pruned: a reading was stored under
+// the newest entry saved before it, unless a later retroactive entry
+// rewrote it.
+func storedCalibrationPeriods(history []types.SensorCalibration) []calibrationPeriod {
+	bounds := []time.Time{{}}
+	for _, h := range history {
+		bounds = append(bounds, h.CreateDT)
+	}
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i].Before(bounds[j]) })
+	bounds = append(bounds, time.Time{})
+
+	var periods []calibrationPeriod
+	for i := 1; i < len(bounds); i++ {
+		lo, hi := bounds[i-1], bounds[i]
+		if !hi.IsZero() && !hi.After(lo) {
+			continue
+		}
+		eff := types.IdentityCalibration
+		for _, h := range history {
+			if !h.CreateDT.After(lo) || (h.Retroactive && !hi.IsZero() && !h.CreateDT.Before(hi)) {
+				eff = h
+			}
+		}
+		if n := len(periods); n > 0 && periods[n-1].cal.Scale == eff.Scale && periods[n-1].cal.Offset == eff.Offset {
+			periods[n-1].through = hi
+			continue
+		}
+		periods = append(periods, calibrationPeriod{after: lo, through: hi, cal: eff})
+	}
+	return periods
+}
+
+// recalibrateSensorRollups brings one sensor's sensor_data_hourly buckets
+// in line with a retroactive calibration. When readings were rewritten,
+// the buckets from the first raw reading on are rebuilt from sensor_data.
+// Older buckets outlive their pruned readings, so their min, max and
+// average are recalibrated in place instead. The rolling average is cleared; the sensor_data insert
+// trigger repopulates it on the next reading. changed is false when
+// nothing needed recalibrating.
+func recalibrateSensorRollups(tx *sql.Tx, sensorID int, history []types.SensorCalibration, cal types.SensorCalibration, rewritten bool) (changed bool, err error) {
+	// Buckets are compared as UTC wall-clock text, which both drivers
+	// read back as the stored bucket.
+	const bucketLayout = "2006-01-02 15:04:05"
+
+	var first time.Time
+	err = tx.QueryRow("SELECT create_dt FROM sensor_data WHERE sensor_id = $1 ORDER BY create_dt LIMIT 1", sensorID).Scan(&first)
+	hasRaw := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+
+	for _, p := range storedCalibrationPeriods(history) {
+		if p.cal.Scale == cal.Scale && p.cal.Offset == cal.Offset {
+			continue
+		}
+		mul, add := recalibration(p.cal, cal)
+		set := "min_val = min_val * $1 + $2, max_val = max_val * $1 + $2"
+		if mul < 0 {
+			// A negative scale turns the lowest reading into the highest.
+			set = "min_val = max_val * $1 + $2, max_val = min_val * $1 + $2"
+		}
+		query := "UPDATE sensor_data_hourly SET " + set + ", avg_val = avg_val * $1 + $2 WHERE sensor_id = $3"
+		args := []interface{}{mul, add, sensorID}
+		cond := func(op string, at time.Time) {
+			args = append(args, at.UTC().Format(bucketLayout))
+			query += fmt.Sprintf(" AND bucket %s $%d", op, len(args))
+		}
+		if hasRaw {
+			cond("<", first.Truncate(time.Hour))
+		}
+		if !p.after.IsZero() {
+			cond(">", p.after)
+		}
+		if !p.through.IsZero() {
+			cond("<=", p.through)
+		}
+		res, err := tx.Exec(query, args...)
+		if err != nil {
+			return false, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			changed = true
+		}
+	}
+
+	if hasRaw && rewritten {
+		if _, err := tx.Exec("DELETE FROM sensor_data_hourly WHERE sensor_id = $1 AND bucket >= $2",
+			sensorID, first.Truncate(time.Hour).UTC().Format(bucketLayout)); err != nil {
+			return false, err
+		}
+		if _, err := tx.Exec(model.HourlyRollupQuery("sd.sensor_id = $1"), sensorID); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+	if !changed {
+		return false, nil
+	}
+	_, err = tx.Exec("DELETE FROM rolling_averages WHERE sensor_id = $1", sensorID)
+	return true, err
+}
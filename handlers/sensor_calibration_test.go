@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"isley/model/types"
+)
+
+// ---------------------------------------------------------------------------
+// buildCalibration
+// ---------------------------------------------------------------------------
+
+func TestBuildCalibration_TwoPointDerivesScaleAndOffset(t *testing.T) {
+	t.Parallel()
+
+	f := func(v float64) *float64 { return &v }
+	cal, errKey := buildCalibration(calibrationInput{
+		Method: types.CalibrationTwoPoint,
+		RawLow: f(1.5), RefLow: f(1.413),
+		RawHigh: f(13.0), RefHigh: f(12.88),
+	})
+	require.Empty(t, errKey)
+	assert.InDelta(t, 1.413, cal.Apply(1.5), 1e-9)
+	assert.InDelta(t, 12.88, cal.Apply(13.0), 1e-9)
+}
+
+func TestBuildCalibration_LinearDefaultsToUnitScale(t *testing.T) {
+	t.Parallel()
+
+	cal, errKey := buildCalibration(calibrationInput{Method: types.CalibrationLinear, Offset: -1.5})
+	require.Empty(t, errKey)
+	assert.Equal(t, 1.0, cal.Scale)
+	assert.Equal(t, 75.8, cal.Apply(77.3), "offset result is rounded, not 75.80000000000001")
+}
+
+func TestBuildCalibration_Rejects(t *testing.T) {
+	t.Parallel()
+
+	f := func(v float64) *float64 { return &v }
+	cases := []struct {
+		name  string
+		input calibrationInput
+		want  string
+	}{
+		{"unknown method", calibrationInput{Method: "polynomial"}, "api_invalid_calibration"},
+		{"zero scale", calibrationInput{Method: types.CalibrationLinear, Scale: f(0)}, "api_invalid_calibration"},
+		{"missing point", calibrationInput{Method: types.CalibrationTwoPoint, RawLow: f(1), RefLow: f(1), RawHigh: f(2)}, "api_calibration_two_point_invalid"},
+		{"same raw readings", calibrationInput{Method: types.CalibrationTwoPoint, RawLow: f(5), RefLow: f(1), RawHigh: f(5), RefHigh: f(2)}, "api_calibration_two_point_invalid"},
+		{"same references", calibrationInput{Method: types.CalibrationTwoPoint, RawLow: f(1), RefLow: f(3), RawHigh: f(2), RefHigh: f(3)}, "api_invalid_calibration"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, errKey := buildCalibration(tc.input)
+			assert.Equal(t, tc.want, errKey)
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// storedCalibrationSegments
+// ---------------------------------------------------------------------------
+
+func TestStoredCalibrationSegments(t *testing.T) {
+	t.Parallel()
+
+	lin := func(scale, offset float64, lastID int, retro bool) types.SensorCalibration {
+		return types.SensorCalibration{Scale: scale, Offset: offset, LastDataID: lastID, Retroactive: retro}
+	}
+	type seg struct {
+		after, through int
+		scale, offset  float64
+	}
+	flatten := func(segs []calibrationSegment) []seg {
+		out := []seg{}
+		for _, s := range segs {
+			out = append(out, seg{s.afterID, s.throughID, s.cal.Scale, s.cal.Offset})
+		}
+		return out
+	}
+
+	cases := []struct {
+		name    string
+		history []types.SensorCalibration
+		maxID   int
+		want    []seg
+	}{
+		{"no history is raw", nil, 50, []seg{{0, 50, 1, 0}}},
+		{"forward-only changes", []types.SensorCalibration{lin(1, -1, 10, false), lin(2, 0, 20, false)}, 30,
+			[]seg{{0, 10, 1, 0}, {10, 20, 1, -1}, {20, 30, 2, 0}}},
+		{"retroactive rewrites everything before it", []types.SensorCalibration{lin(1, -1, 10, false), lin(1, -2, 20, true)}, 30,
+			[]seg{{0, 30, 1, -2}}},
+		{"changes after a retroactive one", []types.SensorCalibration{lin(1, -2, 20, true), lin(3, 0, 25, false)}, 30,
+			[]seg{{0, 25, 1, -2}, {25, 30, 3, 0}}},
+		{"no readings", []types.SensorCalibration{lin(1, -1, 0, false)}, 0, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := flatten(storedCalibrationSegments(tc.history, tc.maxID))
+			if tc.want == nil {
+				assert.Empty(t, got)
+				return
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// storedCalibrationPeriods
+// ---------------------------------------------------------------------------
+
+func TestStoredCalibrationPeriods(t *testing.T) {
+	t.Parallel()
+
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 12, 0, 0, 0, time.UTC) }
+	lin := func(scale, offset float64, at time.Time, retro bool) types.SensorCalibration {
+		return types.SensorCalibration{Scale: scale, Offset: offset, CreateDT: at, Retroactive: retro}
+	}
+	type period struct {
+		after, through time.Time
+		scale, offset  float64
+	}
+	flatten := func(periods []calibrationPeriod) []period {
+		out := []period{}
+		for _, p := range periods {
+			out = append(out, period{p.after, p.through, p.cal.Scale, p.cal.Offset})
+		}
+		return out
+	}
+
+	cases := []struct {
+		name    string
+		history []types.SensorCalibration
+		want    []period
+	}{
+		{"no history is raw", nil, []period{{time.Time{}, time.Time{}, 1, 0}}},
+		{"forward-only changes", []types.SensorCalibration{lin(1, -1, day(10), false), lin(2, 0, day(20), false)},
+			[]period{{time.Time{}, day(10), 1, 0}, {day(10), day(20), 1, -1}, {day(20), time.Time{}, 2, 0}}},
+		{"retroactive rewrites everything before it", []types.SensorCalibration{lin(1, -1, day(10), false), lin(1, -2, day(20), true)},
+			[]period{{time.Time{}, time.Time{}, 1, -2}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.want, flatten(storedCalibrationPeriods(tc.history)))
+		})
+	}
+}
@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"isley/logger"
+	"isley/utils"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultChartPoints is the bucket count used when the caller does not
+	// pass ?points=. Roughly one bucket per horizontal pixel pair on a
+	// typical dashboard card.
+	DefaultChartPoints = 500
+	// MinChartPoints and MaxChartPoints clamp the requested bucket count.
+	MinChartPoints = 10
+	MaxChartPoints = 5000
+	// MaxChartSensors caps how many series one chart request may overlay.
+	MaxChartSensors = 12
+)
+
+// ChartSeries is one sensor's downsampled data, aligned to the shared
+// ChartResponse.Timestamps axis. Values/Min/Max hold one entry per bucket
+// and are nil where the sensor reported nothing in that bucket, so a
+// client can draw gaps instead of interpolating across outages.
+type ChartSeries struct {
+	SensorID int        `json:"sensor_id"`
+	Name     string     `json:"name"`
+	Unit     string     `json:"unit"`
+	Values   []*float64 `json:"values"`
+	Min      []*float64 `json:"min"`
+	Max      []*float64 `json:"max"`
+}
+
+// ChartResponse is the payload returned by ChartSeriesHandler. Every
+// series shares Timestamps (bucket start times) so several sensors can be
+// overlaid on one chart without client-side resampling.
+type ChartResponse struct {
+	Source        string        `json:"source"` // "raw" or "hourly"
+	Start         time.Time     `json:"start"`
+	End           time.Time     `json:"end"`
+	BucketSeconds int64         `json:"bucket_seconds"`
+	Timestamps    []time.Time   `json:"timestamps"`
+	Series        []ChartSeries `json:"series"`
+}
+
+// chartQuery is the validated form of a chart request.
+type chartQuery struct {
+	SensorIDs []int
+	Start     time.Time // UTC
+	End       time.Time // UTC
+	Points    int
+}
+
+// chartBucket accumulates the readings that fall into one time bucket.
+// Hourly rollup rows contribute their own min/max and a sample-weighted
+// average so the envelope stays faithful to the raw data.
+type chartBucket struct {
+	min, max, sum float64
+	count         int64
+}
+
+func (b *chartBucket) add(avg, lo, hi float64, samples int64) {
+	if samples <= 0 {
+		samples = 1
+	}
+	if b.count == 0 || lo < b.min {
+		b.min = lo
+	}
+	if b.count == 0 || hi > b.max {
+		b.max = hi
+	}
+	b.sum += avg * float64(samples)
+	b.count += samples
+}
+
+// ChartSeriesHandler returns several sensors' readings over a time range,
+// downsampled server-side into a fixed number of aligned min/avg/max
+// buckets. Ranges longer than RollupThresholdHours read from
+// sensor_data_hourly; shorter ranges read raw sensor_data.
+//
+// Query parameters:
+//
+//	sensors  comma-separated sensor IDs (required, at most MaxChartSensors)
+//	minutes  look-back window ending now, or
+//	start/end  explicit range in utils.LayoutDB or YYYY-MM-DD form
+//	points   target bucket count (default DefaultChartPoints)
+//
+// Values and units are converted to the configured display units.
+//
+// Buckets are used rather than LTTB because LTTB picks different
+// timestamps per series, which defeats overlaying them on one axis.
+func ChartSeriesHandler(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("handler", "ChartSeriesHandler")
+
+	q, errKey := parseChartQuery(c, time.Now().UTC())
+	if errKey != "" {
+		fieldLogger.WithField("query", c.Request.URL.RawQuery).Warn("Rejected chart query")
+		apiBadRequest(c, errKey)
+		return
+	}
+
+	db := DBFromContext(c)
+	resp, err := queryChartSeries(db, q)
+	if errors.Is(err, errUnknownChartSensor) {
+		apiBadRequest(c, "api_invalid_sensor_ids")
+		return
+	}
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to query chart data")
+		apiInternalError(c, "api_sensor_query_failed")
+		return
+	}
+	convertChartSeries(&resp, UnitPrefsFromContext(c))
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// parseChartQuery validates the request parameters. It returns a
+// translation key on failure so the handler can surface it directly.
+func parseChartQuery(c *gin.Context, now time.Time) (chartQuery, string) {
+	var q chartQuery
+
+	raw := strings.TrimSpace(c.Query("sensors"))
+	if raw == "" {
+		return q, "api_sensor_param_required"
+	}
+	seen := map[int]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || id <= 0 {
+			return q, "api_invalid_sensor_ids"
+		}
+		if !seen[id] {
+			seen[id] = true
+			q.SensorIDs = append(q.SensorIDs, id)
+		}
+	}
+	if len(q.SensorIDs) > MaxChartSensors {
+		return q, "api_chart_too_many_sensors"
+	}
+
+	startDate, endDate := c.Query("start"), c.Query("end")
+	minutes := c.Query("minutes")
+	switch {
+	case startDate != "" && endDate != "":
+		startUTC, err := timeConversion(startDate)
+		if err != nil {
+			return q, "api_sensor_dates_required"
+		}
+		endUTC, err := timeConversion(endDate)
+		if err != nil {
+			return q, "api_sensor_dates_required"
+		}
+		q.Start, _ = time.Parse(utils.LayoutDB, startUTC)
+		q.End, _ = time.Parse(utils.LayoutDB, endUTC)
+		// A bare end date means "through the end of that day".
+		if len(endDate) == 10 {
+			q.End = q.End.Add(24*time.Hour - time.Second)
+		}
+	case minutes != "":
+		m, err := strconv.Atoi(minutes)
+		if err != nil || m <= 0 {
+			return q, "api_sensor_dates_required"
+		}
+		q.End = now.Truncate(time.Second)
+		q.Start = q.End.Add(-time.Duration(m) * time.Minute)
+	default:
+		return q, "api_sensor_dates_required"
+	}
+	if !q.End.After(q.Start) {
+		return q, "api_sensor_dates_required"
+	}
+
+	q.Points = DefaultChartPoints
+	if p := c.Query("points"); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return q, "api_invalid_request"
+		}
+		q.Points = n
+	}
+	if q.Points < MinChartPoints {
+		q.Points = MinChartPoints
+	}
+	if q.Points > MaxChartPoints {
+		q.Points = MaxChartPoints
+	}
+
+	return q, ""
+}
+
+// errUnknownChartSensor is returned by queryChartSeries when one of the
+// requested IDs has no sensors row.
+var errUnknownChartSensor = errors.New("chart: unknown sensor id")
+
+// queryChartSeries loads sensor metadata and readings for q and folds the
+// readings into aligned buckets. Rows are streamed straight into the
+// fixed-size bucket arrays, so memory stays bounded by
+// len(SensorIDs) * Points regardless of how many raw rows the range spans.
+func queryChartSeries(db *sql.DB, q chartQuery) (ChartResponse, error) {
+	useRollup := q.End.Sub(q.Start).Hours() > RollupThresholdHours
+
+	bucketWidth := chartBucketWidth(q.Start, q.End, q.Points, useRollup)
+	nBuckets := int(q.End.Sub(q.Start)/bucketWidth) + 1
+
+	resp := ChartResponse{
+		Source:        "raw",
+		Start:         q.Start.Local(),
+		End:           q.End.Local(),
+		BucketSeconds: int64(bucketWidth / time.Second),
+		Timestamps:    make([]time.Time, nBuckets),
+	}
+	if useRollup {
+		resp.Source = "hourly"
+	}
+	for i := range resp.Timestamps {
+		resp.Timestamps[i] = q.Start.Add(time.Duration(i) * bucketWidth).Local()
+	}
+
+	placeholders := make([]string, len(q.SensorIDs))
+	args := make([]interface{}, 0, len(q.SensorIDs)+2)
+	for i, id := range q.SensorIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args = append(args, id)
+	}
+	inClause := strings.Join(placeholders, ",")
+
+	// Sensor metadata, in request order.
+	index := make(map[int]int, len(q.SensorIDs))
+	buckets := make([][]chartBucket, len(q.SensorIDs))
+	resp.Series = make([]ChartSeries, len(q.SensorIDs))
+	metaRows, err := db.Query(fmt.Sprintf("SELECT id, name, unit FROM sensors WHERE id IN (%s)", inClause), args...)
+	if err != nil {
+		return resp, err
+	}
+	found := 0
+	for metaRows.Next() {
+		var id int
+		var name, unit string
+		if err := metaRows.Scan(&id, &name, &unit); err != nil {
+			metaRows.Close()
+			return resp, err
+		}
+		for i, want := range q.SensorIDs {
+			if want == id {
+				index[id] = i
+				resp.Series[i] = ChartSeries{SensorID: id, Name: name, Unit: unit}
+				buckets[i] = make([]chartBucket, nBuckets)
+				found++
+			}
+		}
+	}
+	metaRows.Close()
+	if err := metaRows.Err(); err != nil {
+		return resp, err
+	}
+	if found != len(q.SensorIDs) {
+		return resp, errUnknownChartSensor
+	}
+
+	startArg := fmt.Sprintf("$%d", len(args)+1)
+	endArg := fmt.Sprintf("$%d", len(args)+2)
+	args = append(args, q.Start.Format(utils.LayoutDB), q.End.Format(utils.LayoutDB))
+
+	var query string
+	if useRollup {
+		query = fmt.Sprintf(`SELECT sensor_id, avg_val, min_val, max_val, sample_count, bucket
+			FROM sensor_data_hourly
+			WHERE sensor_id IN (%s) AND bucket BETWEEN %s AND %s`, inClause, startArg, endArg)
+	} else {
+		query = fmt.Sprintf(`SELECT sensor_id, value, value, value, 1, create_dt
+			FROM sensor_data
+			WHERE sensor_id IN (%s) AND create_dt BETWEEN %s AND %s`, inClause, startArg, endArg)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return resp, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			sensorID    int
+			avg, lo, hi float64
+			samples     int64
+			ts          time.Time
+		)
+		if err := rows.Scan(&sensorID, &avg, &lo, &hi, &samples, &ts); err != nil {
+			return resp, err
+		}
+		si, ok := index[sensorID]
+		if !ok {
+			continue
+		}
+		bi := int(ts.UTC().Sub(q.Start) / bucketWidth)
+		if bi < 0 || bi >= nBuckets {
+			continue
+		}
+		buckets[si][bi].add(avg, lo, hi, samples)
+	}
+	if err := rows.Err(); err != nil {
+		return resp, err
+	}
+
+	for si := range resp.Series {
+		s := &resp.Series[si]
+		s.Values = make([]*float64, nBuckets)
+		s.Min = make([]*float64, nBuckets)
+		s.Max = make([]*float64, nBuckets)
+		for bi, b := range buckets[si] {
+			if b.count == 0 {
+				continue
+			}
+			avg := roundChartValue(b.sum / float64(b.count))
+			lo, hi := roundChartValue(b.min), roundChartValue(b.max)
+			s.Values[bi], s.Min[bi], s.Max[bi] = &avg, &lo, &hi
+		}
+	}
+
+	logger.Log.WithFields(logrus.Fields{
+		"sensors": len(q.SensorIDs),
+		"buckets": nBuckets,
+		"source":  resp.Source,
+	}).Debug("Chart series query completed")
+
+	return resp, nil
+}
+
+// chartBucketWidth divides [start, end] into roughly points buckets. The
+// width never drops below one minute for raw data (finer than any poll
+// interval) or one hour for rollup data (finer than the source).
+func chartBucketWidth(start, end time.Time, points int, rollup bool) time.Duration {
+	floor := time.Minute
+	if rollup {
+		floor = time.Hour
+	}
+	width := end.Sub(start) / time.Duration(points)
+	if width < floor {
+		return floor
+	}
+	// Round up to a whole second so bucket timestamps stay readable.
+	if rem := width % time.Second; rem != 0 {
+		width += time.Second - rem
+	}
+	return width
+}
+
+// roundChartValue trims float noise from averaged readings.
+func roundChartValue(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
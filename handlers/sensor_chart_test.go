@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"isley/utils"
+)
+
+// ---------------------------------------------------------------------------
+// chartBucketWidth
+// ---------------------------------------------------------------------------
+
+func TestChartBucketWidth(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name   string
+		span   time.Duration
+		points int
+		rollup bool
+		want   time.Duration
+	}{
+		{"even split", 10 * time.Hour, 100, false, 6 * time.Minute},
+		{"raw floor", 30 * time.Minute, 500, false, time.Minute},
+		{"rollup floor", 48 * time.Hour, 500, true, time.Hour},
+		{"rounds up to whole second", time.Hour + time.Second, 7, false, 515 * time.Second},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.want, chartBucketWidth(start, start.Add(tc.span), tc.points, tc.rollup))
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// chartBucket
+// ---------------------------------------------------------------------------
+
+func TestChartBucket_WeightsRollupSamples(t *testing.T) {
+	t.Parallel()
+
+	var b chartBucket
+	b.add(20, 18, 22, 3) // rollup row: 3 samples averaging 20
+	b.add(24, 24, 24, 1) // raw reading
+
+	assert.EqualValues(t, 4, b.count)
+	assert.InDelta(t, 21.0, b.sum/float64(b.count), 0.0001)
+	assert.Equal(t, 18.0, b.min)
+	assert.Equal(t, 24.0, b.max)
+}
+
+// ---------------------------------------------------------------------------
+// convertChartSeries
+// ---------------------------------------------------------------------------
+
+func TestConvertChartSeries_ConvertsBucketsAndKeepsGaps(t *testing.T) {
+	t.Parallel()
+
+	v, lo, hi := 77.0, 68.0, 86.0
+	rh := 55.0
+	resp := ChartResponse{Series: []ChartSeries{
+		{Unit: "°F", Values: []*float64{&v, nil}, Min: []*float64{&lo, nil}, Max: []*float64{&hi, nil}},
+		{Unit: "%", Values: []*float64{&rh}, Min: []*float64{&rh}, Max: []*float64{&rh}},
+	}}
+
+	convertChartSeries(&resp, utils.UnitPrefs{System: utils.UnitSystemMetric})
+
+	temp := resp.Series[0]
+	assert.Equal(t, "°C", temp.Unit)
+	assert.InDelta(t, 25.0, *temp.Values[0], 0.001)
+	assert.InDelta(t, 20.0, *temp.Min[0], 0.001)
+	assert.InDelta(t, 30.0, *temp.Max[0], 0.001)
+	assert.Nil(t, temp.Values[1], "empty buckets stay nil")
+	assert.Equal(t, 77.0, v, "conversion must not write through shared pointers")
+
+	assert.Equal(t, "%", resp.Series[1].Unit)
+	assert.Equal(t, 55.0, *resp.Series[1].Values[0])
+}
+
+func TestGroupedSensorsInUnits_LeavesCacheUntouched(t *testing.T) {
+	t.Parallel()
+
+	cached := map[string]map[string][]map[string]interface{}{
+		"Z": {"D": {{"id": 1, "value": 77.0, "unit": "°F"}}},
+	}
+	out := GroupedSensorsInUnits(cached, utils.UnitPrefs{System: utils.UnitSystemMetric})
+
+	assert.InDelta(t, 25.0, out["Z"]["D"][0]["value"], 0.001)
+	assert.Equal(t, "°C", out["Z"]["D"][0]["unit"])
+	assert.Equal(t, 77.0, cached["Z"]["D"][0]["value"])
+	assert.Equal(t, "°F", cached["Z"]["D"][0]["unit"])
+}
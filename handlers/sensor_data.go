@@ -38,19 +38,28 @@ func ChartHandler(c *gin.Context) {
 	cacheKey := generateCacheKey(sensor, timeMinutes, startDate, endDate)
 	cache := SensorCacheServiceFromContext(c)
 
+	db := DBFromContext(c)
+	prefs := UnitPrefsFromContext(c)
+	var unit string
+	if !prefs.IsNative() {
+		// The cache holds readings as stored; conversion happens per
+		// request so a settings change applies immediately.
+		if err := db.QueryRow("SELECT unit FROM sensors WHERE id = $1", sensor).Scan(&unit); err != nil && err != sql.ErrNoRows {
+			sensorLogger.WithError(err).Warn("Failed to look up sensor unit")
+		}
+	}
+
 	cachedData, cachedAt, found := cache.DataGet(cacheKey)
 	pollInterval := ConfigStoreFromContext(c).PollingInterval()
 	if found && time.Since(cachedAt) < time.Duration(pollInterval/10)*time.Second {
 		sensorLogger.Info("Serving data from cache")
-		c.JSON(http.StatusOK, cachedData)
+		c.JSON(http.StatusOK, sensorHistoryInUnits(cachedData, unit, prefs))
 		return
 	}
 
 	var sensorData []types.SensorData
 	var err error
 
-	db := DBFromContext(c)
-
 	if startDate != "" && endDate != "" {
 		sensorData, err = querySensorHistoryByDateRange(db, sensor, startDate, endDate)
 	} else if timeMinutes != "" {
@@ -70,7 +79,7 @@ func ChartHandler(c *gin.Context) {
 	cache.DataPut(cacheKey, sensorData)
 
 	sensorLogger.Info("Returning queried sensor data")
-	c.JSON(http.StatusOK, sensorData)
+	c.JSON(http.StatusOK, sensorHistoryInUnits(sensorData, unit, prefs))
 }
 
 func querySensorHistoryByTime(db *sql.DB, sensor string, timeMinutes string) ([]types.SensorData, error) {
@@ -0,0 +1,342 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"isley/logger"
+	"isley/model/types"
+	"isley/utils"
+)
+
+// maxQuarantineListLimit caps how many quarantined readings one request returns.
+const maxQuarantineListLimit = 500
+
+// sensorFilterInput is the body of POST /sensor-filters and
+// PUT /sensor-filters/:id.
+type sensorFilterInput struct {
+	SensorType         string   `json:"sensor_type"`
+	MinValue           *float64 `json:"min_value"`
+	MaxValue           *float64 `json:"max_value"`
+	MaxDeltaPerMin     *float64 `json:"max_delta_per_min"`
+	MedianWindow       int      `json:"median_window"`
+	MedianMaxDeviation *float64 `json:"median_max_deviation"`
+	Quarantine         bool     `json:"quarantine"`
+}
+
+// ListSensorFilters returns every filter ordered by sensor type.
+func ListSensorFilters(db *sql.DB) ([]types.SensorFilter, error) {
+	rows, err := db.Query(`
+		SELECT id, sensor_type, min_value, max_value, max_delta_per_min, median_window,
+		       median_max_deviation, quarantine, rejected_count, last_rejected_dt
+		FROM sensor_filters ORDER BY sensor_type`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	filters := []types.SensorFilter{}
+	for rows.Next() {
+		var f types.SensorFilter
+		var minValue, maxValue, maxDelta, medianDev sql.NullFloat64
+		var lastRejected sql.NullTime
+		if err := rows.Scan(&f.ID, &f.SensorType, &minValue, &maxValue, &maxDelta, &f.MedianWindow,
+			&medianDev, &f.Quarantine, &f.RejectedCount, &lastRejected); err != nil {
+			return nil, err
+		}
+		f.MinValue, f.MaxValue = nullFloatPtr(minValue), nullFloatPtr(maxValue)
+		f.MaxDeltaPerMin, f.MedianMaxDeviation = nullFloatPtr(maxDelta), nullFloatPtr(medianDev)
+		if lastRejected.Valid {
+			f.LastRejectedDT = &lastRejected.Time
+		}
+		filters = append(filters, f)
+	}
+	return filters, rows.Err()
+}
+
+// GetSensorFiltersHandler lists the filters along with the sensor types in
+// use, which the UI offers when adding a filter.
+func GetSensorFiltersHandler(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "GetSensorFiltersHandler")
+	db := DBFromContext(c)
+
+	filters, err := ListSensorFilters(db)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to list sensor filters")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	rows, err := db.Query("SELECT DISTINCT type FROM sensors WHERE source <> $1 ORDER BY type", types.VirtualSource)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to list sensor types")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer rows.Close()
+	sensorTypes := []string{}
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			fieldLogger.WithError(err).Error("Failed to scan sensor type")
+			apiInternalError(c, "api_database_error")
+			return
+		}
+		sensorTypes = append(sensorTypes, t)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"filters": filters, "sensor_types": sensorTypes})
+}
+
+// CreateSensorFilterHandler adds a filter for a sensor type. Each type has at
+// most one filter.
+func CreateSensorFilterHandler(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "CreateSensorFilterHandler")
+
+	var input sensorFilterInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apiBadRequest(c, "api_invalid_payload")
+		return
+	}
+	if errKey := validateSensorFilter(&input); errKey != "" {
+		apiBadRequest(c, errKey)
+		return
+	}
+
+	db := DBFromContext(c)
+	if taken, err := sensorFilterTypeTaken(db, input.SensorType, 0); err != nil {
+		fieldLogger.WithError(err).Error("Failed to check sensor filter type")
+		apiInternalError(c, "api_database_error")
+		return
+	} else if taken {
+		apiBadRequest(c, "api_sensor_filter_exists")
+		return
+	}
+
+	var id int
+	err := db.QueryRow(`
+		INSERT INTO sensor_filters (sensor_type, min_value, max_value, max_delta_per_min, median_window, median_max_deviation, quarantine)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		input.SensorType, input.MinValue, input.MaxValue, input.MaxDeltaPerMin, input.MedianWindow,
+		input.MedianMaxDeviation, input.Quarantine,
+	).Scan(&id)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to create sensor filter")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": T(c, "api_sensor_filter_saved"), "id": id})
+}
+
+// UpdateSensorFilterHandler replaces a filter's rules. The rejection count
+// is kept.
+func UpdateSensorFilterHandler(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "UpdateSensorFilterHandler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		apiBadRequest(c, "api_invalid_request")
+		return
+	}
+
+	var input sensorFilterInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apiBadRequest(c, "api_invalid_payload")
+		return
+	}
+	if errKey := validateSensorFilter(&input); errKey != "" {
+		apiBadRequest(c, errKey)
+		return
+	}
+
+	db := DBFromContext(c)
+	if taken, err := sensorFilterTypeTaken(db, input.SensorType, id); err != nil {
+		fieldLogger.WithError(err).Error("Failed to check sensor filter type")
+		apiInternalError(c, "api_database_error")
+		return
+	} else if taken {
+		apiBadRequest(c, "api_sensor_filter_exists")
+		return
+	}
+
+	res, err := db.Exec(`
+		UPDATE sensor_filters SET sensor_type = $1, min_value = $2, max_value = $3, max_delta_per_min = $4,
+			median_window = $5, median_max_deviation = $6, quarantine = $7, update_dt = CURRENT_TIMESTAMP
+		WHERE id = $8`,
+		input.SensorType, input.MinValue, input.MaxValue, input.MaxDeltaPerMin, input.MedianWindow,
+		input.MedianMaxDeviation, input.Quarantine, id,
+	)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to update sensor filter")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		apiNotFound(c, "api_sensor_filter_not_found")
+		return
+	}
+
+	apiOK(c, "api_sensor_filter_saved")
+}
+
+// DeleteSensorFilterHandler removes a filter. Quarantined readings are kept.
+func DeleteSensorFilterHandler(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "DeleteSensorFilterHandler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		apiBadRequest(c, "api_invalid_request")
+		return
+	}
+
+	res, err := DBFromContext(c).Exec("DELETE FROM sensor_filters WHERE id = $1", id)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to delete sensor filter")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		apiNotFound(c, "api_sensor_filter_not_found")
+		return
+	}
+
+	apiOK(c, "api_sensor_filter_deleted")
+}
+
+// GetQuarantineHandler lists quarantined readings, newest first. Optional
+// query parameters: sensor_id narrows to one sensor, limit caps the count
+// (default 100).
+func GetQuarantineHandler(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "GetQuarantineHandler")
+
+	limit := 100
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			apiBadRequest(c, "api_invalid_request")
+			return
+		}
+		limit = min(n, maxQuarantineListLimit)
+	}
+
+	query := `
+		SELECT q.id, q.sensor_id, s.name, q.value, q.reason, q.create_dt
+		FROM sensor_data_quarantine q
+		JOIN sensors s ON s.id = q.sensor_id`
+	args := []interface{}{}
+	if v := c.Query("sensor_id"); v != "" {
+		sensorID, err := strconv.Atoi(v)
+		if err != nil {
+			apiBadRequest(c, "api_invalid_request")
+			return
+		}
+		query += " WHERE q.sensor_id = $1"
+		args = append(args, sensorID)
+	}
+	query += " ORDER BY q.create_dt DESC, q.id DESC LIMIT " + strconv.Itoa(limit)
+
+	rows, err := DBFromContext(c).Query(query, args...)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to list quarantined readings")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer rows.Close()
+
+	readings := []types.QuarantinedReading{}
+	for rows.Next() {
+		var r types.QuarantinedReading
+		if err := rows.Scan(&r.ID, &r.SensorID, &r.SensorName, &r.Value, &r.Reason, &r.CreateDT); err != nil {
+			fieldLogger.WithError(err).Error("Failed to scan quarantined reading")
+			apiInternalError(c, "api_database_error")
+			return
+		}
+		readings = append(readings, r)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"readings": readings})
+}
+
+// ClearQuarantineHandler deletes quarantined readings, for one sensor when
+// sensor_id is given and otherwise all of them.
+func ClearQuarantineHandler(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "ClearQuarantineHandler")
+
+	query := "DELETE FROM sensor_data_quarantine"
+	args := []interface{}{}
+	if v := c.Query("sensor_id"); v != "" {
+		sensorID, err := strconv.Atoi(v)
+		if err != nil {
+			apiBadRequest(c, "api_invalid_request")
+			return
+		}
+		query += " WHERE sensor_id = $1"
+		args = append(args, sensorID)
+	}
+
+	if _, err := DBFromContext(c).Exec(query, args...); err != nil {
+		fieldLogger.WithError(err).Error("Failed to clear quarantined readings")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	apiOK(c, "api_quarantine_cleared")
+}
+
+// validateSensorFilter trims input in place and returns a translation key
+// (or a plain validation message) when it is unusable.
+func validateSensorFilter(input *sensorFilterInput) string {
+	input.SensorType = strings.TrimSpace(input.SensorType)
+	if input.SensorType == "" {
+		return "api_sensor_filter_type_required"
+	}
+	if err := utils.ValidateStringLength("sensor_type", input.SensorType, utils.MaxTypeLength); err != nil {
+		return err.Error()
+	}
+
+	for _, v := range []*float64{input.MinValue, input.MaxValue, input.MaxDeltaPerMin, input.MedianMaxDeviation} {
+		if v != nil && (math.IsNaN(*v) || math.IsInf(*v, 0)) {
+			return "api_sensor_filter_invalid"
+		}
+	}
+	if input.MinValue != nil && input.MaxValue != nil && *input.MinValue > *input.MaxValue {
+		return "api_sensor_filter_range_invalid"
+	}
+	if input.MaxDeltaPerMin != nil && *input.MaxDeltaPerMin <= 0 {
+		return "api_sensor_filter_invalid"
+	}
+
+	// The window and the deviation only mean something together.
+	if input.MedianWindow == 0 {
+		input.MedianMaxDeviation = nil
+	} else if input.MedianWindow < 3 || input.MedianWindow > types.MaxFilterMedianWindow ||
+		input.MedianMaxDeviation == nil || *input.MedianMaxDeviation <= 0 {
+		return "api_sensor_filter_median_invalid"
+	}
+
+	if input.MinValue == nil && input.MaxValue == nil && input.MaxDeltaPerMin == nil && input.MedianWindow == 0 {
+		return "api_sensor_filter_empty"
+	}
+	return ""
+}
+
+// sensorFilterTypeTaken reports whether another filter (not selfID) already
+// covers sensorType.
+func sensorFilterTypeTaken(db *sql.DB, sensorType string, selfID int) (bool, error) {
+	var id int
+	err := db.QueryRow("SELECT id FROM sensor_filters WHERE sensor_type = $1", sensorType).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return id != selfID, nil
+}
@@ -19,6 +19,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -973,6 +974,8 @@ func DeleteSensor(c *gin.Context) {
 		apiInternalError(c, "api_failed_to_delete_sensor")
 		return
 	}
+	// Streams gated on this sensor just lost their light condition.
+	ConfigStoreFromContext(c).SetStreams(GetStreams(db))
 
 	apiOK(c, "api_sensor_deleted")
 }
@@ -1000,6 +1003,10 @@ func DeleteSensorByID(db *sql.DB, id string) error {
 		{"sensor data", "DELETE FROM sensor_data WHERE sensor_id = $1"},
 		{"sensor hourly rollups", "DELETE FROM sensor_data_hourly WHERE sensor_id = $1"},
 		{"sensor rolling averages", "DELETE FROM rolling_averages WHERE sensor_id = $1"},
+		{"virtual sensor definition", "DELETE FROM virtual_sensors WHERE sensor_id = $1"},
+		{"stream light condition", "UPDATE streams SET light_sensor_id = NULL, light_threshold = NULL WHERE light_sensor_id = $1"},
+		{"harvest dry-room temperature link", "UPDATE harvests SET dry_temp_sensor_id = NULL WHERE dry_temp_sensor_id = $1"},
+		{"harvest dry-room humidity link", "UPDATE harvests SET dry_humidity_sensor_id = NULL WHERE dry_humidity_sensor_id = $1"},
 		{"sensor", "DELETE FROM sensors WHERE id = $1"},
 	}
 	for _, s := range stmts {
@@ -1146,9 +1153,21 @@ func IngestSensorData(c *gin.Context) {
 		return
 	}
 
-	// Insert the sensor reading
+	// Insert the sensor reading with the sensor's calibration applied,
+	// unless its type's filter rejects it. A filtered reading is still a
+	// successful request so clients don't retry it.
+	value := model.ActiveCalibration(db, sensorID).Apply(payload.Value)
+	if reason := model.FilterReading(db, sensorID, value, time.Now()); reason != "" {
+		c.JSON(http.StatusOK, gin.H{
+			"message":   T(c, "api_sensor_reading_filtered"),
+			"sensor_id": sensorID,
+			"filtered":  true,
+			"reason":    reason,
+		})
+		return
+	}
 	_, err = db.Exec("INSERT INTO sensor_data (sensor_id, value) VALUES ($1, $2)",
-		sensorID, payload.Value)
+		sensorID, value)
 	if err != nil {
 		fieldLogger.WithError(err).Error("Error inserting sensor data")
 		apiInternalError(c, "api_failed_to_save_sensor_data")
@@ -78,6 +78,36 @@ func SaveSettings(c *gin.Context) {
 		apiBadRequest(c, "api_invalid_settings_payload")
 		return
 	}
+	units := utils.UnitPrefs(settings.Units)
+	if !units.Valid() {
+		fieldLogger.WithField("units", units).Warn("Rejected unit preference")
+		apiBadRequest(c, "api_invalid_unit_preference")
+		return
+	}
+	if settings.DailyPhotoTime == "" {
+		settings.DailyPhotoTime = DefaultDailyPhotoTime
+	}
+	if err := utils.ValidateClock("daily_photo_time", settings.DailyPhotoTime); err != nil {
+		apiBadRequest(c, "api_invalid_daily_photo_time")
+		return
+	}
+	if settings.TaskReminderTime == "" {
+		settings.TaskReminderTime = DefaultTaskReminderTime
+	}
+	if err := utils.ValidateClock("task_reminder_time", settings.TaskReminderTime); err != nil {
+		apiBadRequest(c, "api_invalid_task_reminder_time")
+		return
+	}
+	settings.NotifyWebhookURL = strings.TrimSpace(settings.NotifyWebhookURL)
+	if err := validateHTTPURL(settings.NotifyWebhookURL); err != nil {
+		apiBadRequest(c, "api_invalid_webhook_url")
+		return
+	}
+	settings.PublicURL = strings.TrimRight(strings.TrimSpace(settings.PublicURL), "/")
+	if err := validateHTTPURL(settings.PublicURL); err != nil {
+		apiBadRequest(c, "api_invalid_public_url")
+		return
+	}
 
 	db := DBFromContext(c)
 	store := ConfigStoreFromContext(c)
@@ -109,6 +139,7 @@ func SaveSettings(c *gin.Context) {
 		{"cannadb.enabled", settings.Cannadb.Enabled, store.SetCannadbEnabled},
 		{"guest_mode", settings.GuestMode, store.SetGuestMode},
 		{"stream_grab_enabled", settings.StreamGrabEnabled, store.SetStreamGrabEnabled},
+		{"daily_photo_enabled", settings.DailyPhotoEnabled, store.SetDailyPhotoEnabled},
 		{"api_ingest_enabled", !settings.DisableAPIIngest, store.SetAPIIngestEnabled},
 	}
 
@@ -140,6 +171,38 @@ func SaveSettings(c *gin.Context) {
 		store.SetStreamGrabInterval(v)
 	}
 
+	err = UpdateSetting(db, store, "daily_photo_time", settings.DailyPhotoTime)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to save daily photo time setting")
+		apiInternalError(c, "api_failed_to_save_settings")
+		return
+	}
+	store.SetDailyPhotoTime(settings.DailyPhotoTime)
+
+	err = UpdateSetting(db, store, "task_reminder_time", settings.TaskReminderTime)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to save task reminder time setting")
+		apiInternalError(c, "api_failed_to_save_settings")
+		return
+	}
+	store.SetTaskReminderTime(settings.TaskReminderTime)
+
+	err = UpdateSetting(db, store, "notify_webhook_url", settings.NotifyWebhookURL)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to save notification webhook setting")
+		apiInternalError(c, "api_failed_to_save_settings")
+		return
+	}
+	store.SetNotifyWebhookURL(settings.NotifyWebhookURL)
+
+	err = UpdateSetting(db, store, "public_url", settings.PublicURL)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to save public URL setting")
+		apiInternalError(c, "api_failed_to_save_settings")
+		return
+	}
+	store.SetPublicURL(settings.PublicURL)
+
 	// API keys are managed through the dedicated /settings/api-keys endpoints,
 	// not this form.
 
@@ -197,6 +260,20 @@ func SaveSettings(c *gin.Context) {
 		captureTimezoneMetadata(db, store, settings.Timezone)
 	}
 
+	// Display units — always persist (empty = as reported / follow system)
+	for _, kv := range [][2]string{
+		{"units.system", units.System},
+		{"units.pressure", units.Pressure},
+		{"units.weight", units.Weight},
+	} {
+		if err = UpdateSetting(db, store, kv[0], kv[1]); err != nil {
+			fieldLogger.WithError(err).Error("Failed to save unit setting")
+			apiInternalError(c, "api_failed_to_save_settings")
+			return
+		}
+	}
+	store.SetUnitPrefs(units)
+
 	//Load Settings
 	LoadSettings(db, store)
 
@@ -260,7 +337,7 @@ func UpdateSetting(db *sql.DB, store *config.Store, name string, value string) e
 func GetSettings(db *sql.DB) types.SettingsData {
 	fieldLogger := logger.Log.WithField("func", "GetSettings")
 
-	settingsData := types.SettingsData{}
+	settingsData := types.SettingsData{DailyPhotoTime: DefaultDailyPhotoTime, TaskReminderTime: DefaultTaskReminderTime}
 
 	rows, err := db.Query("SELECT * FROM settings")
 	if err != nil {
@@ -303,6 +380,18 @@ func GetSettings(db *sql.DB) types.SettingsData {
 				iValue = DefaultStreamGrabIntervalMs
 			}
 			settingsData.StreamGrabInterval = iValue
+		case "daily_photo_enabled":
+			settingsData.DailyPhotoEnabled = value == "1"
+		case "daily_photo_time":
+			settingsData.DailyPhotoTime = value
+		case "task_reminder_time":
+			settingsData.TaskReminderTime = value
+		case "notify_webhook_url":
+			settingsData.NotifyWebhookURL = value
+		case "public_url":
+			settingsData.PublicURL = value
+		case calendarTokenSetting:
+			settingsData.CalendarTokenSet = value != ""
 		case "api_ingest_enabled":
 			settingsData.APIIngestEnabled = value == "1"
 		case "sensor_retention_days":
@@ -313,6 +402,12 @@ func GetSettings(db *sql.DB) types.SettingsData {
 			settingsData.MaxBackupSizeMB, _ = strconv.Atoi(value)
 		case "timezone":
 			settingsData.Timezone = value
+		case "units.system":
+			settingsData.Units.System = value
+		case "units.pressure":
+			settingsData.Units.Pressure = value
+		case "units.weight":
+			settingsData.Units.Weight = value
 		default:
 			fieldLogger.WithField("name", name).Debug("Unrecognised setting skipped")
 		}
@@ -825,6 +920,19 @@ func DeleteZoneHandler(c *gin.Context) {
 		DeleteStreamByID(db, fmt.Sprintf("%d", streamId))
 	}
 
+	// Grow runs outlive their zone as history
+	if _, err = db.Exec("UPDATE grow_runs SET zone_id = NULL WHERE zone_id = $1", id); err != nil {
+		fieldLogger.WithError(err).Error("Failed to unlink grow runs")
+	}
+
+	// Tasks for the zone go with it
+	if _, err = db.Exec("DELETE FROM task_completions WHERE task_id IN (SELECT id FROM tasks WHERE zone_id = $1)", id); err != nil {
+		fieldLogger.WithError(err).Error("Failed to delete zone task completions")
+	}
+	if _, err = db.Exec("DELETE FROM tasks WHERE zone_id = $1", id); err != nil {
+		fieldLogger.WithError(err).Error("Failed to delete zone tasks")
+	}
+
 	// Delete zone from database
 	_, err = db.Exec("DELETE FROM zones WHERE id = $1", id)
 	if err != nil {
@@ -926,7 +1034,14 @@ func DeleteActivityHandler(c *gin.Context) {
 		fieldLogger.WithField("activityID", id).Warn("Activity is locked but deletion will be allowed")
 	}
 
-	// Delete any plant_activities associated with this activity
+	// Delete any plant_activities associated with this activity, and the
+	// nutrients recorded against them
+	_, err = tx.Exec("DELETE FROM activity_nutrients WHERE plant_activity_id IN (SELECT id FROM plant_activity WHERE activity_id = $1)", id)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to delete plant_activity nutrients")
+		apiInternalError(c, "api_failed_to_delete_plant_activities")
+		return
+	}
 	_, err = tx.Exec("DELETE FROM plant_activity WHERE activity_id = $1", id)
 	if err != nil {
 		fieldLogger.WithError(err).Error("Failed to delete plant_activities")
@@ -941,6 +1056,14 @@ func DeleteActivityHandler(c *gin.Context) {
 		return
 	}
 
+	// Tasks that logged this activity are kept and log nothing
+	_, err = tx.Exec("UPDATE tasks SET activity_id = NULL WHERE activity_id = $1", id)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to unlink tasks")
+		apiInternalError(c, "api_failed_to_delete_activity")
+		return
+	}
+
 	// Delete activity from database
 	_, err = tx.Exec("DELETE FROM activity WHERE id = $1", id)
 	if err != nil {
@@ -1243,6 +1366,33 @@ func LoadSettings(db *sql.DB, store *config.Store) {
 		}
 	}
 
+	strDailyPhotoEnabled, err := GetSetting(db, "daily_photo_enabled")
+	if err == nil {
+		if iDailyPhotoEnabled, err := strconv.Atoi(strDailyPhotoEnabled); err == nil {
+			store.SetDailyPhotoEnabled(iDailyPhotoEnabled)
+		}
+	}
+
+	strDailyPhotoTime, err := GetSetting(db, "daily_photo_time")
+	if err == nil && utils.ValidateClock("daily_photo_time", strDailyPhotoTime) == nil && strDailyPhotoTime != "" {
+		store.SetDailyPhotoTime(strDailyPhotoTime)
+	}
+
+	strTaskReminderTime, err := GetSetting(db, "task_reminder_time")
+	if err == nil && utils.ValidateClock("task_reminder_time", strTaskReminderTime) == nil && strTaskReminderTime != "" {
+		store.SetTaskReminderTime(strTaskReminderTime)
+	}
+
+	strNotifyWebhookURL, err := GetSetting(db, "notify_webhook_url")
+	if err == nil {
+		store.SetNotifyWebhookURL(strNotifyWebhookURL)
+	}
+
+	strPublicURL, err := GetSetting(db, "public_url")
+	if err == nil {
+		store.SetPublicURL(strPublicURL)
+	}
+
 	strAPIKey, err := GetSetting(db, "api_key")
 	if err == nil {
 		fieldLogger.Debug("API key setting loaded")
@@ -1278,6 +1428,14 @@ func LoadSettings(db *sql.DB, store *config.Store) {
 		store.SetTimezone(strTimezone)
 	}
 
+	var units utils.UnitPrefs
+	units.System, _ = GetSetting(db, "units.system")
+	units.Pressure, _ = GetSetting(db, "units.pressure")
+	units.Weight, _ = GetSetting(db, "units.weight")
+	if units.Valid() {
+		store.SetUnitPrefs(units)
+	}
+
 	strCannadbEnabled, err := GetSetting(db, "cannadb.enabled")
 	if err == nil {
 		if iCannadbEnabled, err := strconv.Atoi(strCannadbEnabled); err == nil {
@@ -1313,7 +1471,7 @@ func LoadSettings(db *sql.DB, store *config.Store) {
 	store.SetMetrics(GetMetrics(db))
 	store.SetStatuses(GetStatuses(db))
 	store.SetZones(GetZones(db))
-	store.SetStrains(GetStrains(db))
+	store.SetStrains(GetStrains(db, StrainSortName))
 	store.SetBreeders(GetBreeders(db))
 	store.SetStreams(GetStreams(db))
 }
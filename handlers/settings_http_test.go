@@ -31,15 +31,20 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strconv"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"isley/config"
+	"isley/handlers"
 	"isley/tests/testutil"
+	"isley/utils"
 )
 
 // pngFixture returns the bytes of a 1×1 PNG, used for the upload-logo
@@ -151,6 +156,231 @@ func TestSettingsHTTP_SaveSettings_RejectsMalformedJSON(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 }
 
+func TestSettingsHTTP_SaveSettings_PersistsUnitPreference(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	store := config.NewStore()
+	server := testutil.NewTestServer(t, db, testutil.WithConfigStore(store))
+
+	const apiKey = "save-units-key"
+	testutil.SeedAPIKey(t, db, apiKey)
+
+	c := server.NewClient(t)
+	body := testutil.JSONBody(t, map[string]interface{}{
+		"polling_interval": "60",
+		"log_level":        "info",
+		"units":            map[string]string{"system": "imperial", "pressure": "hPa", "weight": "lb"},
+	})
+	resp, err := c.Do(testutil.APIReq(t, http.MethodPost, c.BaseURL+"/settings", apiKey, body, "application/json"))
+	require.NoError(t, err)
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, utils.UnitPrefs{System: "imperial", Pressure: "hPa", Weight: "lb"}, store.UnitPrefs())
+	units := handlers.GetSettings(db).Units
+	assert.Equal(t, "imperial", units.System)
+	assert.Equal(t, "hPa", units.Pressure)
+	assert.Equal(t, "lb", units.Weight)
+}
+
+func TestSettingsHTTP_SaveSettings_RejectsUnknownUnits(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db)
+
+	const apiKey = "save-bad-units-key"
+	testutil.SeedAPIKey(t, db, apiKey)
+
+	c := server.NewClient(t)
+	body := testutil.JSONBody(t, map[string]interface{}{
+		"polling_interval": "60",
+		"units":            map[string]string{"system": "imperial", "pressure": "psi"},
+	})
+	resp, err := c.Do(testutil.APIReq(t, http.MethodPost, c.BaseURL+"/settings", apiKey, body, "application/json"))
+	require.NoError(t, err)
+	defer testutil.DrainAndClose(resp)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var n int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM settings WHERE name LIKE 'units.%'`).Scan(&n))
+	assert.Zero(t, n, "a rejected preference must not be persisted")
+}
+
+func TestSettingsHTTP_SaveSettings_DailyPhoto(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	store := config.NewStore()
+	server := testutil.NewTestServer(t, db, testutil.WithConfigStore(store))
+
+	const apiKey = "save-daily-photo-key"
+	testutil.SeedAPIKey(t, db, apiKey)
+
+	c := server.NewClient(t)
+	save := func(enabled bool, at string) int {
+		body := testutil.JSONBody(t, map[string]interface{}{
+			"polling_interval":    "60",
+			"log_level":           "info",
+			"daily_photo_enabled": enabled,
+			"daily_photo_time":    at,
+		})
+		resp, err := c.Do(testutil.APIReq(t, http.MethodPost, c.BaseURL+"/settings", apiKey, body, "application/json"))
+		require.NoError(t, err)
+		testutil.DrainAndClose(resp)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusBadRequest, save(true, "noon"))
+	assert.Zero(t, store.DailyPhotoEnabled(), "a rejected time must not enable the job")
+
+	require.Equal(t, http.StatusOK, save(true, "07:45"))
+	assert.Equal(t, 1, store.DailyPhotoEnabled())
+	assert.Equal(t, "07:45", store.DailyPhotoTime())
+	settings := handlers.GetSettings(db)
+	assert.True(t, settings.DailyPhotoEnabled)
+	assert.Equal(t, "07:45", settings.DailyPhotoTime)
+
+	require.Equal(t, http.StatusOK, save(false, ""))
+	assert.Zero(t, store.DailyPhotoEnabled())
+	assert.Equal(t, handlers.DefaultDailyPhotoTime, store.DailyPhotoTime(), "a blank time falls back to the default")
+}
+
+func TestSettingsHTTP_SaveSettings_Notifications(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	store := config.NewStore()
+	server := testutil.NewTestServer(t, db, testutil.WithConfigStore(store))
+
+	const apiKey = "save-notify-key"
+	testutil.SeedAPIKey(t, db, apiKey)
+
+	c := server.NewClient(t)
+	save := func(webhook, at string) int {
+		body := testutil.JSONBody(t, map[string]interface{}{
+			"polling_interval":   "60",
+			"log_level":          "info",
+			"notify_webhook_url": webhook,
+			"task_reminder_time": at,
+		})
+		resp, err := c.Do(testutil.APIReq(t, http.MethodPost, c.BaseURL+"/settings", apiKey, body, "application/json"))
+		require.NoError(t, err)
+		testutil.DrainAndClose(resp)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusBadRequest, save("ftp://hooks.example", ""))
+	assert.Equal(t, http.StatusBadRequest, save("https://hooks.example/x", "8am"))
+	assert.Empty(t, store.NotifyWebhookURL(), "a rejected URL must not be stored")
+
+	require.Equal(t, http.StatusOK, save(" https://hooks.example/x ", "06:30"))
+	assert.Equal(t, "https://hooks.example/x", store.NotifyWebhookURL())
+	assert.Equal(t, "06:30", store.TaskReminderTime())
+	settings := handlers.GetSettings(db)
+	assert.Equal(t, "https://hooks.example/x", settings.NotifyWebhookURL)
+	assert.Equal(t, "06:30", settings.TaskReminderTime)
+
+	require.Equal(t, http.StatusOK, save("", ""))
+	assert.Empty(t, store.NotifyWebhookURL(), "clearing the URL turns notifications off")
+	assert.Equal(t, handlers.DefaultTaskReminderTime, store.TaskReminderTime())
+}
+
+func TestSettingsHTTP_SaveSettings_PublicURL(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	store := config.NewStore()
+	server := testutil.NewTestServer(t, db, testutil.WithConfigStore(store))
+
+	const apiKey = "save-public-url-key"
+	testutil.SeedAPIKey(t, db, apiKey)
+
+	c := server.NewClient(t)
+	save := func(publicURL string) int {
+		body := testutil.JSONBody(t, map[string]interface{}{
+			"polling_interval": "60",
+			"log_level":        "info",
+			"public_url":       publicURL,
+		})
+		resp, err := c.Do(testutil.APIReq(t, http.MethodPost, c.BaseURL+"/settings", apiKey, body, "application/json"))
+		require.NoError(t, err)
+		testutil.DrainAndClose(resp)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusBadRequest, save("isley.example.com"))
+	assert.Empty(t, store.PublicURL(), "a rejected URL must not be stored")
+
+	require.Equal(t, http.StatusOK, save(" https://isley.example.com/ "))
+	assert.Equal(t, "https://isley.example.com", store.PublicURL(), "a trailing slash is trimmed")
+	assert.Equal(t, "https://isley.example.com", handlers.GetSettings(db).PublicURL)
+
+	require.Equal(t, http.StatusOK, save(""))
+	assert.Empty(t, store.PublicURL())
+}
+
+// webhookRecorder is an httptest webhook that keeps what it was sent.
+type webhookRecorder struct {
+	mu       sync.Mutex
+	payloads []map[string]string
+	status   int
+}
+
+func (w *webhookRecorder) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	var payload map[string]string
+	_ = json.NewDecoder(r.Body).Decode(&payload)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.payloads = append(w.payloads, payload)
+	if w.status != 0 {
+		rw.WriteHeader(w.status)
+	}
+}
+
+func (w *webhookRecorder) sent() []map[string]string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]map[string]string(nil), w.payloads...)
+}
+
+func TestSettingsHTTP_TestNotification(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db)
+
+	const apiKey = "test-notify-key"
+	testutil.SeedAPIKey(t, db, apiKey)
+
+	hook := &webhookRecorder{}
+	webhook := httptest.NewServer(hook)
+	t.Cleanup(webhook.Close)
+
+	c := server.NewClient(t)
+	send := func(url string) int {
+		body := testutil.JSONBody(t, map[string]string{"url": url})
+		resp, err := c.Do(testutil.APIReq(t, http.MethodPost, c.BaseURL+"/settings/notifications/test", apiKey, body, "application/json"))
+		require.NoError(t, err)
+		testutil.DrainAndClose(resp)
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusOK, send(webhook.URL))
+	sent := hook.sent()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "Isley", sent[0]["title"])
+	assert.NotEmpty(t, sent[0]["message"])
+
+	hook.mu.Lock()
+	hook.status = http.StatusForbidden
+	hook.mu.Unlock()
+	assert.Equal(t, http.StatusBadRequest, send(webhook.URL), "a rejected test is reported")
+	assert.Equal(t, http.StatusBadRequest, send(""))
+	assert.Equal(t, http.StatusBadRequest, send("not a url"))
+}
+
 // ---------------------------------------------------------------------------
 // AddZoneHandler / UpdateZoneHandler / DeleteZoneHandler
 // ---------------------------------------------------------------------------
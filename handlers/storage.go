@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"isley/logger"
+	"isley/storage"
+	"isley/utils"
+)
+
+// contextKeyStorage is the key under which the engine middleware stores
+// the per-engine storage.Store for uploaded files.
+const contextKeyStorage = "storage"
+
+// StorageFromContext returns the storage.Store uploads are kept in.
+// Mirrors UploadDirFromContext: without an injected store it falls back
+// to local disk under the upload directory.
+func StorageFromContext(c *gin.Context) storage.Store {
+	if v, ok := c.Get(contextKeyStorage); ok {
+		if s, ok := v.(storage.Store); ok && s != nil {
+			return s
+		}
+	}
+	return storage.NewLocal(UploadDirFromContext(c))
+}
+
+// SetStorageOnContext is the small helper the engine middleware uses to
+// bind a Store to a request context.
+func SetStorageOnContext(c *gin.Context, s storage.Store) { c.Set(contextKeyStorage, s) }
+
+// uploadKey returns the storage key of a path recorded in the database.
+func uploadKey(c *gin.Context, p string) string {
+	return storage.KeyForPath(UploadDirFromContext(c), p)
+}
+
+// ensureLocalUpload makes sure the upload recorded at p exists on local
+// disk, fetching it from object storage when needed, for tools that
+// work on files.
+func ensureLocalUpload(c *gin.Context, p string) error {
+	return storage.Materialize(c.Request.Context(), StorageFromContext(c), uploadKey(c, p), p)
+}
+
+// imageVariantKey returns the storage key of the given variant of the
+// image stored under key.
+func imageVariantKey(key, variant string) string {
+	return filepath.ToSlash(utils.ImageVariantPath(key, variant))
+}
+
+// putImageVariants stores every variant of the image in data beside key.
+func putImageVariants(ctx context.Context, store storage.Store, key string, data []byte) error {
+	variants, err := utils.EncodeImageVariants(data)
+	if err != nil {
+		return err
+	}
+	for variant, encoded := range variants {
+		if err := storage.PutBytes(ctx, store, imageVariantKey(key, variant), encoded); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecorateImage fetches the photo being decorated to local disk when
+// uploads live in object storage, then hands over to
+// utils.DecorateImageHandler, which works on files under ./uploads.
+func DecorateImage(c *gin.Context) {
+	store := StorageFromContext(c)
+	if _, local := storage.LocalPath(store, ""); !local {
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+		if err != nil {
+			apiBadRequest(c, "api_invalid_request")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		var req struct {
+			ImagePath string `json:"imagePath"`
+		}
+		if json.Unmarshal(body, &req) == nil && req.ImagePath != "" {
+			if key, err := storage.CleanKey(storage.KeyForPath("uploads", req.ImagePath)); err == nil {
+				if err := storage.Materialize(c.Request.Context(), store, key, filepath.Join("uploads", filepath.FromSlash(key))); err != nil {
+					logger.Log.WithError(err).WithField("key", key).Warn("Failed to fetch image for decoration")
+				}
+			}
+		}
+	}
+	utils.DecorateImageHandler(c)
+}
+
+// ServeStoredUpload serves /uploads/*filepath from store. Files the
+// store doesn't have fall back to localDir: decorated images, exported
+// strips, timelapses and logos are produced on local disk even when
+// photos live in object storage.
+func ServeStoredUpload(store storage.Store, localDir string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, err := storage.CleanKey(c.Param("filepath")[1:])
+		if err != nil {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		rc, err := store.Get(c.Request.Context(), key)
+		if err != nil {
+			if !storage.IsNotExist(err) {
+				logger.Log.WithError(err).WithField("key", key).Error("Failed to read upload from storage")
+				c.Status(http.StatusBadGateway)
+				return
+			}
+			local := filepath.Join(localDir, filepath.FromSlash(key))
+			if info, statErr := os.Stat(local); statErr == nil && !info.IsDir() {
+				c.File(local)
+				return
+			}
+			c.Status(http.StatusNotFound)
+			return
+		}
+		defer rc.Close()
+
+		contentType := mime.TypeByExtension(path.Ext(key))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		c.Header("Cache-Control", "public, max-age=86400")
+		if obj, err := store.Stat(c.Request.Context(), key); err == nil && obj.Size >= 0 {
+			c.Header("Content-Length", strconv.FormatInt(obj.Size, 10))
+		}
+		c.Status(http.StatusOK)
+		c.Header("Content-Type", contentType)
+		if _, err := io.Copy(c.Writer, rc); err != nil {
+			logger.Log.WithError(err).WithField("key", key).Warn("Failed to stream upload")
+		}
+	}
+}
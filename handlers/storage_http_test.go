@@ -0,0 +1,263 @@
+package handlers_test
+
+// HTTP-layer tests for uploads kept in a non-local storage.Store: the
+// upload, variant, delete and /uploads serving paths, and image backups
+// listing the store instead of the upload directory.
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"isley/handlers"
+	"isley/storage"
+	"isley/tests/testutil"
+)
+
+// memStore is an in-memory storage.Store standing in for object
+// storage; it keeps nothing on local disk.
+type memStore struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func newMemStore() *memStore { return &memStore{files: map[string][]byte{}} }
+
+func (m *memStore) Name() string { return "memory" }
+
+func (m *memStore) Put(_ context.Context, key string, r io.Reader, _ int64) error {
+	key, err := storage.CleanKey(key)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[key] = data
+	return nil
+}
+
+func (m *memStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.files[key]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *memStore) Stat(_ context.Context, key string) (storage.Object, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.files[key]
+	if !ok {
+		return storage.Object{}, fs.ErrNotExist
+	}
+	return storage.Object{Key: key, Size: int64(len(data))}, nil
+}
+
+func (m *memStore) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.files, key)
+	return nil
+}
+
+func (m *memStore) List(_ context.Context, prefix string, fn func(storage.Object) error) error {
+	m.mu.Lock()
+	var objects []storage.Object
+	for key, data := range m.files {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, storage.Object{Key: key, Size: int64(len(data))})
+		}
+	}
+	m.mu.Unlock()
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	for _, obj := range objects {
+		if err := fn(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memStore) keys() []string {
+	var keys []string
+	_ = m.List(context.Background(), "", func(obj storage.Object) error {
+		keys = append(keys, obj.Key)
+		return nil
+	})
+	return keys
+}
+
+// ---------------------------------------------------------------------------
+// Plant images
+// ---------------------------------------------------------------------------
+
+func TestStorageHTTP_PlantImageLifecycle(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	store := newMemStore()
+	uploadDir := t.TempDir()
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode(),
+		testutil.WithUploadDir(uploadDir), testutil.WithStorage(store))
+	apiKey := testutil.SeedAPIKey(t, db, "storage-lifecycle-key")
+	plantID := seedImagePlant(t, db)
+	c := server.NewClient(t)
+
+	imageID := uploadPhoto(t, c, plantID, apiKey, exifPhoto(t, 1600, 800, 1, ""), "2026-04-25")
+
+	var imagePath string
+	require.NoError(t, db.QueryRow(`SELECT image_path FROM plant_images WHERE id = $1`, imageID).Scan(&imagePath))
+	key := storage.KeyForPath(uploadDir, imagePath)
+	base := strings.TrimSuffix(filepath.Base(key), filepath.Ext(key))
+	assert.Equal(t, []string{
+		key,
+		"plants/variants/" + base + "_medium.jpg",
+		"plants/variants/" + base + "_thumb.jpg",
+	}, store.keys(), "the original and its variants go to the store")
+	assert.NoDirExists(t, filepath.Join(uploadDir, "plants"), "nothing is written to local disk")
+
+	// The original is served from the store.
+	resp := c.Get("/uploads/" + key)
+	body, _ := io.ReadAll(resp.Body)
+	testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "image/jpeg", resp.Header.Get("Content-Type"))
+	assert.Equal(t, store.files[key], body)
+
+	// A missing variant is rebuilt from the original and stored again.
+	require.NoError(t, store.Delete(context.Background(), "plants/variants/"+base+"_thumb.jpg"))
+	resp = c.Get("/plant/images/" + strconv.Itoa(imageID) + "/thumb")
+	body, _ = io.ReadAll(resp.Body)
+	testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "image/jpeg", resp.Header.Get("Content-Type"))
+	assert.Equal(t, store.files["plants/variants/"+base+"_thumb.jpg"], body)
+	assert.NotEmpty(t, body)
+
+	resp = c.APIDelete(t, "/plant/images/"+strconv.Itoa(imageID)+"/delete", apiKey)
+	testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, store.keys(), "deleting an image removes it and its variants from the store")
+
+	resp = c.Get("/uploads/" + key)
+	testutil.DrainAndClose(resp)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestStorageHTTP_UploadsRejectTraversal(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithStorage(newMemStore()))
+	c := server.NewClient(t)
+
+	resp := c.Get("/uploads/plants/%2e%2e/%2e%2e/go.mod")
+	testutil.DrainAndClose(resp)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+// ---------------------------------------------------------------------------
+// Backups
+// ---------------------------------------------------------------------------
+
+func TestBuildBackupArchive_ListsStorage(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	store := newMemStore()
+	for _, key := range []string{
+		"plants/plant_1_image_0_1.jpg",
+		"plants/variants/plant_1_image_0_1_thumb.jpg",
+		"streams/archive/stream_1/20260425T100000Z.jpg",
+	} {
+		require.NoError(t, storage.PutBytes(context.Background(), store, key, []byte("x")))
+	}
+
+	archive, manifest, err := handlers.BuildBackupArchive(db, handlers.BuildArchiveOptions{
+		IncludeImages:     true,
+		SkipImageVariants: true,
+		Storage:           store,
+		UploadsDir:        t.TempDir(),
+		Now:               time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, manifest.Files)
+
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+	var names []string
+	for _, zf := range zr.File {
+		names = append(names, zf.Name)
+	}
+	assert.Equal(t, []string{"backup.json", "uploads/plants/plant_1_image_0_1.jpg"}, names)
+}
+
+func TestBuildBackupArchive_AddsLocalOnlyUploads(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	store := newMemStore()
+	require.NoError(t, storage.PutBytes(context.Background(), store, "plants/plant_1_image_0_1.jpg", []byte("stored")))
+
+	// Timelapse videos and logos are written to local disk even when
+	// uploads live in object storage.
+	uploads := t.TempDir()
+	for rel, body := range map[string]string{
+		"plants/plant_1_image_0_1.jpg": "stale local copy",
+		"timelapses/timelapse_1_1.mp4": "video",
+		"logos/logo.png":               "logo",
+	} {
+		p := filepath.Join(uploads, filepath.FromSlash(rel))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(body), 0o644))
+	}
+
+	archive, manifest, err := handlers.BuildBackupArchive(db, handlers.BuildArchiveOptions{
+		IncludeImages: true,
+		Storage:       store,
+		UploadsDir:    uploads,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, manifest.Files)
+
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+	got := map[string]string{}
+	for _, zf := range zr.File {
+		if zf.Name == "backup.json" {
+			continue
+		}
+		rc, err := zf.Open()
+		require.NoError(t, err)
+		body, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		got[zf.Name] = string(body)
+	}
+	assert.Equal(t, map[string]string{
+		"uploads/plants/plant_1_image_0_1.jpg": "stored",
+		"uploads/timelapses/timelapse_1_1.mp4": "video",
+		"uploads/logos/logo.png":               "logo",
+	}, got, "a key in both is taken from the store")
+}
@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"database/sql"
+	"fmt"
 	"isley/logger"
 	model "isley/model"
 	"isley/model/types"
 	"isley/utils"
+	"math"
 	"net/http"
 	"strconv"
 
@@ -87,14 +89,35 @@ func UpdateBreederHandler(c *gin.Context) {
 
 	// Update breeder in database
 	db := DBFromContext(c)
+	tx, err := db.Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_failed_to_update_breeder")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+
+	// Keep a local rename of an imported breeder through later CannaDB syncs.
+	if breederID, err := strconv.Atoi(id); err == nil {
+		if err := lockEditedCannadbBreeder(tx, breederID, breeder.Name); err != nil {
+			fieldLogger.WithError(err).Error("Failed to lock edited CannaDB fields")
+			apiInternalError(c, "api_failed_to_update_breeder")
+			return
+		}
+	}
 
 	// Update breeder in database
-	_, err := db.Exec("UPDATE breeder SET name = $1 WHERE id = $2", breeder.Name, id)
+	_, err = tx.Exec("UPDATE breeder SET name = $1 WHERE id = $2", breeder.Name, id)
 	if err != nil {
 		fieldLogger.WithError(err).Error("Failed to update breeder")
 		apiInternalError(c, "api_failed_to_update_breeder")
 		return
 	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit breeder update")
+		apiInternalError(c, "api_failed_to_update_breeder")
+		return
+	}
 
 	//Reload Config
 	ConfigStoreFromContext(c).SetBreeders(GetBreeders(db))
@@ -136,27 +159,72 @@ func DeleteBreederHandler(c *gin.Context) {
 		DeletePlantById(db, strconv.Itoa(plantId))
 	}
 
-	// Delete any strains associated with this breeder
-	_, err = db.Exec("DELETE FROM strain WHERE breeder_id = $1", id)
+	tx, err := db.Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_failed_to_delete_breeder")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+
+	// Delete any strains associated with this breeder, with their records
+	strainIDs, err := breederStrainIDs(tx, id)
 	if err != nil {
-		fieldLogger.WithError(err).Error("Failed to delete strains")
+		fieldLogger.WithError(err).Error("Failed to read strains")
 		apiInternalError(c, "api_failed_to_delete_strains")
+		return
+	}
+	for _, strainID := range strainIDs {
+		if _, err := deleteStrainRecords(tx, strainID); err != nil {
+			fieldLogger.WithError(err).Error("Failed to delete strains")
+			apiInternalError(c, "api_failed_to_delete_strains")
+			return
+		}
 	}
 
 	// Delete breeder from database
-	_, err = db.Exec("DELETE FROM breeder WHERE id = $1", id)
-	if err != nil {
+	if _, err = tx.Exec("DELETE FROM breeder_cannadb_locks WHERE breeder_id = $1", id); err != nil {
+		fieldLogger.WithError(err).Error("Failed to delete breeder locks")
+		apiInternalError(c, "api_failed_to_delete_breeder")
+		return
+	}
+	if _, err = tx.Exec("DELETE FROM breeder WHERE id = $1", id); err != nil {
 		fieldLogger.WithError(err).Error("Failed to delete breeder")
 		apiInternalError(c, "api_failed_to_delete_breeder")
 		return
 	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit delete transaction")
+		apiInternalError(c, "api_failed_to_delete_breeder")
+		return
+	}
 
 	//Reload Config
-	ConfigStoreFromContext(c).SetBreeders(GetBreeders(db))
+	store := ConfigStoreFromContext(c)
+	store.SetBreeders(GetBreeders(db))
+	store.SetStrains(GetStrains(db, StrainSortName))
 
 	apiOK(c, "api_breeder_deleted")
 }
 
+// breederStrainIDs returns the ids of the breeder's strains.
+func breederStrainIDs(tx *sql.Tx, breederID string) ([]int, error) {
+	rows, err := tx.Query("SELECT id FROM strain WHERE breeder_id = $1", breederID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 // ---------------------------------------------------------------------------
 // Strain helpers & handlers
 // ---------------------------------------------------------------------------
@@ -180,10 +248,41 @@ func validateStrainFields(name, description, shortDesc, newBreeder, strainURL st
 	return utils.ValidateWebURL("url", strainURL)
 }
 
-func GetStrains(db *sql.DB) []types.Strain {
+// Orders GetStrains and the stock lists can sort strains in.
+const (
+	StrainSortName   = "name"
+	StrainSortRating = "rating"
+)
+
+// strainRatingJoin joins each strain's review stats as r.avg_rating and
+// r.review_count, both NULL when it has no reviews.
+const strainRatingJoin = `LEFT JOIN (SELECT strain_id, AVG(rating) AS avg_rating, COUNT(*) AS review_count
+		FROM strain_reviews GROUP BY strain_id) r ON r.strain_id = s.id`
+
+// strainOrder is the ORDER BY clause for sortBy. Rating puts the best
+// rated first, then the most reviewed, with unreviewed strains last.
+func strainOrder(sortBy string) string {
+	if sortBy == StrainSortRating {
+		return "r.avg_rating IS NULL, r.avg_rating DESC, r.review_count DESC, s.name ASC"
+	}
+	return "s.name ASC"
+}
+
+// scanStrainRating fills in a strain's review stats.
+func scanStrainRating(strain *types.Strain, avg sql.NullFloat64, count sql.NullInt64) {
+	if avg.Valid {
+		rating := math.Round(avg.Float64*100) / 100
+		strain.AverageRating = &rating
+	}
+	strain.ReviewCount = int(count.Int64)
+}
+
+// GetStrains lists every strain with its breeder and review stats, in
+// sortBy order (StrainSortName or StrainSortRating).
+func GetStrains(db *sql.DB, sortBy string) []types.Strain {
 	fieldLogger := logger.Log.WithField("func", "GetStrains")
 
-	rows, err := db.Query("SELECT s.id, s.name, b.id as breeder_id, b.name as breeder, s.indica, s.sativa, s.autoflower, s.description, coalesce(s.short_desc, ''), s.seed_count FROM strain s left outer join breeder b on s.breeder_id = b.id ORDER BY s.name ASC")
+	rows, err := db.Query("SELECT s.id, s.name, b.id as breeder_id, b.name as breeder, s.indica, s.sativa, s.autoflower, s.description, coalesce(s.short_desc, ''), s.seed_count, r.avg_rating, r.review_count FROM strain s left outer join breeder b on s.breeder_id = b.id " + strainRatingJoin + " ORDER BY " + strainOrder(sortBy))
 	if err != nil {
 		fieldLogger.WithError(err).Error("Failed to query strains")
 		return nil
@@ -192,11 +291,14 @@ func GetStrains(db *sql.DB) []types.Strain {
 	var strains []types.Strain
 	for rows.Next() {
 		var strain types.Strain
-		err = rows.Scan(&strain.ID, &strain.Name, &strain.BreederID, &strain.Breeder, &strain.Indica, &strain.Sativa, &strain.Autoflower, &strain.Description, &strain.ShortDescription, &strain.SeedCount)
+		var avg sql.NullFloat64
+		var count sql.NullInt64
+		err = rows.Scan(&strain.ID, &strain.Name, &strain.BreederID, &strain.Breeder, &strain.Indica, &strain.Sativa, &strain.Autoflower, &strain.Description, &strain.ShortDescription, &strain.SeedCount, &avg, &count)
 		if err != nil {
 			fieldLogger.WithError(err).Error("Failed to scan strain")
 			return nil
 		}
+		scanStrainRating(&strain, avg, count)
 		strains = append(strains, strain)
 	}
 
@@ -316,7 +418,7 @@ func AddStrainHandler(c *gin.Context) {
 		return
 	}
 
-	store.SetStrains(GetStrains(db))
+	store.SetStrains(GetStrains(db, StrainSortName))
 
 	// Respond with success
 	c.JSON(http.StatusCreated, gin.H{"id": id, "message": T(c, "api_strain_added")})
@@ -431,6 +533,24 @@ func UpdateStrainHandler(c *gin.Context) {
 		breederID = *req.BreederID
 	}
 
+	tx, err := db.Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_failed_to_update_strain")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+
+	// Keep local edits of an imported strain through later CannaDB syncs.
+	if err := lockEditedCannadbStrain(tx, id, cannadbSyncedStrain{
+		BreederID: breederID, Name: req.Name, Indica: req.Indica, Sativa: req.Sativa, Autoflower: req.Autoflower,
+		Description: req.Description, ShortDescription: req.ShortDescription, CycleTime: req.CycleTime, Url: req.Url,
+	}); err != nil {
+		fieldLogger.WithError(err).Error("Failed to lock edited CannaDB fields")
+		apiInternalError(c, "api_failed_to_update_strain")
+		return
+	}
+
 	// Update the strain in the database
 	updateStmt := `
         UPDATE strain
@@ -444,18 +564,23 @@ func UpdateStrainHandler(c *gin.Context) {
 	} else {
 		autoflowerInt = 0
 	}
-	_, err = db.Exec(updateStmt, req.Name, breederID, req.Indica, req.Sativa,
+	_, err = tx.Exec(updateStmt, req.Name, breederID, req.Indica, req.Sativa,
 		autoflowerInt, req.Description, req.SeedCount, req.CycleTime, req.Url, req.ShortDescription, id)
 	if err != nil {
 		fieldLogger.WithError(err).Error("Failed to update strain")
 		apiInternalError(c, "api_failed_to_update_strain")
 		return
 	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit strain update")
+		apiInternalError(c, "api_failed_to_update_strain")
+		return
+	}
 
 	// Refresh the in-memory strain cache so the UI reflects the update without
 	// a restart (AddStrainHandler already does this; Update regressed in the
 	// v0.2.0 ConfigStore refactor).
-	ConfigStoreFromContext(c).SetStrains(GetStrains(db))
+	ConfigStoreFromContext(c).SetStrains(GetStrains(db, StrainSortName))
 
 	apiOK(c, "api_strain_updated")
 }
@@ -472,26 +597,77 @@ func DeleteStrainHandler(c *gin.Context) {
 	// Open the database
 	db := DBFromContext(c)
 
-	result, err := db.Exec(`DELETE FROM strain WHERE id = $1`, id)
+	tx, err := db.Begin()
 	if err != nil {
-		fieldLogger.WithError(err).Error("Failed to delete strain")
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
 		apiInternalError(c, "api_failed_to_delete_strain")
 		return
 	}
+	defer tx.Rollback() // no-op after Commit
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
+	found, err := deleteStrainRecords(tx, id)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to delete strain")
+		apiInternalError(c, "api_failed_to_delete_strain")
+		return
+	}
+	if !found {
 		fieldLogger.Error("Strain not found")
 		apiNotFound(c, "api_strain_not_found")
 		return
 	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit delete transaction")
+		apiInternalError(c, "api_failed_to_delete_strain")
+		return
+	}
 
 	apiOK(c, "api_strain_deleted")
 }
 
+// deleteStrainRecords deletes a strain and the records that hang off it
+// in tx, and reports whether the strain existed. The production SQLite
+// database runs without foreign_keys, so the cascades and SET NULLs the
+// schema declares are done here explicitly.
+func deleteStrainRecords(tx *sql.Tx, strainID int) (bool, error) {
+	fieldLogger := logger.Log.WithField("func", "deleteStrainRecords")
+
+	// Child records first, then the strain itself.
+	deletes := []struct {
+		table string
+		query string
+	}{
+		{"plant", "UPDATE plant SET germination_id = NULL WHERE germination_id IN (SELECT g.id FROM germination_attempts g JOIN seed_lots l ON l.id = g.seed_lot_id WHERE l.strain_id = $1)"},
+		{"plant", "UPDATE plant SET seed_lot_id = NULL WHERE seed_lot_id IN (SELECT id FROM seed_lots WHERE strain_id = $1)"},
+		{"germination_attempts", "DELETE FROM germination_attempts WHERE seed_lot_id IN (SELECT id FROM seed_lots WHERE strain_id = $1)"},
+		{"seed_lots", "DELETE FROM seed_lots WHERE strain_id = $1"},
+		{"strain_lineage", "UPDATE strain_lineage SET parent_strain_id = NULL WHERE parent_strain_id = $1"},
+		{"strain_lineage", "DELETE FROM strain_lineage WHERE strain_id = $1"},
+		{"pollinations", "UPDATE pollinations SET female_strain_id = NULL WHERE female_strain_id = $1"},
+		{"pollinations", "UPDATE pollinations SET male_strain_id = NULL WHERE male_strain_id = $1"},
+		{"pollinations", "UPDATE pollinations SET seed_strain_id = NULL WHERE seed_strain_id = $1"},
+		{"strain_review_tags", "DELETE FROM strain_review_tags WHERE review_id IN (SELECT id FROM strain_reviews WHERE strain_id = $1)"},
+		{"strain_reviews", "DELETE FROM strain_reviews WHERE strain_id = $1"},
+		{"strain_cannadb_locks", "DELETE FROM strain_cannadb_locks WHERE strain_id = $1"},
+	}
+	for _, d := range deletes {
+		if _, err := tx.Exec(d.query, strainID); err != nil {
+			fieldLogger.WithError(err).WithField("table", d.table).Error("Failed to delete records")
+			return false, fmt.Errorf("failed to delete from %s: %w", d.table, err)
+		}
+	}
+
+	result, err := tx.Exec("DELETE FROM strain WHERE id = $1", strainID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
 func InStockStrainsHandler(c *gin.Context) {
 	db := DBFromContext(c)
-	strains, err := getStrainsBySeedCount(db, true)
+	strains, err := getStrainsBySeedCount(db, true, c.Query("sort"))
 	if err != nil {
 		apiInternalError(c, "api_failed_to_fetch_strains")
 		return
@@ -500,14 +676,14 @@ func InStockStrainsHandler(c *gin.Context) {
 }
 func OutOfStockStrainsHandler(c *gin.Context) {
 	db := DBFromContext(c)
-	strains, err := getStrainsBySeedCount(db, false)
+	strains, err := getStrainsBySeedCount(db, false, c.Query("sort"))
 	if err != nil {
 		apiInternalError(c, "api_failed_to_fetch_strains")
 		return
 	}
 	c.JSON(http.StatusOK, strains)
 }
-func getStrainsBySeedCount(db *sql.DB, inStock bool) ([]types.Strain, error) {
+func getStrainsBySeedCount(db *sql.DB, inStock bool, sortBy string) ([]types.Strain, error) {
 	fieldLogger := logger.Log.WithField("func", "getStrainsBySeedCount")
 	op := ">"
 	if !inStock {
@@ -523,14 +699,16 @@ func getStrainsBySeedCount(db *sql.DB, inStock bool) ([]types.Strain, error) {
 		SELECT s.id, s.name, b.name AS breeder, b.id as breeder_id,
 		       s.indica, s.sativa, s.autoflower, s.seed_count, s.description,
 		       coalesce(s.short_desc, ''), coalesce(s.cycle_time, 0), coalesce(s.url, ''),
-		       ` + aggExpr + `
+		       ` + aggExpr + `, r.avg_rating, r.review_count
 		FROM strain s
 		JOIN breeder b ON s.breeder_id = b.id
 		LEFT JOIN strain_lineage sl ON sl.strain_id = s.id
+		` + strainRatingJoin + `
 		WHERE s.seed_count ` + op + ` 0
 		GROUP BY s.id, s.name, b.name, b.id, s.indica, s.sativa, s.autoflower,
-		         s.seed_count, s.description, s.short_desc, s.cycle_time, s.url
-		ORDER BY s.name ASC
+		         s.seed_count, s.description, s.short_desc, s.cycle_time, s.url,
+		         r.avg_rating, r.review_count
+		ORDER BY ` + strainOrder(sortBy) + `
 	`
 
 	rows, err := db.Query(query)
@@ -543,13 +721,16 @@ func getStrainsBySeedCount(db *sql.DB, inStock bool) ([]types.Strain, error) {
 	var strains []types.Strain
 	for rows.Next() {
 		var strain types.Strain
+		var avg sql.NullFloat64
+		var count sql.NullInt64
 		if err := rows.Scan(&strain.ID, &strain.Name, &strain.Breeder, &strain.BreederID,
 			&strain.Indica, &strain.Sativa, &strain.Autoflower, &strain.SeedCount,
 			&strain.Description, &strain.ShortDescription, &strain.CycleTime, &strain.Url,
-			&strain.Lineage); err != nil {
+			&strain.Lineage, &avg, &count); err != nil {
 			fieldLogger.WithError(err).Error("Failed to scan strain")
 			return nil, err
 		}
+		scanStrainRating(&strain, avg, count)
 		strains = append(strains, strain)
 	}
 
@@ -572,34 +753,34 @@ func PlantsByStrainHandler(context *gin.Context) {
 
 	db := DBFromContext(context)
 
-	// Query plants with the given strain ID
-	rows, err := db.Query(`SELECT id, name FROM plant WHERE strain_id = $1 ORDER BY name ASC`, strainID)
+	plants, err := GetPlantsByStrain(db, strainID)
 	if err != nil {
 		fieldLogger.WithError(err).Error("Failed to query database")
 		apiInternalError(context, "api_failed_to_fetch_plants")
 		return
 	}
+
+	// Return the list of plants as JSON
+	fieldLogger.WithField("plantCount", len(plants)).Info("Plants fetched successfully")
+	context.JSON(http.StatusOK, plants)
+}
+
+// GetPlantsByStrain returns the id and name of every plant of a strain,
+// ordered by name.
+func GetPlantsByStrain(db *sql.DB, strainID int) ([]types.Plant, error) {
+	rows, err := db.Query(`SELECT id, name FROM plant WHERE strain_id = $1 ORDER BY name ASC`, strainID)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
-	// Parse query results
 	var plants []types.Plant
 	for rows.Next() {
 		var plant types.Plant
 		if err := rows.Scan(&plant.ID, &plant.Name); err != nil {
-			fieldLogger.WithError(err).Error("Failed to scan row")
-			apiInternalError(context, "api_failed_to_process_results")
-			return
+			return nil, err
 		}
 		plants = append(plants, plant)
 	}
-
-	if err := rows.Err(); err != nil {
-		fieldLogger.WithError(err).Error("Error iterating rows")
-		apiInternalError(context, "api_failed_to_process_results")
-		return
-	}
-
-	// Return the list of plants as JSON
-	fieldLogger.WithField("plantCount", len(plants)).Info("Plants fetched successfully")
-	context.JSON(http.StatusOK, plants)
+	return plants, rows.Err()
 }
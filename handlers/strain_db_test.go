@@ -55,7 +55,7 @@ func TestGetStrains_OrderedAlphabetically(t *testing.T) {
 	testutil.MustExec(t, db, `INSERT INTO strain (name, breeder_id, sativa, indica, autoflower, description, seed_count)
 	                 VALUES ('Mango', 1, 50, 50, 0, '', 0)`)
 
-	got := handlers.GetStrains(db)
+	got := handlers.GetStrains(db, handlers.StrainSortName)
 	require.Len(t, got, 3)
 	assert.Equal(t, "Alpha", got[0].Name, "GetStrains must order by name ASC")
 	assert.Equal(t, "Mango", got[1].Name)
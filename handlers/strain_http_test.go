@@ -7,11 +7,12 @@ package handlers_test
 //
 //   - auth gating across breeder + strain api endpoints
 //   - AddBreeder / UpdateBreeder validation (bad JSON, blank, overlength)
+//   - DeleteBreeder — clearing its strains and their records
 //   - AddStrain — bad JSON, missing breeder when BreederID is nil
 //   - UpdateStrain — invalid id, bad JSON, indica/sativa sum mismatch,
 //     missing breeder when BreederID is nil
 //   - GetStrainHandler — invalid id (non-numeric), not-found
-//   - DeleteStrainHandler — invalid id
+//   - DeleteStrainHandler — invalid id, clearing the strain's records
 //   - PlantsByStrainHandler (basic-route, session-only) — invalid id,
 //     happy path
 //
@@ -201,6 +202,40 @@ func TestStrainHTTP_UpdateBreeder_RejectsBlank(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 }
 
+func TestStrainHTTP_DeleteBreeder_ClearsItsStrains(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db)
+
+	const apiKey = "delbrd-records-key"
+	testutil.SeedAPIKey(t, db, apiKey)
+
+	breederID := testutil.SeedBreeder(t, db, "Doomed Breeder")
+	strainID := testutil.SeedStrain(t, db, breederID, "Doomed")
+	keptID := testutil.SeedStrain(t, db, testutil.SeedBreeder(t, db, "Other"), "Kept")
+	testutil.SeedPlant(t, db, "Doomed Plant", strainID, testutil.SeedZone(t, db, "Tent"))
+	testutil.MustExec(t, db, `INSERT INTO seed_lots (strain_id, pack_size, seeds_left) VALUES ($1, 5, 5)`, strainID)
+	testutil.MustExec(t, db, `INSERT INTO strain_reviews (strain_id, review_date, rating) VALUES ($1, '2026-06-01', 4)`, strainID)
+	testutil.MustExec(t, db, `INSERT INTO strain_cannadb_locks (strain_id, field) VALUES ($1, 'description'), ($2, 'description')`, strainID, keptID)
+	testutil.MustExec(t, db, `INSERT INTO breeder_cannadb_locks (breeder_id, field) VALUES ($1, 'name')`, breederID)
+
+	c := server.NewClient(t)
+	resp, err := c.Do(testutil.APIReq(t, http.MethodDelete, c.BaseURL+"/breeders/"+strconv.Itoa(breederID), apiKey, nil, ""))
+	require.NoError(t, err)
+	testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Zero(t, testutil.CountRows(t, db, `SELECT COUNT(*) FROM breeder WHERE id = $1`, breederID))
+	assert.Zero(t, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain WHERE breeder_id = $1`, breederID))
+	assert.Zero(t, testutil.CountRows(t, db, `SELECT COUNT(*) FROM plant WHERE strain_id = $1`, strainID))
+	assert.Zero(t, testutil.CountRows(t, db, `SELECT COUNT(*) FROM seed_lots`))
+	assert.Zero(t, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain_reviews`))
+	assert.Zero(t, testutil.CountRows(t, db, `SELECT COUNT(*) FROM breeder_cannadb_locks`))
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain_cannadb_locks WHERE strain_id = $1`, keptID),
+		"only the breeder's strains lose their locks")
+}
+
 // ---------------------------------------------------------------------------
 // AddStrainHandler — additional validation paths
 // ---------------------------------------------------------------------------
@@ -335,6 +370,57 @@ func TestStrainHTTP_DeleteStrain_RejectsNonNumericID(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 }
 
+func TestStrainHTTP_DeleteStrain_ClearsItsRecords(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db)
+
+	const apiKey = "delstr-records-key"
+	testutil.SeedAPIKey(t, db, apiKey)
+
+	breederID := testutil.SeedBreeder(t, db, "Breeder")
+	strainID := testutil.SeedStrain(t, db, breederID, "Doomed")
+	childID := testutil.SeedStrain(t, db, breederID, "Child")
+	zoneID := testutil.SeedZone(t, db, "Tent")
+	testutil.MustExec(t, db, `INSERT INTO strain_lineage (strain_id, parent_name, parent_strain_id) VALUES ($1, 'Doomed', $2)`, childID, strainID)
+	testutil.MustExec(t, db, `INSERT INTO strain_lineage (strain_id, parent_name) VALUES ($1, 'Landrace')`, strainID)
+	var lotID, germinationID int
+	require.NoError(t, db.QueryRow(`INSERT INTO seed_lots (strain_id, pack_size, seeds_left) VALUES ($1, 5, 3) RETURNING id`, strainID).Scan(&lotID))
+	require.NoError(t, db.QueryRow(`INSERT INTO germination_attempts (seed_lot_id, start_date, seeds) VALUES ($1, '2026-05-01', 2) RETURNING id`, lotID).Scan(&germinationID))
+	plantID := testutil.SeedPlant(t, db, "Sprout", childID, zoneID)
+	testutil.MustExec(t, db, `UPDATE plant SET seed_lot_id = $1, germination_id = $2 WHERE id = $3`, lotID, germinationID, plantID)
+	testutil.MustExec(t, db, `INSERT INTO pollinations (female_strain_id, male_strain_id, seed_strain_id, pollination_date)
+		VALUES ($1, $1, $1, '2026-04-01')`, strainID)
+	var reviewID int
+	require.NoError(t, db.QueryRow(`INSERT INTO strain_reviews (strain_id, review_date, rating) VALUES ($1, '2026-06-01', 4) RETURNING id`, strainID).Scan(&reviewID))
+	testutil.MustExec(t, db, `INSERT INTO strain_review_tags (review_id, kind, tag) VALUES ($1, 'flavor', 'citrus')`, reviewID)
+	testutil.MustExec(t, db, `INSERT INTO strain_cannadb_locks (strain_id, field) VALUES ($1, 'description')`, strainID)
+
+	c := server.NewClient(t)
+	resp, err := c.Do(testutil.APIReq(t, http.MethodDelete, c.BaseURL+"/strains/"+strconv.Itoa(strainID), apiKey, nil, ""))
+	require.NoError(t, err)
+	testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Zero(t, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain WHERE id = $1`, strainID))
+	assert.Zero(t, testutil.CountRows(t, db, `SELECT COUNT(*) FROM seed_lots`))
+	assert.Zero(t, testutil.CountRows(t, db, `SELECT COUNT(*) FROM germination_attempts`))
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM plant WHERE id = $1 AND seed_lot_id IS NULL AND germination_id IS NULL`, plantID))
+	assert.Zero(t, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain_lineage WHERE strain_id = $1`, strainID))
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain_lineage WHERE strain_id = $1 AND parent_strain_id IS NULL`, childID))
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM pollinations
+		WHERE female_strain_id IS NULL AND male_strain_id IS NULL AND seed_strain_id IS NULL`), "the cross is kept without the strain")
+	assert.Zero(t, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain_reviews`))
+	assert.Zero(t, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain_review_tags`))
+	assert.Zero(t, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain_cannadb_locks`))
+
+	resp, err = c.Do(testutil.APIReq(t, http.MethodDelete, c.BaseURL+"/strains/"+strconv.Itoa(strainID), apiKey, nil, ""))
+	require.NoError(t, err)
+	testutil.DrainAndClose(resp)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
 // ---------------------------------------------------------------------------
 // GetStrainHandler  (basic route — public)
 // ---------------------------------------------------------------------------
@@ -0,0 +1,851 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"isley/logger"
+	"isley/utils"
+)
+
+// A strain library is every strain with its breeder, lineage and smoke
+// reports, exported as JSON or CSV and imported back from either, so a
+// library can move between instances or be edited in a spreadsheet.
+
+const (
+	// MaxStrainImportSize bounds an uploaded strain library file (5 MB).
+	MaxStrainImportSize = 5 << 20
+	// MaxStrainImportRows bounds how many strains one import may carry.
+	MaxStrainImportRows = 5000
+	// strainLibraryVersion is the version of the JSON library format.
+	strainLibraryVersion = 1
+	// strainLibraryLineageSep separates parent names in the CSV lineage
+	// column.
+	strainLibraryLineageSep = ";"
+)
+
+// strainLibraryColumns are the CSV columns in export order. Columns are
+// matched by name on import; average_rating and review_count are there
+// for reference and ignored.
+var strainLibraryColumns = []string{
+	"name", "breeder", "indica", "sativa", "autoflower", "seed_count", "cycle_time",
+	"url", "short_desc", "description", "lineage", "average_rating", "review_count",
+}
+
+// utf8BOM is what spreadsheets put in front of a CSV file saved as UTF-8.
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// errStrainImportColumns is returned for a CSV file without name and
+// breeder columns.
+var errStrainImportColumns = errors.New("api_strain_import_missing_columns")
+
+// strainLibrary is the JSON form of a strain library. Breeders lists every
+// breeder, including those without strains.
+type strainLibrary struct {
+	Version    int                  `json:"version"`
+	ExportedAt string               `json:"exported_at"`
+	Breeders   []string             `json:"breeders"`
+	Strains    []strainLibraryEntry `json:"strains"`
+}
+
+// strainLibraryEntry is a strain in a library. Fields other than name and
+// breeder are pointers, and Lineage nil, when the file leaves them out, so
+// an import can tell a missing value from 0 and an update keeps what the
+// strain has. AverageRating is exported only.
+type strainLibraryEntry struct {
+	Name             string              `json:"name"`
+	Breeder          string              `json:"breeder"`
+	Indica           *int                `json:"indica"`
+	Sativa           *int                `json:"sativa"`
+	Autoflower       *bool               `json:"autoflower"`
+	SeedCount        *int                `json:"seed_count"`
+	CycleTime        *int                `json:"cycle_time"`
+	Url              *string             `json:"url"`
+	ShortDescription *string             `json:"short_desc"`
+	Description      *string             `json:"description"`
+	Lineage          []string            `json:"lineage"`
+	AverageRating    *float64            `json:"average_rating,omitempty"`
+	Reviews          []strainReviewInput `json:"reviews,omitempty"`
+}
+
+// record is the entry as a CSV row in strainLibraryColumns order.
+func (e strainLibraryEntry) record() []string {
+	intOrBlank := func(n *int) string {
+		if n == nil {
+			return ""
+		}
+		return strconv.Itoa(*n)
+	}
+	rating := ""
+	if e.AverageRating != nil {
+		rating = strconv.FormatFloat(*e.AverageRating, 'f', -1, 64)
+	}
+	autoflower := ""
+	if e.Autoflower != nil {
+		autoflower = strconv.FormatBool(*e.Autoflower)
+	}
+	return []string{
+		e.Name, e.Breeder, intOrBlank(e.Indica), intOrBlank(e.Sativa), autoflower,
+		intOrBlank(e.SeedCount), intOrBlank(e.CycleTime), valueOf(e.Url), valueOf(e.ShortDescription), valueOf(e.Description),
+		strings.Join(e.Lineage, strainLibraryLineageSep+" "), rating, strconv.Itoa(len(e.Reviews)),
+	}
+}
+
+// loadStrainLibrary reads every breeder and strain, strains ordered by
+// name and breeder, with their lineage and reviews.
+func loadStrainLibrary(db *sql.DB) (strainLibrary, error) {
+	lib := strainLibrary{
+		Version:    strainLibraryVersion,
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Breeders:   []string{},
+		Strains:    []strainLibraryEntry{},
+	}
+
+	rows, err := db.Query("SELECT name FROM breeder ORDER BY name")
+	if err != nil {
+		return lib, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return lib, err
+		}
+		lib.Breeders = append(lib.Breeders, name)
+	}
+	if err := rows.Err(); err != nil {
+		return lib, err
+	}
+
+	strains, err := db.Query(`SELECT s.id, s.name, COALESCE(b.name, ''), s.indica, s.sativa, s.autoflower, s.seed_count,
+		       COALESCE(s.cycle_time, 0), COALESCE(s.url, ''), COALESCE(s.short_desc, ''), COALESCE(s.description, ''), r.avg_rating
+		FROM strain s
+		LEFT JOIN breeder b ON b.id = s.breeder_id
+		` + strainRatingJoin + `
+		ORDER BY s.name, b.name, s.id`)
+	if err != nil {
+		return lib, err
+	}
+	defer strains.Close()
+	index := map[int]int{}
+	for strains.Next() {
+		var id, indica, sativa, seedCount, cycleTime int
+		var autoflower bool
+		var url, shortDesc, description string
+		var avg sql.NullFloat64
+		e := strainLibraryEntry{
+			Indica: &indica, Sativa: &sativa, Autoflower: &autoflower, SeedCount: &seedCount, CycleTime: &cycleTime,
+			Url: &url, ShortDescription: &shortDesc, Description: &description, Lineage: []string{}, Reviews: []strainReviewInput{},
+		}
+		if err := strains.Scan(&id, &e.Name, &e.Breeder, &indica, &sativa, &autoflower, &seedCount,
+			&cycleTime, &url, &shortDesc, &description, &avg); err != nil {
+			return lib, err
+		}
+		if avg.Valid {
+			rating := math.Round(avg.Float64*100) / 100
+			e.AverageRating = &rating
+		}
+		index[id] = len(lib.Strains)
+		lib.Strains = append(lib.Strains, e)
+	}
+	if err := strains.Err(); err != nil {
+		return lib, err
+	}
+
+	lineage, err := db.Query("SELECT strain_id, parent_name FROM strain_lineage ORDER BY id")
+	if err != nil {
+		return lib, err
+	}
+	defer lineage.Close()
+	for lineage.Next() {
+		var strainID int
+		var name string
+		if err := lineage.Scan(&strainID, &name); err != nil {
+			return lib, err
+		}
+		if i, ok := index[strainID]; ok {
+			lib.Strains[i].Lineage = append(lib.Strains[i].Lineage, name)
+		}
+	}
+	if err := lineage.Err(); err != nil {
+		return lib, err
+	}
+
+	reviews, err := loadReviews(db, "1 = 1")
+	if err != nil {
+		return lib, err
+	}
+	for _, r := range reviews {
+		if i, ok := index[r.StrainID]; ok {
+			lib.Strains[i].Reviews = append(lib.Strains[i].Reviews, strainReviewInput{
+				Date:       r.Date.Format(utils.LayoutDate),
+				Rating:     r.Rating,
+				Potency:    r.Potency,
+				Notes:      r.Notes,
+				FlavorTags: r.FlavorTags,
+				EffectTags: r.EffectTags,
+			})
+		}
+	}
+	return lib, nil
+}
+
+// ExportStrainLibrary downloads the strain library as JSON, or as CSV with
+// ?format=csv. The CSV has one row per strain and carries review stats
+// rather than the reviews themselves.
+// GET /strains/export?format=json|csv
+func ExportStrainLibrary(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "ExportStrainLibrary")
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		apiBadRequest(c, "api_invalid_input")
+		return
+	}
+	lib, err := loadStrainLibrary(DBFromContext(c))
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to load strain library")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	filename := "isley-strains-" + time.Now().Format(utils.LayoutDate) + "." + format
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	if format == "json" {
+		c.IndentedJSON(http.StatusOK, lib)
+	} else {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		w := csv.NewWriter(c.Writer)
+		if err := w.Write(strainLibraryColumns); err != nil {
+			fieldLogger.WithError(err).Error("Failed to write CSV header")
+			return
+		}
+		for _, e := range lib.Strains {
+			if err := w.Write(e.record()); err != nil {
+				fieldLogger.WithError(err).Error("Failed to write CSV row")
+				return
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			fieldLogger.WithError(err).Error("CSV writer flushed with error")
+		}
+	}
+	fieldLogger.WithFields(logrus.Fields{
+		"strains":  len(lib.Strains),
+		"filename": filename,
+	}).Info("Strain library exported")
+}
+
+// strainImportRow is a strain read from an import file. Row is its line in
+// a CSV file or its position in a JSON file, counted from 1.
+type strainImportRow struct {
+	Row    int
+	Entry  strainLibraryEntry
+	Errors []string
+}
+
+// parseStrainLibraryJSON reads a JSON library: an export, or a bare array
+// of strains.
+func parseStrainLibraryJSON(data []byte) ([]string, []strainImportRow, error) {
+	var lib strainLibrary
+	var err error
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '[' {
+		err = json.Unmarshal(data, &lib.Strains)
+	} else {
+		err = json.Unmarshal(data, &lib)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	rows := make([]strainImportRow, len(lib.Strains))
+	for i, e := range lib.Strains {
+		rows[i] = strainImportRow{Row: i + 1, Entry: e}
+	}
+	return lib.Breeders, rows, nil
+}
+
+// parseStrainLibraryCSV reads a CSV library. Columns are matched by their
+// header, so they may come in any order and only name and breeder are
+// required. Blank rows are skipped.
+func parseStrainLibraryCSV(data []byte) ([]strainImportRow, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+	cols := map[string]int{}
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := cols["name"]; !ok {
+		return nil, errStrainImportColumns
+	}
+	if _, ok := cols["breeder"]; !ok {
+		return nil, errStrainImportColumns
+	}
+
+	var rows []strainImportRow
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		line, _ := r.FieldPos(0)
+		get := func(col string) string {
+			if i, ok := cols[col]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		text := func(col string) *string {
+			if _, ok := cols[col]; !ok {
+				return nil
+			}
+			v := get(col)
+			return &v
+		}
+		row := strainImportRow{Row: line}
+		number := func(col string) *int {
+			v := get(col)
+			if v == "" {
+				return nil
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				row.Errors = append(row.Errors, fmt.Sprintf("%s must be a whole number", col))
+				return nil
+			}
+			return &n
+		}
+
+		e := &row.Entry
+		e.Name = get("name")
+		e.Breeder = get("breeder")
+		e.Indica = number("indica")
+		e.Sativa = number("sativa")
+		e.SeedCount = number("seed_count")
+		e.CycleTime = number("cycle_time")
+		if _, ok := cols["autoflower"]; ok {
+			switch strings.ToLower(get("autoflower")) {
+			case "", "false", "no", "n", "0":
+				e.Autoflower = new(bool)
+			case "true", "yes", "y", "1":
+				autoflower := true
+				e.Autoflower = &autoflower
+			default:
+				row.Errors = append(row.Errors, "autoflower must be yes or no")
+			}
+		}
+		e.Url = text("url")
+		e.ShortDescription = text("short_desc")
+		e.Description = text("description")
+		if lineage := text("lineage"); lineage != nil {
+			e.Lineage = strings.Split(*lineage, strainLibraryLineageSep)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseStrainLibrary reads an import file as format ("json" or "csv"),
+// guessing from the file name and content when format is empty.
+func parseStrainLibrary(data []byte, format, filename string) ([]string, []strainImportRow, error) {
+	data = bytes.TrimPrefix(data, utf8BOM)
+	if format == "" {
+		format = "csv"
+		trimmed := bytes.TrimSpace(data)
+		if strings.EqualFold(filepath.Ext(filename), ".json") || len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+			format = "json"
+		}
+	}
+	if format == "json" {
+		return parseStrainLibraryJSON(data)
+	}
+	rows, err := parseStrainLibraryCSV(data)
+	return nil, rows, err
+}
+
+// validate checks a row the way adding a strain does and normalizes its
+// URL, ratio and lineage. A ratio with one side missing is completed from
+// the other. Problems are added to row.Errors, translated.
+func (row *strainImportRow) validate(c *gin.Context) {
+	fail := func(msg string) { row.Errors = append(row.Errors, T(c, msg)) }
+	e := &row.Entry
+
+	e.Name = strings.TrimSpace(e.Name)
+	e.Breeder = strings.TrimSpace(e.Breeder)
+	if e.Url != nil {
+		url := utils.NormalizeWebURL(strings.TrimSpace(*e.Url))
+		e.Url = &url
+	}
+	if err := validateStrainFields(e.Name, valueOf(e.Description), valueOf(e.ShortDescription), "", valueOf(e.Url)); err != nil {
+		fail(err.Error())
+	}
+	if err := utils.ValidateRequiredString("breeder", e.Breeder, utils.MaxNameLength); err != nil {
+		fail(err.Error())
+	}
+
+	switch {
+	case e.Indica == nil && e.Sativa == nil:
+	case e.Indica == nil:
+		indica := 100 - *e.Sativa
+		e.Indica = &indica
+	case e.Sativa == nil:
+		sativa := 100 - *e.Indica
+		e.Sativa = &sativa
+	}
+	if e.Indica == nil || *e.Indica < 0 || *e.Sativa < 0 || *e.Indica+*e.Sativa != 100 {
+		fail("api_indica_sativa_must_sum_100")
+	}
+	if e.SeedCount != nil && *e.SeedCount < 0 {
+		fail("seed_count must not be negative")
+	}
+	if e.CycleTime != nil && *e.CycleTime < 0 {
+		fail("cycle_time must not be negative")
+	}
+
+	if e.Lineage != nil {
+		e.Lineage = cleanParentNames(e.Lineage)
+	}
+	for _, name := range e.Lineage {
+		if err := utils.ValidateStringLength("lineage", name, utils.MaxNameLength); err != nil {
+			fail(err.Error())
+			break
+		}
+	}
+	for i := range e.Reviews {
+		if errKey := e.Reviews[i].validate(); errKey != "" {
+			fail(fmt.Sprintf(T(c, "api_strain_import_review"), i+1, T(c, errKey)))
+		}
+	}
+}
+
+// What an import does with a row.
+const (
+	strainImportCreate    = "create"
+	strainImportUpdate    = "update"
+	strainImportSkip      = "skip"      // already in the library
+	strainImportDuplicate = "duplicate" // same strain as an earlier row
+	strainImportInvalid   = "invalid"
+)
+
+// strainImportResult is what an import does, or would do, with a row.
+// StrainID is the strain it matched, or created once applied.
+type strainImportResult struct {
+	Row      int      `json:"row"`
+	Name     string   `json:"name"`
+	Breeder  string   `json:"breeder"`
+	Action   string   `json:"action"`
+	StrainID int      `json:"strain_id,omitempty"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// strainImportReport is the preview, or the outcome, of an import.
+type strainImportReport struct {
+	DryRun      bool                 `json:"dry_run"`
+	Rows        []strainImportResult `json:"rows"`
+	Counts      map[string]int       `json:"counts"`
+	NewBreeders []string             `json:"new_breeders"`
+}
+
+// strainImportPlan is a validated import: the report and, per report row,
+// the entry to write.
+type strainImportPlan struct {
+	report   strainImportReport
+	entries  []strainLibraryEntry
+	breeders map[string]int // lower-cased name to id, 0 until created
+}
+
+// strainKey is the name and breeder a strain is deduplicated by.
+func strainKey(name, breeder string) string {
+	return strings.ToLower(name) + "\x00" + strings.ToLower(breeder)
+}
+
+// planStrainImport validates rows and matches them against the library by
+// name and breeder, ignoring case. Existing strains are updated when
+// update is set and skipped otherwise; a strain listed twice is only
+// imported the first time.
+func planStrainImport(c *gin.Context, db *sql.DB, breederNames []string, rows []strainImportRow, update bool) (*strainImportPlan, error) {
+	plan := &strainImportPlan{
+		report:   strainImportReport{Rows: []strainImportResult{}, NewBreeders: []string{}, Counts: map[string]int{}},
+		breeders: map[string]int{},
+	}
+
+	breeders, err := db.Query("SELECT id, name FROM breeder ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer breeders.Close()
+	for breeders.Next() {
+		var id int
+		var name string
+		if err := breeders.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		if _, ok := plan.breeders[strings.ToLower(name)]; !ok {
+			plan.breeders[strings.ToLower(name)] = id
+		}
+	}
+	if err := breeders.Err(); err != nil {
+		return nil, err
+	}
+
+	existing := map[string]int{}
+	strains, err := db.Query("SELECT s.id, s.name, COALESCE(b.name, '') FROM strain s LEFT JOIN breeder b ON b.id = s.breeder_id ORDER BY s.id")
+	if err != nil {
+		return nil, err
+	}
+	defer strains.Close()
+	for strains.Next() {
+		var id int
+		var name, breeder string
+		if err := strains.Scan(&id, &name, &breeder); err != nil {
+			return nil, err
+		}
+		if _, ok := existing[strainKey(name, breeder)]; !ok {
+			existing[strainKey(name, breeder)] = id
+		}
+	}
+	if err := strains.Err(); err != nil {
+		return nil, err
+	}
+
+	addBreeder := func(name string) {
+		if _, ok := plan.breeders[strings.ToLower(name)]; !ok {
+			plan.breeders[strings.ToLower(name)] = 0
+			plan.report.NewBreeders = append(plan.report.NewBreeders, name)
+		}
+	}
+	for _, name := range breederNames {
+		if name = strings.TrimSpace(name); utils.ValidateRequiredString("breeder", name, utils.MaxNameLength) == nil {
+			addBreeder(name)
+		}
+	}
+
+	seen := map[string]int{}
+	for _, row := range rows {
+		row.validate(c)
+		e := row.Entry
+		result := strainImportResult{Row: row.Row, Name: e.Name, Breeder: e.Breeder, Errors: row.Errors}
+		key := strainKey(e.Name, e.Breeder)
+		switch first, dup := seen[key]; {
+		case len(row.Errors) > 0:
+			result.Action = strainImportInvalid
+		case dup:
+			result.Action = strainImportDuplicate
+			result.Errors = []string{fmt.Sprintf(T(c, "api_strain_import_duplicate_row"), first)}
+		default:
+			seen[key] = row.Row
+			result.StrainID = existing[key]
+			switch {
+			case result.StrainID == 0:
+				result.Action = strainImportCreate
+			case update:
+				result.Action = strainImportUpdate
+			default:
+				result.Action = strainImportSkip
+			}
+			if result.Action != strainImportSkip {
+				addBreeder(e.Breeder)
+			}
+		}
+		plan.report.Counts[result.Action]++
+		plan.report.Rows = append(plan.report.Rows, result)
+		plan.entries = append(plan.entries, e)
+	}
+	return plan, nil
+}
+
+// applyStrainImport writes a plan in one transaction: new breeders, then
+// the created and updated strains, then their lineage. An update only
+// writes the fields the file has, never sets the seed count of a strain
+// tracked by seed lots, and locks the CannaDB fields it changes as editing
+// them by hand would. Parents are linked to a strain of the same name when
+// exactly one exists, and an updated strain keeps the links it had.
+// Reviews are imported with new strains only, so importing a file twice
+// doesn't repeat them.
+func applyStrainImport(db *sql.DB, plan *strainImportPlan) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // no-op after Commit
+
+	for _, name := range plan.report.NewBreeders {
+		var id int
+		if err := tx.QueryRow("INSERT INTO breeder (name) VALUES ($1) RETURNING id", name).Scan(&id); err != nil {
+			return err
+		}
+		plan.breeders[strings.ToLower(name)] = id
+	}
+
+	var written []int
+	for i := range plan.report.Rows {
+		result := &plan.report.Rows[i]
+		e := plan.entries[i]
+		breederID := plan.breeders[strings.ToLower(e.Breeder)]
+		switch result.Action {
+		case strainImportCreate:
+			autoflower := 0
+			if valueOf(e.Autoflower) {
+				autoflower = 1
+			}
+			err := tx.QueryRow(`INSERT INTO strain (name, breeder_id, indica, sativa, autoflower, seed_count, description, cycle_time, url, short_desc)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`,
+				e.Name, breederID, *e.Indica, *e.Sativa, autoflower, valueOf(e.SeedCount), valueOf(e.Description),
+				valueOf(e.CycleTime), valueOf(e.Url), valueOf(e.ShortDescription)).
+				Scan(&result.StrainID)
+			if err != nil {
+				return err
+			}
+			for _, review := range e.Reviews {
+				var reviewID int
+				err := tx.QueryRow(`INSERT INTO strain_reviews (strain_id, review_date, rating, potency, notes)
+					VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+					result.StrainID, review.Date, review.Rating, review.Potency, review.Notes).Scan(&reviewID)
+				if err != nil {
+					return err
+				}
+				if err := replaceReviewTags(tx, reviewID, review); err != nil {
+					return err
+				}
+			}
+		case strainImportUpdate:
+			if err := updateImportedStrain(tx, result.StrainID, breederID, e); err != nil {
+				return err
+			}
+		default:
+			continue
+		}
+		written = append(written, i)
+	}
+
+	// Lower-cased strain names to ids, -1 when the name is taken twice.
+	byName := map[string]int{}
+	rows, err := tx.Query("SELECT id, name FROM strain")
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return err
+		}
+		if _, taken := byName[strings.ToLower(name)]; taken {
+			id = -1
+		}
+		byName[strings.ToLower(name)] = id
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, i := range written {
+		if plan.entries[i].Lineage == nil {
+			continue
+		}
+		strainID := plan.report.Rows[i].StrainID
+		links, err := lineageLinks(tx, strainID)
+		if err != nil {
+			return err
+		}
+		parents := make([]lineageParent, 0, len(plan.entries[i].Lineage))
+		for _, name := range plan.entries[i].Lineage {
+			parent := lineageParent{ParentName: name}
+			if id, ok := links[strings.ToLower(name)]; ok {
+				parent.ParentStrainID = &id
+			} else if id := byName[strings.ToLower(name)]; id > 0 && id != strainID {
+				parent.ParentStrainID = &id
+			}
+			parents = append(parents, parent)
+		}
+		if err := replaceStrainLineage(tx, strainID, parents); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// updateImportedStrain writes the fields of e that the file has to an
+// existing strain.
+func updateImportedStrain(tx *sql.Tx, strainID, breederID int, e strainLibraryEntry) error {
+	current, err := loadCannadbSyncedStrain(tx, strainID)
+	if err != nil {
+		return err
+	}
+	edited := current
+	edited.Name, edited.BreederID, edited.Indica, edited.Sativa = e.Name, breederID, *e.Indica, *e.Sativa
+	sets := []string{"name = $1", "breeder_id = $2", "indica = $3", "sativa = $4"}
+	args := []interface{}{e.Name, breederID, *e.Indica, *e.Sativa}
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	if e.Autoflower != nil {
+		edited.Autoflower = *e.Autoflower
+		autoflower := 0
+		if *e.Autoflower {
+			autoflower = 1
+		}
+		set("autoflower", autoflower)
+	}
+	if e.Description != nil {
+		edited.Description = *e.Description
+		set("description", *e.Description)
+	}
+	if e.ShortDescription != nil {
+		edited.ShortDescription = *e.ShortDescription
+		set("short_desc", *e.ShortDescription)
+	}
+	if e.CycleTime != nil {
+		edited.CycleTime = *e.CycleTime
+		set("cycle_time", *e.CycleTime)
+	}
+	if e.Url != nil {
+		edited.Url = *e.Url
+		set("url", *e.Url)
+	}
+	if e.SeedCount != nil {
+		// Seed lots keep seed_count in step with the seeds on hand.
+		var lots int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM seed_lots WHERE strain_id = $1", strainID).Scan(&lots); err != nil {
+			return err
+		}
+		if lots == 0 {
+			set("seed_count", *e.SeedCount)
+		}
+	}
+
+	if err := lockEditedCannadbStrain(tx, strainID, edited); err != nil {
+		return err
+	}
+	if e.Lineage != nil {
+		if err := lockEditedCannadbLineage(tx, strainID, e.Lineage); err != nil {
+			return err
+		}
+	}
+	args = append(args, strainID)
+	_, err = tx.Exec(fmt.Sprintf("UPDATE strain SET %s WHERE id = $%d", strings.Join(sets, ", "), len(args)), args...)
+	return err
+}
+
+// valueOf returns what p points to, or the zero value when p is nil.
+func valueOf[T any](p *T) T {
+	var v T
+	if p != nil {
+		v = *p
+	}
+	return v
+}
+
+// ImportStrainLibrary imports strains, breeders and lineage from an
+// uploaded CSV or JSON library and reports what it did with each row.
+// Invalid and duplicate rows are left out; with dry_run nothing is written
+// and the report is a preview.
+// POST /strains/import  multipart: file, format=csv|json (optional),
+// duplicates=skip|update, dry_run=true|false
+func ImportStrainLibrary(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "ImportStrainLibrary")
+
+	// Leave room for the multipart framing around the file.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxStrainImportSize+1<<20)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		apiBadRequest(c, "api_strain_import_invalid_file")
+		return
+	}
+	defer file.Close()
+	if header.Size > MaxStrainImportSize {
+		apiBadRequest(c, "api_strain_import_too_large")
+		return
+	}
+	format := c.PostForm("format")
+	duplicates := c.DefaultPostForm("duplicates", "skip")
+	if format != "" && format != "json" && format != "csv" || duplicates != "skip" && duplicates != "update" {
+		apiBadRequest(c, "api_invalid_input")
+		return
+	}
+	dryRun, _ := strconv.ParseBool(c.PostForm("dry_run"))
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to read uploaded file")
+		apiBadRequest(c, "api_strain_import_invalid_file")
+		return
+	}
+	breeders, rows, err := parseStrainLibrary(data, format, header.Filename)
+	if errors.Is(err, errStrainImportColumns) {
+		apiBadRequest(c, err.Error())
+		return
+	}
+	if err != nil {
+		fieldLogger.WithError(err).Warn("Unreadable strain library")
+		apiBadRequest(c, "api_strain_import_invalid_file")
+		return
+	}
+	if len(rows) == 0 && len(breeders) == 0 {
+		apiBadRequest(c, "api_strain_import_empty")
+		return
+	}
+	if len(rows) > MaxStrainImportRows {
+		apiBadRequest(c, "api_strain_import_too_many_rows")
+		return
+	}
+
+	db := DBFromContext(c)
+	plan, err := planStrainImport(c, db, breeders, rows, duplicates == "update")
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to plan strain import")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	plan.report.DryRun = dryRun
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{"report": plan.report, "message": T(c, "api_strain_import_preview")})
+		return
+	}
+
+	if err := applyStrainImport(db, plan); err != nil {
+		fieldLogger.WithError(err).Error("Failed to import strain library")
+		apiInternalError(c, "api_strain_import_failed")
+		return
+	}
+
+	store := ConfigStoreFromContext(c)
+	store.SetBreeders(GetBreeders(db))
+	store.SetStrains(GetStrains(db, StrainSortName))
+
+	fieldLogger.WithFields(logrus.Fields{
+		"created":  plan.report.Counts[strainImportCreate],
+		"updated":  plan.report.Counts[strainImportUpdate],
+		"skipped":  plan.report.Counts[strainImportSkip] + plan.report.Counts[strainImportDuplicate],
+		"invalid":  plan.report.Counts[strainImportInvalid],
+		"breeders": len(plan.report.NewBreeders),
+	}).Info("Imported strain library")
+	c.JSON(http.StatusOK, gin.H{"report": plan.report, "message": T(c, "api_strain_imported")})
+}
@@ -0,0 +1,257 @@
+package handlers_test
+
+// HTTP-layer tests for handlers/strain_library.go: exporting the strain
+// library as JSON and CSV, and importing it back with a preview,
+// validation and deduplication by name and breeder.
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"isley/tests/testutil"
+)
+
+type strainImportResponse struct {
+	Report struct {
+		DryRun bool `json:"dry_run"`
+		Rows   []struct {
+			Row      int      `json:"row"`
+			Name     string   `json:"name"`
+			Action   string   `json:"action"`
+			StrainID int      `json:"strain_id"`
+			Errors   []string `json:"errors"`
+		} `json:"rows"`
+		Counts      map[string]int `json:"counts"`
+		NewBreeders []string       `json:"new_breeders"`
+	} `json:"report"`
+}
+
+// importStrainLibrary uploads content as the import file, with fields as
+// the other form values.
+func importStrainLibrary(t *testing.T, c *testutil.Client, apiKey, filename string, content []byte, fields map[string]string) *http.Response {
+	t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	fw, err := w.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = fw.Write(content)
+	require.NoError(t, err)
+	for k, v := range fields {
+		require.NoError(t, w.WriteField(k, v))
+	}
+	require.NoError(t, w.Close())
+	resp, err := c.Do(testutil.APIReq(t, http.MethodPost, c.BaseURL+"/strains/import", apiKey, &buf, w.FormDataContentType()))
+	require.NoError(t, err)
+	return resp
+}
+
+func expectStrainImport(t *testing.T, resp *http.Response) strainImportResponse {
+	t.Helper()
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var got strainImportResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	return got
+}
+
+func exportStrainLibrary(t *testing.T, c *testutil.Client, apiKey, format string) []byte {
+	t.Helper()
+	resp := harvestRequest(t, c, http.MethodGet, "/strains/export?format="+format, apiKey, nil)
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Disposition"), "isley-strains-")
+	var buf bytes.Buffer
+	_, err := buf.ReadFrom(resp.Body)
+	require.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestStrainLibraryHTTP_ExportAndImportRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	src := testutil.NewTestDB(t)
+	srcServer := testutil.NewTestServer(t, src)
+	srcKey := testutil.SeedAPIKey(t, src, "library-key")
+	cookies := testutil.SeedBreeder(t, src, "Cookies")
+	testutil.SeedBreeder(t, src, "Lonely")
+	sherbet := testutil.SeedStrain(t, src, cookies, "Sunset Sherbet")
+	gelato := testutil.SeedStrain(t, src, cookies, "Gelato")
+	testutil.MustExec(t, src, `INSERT INTO strain_lineage (strain_id, parent_name, parent_strain_id) VALUES ($1, 'Sunset Sherbet', $2), ($1, 'Thin Mint GSC', NULL)`, gelato, sherbet)
+	plantID := testutil.SeedPlant(t, src, "Gelato 1", gelato, testutil.SeedZone(t, src, "Tent"))
+	seedHarvest(t, src, plantID)
+	srcClient := srcServer.NewClient(t)
+	addReview(t, srcClient, srcKey, plantID, map[string]interface{}{
+		"review_date": "2026-07-01", "rating": 4, "potency": 3, "flavor_tags": []string{"sweet"}, "effect_tags": []string{"calm"},
+	})
+
+	exported := exportStrainLibrary(t, srcClient, srcKey, "json")
+	var lib struct {
+		Version  int      `json:"version"`
+		Breeders []string `json:"breeders"`
+		Strains  []struct {
+			Name          string   `json:"name"`
+			Breeder       string   `json:"breeder"`
+			Lineage       []string `json:"lineage"`
+			AverageRating *float64 `json:"average_rating"`
+			Reviews       []struct {
+				Date       string   `json:"review_date"`
+				FlavorTags []string `json:"flavor_tags"`
+			} `json:"reviews"`
+		} `json:"strains"`
+	}
+	require.NoError(t, json.Unmarshal(exported, &lib))
+	assert.Equal(t, 1, lib.Version)
+	assert.Equal(t, []string{"Cookies", "Lonely"}, lib.Breeders)
+	require.Len(t, lib.Strains, 2)
+	assert.Equal(t, "Gelato", lib.Strains[0].Name)
+	assert.Equal(t, []string{"Sunset Sherbet", "Thin Mint GSC"}, lib.Strains[0].Lineage)
+	require.Len(t, lib.Strains[0].Reviews, 1)
+	assert.Equal(t, "2026-07-01", lib.Strains[0].Reviews[0].Date)
+	assert.Equal(t, []string{"sweet"}, lib.Strains[0].Reviews[0].FlavorTags)
+	require.NotNil(t, lib.Strains[0].AverageRating)
+	assert.Empty(t, lib.Strains[1].Reviews)
+
+	records, err := csv.NewReader(bytes.NewReader(exportStrainLibrary(t, srcClient, srcKey, "csv"))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, []string{"name", "breeder", "indica", "sativa", "autoflower", "seed_count", "cycle_time",
+		"url", "short_desc", "description", "lineage", "average_rating", "review_count"}, records[0])
+	assert.Equal(t, []string{"Gelato", "Cookies"}, records[1][:2])
+	assert.Equal(t, "Sunset Sherbet; Thin Mint GSC", records[1][10])
+	assert.Equal(t, "1", records[1][12])
+
+	// A preview of the import into an empty instance writes nothing.
+	dst := testutil.NewTestDB(t)
+	dstServer := testutil.NewTestServer(t, dst)
+	dstKey := testutil.SeedAPIKey(t, dst, "library-key")
+	dstClient := dstServer.NewClient(t)
+	preview := expectStrainImport(t, importStrainLibrary(t, dstClient, dstKey, "strains.json", exported, map[string]string{"dry_run": "true"}))
+	assert.True(t, preview.Report.DryRun)
+	assert.Equal(t, map[string]int{"create": 2}, preview.Report.Counts)
+	assert.Equal(t, []string{"Cookies", "Lonely"}, preview.Report.NewBreeders)
+	assert.Equal(t, 0, testutil.CountRows(t, dst, `SELECT COUNT(*) FROM strain`))
+	assert.Equal(t, 0, testutil.CountRows(t, dst, `SELECT COUNT(*) FROM breeder`))
+
+	imported := expectStrainImport(t, importStrainLibrary(t, dstClient, dstKey, "strains.json", exported, nil))
+	assert.Equal(t, map[string]int{"create": 2}, imported.Report.Counts)
+	assert.Equal(t, 2, testutil.CountRows(t, dst, `SELECT COUNT(*) FROM breeder WHERE name IN ('Cookies', 'Lonely')`))
+	var newGelato, newSherbet int
+	require.NoError(t, dst.QueryRow(`SELECT id FROM strain WHERE name = 'Gelato'`).Scan(&newGelato))
+	require.NoError(t, dst.QueryRow(`SELECT id FROM strain WHERE name = 'Sunset Sherbet'`).Scan(&newSherbet))
+	assert.Equal(t, 1, testutil.CountRows(t, dst, `SELECT COUNT(*) FROM strain_lineage WHERE strain_id = $1 AND parent_name = 'Sunset Sherbet' AND parent_strain_id = $2`, newGelato, newSherbet),
+		"parents are linked to the imported strain of the same name")
+	assert.Equal(t, 1, testutil.CountRows(t, dst, `SELECT COUNT(*) FROM strain_lineage WHERE strain_id = $1 AND parent_name = 'Thin Mint GSC' AND parent_strain_id IS NULL`, newGelato))
+	assert.Equal(t, 1, testutil.CountRows(t, dst, `SELECT COUNT(*) FROM strain_reviews WHERE strain_id = $1 AND plant_id IS NULL AND rating = 4 AND potency = 3`, newGelato))
+	assert.Equal(t, 2, testutil.CountRows(t, dst, `SELECT COUNT(*) FROM strain_review_tags t JOIN strain_reviews r ON r.id = t.review_id WHERE r.strain_id = $1`, newGelato))
+
+	// Importing the same file again finds every strain already there.
+	again := expectStrainImport(t, importStrainLibrary(t, dstClient, dstKey, "strains.json", exported, map[string]string{"duplicates": "update"}))
+	assert.Equal(t, map[string]int{"update": 2}, again.Report.Counts)
+	assert.Empty(t, again.Report.NewBreeders)
+	assert.Equal(t, 2, testutil.CountRows(t, dst, `SELECT COUNT(*) FROM strain`))
+	assert.Equal(t, 1, testutil.CountRows(t, dst, `SELECT COUNT(*) FROM strain_reviews`), "reviews come in with new strains only")
+}
+
+func TestStrainLibraryHTTP_ImportCSVValidatesAndDedupes(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db)
+	apiKey := testutil.SeedAPIKey(t, db, "library-key")
+	lsd := testutil.SeedStrain(t, db, testutil.SeedBreeder(t, db, "Barney's Farm"), "LSD")
+	c := server.NewClient(t)
+
+	file := []byte("\xef\xbb\xbfBreeder,Name,Sativa,Seed_Count,Lineage,Notes\n" +
+		"barney's farm,LSD,40,12,Mazar; Skunk #1,ignored\n" +
+		"New Co,Alpha,,,,\n" +
+		"New Co,Bravo,50,,,\n" +
+		",,,,,\n" +
+		"New Co,bravo,50,,,\n" +
+		",Charlie,50,,,\n" +
+		"New Co,Delta,50,lots,,\n")
+
+	actions := func(got strainImportResponse) []string {
+		out := make([]string, len(got.Report.Rows))
+		for i, r := range got.Report.Rows {
+			out[i] = r.Action
+		}
+		return out
+	}
+
+	skipped := expectStrainImport(t, importStrainLibrary(t, c, apiKey, "strains.csv", file, map[string]string{"dry_run": "1"}))
+	assert.Equal(t, []string{"skip", "invalid", "create", "duplicate", "invalid", "invalid"}, actions(skipped))
+	assert.Equal(t, []string{"New Co"}, skipped.Report.NewBreeders)
+	rows := skipped.Report.Rows
+	assert.Equal(t, lsd, rows[0].StrainID)
+	assert.Equal(t, []int{2, 3, 4, 6, 7, 8}, []int{rows[0].Row, rows[1].Row, rows[2].Row, rows[3].Row, rows[4].Row, rows[5].Row},
+		"rows are numbered by their line in the file")
+	assert.Equal(t, []string{"Indica and Sativa must sum to 100"}, rows[1].Errors)
+	assert.Equal(t, []string{"Same strain and breeder as row 4"}, rows[3].Errors)
+	assert.Equal(t, []string{"breeder is required"}, rows[4].Errors)
+	assert.Equal(t, []string{"seed_count must be a whole number"}, rows[5].Errors)
+
+	updated := expectStrainImport(t, importStrainLibrary(t, c, apiKey, "strains.csv", file, map[string]string{"duplicates": "update"}))
+	assert.Equal(t, []string{"update", "invalid", "create", "duplicate", "invalid", "invalid"}, actions(updated))
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain WHERE id = $1 AND indica = 60 AND sativa = 40 AND seed_count = 12`, lsd),
+		"a missing indica is completed from the sativa")
+	assert.Equal(t, 2, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain_lineage WHERE strain_id = $1 AND parent_name IN ('Mazar', 'Skunk #1')`, lsd))
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain s JOIN breeder b ON b.id = s.breeder_id WHERE s.name = 'Bravo' AND b.name = 'New Co'`))
+	assert.Equal(t, 2, testutil.CountRows(t, db, `SELECT COUNT(*) FROM breeder`))
+	assert.Equal(t, 2, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain`))
+
+	for name, tc := range map[string]struct {
+		filename string
+		content  string
+		fields   map[string]string
+	}{
+		"no breeder column": {"s.csv", "name,indica\nA,50\n", nil},
+		"not json":          {"s.json", "{not json", nil},
+		"empty":             {"s.csv", "", nil},
+		"header only":       {"s.csv", "name,breeder\n", nil},
+		"bad duplicates":    {"s.csv", "name,breeder,indica\nA,B,50\n", map[string]string{"duplicates": "replace"}},
+		"bad format":        {"s.csv", "name,breeder,indica\nA,B,50\n", map[string]string{"format": "xml"}},
+	} {
+		resp := importStrainLibrary(t, c, apiKey, tc.filename, []byte(tc.content), tc.fields)
+		testutil.DrainAndClose(resp)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+	}
+
+	assert.Equal(t, 2, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain`))
+}
+
+func TestStrainLibraryHTTP_ImportUpdateKeepsMissingColumns(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db)
+	apiKey := testutil.SeedAPIKey(t, db, "library-key")
+	breeder := testutil.SeedBreeder(t, db, "Cookies")
+	gelato := testutil.SeedStrain(t, db, breeder, "Gelato")
+	testutil.MustExec(t, db, `UPDATE strain SET autoflower = 1, description = 'Dessert strain.', short_desc = 'Sweet', cycle_time = 63,
+		url = 'https://example.com/gelato', cannadb_uri = 'at://did:plc:test/strain/gelato' WHERE id = $1`, gelato)
+	testutil.MustExec(t, db, `INSERT INTO strain_lineage (strain_id, parent_name) VALUES ($1, 'Sunset Sherbet')`, gelato)
+	runtz := testutil.SeedStrain(t, db, breeder, "Runtz")
+	testutil.MustExec(t, db, `INSERT INTO seed_lots (strain_id, pack_size, seeds_left) VALUES ($1, 5, 5)`, runtz)
+	c := server.NewClient(t)
+
+	file := []byte("name,breeder,sativa,seed_count\nGelato,Cookies,40,12\nRuntz,Cookies,50,99\n")
+	updated := expectStrainImport(t, importStrainLibrary(t, c, apiKey, "strains.csv", file, map[string]string{"duplicates": "update"}))
+	assert.Equal(t, map[string]int{"update": 2}, updated.Report.Counts)
+
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain WHERE id = $1 AND indica = 60 AND sativa = 40 AND seed_count = 12
+		AND autoflower = 1 AND description = 'Dessert strain.' AND short_desc = 'Sweet' AND cycle_time = 63 AND url = 'https://example.com/gelato'`, gelato),
+		"columns missing from the file are left as they were")
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain_lineage WHERE strain_id = $1 AND parent_name = 'Sunset Sherbet'`, gelato))
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain WHERE id = $1 AND seed_count = 5`, runtz),
+		"seed lots keep the seed count")
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain_cannadb_locks WHERE strain_id = $1`, gelato))
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain_cannadb_locks WHERE strain_id = $1 AND field = 'indica_sativa'`, gelato),
+		"only the changed CannaDB field is locked")
+}
@@ -0,0 +1,433 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"isley/logger"
+	"isley/model/types"
+	"isley/utils"
+)
+
+const (
+	// MaxReviewRating is the top of the 1–5 star review scale; potency
+	// uses the same scale.
+	MaxReviewRating = 5
+	// MaxReviewTagLength bounds a single review tag.
+	MaxReviewTagLength = 32
+	// MaxTagsPerReview bounds how many tags of each kind one review may
+	// carry.
+	MaxTagsPerReview = 15
+	// reviewTopTags is how many of the most given tags of each kind a
+	// strain's review summary lists.
+	reviewTopTags = 10
+)
+
+// Kinds of review tag, stored in strain_review_tags.kind.
+const (
+	reviewTagFlavor = "flavor"
+	reviewTagEffect = "effect"
+)
+
+// loadReviews loads the reviews matching where (a condition on r), newest
+// first, with their tags.
+func loadReviews(db *sql.DB, where string, args ...interface{}) ([]types.StrainReview, error) {
+	rows, err := db.Query(`SELECT r.id, r.strain_id, r.plant_id, COALESCE(p.name, ''), r.review_date, r.rating, r.potency, r.notes
+		FROM strain_reviews r
+		LEFT JOIN plant p ON p.id = r.plant_id
+		WHERE `+where+`
+		ORDER BY r.review_date DESC, r.id DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	reviews := []types.StrainReview{}
+	index := map[int]int{}
+	for rows.Next() {
+		var r types.StrainReview
+		var plant, potency sql.NullInt64
+		if err := rows.Scan(&r.ID, &r.StrainID, &plant, &r.PlantName, &r.Date, &r.Rating, &potency, &r.Notes); err != nil {
+			return nil, err
+		}
+		r.PlantID = nullIntPtr(plant)
+		r.Potency = nullIntPtr(potency)
+		r.Date = utils.AsLocal(r.Date)
+		r.FlavorTags = []string{}
+		r.EffectTags = []string{}
+		index[r.ID] = len(reviews)
+		reviews = append(reviews, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(reviews) == 0 {
+		return reviews, nil
+	}
+
+	tags, err := db.Query(`SELECT t.review_id, t.kind, t.tag
+		FROM strain_review_tags t
+		JOIN strain_reviews r ON r.id = t.review_id
+		WHERE `+where+`
+		ORDER BY t.tag`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer tags.Close()
+	for tags.Next() {
+		var reviewID int
+		var kind, tag string
+		if err := tags.Scan(&reviewID, &kind, &tag); err != nil {
+			return nil, err
+		}
+		i, ok := index[reviewID]
+		if !ok {
+			continue
+		}
+		if kind == reviewTagEffect {
+			reviews[i].EffectTags = append(reviews[i].EffectTags, tag)
+		} else {
+			reviews[i].FlavorTags = append(reviews[i].FlavorTags, tag)
+		}
+	}
+	return reviews, tags.Err()
+}
+
+// LoadPlantReviews loads the reviews written on a plant, newest first.
+func LoadPlantReviews(db *sql.DB, plantID int) ([]types.StrainReview, error) {
+	return loadReviews(db, "r.plant_id = $1", plantID)
+}
+
+// LoadStrainReviews loads a strain's reviews and aggregates their
+// ratings, potency and tags.
+func LoadStrainReviews(db *sql.DB, strainID int) (types.StrainReviewSummary, error) {
+	summary := types.StrainReviewSummary{StrainID: strainID, FlavorTags: []types.ReviewTagCount{}, EffectTags: []types.ReviewTagCount{}}
+	reviews, err := loadReviews(db, "r.strain_id = $1", strainID)
+	if err != nil {
+		return summary, err
+	}
+	summary.Reviews = reviews
+	summary.Count = len(reviews)
+
+	var ratings, potencies, rated float64
+	counts := map[string]map[string]int{reviewTagFlavor: {}, reviewTagEffect: {}}
+	for _, r := range reviews {
+		ratings += float64(r.Rating)
+		if r.Potency != nil {
+			potencies += float64(*r.Potency)
+			rated++
+		}
+		for _, tag := range r.FlavorTags {
+			counts[reviewTagFlavor][tag]++
+		}
+		for _, tag := range r.EffectTags {
+			counts[reviewTagEffect][tag]++
+		}
+	}
+	if summary.Count > 0 {
+		avg := math.Round(ratings/float64(summary.Count)*100) / 100
+		summary.AverageRating = &avg
+	}
+	if rated > 0 {
+		avg := math.Round(potencies/rated*100) / 100
+		summary.AveragePotency = &avg
+	}
+	summary.FlavorTags = topReviewTags(counts[reviewTagFlavor])
+	summary.EffectTags = topReviewTags(counts[reviewTagEffect])
+	return summary, nil
+}
+
+// topReviewTags orders tags by how often they were given, then by name,
+// keeping the first reviewTopTags.
+func topReviewTags(counts map[string]int) []types.ReviewTagCount {
+	tags := make([]types.ReviewTagCount, 0, len(counts))
+	for tag, n := range counts {
+		tags = append(tags, types.ReviewTagCount{Tag: tag, Count: n})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+	if len(tags) > reviewTopTags {
+		tags = tags[:reviewTopTags]
+	}
+	return tags
+}
+
+// ListStrainReviews returns a strain's reviews and their aggregate scores.
+func ListStrainReviews(c *gin.Context) {
+	strainID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_strain_id")
+		return
+	}
+	db := DBFromContext(c)
+	if exists, err := rowExists(db, "strain", strainID); err != nil {
+		logger.Log.WithError(err).WithField("func", "ListStrainReviews").Error("Failed to look up strain")
+		apiInternalError(c, "api_database_error")
+		return
+	} else if !exists {
+		apiNotFound(c, "api_strain_not_found")
+		return
+	}
+	summary, err := LoadStrainReviews(db, strainID)
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "ListStrainReviews").Error("Failed to load reviews")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// ListPlantReviews returns the reviews written on a plant.
+func ListPlantReviews(c *gin.Context) {
+	plantID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_plant_id")
+		return
+	}
+	reviews, err := LoadPlantReviews(DBFromContext(c), plantID)
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "ListPlantReviews").Error("Failed to load reviews")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusOK, reviews)
+}
+
+type strainReviewInput struct {
+	Date       string   `json:"review_date"`
+	Rating     int      `json:"rating"`
+	Potency    *int     `json:"potency"`
+	Notes      string   `json:"notes"`
+	FlavorTags []string `json:"flavor_tags"`
+	EffectTags []string `json:"effect_tags"`
+}
+
+// validate checks the input and normalizes its tags. It returns an error
+// key or message for the response.
+func (in *strainReviewInput) validate() string {
+	if in.Potency != nil && *in.Potency == 0 {
+		in.Potency = nil
+	}
+	if in.Rating < 1 || in.Rating > MaxReviewRating {
+		return fmt.Sprintf("rating must be between 1 and %d", MaxReviewRating)
+	}
+	if in.Potency != nil && (*in.Potency < 1 || *in.Potency > MaxReviewRating) {
+		return fmt.Sprintf("potency must be between 1 and %d", MaxReviewRating)
+	}
+	if err := utils.ValidateStringLength("notes", in.Notes, utils.MaxNotesLength); err != nil {
+		return err.Error()
+	}
+	if err := validateRequiredDate("review_date", in.Date); err != nil {
+		return err.Error()
+	}
+	var errKey string
+	if in.FlavorTags, errKey = normalizeTags(in.FlavorTags, MaxReviewTagLength, MaxTagsPerReview, "api_review_tag_too_long", "api_too_many_review_tags"); errKey != "" {
+		return errKey
+	}
+	in.EffectTags, errKey = normalizeTags(in.EffectTags, MaxReviewTagLength, MaxTagsPerReview, "api_review_tag_too_long", "api_too_many_review_tags")
+	return errKey
+}
+
+// bindStrainReviewInput binds and validates a review body. It sends the
+// error response itself.
+func bindStrainReviewInput(c *gin.Context) (strainReviewInput, bool) {
+	var in strainReviewInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		apiBadRequest(c, "api_invalid_input")
+		return in, false
+	}
+	if errKey := in.validate(); errKey != "" {
+		apiBadRequest(c, errKey)
+		return in, false
+	}
+	return in, true
+}
+
+// replaceReviewTags sets a review's tags to those of in.
+func replaceReviewTags(tx *sql.Tx, reviewID int, in strainReviewInput) error {
+	if _, err := tx.Exec(`DELETE FROM strain_review_tags WHERE review_id = $1`, reviewID); err != nil {
+		return err
+	}
+	for kind, tags := range map[string][]string{reviewTagFlavor: in.FlavorTags, reviewTagEffect: in.EffectTags} {
+		for _, tag := range tags {
+			if _, err := tx.Exec(`INSERT INTO strain_review_tags (review_id, kind, tag) VALUES ($1, $2, $3)`, reviewID, kind, tag); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// refreshStrainRatings reloads the cached strain list, which carries each
+// strain's review stats.
+func refreshStrainRatings(c *gin.Context) {
+	ConfigStoreFromContext(c).SetStrains(GetStrains(DBFromContext(c), StrainSortName))
+}
+
+// AddStrainReview records a review of a harvested plant against the
+// plant's strain.
+func AddStrainReview(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "AddStrainReview")
+	plantID, ok := plantIDParam(c)
+	if !ok {
+		return
+	}
+	in, ok := bindStrainReviewInput(c)
+	if !ok {
+		return
+	}
+
+	tx, err := DBFromContext(c).Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+
+	var strainID sql.NullInt64
+	var harvested bool
+	err = tx.QueryRow(`SELECT strain_id, EXISTS (SELECT 1 FROM harvests h WHERE h.plant_id = p.id) FROM plant p WHERE p.id = $1`, plantID).
+		Scan(&strainID, &harvested)
+	if errors.Is(err, sql.ErrNoRows) {
+		apiNotFound(c, "api_plant_not_found")
+		return
+	}
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to look up plant")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if !harvested {
+		apiBadRequest(c, "api_review_plant_not_harvested")
+		return
+	}
+	if !strainID.Valid {
+		apiBadRequest(c, "api_review_plant_has_no_strain")
+		return
+	}
+
+	var id int
+	err = tx.QueryRow(`INSERT INTO strain_reviews (strain_id, plant_id, review_date, rating, potency, notes)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		strainID.Int64, plantID, in.Date, in.Rating, in.Potency, in.Notes).Scan(&id)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to create review")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if err := replaceReviewTags(tx, id, in); err != nil {
+		fieldLogger.WithError(err).Error("Failed to save review tags")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	refreshStrainRatings(c)
+	c.JSON(http.StatusCreated, gin.H{"id": id, "message": T(c, "api_review_saved")})
+}
+
+func strainReviewParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_request")
+		return 0, false
+	}
+	return id, true
+}
+
+// UpdateStrainReview replaces a review's date, scores, notes and tags. The
+// plant and strain it was written on stay.
+func UpdateStrainReview(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "UpdateStrainReview")
+	id, ok := strainReviewParam(c)
+	if !ok {
+		return
+	}
+	in, ok := bindStrainReviewInput(c)
+	if !ok {
+		return
+	}
+
+	tx, err := DBFromContext(c).Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+	result, err := tx.Exec(`UPDATE strain_reviews SET review_date = $1, rating = $2, potency = $3, notes = $4, update_dt = CURRENT_TIMESTAMP
+		WHERE id = $5`, in.Date, in.Rating, in.Potency, in.Notes, id)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to update review")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		apiNotFound(c, "api_review_not_found")
+		return
+	}
+	if err := replaceReviewTags(tx, id, in); err != nil {
+		fieldLogger.WithError(err).Error("Failed to save review tags")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	refreshStrainRatings(c)
+	apiOK(c, "api_review_saved")
+}
+
+// DeleteStrainReview deletes a review and its tags.
+func DeleteStrainReview(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "DeleteStrainReview")
+	id, ok := strainReviewParam(c)
+	if !ok {
+		return
+	}
+	tx, err := DBFromContext(c).Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+	if _, err := tx.Exec(`DELETE FROM strain_review_tags WHERE review_id = $1`, id); err != nil {
+		fieldLogger.WithError(err).Error("Failed to delete review tags")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	result, err := tx.Exec(`DELETE FROM strain_reviews WHERE id = $1`, id)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to delete review")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		apiNotFound(c, "api_review_not_found")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	refreshStrainRatings(c)
+	apiOK(c, "api_review_deleted")
+}
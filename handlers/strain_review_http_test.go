@@ -0,0 +1,199 @@
+package handlers_test
+
+// HTTP-layer tests for handlers/strain_review.go: smoke reports on
+// harvested plants, their aggregate scores and sorting strains by rating.
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"isley/handlers"
+	"isley/model/types"
+	"isley/tests/testutil"
+)
+
+func seedHarvest(t *testing.T, db *sql.DB, plantID int) {
+	t.Helper()
+	testutil.MustExec(t, db, `INSERT INTO harvests (plant_id, harvest_date) VALUES ($1, '2026-06-01')`, plantID)
+}
+
+func addReview(t *testing.T, c *testutil.Client, apiKey string, plantID int, body map[string]interface{}) int {
+	t.Helper()
+	return createNutrientRow(t, c, apiKey, "/plant/"+strconv.Itoa(plantID)+"/reviews", body)
+}
+
+func getStrainReviews(t *testing.T, c *testutil.Client, strainID int) types.StrainReviewSummary {
+	t.Helper()
+	resp := c.Get("/strains/" + strconv.Itoa(strainID) + "/reviews")
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var got types.StrainReviewSummary
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	return got
+}
+
+func TestStrainReviewHTTP_AggregatesReviews(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "review-key")
+	testutil.SeedAdmin(t, db, "review-pw")
+	strainID := testutil.SeedStrain(t, db, testutil.SeedBreeder(t, db, "B"), "Lemon Haze")
+	zoneID := testutil.SeedZone(t, db, "Z")
+	one := testutil.SeedPlant(t, db, "One", strainID, zoneID)
+	two := testutil.SeedPlant(t, db, "Two", strainID, zoneID)
+	seedHarvest(t, db, one)
+	seedHarvest(t, db, two)
+	c := server.NewClient(t)
+
+	addReview(t, c, apiKey, one, map[string]interface{}{
+		"review_date": "2026-07-01", "rating": 5, "potency": 4, "notes": "Loud",
+		"flavor_tags": []string{"Citrus", "pine", " citrus "}, "effect_tags": []string{"uplifted"},
+	})
+	second := addReview(t, c, apiKey, two, map[string]interface{}{
+		"review_date": "2026-07-03", "rating": 2, "potency": 0,
+		"flavor_tags": []string{"citrus"}, "effect_tags": []string{"sleepy", "uplifted"},
+	})
+
+	summary := getStrainReviews(t, c, strainID)
+	assert.Equal(t, 2, summary.Count)
+	require.NotNil(t, summary.AverageRating)
+	assert.InDelta(t, 3.5, *summary.AverageRating, 0.001)
+	require.NotNil(t, summary.AveragePotency)
+	assert.InDelta(t, 4, *summary.AveragePotency, 0.001, "a potency of 0 is left out of the average")
+	assert.Equal(t, []types.ReviewTagCount{{Tag: "citrus", Count: 2}, {Tag: "pine", Count: 1}}, summary.FlavorTags,
+		"tags are lower-cased and deduplicated within a review")
+	assert.Equal(t, []types.ReviewTagCount{{Tag: "uplifted", Count: 2}, {Tag: "sleepy", Count: 1}}, summary.EffectTags)
+	require.Len(t, summary.Reviews, 2)
+	assert.Equal(t, second, summary.Reviews[0].ID, "newest first")
+	assert.Equal(t, "Two", summary.Reviews[0].PlantName)
+
+	// Editing a review replaces its tags.
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/strain-reviews/"+strconv.Itoa(second), apiKey,
+		map[string]interface{}{"review_date": "2026-07-03", "rating": 4, "effect_tags": []string{"calm"}}), http.StatusOK)
+	summary = getStrainReviews(t, c, strainID)
+	assert.InDelta(t, 4.5, *summary.AverageRating, 0.001)
+	assert.Equal(t, []types.ReviewTagCount{{Tag: "citrus", Count: 1}, {Tag: "pine", Count: 1}}, summary.FlavorTags)
+
+	// Signed in, both pages list the reviews and the harvest page offers the form.
+	admin := server.LoginAsAdmin(t, "review-pw")
+	for path, marker := range map[string]string{
+		"/strain/" + strconv.Itoa(strainID):        "reviewScoresCard",
+		"/plant/" + strconv.Itoa(two) + "/harvest": "reviewForm",
+	} {
+		resp := admin.Get(path)
+		body, err := io.ReadAll(resp.Body)
+		testutil.DrainAndClose(resp)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode, path)
+		assert.Contains(t, string(body), marker, path)
+		assert.Contains(t, string(body), "/strain-reviews/"+strconv.Itoa(second), path)
+	}
+
+	// Deleting the plant keeps its review on the strain.
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodDelete, "/plant/delete/"+strconv.Itoa(two), apiKey, nil), http.StatusOK)
+	summary = getStrainReviews(t, c, strainID)
+	require.Len(t, summary.Reviews, 2)
+	assert.Nil(t, summary.Reviews[0].PlantID)
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodDelete, "/strain-reviews/"+strconv.Itoa(second), apiKey, nil), http.StatusOK)
+	assert.Equal(t, 0, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain_review_tags WHERE review_id = $1`, second))
+	assert.Equal(t, 1, getStrainReviews(t, c, strainID).Count)
+}
+
+func TestStrainReviewHTTP_SortsStrainsByRating(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "review-key")
+	breederID := testutil.SeedBreeder(t, db, "B")
+	zoneID := testutil.SeedZone(t, db, "Z")
+	c := server.NewClient(t)
+
+	for name, rating := range map[string]int{"Alpha": 2, "Bravo": 0, "Charlie": 5} {
+		strainID := testutil.SeedStrain(t, db, breederID, name)
+		if rating == 0 {
+			continue
+		}
+		plantID := testutil.SeedPlant(t, db, name+" 1", strainID, zoneID)
+		seedHarvest(t, db, plantID)
+		addReview(t, c, apiKey, plantID, map[string]interface{}{"review_date": "2026-07-01", "rating": rating})
+	}
+
+	names := func(strains []types.Strain) []string {
+		out := make([]string, len(strains))
+		for i, s := range strains {
+			out[i] = s.Name
+		}
+		return out
+	}
+	assert.Equal(t, []string{"Charlie", "Alpha", "Bravo"}, names(handlers.GetStrains(db, handlers.StrainSortRating)),
+		"unrated strains sort last")
+	byName := handlers.GetStrains(db, handlers.StrainSortName)
+	assert.Equal(t, []string{"Alpha", "Bravo", "Charlie"}, names(byName))
+	require.NotNil(t, byName[0].AverageRating)
+	assert.InDelta(t, 2, *byName[0].AverageRating, 0.001)
+	assert.Equal(t, 1, byName[0].ReviewCount)
+	assert.Nil(t, byName[1].AverageRating)
+
+	resp := c.Get("/strains/in-stock?sort=rating")
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var inStock []types.Strain
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&inStock))
+	assert.Equal(t, []string{"Charlie", "Alpha", "Bravo"}, names(inStock))
+}
+
+func TestStrainReviewHTTP_Validation(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "review-key")
+	strainID := testutil.SeedStrain(t, db, testutil.SeedBreeder(t, db, "B"), "S")
+	zoneID := testutil.SeedZone(t, db, "Z")
+	growing := testutil.SeedPlant(t, db, "Growing", strainID, zoneID)
+	harvested := testutil.SeedPlant(t, db, "Harvested", strainID, zoneID)
+	seedHarvest(t, db, harvested)
+	c := server.NewClient(t)
+
+	reviewsPath := func(id int) string { return "/plant/" + strconv.Itoa(id) + "/reviews" }
+	valid := map[string]interface{}{"review_date": "2026-07-01", "rating": 4}
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, reviewsPath(growing), apiKey, valid), http.StatusBadRequest)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, reviewsPath(9999), apiKey, valid), http.StatusNotFound)
+
+	tooMany := make([]string, handlers.MaxTagsPerReview+1)
+	for i := range tooMany {
+		tooMany[i] = "tag" + strconv.Itoa(i)
+	}
+	for name, body := range map[string]map[string]interface{}{
+		"no rating":      {"review_date": "2026-07-01"},
+		"six stars":      {"review_date": "2026-07-01", "rating": 6},
+		"potency":        {"review_date": "2026-07-01", "rating": 3, "potency": 9},
+		"no date":        {"rating": 3},
+		"long tag":       {"review_date": "2026-07-01", "rating": 3, "flavor_tags": []string{strings.Repeat("a", handlers.MaxReviewTagLength+1)}},
+		"too many tags":  {"review_date": "2026-07-01", "rating": 3, "effect_tags": tooMany},
+		"bad date value": {"review_date": "July", "rating": 3},
+	} {
+		resp := harvestRequest(t, c, http.MethodPost, reviewsPath(harvested), apiKey, body)
+		testutil.DrainAndClose(resp)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+	}
+	assert.Equal(t, 0, testutil.CountRows(t, db, `SELECT COUNT(*) FROM strain_reviews`))
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/strain-reviews/9999", apiKey, valid), http.StatusNotFound)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodDelete, "/strain-reviews/9999", apiKey, nil), http.StatusNotFound)
+	resp := c.Get("/strains/9999/reviews")
+	testutil.DrainAndClose(resp)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -8,14 +8,108 @@ import (
 	"isley/utils"
 	"net/http"
 	"path/filepath"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// Bounds for a stream's own grab interval, in seconds. Zero is also
+// accepted and means "use the global stream grab interval".
+const (
+	MinStreamGrabInterval = 10
+	MaxStreamGrabInterval = 24 * 60 * 60
+)
+
+// MaxStreamGrabTimeout bounds a stream's capture timeout, in seconds.
+// Zero means the built-in default (utils.DefaultGrabTimeout).
+const MaxStreamGrabTimeout = 120
+
+// streamSchedule is the capture-schedule part of the add/update stream
+// payloads. The light condition needs both a sensor and a threshold;
+// sending neither clears it.
+type streamSchedule struct {
+	GrabInterval   int      `json:"grab_interval"`
+	ActiveStart    string   `json:"active_start"`
+	ActiveEnd      string   `json:"active_end"`
+	LightSensorID  *uint    `json:"light_sensor_id"`
+	LightThreshold *float64 `json:"light_threshold"`
+}
+
+// validate returns the API error key describing the first problem with
+// the schedule, or "" when it is acceptable.
+func (s streamSchedule) validate(db *sql.DB) string {
+	if s.GrabInterval != 0 && (s.GrabInterval < MinStreamGrabInterval || s.GrabInterval > MaxStreamGrabInterval) {
+		return "api_invalid_grab_interval"
+	}
+	if utils.ValidateClock("active_start", s.ActiveStart) != nil || utils.ValidateClock("active_end", s.ActiveEnd) != nil ||
+		(s.ActiveStart == "") != (s.ActiveEnd == "") {
+		return "api_invalid_active_window"
+	}
+	if (s.LightSensorID == nil) != (s.LightThreshold == nil) {
+		return "api_invalid_light_condition"
+	}
+	if s.LightThreshold != nil && utils.ValidateFiniteFloat64("light_threshold", *s.LightThreshold) != nil {
+		return "api_invalid_light_condition"
+	}
+	if s.LightSensorID != nil {
+		var exists int
+		if err := db.QueryRow("SELECT COUNT(*) FROM sensors WHERE id = $1", *s.LightSensorID).Scan(&exists); err != nil || exists == 0 {
+			return "api_sensor_not_found"
+		}
+	}
+	return ""
+}
+
+// streamCapture is the credential and FFmpeg part of the add/update
+// stream payloads. A nil Password keeps the stored one on update; an
+// empty string clears it.
+type streamCapture struct {
+	Username      string  `json:"username"`
+	Password      *string `json:"password"`
+	RTSPTransport string  `json:"rtsp_transport"`
+	GrabTimeout   int     `json:"grab_timeout"`
+}
+
+// normalize moves any credentials embedded in rawURL into the payload,
+// unless the payload sets them explicitly, and returns the bare URL so
+// the password is never stored in (or logged with) the URL. A blank
+// transport becomes TCP.
+func (s *streamCapture) normalize(rawURL string) string {
+	bare, username, password := utils.SplitStreamCredentials(rawURL)
+	if s.Username == "" {
+		s.Username = username
+	}
+	if s.Password == nil && password != "" {
+		s.Password = &password
+	}
+	if s.RTSPTransport == "" {
+		s.RTSPTransport = utils.RTSPTransportTCP
+	}
+	return bare
+}
+
+// validate returns the API error key describing the first problem with
+// the capture options, or "" when they are acceptable.
+func (s streamCapture) validate() string {
+	if utils.ValidateStringLength("username", s.Username, utils.MaxNameLength) != nil ||
+		(s.Password != nil && utils.ValidateStringLength("password", *s.Password, utils.MaxNameLength) != nil) {
+		return "api_invalid_stream_credentials"
+	}
+	if s.RTSPTransport != utils.RTSPTransportTCP && s.RTSPTransport != utils.RTSPTransportUDP {
+		return "api_invalid_rtsp_transport"
+	}
+	if s.GrabTimeout < 0 || s.GrabTimeout > MaxStreamGrabTimeout {
+		return "api_invalid_grab_timeout"
+	}
+	return ""
+}
+
 func GetStreams(db *sql.DB) []types.Stream {
 	streams := []types.Stream{}
 	fieldLogger := logger.Log.WithField("func", "GetStreams")
-	rows, err := db.Query("SELECT s.id, s.name, url, zone_id, visible, z.name as zone_name FROM streams s left outer join zones z on s.zone_id = z.id")
+	rows, err := db.Query(`SELECT s.id, s.name, url, zone_id, visible, z.name as zone_name, s.archive_enabled, s.archive_max_frames, s.archive_max_mb,
+		s.grab_interval, s.active_start, s.active_end, s.light_sensor_id, s.light_threshold,
+		s.username, s.password, s.rtsp_transport, s.grab_timeout FROM streams s left outer join zones z on s.zone_id = z.id`)
 	if err != nil {
 		fieldLogger.WithError(err).Error("Failed to read stream")
 		return streams
@@ -25,14 +119,28 @@ func GetStreams(db *sql.DB) []types.Stream {
 	stream := types.Stream{}
 	for rows.Next() {
 		var id, zoneID uint
-		var visible bool
-		var name, url, zoneName string
-		err = rows.Scan(&id, &name, &url, &zoneID, &visible, &zoneName)
+		var visible, archiveEnabled bool
+		var archiveMaxFrames, archiveMaxMB, grabInterval, grabTimeout int
+		var name, url, zoneName, activeStart, activeEnd, username, password, rtspTransport string
+		var lightSensorID sql.NullInt64
+		var lightThreshold sql.NullFloat64
+		err = rows.Scan(&id, &name, &url, &zoneID, &visible, &zoneName, &archiveEnabled, &archiveMaxFrames, &archiveMaxMB,
+			&grabInterval, &activeStart, &activeEnd, &lightSensorID, &lightThreshold,
+			&username, &password, &rtspTransport, &grabTimeout)
 		if err != nil {
 			fieldLogger.WithError(err).Error("Failed to read stream")
 			continue
 		}
-		stream = types.Stream{ID: id, Name: name, URL: url, ZoneID: zoneID, ZoneName: zoneName, Visible: visible}
+		stream = types.Stream{ID: id, Name: name, URL: url, ZoneID: zoneID, ZoneName: zoneName, Visible: visible,
+			ArchiveEnabled: archiveEnabled, ArchiveMaxFrames: archiveMaxFrames, ArchiveMaxMB: archiveMaxMB,
+			GrabInterval: grabInterval, ActiveStart: activeStart, ActiveEnd: activeEnd,
+			Username: username, Password: password, HasPassword: password != "", RTSPTransport: rtspTransport, GrabTimeout: grabTimeout}
+		if lightSensorID.Valid && lightThreshold.Valid {
+			sensorID := uint(lightSensorID.Int64)
+			threshold := lightThreshold.Float64
+			stream.LightSensorID = &sensorID
+			stream.LightThreshold = &threshold
+		}
 		streams = append(streams, stream)
 	}
 
@@ -46,6 +154,13 @@ func AddStreamHandler(c *gin.Context) {
 		URL     string `json:"url"`
 		ZoneID  string `json:"zone_id"`
 		Visible bool   `json:"visible"`
+
+		ArchiveEnabled   bool `json:"archive_enabled"`
+		ArchiveMaxFrames int  `json:"archive_max_frames"`
+		ArchiveMaxMB     int  `json:"archive_max_mb"`
+
+		streamSchedule
+		streamCapture
 	}
 	if err := c.ShouldBindJSON(&stream); err != nil {
 		fieldLogger.WithError(err).Error("Failed to add stream")
@@ -60,13 +175,35 @@ func AddStreamHandler(c *gin.Context) {
 		apiBadRequest(c, err.Error())
 		return
 	}
+	stream.URL = stream.normalize(stream.URL)
+	if key := stream.streamCapture.validate(); key != "" {
+		apiBadRequest(c, key)
+		return
+	}
+	if stream.ArchiveMaxFrames < 0 || stream.ArchiveMaxMB < 0 {
+		apiBadRequest(c, "api_invalid_archive_budget")
+		return
+	}
 
 	// Add stream to database
 	db := DBFromContext(c)
+	if key := stream.streamSchedule.validate(db); key != "" {
+		apiBadRequest(c, key)
+		return
+	}
 
 	// Insert new stream and return new id
 	var id int
-	err := db.QueryRow("INSERT INTO streams (name, url, zone_id, visible) VALUES ($1, $2, $3, $4) RETURNING id", stream.Name, stream.URL, stream.ZoneID, stream.Visible).Scan(&id)
+	password := ""
+	if stream.Password != nil {
+		password = *stream.Password
+	}
+	err := db.QueryRow(`INSERT INTO streams (name, url, zone_id, visible, archive_enabled, archive_max_frames, archive_max_mb,
+		grab_interval, active_start, active_end, light_sensor_id, light_threshold, username, password, rtsp_transport, grab_timeout)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16) RETURNING id`,
+		stream.Name, stream.URL, stream.ZoneID, stream.Visible, stream.ArchiveEnabled, stream.ArchiveMaxFrames, stream.ArchiveMaxMB,
+		stream.GrabInterval, stream.ActiveStart, stream.ActiveEnd, stream.LightSensorID, stream.LightThreshold,
+		stream.Username, password, stream.RTSPTransport, stream.GrabTimeout).Scan(&id)
 	if err != nil {
 		fieldLogger.WithError(err).Error("Failed to add stream")
 		apiInternalError(c, "api_failed_to_add_stream")
@@ -78,7 +215,12 @@ func AddStreamHandler(c *gin.Context) {
 
 	latestFileName := fmt.Sprintf("stream_%d_latest%s", id, filepath.Ext(".jpg"))
 	latestSavePath := filepath.Join(StreamDirFromContext(c), latestFileName)
-	utils.GrabWebcamImage(stream.URL, latestSavePath)
+	utils.GrabStreamImage(stream.URL, latestSavePath, utils.GrabOptions{
+		Username:      stream.Username,
+		Password:      password,
+		RTSPTransport: stream.RTSPTransport,
+		Timeout:       time.Duration(stream.GrabTimeout) * time.Second,
+	})
 
 	c.JSON(http.StatusCreated, gin.H{"id": id, "streams": streams})
 }
@@ -91,6 +233,13 @@ func UpdateStreamHandler(c *gin.Context) {
 		URL     string `json:"url"`
 		ZoneID  int    `json:"zone_id"`
 		Visible bool   `json:"visible"`
+
+		ArchiveEnabled   bool `json:"archive_enabled"`
+		ArchiveMaxFrames int  `json:"archive_max_frames"`
+		ArchiveMaxMB     int  `json:"archive_max_mb"`
+
+		streamSchedule
+		streamCapture
 	}
 	if err := c.ShouldBindJSON(&stream); err != nil {
 		fieldLogger.WithError(err).Error("Failed to update stream")
@@ -105,9 +254,22 @@ func UpdateStreamHandler(c *gin.Context) {
 		apiBadRequest(c, err.Error())
 		return
 	}
+	stream.URL = stream.normalize(stream.URL)
+	if key := stream.streamCapture.validate(); key != "" {
+		apiBadRequest(c, key)
+		return
+	}
+	if stream.ArchiveMaxFrames < 0 || stream.ArchiveMaxMB < 0 {
+		apiBadRequest(c, "api_invalid_archive_budget")
+		return
+	}
 
 	// Update stream in database
 	db := DBFromContext(c)
+	if key := stream.streamSchedule.validate(db); key != "" {
+		apiBadRequest(c, key)
+		return
+	}
 
 	//convert visible to int
 	var visibleInt int
@@ -118,7 +280,12 @@ func UpdateStreamHandler(c *gin.Context) {
 	}
 
 	// Update stream in database
-	_, err := db.Exec("UPDATE streams SET name = $1, url = $2, zone_id = $3, visible = $4 WHERE id = $5", stream.Name, stream.URL, stream.ZoneID, visibleInt, id)
+	_, err := db.Exec(`UPDATE streams SET name = $1, url = $2, zone_id = $3, visible = $4, archive_enabled = $5, archive_max_frames = $6, archive_max_mb = $7,
+		grab_interval = $8, active_start = $9, active_end = $10, light_sensor_id = $11, light_threshold = $12,
+		username = $13, password = COALESCE($14, password), rtsp_transport = $15, grab_timeout = $16 WHERE id = $17`,
+		stream.Name, stream.URL, stream.ZoneID, visibleInt, stream.ArchiveEnabled, stream.ArchiveMaxFrames, stream.ArchiveMaxMB,
+		stream.GrabInterval, stream.ActiveStart, stream.ActiveEnd, stream.LightSensorID, stream.LightThreshold,
+		stream.Username, stream.Password, stream.RTSPTransport, stream.GrabTimeout, id)
 	if err != nil {
 		fieldLogger.WithError(err).Error("Failed to update stream")
 		apiInternalError(c, "api_failed_to_update_stream")
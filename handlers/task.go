@@ -0,0 +1,677 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"isley/logger"
+	"isley/model/types"
+	"isley/utils"
+)
+
+// Task schedules; see types.Task.
+const (
+	TaskScheduleOnce     = "once"
+	TaskScheduleInterval = "interval"
+	TaskScheduleStage    = "stage"
+)
+
+// TaskSchedules lists the schedules in the order the task form offers
+// them.
+var TaskSchedules = []string{TaskScheduleOnce, TaskScheduleInterval, TaskScheduleStage}
+
+const (
+	// MaxTaskDays bounds a task's interval and stage day.
+	MaxTaskDays = 365
+	// DefaultTaskHorizonDays is how far ahead the due list looks.
+	DefaultTaskHorizonDays = 7
+	// MaxTaskHorizonDays bounds the days parameter of the due list.
+	MaxTaskHorizonDays = 90
+)
+
+// TasksPageContext is everything views/tasks.html renders.
+type TasksPageContext struct {
+	Due     []types.DueTask
+	Tasks   []types.Task
+	Plants  []types.PlantListResponse
+	Runs    []types.GrowRun
+	Horizon int
+}
+
+// BuildTasksPageContext loads the due list and the task list for the
+// tasks page.
+func BuildTasksPageContext(c *gin.Context) (TasksPageContext, error) {
+	ctx := TasksPageContext{Horizon: DefaultTaskHorizonDays}
+	db := DBFromContext(c)
+	var err error
+	if ctx.Due, err = LoadDueTasks(db, taskToday(c), ctx.Horizon); err != nil {
+		return ctx, err
+	}
+	if ctx.Tasks, err = LoadTasks(db); err != nil {
+		return ctx, err
+	}
+	if ctx.Runs, err = loadGrowRuns(db, ""); err != nil {
+		return ctx, err
+	}
+	ctx.Plants = GetLivingPlants(db)
+	return ctx, nil
+}
+
+// taskToday is the current date in the app timezone.
+func taskToday(c *gin.Context) time.Time {
+	return time.Now().In(AppTimeLocation(ConfigStoreFromContext(c).Timezone()))
+}
+
+// dayOf drops the time of day, keeping the date digits. Stored dates are
+// naive local wall clocks, so days are compared on their digits.
+func dayOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+const taskColumns = `t.id, t.title, t.notes, t.activity_id, COALESCE(a.name, ''),
+	t.plant_id, t.zone_id, t.grow_run_id, COALESCE(p.name, z.name, r.name, ''),
+	t.schedule, t.due_date, t.interval_days, t.stage_status_id, COALESCE(ps.status, ''), t.stage_day,
+	t.last_done, t.completed_at`
+
+const taskJoins = `FROM tasks t
+	LEFT JOIN activity a ON a.id = t.activity_id
+	LEFT JOIN plant p ON p.id = t.plant_id
+	LEFT JOIN zones z ON z.id = t.zone_id
+	LEFT JOIN grow_runs r ON r.id = t.grow_run_id
+	LEFT JOIN plant_status ps ON ps.id = t.stage_status_id`
+
+func scanTask(row interface{ Scan(...interface{}) error }) (types.Task, error) {
+	var t types.Task
+	var activityID, plantID, zoneID, runID, interval, stageID, stageDay sql.NullInt64
+	var due, lastDone, completed sql.NullTime
+	err := row.Scan(&t.ID, &t.Title, &t.Notes, &activityID, &t.ActivityName,
+		&plantID, &zoneID, &runID, &t.TargetName,
+		&t.Schedule, &due, &interval, &stageID, &t.StageStatus, &stageDay,
+		&lastDone, &completed)
+	if err != nil {
+		return t, err
+	}
+	t.ActivityID, t.PlantID, t.ZoneID, t.GrowRunID = nullIntPtr(activityID), nullIntPtr(plantID), nullIntPtr(zoneID), nullIntPtr(runID)
+	t.IntervalDays, t.StageStatusID, t.StageDay = nullIntPtr(interval), nullIntPtr(stageID), nullIntPtr(stageDay)
+	t.DueDate, t.LastDone, t.CompletedAt = nullLocalTimePtr(due), nullLocalTimePtr(lastDone), nullLocalTimePtr(completed)
+	return t, nil
+}
+
+// LoadTasks returns every task, open ones first, sorted by title.
+func LoadTasks(db *sql.DB) ([]types.Task, error) {
+	rows, err := db.Query(`SELECT ` + taskColumns + ` ` + taskJoins + `
+		ORDER BY CASE WHEN t.completed_at IS NULL THEN 0 ELSE 1 END, LOWER(t.title), t.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tasks := []types.Task{}
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, rows.Err()
+}
+
+// LoadTask returns one task, or nil when it doesn't exist.
+func LoadTask(db *sql.DB, id int) (*types.Task, error) {
+	t, err := scanTask(db.QueryRow(`SELECT `+taskColumns+` `+taskJoins+` WHERE t.id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// taskPlant is a living plant with the day its current stage began.
+type taskPlant struct {
+	id         int
+	name       string
+	zoneID     int
+	growRunID  int
+	statusID   int
+	stageStart time.Time
+}
+
+// loadTaskPlants returns every plant whose current status is active.
+func loadTaskPlants(db *sql.DB) ([]taskPlant, error) {
+	rows, err := db.Query(`SELECT p.id, p.name, COALESCE(p.zone_id, 0), COALESCE(p.grow_run_id, 0),
+			psl.status_id, ps.active, psl.date
+		FROM plant p
+		JOIN plant_status_log psl ON psl.plant_id = p.id
+		JOIN plant_status ps ON ps.id = psl.status_id
+		ORDER BY p.id, psl.date, psl.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var plants []taskPlant
+	var active []bool
+	for rows.Next() {
+		var p taskPlant
+		var isActive bool
+		var at time.Time
+		if err := rows.Scan(&p.id, &p.name, &p.zoneID, &p.growRunID, &p.statusID, &isActive, &at); err != nil {
+			return nil, err
+		}
+		p.stageStart = dayOf(at)
+		// Rows come in date order, so the last one per plant is its
+		// current status.
+		if n := len(plants); n > 0 && plants[n-1].id == p.id {
+			plants[n-1], active[n-1] = p, isActive
+			continue
+		}
+		plants = append(plants, p)
+		active = append(active, isActive)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	living := plants[:0]
+	for i, p := range plants {
+		if active[i] {
+			living = append(living, p)
+		}
+	}
+	return living, nil
+}
+
+// taskScope returns the living plants a task applies to.
+func taskScope(t types.Task, plants []taskPlant) []taskPlant {
+	var scope []taskPlant
+	for _, p := range plants {
+		switch {
+		case t.PlantID != nil && p.id == *t.PlantID,
+			t.ZoneID != nil && p.zoneID == *t.ZoneID,
+			t.GrowRunID != nil && p.growRunID == *t.GrowRunID:
+			scope = append(scope, p)
+		}
+	}
+	return scope
+}
+
+// nextTaskDue is when a once or interval task is next due, or false when
+// it isn't.
+func nextTaskDue(t types.Task) (time.Time, bool) {
+	if t.CompletedAt != nil || t.DueDate == nil {
+		return time.Time{}, false
+	}
+	due := dayOf(*t.DueDate)
+	if t.Schedule == TaskScheduleInterval && t.LastDone != nil && t.IntervalDays != nil {
+		if next := dayOf(*t.LastDone).AddDate(0, 0, *t.IntervalDays); next.After(due) {
+			due = next
+		}
+	}
+	return due, true
+}
+
+// stageCompletions returns, per stage task and plant, the day it was last
+// completed.
+func stageCompletions(db *sql.DB) (map[[2]int]time.Time, error) {
+	rows, err := db.Query(`SELECT c.task_id, c.plant_id, c.done_at FROM task_completions c
+		JOIN tasks t ON t.id = c.task_id
+		WHERE t.schedule = $1 AND c.plant_id IS NOT NULL`, TaskScheduleStage)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	done := map[[2]int]time.Time{}
+	for rows.Next() {
+		var taskID, plantID int
+		var at time.Time
+		if err := rows.Scan(&taskID, &plantID, &at); err != nil {
+			return nil, err
+		}
+		key := [2]int{taskID, plantID}
+		if day := dayOf(at); day.After(done[key]) {
+			done[key] = day
+		}
+	}
+	return done, rows.Err()
+}
+
+// LoadDueTasks returns the tasks due on or before horizon days after
+// today, overdue ones first. A stage task is due for each plant in its
+// scope that is in the stage and hasn't had the task done since entering
+// it.
+func LoadDueTasks(db *sql.DB, today time.Time, horizon int) ([]types.DueTask, error) {
+	tasks, err := LoadTasks(db)
+	if err != nil {
+		return nil, err
+	}
+	plants, err := loadTaskPlants(db)
+	if err != nil {
+		return nil, err
+	}
+	done, err := stageCompletions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	day := dayOf(today)
+	until := day.AddDate(0, 0, horizon)
+	due := []types.DueTask{}
+	add := func(t types.Task, at time.Time, plant *taskPlant) {
+		if at.After(until) {
+			return
+		}
+		d := types.DueTask{
+			TaskID:       t.ID,
+			Title:        t.Title,
+			Notes:        t.Notes,
+			Schedule:     t.Schedule,
+			ActivityName: t.ActivityName,
+			TargetName:   t.TargetName,
+			DueDate:      utils.AsLocal(at),
+			DaysOverdue:  daysBetween(at, day),
+		}
+		if plant != nil {
+			d.PlantID, d.PlantName = plant.id, plant.name
+		}
+		due = append(due, d)
+	}
+	for _, t := range tasks {
+		if t.Schedule != TaskScheduleStage {
+			if at, ok := nextTaskDue(t); ok {
+				add(t, at, nil)
+			}
+			continue
+		}
+		if t.StageStatusID == nil || t.StageDay == nil {
+			continue
+		}
+		for _, p := range taskScope(t, plants) {
+			if p.statusID != *t.StageStatusID {
+				continue
+			}
+			if last, ok := done[[2]int{t.ID, p.id}]; ok && !last.Before(p.stageStart) {
+				continue
+			}
+			add(t, p.stageStart.AddDate(0, 0, *t.StageDay-1), &p)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		if !due[i].DueDate.Equal(due[j].DueDate) {
+			return due[i].DueDate.Before(due[j].DueDate)
+		}
+		return strings.ToLower(due[i].Title) < strings.ToLower(due[j].Title)
+	})
+	return due, nil
+}
+
+// ListTasks returns every task.
+func ListTasks(c *gin.Context) {
+	tasks, err := LoadTasks(DBFromContext(c))
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "ListTasks").Error("Failed to load tasks")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusOK, tasks)
+}
+
+// ListDueTasks returns the due list, looking ahead the days query
+// parameter (default DefaultTaskHorizonDays).
+func ListDueTasks(c *gin.Context) {
+	horizon := DefaultTaskHorizonDays
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > MaxTaskHorizonDays {
+			apiBadRequest(c, "days must be between 0 and "+strconv.Itoa(MaxTaskHorizonDays))
+			return
+		}
+		horizon = n
+	}
+	due, err := LoadDueTasks(DBFromContext(c), taskToday(c), horizon)
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "ListDueTasks").Error("Failed to load due tasks")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusOK, due)
+}
+
+// taskParam parses the :id route parameter, sending a 400 when it is
+// malformed.
+func taskParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apiBadRequest(c, "api_invalid_request")
+		return 0, false
+	}
+	return id, true
+}
+
+// taskInput is the body of the task create and update requests. Exactly
+// one of PlantID, ZoneID and GrowRunID is set.
+type taskInput struct {
+	Title         string `json:"title"`
+	Notes         string `json:"notes"`
+	ActivityID    *int   `json:"activity_id"`
+	PlantID       *int   `json:"plant_id"`
+	ZoneID        *int   `json:"zone_id"`
+	GrowRunID     *int   `json:"grow_run_id"`
+	Schedule      string `json:"schedule"`
+	DueDate       string `json:"due_date"`
+	IntervalDays  *int   `json:"interval_days"`
+	StageStatusID *int   `json:"stage_status_id"`
+	StageDay      *int   `json:"stage_day"`
+}
+
+// positiveOrNil treats missing and non-positive IDs alike.
+func positiveOrNil(v *int) *int {
+	if v == nil || *v <= 0 {
+		return nil
+	}
+	return v
+}
+
+func validateTaskDays(field string, v *int) error {
+	if v == nil || *v < 1 || *v > MaxTaskDays {
+		return errors.New(field + " must be between 1 and " + strconv.Itoa(MaxTaskDays))
+	}
+	return nil
+}
+
+func (in *taskInput) validate() error {
+	in.Title = strings.TrimSpace(in.Title)
+	if err := utils.ValidateRequiredString("title", in.Title, utils.MaxNameLength); err != nil {
+		return err
+	}
+	if err := utils.ValidateStringLength("notes", in.Notes, utils.MaxNotesLength); err != nil {
+		return err
+	}
+	in.ActivityID = positiveOrNil(in.ActivityID)
+	in.PlantID, in.ZoneID, in.GrowRunID = positiveOrNil(in.PlantID), positiveOrNil(in.ZoneID), positiveOrNil(in.GrowRunID)
+	targets := 0
+	for _, id := range []*int{in.PlantID, in.ZoneID, in.GrowRunID} {
+		if id != nil {
+			targets++
+		}
+	}
+	if targets != 1 {
+		return errors.New("a task needs exactly one of plant_id, zone_id or grow_run_id")
+	}
+	if in.Schedule == "" {
+		in.Schedule = TaskScheduleOnce
+	}
+	if !slices.Contains(TaskSchedules, in.Schedule) {
+		return errors.New("schedule must be one of " + strings.Join(TaskSchedules, ", "))
+	}
+
+	// Fields the schedule doesn't use are dropped.
+	switch in.Schedule {
+	case TaskScheduleOnce:
+		in.IntervalDays, in.StageStatusID, in.StageDay = nil, nil, nil
+	case TaskScheduleInterval:
+		in.StageStatusID, in.StageDay = nil, nil
+		if err := validateTaskDays("interval_days", in.IntervalDays); err != nil {
+			return err
+		}
+	case TaskScheduleStage:
+		in.DueDate, in.IntervalDays = "", nil
+		if in.StageStatusID = positiveOrNil(in.StageStatusID); in.StageStatusID == nil {
+			return errors.New("stage_status_id is required")
+		}
+		return validateTaskDays("stage_day", in.StageDay)
+	}
+	return validateRequiredDate("due_date", in.DueDate)
+}
+
+// bindTaskInput binds and validates a task body, checking that what it
+// refers to exists. It sends the error response itself.
+func bindTaskInput(c *gin.Context) (taskInput, bool) {
+	var in taskInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		apiBadRequest(c, "api_invalid_input")
+		return in, false
+	}
+	if err := in.validate(); err != nil {
+		apiBadRequest(c, err.Error())
+		return in, false
+	}
+	db := DBFromContext(c)
+	for _, ref := range []struct {
+		id       *int
+		table    string
+		errorKey string
+	}{
+		{in.ActivityID, "activity", "api_activity_not_found"},
+		{in.PlantID, "plant", "api_plant_not_found"},
+		{in.ZoneID, "zones", "api_zone_not_found"},
+		{in.GrowRunID, "grow_runs", "api_grow_run_not_found"},
+		{in.StageStatusID, "plant_status", "api_status_not_found"},
+	} {
+		if ref.id == nil {
+			continue
+		}
+		found, err := rowExists(db, ref.table, *ref.id)
+		if err != nil {
+			logger.Log.WithError(err).WithField("table", ref.table).Error("Failed to look up task reference")
+			apiInternalError(c, "api_database_error")
+			return in, false
+		}
+		if !found {
+			apiBadRequest(c, ref.errorKey)
+			return in, false
+		}
+	}
+	return in, true
+}
+
+// AddTask creates a task.
+func AddTask(c *gin.Context) {
+	in, ok := bindTaskInput(c)
+	if !ok {
+		return
+	}
+	var id int
+	err := DBFromContext(c).QueryRow(`INSERT INTO tasks (title, notes, activity_id, plant_id, zone_id, grow_run_id,
+			schedule, due_date, interval_days, stage_status_id, stage_day)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`,
+		in.Title, in.Notes, in.ActivityID, in.PlantID, in.ZoneID, in.GrowRunID,
+		in.Schedule, nullDateArg(in.DueDate), in.IntervalDays, in.StageStatusID, in.StageDay).Scan(&id)
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "AddTask").Error("Failed to create task")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id, "message": T(c, "api_task_saved")})
+}
+
+// UpdateTask replaces a task's details. Editing a completed one-off task
+// reopens it.
+func UpdateTask(c *gin.Context) {
+	id, ok := taskParam(c)
+	if !ok {
+		return
+	}
+	in, ok := bindTaskInput(c)
+	if !ok {
+		return
+	}
+	res, err := DBFromContext(c).Exec(`UPDATE tasks SET title = $1, notes = $2, activity_id = $3, plant_id = $4,
+			zone_id = $5, grow_run_id = $6, schedule = $7, due_date = $8, interval_days = $9,
+			stage_status_id = $10, stage_day = $11, completed_at = NULL, update_dt = CURRENT_TIMESTAMP
+		WHERE id = $12`,
+		in.Title, in.Notes, in.ActivityID, in.PlantID, in.ZoneID, in.GrowRunID,
+		in.Schedule, nullDateArg(in.DueDate), in.IntervalDays, in.StageStatusID, in.StageDay, id)
+	if err != nil {
+		logger.Log.WithError(err).WithField("func", "UpdateTask").Error("Failed to update task")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		apiNotFound(c, "api_task_not_found")
+		return
+	}
+	apiOK(c, "api_task_saved")
+}
+
+// DeleteTask deletes a task and its completions. Activities it logged are
+// kept.
+func DeleteTask(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "DeleteTask")
+	id, ok := taskParam(c)
+	if !ok {
+		return
+	}
+	tx, err := DBFromContext(c).Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+	if _, err := tx.Exec("DELETE FROM task_completions WHERE task_id = $1", id); err != nil {
+		fieldLogger.WithError(err).Error("Failed to delete task completions")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	res, err := tx.Exec("DELETE FROM tasks WHERE id = $1", id)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to delete task")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		apiNotFound(c, "api_task_not_found")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit task delete")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	apiOK(c, "api_task_deleted")
+}
+
+// CompleteTask marks a task done, logging its activity against its
+// plants: the plant given for a stage task, which must be in the stage,
+// otherwise the task's plant or the living plants of its zone or run. A one-off task is closed; an
+// interval task next falls due interval_days after date.
+func CompleteTask(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "CompleteTask")
+	id, ok := taskParam(c)
+	if !ok {
+		return
+	}
+	var in struct {
+		PlantID *int   `json:"plant_id"`
+		Date    string `json:"date"`
+		Note    string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		apiBadRequest(c, "api_invalid_input")
+		return
+	}
+	if err := utils.ValidateStringLength("note", in.Note, utils.MaxNotesLength); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
+	if err := utils.ValidateDate("date", in.Date); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
+	if in.Date == "" {
+		in.Date = taskToday(c).Format(utils.LayoutDB)
+	}
+
+	db := DBFromContext(c)
+	task, err := LoadTask(db, id)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to load task")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if task == nil {
+		apiNotFound(c, "api_task_not_found")
+		return
+	}
+	if task.CompletedAt != nil {
+		apiBadRequest(c, "api_task_already_completed")
+		return
+	}
+	living, err := loadTaskPlants(db)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to load plants")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	plants := taskScope(*task, living)
+	var completedPlant *int
+	if task.Schedule == TaskScheduleStage {
+		if in.PlantID == nil && task.PlantID != nil {
+			in.PlantID = task.PlantID
+		}
+		var plant *taskPlant
+		for i := range plants {
+			if in.PlantID != nil && plants[i].id == *in.PlantID && plants[i].statusID == *task.StageStatusID {
+				plant = &plants[i]
+			}
+		}
+		if plant == nil {
+			apiBadRequest(c, "api_task_plant_required")
+			return
+		}
+		plants, completedPlant = []taskPlant{*plant}, &plant.id
+	}
+
+	note := in.Note
+	if note == "" {
+		note = task.Title
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() // no-op after Commit
+	if task.ActivityID != nil {
+		for _, p := range plants {
+			if err := createPlantActivity(tx, p.id, *task.ActivityID, note, in.Date, nil, nil); err != nil {
+				fieldLogger.WithError(err).WithField("plant", p.id).Error("Failed to log task activity")
+				apiInternalError(c, "api_database_error")
+				return
+			}
+		}
+	}
+	if _, err := tx.Exec(`INSERT INTO task_completions (task_id, plant_id, done_at, note) VALUES ($1, $2, $3, $4)`,
+		id, completedPlant, in.Date, in.Note); err != nil {
+		fieldLogger.WithError(err).Error("Failed to record task completion")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	switch task.Schedule {
+	case TaskScheduleOnce:
+		_, err = tx.Exec("UPDATE tasks SET completed_at = $1, update_dt = CURRENT_TIMESTAMP WHERE id = $2", in.Date, id)
+	case TaskScheduleInterval:
+		_, err = tx.Exec("UPDATE tasks SET last_done = $1, update_dt = CURRENT_TIMESTAMP WHERE id = $2", in.Date, id)
+	}
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to update task")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit task completion")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	apiOK(c, "api_task_completed")
+}
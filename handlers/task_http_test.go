@@ -0,0 +1,349 @@
+package handlers_test
+
+// HTTP-layer tests for handlers/task.go: task CRUD and validation, the
+// due list for each schedule, and completing tasks into plant activities.
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"isley/tests/testutil"
+	"isley/utils"
+)
+
+type dueTaskResponse struct {
+	TaskID      int    `json:"task_id"`
+	Title       string `json:"title"`
+	PlantID     int    `json:"plant_id"`
+	PlantName   string `json:"plant_name"`
+	DueDate     string `json:"due_date"`
+	DaysOverdue int    `json:"days_overdue"`
+}
+
+// createTask posts a task and returns its ID.
+func createTask(t *testing.T, c *testutil.Client, apiKey string, body map[string]interface{}) int {
+	t.Helper()
+	return createNutrientRow(t, c, apiKey, "/tasks", body)
+}
+
+func getDueTasks(t *testing.T, c *testutil.Client, days int) []dueTaskResponse {
+	t.Helper()
+	resp := c.Get("/tasks/due?days=" + strconv.Itoa(days))
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var due []dueTaskResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&due))
+	return due
+}
+
+// findDue returns the due entry for a task (and plant, for stage tasks),
+// or nil.
+func findDue(due []dueTaskResponse, taskID, plantID int) *dueTaskResponse {
+	for i := range due {
+		if due[i].TaskID == taskID && due[i].PlantID == plantID {
+			return &due[i]
+		}
+	}
+	return nil
+}
+
+// seedTaskPlants creates three plants in one zone: two that entered
+// Flower 25 and 10 days ago and one in Veg.
+func seedTaskPlants(t *testing.T, db *sql.DB) (zoneID, early, late, veg int) {
+	t.Helper()
+	strainID := testutil.SeedStrain(t, db, testutil.SeedBreeder(t, db, "B"), "S")
+	zoneID = testutil.SeedZone(t, db, "Tent")
+	early = testutil.SeedPlant(t, db, "Early", strainID, zoneID)
+	late = testutil.SeedPlant(t, db, "Late", strainID, zoneID)
+	veg = testutil.SeedPlant(t, db, "Young", strainID, zoneID)
+	vegID, flowerID := plantStatusID(t, db, "Veg"), plantStatusID(t, db, "Flower")
+	for _, p := range []int{early, late, veg} {
+		testutil.MustExec(t, db, `INSERT INTO plant_status_log (plant_id, status_id, date) VALUES ($1, $2, $3)`, p, vegID, testutil.DaysAgo(60))
+	}
+	testutil.MustExec(t, db, `INSERT INTO plant_status_log (plant_id, status_id, date) VALUES ($1, $2, $3)`, early, flowerID, testutil.DaysAgo(25))
+	testutil.MustExec(t, db, `INSERT INTO plant_status_log (plant_id, status_id, date) VALUES ($1, $2, $3)`, late, flowerID, testutil.DaysAgo(10))
+	return zoneID, early, late, veg
+}
+
+// ---------------------------------------------------------------------------
+// AddTask / UpdateTask / DeleteTask
+// ---------------------------------------------------------------------------
+
+func TestTaskHTTP_CRUDAndValidation(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "task-crud-key")
+	plantID := seedImagePlant(t, db)
+	zoneID := testutil.SeedZone(t, db, "Other")
+	c := server.NewClient(t)
+
+	id := createTask(t, c, apiKey, map[string]interface{}{
+		"title": "Repot", "plant_id": plantID, "due_date": "2026-05-01",
+	})
+
+	resp := c.Get("/tasks/list")
+	var tasks []struct {
+		ID         int    `json:"id"`
+		Title      string `json:"title"`
+		Schedule   string `json:"schedule"`
+		TargetName string `json:"target_name"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&tasks))
+	testutil.DrainAndClose(resp)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "once", tasks[0].Schedule, "schedule defaults to once")
+	assert.Equal(t, "Plant 1", tasks[0].TargetName)
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/tasks/"+strconv.Itoa(id), apiKey, map[string]interface{}{
+		"title": "Flush", "zone_id": zoneID, "schedule": "interval", "interval_days": 3, "due_date": "2026-05-02",
+	}), http.StatusOK)
+	var title, schedule string
+	var interval sql.NullInt64
+	var plant sql.NullInt64
+	require.NoError(t, db.QueryRow(`SELECT title, schedule, interval_days, plant_id FROM tasks WHERE id = $1`, id).
+		Scan(&title, &schedule, &interval, &plant))
+	assert.Equal(t, "Flush", title)
+	assert.Equal(t, "interval", schedule)
+	assert.Equal(t, int64(3), interval.Int64)
+	assert.False(t, plant.Valid, "the task moved from the plant to the zone")
+
+	for name, body := range map[string]map[string]interface{}{
+		"no title":          {"plant_id": plantID, "due_date": "2026-05-01"},
+		"no target":         {"title": "X", "due_date": "2026-05-01"},
+		"two targets":       {"title": "X", "plant_id": plantID, "zone_id": zoneID, "due_date": "2026-05-01"},
+		"once without date": {"title": "X", "plant_id": plantID},
+		"bad date":          {"title": "X", "plant_id": plantID, "due_date": "soon"},
+		"unknown schedule":  {"title": "X", "plant_id": plantID, "schedule": "weekly", "due_date": "2026-05-01"},
+		"no interval":       {"title": "X", "plant_id": plantID, "schedule": "interval", "due_date": "2026-05-01"},
+		"long interval":     {"title": "X", "plant_id": plantID, "schedule": "interval", "interval_days": 400, "due_date": "2026-05-01"},
+		"no stage":          {"title": "X", "plant_id": plantID, "schedule": "stage", "stage_day": 21},
+		"no stage day":      {"title": "X", "plant_id": plantID, "schedule": "stage", "stage_status_id": plantStatusID(t, db, "Flower")},
+		"unknown plant":     {"title": "X", "plant_id": 9999, "due_date": "2026-05-01"},
+		"unknown activity":  {"title": "X", "plant_id": plantID, "activity_id": 9999, "due_date": "2026-05-01"},
+		"unknown status":    {"title": "X", "plant_id": plantID, "schedule": "stage", "stage_status_id": 9999, "stage_day": 1},
+	} {
+		resp := harvestRequest(t, c, http.MethodPost, "/tasks", apiKey, body)
+		testutil.DrainAndClose(resp)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+	}
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/tasks/9999", apiKey, map[string]interface{}{
+		"title": "X", "plant_id": plantID, "due_date": "2026-05-01",
+	}), http.StatusNotFound)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodDelete, "/tasks/"+strconv.Itoa(id), apiKey, nil), http.StatusOK)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodDelete, "/tasks/"+strconv.Itoa(id), apiKey, nil), http.StatusNotFound)
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodDelete, "/tasks/abc", apiKey, nil), http.StatusBadRequest)
+}
+
+// ---------------------------------------------------------------------------
+// LoadDueTasks
+// ---------------------------------------------------------------------------
+
+func TestTaskHTTP_DueListCoversEachSchedule(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "task-due-key")
+	zoneID, early, late, veg := seedTaskPlants(t, db)
+	flowerID := plantStatusID(t, db, "Flower")
+	c := server.NewClient(t)
+	today := time.Now().Format(utils.LayoutDate)
+	inDays := func(n int) string { return time.Now().AddDate(0, 0, n).Format(utils.LayoutDate) }
+
+	defoliate := createTask(t, c, apiKey, map[string]interface{}{
+		"title": "Defoliate", "zone_id": zoneID, "schedule": "stage", "stage_status_id": flowerID, "stage_day": 21,
+	})
+	topUp := createTask(t, c, apiKey, map[string]interface{}{
+		"title": "Top up reservoir", "zone_id": zoneID, "schedule": "interval", "interval_days": 7, "due_date": inDays(-3),
+	})
+	tomorrow := createTask(t, c, apiKey, map[string]interface{}{
+		"title": "Check runoff", "plant_id": veg, "due_date": inDays(1),
+	})
+	later := createTask(t, c, apiKey, map[string]interface{}{
+		"title": "Order soil", "plant_id": veg, "due_date": inDays(20),
+	})
+
+	due := getDueTasks(t, c, 7)
+	if d := findDue(due, defoliate, early); assert.NotNil(t, d, "day 21 of flower was 5 days ago for Early") {
+		assert.Equal(t, 5, d.DaysOverdue)
+		assert.Equal(t, "Early", d.PlantName)
+	}
+	assert.Nil(t, findDue(due, defoliate, late), "Late reaches day 21 after the horizon")
+	assert.Nil(t, findDue(due, defoliate, veg), "Young isn't in flower")
+	if d := findDue(due, topUp, 0); assert.NotNil(t, d) {
+		assert.Equal(t, 3, d.DaysOverdue)
+	}
+	if d := findDue(due, tomorrow, 0); assert.NotNil(t, d) {
+		assert.Equal(t, -1, d.DaysOverdue)
+		assert.Equal(t, inDays(1), d.DueDate[:10])
+	}
+	assert.Nil(t, findDue(due, later, 0))
+	require.Len(t, due, 3)
+	assert.Equal(t, defoliate, due[0].TaskID, "the most overdue comes first")
+
+	due = getDueTasks(t, c, 30)
+	if d := findDue(due, defoliate, late); assert.NotNil(t, d) {
+		assert.Equal(t, -10, d.DaysOverdue)
+	}
+	assert.NotNil(t, findDue(due, later, 0))
+
+	// A plant that moves on to the next stage drops off the stage task.
+	testutil.MustExec(t, db, `INSERT INTO plant_status_log (plant_id, status_id, date) VALUES ($1, $2, $3)`,
+		early, plantStatusID(t, db, "Drying"), today+" 08:00:00")
+	assert.Nil(t, findDue(getDueTasks(t, c, 7), defoliate, early))
+
+	resp := c.Get("/tasks/due?days=500")
+	testutil.DrainAndClose(resp)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+// ---------------------------------------------------------------------------
+// CompleteTask
+// ---------------------------------------------------------------------------
+
+func TestTaskHTTP_CompleteLogsActivityAndReschedules(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "task-complete-key")
+	zoneID, early, late, veg := seedTaskPlants(t, db)
+	waterID := testutil.ActivityIDByName(t, db, "Water")
+	noteID := testutil.ActivityIDByName(t, db, "Note")
+	c := server.NewClient(t)
+	inDays := func(n int) string { return time.Now().AddDate(0, 0, n).Format(utils.LayoutDate) }
+	complete := func(id int, body map[string]interface{}) int {
+		resp := harvestRequest(t, c, http.MethodPost, "/tasks/"+strconv.Itoa(id)+"/complete", apiKey, body)
+		testutil.DrainAndClose(resp)
+		return resp.StatusCode
+	}
+
+	// An interval task on a zone waters every living plant in it and next
+	// falls due a full interval after completion.
+	water := createTask(t, c, apiKey, map[string]interface{}{
+		"title": "Water tent", "zone_id": zoneID, "activity_id": waterID,
+		"schedule": "interval", "interval_days": 3, "due_date": inDays(-2),
+	})
+	require.Equal(t, http.StatusOK, complete(water, map[string]interface{}{"note": "2 litres each"}))
+	for _, p := range []int{early, late, veg} {
+		var activityID int
+		var note string
+		require.NoError(t, db.QueryRow(`SELECT activity_id, note FROM plant_activity WHERE id = $1`,
+			latestPlantActivityID(t, db, p)).Scan(&activityID, &note))
+		assert.Equal(t, waterID, activityID)
+		assert.Equal(t, "2 litres each", note)
+	}
+	if d := findDue(getDueTasks(t, c, 7), water, 0); assert.NotNil(t, d) {
+		assert.Equal(t, -3, d.DaysOverdue)
+	}
+
+	// A stage task is completed plant by plant.
+	defoliate := createTask(t, c, apiKey, map[string]interface{}{
+		"title": "Defoliate", "zone_id": zoneID, "activity_id": noteID,
+		"schedule": "stage", "stage_status_id": plantStatusID(t, db, "Flower"), "stage_day": 1,
+	})
+	assert.Equal(t, http.StatusBadRequest, complete(defoliate, map[string]interface{}{}), "a zone stage task needs a plant")
+	assert.Equal(t, http.StatusBadRequest, complete(defoliate, map[string]interface{}{"plant_id": veg}), "Young isn't in flower")
+	before := testutil.CountRows(t, db, `SELECT COUNT(*) FROM plant_activity WHERE plant_id = $1`, late)
+	require.Equal(t, http.StatusOK, complete(defoliate, map[string]interface{}{"plant_id": early}))
+	due := getDueTasks(t, c, 7)
+	assert.Nil(t, findDue(due, defoliate, early))
+	assert.NotNil(t, findDue(due, defoliate, late))
+	assert.Equal(t, before, testutil.CountRows(t, db, `SELECT COUNT(*) FROM plant_activity WHERE plant_id = $1`, late))
+	var note string
+	require.NoError(t, db.QueryRow(`SELECT note FROM plant_activity WHERE id = $1`, latestPlantActivityID(t, db, early)).Scan(&note))
+	assert.Equal(t, "Defoliate", note, "the title is logged when no note is given")
+
+	// A one-off task closes; editing it reopens it.
+	once := createTask(t, c, apiKey, map[string]interface{}{"title": "Add stakes", "plant_id": veg, "due_date": inDays(0)})
+	require.Equal(t, http.StatusOK, complete(once, map[string]interface{}{"date": inDays(0) + "T10:00:00"}))
+	assert.Nil(t, findDue(getDueTasks(t, c, 7), once, 0))
+	assert.Equal(t, http.StatusBadRequest, complete(once, map[string]interface{}{}))
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPut, "/tasks/"+strconv.Itoa(once), apiKey, map[string]interface{}{
+		"title": "Add stakes", "plant_id": veg, "due_date": inDays(1),
+	}), http.StatusOK)
+	assert.NotNil(t, findDue(getDueTasks(t, c, 7), once, 0))
+
+	assert.Equal(t, http.StatusNotFound, complete(9999, map[string]interface{}{}))
+	assert.Equal(t, http.StatusBadRequest, complete(once, map[string]interface{}{"date": "yesterday"}))
+	assert.Equal(t, 3, testutil.CountRows(t, db, `SELECT COUNT(*) FROM task_completions`))
+}
+
+func TestTaskHTTP_DeletesClearTasks(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "task-delete-key")
+	zoneID, early, _, _ := seedTaskPlants(t, db)
+	elsewhere := testutil.SeedPlant(t, db, "Elsewhere", testutil.SeedStrain(t, db, testutil.SeedBreeder(t, db, "B2"), "S2"),
+		testutil.SeedZone(t, db, "Shelf"))
+	noteID := testutil.ActivityIDByName(t, db, "Note")
+	c := server.NewClient(t)
+
+	plantTask := createTask(t, c, apiKey, map[string]interface{}{
+		"title": "Stage task", "plant_id": early, "activity_id": noteID,
+		"schedule": "stage", "stage_status_id": plantStatusID(t, db, "Flower"), "stage_day": 1,
+	})
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodPost, "/tasks/"+strconv.Itoa(plantTask)+"/complete", apiKey,
+		map[string]interface{}{}), http.StatusOK)
+	zoneTask := createTask(t, c, apiKey, map[string]interface{}{"title": "Clean", "zone_id": zoneID, "due_date": "2026-05-01"})
+	kept := createTask(t, c, apiKey, map[string]interface{}{
+		"title": "Kept", "plant_id": elsewhere, "activity_id": noteID, "due_date": "2026-05-01",
+	})
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodDelete, "/plant/delete/"+strconv.Itoa(early), apiKey, nil), http.StatusOK)
+	assert.Zero(t, testutil.CountRows(t, db, `SELECT COUNT(*) FROM tasks WHERE id = $1`, plantTask))
+	assert.Zero(t, testutil.CountRows(t, db, `SELECT COUNT(*) FROM task_completions`))
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodDelete, "/activities/"+strconv.Itoa(noteID), apiKey, nil), http.StatusOK)
+	var activityID sql.NullInt64
+	require.NoError(t, db.QueryRow(`SELECT activity_id FROM tasks WHERE id = $1`, kept).Scan(&activityID))
+	assert.False(t, activityID.Valid, "deleting the activity unlinks it")
+
+	expectHarvestStatus(t, harvestRequest(t, c, http.MethodDelete, "/zones/"+strconv.Itoa(zoneID), apiKey, nil), http.StatusOK)
+	assert.Zero(t, testutil.CountRows(t, db, `SELECT COUNT(*) FROM tasks WHERE id = $1`, zoneTask))
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM tasks`))
+}
+
+func TestTaskHTTP_PageRenders(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithGuestMode())
+	apiKey := testutil.SeedAPIKey(t, db, "task-page-key")
+	testutil.SeedAdmin(t, db, "task-page-pw")
+	zoneID, _, _, veg := seedTaskPlants(t, db)
+	c := server.NewClient(t)
+
+	createTask(t, c, apiKey, map[string]interface{}{
+		"title": "Defoliate", "zone_id": zoneID, "schedule": "stage",
+		"stage_status_id": plantStatusID(t, db, "Flower"), "stage_day": 21,
+	})
+	createTask(t, c, apiKey, map[string]interface{}{
+		"title": "Order soil", "plant_id": veg, "due_date": "2030-01-01",
+	})
+
+	admin := server.LoginAsAdmin(t, "task-page-pw")
+	for _, client := range []*testutil.Client{c, admin} {
+		resp := client.Get("/tasks")
+		body, err := io.ReadAll(resp.Body)
+		testutil.DrainAndClose(resp)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, string(body), "Defoliate")
+		assert.Contains(t, string(body), "Early", "the due list names the plant")
+		assert.Contains(t, string(body), "Order soil")
+	}
+}
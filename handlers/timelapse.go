@@ -0,0 +1,517 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image/color"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"isley/logger"
+	"isley/model/types"
+	"isley/utils"
+)
+
+// Timelapse render lifecycle, stored in timelapses.status.
+const (
+	TimelapseStatusPending   = "pending"
+	TimelapseStatusRendering = "rendering"
+	TimelapseStatusDone      = "done"
+	TimelapseStatusFailed    = "failed"
+)
+
+// Burn-in modes: nothing, the frame's capture time, or the reading of a
+// chosen sensor at capture time (the timestamp is drawn as well).
+const (
+	TimelapseBurnInNone      = ""
+	TimelapseBurnInTimestamp = "timestamp"
+	TimelapseBurnInSensor    = "sensor"
+)
+
+const (
+	DefaultTimelapseFPS = 24
+	MaxTimelapseFPS     = 60
+	// timelapseRenderTimeout bounds a single ffmpeg run so a wedged
+	// encoder can't hold the render slot forever.
+	timelapseRenderTimeout = 30 * time.Minute
+	timelapseBurnInFont    = "fonts/Anton-Regular.ttf"
+)
+
+// timelapseRenderSlot serialises renders; encoding is CPU-heavy and a
+// grow-room box has no cores to spare for parallel jobs.
+var timelapseRenderSlot = make(chan struct{}, 1)
+
+// timelapseInput is the body of POST /streams/:id/timelapses. Start and
+// end accept the datetime-local input format and are read as local time.
+type timelapseInput struct {
+	Name     string `json:"name"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	FPS      int    `json:"fps"`
+	Format   string `json:"format"`
+	BurnIn   string `json:"burn_in"`
+	SensorID *uint  `json:"sensor_id"`
+}
+
+// timelapseJob carries everything the background renderer needs so it
+// never touches the request context after the handler returns.
+type timelapseJob struct {
+	ID         uint
+	ArchiveDir string
+	OutputDir  string
+	Start, End time.Time
+	FPS        int
+	Format     string
+	BurnIn     string
+	SensorID   *uint
+	Units      utils.UnitPrefs
+}
+
+func parseTimelapseTime(s string) (time.Time, error) {
+	for _, layout := range []string{utils.LayoutDateTimeLocal, "2006-01-02T15:04", utils.LayoutDB, utils.LayoutDate} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+// CreateTimelapseHandler queues a timelapse render of a stream's archived
+// frames between start and end and returns 202 with the new id. Progress
+// is polled through GET /timelapses.
+func CreateTimelapseHandler(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "CreateTimelapseHandler")
+
+	streamID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || streamID <= 0 {
+		apiBadRequest(c, "api_invalid_request")
+		return
+	}
+
+	var input timelapseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apiBadRequest(c, "api_invalid_payload")
+		return
+	}
+	if err := utils.ValidateRequiredString("name", input.Name, utils.MaxNameLength); err != nil {
+		apiBadRequest(c, err.Error())
+		return
+	}
+	start, err := parseTimelapseTime(input.Start)
+	if err != nil {
+		apiBadRequest(c, "api_invalid_timelapse_range")
+		return
+	}
+	end, err := parseTimelapseTime(input.End)
+	if err != nil || !end.After(start) {
+		apiBadRequest(c, "api_invalid_timelapse_range")
+		return
+	}
+	if input.FPS == 0 {
+		input.FPS = DefaultTimelapseFPS
+	}
+	if input.FPS < 1 || input.FPS > MaxTimelapseFPS {
+		apiBadRequest(c, "api_invalid_timelapse_fps")
+		return
+	}
+	if input.Format == "" {
+		input.Format = utils.TimelapseFormatMP4
+	}
+	if input.Format != utils.TimelapseFormatMP4 && input.Format != utils.TimelapseFormatWebM {
+		apiBadRequest(c, "api_invalid_timelapse_format")
+		return
+	}
+	switch input.BurnIn {
+	case TimelapseBurnInNone, TimelapseBurnInTimestamp:
+		input.SensorID = nil
+	case TimelapseBurnInSensor:
+		if input.SensorID == nil {
+			apiBadRequest(c, "api_invalid_timelapse_burn_in")
+			return
+		}
+	default:
+		apiBadRequest(c, "api_invalid_timelapse_burn_in")
+		return
+	}
+
+	db := DBFromContext(c)
+	var exists int
+	if err := db.QueryRow("SELECT id FROM streams WHERE id = $1", streamID).Scan(&exists); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			apiNotFound(c, "api_stream_not_found")
+			return
+		}
+		fieldLogger.WithError(err).Error("Failed to look up stream")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if input.SensorID != nil {
+		if err := db.QueryRow("SELECT id FROM sensors WHERE id = $1", *input.SensorID).Scan(&exists); err != nil {
+			apiBadRequest(c, "api_invalid_timelapse_burn_in")
+			return
+		}
+	}
+
+	var id uint
+	err = db.QueryRow(`INSERT INTO timelapses (stream_id, name, start_dt, end_dt, fps, format, burn_in, sensor_id, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`,
+		streamID, input.Name, start, end, input.FPS, input.Format, input.BurnIn, input.SensorID,
+		TimelapseStatusPending).Scan(&id)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to insert timelapse")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	job := timelapseJob{
+		ID:         id,
+		ArchiveDir: utils.FrameArchiveDir(StreamDirFromContext(c), uint(streamID)),
+		OutputDir:  filepath.Join(UploadDirFromContext(c), "timelapses"),
+		Start:      start,
+		End:        end,
+		FPS:        input.FPS,
+		Format:     input.Format,
+		BurnIn:     input.BurnIn,
+		SensorID:   input.SensorID,
+		Units:      UnitPrefsFromContext(c),
+	}
+	go renderTimelapse(db, job)
+
+	c.JSON(http.StatusAccepted, gin.H{"id": id, "message": T(c, "api_timelapse_queued")})
+}
+
+// renderTimelapse runs a queued job to completion, recording the outcome
+// on the timelapses row.
+func renderTimelapse(db *sql.DB, job timelapseJob) {
+	fieldLogger := logger.Log.WithField("timelapse", job.ID)
+
+	timelapseRenderSlot <- struct{}{}
+	defer func() { <-timelapseRenderSlot }()
+
+	setStatus := func(status string, frames int, path, msg string) {
+		if _, err := db.Exec(`UPDATE timelapses SET status = $1, frame_count = $2, file_path = $3, error = $4, update_dt = $5 WHERE id = $6`,
+			status, frames, path, msg, time.Now(), job.ID); err != nil {
+			fieldLogger.WithError(err).Error("Failed to update timelapse status")
+		}
+	}
+	setStatus(TimelapseStatusRendering, 0, "", "")
+
+	frames, path, err := buildTimelapse(db, job)
+	if err != nil {
+		fieldLogger.WithError(err).Warn("Timelapse render failed")
+		setStatus(TimelapseStatusFailed, frames, "", err.Error())
+		return
+	}
+	fieldLogger.WithField("frames", frames).Info("Timelapse rendered")
+	setStatus(TimelapseStatusDone, frames, path, "")
+}
+
+// buildTimelapse stages the job's frames (burning in text when asked),
+// encodes them, and returns the frame count and output path.
+func buildTimelapse(db *sql.DB, job timelapseJob) (int, string, error) {
+	frames, err := utils.ListArchivedFrames(job.ArchiveDir, job.Start, job.End)
+	if err != nil {
+		return 0, "", err
+	}
+	if len(frames) == 0 {
+		return 0, "", errors.New("no archived frames in the selected range")
+	}
+
+	stage, err := os.MkdirTemp("", "isley-timelapse-*")
+	if err != nil {
+		return 0, "", err
+	}
+	defer os.RemoveAll(stage)
+
+	var readings *timelapseSensorReadings
+	if job.BurnIn == TimelapseBurnInSensor && job.SensorID != nil {
+		readings, err = loadTimelapseSensorReadings(db, *job.SensorID, job.Start, job.End, job.Units)
+		if err != nil {
+			return 0, "", err
+		}
+	}
+
+	// ProcessImageWithTextOverlay always writes PNG, so burned-in
+	// sequences are staged as .png and plain ones keep the archive's .jpg.
+	ext := ".jpg"
+	if job.BurnIn != TimelapseBurnInNone {
+		ext = ".png"
+	}
+	for i, f := range frames {
+		dst := filepath.Join(stage, utils.TimelapseFrameName(i, ext))
+		if job.BurnIn == TimelapseBurnInNone {
+			err = linkOrCopyFile(f.Path, dst)
+		} else {
+			err = utils.ProcessImageWithTextOverlay(utils.TextOverlayRequest{
+				ImagePath:   f.Path,
+				OutputPath:  dst,
+				TextObjects: timelapseBurnIn(f.CapturedAt, readings),
+			})
+		}
+		if err != nil {
+			return 0, "", fmt.Errorf("frame %s: %w", filepath.Base(f.Path), err)
+		}
+	}
+
+	if err := os.MkdirAll(job.OutputDir, os.ModePerm); err != nil {
+		return len(frames), "", err
+	}
+	out := filepath.Join(job.OutputDir, fmt.Sprintf("timelapse_%d_%d.%s", job.ID, time.Now().UnixNano(), job.Format))
+	ctx, cancel := context.WithTimeout(context.Background(), timelapseRenderTimeout)
+	defer cancel()
+	if err := utils.RenderTimelapse(ctx, stage, ext, out, job.FPS, job.Format); err != nil {
+		os.Remove(out)
+		return len(frames), "", err
+	}
+	return len(frames), out, nil
+}
+
+// timelapseBurnIn returns the overlay text for a frame captured at ts:
+// the local capture time bottom-left and, when readings are loaded, the
+// sensor value in effect at ts bottom-right.
+func timelapseBurnIn(ts time.Time, readings *timelapseSensorReadings) []utils.TextObject {
+	text := func(s, corner string) utils.TextObject {
+		return utils.TextObject{
+			Text: s, Corner: corner, FontPath: timelapseBurnInFont,
+			FontColor: color.White, ShadowColor: color.Black, FontScale: 0.8,
+		}
+	}
+	objs := []utils.TextObject{text(ts.Local().Format(utils.LayoutDB), "bottom-left")}
+	if readings != nil {
+		if s, ok := readings.at(ts); ok {
+			objs = append(objs, text(s, "bottom-right"))
+		}
+	}
+	return objs
+}
+
+// timelapseSensorReadings is a sensor's history over a render window,
+// already converted to the display units.
+type timelapseSensorReadings struct {
+	name   string
+	unit   string
+	times  []time.Time
+	values []float64
+}
+
+// loadTimelapseSensorReadings reads sensorID's raw readings over
+// [start-1h, end]; the hour of lead-in gives the first frames a value.
+func loadTimelapseSensorReadings(db *sql.DB, sensorID uint, start, end time.Time, prefs utils.UnitPrefs) (*timelapseSensorReadings, error) {
+	r := &timelapseSensorReadings{}
+	var unit string
+	if err := db.QueryRow("SELECT name, unit FROM sensors WHERE id = $1", sensorID).Scan(&r.name, &unit); err != nil {
+		return nil, err
+	}
+	_, r.unit = prefs.Convert(0, unit)
+
+	rows, err := db.Query(`SELECT value, create_dt FROM sensor_data
+		WHERE sensor_id = $1 AND create_dt BETWEEN $2 AND $3 ORDER BY create_dt`,
+		sensorID, start.Add(-time.Hour).UTC().Format(utils.LayoutDB), end.UTC().Format(utils.LayoutDB))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var v float64
+		var ts time.Time
+		if err := rows.Scan(&v, &ts); err != nil {
+			return nil, err
+		}
+		v, _ = prefs.Convert(v, unit)
+		r.values = append(r.values, v)
+		r.times = append(r.times, ts)
+	}
+	return r, rows.Err()
+}
+
+// at formats the latest reading taken at or before ts.
+func (r *timelapseSensorReadings) at(ts time.Time) (string, bool) {
+	i := len(r.times) - 1
+	for i >= 0 && r.times[i].After(ts) {
+		i--
+	}
+	if i < 0 {
+		return "", false
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %.1f %s", r.name, r.values[i], r.unit)), true
+}
+
+// linkOrCopyFile hard-links src to dst, falling back to a copy when the
+// two live on different filesystems.
+func linkOrCopyFile(src, dst string) error {
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+	return utils.CopyFile(src, dst)
+}
+
+// GetTimelapses returns every timelapse, newest first.
+func GetTimelapses(db *sql.DB) ([]types.Timelapse, error) {
+	rows, err := db.Query(`SELECT t.id, t.stream_id, COALESCE(s.name, ''), t.name, t.start_dt, t.end_dt, t.fps, t.format,
+		       t.burn_in, t.sensor_id, t.status, t.frame_count, t.file_path, t.error, t.create_dt
+		FROM timelapses t LEFT OUTER JOIN streams s ON s.id = t.stream_id
+		ORDER BY t.create_dt DESC, t.id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []types.Timelapse{}
+	for rows.Next() {
+		var t types.Timelapse
+		var streamID, sensorID sql.NullInt64
+		if err := rows.Scan(&t.ID, &streamID, &t.StreamName, &t.Name, &t.StartDT, &t.EndDT, &t.FPS, &t.Format,
+			&t.BurnIn, &sensorID, &t.Status, &t.FrameCount, &t.FilePath, &t.Error, &t.CreateDT); err != nil {
+			return nil, err
+		}
+		if streamID.Valid {
+			id := uint(streamID.Int64)
+			t.StreamID = &id
+		}
+		if sensorID.Valid {
+			id := uint(sensorID.Int64)
+			t.SensorID = &id
+		}
+		if t.FilePath != "" {
+			t.FilePath = "/" + strings.ReplaceAll(t.FilePath, "\\", "/")
+		}
+		t.StartDT, t.EndDT, t.CreateDT = utils.AsLocal(t.StartDT), utils.AsLocal(t.EndDT), utils.AsLocal(t.CreateDT)
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// GetTimelapsesHandler lists timelapses with their render status.
+func GetTimelapsesHandler(c *gin.Context) {
+	timelapses, err := GetTimelapses(DBFromContext(c))
+	if err != nil {
+		logger.Log.WithField("func", "GetTimelapsesHandler").WithError(err).Error("Failed to list timelapses")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusOK, timelapses)
+}
+
+// DeleteTimelapseHandler removes a timelapse and its video file. Copies
+// already attached to plants are independent and left alone.
+func DeleteTimelapseHandler(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "DeleteTimelapseHandler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		apiBadRequest(c, "api_invalid_request")
+		return
+	}
+	db := DBFromContext(c)
+	var status, path string
+	if err := db.QueryRow("SELECT status, file_path FROM timelapses WHERE id = $1", id).Scan(&status, &path); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			apiNotFound(c, "api_timelapse_not_found")
+			return
+		}
+		fieldLogger.WithError(err).Error("Failed to look up timelapse")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if status == TimelapseStatusRendering {
+		apiError(c, http.StatusConflict, "api_timelapse_rendering")
+		return
+	}
+	if path != "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			fieldLogger.WithError(err).Error("Failed to delete timelapse file")
+			apiInternalError(c, "api_failed_to_delete_file")
+			return
+		}
+	}
+	if _, err := db.Exec("DELETE FROM timelapses WHERE id = $1", id); err != nil {
+		fieldLogger.WithError(err).Error("Failed to delete timelapse")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	apiOK(c, "api_timelapse_deleted")
+}
+
+// AttachTimelapseHandler copies a finished timelapse into a plant's image
+// gallery, dated at the end of the timelapse window. The copy is owned by
+// the plant so deleting either side leaves the other intact.
+func AttachTimelapseHandler(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "AttachTimelapseHandler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		apiBadRequest(c, "api_invalid_request")
+		return
+	}
+	var input struct {
+		PlantID int `json:"plant_id"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || input.PlantID <= 0 {
+		apiBadRequest(c, "api_invalid_plant_id")
+		return
+	}
+
+	db := DBFromContext(c)
+	var name, status, path string
+	var endDT time.Time
+	if err := db.QueryRow("SELECT name, status, file_path, end_dt FROM timelapses WHERE id = $1", id).Scan(&name, &status, &path, &endDT); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			apiNotFound(c, "api_timelapse_not_found")
+			return
+		}
+		fieldLogger.WithError(err).Error("Failed to look up timelapse")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if status != TimelapseStatusDone || path == "" {
+		apiError(c, http.StatusConflict, "api_timelapse_not_ready")
+		return
+	}
+	var plantID int
+	if err := db.QueryRow("SELECT id FROM plant WHERE id = $1", input.PlantID).Scan(&plantID); err != nil {
+		apiNotFound(c, "api_plant_not_found")
+		return
+	}
+
+	fileName := fmt.Sprintf("plant_%d_timelapse_%d_%d%s", plantID, id, time.Now().UnixNano(), filepath.Ext(path))
+	savePath := filepath.Join(UploadDirFromContext(c), "plants", fileName)
+	if err := os.MkdirAll(filepath.Dir(savePath), os.ModePerm); err != nil {
+		fieldLogger.WithError(err).Error("Failed to create directory")
+		apiInternalError(c, "api_failed_to_create_directory")
+		return
+	}
+	if err := linkOrCopyFile(path, savePath); err != nil {
+		fieldLogger.WithError(err).Error("Failed to copy timelapse")
+		apiInternalError(c, "api_failed_to_save_file")
+		return
+	}
+
+	var imageID int
+	err = db.QueryRow(`INSERT INTO plant_images (plant_id, image_path, image_description, image_order, image_date)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		plantID, savePath, name, DefaultPlantImageOrder, utils.AsLocal(endDT)).Scan(&imageID)
+	if err != nil {
+		os.Remove(savePath)
+		fieldLogger.WithError(err).Error("Failed to save plant image record")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": imageID, "message": T(c, "api_timelapse_attached")})
+}
+
+// FailInterruptedTimelapses marks renders that were queued or running
+// when the process stopped as failed; their goroutines are gone.
+func FailInterruptedTimelapses(db *sql.DB) {
+	if _, err := db.Exec("UPDATE timelapses SET status = $1, error = $2 WHERE status IN ($3, $4)",
+		TimelapseStatusFailed, "interrupted by restart", TimelapseStatusPending, TimelapseStatusRendering); err != nil {
+		logger.Log.WithError(err).Error("Failed to reset interrupted timelapses")
+	}
+}
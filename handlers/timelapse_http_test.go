@@ -0,0 +1,271 @@
+package handlers_test
+
+// HTTP-layer tests for handlers/timelapse.go: request validation, the
+// background render reaching a terminal status, and attaching a finished
+// timelapse to a plant's gallery.
+
+import (
+	"database/sql"
+	"encoding/json"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"isley/model/types"
+	"isley/tests/testutil"
+	"isley/utils"
+)
+
+// seedCamStream inserts stream id named "Cam" in a fresh zone.
+func seedCamStream(t *testing.T, db *sql.DB, id int) {
+	t.Helper()
+	zoneID := testutil.SeedZone(t, db, "Cam Zone")
+	testutil.MustExec(t, db, `INSERT INTO streams (id, name, url, zone_id, visible) VALUES ($1, 'Cam', 'https://example.com/cam.jpg', $2, TRUE)`, id, zoneID)
+}
+
+// fetchTimelapses returns GET /timelapses for apiKey.
+func fetchTimelapses(t *testing.T, c *testutil.Client, apiKey string) []types.Timelapse {
+	t.Helper()
+	resp := c.APIGet(t, "/timelapses", apiKey)
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list []types.Timelapse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	return list
+}
+
+// waitForTimelapse polls until timelapse id leaves pending/rendering.
+func waitForTimelapse(t *testing.T, c *testutil.Client, apiKey string, id uint) types.Timelapse {
+	t.Helper()
+	deadline := time.Now().Add(20 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, tl := range fetchTimelapses(t, c, apiKey) {
+			if tl.ID == id && tl.Status != "pending" && tl.Status != "rendering" {
+				return tl
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("timelapse %d did not finish rendering", id)
+	return types.Timelapse{}
+}
+
+// ---------------------------------------------------------------------------
+// Auth gating
+// ---------------------------------------------------------------------------
+
+func TestTimelapseHTTP_AuthGating(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db)
+
+	cases := []struct {
+		method, path string
+	}{
+		{http.MethodPost, "/streams/1/timelapses"},
+		{http.MethodGet, "/timelapses"},
+		{http.MethodDelete, "/timelapses/1"},
+		{http.MethodPost, "/timelapses/1/attach"},
+	}
+
+	c := server.NewClient(t)
+	for _, tc := range cases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, c.BaseURL+tc.path, nil)
+			require.NoError(t, err)
+			resp, err := c.Do(req)
+			require.NoError(t, err)
+			defer testutil.DrainAndClose(resp)
+			assert.Containsf(t,
+				[]int{http.StatusUnauthorized, http.StatusForbidden},
+				resp.StatusCode,
+				"%s %s should be rejected (got %d)", tc.method, tc.path, resp.StatusCode)
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// CreateTimelapseHandler
+// ---------------------------------------------------------------------------
+
+func TestTimelapseHTTP_Create_Validation(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db)
+	const apiKey = "timelapse-validate-key"
+	testutil.SeedAPIKey(t, db, apiKey)
+	seedCamStream(t, db, 1)
+
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{"name": "Week 1", "start": "2026-03-01T00:00", "end": "2026-03-08T00:00"}
+	}
+	cases := []struct {
+		name   string
+		path   string
+		mutate func(map[string]interface{})
+		want   int
+	}{
+		{"end before start", "/streams/1/timelapses", func(b map[string]interface{}) { b["end"] = "2026-02-01T00:00" }, http.StatusBadRequest},
+		{"unparseable start", "/streams/1/timelapses", func(b map[string]interface{}) { b["start"] = "last week" }, http.StatusBadRequest},
+		{"fps out of range", "/streams/1/timelapses", func(b map[string]interface{}) { b["fps"] = 500 }, http.StatusBadRequest},
+		{"unknown format", "/streams/1/timelapses", func(b map[string]interface{}) { b["format"] = "gif" }, http.StatusBadRequest},
+		{"sensor burn-in needs sensor", "/streams/1/timelapses", func(b map[string]interface{}) { b["burn_in"] = "sensor" }, http.StatusBadRequest},
+		{"unknown stream", "/streams/999/timelapses", func(map[string]interface{}) {}, http.StatusNotFound},
+	}
+
+	c := server.NewClient(t)
+	for _, tc := range cases {
+		body := valid()
+		tc.mutate(body)
+		resp := c.APIPostJSON(t, tc.path, apiKey, body)
+		testutil.DrainAndClose(resp)
+		assert.Equal(t, tc.want, resp.StatusCode, tc.name)
+	}
+	assert.Empty(t, fetchTimelapses(t, c, apiKey), "rejected requests must not queue renders")
+}
+
+func TestTimelapseHTTP_Create_NoFramesFails(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithStreamDir(t.TempDir()))
+	const apiKey = "timelapse-empty-key"
+	testutil.SeedAPIKey(t, db, apiKey)
+	seedCamStream(t, db, 1)
+
+	c := server.NewClient(t)
+	resp := c.APIPostJSON(t, "/streams/1/timelapses", apiKey, map[string]interface{}{
+		"name": "Empty", "start": "2026-03-01T00:00", "end": "2026-03-02T00:00",
+	})
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	var created struct {
+		ID uint `json:"id"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+
+	tl := waitForTimelapse(t, c, apiKey, created.ID)
+	assert.Equal(t, "failed", tl.Status)
+	assert.Contains(t, tl.Error, "no archived frames")
+	assert.Equal(t, "Cam", tl.StreamName)
+	assert.Equal(t, "2026-03-01 00:00", tl.StartDT.Format("2006-01-02 15:04"), "range is stored as local wall time")
+}
+
+// TestTimelapseHTTP_Create_RendersArchivedFrames archives a few frames
+// and renders them with a timestamp burn-in. Without ffmpeg the job must
+// fail with the missing-ffmpeg error; with it, a video must be produced.
+func TestTimelapseHTTP_Create_RendersArchivedFrames(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	streamDir := t.TempDir()
+	uploadDir := t.TempDir()
+	server := testutil.NewTestServer(t, db, testutil.WithStreamDir(streamDir), testutil.WithUploadDir(uploadDir))
+	const apiKey = "timelapse-render-key"
+	testutil.SeedAPIKey(t, db, apiKey)
+	seedCamStream(t, db, 3)
+
+	src := filepath.Join(t.TempDir(), "frame.jpg")
+	f, err := os.Create(src)
+	require.NoError(t, err)
+	require.NoError(t, jpeg.Encode(f, image.NewRGBA(image.Rect(0, 0, 64, 48)), nil))
+	require.NoError(t, f.Close())
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	for i := 0; i < 3; i++ {
+		_, err := utils.ArchiveFrame(src, utils.FrameArchiveDir(streamDir, 3), base.Add(time.Duration(i)*time.Minute))
+		require.NoError(t, err)
+	}
+
+	c := server.NewClient(t)
+	resp := c.APIPostJSON(t, "/streams/3/timelapses", apiKey, map[string]interface{}{
+		"name": "Day 1", "start": "2026-03-01T11:00", "end": "2026-03-01T13:00", "fps": 2, "burn_in": "timestamp",
+	})
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	var created struct {
+		ID uint `json:"id"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+
+	tl := waitForTimelapse(t, c, apiKey, created.ID)
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		assert.Equal(t, "failed", tl.Status)
+		assert.Contains(t, tl.Error, "ffmpeg")
+		return
+	}
+	require.Equal(t, "done", tl.Status, tl.Error)
+	assert.Equal(t, 3, tl.FrameCount)
+	assert.True(t, strings.HasSuffix(tl.FilePath, ".mp4"))
+}
+
+// ---------------------------------------------------------------------------
+// AttachTimelapseHandler / DeleteTimelapseHandler
+// ---------------------------------------------------------------------------
+
+func TestTimelapseHTTP_AttachCopiesIntoGallery(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	uploadDir := t.TempDir()
+	server := testutil.NewTestServer(t, db, testutil.WithUploadDir(uploadDir))
+	const apiKey = "timelapse-attach-key"
+	testutil.SeedAPIKey(t, db, apiKey)
+
+	zoneID := testutil.SeedZone(t, db, "Tent")
+	strainID := testutil.SeedStrain(t, db, testutil.SeedBreeder(t, db, "Breeder"), "Strain")
+	plantID := testutil.SeedPlant(t, db, "Plant", strainID, zoneID)
+
+	video := filepath.Join(uploadDir, "timelapses", "timelapse_1_1.webm")
+	require.NoError(t, os.MkdirAll(filepath.Dir(video), 0o755))
+	require.NoError(t, os.WriteFile(video, []byte("webm"), 0o644))
+	testutil.MustExec(t, db, `INSERT INTO timelapses (id, name, start_dt, end_dt, format, status, file_path)
+		VALUES (1, 'Week 1', '2026-03-01 00:00:00', '2026-03-08 00:00:00', 'webm', 'done', $1)`, video)
+	testutil.MustExec(t, db, `INSERT INTO timelapses (id, name, start_dt, end_dt, status)
+		VALUES (2, 'Queued', '2026-03-01 00:00:00', '2026-03-08 00:00:00', 'pending')`)
+
+	c := server.NewClient(t)
+
+	resp := c.APIPostJSON(t, "/timelapses/2/attach", apiKey, map[string]interface{}{"plant_id": plantID})
+	testutil.DrainAndClose(resp)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "unfinished timelapse cannot be attached")
+
+	resp = c.APIPostJSON(t, "/timelapses/1/attach", apiKey, map[string]interface{}{"plant_id": 99999})
+	testutil.DrainAndClose(resp)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = c.APIPostJSON(t, "/timelapses/1/attach", apiKey, map[string]interface{}{"plant_id": plantID})
+	testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var path, desc string
+	require.NoError(t, db.QueryRow(`SELECT image_path, image_description FROM plant_images WHERE plant_id = $1`, plantID).Scan(&path, &desc))
+	assert.Equal(t, "Week 1", desc)
+	assert.True(t, strings.HasSuffix(path, ".webm"))
+	assert.NotEqual(t, video, path, "the plant must own its own copy")
+	_, err := os.Stat(path)
+	require.NoError(t, err)
+
+	// Deleting the timelapse removes its file but not the plant's copy.
+	resp = c.APIDelete(t, "/timelapses/1", apiKey)
+	testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	_, err = os.Stat(video)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+
+	resp = c.APIDelete(t, "/timelapses/1", apiKey)
+	testutil.DrainAndClose(resp)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"isley/model/types"
+	"isley/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UnitPrefsFromContext returns the display unit preference from the
+// engine's Store.
+func UnitPrefsFromContext(c *gin.Context) utils.UnitPrefs {
+	return ConfigStoreFromContext(c).UnitPrefs()
+}
+
+// GroupedSensorsInUnits returns grouped (as built by
+// GetGroupedSensorsWithLatestReading) with every reading converted to
+// prefs. The input is shared with the sensor cache, so sensors are copied
+// rather than converted in place.
+func GroupedSensorsInUnits(grouped map[string]map[string][]map[string]interface{}, prefs utils.UnitPrefs) map[string]map[string][]map[string]interface{} {
+	if prefs.IsNative() || grouped == nil {
+		return grouped
+	}
+	out := make(map[string]map[string][]map[string]interface{}, len(grouped))
+	for zone, devices := range grouped {
+		out[zone] = make(map[string][]map[string]interface{}, len(devices))
+		for device, sensors := range devices {
+			converted := make([]map[string]interface{}, len(sensors))
+			for i, sensor := range sensors {
+				cp := make(map[string]interface{}, len(sensor))
+				for k, v := range sensor {
+					cp[k] = v
+				}
+				value, vok := sensor["value"].(float64)
+				unit, uok := sensor["unit"].(string)
+				if vok && uok {
+					cp["value"], cp["unit"] = prefs.Convert(value, unit)
+				}
+				converted[i] = cp
+			}
+			out[zone][device] = converted
+		}
+	}
+	return out
+}
+
+// sensorHistoryInUnits returns a copy of data, a single sensor's readings
+// stored in unit, converted to prefs.
+func sensorHistoryInUnits(data []types.SensorData, unit string, prefs utils.UnitPrefs) []types.SensorData {
+	if prefs.IsNative() || prefs.ConvertUnit(unit) == unit {
+		return data
+	}
+	out := make([]types.SensorData, len(data))
+	for i, d := range data {
+		d.Value, _ = prefs.Convert(d.Value, unit)
+		out[i] = d
+	}
+	return out
+}
+
+// convertChartSeries converts each series of resp to prefs in place.
+// Converting after bucketing is exact: every supported conversion is
+// linear, so the average of converted readings is the converted average.
+func convertChartSeries(resp *ChartResponse, prefs utils.UnitPrefs) {
+	if prefs.IsNative() {
+		return
+	}
+	for si := range resp.Series {
+		s := &resp.Series[si]
+		unit := s.Unit
+		if prefs.ConvertUnit(unit) == unit {
+			continue
+		}
+		for _, vals := range [][]*float64{s.Values, s.Min, s.Max} {
+			for i, v := range vals {
+				if v != nil {
+					cv, _ := prefs.Convert(*v, unit)
+					vals[i] = &cv
+				}
+			}
+		}
+		s.Unit = prefs.ConvertUnit(unit)
+	}
+}
+
+// ConvertPlantUnits converts a plant's sensor readings and measurements
+// to prefs for display. Measurements keep their stored Value (the edit
+// forms post it back) and get DisplayValue/DisplayUnit instead.
+func ConvertPlantUnits(plant *types.Plant, prefs utils.UnitPrefs) {
+	if prefs.IsNative() {
+		return
+	}
+	for i := range plant.Sensors {
+		s := &plant.Sensors[i]
+		s.Value, s.Unit = prefs.Convert(s.Value, s.Unit)
+	}
+	for i := range plant.Measurements {
+		m := &plant.Measurements[i]
+		m.DisplayValue, m.DisplayUnit = prefs.Convert(m.Value, m.Unit)
+	}
+}
@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"isley/logger"
+	"isley/model/types"
+	"isley/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxVirtualSensorInputs caps how many sensors one virtual sensor may read.
+const MaxVirtualSensorInputs = 16
+
+// virtualSensorInput is the create/update payload for a virtual sensor.
+type virtualSensorInput struct {
+	Name       string `json:"name"`
+	ZoneID     *int   `json:"zone_id"`
+	Unit       string `json:"unit"`
+	Function   string `json:"function"`
+	Inputs     []int  `json:"inputs"`
+	Expression string `json:"expression"`
+	Conversion string `json:"conversion"`
+}
+
+// ListVirtualSensors returns every virtual sensor definition with its output
+// sensor's name, zone and unit, ordered by id (the watcher's evaluation order).
+func ListVirtualSensors(db *sql.DB) ([]types.VirtualSensor, error) {
+	rows, err := db.Query(`
+		SELECT v.id, v.sensor_id, s.name, s.zone_id, s.unit, v.function_name, v.inputs, v.expression, v.conversion
+		FROM virtual_sensors v
+		JOIN sensors s ON s.id = v.sensor_id
+		ORDER BY v.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []types.VirtualSensor{}
+	for rows.Next() {
+		var (
+			vs     types.VirtualSensor
+			zoneID sql.NullInt64
+			inputs string
+		)
+		if err := rows.Scan(&vs.ID, &vs.SensorID, &vs.Name, &zoneID, &vs.Unit, &vs.Function, &inputs, &vs.Expression, &vs.Conversion); err != nil {
+			return nil, err
+		}
+		if zoneID.Valid {
+			z := int(zoneID.Int64)
+			vs.ZoneID = &z
+		}
+		if err := json.Unmarshal([]byte(inputs), &vs.Inputs); err != nil {
+			vs.Inputs = []int{}
+		}
+		list = append(list, vs)
+	}
+	return list, rows.Err()
+}
+
+// GetVirtualSensorsHandler returns every virtual sensor definition.
+func GetVirtualSensorsHandler(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "GetVirtualSensorsHandler")
+
+	list, err := ListVirtualSensors(DBFromContext(c))
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to list virtual sensors")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"virtual_sensors": list})
+}
+
+// CreateVirtualSensorHandler creates the output sensors row (source
+// "virtual") and its definition in one transaction. The watcher starts
+// producing readings on its next cycle.
+func CreateVirtualSensorHandler(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "CreateVirtualSensorHandler")
+
+	var input virtualSensorInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apiBadRequest(c, "api_invalid_payload")
+		return
+	}
+
+	db := DBFromContext(c)
+	if errKey := validateVirtualSensor(db, &input, 0); errKey != "" {
+		apiBadRequest(c, errKey)
+		return
+	}
+	inputsJSON, _ := json.Marshal(input.Inputs)
+
+	tx, err := db.Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op once the tx is committed
+
+	var sensorID int
+	err = tx.QueryRow(
+		`INSERT INTO sensors (name, zone_id, source, device, type, unit, visibility)
+		 VALUES ($1, $2, $3, $4, $5, $6, 'zone_plant') RETURNING id`,
+		input.Name, input.ZoneID, types.VirtualSource, types.VirtualSource, input.Function, input.Unit,
+	).Scan(&sensorID)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to create virtual sensor output row")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	var id int
+	err = tx.QueryRow(
+		`INSERT INTO virtual_sensors (sensor_id, function_name, inputs, expression, conversion)
+		 VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		sensorID, input.Function, string(inputsJSON), input.Expression, input.Conversion,
+	).Scan(&id)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to create virtual sensor")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit virtual sensor")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": T(c, "api_virtual_sensor_created"),
+		"virtual_sensor": types.VirtualSensor{
+			ID:         id,
+			SensorID:   sensorID,
+			Name:       input.Name,
+			ZoneID:     input.ZoneID,
+			Unit:       input.Unit,
+			Function:   input.Function,
+			Inputs:     input.Inputs,
+			Expression: input.Expression,
+			Conversion: input.Conversion,
+		},
+	})
+}
+
+// UpdateVirtualSensorHandler replaces a virtual sensor's definition and its
+// output sensor's name, zone and unit. Readings already stored are kept.
+func UpdateVirtualSensorHandler(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "UpdateVirtualSensorHandler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		apiBadRequest(c, "api_invalid_request")
+		return
+	}
+
+	var input virtualSensorInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apiBadRequest(c, "api_invalid_payload")
+		return
+	}
+
+	db := DBFromContext(c)
+	var sensorID int
+	if err := db.QueryRow("SELECT sensor_id FROM virtual_sensors WHERE id = $1", id).Scan(&sensorID); err != nil {
+		if err == sql.ErrNoRows {
+			apiNotFound(c, "api_virtual_sensor_not_found")
+			return
+		}
+		fieldLogger.WithError(err).Error("Failed to look up virtual sensor")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	if errKey := validateVirtualSensor(db, &input, sensorID); errKey != "" {
+		apiBadRequest(c, errKey)
+		return
+	}
+	inputsJSON, _ := json.Marshal(input.Inputs)
+
+	tx, err := db.Begin()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to begin transaction")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op once the tx is committed
+
+	// type mirrors the function so the sensors list shows what the row is;
+	// virtual rows are never matched by (source, device, type) so changing
+	// it is safe, unlike for polled sensors.
+	if _, err := tx.Exec(
+		"UPDATE sensors SET name = $1, zone_id = $2, unit = $3, type = $4 WHERE id = $5",
+		input.Name, input.ZoneID, input.Unit, input.Function, sensorID,
+	); err != nil {
+		fieldLogger.WithError(err).Error("Failed to update virtual sensor output row")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+	if _, err := tx.Exec(
+		`UPDATE virtual_sensors SET function_name = $1, inputs = $2, expression = $3, conversion = $4,
+		 update_dt = CURRENT_TIMESTAMP WHERE id = $5`,
+		input.Function, string(inputsJSON), input.Expression, input.Conversion, id,
+	); err != nil {
+		fieldLogger.WithError(err).Error("Failed to update virtual sensor")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		fieldLogger.WithError(err).Error("Failed to commit virtual sensor update")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	apiOK(c, "api_virtual_sensor_updated")
+}
+
+// DeleteVirtualSensorHandler removes a virtual sensor together with its
+// output sensor and stored readings.
+func DeleteVirtualSensorHandler(c *gin.Context) {
+	fieldLogger := logger.Log.WithField("func", "DeleteVirtualSensorHandler")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		apiBadRequest(c, "api_invalid_request")
+		return
+	}
+
+	db := DBFromContext(c)
+	var sensorID int
+	if err := db.QueryRow("SELECT sensor_id FROM virtual_sensors WHERE id = $1", id).Scan(&sensorID); err != nil {
+		if err == sql.ErrNoRows {
+			apiNotFound(c, "api_virtual_sensor_not_found")
+			return
+		}
+		fieldLogger.WithError(err).Error("Failed to look up virtual sensor")
+		apiInternalError(c, "api_database_error")
+		return
+	}
+
+	if err := DeleteSensorByID(db, strconv.Itoa(sensorID)); err != nil {
+		fieldLogger.WithError(err).Error("Failed to delete virtual sensor")
+		apiInternalError(c, "api_failed_to_delete_sensor")
+		return
+	}
+
+	apiOK(c, "api_virtual_sensor_deleted")
+}
+
+// validateVirtualSensor normalises input in place and returns a translation
+// key (or a plain validation message) when it is unusable. selfSensorID is the
+// output sensor being updated, or 0 on create, and may not appear as an input.
+func validateVirtualSensor(db *sql.DB, input *virtualSensorInput, selfSensorID int) string {
+	input.Name = strings.TrimSpace(input.Name)
+	input.Unit = strings.TrimSpace(input.Unit)
+	input.Function = strings.TrimSpace(input.Function)
+	input.Expression = strings.TrimSpace(input.Expression)
+	input.Conversion = strings.TrimSpace(input.Conversion)
+
+	if err := utils.ValidateRequiredString("name", input.Name, utils.MaxNameLength); err != nil {
+		return err.Error()
+	}
+	if err := utils.ValidateStringLength("unit", input.Unit, utils.MaxUnitLength); err != nil {
+		return err.Error()
+	}
+	if input.ZoneID == nil {
+		return "api_virtual_sensor_zone_required"
+	}
+	defaultUnit, ok := types.VirtualFunctionUnits[input.Function]
+	if !ok {
+		return "api_invalid_virtual_function"
+	}
+
+	// Only the fields the function uses are kept, so a stale expression or
+	// conversion from an earlier edit can't linger in the row.
+	minInputs, maxInputs := 1, 1
+	switch input.Function {
+	case types.VirtualDewPoint, types.VirtualAbsHumidity:
+		minInputs, maxInputs = 2, 2
+		input.Expression, input.Conversion = "", ""
+	case types.VirtualDLI:
+		input.Expression, input.Conversion = "", ""
+	case types.VirtualMean, types.VirtualMin, types.VirtualMax:
+		minInputs, maxInputs = 2, MaxVirtualSensorInputs
+		input.Expression, input.Conversion = "", ""
+	case types.VirtualConvert:
+		conv, ok := types.UnitConversions[input.Conversion]
+		if !ok {
+			return "api_invalid_conversion"
+		}
+		defaultUnit = conv.Unit
+		input.Expression = ""
+	case types.VirtualExpression:
+		expr, err := utils.CompileExpression(input.Expression)
+		if err != nil {
+			return err.Error()
+		}
+		input.Inputs = expr.SensorIDs()
+		minInputs, maxInputs = 0, MaxVirtualSensorInputs
+		input.Conversion = ""
+	}
+
+	if len(input.Inputs) < minInputs || len(input.Inputs) > maxInputs {
+		return "api_virtual_sensor_inputs_invalid"
+	}
+	seen := map[int]bool{}
+	for _, id := range input.Inputs {
+		if id <= 0 || id == selfSensorID || seen[id] {
+			return "api_virtual_sensor_inputs_invalid"
+		}
+		seen[id] = true
+	}
+
+	if len(input.Inputs) > 0 {
+		placeholders := make([]string, len(input.Inputs))
+		args := make([]interface{}, len(input.Inputs))
+		for i, id := range input.Inputs {
+			placeholders[i] = fmt.Sprintf("$%d", i+1)
+			args[i] = id
+		}
+		rows, err := db.Query(
+			fmt.Sprintf("SELECT id, unit FROM sensors WHERE id IN (%s)", strings.Join(placeholders, ",")),
+			args...,
+		)
+		if err != nil {
+			logger.Log.WithError(err).Error("Failed to look up virtual sensor inputs")
+			return "api_database_error"
+		}
+		units := map[int]string{}
+		for rows.Next() {
+			var id int
+			var unit string
+			if err := rows.Scan(&id, &unit); err == nil {
+				units[id] = unit
+			}
+		}
+		rows.Close()
+		if len(units) != len(input.Inputs) {
+			return "api_invalid_sensor_ids"
+		}
+		// Temperature/humidity pairs may arrive in either order; the
+		// humidity input is the one reported in percent.
+		if minInputs == 2 && maxInputs == 2 &&
+			strings.Contains(units[input.Inputs[0]], "%") && !strings.Contains(units[input.Inputs[1]], "%") {
+			input.Inputs[0], input.Inputs[1] = input.Inputs[1], input.Inputs[0]
+		}
+		// Dew point and aggregates report in their (first) input's unit.
+		if defaultUnit == "" {
+			defaultUnit = units[input.Inputs[0]]
+		}
+	}
+
+	if input.Unit == "" {
+		input.Unit = defaultUnit
+	}
+	if input.Unit == "" {
+		input.Unit = "units"
+	}
+	return ""
+}
@@ -21,6 +21,7 @@ import (
 	"isley/handlers"
 	"isley/logger"
 	"isley/model"
+	"isley/storage"
 	"isley/utils"
 	"isley/watcher"
 )
@@ -44,6 +45,10 @@ var trustedProxies = []string{
 func main() {
 	logger.InitLogger()
 
+	if len(os.Args) > 1 && os.Args[1] == migrateStorageCommand {
+		os.Exit(runMigrateStorage(os.Args[2:]))
+	}
+
 	version := fmt.Sprintf("Isley %s", getVersion())
 	logger.Log.Info("Starting application version:", version)
 
@@ -87,6 +92,7 @@ func main() {
 	// threaded into app.NewEngine, the watcher, and the grabber.
 	configStore := config.NewStore()
 	handlers.LoadSettings(db, configStore)
+	handlers.FailInterruptedTimelapses(db)
 
 	if configStore.SensorRetention() <= 0 {
 		logger.Log.Warn("Sensor data retention is disabled (sensor_retention_days = 0). " +
@@ -147,6 +153,14 @@ func main() {
 		}
 	}
 
+	// Uploaded photos and stream frames live on local disk unless
+	// ISLEY_STORAGE_BACKEND selects object storage.
+	files, err := storage.New(storage.ConfigFromEnv(handlers.DefaultUploadDir))
+	if err != nil {
+		logger.Log.WithError(err).Fatal("Failed to configure upload storage")
+	}
+	logger.Log.WithField("backend", files.Name()).Info("Upload storage configured")
+
 	engineCfg := app.ResolvePathDefaults(app.Config{
 		DB:                    db,
 		Assets:                embeddedFiles,
@@ -159,6 +173,7 @@ func main() {
 		GuestMode:             configStore.GuestMode() == 1,
 		TrustedProxies:        trustedProxies,
 		DataDir:               "data",
+		Storage:               files,
 		ConfigStore:           configStore,
 	})
 	engine, err := app.NewEngine(engineCfg)
@@ -166,13 +181,31 @@ func main() {
 		logger.Log.WithError(err).Fatal("Failed to construct HTTP engine")
 	}
 
-	grabber := watcher.NewGrabber(configStore, engineCfg.FrameDir)
+	grabber := watcher.NewGrabber(db, configStore, engineCfg.FrameDir)
+	if _, local := storage.LocalPath(files, ""); !local {
+		grabber.Files = files
+	}
 	bgWG.Add(1)
 	go func() {
 		defer bgWG.Done()
 		grabber.Run(ctx)
 	}()
 
+	dailyPhotos := watcher.NewDailyPhotos(db, configStore, engineCfg.FrameDir, engineCfg.UploadDir)
+	dailyPhotos.Files = files
+	bgWG.Add(1)
+	go func() {
+		defer bgWG.Done()
+		dailyPhotos.Run(ctx)
+	}()
+
+	taskReminders := watcher.NewTaskReminders(db, configStore)
+	bgWG.Add(1)
+	go func() {
+		defer bgWG.Done()
+		taskReminders.Run(ctx)
+	}()
+
 	srv := &http.Server{
 		Addr:    ":" + port,
 		Handler: engine,
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"isley/handlers"
+	"isley/storage"
+)
+
+// migrateStorageCommand is the subcommand that copies uploads between
+// storage backends: `isley migrate-storage -to s3`.
+const migrateStorageCommand = "migrate-storage"
+
+// runMigrateStorage copies every upload from one storage backend to
+// another and returns the process exit code. Both backends are
+// configured the way the server configures its own: the local backend
+// is rooted at -upload-dir and S3 is read from the ISLEY_S3_*
+// environment variables. The stream frame archive only ever lives on
+// local disk and is left out.
+func runMigrateStorage(args []string) int {
+	fs := flag.NewFlagSet(migrateStorageCommand, flag.ContinueOnError)
+	from := fs.String("from", storage.BackendLocal, "backend to copy from (local or s3)")
+	to := fs.String("to", "", "backend to copy to (local or s3)")
+	uploadDir := fs.String("upload-dir", handlers.DefaultUploadDir, "upload directory of the local backend")
+	deleteSource := fs.Bool("delete-source", false, "remove each file from the source once it is copied")
+	dryRun := fs.Bool("dry-run", false, "list what would be copied without changing anything")
+	overwrite := fs.Bool("overwrite", false, "copy files that already exist at the destination with the same size")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: isley %s -to <local|s3> [flags]\n\n", migrateStorageCommand)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *to == "" || *to == *from {
+		fmt.Fprintln(os.Stderr, "migrate-storage: -to must name a backend other than -from")
+		fs.Usage()
+		return 2
+	}
+
+	src, err := migrationStore(*from, *uploadDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate-storage: source: %v\n", err)
+		return 1
+	}
+	dst, err := migrationStore(*to, *uploadDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate-storage: destination: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	stats, err := storage.Migrate(ctx, src, dst, storage.MigrateOptions{
+		Exclude:      []string{"streams/archive/"},
+		Overwrite:    *overwrite,
+		DeleteSource: *deleteSource,
+		DryRun:       *dryRun,
+		Progress: func(obj storage.Object, action string) {
+			fmt.Printf("%-7s %s (%d bytes)\n", action, obj.Key, obj.Size)
+		},
+	})
+	fmt.Printf("%d copied (%d bytes), %d already present, %d deleted from %s\n",
+		stats.Copied, stats.Bytes, stats.Skipped, stats.Deleted, src.Name())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate-storage: %v\n", err)
+		return 1
+	}
+	if *dryRun {
+		fmt.Println("Dry run: nothing was changed.")
+	}
+	return 0
+}
+
+// migrationStore builds the named backend for runMigrateStorage.
+func migrationStore(backend, uploadDir string) (storage.Store, error) {
+	cfg := storage.ConfigFromEnv(uploadDir)
+	cfg.Backend = backend
+	return storage.New(cfg)
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"database/sql"
+	"errors"
+
+	"isley/logger"
+	"isley/model/types"
+)
+
+// ActiveCalibration returns the newest calibration recorded for sensorID,
+// or types.IdentityCalibration when it has none. Both ingest paths (the
+// watcher's poller and the HTTP ingest API) call it before storing a
+// reading. A lookup failure is logged and treated as uncalibrated so a
+// reading is never dropped over it.
+func ActiveCalibration(db *sql.DB, sensorID int) types.SensorCalibration {
+	cal := types.IdentityCalibration
+	err := db.QueryRow(
+		"SELECT scale, offset_value FROM sensor_calibrations WHERE sensor_id = $1 ORDER BY id DESC LIMIT 1",
+		sensorID,
+	).Scan(&cal.Scale, &cal.Offset)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		logger.Log.WithError(err).WithField("sensor_id", sensorID).Warn("Failed to load sensor calibration, storing raw value")
+		return types.IdentityCalibration
+	}
+	return cal
+}
@@ -0,0 +1 @@
+DROP TABLE virtual_sensors;
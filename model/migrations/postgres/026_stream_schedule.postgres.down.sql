@@ -0,0 +1,5 @@
+ALTER TABLE streams DROP COLUMN IF EXISTS light_threshold;
+ALTER TABLE streams DROP COLUMN IF EXISTS light_sensor_id;
+ALTER TABLE streams DROP COLUMN IF EXISTS active_end;
+ALTER TABLE streams DROP COLUMN IF EXISTS active_start;
+ALTER TABLE streams DROP COLUMN IF EXISTS grab_interval;
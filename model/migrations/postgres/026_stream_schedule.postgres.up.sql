@@ -0,0 +1,11 @@
+-- Per-stream capture schedule. grab_interval overrides the global
+-- stream grab interval (seconds, 0 = use the global setting).
+-- active_start/active_end bound captures to a daily HH:MM window in the
+-- app timezone; the window may wrap midnight and is unrestricted when
+-- either end is blank. When light_sensor_id is set, frames are only
+-- captured while that sensor's latest reading exceeds light_threshold.
+ALTER TABLE streams ADD COLUMN grab_interval INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE streams ADD COLUMN active_start TEXT NOT NULL DEFAULT '';
+ALTER TABLE streams ADD COLUMN active_end TEXT NOT NULL DEFAULT '';
+ALTER TABLE streams ADD COLUMN light_sensor_id INTEGER REFERENCES sensors(id) ON DELETE SET NULL;
+ALTER TABLE streams ADD COLUMN light_threshold REAL;
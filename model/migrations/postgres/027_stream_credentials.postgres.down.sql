@@ -0,0 +1,4 @@
+ALTER TABLE streams DROP COLUMN IF EXISTS grab_timeout;
+ALTER TABLE streams DROP COLUMN IF EXISTS rtsp_transport;
+ALTER TABLE streams DROP COLUMN IF EXISTS password;
+ALTER TABLE streams DROP COLUMN IF EXISTS username;
@@ -0,0 +1,20 @@
+-- Tags on plant photos (deficiency, pest, trichomes, ...) so the cross-plant
+-- gallery can find every photo of a problem or stage. Tag names are stored
+-- lowercase and are shared by all plants; plant_image_tags links them to
+-- individual images.
+CREATE TABLE image_tags (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    create_dt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO image_tags (name) VALUES ('deficiency'), ('pest'), ('trichomes'), ('training'), ('harvest');
+
+CREATE TABLE plant_image_tags (
+    id SERIAL PRIMARY KEY,
+    image_id INTEGER NOT NULL REFERENCES plant_images(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES image_tags(id) ON DELETE CASCADE,
+    UNIQUE (image_id, tag_id)
+);
+
+CREATE INDEX idx_plant_image_tags_tag_id ON plant_image_tags(tag_id);
@@ -0,0 +1,65 @@
+-- Harvest and post-harvest tracking. One harvest per plant records the
+-- wet, trim and dry weights (grams), the light wattage and canopy area the
+-- plant was grown under for yield-efficiency figures, and the dry-room
+-- temperature + humidity sensors whose readings describe the dry.
+-- plant.harvest_weight stays the headline yield and is kept in sync.
+CREATE TABLE harvests (
+    id SERIAL PRIMARY KEY,
+    plant_id INTEGER NOT NULL UNIQUE REFERENCES plant(id) ON DELETE CASCADE,
+    harvest_date TIMESTAMP NOT NULL,
+    wet_weight NUMERIC(10,2),
+    trim_weight NUMERIC(10,2),
+    dry_weight NUMERIC(10,2),
+    light_watts REAL,
+    canopy_area REAL,
+    dry_temp_sensor_id INTEGER REFERENCES sensors(id) ON DELETE SET NULL,
+    dry_humidity_sensor_id INTEGER REFERENCES sensors(id) ON DELETE SET NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    create_dt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    update_dt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Per-day weights while the harvest hangs to dry.
+CREATE TABLE harvest_dry_logs (
+    id SERIAL PRIMARY KEY,
+    harvest_id INTEGER NOT NULL REFERENCES harvests(id) ON DELETE CASCADE,
+    date TIMESTAMP NOT NULL,
+    weight NUMERIC(10,2) NOT NULL,
+    notes TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_harvest_dry_logs_harvest ON harvest_dry_logs (harvest_id, date);
+
+-- Cure jars and their burp / relative-humidity log.
+CREATE TABLE cure_jars (
+    id SERIAL PRIMARY KEY,
+    harvest_id INTEGER NOT NULL REFERENCES harvests(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    weight NUMERIC(10,2),
+    sealed_date TIMESTAMP NOT NULL,
+    notes TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_cure_jars_harvest ON cure_jars (harvest_id);
+
+CREATE TABLE cure_jar_logs (
+    id SERIAL PRIMARY KEY,
+    jar_id INTEGER NOT NULL REFERENCES cure_jars(id) ON DELETE CASCADE,
+    date TIMESTAMP NOT NULL,
+    burped BOOLEAN NOT NULL DEFAULT FALSE,
+    humidity REAL,
+    notes TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_cure_jar_logs_jar ON cure_jar_logs (jar_id, date);
+
+-- The final yield, split by grade (e.g. "A", "Smalls", "Trim"). When any
+-- grades are recorded their total is the plant's yield.
+CREATE TABLE harvest_yields (
+    id SERIAL PRIMARY KEY,
+    harvest_id INTEGER NOT NULL REFERENCES harvests(id) ON DELETE CASCADE,
+    grade TEXT NOT NULL,
+    weight NUMERIC(10,2) NOT NULL
+);
+
+CREATE INDEX idx_harvest_yields_harvest ON harvest_yields (harvest_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_plant_grow_run;
+ALTER TABLE plant DROP COLUMN IF EXISTS grow_run_id;
+DROP INDEX IF EXISTS idx_grow_runs_zone;
+DROP TABLE IF EXISTS grow_runs;
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_activity_nutrients_activity;
+DROP TABLE IF EXISTS activity_nutrients;
+ALTER TABLE plant_activity DROP COLUMN IF EXISTS feed_volume_l;
+ALTER TABLE plant_activity DROP COLUMN IF EXISTS feed_recipe_id;
+ALTER TABLE plant DROP COLUMN IF EXISTS feeding_schedule_id;
+DROP TABLE IF EXISTS feeding_schedule_weeks;
+DROP TABLE IF EXISTS feeding_schedules;
+DROP TABLE IF EXISTS nutrient_recipe_items;
+DROP TABLE IF EXISTS nutrient_recipes;
+DROP TABLE IF EXISTS nutrient_products;
@@ -0,0 +1,42 @@
+-- Tasks are reminders tied to a plant, a zone or a grow run (exactly one).
+-- schedule picks when they fall due:
+--   once      on due_date, until completed_at is set;
+--   interval  every interval_days, first on due_date and then counting
+--             from last_done;
+--   stage     on day stage_day of stage_status_id, once per plant and per
+--             time the plant enters that stage.
+-- Completing a task logs activity_id against its plants when one is set.
+CREATE TABLE tasks (
+    id SERIAL PRIMARY KEY,
+    title TEXT NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    activity_id INTEGER REFERENCES activity(id) ON DELETE SET NULL,
+    plant_id INTEGER REFERENCES plant(id) ON DELETE CASCADE,
+    zone_id INTEGER REFERENCES zones(id) ON DELETE CASCADE,
+    grow_run_id INTEGER REFERENCES grow_runs(id) ON DELETE CASCADE,
+    schedule TEXT NOT NULL DEFAULT 'once',
+    due_date TIMESTAMP,
+    interval_days INTEGER,
+    stage_status_id INTEGER REFERENCES plant_status(id) ON DELETE CASCADE,
+    stage_day INTEGER,
+    last_done TIMESTAMP,
+    completed_at TIMESTAMP,
+    create_dt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    update_dt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_tasks_plant ON tasks (plant_id);
+CREATE INDEX idx_tasks_zone ON tasks (zone_id);
+CREATE INDEX idx_tasks_grow_run ON tasks (grow_run_id);
+
+-- One row per completion. plant_id is set for stage tasks, which are
+-- completed plant by plant.
+CREATE TABLE task_completions (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    plant_id INTEGER REFERENCES plant(id) ON DELETE CASCADE,
+    done_at TIMESTAMP NOT NULL,
+    note TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_task_completions_task ON task_completions (task_id, plant_id);
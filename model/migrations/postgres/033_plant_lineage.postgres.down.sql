@@ -0,0 +1,3 @@
+UPDATE activity SET lock = FALSE WHERE name = 'Clone';
+DROP INDEX IF EXISTS idx_plant_parent;
+ALTER TABLE plant DROP COLUMN keeper;
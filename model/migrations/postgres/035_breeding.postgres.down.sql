@@ -0,0 +1,5 @@
+UPDATE activity SET lock = FALSE WHERE name = 'Pollinate';
+DROP INDEX IF EXISTS idx_pollinations_seed_strain;
+DROP INDEX IF EXISTS idx_pollinations_project;
+DROP TABLE IF EXISTS pollinations;
+DROP TABLE IF EXISTS breeding_projects;
@@ -0,0 +1,41 @@
+-- A breeding project groups the crosses made towards one goal.
+CREATE TABLE breeding_projects (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    goal TEXT NOT NULL DEFAULT '',
+    notes TEXT NOT NULL DEFAULT '',
+    create_dt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    update_dt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A pollination crosses a female plant with pollen from a male plant, or
+-- from a source we don't grow (pollen_source). The parents' strains are
+-- kept as they were on the day, so the cross still knows its parentage
+-- once the plants are gone. generation is a label such as F1, F2, BX1 or
+-- S1. Harvesting the seeds creates seed_strain_id, with its lineage set
+-- from the parent strains, and a seed lot of seeds_harvested seeds.
+CREATE TABLE pollinations (
+    id SERIAL PRIMARY KEY,
+    project_id INTEGER REFERENCES breeding_projects(id) ON DELETE SET NULL,
+    female_plant_id INTEGER REFERENCES plant(id) ON DELETE SET NULL,
+    female_strain_id INTEGER REFERENCES strain(id) ON DELETE SET NULL,
+    male_plant_id INTEGER REFERENCES plant(id) ON DELETE SET NULL,
+    male_strain_id INTEGER REFERENCES strain(id) ON DELETE SET NULL,
+    pollen_source TEXT NOT NULL DEFAULT '',
+    pollination_date TIMESTAMP NOT NULL,
+    generation TEXT NOT NULL DEFAULT '',
+    notes TEXT NOT NULL DEFAULT '',
+    seed_strain_id INTEGER REFERENCES strain(id) ON DELETE SET NULL,
+    seeds_harvested INTEGER,
+    harvest_date TIMESTAMP,
+    create_dt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    update_dt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_pollinations_project ON pollinations (project_id, pollination_date);
+CREATE INDEX idx_pollinations_seed_strain ON pollinations (seed_strain_id);
+
+-- Logging a pollination logs this activity on the female.
+INSERT INTO activity (name, lock)
+    SELECT 'Pollinate', TRUE WHERE NOT EXISTS (SELECT 1 FROM activity WHERE name = 'Pollinate');
+UPDATE activity SET lock = TRUE WHERE name = 'Pollinate';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS strain_review_tags;
+DROP INDEX IF EXISTS idx_strain_reviews_plant;
+DROP INDEX IF EXISTS idx_strain_reviews_strain;
+DROP TABLE IF EXISTS strain_reviews;
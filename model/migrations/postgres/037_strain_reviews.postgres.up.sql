@@ -0,0 +1,27 @@
+-- A smoke report on a harvested plant. strain_id is the plant's strain on
+-- the day, so the review stays with the strain once the plant is gone.
+-- rating is 1 to 5 stars; potency, when given, runs from 1 (mild) to 5
+-- (overwhelming).
+CREATE TABLE strain_reviews (
+    id SERIAL PRIMARY KEY,
+    strain_id INTEGER NOT NULL REFERENCES strain(id) ON DELETE CASCADE,
+    plant_id INTEGER REFERENCES plant(id) ON DELETE SET NULL,
+    review_date TIMESTAMP NOT NULL,
+    rating INTEGER NOT NULL,
+    potency INTEGER,
+    notes TEXT NOT NULL DEFAULT '',
+    create_dt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    update_dt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_strain_reviews_strain ON strain_reviews (strain_id, review_date);
+CREATE INDEX idx_strain_reviews_plant ON strain_reviews (plant_id);
+
+-- Aroma/flavor and effect tags of a review. kind is 'flavor' or 'effect'.
+CREATE TABLE strain_review_tags (
+    id SERIAL PRIMARY KEY,
+    review_id INTEGER NOT NULL REFERENCES strain_reviews(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    tag TEXT NOT NULL,
+    UNIQUE (review_id, kind, tag)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS breeder_cannadb_locks;
+DROP TABLE IF EXISTS strain_cannadb_locks;
@@ -0,0 +1,19 @@
+-- Fields of a CannaDB-imported strain or breeder that were edited locally.
+-- A CannaDB sync leaves locked fields alone. field is one of the synced
+-- field names (name, breeder, indica_sativa, autoflower, description,
+-- short_desc, cycle_time, url, lineage; breeders only have name).
+CREATE TABLE strain_cannadb_locks (
+    id SERIAL PRIMARY KEY,
+    strain_id INTEGER NOT NULL REFERENCES strain(id) ON DELETE CASCADE,
+    field TEXT NOT NULL,
+    create_dt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (strain_id, field)
+);
+
+CREATE TABLE breeder_cannadb_locks (
+    id SERIAL PRIMARY KEY,
+    breeder_id INTEGER NOT NULL REFERENCES breeder(id) ON DELETE CASCADE,
+    field TEXT NOT NULL,
+    create_dt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (breeder_id, field)
+);
@@ -0,0 +1,20 @@
+-- Virtual sensors: sensors whose readings the watcher computes each poll cycle
+-- from other sensors (dew point, DLI, averages, unit conversion, expressions).
+-- The output is an ordinary sensors row (source = 'virtual') so charts, the
+-- overlay and zone cards read it like any polled sensor; this table only holds
+-- the definition behind it.
+--
+-- inputs is a JSON array of source sensor IDs in the order the function reads
+-- them (e.g. [temperature, humidity] for dew_point). For expression sensors it
+-- is derived from the s<ID> variables in the expression.
+CREATE TABLE virtual_sensors (
+                                 id INTEGER PRIMARY KEY AUTOINCREMENT,
+                                 sensor_id INTEGER NOT NULL UNIQUE,
+                                 function_name TEXT NOT NULL,
+                                 inputs TEXT NOT NULL DEFAULT '[]',
+                                 expression TEXT NOT NULL DEFAULT '',
+                                 conversion TEXT NOT NULL DEFAULT '',
+                                 create_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                 update_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                 FOREIGN KEY (sensor_id) REFERENCES sensors(id) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_sensor_calibrations_sensor;
+DROP TABLE sensor_calibrations;
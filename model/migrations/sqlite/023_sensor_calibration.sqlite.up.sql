@@ -0,0 +1,31 @@
+-- Per-sensor calibration history. The newest row for a sensor is its active
+-- calibration and is applied at ingest as value*scale + offset_value; a sensor
+-- with no rows is uncalibrated. Rows are never updated, so the table doubles
+-- as the audit trail of calibration changes.
+--
+-- method is 'linear' (offset/scale entered directly) or 'two_point' (scale and
+-- offset derived from raw_low/ref_low and raw_high/ref_high, kept for display).
+--
+-- last_data_id is the highest sensor_data.id for the sensor when the row was
+-- written: readings above it were stored with this calibration. retroactive
+-- marks rows that also rewrote every reading up to last_data_id. Together they
+-- tell which calibration each stored reading carries, so a later retroactive
+-- change can undo the right one.
+CREATE TABLE sensor_calibrations (
+                                     id INTEGER PRIMARY KEY AUTOINCREMENT,
+                                     sensor_id INTEGER NOT NULL,
+                                     method TEXT NOT NULL DEFAULT 'linear',
+                                     scale REAL NOT NULL DEFAULT 1,
+                                     offset_value REAL NOT NULL DEFAULT 0,
+                                     raw_low REAL,
+                                     ref_low REAL,
+                                     raw_high REAL,
+                                     ref_high REAL,
+                                     retroactive BOOLEAN NOT NULL DEFAULT FALSE,
+                                     last_data_id INTEGER NOT NULL DEFAULT 0,
+                                     note TEXT NOT NULL DEFAULT '',
+                                     create_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                     FOREIGN KEY (sensor_id) REFERENCES sensors(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_sensor_calibrations_sensor ON sensor_calibrations (sensor_id, id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_sensor_data_quarantine_sensor;
+DROP TABLE sensor_data_quarantine;
+DROP TABLE sensor_filters;
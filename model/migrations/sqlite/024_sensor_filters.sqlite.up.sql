@@ -0,0 +1,36 @@
+-- Outlier and spike filtering. A rule applies to every sensor whose type
+-- matches sensor_type exactly (e.g. 'ACI.humidity') and is checked on the
+-- calibrated value before it is stored. NULL limits are not checked.
+--
+--   min_value / max_value   plausible range
+--   max_delta_per_min       largest change per minute since the last stored reading
+--   median_window           number of recent stored readings to take the median of (0 = off)
+--   median_max_deviation    largest distance from that median
+--
+-- Rejected readings are counted on the rule and dropped, or kept in
+-- sensor_data_quarantine when quarantine is set.
+CREATE TABLE sensor_filters (
+                                id INTEGER PRIMARY KEY AUTOINCREMENT,
+                                sensor_type TEXT NOT NULL UNIQUE,
+                                min_value REAL,
+                                max_value REAL,
+                                max_delta_per_min REAL,
+                                median_window INTEGER NOT NULL DEFAULT 0,
+                                median_max_deviation REAL,
+                                quarantine BOOLEAN NOT NULL DEFAULT FALSE,
+                                rejected_count INTEGER NOT NULL DEFAULT 0,
+                                last_rejected_dt DATETIME,
+                                create_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                update_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE sensor_data_quarantine (
+                                        id INTEGER PRIMARY KEY AUTOINCREMENT,
+                                        sensor_id INTEGER NOT NULL,
+                                        value REAL NOT NULL,
+                                        reason TEXT NOT NULL,
+                                        create_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                        FOREIGN KEY (sensor_id) REFERENCES sensors(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_sensor_data_quarantine_sensor ON sensor_data_quarantine (sensor_id, create_dt);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_timelapses_stream;
+DROP TABLE IF EXISTS timelapses;
+ALTER TABLE streams DROP COLUMN archive_max_mb;
+ALTER TABLE streams DROP COLUMN archive_max_frames;
+ALTER TABLE streams DROP COLUMN archive_enabled;
@@ -0,0 +1,33 @@
+-- Optional per-stream frame archive. When archive_enabled is set the
+-- grabber keeps a timestamped copy of every captured frame under
+-- <stream dir>/archive/stream_<id>/, pruning oldest-first to stay within
+-- archive_max_frames and archive_max_mb (0 = no limit on that axis).
+ALTER TABLE streams ADD COLUMN archive_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE streams ADD COLUMN archive_max_frames INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE streams ADD COLUMN archive_max_mb INTEGER NOT NULL DEFAULT 0;
+
+-- Timelapse videos rendered from a stream's archive. Rendering runs in
+-- the background; status moves pending -> rendering -> done|failed and
+-- file_path is set once the video exists. stream_id is nulled rather
+-- than cascaded so finished videos survive deleting their stream.
+CREATE TABLE timelapses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    stream_id INTEGER,
+    name TEXT NOT NULL,
+    start_dt DATETIME NOT NULL,
+    end_dt DATETIME NOT NULL,
+    fps INTEGER NOT NULL DEFAULT 24,
+    format TEXT NOT NULL DEFAULT 'mp4',
+    burn_in TEXT NOT NULL DEFAULT '',
+    sensor_id INTEGER,
+    status TEXT NOT NULL DEFAULT 'pending',
+    frame_count INTEGER NOT NULL DEFAULT 0,
+    file_path TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    create_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    update_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (stream_id) REFERENCES streams(id) ON DELETE SET NULL,
+    FOREIGN KEY (sensor_id) REFERENCES sensors(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_timelapses_stream ON timelapses (stream_id);
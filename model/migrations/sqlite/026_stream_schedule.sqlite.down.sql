@@ -0,0 +1,5 @@
+ALTER TABLE streams DROP COLUMN light_threshold;
+ALTER TABLE streams DROP COLUMN light_sensor_id;
+ALTER TABLE streams DROP COLUMN active_end;
+ALTER TABLE streams DROP COLUMN active_start;
+ALTER TABLE streams DROP COLUMN grab_interval;
@@ -0,0 +1,4 @@
+ALTER TABLE streams DROP COLUMN grab_timeout;
+ALTER TABLE streams DROP COLUMN rtsp_transport;
+ALTER TABLE streams DROP COLUMN password;
+ALTER TABLE streams DROP COLUMN username;
@@ -0,0 +1,9 @@
+-- Camera credentials and FFmpeg capture options. username/password are
+-- kept out of the URL so the URL can be shown and logged as-is; the
+-- password is blanked in backups. rtsp_transport selects the RTSP
+-- transport FFmpeg uses ('tcp' or 'udp') and grab_timeout bounds a
+-- single capture in seconds (0 = the built-in default).
+ALTER TABLE streams ADD COLUMN username TEXT NOT NULL DEFAULT '';
+ALTER TABLE streams ADD COLUMN password TEXT NOT NULL DEFAULT '';
+ALTER TABLE streams ADD COLUMN rtsp_transport TEXT NOT NULL DEFAULT 'tcp';
+ALTER TABLE streams ADD COLUMN grab_timeout INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_plant_image_tags_tag_id;
+DROP TABLE IF EXISTS plant_image_tags;
+DROP TABLE IF EXISTS image_tags;
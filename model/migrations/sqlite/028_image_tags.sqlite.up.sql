@@ -0,0 +1,26 @@
+-- Tags on plant photos (deficiency, pest, trichomes, ...) so the cross-plant
+-- gallery can find every photo of a problem or stage. Tag names are stored
+-- lowercase and are shared by all plants; plant_image_tags links them to
+-- individual images.
+CREATE TABLE image_tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    create_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO image_tags (name) VALUES ('deficiency');
+INSERT INTO image_tags (name) VALUES ('pest');
+INSERT INTO image_tags (name) VALUES ('trichomes');
+INSERT INTO image_tags (name) VALUES ('training');
+INSERT INTO image_tags (name) VALUES ('harvest');
+
+CREATE TABLE plant_image_tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    image_id INTEGER NOT NULL,
+    tag_id INTEGER NOT NULL,
+    UNIQUE(image_id, tag_id),
+    FOREIGN KEY (image_id) REFERENCES plant_images(id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES image_tags(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_plant_image_tags_tag_id ON plant_image_tags(tag_id);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_harvest_yields_harvest;
+DROP TABLE IF EXISTS harvest_yields;
+DROP INDEX IF EXISTS idx_cure_jar_logs_jar;
+DROP TABLE IF EXISTS cure_jar_logs;
+DROP INDEX IF EXISTS idx_cure_jars_harvest;
+DROP TABLE IF EXISTS cure_jars;
+DROP INDEX IF EXISTS idx_harvest_dry_logs_harvest;
+DROP TABLE IF EXISTS harvest_dry_logs;
+DROP TABLE IF EXISTS harvests;
@@ -0,0 +1,72 @@
+-- Harvest and post-harvest tracking. One harvest per plant records the
+-- wet, trim and dry weights (grams), the light wattage and canopy area the
+-- plant was grown under for yield-efficiency figures, and the dry-room
+-- temperature + humidity sensors whose readings describe the dry.
+-- plant.harvest_weight stays the headline yield and is kept in sync.
+CREATE TABLE harvests (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    plant_id INTEGER NOT NULL UNIQUE,
+    harvest_date DATETIME NOT NULL,
+    wet_weight REAL,
+    trim_weight REAL,
+    dry_weight REAL,
+    light_watts REAL,
+    canopy_area REAL,
+    dry_temp_sensor_id INTEGER,
+    dry_humidity_sensor_id INTEGER,
+    notes TEXT NOT NULL DEFAULT '',
+    create_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    update_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (plant_id) REFERENCES plant(id) ON DELETE CASCADE,
+    FOREIGN KEY (dry_temp_sensor_id) REFERENCES sensors(id) ON DELETE SET NULL,
+    FOREIGN KEY (dry_humidity_sensor_id) REFERENCES sensors(id) ON DELETE SET NULL
+);
+
+-- Per-day weights while the harvest hangs to dry.
+CREATE TABLE harvest_dry_logs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    harvest_id INTEGER NOT NULL,
+    date DATETIME NOT NULL,
+    weight REAL NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY (harvest_id) REFERENCES harvests(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_harvest_dry_logs_harvest ON harvest_dry_logs (harvest_id, date);
+
+-- Cure jars and their burp / relative-humidity log.
+CREATE TABLE cure_jars (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    harvest_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    weight REAL,
+    sealed_date DATETIME NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY (harvest_id) REFERENCES harvests(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_cure_jars_harvest ON cure_jars (harvest_id);
+
+CREATE TABLE cure_jar_logs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    jar_id INTEGER NOT NULL,
+    date DATETIME NOT NULL,
+    burped BOOLEAN NOT NULL DEFAULT FALSE,
+    humidity REAL,
+    notes TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY (jar_id) REFERENCES cure_jars(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_cure_jar_logs_jar ON cure_jar_logs (jar_id, date);
+
+-- The final yield, split by grade (e.g. "A", "Smalls", "Trim"). When any
+-- grades are recorded their total is the plant's yield.
+CREATE TABLE harvest_yields (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    harvest_id INTEGER NOT NULL,
+    grade TEXT NOT NULL,
+    weight REAL NOT NULL,
+    FOREIGN KEY (harvest_id) REFERENCES harvests(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_harvest_yields_harvest ON harvest_yields (harvest_id);
@@ -0,0 +1,24 @@
+-- Grow runs group the plants of one cycle in a zone ("Tent A, Spring
+-- 2026") so yield and climate can be reported per run and compared with
+-- earlier runs. light_watts and canopy_area (square metres) describe the
+-- whole run; flip_date starts the flower stage and end_date closes it.
+CREATE TABLE grow_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    zone_id INTEGER,
+    start_date DATETIME NOT NULL,
+    flip_date DATETIME,
+    end_date DATETIME,
+    light_watts REAL,
+    canopy_area REAL,
+    notes TEXT NOT NULL DEFAULT '',
+    create_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    update_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (zone_id) REFERENCES zones(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_grow_runs_zone ON grow_runs (zone_id, start_date);
+
+ALTER TABLE plant ADD COLUMN grow_run_id INTEGER REFERENCES grow_runs(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_plant_grow_run ON plant (grow_run_id);
@@ -0,0 +1,80 @@
+-- Nutrient library: products with their N-P-K and recommended dose per
+-- litre, and recipes that mix products at a per-litre amount with a target
+-- EC/pH. Amounts are in the product's unit (ml for liquids, g for powders).
+CREATE TABLE nutrient_products (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    brand TEXT NOT NULL DEFAULT '',
+    npk_n REAL NOT NULL DEFAULT 0,
+    npk_p REAL NOT NULL DEFAULT 0,
+    npk_k REAL NOT NULL DEFAULT 0,
+    dose_per_l REAL,
+    unit TEXT NOT NULL DEFAULT 'ml',
+    notes TEXT NOT NULL DEFAULT '',
+    create_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    update_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE nutrient_recipes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    target_ec REAL,
+    target_ph REAL,
+    notes TEXT NOT NULL DEFAULT '',
+    create_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    update_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE nutrient_recipe_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    recipe_id INTEGER NOT NULL,
+    product_id INTEGER NOT NULL,
+    amount_per_l REAL NOT NULL,
+    FOREIGN KEY (recipe_id) REFERENCES nutrient_recipes(id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES nutrient_products(id) ON DELETE CASCADE,
+    UNIQUE (recipe_id, product_id)
+);
+
+-- A feeding schedule picks a recipe for each week of a plant stage
+-- (Veg week 1, Veg week 2, Flower week 1, ...). A plant follows at most one
+-- schedule.
+CREATE TABLE feeding_schedules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    create_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    update_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE feeding_schedule_weeks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    schedule_id INTEGER NOT NULL,
+    status_id INTEGER NOT NULL,
+    week INTEGER NOT NULL,
+    recipe_id INTEGER NOT NULL,
+    FOREIGN KEY (schedule_id) REFERENCES feeding_schedules(id) ON DELETE CASCADE,
+    FOREIGN KEY (status_id) REFERENCES plant_status(id) ON DELETE CASCADE,
+    FOREIGN KEY (recipe_id) REFERENCES nutrient_recipes(id) ON DELETE CASCADE,
+    UNIQUE (schedule_id, status_id, week)
+);
+
+ALTER TABLE plant ADD COLUMN feeding_schedule_id INTEGER REFERENCES feeding_schedules(id) ON DELETE SET NULL;
+
+-- A recorded feed keeps the recipe it started from, the volume mixed and
+-- the amount of each product actually used. Product name and unit are
+-- copied so the history survives the product being deleted.
+ALTER TABLE plant_activity ADD COLUMN feed_recipe_id INTEGER REFERENCES nutrient_recipes(id) ON DELETE SET NULL;
+ALTER TABLE plant_activity ADD COLUMN feed_volume_l REAL;
+
+CREATE TABLE activity_nutrients (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    plant_activity_id INTEGER NOT NULL,
+    product_id INTEGER,
+    product_name TEXT NOT NULL,
+    unit TEXT NOT NULL DEFAULT 'ml',
+    amount REAL NOT NULL,
+    FOREIGN KEY (plant_activity_id) REFERENCES plant_activity(id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES nutrient_products(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_activity_nutrients_activity ON activity_nutrients (plant_activity_id);
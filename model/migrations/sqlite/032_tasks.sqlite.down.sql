@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_task_completions_task;
+DROP TABLE IF EXISTS task_completions;
+DROP INDEX IF EXISTS idx_tasks_grow_run;
+DROP INDEX IF EXISTS idx_tasks_zone;
+DROP INDEX IF EXISTS idx_tasks_plant;
+DROP TABLE IF EXISTS tasks;
@@ -0,0 +1,49 @@
+-- Tasks are reminders tied to a plant, a zone or a grow run (exactly one).
+-- schedule picks when they fall due:
+--   once      on due_date, until completed_at is set;
+--   interval  every interval_days, first on due_date and then counting
+--             from last_done;
+--   stage     on day stage_day of stage_status_id, once per plant and per
+--             time the plant enters that stage.
+-- Completing a task logs activity_id against its plants when one is set.
+CREATE TABLE tasks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    activity_id INTEGER,
+    plant_id INTEGER,
+    zone_id INTEGER,
+    grow_run_id INTEGER,
+    schedule TEXT NOT NULL DEFAULT 'once',
+    due_date DATETIME,
+    interval_days INTEGER,
+    stage_status_id INTEGER,
+    stage_day INTEGER,
+    last_done DATETIME,
+    completed_at DATETIME,
+    create_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    update_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (activity_id) REFERENCES activity(id) ON DELETE SET NULL,
+    FOREIGN KEY (plant_id) REFERENCES plant(id) ON DELETE CASCADE,
+    FOREIGN KEY (zone_id) REFERENCES zones(id) ON DELETE CASCADE,
+    FOREIGN KEY (grow_run_id) REFERENCES grow_runs(id) ON DELETE CASCADE,
+    FOREIGN KEY (stage_status_id) REFERENCES plant_status(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_tasks_plant ON tasks (plant_id);
+CREATE INDEX idx_tasks_zone ON tasks (zone_id);
+CREATE INDEX idx_tasks_grow_run ON tasks (grow_run_id);
+
+-- One row per completion. plant_id is set for stage tasks, which are
+-- completed plant by plant.
+CREATE TABLE task_completions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    plant_id INTEGER,
+    done_at DATETIME NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (plant_id) REFERENCES plant(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_task_completions_task ON task_completions (task_id, plant_id);
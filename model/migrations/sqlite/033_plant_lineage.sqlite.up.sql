@@ -0,0 +1,11 @@
+-- keeper marks a mother plant kept for taking cuttings. Cuttings are
+-- plants with clone set and parent_plant_id pointing at their mother, so
+-- the family tree and per-mother clone stats come from parent_plant_id.
+ALTER TABLE plant ADD COLUMN keeper BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_plant_parent ON plant (parent_plant_id);
+
+-- Taking cuttings logs this activity on the mother.
+INSERT INTO activity (name, lock)
+    SELECT 'Clone', TRUE WHERE NOT EXISTS (SELECT 1 FROM activity WHERE name = 'Clone');
+UPDATE activity SET lock = TRUE WHERE name = 'Clone';
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_plant_seed_lot;
+ALTER TABLE plant DROP COLUMN germination_id;
+ALTER TABLE plant DROP COLUMN seed_lot_id;
+DROP INDEX IF EXISTS idx_germination_attempts_lot;
+DROP TABLE IF EXISTS germination_attempts;
+DROP INDEX IF EXISTS idx_seed_lots_strain;
+DROP TABLE IF EXISTS seed_lots;
@@ -0,0 +1,45 @@
+-- Seed lots are the packs of a strain on hand: where and when they were
+-- bought, the pack size, what it cost and whether the seeds are feminized.
+-- seeds_left counts the seeds still in the pack; strain.seed_count is kept
+-- in step as seeds are added to or taken from a lot.
+CREATE TABLE seed_lots (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    strain_id INTEGER NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    purchase_date DATETIME,
+    pack_size INTEGER NOT NULL,
+    seeds_left INTEGER NOT NULL,
+    cost REAL,
+    feminized BOOLEAN NOT NULL DEFAULT TRUE,
+    notes TEXT NOT NULL DEFAULT '',
+    create_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    update_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (strain_id) REFERENCES strain(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_seed_lots_strain ON seed_lots (strain_id);
+
+-- A germination attempt sows seeds from a lot, taking them out of it.
+-- sprouted stays NULL until the outcome is recorded; sprout_date is when
+-- the first seed broke ground.
+CREATE TABLE germination_attempts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    seed_lot_id INTEGER NOT NULL,
+    start_date DATETIME NOT NULL,
+    seeds INTEGER NOT NULL,
+    sprouted INTEGER,
+    sprout_date DATETIME,
+    method TEXT NOT NULL DEFAULT '',
+    notes TEXT NOT NULL DEFAULT '',
+    create_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    update_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (seed_lot_id) REFERENCES seed_lots(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_germination_attempts_lot ON germination_attempts (seed_lot_id, start_date);
+
+-- The lot a plant was started from, and the attempt it sprouted in.
+ALTER TABLE plant ADD COLUMN seed_lot_id INTEGER REFERENCES seed_lots(id) ON DELETE SET NULL;
+ALTER TABLE plant ADD COLUMN germination_id INTEGER REFERENCES germination_attempts(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_plant_seed_lot ON plant (seed_lot_id);
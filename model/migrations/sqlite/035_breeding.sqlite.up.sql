@@ -0,0 +1,47 @@
+-- A breeding project groups the crosses made towards one goal.
+CREATE TABLE breeding_projects (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    goal TEXT NOT NULL DEFAULT '',
+    notes TEXT NOT NULL DEFAULT '',
+    create_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    update_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A pollination crosses a female plant with pollen from a male plant, or
+-- from a source we don't grow (pollen_source). The parents' strains are
+-- kept as they were on the day, so the cross still knows its parentage
+-- once the plants are gone. generation is a label such as F1, F2, BX1 or
+-- S1. Harvesting the seeds creates seed_strain_id, with its lineage set
+-- from the parent strains, and a seed lot of seeds_harvested seeds.
+CREATE TABLE pollinations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    project_id INTEGER,
+    female_plant_id INTEGER,
+    female_strain_id INTEGER,
+    male_plant_id INTEGER,
+    male_strain_id INTEGER,
+    pollen_source TEXT NOT NULL DEFAULT '',
+    pollination_date DATETIME NOT NULL,
+    generation TEXT NOT NULL DEFAULT '',
+    notes TEXT NOT NULL DEFAULT '',
+    seed_strain_id INTEGER,
+    seeds_harvested INTEGER,
+    harvest_date DATETIME,
+    create_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    update_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (project_id) REFERENCES breeding_projects(id) ON DELETE SET NULL,
+    FOREIGN KEY (female_plant_id) REFERENCES plant(id) ON DELETE SET NULL,
+    FOREIGN KEY (female_strain_id) REFERENCES strain(id) ON DELETE SET NULL,
+    FOREIGN KEY (male_plant_id) REFERENCES plant(id) ON DELETE SET NULL,
+    FOREIGN KEY (male_strain_id) REFERENCES strain(id) ON DELETE SET NULL,
+    FOREIGN KEY (seed_strain_id) REFERENCES strain(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_pollinations_project ON pollinations (project_id, pollination_date);
+CREATE INDEX idx_pollinations_seed_strain ON pollinations (seed_strain_id);
+
+-- Logging a pollination logs this activity on the female.
+INSERT INTO activity (name, lock)
+    SELECT 'Pollinate', TRUE WHERE NOT EXISTS (SELECT 1 FROM activity WHERE name = 'Pollinate');
+UPDATE activity SET lock = TRUE WHERE name = 'Pollinate';
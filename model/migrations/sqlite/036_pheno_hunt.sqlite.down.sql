@@ -0,0 +1,3 @@
+ALTER TABLE plant DROP COLUMN pheno_keeper;
+DROP TABLE IF EXISTS pheno_scores;
+DROP TABLE IF EXISTS pheno_criteria;
@@ -0,0 +1,32 @@
+-- Pheno hunt scoring criteria. A plant's weighted score is the average of
+-- its scores weighted by each criterion's weight.
+CREATE TABLE pheno_criteria (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    weight REAL NOT NULL DEFAULT 1,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    create_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO pheno_criteria (name, weight, sort_order) VALUES
+    ('Vigor', 1, 1),
+    ('Structure', 1, 2),
+    ('Terpene', 1, 3),
+    ('Yield', 1, 4),
+    ('Resistance', 1, 5);
+
+-- One score from 1 to 10 per plant and criterion.
+CREATE TABLE pheno_scores (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    plant_id INTEGER NOT NULL,
+    criterion_id INTEGER NOT NULL,
+    score INTEGER NOT NULL,
+    update_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (plant_id) REFERENCES plant(id) ON DELETE CASCADE,
+    FOREIGN KEY (criterion_id) REFERENCES pheno_criteria(id) ON DELETE CASCADE,
+    UNIQUE (plant_id, criterion_id)
+);
+
+-- pheno_keeper marks the pick of a pheno hunt. It is separate from
+-- keeper, which marks a mother kept for cuttings.
+ALTER TABLE plant ADD COLUMN pheno_keeper BOOLEAN NOT NULL DEFAULT FALSE;
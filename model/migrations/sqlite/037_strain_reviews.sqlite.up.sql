@@ -0,0 +1,30 @@
+-- A smoke report on a harvested plant. strain_id is the plant's strain on
+-- the day, so the review stays with the strain once the plant is gone.
+-- rating is 1 to 5 stars; potency, when given, runs from 1 (mild) to 5
+-- (overwhelming).
+CREATE TABLE strain_reviews (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    strain_id INTEGER NOT NULL,
+    plant_id INTEGER,
+    review_date DATETIME NOT NULL,
+    rating INTEGER NOT NULL,
+    potency INTEGER,
+    notes TEXT NOT NULL DEFAULT '',
+    create_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    update_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (strain_id) REFERENCES strain(id) ON DELETE CASCADE,
+    FOREIGN KEY (plant_id) REFERENCES plant(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_strain_reviews_strain ON strain_reviews (strain_id, review_date);
+CREATE INDEX idx_strain_reviews_plant ON strain_reviews (plant_id);
+
+-- Aroma/flavor and effect tags of a review. kind is 'flavor' or 'effect'.
+CREATE TABLE strain_review_tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    review_id INTEGER NOT NULL,
+    kind TEXT NOT NULL,
+    tag TEXT NOT NULL,
+    FOREIGN KEY (review_id) REFERENCES strain_reviews(id) ON DELETE CASCADE,
+    UNIQUE (review_id, kind, tag)
+);
@@ -0,0 +1,21 @@
+-- Fields of a CannaDB-imported strain or breeder that were edited locally.
+-- A CannaDB sync leaves locked fields alone. field is one of the synced
+-- field names (name, breeder, indica_sativa, autoflower, description,
+-- short_desc, cycle_time, url, lineage; breeders only have name).
+CREATE TABLE strain_cannadb_locks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    strain_id INTEGER NOT NULL,
+    field TEXT NOT NULL,
+    create_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (strain_id, field),
+    FOREIGN KEY (strain_id) REFERENCES strain(id) ON DELETE CASCADE
+);
+
+CREATE TABLE breeder_cannadb_locks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    breeder_id INTEGER NOT NULL,
+    field TEXT NOT NULL,
+    create_dt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (breeder_id, field),
+    FOREIGN KEY (breeder_id) REFERENCES breeder(id) ON DELETE CASCADE
+);
@@ -0,0 +1,56 @@
+package model
+
+import "fmt"
+
+// HourlyBucket returns the SQL expression that truncates the timestamp
+// column col to the start of its hour, the bucket sensor_data_hourly is
+// keyed on.
+func HourlyBucket(col string) string {
+	if IsPostgres() {
+		return "date_trunc('hour', " + col + ")"
+	}
+	return "strftime('%Y-%m-%d %H:00:00', " + col + ")"
+}
+
+// HourlyRollupQuery returns the statement that aggregates the sensor_data
+// rows (aliased sd) matching where into sensor_data_hourly, replacing the
+// buckets it recomputes. An empty where aggregates every reading.
+func HourlyRollupQuery(where string) string {
+	if where != "" {
+		where = "WHERE " + where
+	}
+	bucket := HourlyBucket("sd.create_dt")
+	if IsPostgres() {
+		return fmt.Sprintf(`
+		INSERT INTO sensor_data_hourly (sensor_id, bucket, min_val, max_val, avg_val, sample_count)
+		SELECT
+			sd.sensor_id,
+			%[1]s AS bucket,
+			MIN(sd.value),
+			MAX(sd.value),
+			AVG(sd.value),
+			COUNT(*)
+		FROM sensor_data sd
+		%[2]s
+		GROUP BY sd.sensor_id, %[1]s
+		ON CONFLICT (sensor_id, bucket) DO UPDATE SET
+			min_val      = EXCLUDED.min_val,
+			max_val      = EXCLUDED.max_val,
+			avg_val      = EXCLUDED.avg_val,
+			sample_count = EXCLUDED.sample_count
+	`, bucket, where)
+	}
+	return fmt.Sprintf(`
+		INSERT OR REPLACE INTO sensor_data_hourly (sensor_id, bucket, min_val, max_val, avg_val, sample_count)
+		SELECT
+			sd.sensor_id,
+			%[1]s AS bucket,
+			MIN(sd.value),
+			MAX(sd.value),
+			AVG(sd.value),
+			COUNT(*)
+		FROM sensor_data sd
+		%[2]s
+		GROUP BY sd.sensor_id, %[1]s
+	`, bucket, where)
+}
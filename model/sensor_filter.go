@@ -0,0 +1,223 @@
+package model
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"isley/logger"
+	"isley/model/types"
+)
+
+// recentReading is one stored reading the spike checks compare against.
+type recentReading struct {
+	value float64
+	at    time.Time
+}
+
+// FilterReading checks a calibrated reading against the filter for its
+// sensor's type before it is stored. It returns "" when the reading should
+// be stored, or the rejection reason (one of the types.Filter* constants).
+//
+// A rejection is logged, counted on the filter, and kept in
+// sensor_data_quarantine when the filter quarantines; the caller only has to
+// skip the insert. Lookup failures let the reading through, since dropping
+// real data over a transient error is worse than storing one outlier.
+func FilterReading(db *sql.DB, sensorID int, value float64, now time.Time) string {
+	fieldLogger := logger.Log.WithField("func", "FilterReading")
+
+	var f types.SensorFilter
+	var minValue, maxValue, maxDelta, medianDev sql.NullFloat64
+	err := db.QueryRow(`
+		SELECT f.id, f.sensor_type, f.min_value, f.max_value, f.max_delta_per_min,
+		       f.median_window, f.median_max_deviation, f.quarantine
+		FROM sensor_filters f
+		JOIN sensors s ON s.type = f.sensor_type
+		WHERE s.id = $1`, sensorID,
+	).Scan(&f.ID, &f.SensorType, &minValue, &maxValue, &maxDelta, &f.MedianWindow, &medianDev, &f.Quarantine)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			fieldLogger.WithError(err).WithField("sensor_id", sensorID).Warn("Failed to load sensor filter, storing reading unfiltered")
+		}
+		return ""
+	}
+	f.MinValue, f.MaxValue = nullFloat(minValue), nullFloat(maxValue)
+	f.MaxDeltaPerMin, f.MedianMaxDeviation = nullFloat(maxDelta), nullFloat(medianDev)
+
+	var recent []recentReading
+	if n := readingsNeeded(f); n > 0 {
+		recent, err = loadRecentReadings(db, sensorID, n)
+		if err != nil {
+			fieldLogger.WithError(err).WithField("sensor_id", sensorID).Warn("Failed to load recent readings, skipping spike checks")
+		}
+	}
+
+	reason := checkReading(f, value, now.UTC(), recent, recentRejections(sensorID))
+	if reason == "" {
+		return ""
+	}
+	if reason == types.FilterSpikeMedian {
+		noteRejection(sensorID, recentReading{value: value, at: now.UTC()}, f.MedianWindow)
+	}
+
+	fieldLogger.WithFields(logrus.Fields{
+		"sensor_id":   sensorID,
+		"sensor_type": f.SensorType,
+		"value":       value,
+		"reason":      reason,
+		"quarantined": f.Quarantine,
+	}).Warn("Rejected sensor reading")
+
+	if _, err := db.Exec(
+		"UPDATE sensor_filters SET rejected_count = rejected_count + 1, last_rejected_dt = CURRENT_TIMESTAMP WHERE id = $1", f.ID,
+	); err != nil {
+		fieldLogger.WithError(err).Error("Failed to count rejected reading")
+	}
+	if f.Quarantine {
+		if _, err := db.Exec(
+			"INSERT INTO sensor_data_quarantine (sensor_id, value, reason) VALUES ($1, $2, $3)", sensorID, value, reason,
+		); err != nil {
+			fieldLogger.WithError(err).Error("Failed to quarantine rejected reading")
+		}
+	}
+	return reason
+}
+
+// readingsNeeded is how many recent stored readings f's spike checks read.
+func readingsNeeded(f types.SensorFilter) int {
+	n := 0
+	if f.MaxDeltaPerMin != nil {
+		n = 1
+	}
+	if f.MedianMaxDeviation != nil && f.MedianWindow > n {
+		n = f.MedianWindow
+	}
+	return n
+}
+
+// loadRecentReadings returns up to n of the sensor's stored readings, newest
+// first. Rejected readings never reach sensor_data; the median check sees
+// them through recentRejections instead.
+func loadRecentReadings(db *sql.DB, sensorID, n int) ([]recentReading, error) {
+	rows, err := db.Query(
+		"SELECT value, create_dt FROM sensor_data WHERE sensor_id = $1 ORDER BY create_dt DESC, id DESC LIMIT $2",
+		sensorID, n,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []recentReading
+	for rows.Next() {
+		var r recentReading
+		if err := rows.Scan(&r.value, &r.at); err != nil {
+			return nil, err
+		}
+		r.at = r.at.UTC()
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// rejectedSpikes holds each sensor's latest median-spike rejections, newest
+// first and at most a median window of them. They join the stored readings
+// in the median window, so after a genuine step change (a heater turning
+// on, a sensor moved) the median follows the new level once it has lasted
+// about half a window, instead of every later reading being judged against
+// the old one. A lone spike is outvoted by the stored readings. The list is
+// kept in memory; after a restart a step change just takes another half
+// window to be accepted.
+var rejectedSpikes = struct {
+	sync.Mutex
+	bySensor map[int][]recentReading
+}{bySensor: map[int][]recentReading{}}
+
+// recentRejections returns a copy of the sensor's recent median-spike
+// rejections, newest first.
+func recentRejections(sensorID int) []recentReading {
+	rejectedSpikes.Lock()
+	defer rejectedSpikes.Unlock()
+	return append([]recentReading(nil), rejectedSpikes.bySensor[sensorID]...)
+}
+
+// noteRejection records a median-spike rejection, keeping at most window of
+// them per sensor.
+func noteRejection(sensorID int, r recentReading, window int) {
+	rejectedSpikes.Lock()
+	defer rejectedSpikes.Unlock()
+	list := append([]recentReading{r}, rejectedSpikes.bySensor[sensorID]...)
+	if len(list) > window {
+		list = list[:window]
+	}
+	rejectedSpikes.bySensor[sensorID] = list
+}
+
+// medianWindow merges stored and rejected readings (both newest first) and
+// returns the values of the newest n, or fewer when there aren't n.
+func medianWindow(recent, rejected []recentReading, n int) []float64 {
+	vals := make([]float64, 0, n)
+	for len(vals) < n && (len(recent) > 0 || len(rejected) > 0) {
+		if len(rejected) == 0 || len(recent) > 0 && !recent[0].at.Before(rejected[0].at) {
+			vals = append(vals, recent[0].value)
+			recent = recent[1:]
+		} else {
+			vals = append(vals, rejected[0].value)
+			rejected = rejected[1:]
+		}
+	}
+	return vals
+}
+
+// checkReading applies f to value and returns the first rule it breaks, or
+// "". recent holds stored readings and rejected the recent median-spike
+// rejections, both newest first.
+func checkReading(f types.SensorFilter, value float64, now time.Time, recent, rejected []recentReading) string {
+	if f.MinValue != nil && value < *f.MinValue {
+		return types.FilterBelowMin
+	}
+	if f.MaxValue != nil && value > *f.MaxValue {
+		return types.FilterAboveMax
+	}
+
+	if f.MaxDeltaPerMin != nil && len(recent) > 0 {
+		// Readings less than a minute apart are compared as if a minute
+		// passed, so fast ingest doesn't turn ordinary noise into a spike.
+		minutes := math.Max(now.Sub(recent[0].at).Minutes(), 1)
+		if math.Abs(value-recent[0].value)/minutes > *f.MaxDeltaPerMin {
+			return types.FilterSpikeDelta
+		}
+	}
+
+	// The median check waits for a full window so a new sensor's first few
+	// readings aren't judged against one or two samples.
+	if f.MedianMaxDeviation != nil && f.MedianWindow > 0 {
+		vals := medianWindow(recent, rejected, f.MedianWindow)
+		if len(vals) == f.MedianWindow && math.Abs(value-median(vals)) > *f.MedianMaxDeviation {
+			return types.FilterSpikeMedian
+		}
+	}
+	return ""
+}
+
+// median sorts vals in place and returns their median.
+func median(vals []float64) float64 {
+	sort.Float64s(vals)
+	n := len(vals)
+	if n%2 == 1 {
+		return vals[n/2]
+	}
+	return (vals[n/2-1] + vals[n/2]) / 2
+}
+
+func nullFloat(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
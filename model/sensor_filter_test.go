@@ -0,0 +1,106 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"isley/model/types"
+)
+
+// ---------------------------------------------------------------------------
+// checkReading
+// ---------------------------------------------------------------------------
+
+func TestCheckReading(t *testing.T) {
+	t.Parallel()
+
+	f := func(v float64) *float64 { return &v }
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	steady := func(vals ...float64) []recentReading {
+		out := make([]recentReading, len(vals))
+		for i, v := range vals {
+			out[i] = recentReading{value: v, at: now.Add(-time.Duration(i+1) * time.Minute)}
+		}
+		return out
+	}
+
+	humidity := types.SensorFilter{MinValue: f(1), MaxValue: f(100)}
+	delta := types.SensorFilter{MaxDeltaPerMin: f(2)}
+	med := types.SensorFilter{MedianWindow: 3, MedianMaxDeviation: f(5)}
+
+	cases := []struct {
+		name   string
+		filter types.SensorFilter
+		value  float64
+		recent []recentReading
+		want   string
+	}{
+		{"zero humidity", humidity, 0, nil, types.FilterBelowMin},
+		{"absurd humidity", humidity, 6553.5, nil, types.FilterAboveMax},
+		{"in range", humidity, 55, nil, ""},
+		{"spike since last reading", delta, 60, steady(55), types.FilterSpikeDelta},
+		{"gradual change over time", delta, 60, []recentReading{{55, now.Add(-5 * time.Minute)}}, ""},
+		{"sub-minute gap is floored to a minute", delta, 56.5, []recentReading{{55, now.Add(-5 * time.Second)}}, ""},
+		{"no history for delta", delta, 60, nil, ""},
+		{"median outlier", med, 80, steady(55, 90, 56), types.FilterSpikeMedian},
+		{"median within deviation", med, 58, steady(55, 90, 56), ""},
+		{"median waits for a full window", med, 80, steady(55, 56), ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.want, checkReading(tc.filter, tc.value, now, tc.recent, nil))
+		})
+	}
+}
+
+// TestCheckReading_StepChange feeds a sensor that jumps to a new level and
+// stays there through checkReading the way FilterReading does: stored
+// readings are kept, median-spike rejections are noted. The new level is
+// rejected for about half a window and then accepted for good, while a lone
+// spike afterwards is still rejected.
+func TestCheckReading_StepChange(t *testing.T) {
+	t.Parallel()
+
+	const sensorID = -29 // never a real sensor, so parallel tests don't share state
+	dev := 5.0
+	filter := types.SensorFilter{MedianWindow: 5, MedianMaxDeviation: &dev}
+	start := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	var stored []recentReading // newest first
+	feed := func(i int, value float64) string {
+		at := start.Add(time.Duration(i) * time.Minute)
+		reason := checkReading(filter, value, at, stored, recentRejections(sensorID))
+		if reason == "" {
+			stored = append([]recentReading{{value, at}}, stored...)
+		} else if reason == types.FilterSpikeMedian {
+			noteRejection(sensorID, recentReading{value, at}, filter.MedianWindow)
+		}
+		return reason
+	}
+
+	for i := 0; i < 5; i++ {
+		assert.Empty(t, feed(i, 20), "steady readings are stored")
+	}
+	var results []string
+	for i := 5; i < 15; i++ {
+		results = append(results, feed(i, 30))
+	}
+	assert.Equal(t, []string{
+		types.FilterSpikeMedian, types.FilterSpikeMedian, types.FilterSpikeMedian,
+		"", "", "", "", "", "", "",
+	}, results, "the new level is accepted once it outnumbers the old one in the window")
+	assert.Equal(t, types.FilterSpikeMedian, feed(15, 80), "a lone spike is still rejected")
+	assert.Empty(t, feed(16, 30))
+}
+
+func TestReadingsNeeded(t *testing.T) {
+	t.Parallel()
+
+	f := func(v float64) *float64 { return &v }
+	assert.Zero(t, readingsNeeded(types.SensorFilter{MinValue: f(0)}))
+	assert.Equal(t, 1, readingsNeeded(types.SensorFilter{MaxDeltaPerMin: f(1)}))
+	assert.Equal(t, 7, readingsNeeded(types.SensorFilter{MaxDeltaPerMin: f(1), MedianWindow: 7, MedianMaxDeviation: f(2)}))
+}
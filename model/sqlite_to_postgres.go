@@ -19,24 +19,55 @@ type dbExecutor interface {
 }
 
 var conflictKeys = map[string]string{
-	"settings":           "id",
-	"api_keys":           "id",
-	"zones":              "id",
-	"sensors":            "id",
-	"strain":             "id",
-	"plant_status":       "id",
-	"plant":              "id",
-	"plant_status_log":   "id",
-	"metric":             "id",
-	"plant_measurements": "id",
-	"activity":           "id",
-	"activity_metric":    "id",
-	"plant_activity":     "id",
-	"plant_images":       "id",
-	"breeder":            "id",
-	"sensor_data":        "id",
-	"streams":            "id",
-	"strain_lineage":     "id",
+	"settings":               "id",
+	"api_keys":               "id",
+	"zones":                  "id",
+	"sensors":                "id",
+	"strain":                 "id",
+	"plant_status":           "id",
+	"plant":                  "id",
+	"plant_status_log":       "id",
+	"metric":                 "id",
+	"plant_measurements":     "id",
+	"activity":               "id",
+	"activity_metric":        "id",
+	"plant_activity":         "id",
+	"plant_images":           "id",
+	"breeder":                "id",
+	"sensor_data":            "id",
+	"streams":                "id",
+	"strain_lineage":         "id",
+	"virtual_sensors":        "id",
+	"sensor_calibrations":    "id",
+	"sensor_filters":         "id",
+	"sensor_data_quarantine": "id",
+	"timelapses":             "id",
+	"image_tags":             "id",
+	"plant_image_tags":       "id",
+	"harvests":               "id",
+	"harvest_dry_logs":       "id",
+	"cure_jars":              "id",
+	"cure_jar_logs":          "id",
+	"harvest_yields":         "id",
+	"grow_runs":              "id",
+	"nutrient_products":      "id",
+	"nutrient_recipes":       "id",
+	"nutrient_recipe_items":  "id",
+	"feeding_schedules":      "id",
+	"feeding_schedule_weeks": "id",
+	"activity_nutrients":     "id",
+	"tasks":                  "id",
+	"task_completions":       "id",
+	"seed_lots":              "id",
+	"germination_attempts":   "id",
+	"breeding_projects":      "id",
+	"pollinations":           "id",
+	"pheno_criteria":         "id",
+	"pheno_scores":           "id",
+	"strain_reviews":         "id",
+	"strain_review_tags":     "id",
+	"strain_cannadb_locks":   "id",
+	"breeder_cannadb_locks":  "id",
 }
 
 var boolToIntFields = map[string][]string{
@@ -50,11 +81,23 @@ var orderedTables = []string{
 	"breeder", // Must come before strain
 	"strain",
 	"strain_lineage", // After strain — references strain(id)
+	"seed_lots",
+	"germination_attempts",
+	"grow_runs",
+	"nutrient_products",
+	"nutrient_recipes",
+	"nutrient_recipe_items",
+	"feeding_schedules",
 	"sensors",
+	"sensor_calibrations",
+	"sensor_filters",
+	"sensor_data_quarantine",
+	"virtual_sensors",
 	"sensor_data",
 	// rolling_averages is excluded — it's a trigger-maintained cache (one row per sensor)
 	// that rebuilds automatically on the first sensor_data insert after migration.
 	"plant_status",
+	"feeding_schedule_weeks", // After plant_status — references plant_status(id)
 	"plant",
 	"plant_status_log",
 	"metric",
@@ -62,8 +105,27 @@ var orderedTables = []string{
 	"activity",
 	"activity_metric",
 	"plant_activity",
+	"activity_nutrients",
+	"tasks",
+	"task_completions",
+	"breeding_projects",
+	"pollinations", // After plant and strain — references both
+	"pheno_criteria",
+	"pheno_scores",
+	"strain_reviews",
+	"strain_review_tags",
+	"strain_cannadb_locks",
+	"breeder_cannadb_locks",
 	"plant_images",
+	"image_tags",
+	"plant_image_tags",
+	"harvests",
+	"harvest_dry_logs",
+	"cure_jars",
+	"cure_jar_logs",
+	"harvest_yields",
 	"streams",
+	"timelapses",
 }
 
 // MigrateSqliteToPostgres copies all data from the SQLite database at
@@ -239,24 +301,55 @@ func copyTableData(src *sql.DB, dest dbExecutor, table string) error {
 func hasSerialID(table string) bool {
 	// List of tables where 'id' is a SERIAL/identity column and needs sequence reset
 	serialTables := map[string]bool{
-		"api_keys":           true,
-		"settings":           true,
-		"zones":              true,
-		"sensors":            true,
-		"sensor_data":        true,
-		"strain":             true,
-		"plant_status":       true,
-		"plant":              true,
-		"plant_status_log":   true,
-		"metric":             true,
-		"plant_measurements": true,
-		"activity":           true,
-		"activity_metric":    true,
-		"plant_activity":     true,
-		"plant_images":       true,
-		"breeder":            true,
-		"streams":            true,
-		"strain_lineage":     true,
+		"api_keys":               true,
+		"settings":               true,
+		"zones":                  true,
+		"sensors":                true,
+		"sensor_data":            true,
+		"strain":                 true,
+		"plant_status":           true,
+		"plant":                  true,
+		"plant_status_log":       true,
+		"metric":                 true,
+		"plant_measurements":     true,
+		"activity":               true,
+		"activity_metric":        true,
+		"plant_activity":         true,
+		"plant_images":           true,
+		"breeder":                true,
+		"streams":                true,
+		"strain_lineage":         true,
+		"virtual_sensors":        true,
+		"sensor_calibrations":    true,
+		"sensor_filters":         true,
+		"sensor_data_quarantine": true,
+		"timelapses":             true,
+		"image_tags":             true,
+		"plant_image_tags":       true,
+		"harvests":               true,
+		"harvest_dry_logs":       true,
+		"cure_jars":              true,
+		"cure_jar_logs":          true,
+		"harvest_yields":         true,
+		"grow_runs":              true,
+		"nutrient_products":      true,
+		"nutrient_recipes":       true,
+		"nutrient_recipe_items":  true,
+		"feeding_schedules":      true,
+		"feeding_schedule_weeks": true,
+		"activity_nutrients":     true,
+		"tasks":                  true,
+		"task_completions":       true,
+		"seed_lots":              true,
+		"germination_attempts":   true,
+		"breeding_projects":      true,
+		"pollinations":           true,
+		"pheno_criteria":         true,
+		"pheno_scores":           true,
+		"strain_reviews":         true,
+		"strain_review_tags":     true,
+		"strain_cannadb_locks":   true,
+		"breeder_cannadb_locks":  true,
 	}
 
 	return serialTables[table]
@@ -13,6 +13,7 @@ type PlantActivity struct {
 	IsWatering   bool          `json:"is_watering"`
 	IsFeeding    bool          `json:"is_feeding"`
 	Measurements []Measurement `json:"measurements"`
+	Feeding      *Feeding      `json:"feeding,omitempty"`
 }
 
 type Activity struct {
@@ -35,12 +36,17 @@ type Breeder struct {
 	CannadbIndexedAt string `json:"cannadb_indexed_at,omitempty"`
 }
 
+// Measurement is one recorded metric value. Value is stored in the metric's
+// Unit; DisplayValue/DisplayUnit carry it in the user's preferred units.
 type Measurement struct {
-	ID       uint      `json:"id"`
-	MetricID int       `json:"metric_id"`
-	Name     string    `json:"name"`
-	Value    float64   `json:"value"`
-	Date     time.Time `json:"date"`
+	ID           uint      `json:"id"`
+	MetricID     int       `json:"metric_id"`
+	Name         string    `json:"name"`
+	Value        float64   `json:"value"`
+	Unit         string    `json:"unit,omitempty"`
+	DisplayValue float64   `json:"display_value"`
+	DisplayUnit  string    `json:"display_unit,omitempty"`
+	Date         time.Time `json:"date"`
 }
 
 type Metric struct {
@@ -82,18 +88,32 @@ type Plant struct {
 	Autoflower     bool                 `json:"autoflower"`
 	ParentID       uint                 `json:"parent_id"`
 	ParentName     string               `json:"parent_name"`
+	Keeper         bool                 `json:"keeper"`
+	GrowRunID      uint                 `json:"grow_run_id"`
+	GrowRunName    string               `json:"grow_run_name"`
+	ScheduleID     uint                 `json:"feeding_schedule_id"`
+	ScheduleName   string               `json:"feeding_schedule_name"`
 }
 
 // PlantImage represents the structure of the plant_images table
 type PlantImage struct {
-	ID               uint      `json:"id" gorm:"primaryKey"`
-	PlantID          uint      `json:"plant_id" gorm:"not null"`
-	ImagePath        string    `json:"image_path" gorm:"not null"`
-	ImageDescription string    `json:"image_description" gorm:"not null"`
-	ImageOrder       int       `json:"image_order" gorm:"not null"`
-	ImageDate        time.Time `json:"image_date" gorm:"not null;default:CURRENT_TIMESTAMP"`
-	CreatedAt        time.Time `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
-	UpdatedAt        time.Time `json:"updated_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	PlantID          uint       `json:"plant_id" gorm:"not null"`
+	ImagePath        string     `json:"image_path" gorm:"not null"`
+	ImageDescription string     `json:"image_description" gorm:"not null"`
+	ImageOrder       int        `json:"image_order" gorm:"not null"`
+	ImageDate        time.Time  `json:"image_date" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	CreatedAt        time.Time  `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt        time.Time  `json:"updated_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	Tags             []ImageTag `json:"tags" gorm:"-"`
+}
+
+// ImageTag labels plant photos (e.g. "deficiency", "trichomes") for the
+// cross-plant gallery. Count is only filled in by tag listings.
+type ImageTag struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Count int    `json:"count,omitempty"`
 }
 
 type PlantListResponse struct {
@@ -118,6 +138,8 @@ type PlantListResponse struct {
 	EstHarvestDate        time.Time `json:"est_harvest_date"`
 	Autoflower            bool      `json:"autoflower"`
 	HarvestDate           time.Time `json:"harvest_date"`
+	// Harvest holds the yield figures of plants with a harvest record.
+	Harvest *HarvestMetrics `json:"harvest,omitempty"`
 }
 
 type Sensor struct {
@@ -171,13 +193,19 @@ type Settings struct {
 	GuestMode          bool   `json:"guest_mode"`
 	StreamGrabEnabled  bool   `json:"stream_grab_enabled"`
 	StreamGrabInterval string `json:"stream_grab_interval"`
+	DailyPhotoEnabled  bool   `json:"daily_photo_enabled"`
+	DailyPhotoTime     string `json:"daily_photo_time"`
+	NotifyWebhookURL   string `json:"notify_webhook_url"`
+	PublicURL          string `json:"public_url"`
+	TaskReminderTime   string `json:"task_reminder_time"`
 	APIKey             string `json:"api_key"`
 	// New: allow disabling API ingest from settings form
-	DisableAPIIngest    bool   `json:"disable_api_ingest"`
-	SensorRetentionDays string `json:"sensor_retention_days"`
-	LogLevel            string `json:"log_level"`
-	MaxBackupSizeMB     string `json:"max_backup_size_mb"`
-	Timezone            string `json:"timezone"`
+	DisableAPIIngest    bool         `json:"disable_api_ingest"`
+	SensorRetentionDays string       `json:"sensor_retention_days"`
+	LogLevel            string       `json:"log_level"`
+	MaxBackupSizeMB     string       `json:"max_backup_size_mb"`
+	Timezone            string       `json:"timezone"`
+	Units               UnitSettings `json:"units"`
 }
 
 type ACInfinitySettings struct {
@@ -189,6 +217,13 @@ type EcoWittSettings struct {
 	Enabled bool `json:"enabled"`
 }
 
+// UnitSettings is the display unit preference; see utils.UnitPrefs.
+type UnitSettings struct {
+	System   string `json:"system"`
+	Pressure string `json:"pressure"`
+	Weight   string `json:"weight"`
+}
+
 type CannadbSettings struct {
 	Enabled bool   `json:"enabled"`
 	BaseURL string `json:"base_url"`
@@ -202,13 +237,20 @@ type SettingsData struct {
 	GuestMode          bool               `json:"guest_mode"`
 	StreamGrabEnabled  bool               `json:"stream_grab_enabled"`
 	StreamGrabInterval int                `json:"stream_grab_interval"`
+	DailyPhotoEnabled  bool               `json:"daily_photo_enabled"`
+	DailyPhotoTime     string             `json:"daily_photo_time"`
+	NotifyWebhookURL   string             `json:"notify_webhook_url"`
+	PublicURL          string             `json:"public_url"`
+	TaskReminderTime   string             `json:"task_reminder_time"`
 	APIKey             string             `json:"api_key"`
 	// New: reflect whether API ingest is enabled (true) or disabled (false)
-	APIIngestEnabled    bool   `json:"api_ingest_enabled"`
-	SensorRetentionDays int    `json:"sensor_retention_days"`
-	LogLevel            string `json:"log_level"`
-	MaxBackupSizeMB     int    `json:"max_backup_size_mb"`
-	Timezone            string `json:"timezone"`
+	APIIngestEnabled    bool         `json:"api_ingest_enabled"`
+	CalendarTokenSet    bool         `json:"calendar_token_set"`
+	SensorRetentionDays int          `json:"sensor_retention_days"`
+	LogLevel            string       `json:"log_level"`
+	MaxBackupSizeMB     int          `json:"max_backup_size_mb"`
+	Timezone            string       `json:"timezone"`
+	Units               UnitSettings `json:"units"`
 }
 
 type Status struct {
@@ -236,9 +278,13 @@ type Strain struct {
 	Lineage          string `json:"lineage,omitempty"`
 	// CannadbURI is the AT-URI of the source CannaDB record when this strain was
 	// imported (empty for manually-created strains). CannadbIndexedAt is that
-	// record's indexedAt, kept for future refresh logic.
+	// record's indexedAt, which a CannaDB sync compares to spot changes.
 	CannadbURI       string `json:"cannadb_uri,omitempty"`
 	CannadbIndexedAt string `json:"cannadb_indexed_at,omitempty"`
+	// AverageRating is the mean star rating of the strain's reviews, nil
+	// when it has none.
+	AverageRating *float64 `json:"average_rating,omitempty"`
+	ReviewCount   int      `json:"review_count"`
 }
 
 type StrainLineage struct {
@@ -264,4 +310,48 @@ type Stream struct {
 	ZoneID   uint   `json:"zone_id"`
 	ZoneName string `json:"zone_name"`
 	Visible  bool   `json:"visible"`
+
+	// Frame archive settings; a zero budget leaves that axis unlimited.
+	ArchiveEnabled   bool `json:"archive_enabled"`
+	ArchiveMaxFrames int  `json:"archive_max_frames"`
+	ArchiveMaxMB     int  `json:"archive_max_mb"`
+
+	// Capture schedule. GrabInterval is in seconds (0 = global setting);
+	// the active window is HH:MM in the app timezone and may wrap
+	// midnight. With LightSensorID set, frames are only captured while
+	// that sensor's latest reading exceeds LightThreshold.
+	GrabInterval   int      `json:"grab_interval"`
+	ActiveStart    string   `json:"active_start"`
+	ActiveEnd      string   `json:"active_end"`
+	LightSensorID  *uint    `json:"light_sensor_id"`
+	LightThreshold *float64 `json:"light_threshold"`
+
+	// Camera credentials, kept apart from URL. Password never leaves the
+	// server; HasPassword tells the UI whether one is stored.
+	Username    string `json:"username"`
+	Password    string `json:"-"`
+	HasPassword bool   `json:"has_password"`
+	// FFmpeg capture options for rtsp(s):// and rtmp:// streams.
+	// GrabTimeout is in seconds (0 = the built-in default).
+	RTSPTransport string `json:"rtsp_transport"`
+	GrabTimeout   int    `json:"grab_timeout"`
+}
+
+// Timelapse is a video rendered from a stream's frame archive.
+type Timelapse struct {
+	ID         uint      `json:"id"`
+	StreamID   *uint     `json:"stream_id"`
+	StreamName string    `json:"stream_name"`
+	Name       string    `json:"name"`
+	StartDT    time.Time `json:"start_dt"`
+	EndDT      time.Time `json:"end_dt"`
+	FPS        int       `json:"fps"`
+	Format     string    `json:"format"`
+	BurnIn     string    `json:"burn_in"`
+	SensorID   *uint     `json:"sensor_id"`
+	Status     string    `json:"status"`
+	FrameCount int       `json:"frame_count"`
+	FilePath   string    `json:"file_path"`
+	Error      string    `json:"error"`
+	CreateDT   time.Time `json:"create_dt"`
 }
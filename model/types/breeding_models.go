@@ -0,0 +1,54 @@
+package types
+
+import "time"
+
+// Pollination is a cross of a female plant with pollen from a male plant
+// or another source. The parent strains are those of the plants on the
+// day of the cross. SeedStrainID, SeedsHarvested and HarvestDate stay nil
+// until the seeds are harvested.
+type Pollination struct {
+	ID               int        `json:"id"`
+	ProjectID        *int       `json:"project_id"`
+	FemalePlantID    *int       `json:"female_plant_id"`
+	FemalePlantName  string     `json:"female_plant_name"`
+	FemaleStrainID   *int       `json:"female_strain_id"`
+	FemaleStrainName string     `json:"female_strain_name"`
+	MalePlantID      *int       `json:"male_plant_id"`
+	MalePlantName    string     `json:"male_plant_name"`
+	MaleStrainID     *int       `json:"male_strain_id"`
+	MaleStrainName   string     `json:"male_strain_name"`
+	PollenSource     string     `json:"pollen_source"`
+	Date             time.Time  `json:"pollination_date"`
+	Generation       string     `json:"generation"`
+	Notes            string     `json:"notes"`
+	SeedStrainID     *int       `json:"seed_strain_id"`
+	SeedStrainName   string     `json:"seed_strain_name"`
+	SeedsHarvested   *int       `json:"seeds_harvested"`
+	HarvestDate      *time.Time `json:"harvest_date"`
+}
+
+// BreedingProject groups the pollinations made towards one goal, oldest
+// first.
+type BreedingProject struct {
+	ID           int           `json:"id"`
+	Name         string        `json:"name"`
+	Goal         string        `json:"goal"`
+	Notes        string        `json:"notes"`
+	Pollinations []Pollination `json:"pollinations"`
+}
+
+// BreedingOverview is every breeding project, plus the pollinations not
+// filed under one.
+type BreedingOverview struct {
+	Projects   []BreedingProject `json:"projects"`
+	Unassigned []Pollination     `json:"unassigned"`
+}
+
+// Groups lists the projects followed by the unassigned pollinations, if
+// any, as a project with ID 0, so a page can show them all alike.
+func (o BreedingOverview) Groups() []BreedingProject {
+	if len(o.Unassigned) == 0 {
+		return o.Projects
+	}
+	return append(append([]BreedingProject{}, o.Projects...), BreedingProject{Pollinations: o.Unassigned})
+}
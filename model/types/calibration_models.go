@@ -0,0 +1,45 @@
+package types
+
+import (
+	"math"
+	"time"
+)
+
+// Calibration methods. Both reduce to a scale and offset; two_point keeps the
+// reference readings it was derived from so the UI can show them again.
+const (
+	CalibrationLinear   = "linear"
+	CalibrationTwoPoint = "two_point"
+)
+
+// SensorCalibration is one entry in a sensor's calibration history. The
+// newest entry is the active calibration.
+type SensorCalibration struct {
+	ID          int       `json:"id"`
+	SensorID    int       `json:"sensor_id"`
+	Method      string    `json:"method"`
+	Scale       float64   `json:"scale"`
+	Offset      float64   `json:"offset"`
+	RawLow      *float64  `json:"raw_low"`
+	RefLow      *float64  `json:"ref_low"`
+	RawHigh     *float64  `json:"raw_high"`
+	RefHigh     *float64  `json:"ref_high"`
+	Retroactive bool      `json:"retroactive"`
+	LastDataID  int       `json:"last_data_id"` // highest sensor_data.id when recorded
+	Note        string    `json:"note"`
+	CreateDT    time.Time `json:"create_dt"`
+}
+
+// IdentityCalibration is what an uncalibrated sensor uses.
+var IdentityCalibration = SensorCalibration{Method: CalibrationLinear, Scale: 1}
+
+// Apply converts a raw reading to its calibrated value. The result is rounded
+// to six decimals so a plain offset doesn't store float noise like 75.80000001.
+func (c SensorCalibration) Apply(raw float64) float64 {
+	return math.Round((raw*c.Scale+c.Offset)*1e6) / 1e6
+}
+
+// IsIdentity reports whether the calibration leaves readings unchanged.
+func (c SensorCalibration) IsIdentity() bool {
+	return c.Scale == 1 && c.Offset == 0
+}
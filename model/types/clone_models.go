@@ -0,0 +1,46 @@
+package types
+
+import "time"
+
+// CloneNode is a plant in a clone family tree. Generation counts cuttings
+// down from the family's first mother, which is generation 0.
+type CloneNode struct {
+	ID         int          `json:"id"`
+	Name       string       `json:"name"`
+	StrainName string       `json:"strain_name"`
+	Status     string       `json:"status"`
+	Living     bool         `json:"living"`
+	Clone      bool         `json:"clone"`
+	Keeper     bool         `json:"keeper"`
+	StartDT    time.Time    `json:"start_dt"`
+	Generation int          `json:"generation"`
+	Children   []*CloneNode `json:"children"`
+}
+
+// CloneFamily is the clone family a plant belongs to: Root is the first
+// mother, with every generation of cuttings below it. Mother holds the
+// plant's own clone stats.
+type CloneFamily struct {
+	PlantID    int         `json:"plant_id"`
+	Generation int         `json:"generation"`
+	Root       *CloneNode  `json:"root"`
+	Mother     MotherStats `json:"mother"`
+}
+
+// MotherStats summarises the cuttings taken from one plant. A cutting
+// counts as failed once it is marked Dead; SuccessRate is the fraction of
+// cuttings that haven't failed and is nil until one has been taken.
+// Descendants counts every generation below the plant.
+type MotherStats struct {
+	PlantID      int        `json:"plant_id"`
+	Name         string     `json:"name"`
+	StrainName   string     `json:"strain_name"`
+	Status       string     `json:"status"`
+	Living       bool       `json:"living"`
+	Keeper       bool       `json:"keeper"`
+	ClonesTaken  int        `json:"clones_taken"`
+	ClonesFailed int        `json:"clones_failed"`
+	SuccessRate  *float64   `json:"success_rate"`
+	LastTaken    *time.Time `json:"last_taken"`
+	Descendants  int        `json:"descendants"`
+}
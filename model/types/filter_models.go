@@ -0,0 +1,40 @@
+package types
+
+import "time"
+
+// Reasons a reading is rejected by a SensorFilter. They are stored in
+// sensor_data_quarantine.reason and returned by the ingest API.
+const (
+	FilterBelowMin    = "below_min"
+	FilterAboveMax    = "above_max"
+	FilterSpikeDelta  = "spike_delta"
+	FilterSpikeMedian = "spike_median"
+)
+
+// MaxFilterMedianWindow caps how many recent readings the median check reads.
+const MaxFilterMedianWindow = 50
+
+// SensorFilter is a validity rule for every sensor of one type. Nil limits
+// are not checked; MedianWindow 0 disables the median check.
+type SensorFilter struct {
+	ID                 int        `json:"id"`
+	SensorType         string     `json:"sensor_type"`
+	MinValue           *float64   `json:"min_value"`
+	MaxValue           *float64   `json:"max_value"`
+	MaxDeltaPerMin     *float64   `json:"max_delta_per_min"`
+	MedianWindow       int        `json:"median_window"`
+	MedianMaxDeviation *float64   `json:"median_max_deviation"`
+	Quarantine         bool       `json:"quarantine"`
+	RejectedCount      int        `json:"rejected_count"`
+	LastRejectedDT     *time.Time `json:"last_rejected_dt"`
+}
+
+// QuarantinedReading is a rejected reading kept for review.
+type QuarantinedReading struct {
+	ID         int       `json:"id"`
+	SensorID   int       `json:"sensor_id"`
+	SensorName string    `json:"sensor_name"`
+	Value      float64   `json:"value"`
+	Reason     string    `json:"reason"`
+	CreateDT   time.Time `json:"create_dt"`
+}
@@ -0,0 +1,88 @@
+package types
+
+import "time"
+
+// GrowRun is one growing cycle in a zone. LightWatts and CanopyArea
+// (square metres) describe the whole run; FlipDate and EndDate are nil
+// until the run flips to flower or finishes.
+type GrowRun struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	ZoneID     *int       `json:"zone_id"`
+	ZoneName   string     `json:"zone_name"`
+	StartDate  time.Time  `json:"start_date"`
+	FlipDate   *time.Time `json:"flip_date"`
+	EndDate    *time.Time `json:"end_date"`
+	LightWatts *float64   `json:"light_watts"`
+	CanopyArea *float64   `json:"canopy_area"`
+	Notes      string     `json:"notes"`
+	PlantCount int        `json:"plant_count"`
+}
+
+// GrowRunMetrics are the yield figures of a run. Figures that need a value
+// that wasn't recorded are left at zero.
+type GrowRunMetrics struct {
+	YieldGrams      float64 `json:"yield_grams"`
+	HarvestedPlants int     `json:"harvested_plants"`
+	GramsPerPlant   float64 `json:"grams_per_plant,omitempty"`
+	GramsPerWatt    float64 `json:"grams_per_watt,omitempty"`
+	GramsPerSqM     float64 `json:"grams_per_sqm,omitempty"`
+	VegDays         int     `json:"veg_days"`
+	FlowerDays      int     `json:"flower_days"`
+	TotalDays       int     `json:"total_days"`
+}
+
+// GrowRunPlant is a plant assigned to a run.
+type GrowRunPlant struct {
+	ID            int     `json:"id"`
+	Name          string  `json:"name"`
+	StrainID      int     `json:"strain_id"`
+	StrainName    string  `json:"strain_name"`
+	Status        string  `json:"status"`
+	HarvestWeight float64 `json:"harvest_weight"`
+}
+
+// GrowRunStrain is the yield of one strain within a run. Share is the
+// strain's fraction of the run's yield.
+type GrowRunStrain struct {
+	StrainID      int     `json:"strain_id"`
+	StrainName    string  `json:"strain_name"`
+	Plants        int     `json:"plants"`
+	YieldGrams    float64 `json:"yield_grams"`
+	GramsPerPlant float64 `json:"grams_per_plant"`
+	Share         float64 `json:"share"`
+}
+
+// GrowRunStage summarises the zone climate over one stage of a run, from
+// the hourly sensor rollups. Temperature and VPD are nil when the zone has
+// no such sensor or it logged nothing during the stage.
+type GrowRunStage struct {
+	Stage       string        `json:"stage"`
+	Start       time.Time     `json:"start"`
+	End         time.Time     `json:"end"`
+	Days        int           `json:"days"`
+	Temperature *ReadingStats `json:"temperature,omitempty"`
+	VPD         *ReadingStats `json:"vpd,omitempty"`
+}
+
+// GrowRunReport is a run with its plants, yield figures, per-strain
+// breakdown and per-stage climate.
+type GrowRunReport struct {
+	Run     GrowRun         `json:"run"`
+	Metrics GrowRunMetrics  `json:"metrics"`
+	Plants  []GrowRunPlant  `json:"plants"`
+	Strains []GrowRunStrain `json:"strains"`
+	Stages  []GrowRunStage  `json:"stages"`
+}
+
+// GrowRunComparison is one row of the run-to-run comparison. PreviousRunID
+// is the run before this one in the same zone, and the change fields are
+// the fractional change from it, nil when there is nothing to compare.
+type GrowRunComparison struct {
+	Run                GrowRun        `json:"run"`
+	Metrics            GrowRunMetrics `json:"metrics"`
+	Stages             []GrowRunStage `json:"stages"`
+	PreviousRunID      int            `json:"previous_run_id,omitempty"`
+	YieldChange        *float64       `json:"yield_change,omitempty"`
+	GramsPerWattChange *float64       `json:"grams_per_watt_change,omitempty"`
+}
@@ -0,0 +1,94 @@
+package types
+
+import "time"
+
+// Harvest is a plant's harvest and post-harvest record. Weights are in
+// grams and CanopyArea in square metres; optional values are nil when
+// they haven't been recorded.
+type Harvest struct {
+	ID                  int             `json:"id"`
+	PlantID             int             `json:"plant_id"`
+	HarvestDate         time.Time       `json:"harvest_date"`
+	WetWeight           *float64        `json:"wet_weight"`
+	TrimWeight          *float64        `json:"trim_weight"`
+	DryWeight           *float64        `json:"dry_weight"`
+	LightWatts          *float64        `json:"light_watts"`
+	CanopyArea          *float64        `json:"canopy_area"`
+	DryTempSensorID     *int            `json:"dry_temp_sensor_id"`
+	DryHumiditySensorID *int            `json:"dry_humidity_sensor_id"`
+	Notes               string          `json:"notes"`
+	DryLogs             []HarvestDryLog `json:"dry_logs"`
+	Jars                []CureJar       `json:"jars"`
+	Yields              []HarvestYield  `json:"yields"`
+	Metrics             HarvestMetrics  `json:"metrics"`
+	DryConditions       *DryConditions  `json:"dry_conditions,omitempty"`
+}
+
+// HarvestDryLog is one weigh-in while the harvest dries. AvgTemperature
+// and AvgHumidity are the dry-room sensor averages for that day, in
+// display units, when the harvest has sensors linked.
+type HarvestDryLog struct {
+	ID             int       `json:"id"`
+	Date           time.Time `json:"date"`
+	Weight         float64   `json:"weight"`
+	Notes          string    `json:"notes"`
+	AvgTemperature *float64  `json:"avg_temperature,omitempty"`
+	AvgHumidity    *float64  `json:"avg_humidity,omitempty"`
+}
+
+// CureJar is a jar of cured flower and its burp / RH log.
+type CureJar struct {
+	ID         int          `json:"id"`
+	Name       string       `json:"name"`
+	Weight     *float64     `json:"weight"`
+	SealedDate time.Time    `json:"sealed_date"`
+	Notes      string       `json:"notes"`
+	Logs       []CureJarLog `json:"logs"`
+}
+
+// CureJarLog records a jar check: whether it was burped and the RH read
+// inside it.
+type CureJarLog struct {
+	ID       int       `json:"id"`
+	JarID    int       `json:"jar_id"`
+	Date     time.Time `json:"date"`
+	Burped   bool      `json:"burped"`
+	Humidity *float64  `json:"humidity"`
+	Notes    string    `json:"notes"`
+}
+
+// HarvestYield is the final weight of one grade of the harvest.
+type HarvestYield struct {
+	ID     int     `json:"id"`
+	Grade  string  `json:"grade"`
+	Weight float64 `json:"weight"`
+}
+
+// HarvestMetrics are the yield figures derived from a harvest. Figures
+// that need a value that wasn't recorded are left at zero.
+type HarvestMetrics struct {
+	YieldGrams   float64 `json:"yield_grams"`
+	DryWetRatio  float64 `json:"dry_wet_ratio,omitempty"`
+	GrowDays     int     `json:"grow_days"`
+	DryDays      int     `json:"dry_days"`
+	GramsPerDay  float64 `json:"grams_per_day,omitempty"`
+	GramsPerWatt float64 `json:"grams_per_watt,omitempty"`
+	GramsPerSqM  float64 `json:"grams_per_sqm,omitempty"`
+}
+
+// DryConditions summarises the dry-room sensors over the drying period.
+type DryConditions struct {
+	Temperature *ReadingStats `json:"temperature,omitempty"`
+	Humidity    *ReadingStats `json:"humidity,omitempty"`
+}
+
+// ReadingStats are the average, minimum and maximum of a sensor's
+// readings over a period, in display units.
+type ReadingStats struct {
+	SensorName string  `json:"sensor_name"`
+	Unit       string  `json:"unit"`
+	Avg        float64 `json:"avg"`
+	Min        float64 `json:"min"`
+	Max        float64 `json:"max"`
+	Count      int     `json:"count"`
+}
@@ -0,0 +1,90 @@
+package types
+
+import "time"
+
+// NutrientProduct is a fertiliser or additive in the nutrient library.
+// N, P and K are the label percentages; DosePerL is the manufacturer's
+// recommended amount per litre, in Unit ("ml" or "g").
+type NutrientProduct struct {
+	ID       int      `json:"id"`
+	Name     string   `json:"name"`
+	Brand    string   `json:"brand"`
+	N        float64  `json:"n"`
+	P        float64  `json:"p"`
+	K        float64  `json:"k"`
+	DosePerL *float64 `json:"dose_per_l"`
+	Unit     string   `json:"unit"`
+	Notes    string   `json:"notes"`
+}
+
+// NutrientRecipe mixes products at a per-litre amount, aiming for
+// TargetEC (mS/cm) and TargetPH when they are set.
+type NutrientRecipe struct {
+	ID       int                  `json:"id"`
+	Name     string               `json:"name"`
+	TargetEC *float64             `json:"target_ec"`
+	TargetPH *float64             `json:"target_ph"`
+	Notes    string               `json:"notes"`
+	Items    []NutrientRecipeItem `json:"items"`
+}
+
+// NutrientRecipeItem is one product of a recipe. AmountPerL is in the
+// product's Unit.
+type NutrientRecipeItem struct {
+	ProductID   int     `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	Unit        string  `json:"unit"`
+	AmountPerL  float64 `json:"amount_per_l"`
+}
+
+// FeedingSchedule picks a recipe for each week of a plant stage.
+// PlantCount is the number of plants following it.
+type FeedingSchedule struct {
+	ID         int                   `json:"id"`
+	Name       string                `json:"name"`
+	Notes      string                `json:"notes"`
+	Weeks      []FeedingScheduleWeek `json:"weeks"`
+	PlantCount int                   `json:"plant_count"`
+}
+
+// FeedingScheduleWeek feeds RecipeID in week Week (from 1) of the stage
+// StatusID.
+type FeedingScheduleWeek struct {
+	StatusID   int    `json:"status_id"`
+	Status     string `json:"status"`
+	Week       int    `json:"week"`
+	RecipeID   int    `json:"recipe_id"`
+	RecipeName string `json:"recipe_name"`
+}
+
+// FeedingPlan is what a plant's schedule says to feed on Date: the stage
+// the plant is in, the week of that stage and the recipe for it. Recipe is
+// nil when the plant has no schedule or the schedule has nothing for the
+// stage.
+type FeedingPlan struct {
+	Date         time.Time       `json:"date"`
+	ScheduleID   int             `json:"schedule_id,omitempty"`
+	ScheduleName string          `json:"schedule_name,omitempty"`
+	StatusID     int             `json:"status_id,omitempty"`
+	Status       string          `json:"status,omitempty"`
+	Week         int             `json:"week,omitempty"`
+	Recipe       *NutrientRecipe `json:"recipe"`
+}
+
+// Feeding is the structured part of a feed activity: the recipe it was
+// mixed from, the volume in litres and what actually went in.
+type Feeding struct {
+	RecipeID   *int           `json:"recipe_id"`
+	RecipeName string         `json:"recipe_name,omitempty"`
+	VolumeL    *float64       `json:"volume_l"`
+	Nutrients  []FeedNutrient `json:"nutrients"`
+}
+
+// FeedNutrient is the amount of one product used in a feed, in Unit.
+// ProductID is nil once the product has been deleted from the library.
+type FeedNutrient struct {
+	ProductID *int    `json:"product_id"`
+	Name      string  `json:"name"`
+	Unit      string  `json:"unit"`
+	Amount    float64 `json:"amount"`
+}
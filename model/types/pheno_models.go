@@ -0,0 +1,39 @@
+package types
+
+import "time"
+
+// PhenoCriterion is a trait plants are scored on in a pheno hunt. Weight
+// sets how much its score counts towards a plant's weighted score.
+type PhenoCriterion struct {
+	ID        int     `json:"id"`
+	Name      string  `json:"name"`
+	Weight    float64 `json:"weight"`
+	SortOrder int     `json:"sort_order"`
+}
+
+// PhenoPlant is one plant in a pheno hunt. Scores maps criterion ids to
+// scores from 1 to 10; Score is the weighted average of them and is nil
+// until the plant has been scored. Measurements holds the latest reading
+// of each metric, and ImageIDs the plant's latest photos.
+type PhenoPlant struct {
+	ID            int           `json:"id"`
+	Name          string        `json:"name"`
+	Status        string        `json:"status"`
+	StartDT       time.Time     `json:"start_dt"`
+	Clone         bool          `json:"clone"`
+	PhenoKeeper   bool          `json:"pheno_keeper"`
+	Keeper        bool          `json:"keeper"`
+	Scores        map[int]int   `json:"scores"`
+	Score         *float64      `json:"score"`
+	Measurements  []Measurement `json:"measurements"`
+	HarvestWeight float64       `json:"harvest_weight"`
+	ImageIDs      []int         `json:"image_ids"`
+}
+
+// PhenoHunt compares the sibling plants of one strain, best scored first.
+type PhenoHunt struct {
+	StrainID   int              `json:"strain_id"`
+	StrainName string           `json:"strain_name"`
+	Criteria   []PhenoCriterion `json:"criteria"`
+	Plants     []PhenoPlant     `json:"plants"`
+}
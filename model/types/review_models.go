@@ -0,0 +1,55 @@
+package types
+
+import (
+	"strings"
+	"time"
+)
+
+// StrainReview is a smoke report on a harvested plant. StrainID is the
+// plant's strain when the review was written; PlantID is nil once the
+// plant has been deleted. Rating runs from 1 to 5 stars and Potency, when
+// given, from 1 (mild) to 5 (overwhelming).
+type StrainReview struct {
+	ID         int       `json:"id"`
+	StrainID   int       `json:"strain_id"`
+	PlantID    *int      `json:"plant_id"`
+	PlantName  string    `json:"plant_name"`
+	Date       time.Time `json:"review_date"`
+	Rating     int       `json:"rating"`
+	Potency    *int      `json:"potency"`
+	Notes      string    `json:"notes"`
+	FlavorTags []string  `json:"flavor_tags"`
+	EffectTags []string  `json:"effect_tags"`
+}
+
+// ReviewTagCount is how many of a strain's reviews carry a tag.
+type ReviewTagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// StrainReviewSummary aggregates a strain's reviews, newest first. The
+// averages are nil until something has been rated; tags are ordered by
+// how often they were given.
+type StrainReviewSummary struct {
+	StrainID       int              `json:"strain_id"`
+	Count          int              `json:"count"`
+	AverageRating  *float64         `json:"average_rating"`
+	AveragePotency *float64         `json:"average_potency"`
+	FlavorTags     []ReviewTagCount `json:"flavor_tags"`
+	EffectTags     []ReviewTagCount `json:"effect_tags"`
+	Reviews        []StrainReview   `json:"reviews"`
+}
+
+// Stars renders the rating as five filled or empty stars.
+func (r StrainReview) Stars() string {
+	return strings.Repeat("★", max(0, r.Rating)) + strings.Repeat("☆", max(0, 5-r.Rating))
+}
+
+// PotencyLevel is the potency, or 0 when none was given.
+func (r StrainReview) PotencyLevel() int {
+	if r.Potency == nil {
+		return 0
+	}
+	return *r.Potency
+}
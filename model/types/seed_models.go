@@ -0,0 +1,77 @@
+package types
+
+import "time"
+
+// Germination outcomes, derived from how many of an attempt's seeds
+// sprouted.
+const (
+	GerminationPending  = "pending"
+	GerminationSprouted = "sprouted"
+	GerminationPartial  = "partial"
+	GerminationFailed   = "failed"
+)
+
+// GerminationStats totals germination attempts. Attempts still pending
+// count towards Attempts and Pending only; Rate is the fraction of the
+// seeds sown in recorded attempts that sprouted and is nil until one has
+// been recorded.
+type GerminationStats struct {
+	Attempts        int      `json:"attempts"`
+	Pending         int      `json:"pending"`
+	SeedsSown       int      `json:"seeds_sown"`
+	Sprouted        int      `json:"sprouted"`
+	Rate            *float64 `json:"rate"`
+	AvgDaysToSprout *float64 `json:"avg_days_to_sprout"`
+}
+
+// GerminationAttempt is a batch of seeds sown from a lot. Sprouted and
+// SproutDate are nil until the outcome is recorded.
+type GerminationAttempt struct {
+	ID            int        `json:"id"`
+	SeedLotID     int        `json:"seed_lot_id"`
+	StartDate     time.Time  `json:"start_date"`
+	Seeds         int        `json:"seeds"`
+	Sprouted      *int       `json:"sprouted"`
+	SproutDate    *time.Time `json:"sprout_date"`
+	Method        string     `json:"method"`
+	Notes         string     `json:"notes"`
+	Outcome       string     `json:"outcome"`
+	DaysToSprout  *int       `json:"days_to_sprout"`
+	PlantsStarted int        `json:"plants_started"`
+}
+
+// SeedLot is a pack of seeds of one strain. SeedsLeft counts the seeds
+// not yet sown or started.
+type SeedLot struct {
+	ID            int                  `json:"id"`
+	StrainID      int                  `json:"strain_id"`
+	StrainName    string               `json:"strain_name"`
+	BreederID     int                  `json:"breeder_id"`
+	Breeder       string               `json:"breeder"`
+	Source        string               `json:"source"`
+	PurchaseDate  *time.Time           `json:"purchase_date"`
+	PackSize      int                  `json:"pack_size"`
+	SeedsLeft     int                  `json:"seeds_left"`
+	Cost          *float64             `json:"cost"`
+	Feminized     bool                 `json:"feminized"`
+	Notes         string               `json:"notes"`
+	PlantsStarted int                  `json:"plants_started"`
+	Stats         GerminationStats     `json:"stats"`
+	Attempts      []GerminationAttempt `json:"attempts"`
+}
+
+// BreederGermination is the germination record of every lot of one
+// breeder's strains.
+type BreederGermination struct {
+	BreederID int              `json:"breeder_id"`
+	Breeder   string           `json:"breeder"`
+	Lots      int              `json:"lots"`
+	Stats     GerminationStats `json:"stats"`
+}
+
+// SeedBank is every seed lot with germination stats per lot and per
+// breeder.
+type SeedBank struct {
+	Breeders []BreederGermination `json:"breeders"`
+	Lots     []SeedLot            `json:"lots"`
+}
@@ -0,0 +1,51 @@
+package types
+
+import "time"
+
+// Task is a reminder tied to one plant, zone or grow run. Schedule is
+// "once" (due on DueDate), "interval" (every IntervalDays, first on
+// DueDate) or "stage" (on day StageDay of the StageStatusID stage, for
+// each plant). ActivityID, when set, is logged against the task's plants
+// when it is completed.
+type Task struct {
+	ID            int        `json:"id"`
+	Title         string     `json:"title"`
+	Notes         string     `json:"notes"`
+	ActivityID    *int       `json:"activity_id"`
+	ActivityName  string     `json:"activity_name,omitempty"`
+	PlantID       *int       `json:"plant_id"`
+	ZoneID        *int       `json:"zone_id"`
+	GrowRunID     *int       `json:"grow_run_id"`
+	TargetName    string     `json:"target_name"`
+	Schedule      string     `json:"schedule"`
+	DueDate       *time.Time `json:"due_date"`
+	IntervalDays  *int       `json:"interval_days"`
+	StageStatusID *int       `json:"stage_status_id"`
+	StageStatus   string     `json:"stage_status,omitempty"`
+	StageDay      *int       `json:"stage_day"`
+	LastDone      *time.Time `json:"last_done"`
+	CompletedAt   *time.Time `json:"completed_at"`
+}
+
+// DueTask is one occurrence of a task on the due list. Stage tasks fall
+// due plant by plant, so PlantID and PlantName say which plant this one
+// is for; they are empty for the other schedules. DaysOverdue is negative
+// for upcoming tasks.
+type DueTask struct {
+	TaskID       int       `json:"task_id"`
+	Title        string    `json:"title"`
+	Notes        string    `json:"notes"`
+	Schedule     string    `json:"schedule"`
+	ActivityName string    `json:"activity_name,omitempty"`
+	TargetName   string    `json:"target_name"`
+	PlantID      int       `json:"plant_id,omitempty"`
+	PlantName    string    `json:"plant_name,omitempty"`
+	DueDate      time.Time `json:"due_date"`
+	DaysOverdue  int       `json:"days_overdue"`
+}
+
+// Overdue reports whether the task was due before today.
+func (d DueTask) Overdue() bool { return d.DaysOverdue > 0 }
+
+// DueToday reports whether the task is due today.
+func (d DueTask) DueToday() bool { return d.DaysOverdue == 0 }
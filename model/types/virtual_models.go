@@ -0,0 +1,77 @@
+package types
+
+// VirtualSource is the sensors.source value for sensors whose readings are
+// computed from other sensors by the watcher rather than polled or ingested.
+const VirtualSource = "virtual"
+
+// Virtual sensor functions. Each names how the watcher derives a reading
+// from the sensor's inputs on every poll cycle.
+const (
+	// VirtualDewPoint takes [temperature, humidity] and yields the dew point
+	// in the temperature sensor's unit.
+	VirtualDewPoint = "dew_point"
+	// VirtualAbsHumidity takes [temperature, humidity] and yields g/m³.
+	VirtualAbsHumidity = "abs_humidity"
+	// VirtualDLI takes [PAR] (µmol/m²/s) and yields the daily light
+	// integral accumulated since local midnight, in mol/m²/d.
+	VirtualDLI = "dli"
+	// VirtualMean, VirtualMin and VirtualMax aggregate two or more inputs.
+	VirtualMean = "mean"
+	VirtualMin  = "min"
+	VirtualMax  = "max"
+	// VirtualConvert takes one input and applies a UnitConversions entry.
+	VirtualConvert = "convert"
+	// VirtualExpression evaluates an arithmetic expression over s<ID>
+	// variables; see utils.CompileExpression.
+	VirtualExpression = "expression"
+)
+
+// VirtualFunctionUnits is the set of supported functions mapped to the unit
+// assigned to a new output sensor. An empty unit means it depends on the
+// inputs (or the conversion) and is filled in at creation time.
+var VirtualFunctionUnits = map[string]string{
+	VirtualDewPoint:    "",
+	VirtualAbsHumidity: "g/m³",
+	VirtualDLI:         "mol/m²/d",
+	VirtualMean:        "",
+	VirtualMin:         "",
+	VirtualMax:         "",
+	VirtualConvert:     "",
+	VirtualExpression:  "units",
+}
+
+// UnitConversion is a linear conversion y = x*Scale + Offset.
+type UnitConversion struct {
+	Scale  float64
+	Offset float64
+	Unit   string // unit of the converted value
+}
+
+// UnitConversions lists the conversions available to the convert function.
+var UnitConversions = map[string]UnitConversion{
+	"c_to_f":      {Scale: 9.0 / 5.0, Offset: 32, Unit: "°F"},
+	"f_to_c":      {Scale: 5.0 / 9.0, Offset: -32 * 5.0 / 9.0, Unit: "°C"},
+	"ms_to_us":    {Scale: 1000, Unit: "µS/cm"},
+	"us_to_ms":    {Scale: 0.001, Unit: "mS/cm"},
+	"hpa_to_inhg": {Scale: 0.0295299830714, Unit: "inHg"},
+	"inhg_to_hpa": {Scale: 33.8638866667, Unit: "hPa"},
+	"kpa_to_psi":  {Scale: 0.145037738, Unit: "psi"},
+	"in_to_cm":    {Scale: 2.54, Unit: "cm"},
+	"cm_to_in":    {Scale: 1 / 2.54, Unit: "in"},
+	// Sunlight approximation; artificial spectra differ by up to ±30%.
+	"lux_to_ppfd": {Scale: 0.0185, Unit: "µmol/m²/s"},
+}
+
+// VirtualSensor is the definition behind a sensors row whose source is
+// VirtualSource. Inputs are the sensor IDs the function reads, in order.
+type VirtualSensor struct {
+	ID         int    `json:"id"`
+	SensorID   int    `json:"sensor_id"`
+	Name       string `json:"name"`
+	ZoneID     *int   `json:"zone_id"`
+	Unit       string `json:"unit"`
+	Function   string `json:"function"`
+	Inputs     []int  `json:"inputs"`
+	Expression string `json:"expression"`
+	Conversion string `json:"conversion"`
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"isley/handlers"
 	"isley/model"
+	"isley/model/types"
 	"isley/utils"
 	"net/http"
 	"strconv"
@@ -113,6 +114,237 @@ func AddBasicRoutes(r *gin.RouterGroup, version string) {
 	})
 	r.GET("/activities/list", handlers.ListAllActivities)
 
+	r.GET("/gallery", func(c *gin.Context) {
+		lang := utils.GetLanguage(c)
+		translations := utils.TranslationService.GetTranslations(lang)
+		currentPath, _ := c.Get("currentPath")
+
+		page := 1
+		if v := c.Query("page"); v != "" {
+			if n, perr := strconv.Atoi(v); perr == nil && n > 0 {
+				page = n
+			}
+		}
+
+		pageCtx, err := handlers.BuildGalleryPageContext(handlers.DBFromContext(c), handlers.ParseGalleryFilters(c), page, c.Request.URL.Query())
+		if err != nil {
+			pageCtx = handlers.GalleryPageContext{}
+		}
+
+		store := handlers.ConfigStoreFromContext(c)
+		c.HTML(http.StatusOK, "views/gallery.html", gin.H{
+			"title":           "Gallery",
+			"currentPath":     currentPath,
+			"version":         version,
+			"zones":           store.Zones(),
+			"strains":         store.Strains(),
+			"statuses":        store.Statuses(),
+			"galleryCtx":      pageCtx,
+			"activities":      store.Activities(),
+			"loggedIn":        sessions.Default(c).Get("logged_in"),
+			"lcl":             translations,
+			"languages":       utils.AvailableLanguages,
+			"currentLanguage": lang,
+			"csrfToken":       c.GetString("csrf_token"),
+			"cspNonce":        c.GetString("cspNonce"),
+		})
+	})
+	r.GET("/gallery/images", handlers.ListGalleryImages)
+	r.GET("/image-tags", handlers.ListImageTags)
+
+	r.GET("/runs", func(c *gin.Context) {
+		lang := utils.GetLanguage(c)
+		translations := utils.TranslationService.GetTranslations(lang)
+		currentPath, _ := c.Get("currentPath")
+		store := handlers.ConfigStoreFromContext(c)
+		pageCtx, err := handlers.BuildGrowRunsPageContext(c)
+		if err != nil {
+			pageCtx = handlers.GrowRunsPageContext{}
+		}
+		c.HTML(http.StatusOK, "views/runs.html", gin.H{
+			"title":           "Grow Runs",
+			"currentPath":     currentPath,
+			"version":         version,
+			"runsCtx":         pageCtx,
+			"zones":           store.Zones(),
+			"activities":      store.Activities(),
+			"loggedIn":        sessions.Default(c).Get("logged_in"),
+			"lcl":             translations,
+			"languages":       utils.AvailableLanguages,
+			"currentLanguage": lang,
+			"csrfToken":       c.GetString("csrf_token"),
+			"cspNonce":        c.GetString("cspNonce"),
+		})
+	})
+	r.GET("/runs/list", handlers.ListGrowRuns)
+	r.GET("/runs/compare", handlers.CompareGrowRunsHandler)
+	r.GET("/runs/:id", func(c *gin.Context) {
+		lang := utils.GetLanguage(c)
+		translations := utils.TranslationService.GetTranslations(lang)
+		currentPath, _ := c.Get("currentPath")
+		store := handlers.ConfigStoreFromContext(c)
+		pageCtx, err := handlers.BuildGrowRunPageContext(c, c.Param("id"))
+		if err != nil {
+			pageCtx = handlers.GrowRunPageContext{}
+		}
+		c.HTML(http.StatusOK, "views/run.html", gin.H{
+			"title":           "Grow Run",
+			"currentPath":     currentPath,
+			"version":         version,
+			"runCtx":          pageCtx,
+			"zones":           store.Zones(),
+			"activities":      store.Activities(),
+			"loggedIn":        sessions.Default(c).Get("logged_in"),
+			"lcl":             translations,
+			"languages":       utils.AvailableLanguages,
+			"currentLanguage": lang,
+			"csrfToken":       c.GetString("csrf_token"),
+			"cspNonce":        c.GetString("cspNonce"),
+		})
+	})
+	r.GET("/runs/:id/report", handlers.GetGrowRunReport)
+
+	r.GET("/nutrients", func(c *gin.Context) {
+		lang := utils.GetLanguage(c)
+		translations := utils.TranslationService.GetTranslations(lang)
+		currentPath, _ := c.Get("currentPath")
+		store := handlers.ConfigStoreFromContext(c)
+		pageCtx, err := handlers.BuildNutrientsPageContext(c)
+		if err != nil {
+			pageCtx = handlers.NutrientsPageContext{}
+		}
+		c.HTML(http.StatusOK, "views/nutrients.html", gin.H{
+			"title":           "Nutrients",
+			"currentPath":     currentPath,
+			"version":         version,
+			"nutrientsCtx":    pageCtx,
+			"nutrientUnits":   handlers.NutrientUnits,
+			"statuses":        store.Statuses(),
+			"zones":           store.Zones(),
+			"activities":      store.Activities(),
+			"loggedIn":        sessions.Default(c).Get("logged_in"),
+			"lcl":             translations,
+			"languages":       utils.AvailableLanguages,
+			"currentLanguage": lang,
+			"csrfToken":       c.GetString("csrf_token"),
+			"cspNonce":        c.GetString("cspNonce"),
+		})
+	})
+	r.GET("/nutrients/products", handlers.ListNutrientProducts)
+	r.GET("/nutrients/recipes", handlers.ListNutrientRecipes)
+	r.GET("/nutrients/schedules", handlers.ListFeedingSchedules)
+
+	r.GET("/tasks", func(c *gin.Context) {
+		lang := utils.GetLanguage(c)
+		translations := utils.TranslationService.GetTranslations(lang)
+		currentPath, _ := c.Get("currentPath")
+		store := handlers.ConfigStoreFromContext(c)
+		pageCtx, err := handlers.BuildTasksPageContext(c)
+		if err != nil {
+			pageCtx = handlers.TasksPageContext{}
+		}
+		c.HTML(http.StatusOK, "views/tasks.html", gin.H{
+			"title":           "Tasks",
+			"currentPath":     currentPath,
+			"version":         version,
+			"tasksCtx":        pageCtx,
+			"taskSchedules":   handlers.TaskSchedules,
+			"statuses":        store.Statuses(),
+			"zones":           store.Zones(),
+			"activities":      store.Activities(),
+			"loggedIn":        sessions.Default(c).Get("logged_in"),
+			"lcl":             translations,
+			"languages":       utils.AvailableLanguages,
+			"currentLanguage": lang,
+			"csrfToken":       c.GetString("csrf_token"),
+			"cspNonce":        c.GetString("cspNonce"),
+		})
+	})
+	r.GET("/tasks/list", handlers.ListTasks)
+	r.GET("/tasks/due", handlers.ListDueTasks)
+
+	r.GET("/mothers", func(c *gin.Context) {
+		lang := utils.GetLanguage(c)
+		translations := utils.TranslationService.GetTranslations(lang)
+		currentPath, _ := c.Get("currentPath")
+		store := handlers.ConfigStoreFromContext(c)
+		db := handlers.DBFromContext(c)
+		mothers, err := handlers.LoadMotherStats(db)
+		if err != nil {
+			mothers = []types.MotherStats{}
+		}
+		c.HTML(http.StatusOK, "views/mothers.html", gin.H{
+			"title":           "Mothers",
+			"currentPath":     currentPath,
+			"version":         version,
+			"mothers":         mothers,
+			"plants":          handlers.GetLivingPlants(db),
+			"activities":      store.Activities(),
+			"loggedIn":        sessions.Default(c).Get("logged_in"),
+			"lcl":             translations,
+			"languages":       utils.AvailableLanguages,
+			"currentLanguage": lang,
+			"csrfToken":       c.GetString("csrf_token"),
+			"cspNonce":        c.GetString("cspNonce"),
+		})
+	})
+	r.GET("/mothers/list", handlers.ListMotherStats)
+
+	r.GET("/seeds", func(c *gin.Context) {
+		lang := utils.GetLanguage(c)
+		translations := utils.TranslationService.GetTranslations(lang)
+		currentPath, _ := c.Get("currentPath")
+		store := handlers.ConfigStoreFromContext(c)
+		db := handlers.DBFromContext(c)
+		bank, err := handlers.LoadSeedBank(db)
+		if err != nil {
+			bank = types.SeedBank{}
+		}
+		c.HTML(http.StatusOK, "views/seeds.html", gin.H{
+			"title":           "Seeds",
+			"currentPath":     currentPath,
+			"version":         version,
+			"bank":            bank,
+			"plants":          handlers.GetLivingPlants(db),
+			"activities":      store.Activities(),
+			"loggedIn":        sessions.Default(c).Get("logged_in"),
+			"lcl":             translations,
+			"languages":       utils.AvailableLanguages,
+			"currentLanguage": lang,
+			"csrfToken":       c.GetString("csrf_token"),
+			"cspNonce":        c.GetString("cspNonce"),
+		})
+	})
+	r.GET("/seeds/stats", handlers.GetSeedBank)
+
+	r.GET("/breeding", func(c *gin.Context) {
+		lang := utils.GetLanguage(c)
+		translations := utils.TranslationService.GetTranslations(lang)
+		currentPath, _ := c.Get("currentPath")
+		store := handlers.ConfigStoreFromContext(c)
+		db := handlers.DBFromContext(c)
+		overview, err := handlers.LoadBreedingOverview(db)
+		if err != nil {
+			overview = types.BreedingOverview{}
+		}
+		c.HTML(http.StatusOK, "views/breeding.html", gin.H{
+			"title":           "Breeding",
+			"currentPath":     currentPath,
+			"version":         version,
+			"breeding":        overview,
+			"breeders":        store.Breeders(),
+			"plants":          handlers.GetLivingPlants(db),
+			"activities":      store.Activities(),
+			"loggedIn":        sessions.Default(c).Get("logged_in"),
+			"lcl":             translations,
+			"languages":       utils.AvailableLanguages,
+			"currentLanguage": lang,
+			"csrfToken":       c.GetString("csrf_token"),
+			"cspNonce":        c.GetString("cspNonce"),
+		})
+	})
+	r.GET("/breeding/projects", handlers.GetBreedingOverview)
+
 	r.GET("/strains", func(c *gin.Context) {
 		lang := utils.GetLanguage(c)
 		translations := utils.TranslationService.GetTranslations(lang)
@@ -194,7 +426,12 @@ func AddBasicRoutes(r *gin.RouterGroup, version string) {
 		db := handlers.DBFromContext(c)
 		idStr := c.Param("id")
 		plant := handlers.GetPlant(db, idStr)
+		handlers.ConvertPlantUnits(&plant, store.UnitPrefs())
 		prevPlantID, nextPlantID := handlers.GetAdjacentPlantIDs(db, int(plant.ID))
+		feedingSchedules, err := handlers.LoadFeedingSchedules(db)
+		if err != nil {
+			feedingSchedules = []types.FeedingSchedule{}
+		}
 		c.HTML(http.StatusOK, "views/plant.html", gin.H{
 			"title":           "Plant Details",
 			"currentPath":     currentPath,
@@ -208,6 +445,7 @@ func AddBasicRoutes(r *gin.RouterGroup, version string) {
 			"sensors":         handlers.GetSensors(db),
 			"plants":          handlers.GetLivingPlants(db),
 			"activities":      store.Activities(),
+			"feedSchedules":   feedingSchedules,
 			"loggedIn":        sessions.Default(c).Get("logged_in"),
 			"lcl":             translations,
 			"languages":       utils.AvailableLanguages,
@@ -219,6 +457,34 @@ func AddBasicRoutes(r *gin.RouterGroup, version string) {
 		})
 	})
 
+	r.GET("/plant/:id/harvest", func(c *gin.Context) {
+		lang := utils.GetLanguage(c)
+		translations := utils.TranslationService.GetTranslations(lang)
+		currentPath, _ := c.Get("currentPath")
+		store := handlers.ConfigStoreFromContext(c)
+		db := handlers.DBFromContext(c)
+		pageCtx, err := handlers.BuildHarvestPageContext(c, c.Param("id"))
+		if err != nil {
+			pageCtx = handlers.HarvestPageContext{}
+		}
+		c.HTML(http.StatusOK, "views/harvest.html", gin.H{
+			"title":           "Harvest",
+			"currentPath":     currentPath,
+			"version":         version,
+			"harvestCtx":      pageCtx,
+			"reviewList":      pageCtx.Reviews,
+			"sensors":         handlers.GetSensors(db),
+			"plants":          handlers.GetLivingPlants(db),
+			"activities":      store.Activities(),
+			"loggedIn":        sessions.Default(c).Get("logged_in"),
+			"lcl":             translations,
+			"languages":       utils.AvailableLanguages,
+			"currentLanguage": lang,
+			"csrfToken":       c.GetString("csrf_token"),
+			"cspNonce":        c.GetString("cspNonce"),
+		})
+	})
+
 	r.GET("/strain/new", func(c *gin.Context) {
 		// Redirect to login if not authenticated
 		if sessions.Default(c).Get("logged_in") == nil {
@@ -251,13 +517,25 @@ func AddBasicRoutes(r *gin.RouterGroup, version string) {
 		translations := utils.TranslationService.GetTranslations(lang)
 		currentPath, _ := c.Get("currentPath")
 		store := handlers.ConfigStoreFromContext(c)
-		strain := handlers.GetStrain(handlers.DBFromContext(c), c.Param("id"))
+		db := handlers.DBFromContext(c)
+		strain := handlers.GetStrain(db, c.Param("id"))
+		seedLots, err := handlers.LoadStrainSeedLots(db, strain.ID)
+		if err != nil {
+			seedLots = []types.SeedLot{}
+		}
+		reviews, err := handlers.LoadStrainReviews(db, strain.ID)
+		if err != nil {
+			reviews = types.StrainReviewSummary{}
+		}
 		c.HTML(http.StatusOK, "views/strain.html", gin.H{
 			"title":           "Strain Details",
 			"currentPath":     currentPath,
 			"version":         version,
 			"strain":          strain,
 			"cannadbURL":      handlers.CannadbWebURL(strain.CannadbURI),
+			"seedLots":        seedLots,
+			"reviews":         reviews,
+			"reviewList":      reviews.Reviews,
 			"breeders":        store.Breeders(),
 			"loggedIn":        sessions.Default(c).Get("logged_in"),
 			"lcl":             translations,
@@ -268,6 +546,32 @@ func AddBasicRoutes(r *gin.RouterGroup, version string) {
 		})
 	})
 
+	r.GET("/strain/:id/pheno-hunt", func(c *gin.Context) {
+		lang := utils.GetLanguage(c)
+		translations := utils.TranslationService.GetTranslations(lang)
+		currentPath, _ := c.Get("currentPath")
+		store := handlers.ConfigStoreFromContext(c)
+		db := handlers.DBFromContext(c)
+		strainID, _ := strconv.Atoi(c.Param("id"))
+		hunt, err := handlers.LoadPhenoHunt(db, strainID, handlers.UnitPrefsFromContext(c))
+		if err != nil {
+			hunt = types.PhenoHunt{StrainID: strainID}
+		}
+		c.HTML(http.StatusOK, "views/pheno-hunt.html", gin.H{
+			"title":           "Pheno Hunt",
+			"currentPath":     currentPath,
+			"version":         version,
+			"hunt":            hunt,
+			"activities":      store.Activities(),
+			"loggedIn":        sessions.Default(c).Get("logged_in"),
+			"lcl":             translations,
+			"languages":       utils.AvailableLanguages,
+			"currentLanguage": lang,
+			"csrfToken":       c.GetString("csrf_token"),
+			"cspNonce":        c.GetString("cspNonce"),
+		})
+	})
+
 	r.GET("/listFonts", utils.ListFontsHandler)
 	r.GET("/listLogos", utils.ListLogosHandler)
 
@@ -276,20 +580,31 @@ func AddBasicRoutes(r *gin.RouterGroup, version string) {
 	r.GET("/plants/dead", handlers.DeadPlantsHandler)
 	r.GET("/plants/by-strain/:strainID", handlers.PlantsByStrainHandler)
 	r.GET("/sensorData", handlers.ChartHandler)
+	r.GET("/sensorData/chart", handlers.ChartSeriesHandler)
 	r.GET("/sensors/grouped", func(c *gin.Context) {
 		groupedSensors := handlers.GetGroupedSensorsWithLatestReading(
 			handlers.DBFromContext(c), handlers.SensorCacheServiceFromContext(c))
-		c.JSON(http.StatusOK, groupedSensors)
+		c.JSON(http.StatusOK, handlers.GroupedSensorsInUnits(groupedSensors, handlers.UnitPrefsFromContext(c)))
 	})
 	r.GET("/strains/:id", handlers.GetStrainHandler)
 	r.GET("/strains/in-stock", handlers.InStockStrainsHandler)
 	r.GET("/strains/out-of-stock", handlers.OutOfStockStrainsHandler)
-	r.POST("/decorateImage", utils.DecorateImageHandler)
+	r.POST("/decorateImage", handlers.DecorateImage)
 	r.GET("/streams", handlers.GetStreamsByZoneHandler)
+	r.GET("/plant/images/:imageID/:variant", handlers.GetPlantImageVariant)
+	r.GET("/plant/:id/progression", handlers.GetPlantProgression)
+	r.GET("/plant/:id/harvest/summary", handlers.GetPlantHarvest)
+	r.GET("/plant/:id/feeding-plan", handlers.GetPlantFeedingPlan)
+	r.GET("/plant/:id/lineage", handlers.GetPlantLineage)
 
 	// Lineage (public read)
 	r.GET("/strains/:id/lineage", handlers.GetLineageHandler)
 	r.GET("/strains/:id/descendants", handlers.GetDescendantsHandler)
+	r.GET("/strains/:id/seed-lots", handlers.ListStrainSeedLots)
+	r.GET("/strains/:id/pheno-hunt", handlers.GetPhenoHunt)
+	r.GET("/strains/:id/reviews", handlers.ListStrainReviews)
+	r.GET("/plant/:id/reviews", handlers.ListPlantReviews)
+	r.GET("/pheno/criteria", handlers.GetPhenoCriteria)
 	r.GET("/strains/lookup", handlers.LookupStrainByName)
 }
 
@@ -310,11 +625,58 @@ func AddProtectedApiRoutes(r *gin.RouterGroup) {
 	r.DELETE("/plantActivity/delete/:id", handlers.DeleteActivity)
 	r.POST("/plant/:plantID/images/upload", handlers.UploadPlantImages)
 	r.DELETE("/plant/images/:imageID/delete", handlers.DeletePlantImage)
+	r.PUT("/plant/images/:imageID/tags", handlers.SetPlantImageTags)
+	r.POST("/plant/:plantID/progression/export", handlers.ExportPlantProgression)
+
+	r.PUT("/plant/:plantID/harvest", handlers.SaveHarvest)
+	r.DELETE("/plant/:plantID/harvest", handlers.DeleteHarvest)
+	r.POST("/plant/:plantID/harvest/dry-logs", handlers.AddHarvestDryLog)
+	r.DELETE("/harvest/dry-logs/:id", handlers.DeleteHarvestDryLog)
+	r.POST("/plant/:plantID/harvest/jars", handlers.AddCureJar)
+	r.PUT("/harvest/jars/:id", handlers.UpdateCureJar)
+	r.DELETE("/harvest/jars/:id", handlers.DeleteCureJar)
+	r.POST("/harvest/jars/:id/logs", handlers.AddCureJarLog)
+	r.DELETE("/harvest/jar-logs/:id", handlers.DeleteCureJarLog)
+	r.PUT("/plant/:plantID/harvest/yields", handlers.SetHarvestYields)
+	r.POST("/plant/:plantID/clones", handlers.TakeClones)
+	r.PUT("/plant/:plantID/keeper", handlers.SetPlantKeeper)
+	r.POST("/runs", handlers.AddGrowRun)
+	r.PUT("/runs/:id", handlers.UpdateGrowRun)
+	r.DELETE("/runs/:id", handlers.DeleteGrowRun)
+	r.PUT("/runs/:id/plants", handlers.SetGrowRunPlants)
+	r.POST("/nutrients/products", handlers.AddNutrientProduct)
+	r.PUT("/nutrients/products/:id", handlers.UpdateNutrientProduct)
+	r.DELETE("/nutrients/products/:id", handlers.DeleteNutrientProduct)
+	r.POST("/nutrients/recipes", handlers.AddNutrientRecipe)
+	r.PUT("/nutrients/recipes/:id", handlers.UpdateNutrientRecipe)
+	r.DELETE("/nutrients/recipes/:id", handlers.DeleteNutrientRecipe)
+	r.POST("/nutrients/schedules", handlers.AddFeedingSchedule)
+	r.PUT("/nutrients/schedules/:id", handlers.UpdateFeedingSchedule)
+	r.DELETE("/nutrients/schedules/:id", handlers.DeleteFeedingSchedule)
+	r.PUT("/plant/:plantID/feeding-schedule", handlers.SetPlantFeedingSchedule)
+	r.POST("/tasks", handlers.AddTask)
+	r.PUT("/tasks/:id", handlers.UpdateTask)
+	r.DELETE("/tasks/:id", handlers.DeleteTask)
+	r.POST("/tasks/:id/complete", handlers.CompleteTask)
 
 	r.POST("/sensors/scanACI", handlers.ScanACInfinitySensors)
 	r.POST("/sensors/scanEC", handlers.ScanEcoWittSensors)
 	r.POST("/sensors/edit", handlers.EditSensor)
 	r.DELETE("/sensors/delete/:id", handlers.DeleteSensor)
+	r.GET("/sensors/:id/calibration", handlers.GetSensorCalibrationHandler)
+	r.POST("/sensors/:id/calibration", handlers.SetSensorCalibrationHandler)
+
+	r.GET("/virtual-sensors", handlers.GetVirtualSensorsHandler)
+	r.POST("/virtual-sensors", handlers.CreateVirtualSensorHandler)
+	r.PUT("/virtual-sensors/:id", handlers.UpdateVirtualSensorHandler)
+	r.DELETE("/virtual-sensors/:id", handlers.DeleteVirtualSensorHandler)
+
+	r.GET("/sensor-filters", handlers.GetSensorFiltersHandler)
+	r.POST("/sensor-filters", handlers.CreateSensorFilterHandler)
+	r.PUT("/sensor-filters/:id", handlers.UpdateSensorFilterHandler)
+	r.DELETE("/sensor-filters/:id", handlers.DeleteSensorFilterHandler)
+	r.GET("/sensor-filters/quarantine", handlers.GetQuarantineHandler)
+	r.DELETE("/sensor-filters/quarantine", handlers.ClearQuarantineHandler)
 
 	// Debug endpoint to dump raw AC Infinity API response
 	r.GET("/sensors/dumpACI", handlers.DumpACInfinityJSON)
@@ -324,10 +686,20 @@ func AddProtectedApiRoutes(r *gin.RouterGroup) {
 	r.PUT("/strains/:id", handlers.UpdateStrainHandler)
 	r.DELETE("/strains/:id", handlers.DeleteStrainHandler)
 
+	// Strain library export and bulk import (CSV or JSON)
+	r.GET("/strains/export", handlers.ExportStrainLibrary)
+	r.POST("/strains/import", handlers.ImportStrainLibrary)
+
 	// CannaDB import (search + one-click import). Auth-gated so anonymous
 	// users can't drive outbound calls against the shared per-IP rate budget.
 	r.GET("/strains/cannadb/search", handlers.CannadbSearchHandler)
 	r.POST("/strains/cannadb/import", handlers.CannadbImportHandler)
+	r.GET("/strains/cannadb/sync", handlers.CannadbSyncPreviewHandler)
+	r.POST("/strains/cannadb/sync", handlers.CannadbSyncHandler)
+	r.PUT("/strains/:id/cannadb-locks/:field", handlers.LockStrainCannadbField)
+	r.DELETE("/strains/:id/cannadb-locks/:field", handlers.UnlockStrainCannadbField)
+	r.PUT("/breeders/:id/cannadb-locks/:field", handlers.LockBreederCannadbField)
+	r.DELETE("/breeders/:id/cannadb-locks/:field", handlers.UnlockBreederCannadbField)
 
 	// Lineage (protected write)
 	r.POST("/strains/:id/lineage", handlers.AddLineageHandler)
@@ -335,6 +707,35 @@ func AddProtectedApiRoutes(r *gin.RouterGroup) {
 	r.PUT("/lineage/:lineageID", handlers.UpdateLineageHandler)
 	r.DELETE("/lineage/:lineageID", handlers.DeleteLineageHandler)
 
+	// Seed lots and germination
+	r.POST("/strains/:id/seed-lots", handlers.AddSeedLot)
+	r.PUT("/seed-lots/:id", handlers.UpdateSeedLot)
+	r.DELETE("/seed-lots/:id", handlers.DeleteSeedLot)
+	r.POST("/seed-lots/:id/germinations", handlers.AddGerminationAttempt)
+	r.PUT("/germinations/:id", handlers.UpdateGerminationAttempt)
+	r.DELETE("/germinations/:id", handlers.DeleteGerminationAttempt)
+
+	// Breeding
+	r.POST("/breeding/projects", handlers.AddBreedingProject)
+	r.PUT("/breeding/projects/:id", handlers.UpdateBreedingProject)
+	r.DELETE("/breeding/projects/:id", handlers.DeleteBreedingProject)
+	r.POST("/pollinations", handlers.AddPollination)
+	r.PUT("/pollinations/:id", handlers.UpdatePollination)
+	r.DELETE("/pollinations/:id", handlers.DeletePollination)
+	r.POST("/pollinations/:id/harvest", handlers.HarvestPollinationSeeds)
+
+	// Pheno hunts
+	r.POST("/pheno/criteria", handlers.AddPhenoCriterion)
+	r.PUT("/pheno/criteria/:id", handlers.UpdatePhenoCriterion)
+	r.DELETE("/pheno/criteria/:id", handlers.DeletePhenoCriterion)
+	r.PUT("/plant/:plantID/pheno-scores", handlers.SetPhenoScores)
+	r.PUT("/plant/:plantID/pheno-keeper", handlers.SetPhenoKeeper)
+
+	// Strain reviews
+	r.POST("/plant/:plantID/reviews", handlers.AddStrainReview)
+	r.PUT("/strain-reviews/:id", handlers.UpdateStrainReview)
+	r.DELETE("/strain-reviews/:id", handlers.DeleteStrainReview)
+
 	r.POST("/aci/login", handlers.ACILoginHandler)
 
 	r.POST("/zones", handlers.AddZoneHandler)
@@ -354,6 +755,10 @@ func AddProtectedApiRoutes(r *gin.RouterGroup) {
 	r.POST("/streams", handlers.AddStreamHandler)
 	r.PUT("/streams/:id", handlers.UpdateStreamHandler)
 	r.DELETE("/streams/:id", handlers.DeleteStreamHandler)
+	r.POST("/streams/:id/timelapses", handlers.CreateTimelapseHandler)
+	r.GET("/timelapses", handlers.GetTimelapsesHandler)
+	r.DELETE("/timelapses/:id", handlers.DeleteTimelapseHandler)
+	r.POST("/timelapses/:id/attach", handlers.AttachTimelapseHandler)
 
 	r.POST("/breeders", handlers.AddBreederHandler)
 	r.PUT("/breeders/:id", handlers.UpdateBreederHandler)
@@ -373,6 +778,9 @@ func AddProtectedApiRoutes(r *gin.RouterGroup) {
 	r.POST("/settings/backup/sqlite/upload", handlers.UploadSQLiteDB)
 	r.POST("/record-multi-activity", handlers.RecordMultiPlantActivity)
 	r.POST("/settings", handlers.SaveSettings)
+	r.POST("/settings/notifications/test", handlers.TestNotification)
+	r.POST("/settings/calendar-token", handlers.CreateCalendarToken)
+	r.DELETE("/settings/calendar-token", handlers.RevokeCalendarToken)
 }
 
 // AddExternalApiRoutes External API endpoints
@@ -450,6 +858,8 @@ func AddProtectedRoutes(r *gin.RouterGroup, version string) {
 			"activities":      store.Activities(),
 			"breeders":        store.Breeders(),
 			"streams":         handlers.GetStreams(db),
+			"pressureUnits":   utils.PressureUnits,
+			"weightUnits":     utils.WeightUnits,
 			"loggedIn":        sessions.Default(c).Get("logged_in"),
 			"lcl":             translations,
 			"languages":       utils.AvailableLanguages,
@@ -478,6 +888,7 @@ func AddProtectedRoutes(r *gin.RouterGroup, version string) {
 			"version":         version,
 			"settings":        handlers.GetSettings(handlers.DBFromContext(c)),
 			"sensors":         handlers.GetSensors(handlers.DBFromContext(c)),
+			"conversions":     types.UnitConversions,
 			"zones":           store.Zones(),
 			"plants":          handlers.GetLivingPlants(handlers.DBFromContext(c)),
 			"activities":      store.Activities(),
@@ -77,12 +77,16 @@ func TestAddBasicRoutes_RegistersAllExpectedRoutes(t *testing.T) {
 		{"GET", "/plants"},
 		{"GET", "/activities"},
 		{"GET", "/activities/list"},
+		{"GET", "/gallery"},
+		{"GET", "/gallery/images"},
+		{"GET", "/image-tags"},
 		{"GET", "/strains"},
 		{"GET", "/graph/:id"},
 		{"GET", "/plant/new"},
 		{"GET", "/plant/:id"},
 		{"GET", "/strain/new"},
 		{"GET", "/strain/:id"},
+		{"GET", "/strain/:id/pheno-hunt"},
 		{"GET", "/listFonts"},
 		{"GET", "/listLogos"},
 		{"GET", "/plants/living"},
@@ -96,8 +100,37 @@ func TestAddBasicRoutes_RegistersAllExpectedRoutes(t *testing.T) {
 		{"GET", "/strains/out-of-stock"},
 		{"POST", "/decorateImage"},
 		{"GET", "/streams"},
+		{"GET", "/plant/images/:imageID/:variant"},
+		{"GET", "/plant/:id/progression"},
+		{"GET", "/plant/:id/harvest"},
+		{"GET", "/plant/:id/harvest/summary"},
+		{"GET", "/runs"},
+		{"GET", "/runs/list"},
+		{"GET", "/runs/compare"},
+		{"GET", "/runs/:id"},
+		{"GET", "/runs/:id/report"},
+		{"GET", "/nutrients"},
+		{"GET", "/nutrients/products"},
+		{"GET", "/nutrients/recipes"},
+		{"GET", "/nutrients/schedules"},
+		{"GET", "/tasks"},
+		{"GET", "/tasks/list"},
+		{"GET", "/tasks/due"},
+		{"GET", "/mothers"},
+		{"GET", "/mothers/list"},
+		{"GET", "/seeds"},
+		{"GET", "/seeds/stats"},
+		{"GET", "/breeding"},
+		{"GET", "/breeding/projects"},
+		{"GET", "/plant/:id/feeding-plan"},
+		{"GET", "/plant/:id/lineage"},
 		{"GET", "/strains/:id/lineage"},
 		{"GET", "/strains/:id/descendants"},
+		{"GET", "/strains/:id/seed-lots"},
+		{"GET", "/strains/:id/pheno-hunt"},
+		{"GET", "/strains/:id/reviews"},
+		{"GET", "/plant/:id/reviews"},
+		{"GET", "/pheno/criteria"},
 		{"GET", "/strains/lookup"},
 	}
 	requireAllPresent(t, got, want, "AddBasicRoutes")
@@ -120,6 +153,38 @@ func TestAddProtectedApiRoutes_RegistersAllExpectedRoutes(t *testing.T) {
 		{"POST", "/plant/link-sensors"},
 		{"POST", "/plant/:plantID/images/upload"},
 		{"DELETE", "/plant/images/:imageID/delete"},
+		{"PUT", "/plant/images/:imageID/tags"},
+		{"POST", "/plant/:plantID/progression/export"},
+		{"PUT", "/plant/:plantID/harvest"},
+		{"DELETE", "/plant/:plantID/harvest"},
+		{"POST", "/plant/:plantID/harvest/dry-logs"},
+		{"DELETE", "/harvest/dry-logs/:id"},
+		{"POST", "/plant/:plantID/harvest/jars"},
+		{"PUT", "/harvest/jars/:id"},
+		{"DELETE", "/harvest/jars/:id"},
+		{"POST", "/harvest/jars/:id/logs"},
+		{"DELETE", "/harvest/jar-logs/:id"},
+		{"PUT", "/plant/:plantID/harvest/yields"},
+		{"POST", "/plant/:plantID/clones"},
+		{"PUT", "/plant/:plantID/keeper"},
+		{"POST", "/runs"},
+		{"PUT", "/runs/:id"},
+		{"DELETE", "/runs/:id"},
+		{"PUT", "/runs/:id/plants"},
+		{"POST", "/nutrients/products"},
+		{"PUT", "/nutrients/products/:id"},
+		{"DELETE", "/nutrients/products/:id"},
+		{"POST", "/nutrients/recipes"},
+		{"PUT", "/nutrients/recipes/:id"},
+		{"DELETE", "/nutrients/recipes/:id"},
+		{"POST", "/nutrients/schedules"},
+		{"PUT", "/nutrients/schedules/:id"},
+		{"DELETE", "/nutrients/schedules/:id"},
+		{"PUT", "/plant/:plantID/feeding-schedule"},
+		{"POST", "/tasks"},
+		{"PUT", "/tasks/:id"},
+		{"DELETE", "/tasks/:id"},
+		{"POST", "/tasks/:id/complete"},
 
 		// Status / measurement / activity
 		{"POST", "/plantStatus/edit"},
@@ -142,16 +207,51 @@ func TestAddProtectedApiRoutes_RegistersAllExpectedRoutes(t *testing.T) {
 		{"POST", "/strains"},
 		{"PUT", "/strains/:id"},
 		{"DELETE", "/strains/:id"},
+		{"GET", "/strains/export"},
+		{"POST", "/strains/import"},
 		{"POST", "/breeders"},
 		{"PUT", "/breeders/:id"},
 		{"DELETE", "/breeders/:id"},
 
+		// CannaDB
+		{"GET", "/strains/cannadb/search"},
+		{"POST", "/strains/cannadb/import"},
+		{"GET", "/strains/cannadb/sync"},
+		{"POST", "/strains/cannadb/sync"},
+		{"PUT", "/strains/:id/cannadb-locks/:field"},
+		{"DELETE", "/strains/:id/cannadb-locks/:field"},
+		{"PUT", "/breeders/:id/cannadb-locks/:field"},
+		{"DELETE", "/breeders/:id/cannadb-locks/:field"},
+
 		// Lineage
 		{"POST", "/strains/:id/lineage"},
 		{"PUT", "/strains/:id/lineage"},
 		{"PUT", "/lineage/:lineageID"},
 		{"DELETE", "/lineage/:lineageID"},
 
+		// Seed lots and germination
+		{"POST", "/strains/:id/seed-lots"},
+		{"PUT", "/seed-lots/:id"},
+		{"DELETE", "/seed-lots/:id"},
+		{"POST", "/seed-lots/:id/germinations"},
+		{"PUT", "/germinations/:id"},
+		{"DELETE", "/germinations/:id"},
+		{"POST", "/breeding/projects"},
+		{"PUT", "/breeding/projects/:id"},
+		{"DELETE", "/breeding/projects/:id"},
+		{"POST", "/pollinations"},
+		{"PUT", "/pollinations/:id"},
+		{"DELETE", "/pollinations/:id"},
+		{"POST", "/pollinations/:id/harvest"},
+		{"POST", "/pheno/criteria"},
+		{"PUT", "/pheno/criteria/:id"},
+		{"DELETE", "/pheno/criteria/:id"},
+		{"PUT", "/plant/:plantID/pheno-scores"},
+		{"PUT", "/plant/:plantID/pheno-keeper"},
+		{"POST", "/plant/:plantID/reviews"},
+		{"PUT", "/strain-reviews/:id"},
+		{"DELETE", "/strain-reviews/:id"},
+
 		// AC Infinity OAuth
 		{"POST", "/aci/login"},
 
@@ -170,9 +270,16 @@ func TestAddProtectedApiRoutes_RegistersAllExpectedRoutes(t *testing.T) {
 		{"POST", "/streams"},
 		{"PUT", "/streams/:id"},
 		{"DELETE", "/streams/:id"},
+		{"POST", "/streams/:id/timelapses"},
+		{"GET", "/timelapses"},
+		{"DELETE", "/timelapses/:id"},
+		{"POST", "/timelapses/:id/attach"},
 
 		// Settings + backup + logs
 		{"POST", "/settings"},
+		{"POST", "/settings/notifications/test"},
+		{"POST", "/settings/calendar-token"},
+		{"DELETE", "/settings/calendar-token"},
 		{"POST", "/settings/upload-logo"},
 		{"GET", "/settings/logs"},
 		{"GET", "/settings/logs/download"},
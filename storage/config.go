@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Config selects and configures a storage backend.
+type Config struct {
+	// Backend is BackendLocal (the default) or BackendS3.
+	Backend string
+	// LocalDir roots the local backend; normally the upload directory.
+	LocalDir string
+	// S3 configures the S3 backend.
+	S3 S3Config
+}
+
+// ConfigFromEnv reads the storage configuration from ISLEY_STORAGE_* and
+// ISLEY_S3_* environment variables. localDir roots the local backend.
+func ConfigFromEnv(localDir string) Config {
+	return Config{
+		Backend:  strings.ToLower(strings.TrimSpace(os.Getenv("ISLEY_STORAGE_BACKEND"))),
+		LocalDir: localDir,
+		S3: S3Config{
+			Endpoint:        os.Getenv("ISLEY_S3_ENDPOINT"),
+			Region:          os.Getenv("ISLEY_S3_REGION"),
+			Bucket:          os.Getenv("ISLEY_S3_BUCKET"),
+			Prefix:          os.Getenv("ISLEY_S3_PREFIX"),
+			AccessKeyID:     os.Getenv("ISLEY_S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("ISLEY_S3_SECRET_ACCESS_KEY"),
+			PathStyle:       strings.EqualFold(os.Getenv("ISLEY_S3_PATH_STYLE"), "true"),
+		},
+	}
+}
+
+// New builds the Store cfg describes.
+func New(cfg Config) (Store, error) {
+	switch cfg.Backend {
+	case "", BackendLocal:
+		return NewLocal(cfg.LocalDir), nil
+	case BackendS3:
+		return NewS3(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q (expected %q or %q)", cfg.Backend, BackendLocal, BackendS3)
+	}
+}
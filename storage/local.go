@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Local stores files under a directory on local disk.
+type Local struct {
+	root string
+}
+
+// NewLocal returns a Store rooted at dir. The directory is created
+// lazily by the first Put.
+func NewLocal(dir string) *Local {
+	return &Local{root: dir}
+}
+
+// Name implements Store.
+func (l *Local) Name() string { return BackendLocal }
+
+// Root returns the directory the store is rooted at.
+func (l *Local) Root() string { return l.root }
+
+// Path returns where key is kept on disk. Invalid keys map to a path
+// that cannot exist inside the root.
+func (l *Local) Path(key string) string {
+	if key == "" {
+		return l.root
+	}
+	clean, err := CleanKey(key)
+	if err != nil {
+		return filepath.Join(l.root, ".invalid")
+	}
+	return filepath.Join(l.root, filepath.FromSlash(clean))
+}
+
+func (l *Local) path(key string) (string, error) {
+	clean, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.root, filepath.FromSlash(clean)), nil
+}
+
+// Put implements Store.
+func (l *Local) Put(_ context.Context, key string, r io.Reader, _ int64) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(p, r)
+}
+
+// Get implements Store.
+func (l *Local) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(p)
+}
+
+// Stat implements Store.
+func (l *Local) Stat(_ context.Context, key string) (Object, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return Object{}, err
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		return Object{}, err
+	}
+	if info.IsDir() {
+		return Object{}, &fs.PathError{Op: "stat", Path: p, Err: fs.ErrNotExist}
+	}
+	clean, _ := CleanKey(key)
+	return Object{Key: clean, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// Delete implements Store.
+func (l *Local) Delete(_ context.Context, key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List implements Store. Temporary files left by an interrupted Put or
+// variant write are skipped.
+func (l *Local) List(ctx context.Context, prefix string, fn func(Object) error) error {
+	var objects []Object
+	err := filepath.WalkDir(l.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && p == l.root {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") || strings.HasPrefix(d.Name(), ".variant-") {
+			return nil
+		}
+		rel, err := filepath.Rel(l.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, Object{Key: key, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	for _, obj := range objects {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listKeys returns every key s lists under prefix.
+func listKeys(t *testing.T, s Store, prefix string) []string {
+	t.Helper()
+	var keys []string
+	require.NoError(t, s.List(context.Background(), prefix, func(obj Object) error {
+		keys = append(keys, obj.Key)
+		return nil
+	}))
+	return keys
+}
+
+func TestLocal_RoundTrip(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	root := t.TempDir()
+	s := NewLocal(root)
+
+	require.NoError(t, s.Put(ctx, "plants/a.jpg", strings.NewReader("photo"), -1))
+	data, err := os.ReadFile(filepath.Join(root, "plants", "a.jpg"))
+	require.NoError(t, err, "keys map onto the upload directory")
+	assert.Equal(t, "photo", string(data))
+
+	obj, err := s.Stat(ctx, "plants/a.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, "plants/a.jpg", obj.Key)
+	assert.EqualValues(t, 5, obj.Size)
+
+	got, err := ReadAll(ctx, s, "plants/a.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, "photo", string(got))
+
+	require.NoError(t, s.Delete(ctx, "plants/a.jpg"))
+	require.NoError(t, s.Delete(ctx, "plants/a.jpg"), "deleting a missing key succeeds")
+	_, err = s.Stat(ctx, "plants/a.jpg")
+	assert.True(t, IsNotExist(err))
+	_, err = s.Get(ctx, "plants/a.jpg")
+	assert.True(t, IsNotExist(err))
+	_, err = s.Stat(ctx, "plants")
+	assert.True(t, IsNotExist(err), "directories are not objects")
+}
+
+func TestLocal_RejectsEscapingKeys(t *testing.T) {
+	t.Parallel()
+	s := NewLocal(t.TempDir())
+	assert.Error(t, s.Put(context.Background(), "../escape.jpg", strings.NewReader("x"), 1))
+	assert.Error(t, s.Delete(context.Background(), "/etc/passwd"))
+}
+
+func TestLocal_List(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	root := t.TempDir()
+	s := NewLocal(root)
+	for _, key := range []string{"streams/stream_1_latest.jpg", "plants/b.jpg", "plants/a.jpg"} {
+		require.NoError(t, PutBytes(ctx, s, key, []byte("x")))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(root, "plants", ".upload-123"), []byte("partial"), 0o644))
+
+	assert.Equal(t, []string{"plants/a.jpg", "plants/b.jpg", "streams/stream_1_latest.jpg"}, listKeys(t, s, ""))
+	assert.Equal(t, []string{"plants/a.jpg", "plants/b.jpg"}, listKeys(t, s, "plants/"))
+	assert.Empty(t, listKeys(t, NewLocal(filepath.Join(root, "missing")), ""), "a missing root lists nothing")
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// MigrateOptions tunes Migrate.
+type MigrateOptions struct {
+	// Exclude lists key prefixes that are left where they are, e.g. the
+	// stream frame archive, which only ever lives on local disk.
+	Exclude []string
+	// Overwrite copies files that already exist at the destination with
+	// the same size. By default those are skipped, so an interrupted
+	// migration can simply be re-run.
+	Overwrite bool
+	// DeleteSource removes each file from the source once it has been
+	// copied (or found already present).
+	DeleteSource bool
+	// DryRun reports what would be copied without changing anything.
+	DryRun bool
+	// Progress, when set, is called after each file.
+	Progress func(obj Object, action string)
+}
+
+// MigrateStats summarises a migration.
+type MigrateStats struct {
+	Copied  int
+	Skipped int
+	Deleted int
+	Bytes   int64
+}
+
+// Migration actions reported to MigrateOptions.Progress.
+const (
+	MigrateCopied  = "copied"
+	MigrateSkipped = "skipped"
+)
+
+// Migrate copies every file in src to dst. Keys are unchanged, so
+// database rows keep pointing at the right files after the switch.
+func Migrate(ctx context.Context, src, dst Store, opts MigrateOptions) (MigrateStats, error) {
+	var stats MigrateStats
+	err := src.List(ctx, "", func(obj Object) error {
+		for _, prefix := range opts.Exclude {
+			if strings.HasPrefix(obj.Key, prefix) {
+				return nil
+			}
+		}
+
+		action := MigrateCopied
+		if !opts.Overwrite {
+			if existing, err := dst.Stat(ctx, obj.Key); err == nil && existing.Size == obj.Size {
+				action = MigrateSkipped
+			} else if err != nil && !IsNotExist(err) {
+				return err
+			}
+		}
+
+		if !opts.DryRun {
+			if action == MigrateCopied {
+				if err := copyObject(ctx, src, dst, obj); err != nil {
+					return err
+				}
+			}
+			if opts.DeleteSource {
+				if err := src.Delete(ctx, obj.Key); err != nil {
+					return fmt.Errorf("delete %s from %s: %w", obj.Key, src.Name(), err)
+				}
+				stats.Deleted++
+			}
+		}
+
+		if action == MigrateCopied {
+			stats.Copied++
+			stats.Bytes += obj.Size
+		} else {
+			stats.Skipped++
+		}
+		if opts.Progress != nil {
+			opts.Progress(obj, action)
+		}
+		return nil
+	})
+	return stats, err
+}
+
+func copyObject(ctx context.Context, src, dst Store, obj Object) error {
+	rc, err := src.Get(ctx, obj.Key)
+	if err != nil {
+		return fmt.Errorf("read %s from %s: %w", obj.Key, src.Name(), err)
+	}
+	defer rc.Close()
+	if err := dst.Put(ctx, obj.Key, rc, obj.Size); err != nil {
+		return fmt.Errorf("write %s to %s: %w", obj.Key, dst.Name(), err)
+	}
+	return nil
+}
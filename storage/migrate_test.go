@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedStore writes each key with its value as contents.
+func seedStore(t *testing.T, s Store, files map[string]string) {
+	t.Helper()
+	for key, data := range files {
+		require.NoError(t, PutBytes(context.Background(), s, key, []byte(data)))
+	}
+}
+
+func TestMigrate_LocalToS3(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	src := NewLocal(t.TempDir())
+	_, dst := newFakeS3(t)
+	seedStore(t, src, map[string]string{
+		"plants/a.jpg":                        "aaa",
+		"plants/variants/a_thumb.jpg":         "t",
+		"streams/stream_1_latest.jpg":         "frame",
+		"streams/archive/stream_1/frame1.jpg": "old frame",
+	})
+	seedStore(t, dst, map[string]string{"plants/variants/a_thumb.jpg": "t"})
+
+	var progress []string
+	stats, err := Migrate(ctx, src, dst, MigrateOptions{
+		Exclude:  []string{"streams/archive/"},
+		Progress: func(obj Object, action string) { progress = append(progress, action+" "+obj.Key) },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, MigrateStats{Copied: 2, Skipped: 1, Bytes: 8}, stats)
+	assert.Equal(t, []string{
+		"copied plants/a.jpg",
+		"skipped plants/variants/a_thumb.jpg",
+		"copied streams/stream_1_latest.jpg",
+	}, progress)
+
+	got, err := ReadAll(ctx, dst, "plants/a.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, "aaa", string(got))
+	_, err = dst.Stat(ctx, "streams/archive/stream_1/frame1.jpg")
+	assert.True(t, IsNotExist(err), "excluded prefixes stay behind")
+	assert.Len(t, listKeys(t, src, ""), 4, "the source is kept by default")
+}
+
+func TestMigrate_DryRunAndDeleteSource(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	_, src := newFakeS3(t)
+	dst := NewLocal(t.TempDir())
+	seedStore(t, src, map[string]string{"plants/a.jpg": "aaa", "plants/b.jpg": "bb"})
+
+	stats, err := Migrate(ctx, src, dst, MigrateOptions{DryRun: true, DeleteSource: true})
+	require.NoError(t, err)
+	assert.Equal(t, MigrateStats{Copied: 2, Bytes: 5}, stats)
+	assert.Empty(t, listKeys(t, dst, ""), "a dry run copies nothing")
+	assert.Len(t, listKeys(t, src, ""), 2, "a dry run deletes nothing")
+
+	stats, err = Migrate(ctx, src, dst, MigrateOptions{DeleteSource: true})
+	require.NoError(t, err)
+	assert.Equal(t, MigrateStats{Copied: 2, Deleted: 2, Bytes: 5}, stats)
+	assert.Equal(t, []string{"plants/a.jpg", "plants/b.jpg"}, listKeys(t, dst, ""))
+	assert.Empty(t, listKeys(t, src, ""))
+}
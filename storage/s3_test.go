@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 is a path-style, in-memory S3 endpoint covering the calls the
+// S3 store makes. It pages listings two keys at a time so continuation
+// tokens are exercised.
+type fakeS3 struct {
+	t      *testing.T
+	bucket string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+var authHeaderRE = regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=AKID/\d{8}/eu-central-1/s3/aws4_request, ` +
+	`SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=[0-9a-f]{64}$`)
+
+func newFakeS3(t *testing.T) (*fakeS3, *S3) {
+	t.Helper()
+	f := &fakeS3{t: t, bucket: "isley", objects: map[string][]byte{}}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	s, err := NewS3(S3Config{
+		Endpoint:        srv.URL,
+		Region:          "eu-central-1",
+		Bucket:          "isley",
+		Prefix:          "/site-a/",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+		PathStyle:       true,
+	})
+	require.NoError(t, err)
+	return f, s
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !authHeaderRE.MatchString(r.Header.Get("Authorization")) || r.Header.Get("x-amz-date") == "" {
+		f.t.Errorf("unsigned request %s %s: %q", r.Method, r.URL, r.Header.Get("Authorization"))
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+		return
+	}
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+f.bucket)
+	if !ok {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+	key = strings.TrimPrefix(key, "/")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		f.list(w, r)
+	case r.Method == http.MethodPut:
+		if r.ContentLength < 0 {
+			http.Error(w, "MissingContentLength", http.StatusLengthRequired)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = data
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		data, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Last-Modified", time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC).Format(http.TimeFormat))
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "NotImplemented", http.StatusNotImplemented)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	var keys []string
+	for k := range f.objects {
+		if strings.HasPrefix(k, prefix) && k > r.URL.Query().Get("continuation-token") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	type content struct {
+		Key          string
+		Size         int
+		LastModified string
+	}
+	result := struct {
+		XMLName               xml.Name `xml:"ListBucketResult"`
+		Contents              []content
+		IsTruncated           bool
+		NextContinuationToken string `xml:",omitempty"`
+	}{}
+	for i, k := range keys {
+		if i == 2 {
+			result.IsTruncated = true
+			result.NextContinuationToken = keys[1]
+			break
+		}
+		result.Contents = append(result.Contents, content{Key: k, Size: len(f.objects[k]), LastModified: "2026-05-01T12:00:00.000Z"})
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+// ---------------------------------------------------------------------------
+// Configuration
+// ---------------------------------------------------------------------------
+
+func TestNewS3_Validates(t *testing.T) {
+	t.Parallel()
+	_, err := NewS3(S3Config{AccessKeyID: "a", SecretAccessKey: "b"})
+	assert.Error(t, err, "bucket is required")
+	_, err = NewS3(S3Config{Bucket: "b"})
+	assert.Error(t, err, "credentials are required")
+	_, err = NewS3(S3Config{Bucket: "b", AccessKeyID: "a", SecretAccessKey: "b", Endpoint: "minio:9000"})
+	assert.Error(t, err, "endpoint needs a scheme")
+
+	s, err := NewS3(S3Config{Bucket: "b", AccessKeyID: "a", SecretAccessKey: "b"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://s3.us-east-1.amazonaws.com", s.cfg.Endpoint)
+}
+
+func TestS3_RequestURL(t *testing.T) {
+	t.Parallel()
+	virtual, err := NewS3(S3Config{Endpoint: "https://s3.example.com", Bucket: "isley", AccessKeyID: "a", SecretAccessKey: "b"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://isley.s3.example.com/plants/plant%201%2Ba.jpg",
+		virtual.requestURL("plants/plant 1+a.jpg", nil).String())
+
+	path, err := NewS3(S3Config{Endpoint: "http://minio:9000/", Bucket: "isley", AccessKeyID: "a", SecretAccessKey: "b", PathStyle: true})
+	require.NoError(t, err)
+	u := path.requestURL("", map[string][]string{"prefix": {"a/b"}, "list-type": {"2"}})
+	assert.Equal(t, "http://minio:9000/isley/?list-type=2&prefix=a%2Fb", u.String())
+}
+
+func TestS3_Sign(t *testing.T) {
+	t.Parallel()
+	s, err := NewS3(S3Config{Endpoint: "https://s3.example.com", Region: "eu-central-1", Bucket: "isley",
+		AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	require.NoError(t, err)
+	s.now = func() time.Time { return time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	sign := func(key string) string {
+		req, err := http.NewRequest(http.MethodGet, s.requestURL(key, nil).String(), nil)
+		require.NoError(t, err)
+		s.sign(req)
+		assert.Equal(t, "20260501T120000Z", req.Header.Get("x-amz-date"))
+		assert.Equal(t, "UNSIGNED-PAYLOAD", req.Header.Get("x-amz-content-sha256"))
+		return req.Header.Get("Authorization")
+	}
+	a := sign("plants/a.jpg")
+	assert.True(t, strings.HasPrefix(a, "AWS4-HMAC-SHA256 Credential=AKID/20260501/eu-central-1/s3/aws4_request, "), a)
+	assert.Equal(t, a, sign("plants/a.jpg"), "signing is deterministic")
+	assert.NotEqual(t, a, sign("plants/b.jpg"), "the path is signed")
+}
+
+// ---------------------------------------------------------------------------
+// Operations against a fake endpoint
+// ---------------------------------------------------------------------------
+
+func TestS3_RoundTrip(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fake, s := newFakeS3(t)
+
+	require.NoError(t, s.Put(ctx, "plants/a.jpg", strings.NewReader("photo"), 5))
+	require.NoError(t, s.Put(ctx, "plants/b.jpg", strings.NewReader("unknown length"), -1))
+	assert.Contains(t, fake.objects, "site-a/plants/a.jpg", "the prefix is applied")
+
+	got, err := ReadAll(ctx, s, "plants/a.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, "photo", string(got))
+
+	obj, err := s.Stat(ctx, "plants/b.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, Object{Key: "plants/b.jpg", Size: 14, ModTime: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)}, obj)
+
+	require.NoError(t, s.Delete(ctx, "plants/a.jpg"))
+	_, err = s.Get(ctx, "plants/a.jpg")
+	assert.True(t, IsNotExist(err))
+	_, err = s.Stat(ctx, "plants/a.jpg")
+	assert.True(t, IsNotExist(err))
+
+	assert.Error(t, s.Put(ctx, "../a.jpg", strings.NewReader("x"), 1), "keys are validated")
+}
+
+func TestS3_ListPages(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fake, s := newFakeS3(t)
+	for _, key := range []string{"plants/a.jpg", "plants/b.jpg", "plants/c.jpg", "streams/s.jpg", "timelapses/t.mp4"} {
+		require.NoError(t, PutBytes(ctx, s, key, []byte("x")))
+	}
+	fake.objects["other-site/plants/z.jpg"] = []byte("x")
+
+	assert.Equal(t, []string{"plants/a.jpg", "plants/b.jpg", "plants/c.jpg", "streams/s.jpg", "timelapses/t.mp4"},
+		listKeys(t, s, ""), "every page is read and the prefix stripped")
+	assert.Equal(t, []string{"plants/a.jpg", "plants/b.jpg", "plants/c.jpg"}, listKeys(t, s, "plants/"))
+}
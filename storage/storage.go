@@ -0,0 +1,180 @@
+// Package storage abstracts where uploaded files live. Plant photos,
+// their variants and the latest stream frames are addressed by a key
+// relative to the upload root ("plants/plant_1_image_0_1.jpg",
+// "streams/stream_2_latest.jpg"); a Store maps keys onto local disk or
+// an S3-compatible bucket. Database rows keep the upload-rooted path they
+// always had, so switching backends only moves files, never rows.
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Backend names accepted by Config.Backend and ISLEY_STORAGE_BACKEND.
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// Object describes a stored file.
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Store is a flat key/value file store. Missing keys are reported with
+// errors wrapping fs.ErrNotExist; Delete of a missing key succeeds.
+type Store interface {
+	// Name is the backend name, for logs and the migrate command.
+	Name() string
+	// Put stores r under key, replacing any existing file. size is the
+	// length of r, or -1 when unknown.
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get opens the file stored under key.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Stat describes the file stored under key.
+	Stat(ctx context.Context, key string) (Object, error)
+	// Delete removes the file stored under key.
+	Delete(ctx context.Context, key string) error
+	// List calls fn for every file whose key starts with prefix, in key
+	// order. Returning an error from fn stops the listing.
+	List(ctx context.Context, prefix string, fn func(Object) error) error
+}
+
+// localPather is implemented by stores that keep files on local disk.
+type localPather interface {
+	Path(key string) string
+}
+
+// LocalPath returns the on-disk path of key when s keeps its files on
+// local disk, so callers can hand the file to code that needs a path.
+func LocalPath(s Store, key string) (string, bool) {
+	lp, ok := s.(localPather)
+	if !ok {
+		return "", false
+	}
+	return lp.Path(key), true
+}
+
+// CleanKey normalises key to a slash-separated relative path and rejects
+// keys that would escape the store.
+func CleanKey(key string) (string, error) {
+	key = strings.ReplaceAll(key, "\\", "/")
+	cleaned := path.Clean("/" + key)[1:]
+	if cleaned == "" || strings.HasPrefix(key, "/") || cleaned != strings.TrimSuffix(key, "/") {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return cleaned, nil
+}
+
+// KeyForPath converts a stored file path (as recorded in plant_images,
+// e.g. "uploads/plants/x.jpg") into its key relative to root, the upload
+// directory. Paths outside root fall back to trimming a leading
+// "uploads/", which covers rows written before the upload root was
+// configurable.
+func KeyForPath(root, p string) string {
+	p = strings.ReplaceAll(p, "\\", "/")
+	if root != "" {
+		if rel, err := filepath.Rel(filepath.Clean(root), filepath.FromSlash(p)); err == nil && rel != "." &&
+			!strings.HasPrefix(rel, "..") && !filepath.IsAbs(rel) {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(p, "/"), "uploads/")
+}
+
+// IsNotExist reports whether err means a key has no file.
+func IsNotExist(err error) bool {
+	return errors.Is(err, fs.ErrNotExist)
+}
+
+// PutBytes stores data under key.
+func PutBytes(ctx context.Context, s Store, key string, data []byte) error {
+	return s.Put(ctx, key, bytes.NewReader(data), int64(len(data)))
+}
+
+// ReadAll returns the contents stored under key.
+func ReadAll(ctx context.Context, s Store, key string) ([]byte, error) {
+	rc, err := s.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// PutFile stores the local file at src under key. It is a no-op when s
+// is a local store and src already is the file for key, which lets
+// producers that write into the upload directory (the stream grabber)
+// publish unconditionally.
+func PutFile(ctx context.Context, s Store, key, src string) error {
+	if dst, ok := LocalPath(s, key); ok && samePath(dst, src) {
+		return nil
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, key, f, info.Size())
+}
+
+// Materialize makes sure the file for key exists at the local path dst,
+// downloading it from s when it is missing. Tools that need a real file
+// (image overlays, progression strips) call it before opening dst.
+func Materialize(ctx context.Context, s Store, key, dst string) error {
+	if _, err := os.Stat(dst); err == nil {
+		return nil
+	}
+	if src, ok := LocalPath(s, key); ok && samePath(src, dst) {
+		return fmt.Errorf("materialize %s: %w", key, fs.ErrNotExist)
+	}
+	rc, err := s.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return writeFileAtomic(dst, rc)
+}
+
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// writeFileAtomic copies r to dst via a temporary file beside it, so
+// readers never see a partial file.
+func writeFileAtomic(dst string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
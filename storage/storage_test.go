@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// Keys
+// ---------------------------------------------------------------------------
+
+func TestCleanKey(t *testing.T) {
+	t.Parallel()
+	for key, want := range map[string]string{
+		"plants/a.jpg":          "plants/a.jpg",
+		`plants\variants\a.jpg`: "plants/variants/a.jpg",
+		"streams/":              "streams",
+	} {
+		got, err := CleanKey(key)
+		require.NoError(t, err, key)
+		assert.Equal(t, want, got, key)
+	}
+	for _, key := range []string{"", "/plants/a.jpg", "../a.jpg", "plants/../../a.jpg", "plants//a.jpg", "./a.jpg"} {
+		_, err := CleanKey(key)
+		assert.Error(t, err, "key %q", key)
+	}
+}
+
+func TestKeyForPath(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "plants/a.jpg", KeyForPath("uploads", "uploads/plants/a.jpg"))
+	assert.Equal(t, "plants/a.jpg", KeyForPath("uploads", `uploads\plants\a.jpg`), "Windows rows")
+	assert.Equal(t, "plants/a.jpg", KeyForPath("uploads", "/uploads/plants/a.jpg"), "URL paths")
+	assert.Equal(t, "plants/a.jpg", KeyForPath("/srv/isley/media", "/srv/isley/media/plants/a.jpg"))
+	assert.Equal(t, "plants/a.jpg", KeyForPath("/srv/isley/media", "uploads/plants/a.jpg"),
+		"rows written before the upload root moved")
+}
+
+// ---------------------------------------------------------------------------
+// PutFile / Materialize
+// ---------------------------------------------------------------------------
+
+func TestPutFile_SkipsItsOwnFile(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	s := NewLocal(root)
+	p := filepath.Join(root, "streams", "stream_1_latest.jpg")
+	require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+	require.NoError(t, os.WriteFile(p, []byte("frame"), 0o644))
+
+	require.NoError(t, PutFile(context.Background(), s, "streams/stream_1_latest.jpg", p))
+	data, err := os.ReadFile(p)
+	require.NoError(t, err)
+	assert.Equal(t, "frame", string(data))
+
+	require.NoError(t, PutFile(context.Background(), s, "plants/copy.jpg", p))
+	data, err = ReadAll(context.Background(), s, "plants/copy.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, "frame", string(data))
+}
+
+func TestMaterialize(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	remote := NewLocal(t.TempDir())
+	require.NoError(t, PutBytes(ctx, remote, "plants/a.jpg", []byte("photo")))
+
+	dst := filepath.Join(t.TempDir(), "uploads", "plants", "a.jpg")
+	require.NoError(t, Materialize(ctx, remote, "plants/a.jpg", dst))
+	data, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "photo", string(data))
+
+	// An existing file is left alone.
+	require.NoError(t, PutBytes(ctx, remote, "plants/a.jpg", []byte("changed")))
+	require.NoError(t, Materialize(ctx, remote, "plants/a.jpg", dst))
+	data, err = os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "photo", string(data))
+
+	err = Materialize(ctx, remote, "plants/missing.jpg", filepath.Join(t.TempDir(), "missing.jpg"))
+	assert.True(t, IsNotExist(err))
+}
@@ -0,0 +1,167 @@
+package integration
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"isley/model/types"
+	"isley/tests/testutil"
+)
+
+func ingestReading(t *testing.T, c *testutil.Client, apiKey string, value float64) int {
+	t.Helper()
+	resp := c.APIPostJSON(t, "/api/sensors/ingest", apiKey, map[string]interface{}{
+		"source": "probe", "device": "P1", "type": "temp", "value": value,
+	})
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var body struct {
+		SensorID int `json:"sensor_id"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	return body.SensorID
+}
+
+func storedValues(t *testing.T, db *sql.DB, sensorID int) []float64 {
+	t.Helper()
+	rows, err := db.Query(`SELECT value FROM sensor_data WHERE sensor_id = $1 ORDER BY id`, sensorID)
+	require.NoError(t, err)
+	defer rows.Close()
+	var out []float64
+	for rows.Next() {
+		var v float64
+		require.NoError(t, rows.Scan(&v))
+		out = append(out, v)
+	}
+	require.NoError(t, rows.Err())
+	return out
+}
+
+func postCalibration(t *testing.T, c *testutil.Client, apiKey string, sensorID int, body map[string]interface{}) *http.Response {
+	t.Helper()
+	return c.APIPostJSON(t, "/sensors/"+strconv.Itoa(sensorID)+"/calibration", apiKey, body)
+}
+
+func TestSensorCalibration_AppliedAtIngestAndRetroactively(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithConfigStore(storeWithAPIIngest(1)))
+	apiKey := seedSensorIngestKey(t, db)
+	c := server.NewClient(t)
+
+	sensorID := ingestReading(t, c, apiKey, 77.3)
+	ingestReading(t, c, apiKey, 78.3)
+
+	resp := postCalibration(t, c, apiKey, sensorID, map[string]interface{}{
+		"method": "linear", "offset": -1.5, "note": "reads high",
+	})
+	testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	ingestReading(t, c, apiKey, 79.3)
+	vals := storedValues(t, db, sensorID)
+	require.Len(t, vals, 3)
+	assert.InDelta(t, 77.3, vals[0], 0.001, "existing readings are untouched without retroactive")
+	assert.InDelta(t, 78.3, vals[1], 0.001)
+	assert.InDelta(t, 77.8, vals[2], 0.001, "new readings are calibrated at ingest")
+
+	// Re-calibrating retroactively undoes each reading's own calibration
+	// before applying the new one.
+	resp = postCalibration(t, c, apiKey, sensorID, map[string]interface{}{
+		"method": "linear", "offset": -1.0, "retroactive": true,
+	})
+	var saved struct {
+		RowsUpdated int `json:"rows_updated"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&saved))
+	testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 3, saved.RowsUpdated)
+
+	vals = storedValues(t, db, sensorID)
+	assert.InDelta(t, 76.3, vals[0], 0.001)
+	assert.InDelta(t, 77.3, vals[1], 0.001)
+	assert.InDelta(t, 78.3, vals[2], 0.001)
+
+	var avg float64
+	var samples int
+	require.NoError(t, db.QueryRow(
+		`SELECT SUM(avg_val * sample_count) / SUM(sample_count), SUM(sample_count) FROM sensor_data_hourly WHERE sensor_id = $1`, sensorID,
+	).Scan(&avg, &samples))
+	assert.Equal(t, 3, samples, "rollups are rebuilt from the rewritten readings")
+	assert.InDelta(t, 77.3, avg, 0.001)
+
+	resp = c.APIGet(t, "/sensors/"+strconv.Itoa(sensorID)+"/calibration", apiKey)
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var got struct {
+		Calibration types.SensorCalibration   `json:"calibration"`
+		History     []types.SensorCalibration `json:"history"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, -1.0, got.Calibration.Offset)
+	require.Len(t, got.History, 2)
+	assert.True(t, got.History[0].Retroactive, "history is newest first")
+	assert.Equal(t, "reads high", got.History[1].Note)
+}
+
+func TestSensorCalibration_RetroactiveKeepsRollupsOlderThanRawData(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithConfigStore(storeWithAPIIngest(1)))
+	apiKey := seedSensorIngestKey(t, db)
+	c := server.NewClient(t)
+
+	sensorID := ingestReading(t, c, apiKey, 20)
+	// An hour whose raw readings have been pruned.
+	testutil.MustExec(t, db, `INSERT INTO sensor_data_hourly (sensor_id, bucket, min_val, max_val, avg_val, sample_count)
+		VALUES ($1, '2025-01-01 10:00:00', 8, 12, 10, 4)`, sensorID)
+
+	resp := postCalibration(t, c, apiKey, sensorID, map[string]interface{}{
+		"method": "linear", "scale": -2.0, "offset": 1.0, "retroactive": true,
+	})
+	testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var minVal, maxVal, avgVal float64
+	require.NoError(t, db.QueryRow(`SELECT min_val, max_val, avg_val FROM sensor_data_hourly WHERE sensor_id = $1 AND bucket = '2025-01-01 10:00:00'`,
+		sensorID).Scan(&minVal, &maxVal, &avgVal), "history older than the raw data is kept")
+	assert.InDelta(t, -23, minVal, 0.001, "a negative scale swaps min and max")
+	assert.InDelta(t, -15, maxVal, 0.001)
+	assert.InDelta(t, -19, avgVal, 0.001)
+
+	require.NoError(t, db.QueryRow(`SELECT avg_val FROM sensor_data_hourly WHERE sensor_id = $1 AND sample_count = 1`, sensorID).Scan(&avgVal))
+	assert.InDelta(t, -39, avgVal, 0.001, "buckets with raw readings are rebuilt from them")
+	assert.Equal(t, 2, testutil.CountRows(t, db, `SELECT COUNT(*) FROM sensor_data_hourly WHERE sensor_id = $1`, sensorID))
+}
+
+func TestSensorCalibration_Rejects(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db)
+	apiKey := seedSensorIngestKey(t, db)
+	c := server.NewClient(t)
+
+	virtual := testutil.SeedSensor(t, db, types.VirtualSource, types.VirtualSource, "mean")
+
+	resp := postCalibration(t, c, apiKey, 9999, map[string]interface{}{"method": "linear", "offset": 1})
+	testutil.DrainAndClose(resp)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = postCalibration(t, c, apiKey, virtual, map[string]interface{}{"method": "linear", "offset": 1})
+	testutil.DrainAndClose(resp)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = postCalibration(t, c, apiKey, virtual, map[string]interface{}{"method": "two_point", "raw_low": 1})
+	testutil.DrainAndClose(resp)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
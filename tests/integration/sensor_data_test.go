@@ -301,6 +301,145 @@ func TestChartHandler_RejectsMissingTimeParams(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 }
 
+// ---------------------------------------------------------------------------
+// ChartSeriesHandler — downsampled multi-sensor overlay
+// ---------------------------------------------------------------------------
+
+type chartSeriesResponse struct {
+	Source        string      `json:"source"`
+	BucketSeconds int64       `json:"bucket_seconds"`
+	Timestamps    []time.Time `json:"timestamps"`
+	Series        []struct {
+		SensorID int        `json:"sensor_id"`
+		Name     string     `json:"name"`
+		Unit     string     `json:"unit"`
+		Values   []*float64 `json:"values"`
+		Min      []*float64 `json:"min"`
+		Max      []*float64 `json:"max"`
+	} `json:"series"`
+}
+
+func TestChartSeries_RawOverlayIsAligned(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithConfigStore(storeWithPollingInterval(60)))
+
+	testutil.MustExec(t, db, `INSERT INTO zones (id, name) VALUES (1, 'Z')`)
+	testutil.MustExec(t, db, `INSERT INTO sensors (id, name, zone_id, source, device, type, unit) VALUES (1, 'Tent Temp', 1, 'src', 'D', 'temp', '°C')`)
+	testutil.MustExec(t, db, `INSERT INTO sensors (id, name, zone_id, source, device, type, unit) VALUES (2, 'Tent RH', 1, 'src', 'D', 'hum', '%')`)
+
+	now := time.Now().UTC()
+	insertReadingAt(t, db, 1, 20.0, now.Add(-50*time.Minute))
+	insertReadingAt(t, db, 1, 24.0, now.Add(-50*time.Minute).Add(10*time.Second))
+	insertReadingAt(t, db, 2, 55.0, now.Add(-10*time.Minute))
+
+	testutil.SeedAdmin(t, db, "series-pw")
+	c := server.LoginAsAdmin(t, "series-pw")
+
+	resp := c.Get("/sensorData/chart?sensors=1,2&minutes=60&points=10")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var got chartSeriesResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, "raw", got.Source)
+	assert.EqualValues(t, 360, got.BucketSeconds, "60 minutes / 10 points")
+	require.Len(t, got.Series, 2)
+	assert.Equal(t, "Tent Temp", got.Series[0].Name)
+	assert.Equal(t, "%", got.Series[1].Unit)
+
+	for _, s := range got.Series {
+		require.Len(t, s.Values, len(got.Timestamps), "every series shares the timestamp axis")
+	}
+
+	// Both temperature readings fall into the same bucket and collapse to
+	// one avg with a min/max envelope.
+	var populated int
+	for i, v := range got.Series[0].Values {
+		if v == nil {
+			continue
+		}
+		populated++
+		assert.InDelta(t, 22.0, *v, 0.0001)
+		assert.InDelta(t, 20.0, *got.Series[0].Min[i], 0.0001)
+		assert.InDelta(t, 24.0, *got.Series[0].Max[i], 0.0001)
+	}
+	assert.Equal(t, 1, populated)
+}
+
+func TestChartSeries_LongRangeUsesRollup(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithConfigStore(storeWithPollingInterval(60)))
+
+	testutil.MustExec(t, db, `INSERT INTO zones (id, name) VALUES (1, 'Z')`)
+	testutil.MustExec(t, db, `INSERT INTO sensors (id, name, zone_id, source, device, type) VALUES (1, 'Tent Temp', 1, 'src', 'D', 'temp')`)
+
+	bucket := time.Now().UTC().Add(-3 * time.Hour).Format("2006-01-02 15:00:00")
+	testutil.MustExec(t, db,
+		`INSERT INTO sensor_data_hourly (sensor_id, bucket, min_val, max_val, avg_val, sample_count)
+		 VALUES (1, $1, 18.0, 22.0, 20.0, 4)`,
+		bucket,
+	)
+
+	testutil.SeedAdmin(t, db, "series-pw-2")
+	c := server.LoginAsAdmin(t, "series-pw-2")
+
+	resp := c.Get("/sensorData/chart?sensors=1&minutes=" + strconv.Itoa(7*24*60))
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var got chartSeriesResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, "hourly", got.Source)
+	assert.GreaterOrEqual(t, got.BucketSeconds, int64(3600), "rollup buckets are never finer than an hour")
+	require.Len(t, got.Series, 1)
+
+	var found bool
+	for i, v := range got.Series[0].Values {
+		if v != nil {
+			found = true
+			assert.InDelta(t, 20.0, *v, 0.0001)
+			assert.InDelta(t, 18.0, *got.Series[0].Min[i], 0.0001)
+			assert.InDelta(t, 22.0, *got.Series[0].Max[i], 0.0001)
+		}
+	}
+	assert.True(t, found, "the seeded rollup row should land in a bucket")
+}
+
+func TestChartSeries_RejectsBadRequests(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithConfigStore(storeWithPollingInterval(60)))
+
+	testutil.MustExec(t, db, `INSERT INTO zones (id, name) VALUES (1, 'Z')`)
+	testutil.MustExec(t, db, `INSERT INTO sensors (id, name, zone_id, source, device, type) VALUES (1, 'Tent Temp', 1, 'src', 'D', 'temp')`)
+
+	testutil.SeedAdmin(t, db, "series-pw-3")
+	c := server.LoginAsAdmin(t, "series-pw-3")
+
+	ids := make([]string, 13)
+	for i := range ids {
+		ids[i] = strconv.Itoa(i + 1)
+	}
+
+	for name, path := range map[string]string{
+		"missing sensors": "/sensorData/chart?minutes=60",
+		"non-numeric id":  "/sensorData/chart?sensors=1,abc&minutes=60",
+		"unknown sensor":  "/sensorData/chart?sensors=1,999&minutes=60",
+		"too many":        "/sensorData/chart?minutes=60&sensors=" + strings.Join(ids, ","),
+		"missing range":   "/sensorData/chart?sensors=1",
+		"bad points":      "/sensorData/chart?sensors=1&minutes=60&points=lots",
+	} {
+		resp := c.Get(path)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Concurrent ingest doesn't corrupt the sensor row (smoke check that
 // the duplicate-key path is exercised under contention).
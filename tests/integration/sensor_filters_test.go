@@ -0,0 +1,122 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"isley/model/types"
+	"isley/tests/testutil"
+)
+
+func TestSensorFilters_IngestRejectsAndQuarantines(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db, testutil.WithConfigStore(storeWithAPIIngest(1)))
+	apiKey := seedSensorIngestKey(t, db)
+	c := server.NewClient(t)
+
+	resp := c.APIPostJSON(t, "/sensor-filters", apiKey, map[string]interface{}{
+		"sensor_type": "temp", "min_value": -20, "max_value": 60, "quarantine": true,
+	})
+	var created struct {
+		ID int `json:"id"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	sensorID := ingestReading(t, c, apiKey, 21.5)
+
+	resp = c.APIPostJSON(t, "/api/sensors/ingest", apiKey, map[string]interface{}{
+		"source": "probe", "device": "P1", "type": "temp", "value": 850.0,
+	})
+	var filtered struct {
+		Filtered bool   `json:"filtered"`
+		Reason   string `json:"reason"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&filtered))
+	testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "a filtered reading is not a client error")
+	assert.True(t, filtered.Filtered)
+	assert.Equal(t, types.FilterAboveMax, filtered.Reason)
+
+	assert.Equal(t, []float64{21.5}, storedValues(t, db, sensorID))
+
+	resp = c.APIGet(t, "/sensor-filters/quarantine?sensor_id="+strconv.Itoa(sensorID), apiKey)
+	var quarantine struct {
+		Readings []types.QuarantinedReading `json:"readings"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&quarantine))
+	testutil.DrainAndClose(resp)
+	require.Len(t, quarantine.Readings, 1)
+	assert.InDelta(t, 850.0, quarantine.Readings[0].Value, 0.001)
+
+	resp = c.APIGet(t, "/sensor-filters", apiKey)
+	var list struct {
+		Filters     []types.SensorFilter `json:"filters"`
+		SensorTypes []string             `json:"sensor_types"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	testutil.DrainAndClose(resp)
+	require.Len(t, list.Filters, 1)
+	assert.Equal(t, 1, list.Filters[0].RejectedCount)
+	assert.NotNil(t, list.Filters[0].LastRejectedDT)
+	assert.Contains(t, list.SensorTypes, "temp")
+
+	resp = c.APIDelete(t, "/sensor-filters/quarantine", apiKey)
+	testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var n int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM sensor_data_quarantine`).Scan(&n))
+	assert.Zero(t, n)
+
+	resp = c.APIDelete(t, "/sensor-filters/"+strconv.Itoa(created.ID), apiKey)
+	testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	ingestReading(t, c, apiKey, 850.0)
+	assert.Equal(t, []float64{21.5, 850.0}, storedValues(t, db, sensorID), "no filter, no rejection")
+}
+
+func TestSensorFilters_Validation(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db)
+	apiKey := seedSensorIngestKey(t, db)
+	c := server.NewClient(t)
+
+	cases := map[string]map[string]interface{}{
+		"missing type":       {"min_value": 0},
+		"no checks":          {"sensor_type": "temp"},
+		"inverted range":     {"sensor_type": "temp", "min_value": 10, "max_value": 5},
+		"negative delta":     {"sensor_type": "temp", "max_delta_per_min": -1},
+		"tiny median":        {"sensor_type": "temp", "median_window": 2, "median_max_deviation": 5},
+		"median without dev": {"sensor_type": "temp", "median_window": 5},
+	}
+	for name, body := range cases {
+		resp := c.APIPostJSON(t, "/sensor-filters", apiKey, body)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+		testutil.DrainAndClose(resp)
+	}
+
+	resp := c.APIPostJSON(t, "/sensor-filters", apiKey, map[string]interface{}{"sensor_type": "temp", "max_delta_per_min": 2})
+	testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = c.APIPostJSON(t, "/sensor-filters", apiKey, map[string]interface{}{"sensor_type": "temp", "max_value": 50})
+	testutil.DrainAndClose(resp)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "one filter per sensor type")
+
+	req := testutil.APIReq(t, http.MethodPut, c.BaseURL+"/sensor-filters/9999", apiKey,
+		testutil.JSONBody(t, map[string]interface{}{"sensor_type": "hum", "max_value": 100}), "application/json")
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+	testutil.DrainAndClose(resp)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,133 @@
+package integration
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"isley/model/types"
+	"isley/tests/testutil"
+)
+
+const virtualSensorTestPassword = "virtual-sensor-pw"
+
+// newVirtualSensorSession seeds a zone with a °F temperature sensor and a
+// humidity sensor, logs in, and returns the ids of both inputs.
+func newVirtualSensorSession(t *testing.T) (*sql.DB, *testutil.Client, string, int, int) {
+	t.Helper()
+	db := testutil.NewTestDB(t)
+	server := testutil.NewTestServer(t, db)
+	testutil.SeedAdmin(t, db, virtualSensorTestPassword)
+	testutil.MustExec(t, db, `INSERT INTO zones (id, name) VALUES (1, 'Z')`)
+
+	temp := testutil.SeedSensor(t, db, "test", "dev", "temp")
+	hum := testutil.SeedSensor(t, db, "test", "dev", "hum")
+	testutil.MustExec(t, db, `UPDATE sensors SET unit = '°F' WHERE id = $1`, temp)
+	testutil.MustExec(t, db, `UPDATE sensors SET unit = '%' WHERE id = $1`, hum)
+
+	c, csrf := server.LoginAndFetchCSRF(t, virtualSensorTestPassword, "/settings")
+	return db, c, csrf, temp, hum
+}
+
+func createVirtualSensor(t *testing.T, c *testutil.Client, csrf string, body map[string]interface{}) types.VirtualSensor {
+	t.Helper()
+	resp := c.SessionPostJSON(t, "/virtual-sensors", csrf, body)
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var got struct {
+		VirtualSensor types.VirtualSensor `json:"virtual_sensor"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	require.NotZero(t, got.VirtualSensor.SensorID)
+	return got.VirtualSensor
+}
+
+func TestVirtualSensors_CreateDewPoint(t *testing.T) {
+	t.Parallel()
+
+	db, c, csrf, temp, hum := newVirtualSensorSession(t)
+
+	// Humidity first: the server reorders inputs to [temperature, humidity].
+	vs := createVirtualSensor(t, c, csrf, map[string]interface{}{
+		"name":     "Canopy dew point",
+		"zone_id":  1,
+		"function": "dew_point",
+		"inputs":   []int{hum, temp},
+	})
+	assert.Equal(t, []int{temp, hum}, vs.Inputs)
+	assert.Equal(t, "°F", vs.Unit, "dew point inherits the temperature unit")
+
+	var source, unit string
+	require.NoError(t, db.QueryRow(`SELECT source, unit FROM sensors WHERE id = $1`, vs.SensorID).Scan(&source, &unit))
+	assert.Equal(t, types.VirtualSource, source)
+	assert.Equal(t, "°F", unit)
+
+	resp := c.Get("/virtual-sensors")
+	defer testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list struct {
+		VirtualSensors []types.VirtualSensor `json:"virtual_sensors"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	require.Len(t, list.VirtualSensors, 1)
+	assert.Equal(t, "Canopy dew point", list.VirtualSensors[0].Name)
+}
+
+func TestVirtualSensors_CreateRejectsInvalidDefinitions(t *testing.T) {
+	t.Parallel()
+
+	_, c, csrf, temp, hum := newVirtualSensorSession(t)
+
+	cases := map[string]map[string]interface{}{
+		"unknown function":  {"name": "x", "zone_id": 1, "function": "median", "inputs": []int{temp, hum}},
+		"wrong input count": {"name": "x", "zone_id": 1, "function": "dew_point", "inputs": []int{temp}},
+		"unknown input":     {"name": "x", "zone_id": 1, "function": "mean", "inputs": []int{temp, 9999}},
+		"duplicate input":   {"name": "x", "zone_id": 1, "function": "mean", "inputs": []int{temp, temp}},
+		"bad expression":    {"name": "x", "zone_id": 1, "function": "expression", "expression": "s" + strconv.Itoa(temp) + " +"},
+		"bad conversion":    {"name": "x", "zone_id": 1, "function": "convert", "inputs": []int{temp}, "conversion": "f_to_k"},
+		"missing zone":      {"name": "x", "function": "mean", "inputs": []int{temp, hum}},
+	}
+	for name, body := range cases {
+		resp := c.SessionPostJSON(t, "/virtual-sensors", csrf, body)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+		testutil.DrainAndClose(resp)
+	}
+}
+
+func TestVirtualSensors_DeleteRemovesOutputSensor(t *testing.T) {
+	t.Parallel()
+
+	db, c, csrf, temp, _ := newVirtualSensorSession(t)
+
+	vs := createVirtualSensor(t, c, csrf, map[string]interface{}{
+		"name":       "Canopy °C",
+		"zone_id":    1,
+		"function":   "convert",
+		"inputs":     []int{temp},
+		"conversion": "f_to_c",
+	})
+	assert.Equal(t, "°C", vs.Unit)
+	testutil.MustExec(t, db, `INSERT INTO sensor_data (sensor_id, value) VALUES ($1, 21.5)`, vs.SensorID)
+
+	resp := sessionDelete(t, c, csrf, "/virtual-sensors/"+strconv.Itoa(vs.ID))
+	testutil.DrainAndClose(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var n int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM sensors WHERE id = $1`, vs.SensorID).Scan(&n))
+	assert.Zero(t, n)
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM virtual_sensors`).Scan(&n))
+	assert.Zero(t, n)
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM sensor_data WHERE sensor_id = $1`, vs.SensorID).Scan(&n))
+	assert.Zero(t, n)
+
+	resp = sessionDelete(t, c, csrf, "/virtual-sensors/"+strconv.Itoa(vs.ID))
+	testutil.DrainAndClose(resp)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -13,6 +13,7 @@ import (
 	"fmt"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	migratesqlite "github.com/golang-migrate/migrate/v4/database/sqlite"
@@ -21,6 +22,7 @@ import (
 	_ "modernc.org/sqlite"
 
 	"isley/model"
+	"isley/utils"
 )
 
 // dbSeq makes each NewTestDB call use a unique shared-cache name so that
@@ -83,6 +85,29 @@ func MustExec(t *testing.T, db *sql.DB, query string, args ...interface{}) {
 	require.NoErrorf(t, err, "MustExec: %s", query)
 }
 
+// CountRows runs a single-value query such as SELECT COUNT(*) and returns
+// the result, failing the test if it errors.
+func CountRows(t *testing.T, db *sql.DB, query string, args ...interface{}) int {
+	t.Helper()
+	var n int
+	require.NoErrorf(t, db.QueryRow(query, args...).Scan(&n), "CountRows: %s", query)
+	return n
+}
+
+// ActivityIDByName returns the id of the activity called name, such as
+// one of the seeded "Water" or "Feed" rows.
+func ActivityIDByName(t *testing.T, db *sql.DB, name string) int {
+	t.Helper()
+	var id int
+	require.NoErrorf(t, db.QueryRow(`SELECT id FROM activity WHERE name = $1`, name).Scan(&id), "ActivityIDByName: %s", name)
+	return id
+}
+
+// DaysAgo returns midday n days before today, as the DB stores it.
+func DaysAgo(n int) string {
+	return time.Now().AddDate(0, 0, -n).Format(utils.LayoutDate) + " 12:00:00"
+}
+
 func applyMigrations(db *sql.DB) error {
 	src, err := iofs.New(model.MigrationsFS, "migrations/sqlite")
 	if err != nil {
@@ -13,6 +13,7 @@ import (
 	"isley/app"
 	"isley/config"
 	"isley/handlers"
+	"isley/storage"
 )
 
 // RepoFS returns an fs.FS rooted at the repository's go.mod directory,
@@ -85,6 +86,7 @@ type serverOptions struct {
 	streamDir          string
 	logsDir            string
 	frameDir           string
+	storage            storage.Store
 	configStore        *config.Store
 	rateLimiterService *handlers.RateLimiterService
 	sensorCacheService *handlers.SensorCacheService
@@ -144,6 +146,13 @@ func WithFrameDir(dir string) ServerOption {
 	return func(o *serverOptions) { o.frameDir = dir }
 }
 
+// WithStorage overrides the engine's upload storage. Tests that exercise
+// the object-storage code paths pass an in-memory or fake-S3 Store;
+// without it uploads go to local disk under UploadDir.
+func WithStorage(s storage.Store) ServerOption {
+	return func(o *serverOptions) { o.storage = s }
+}
+
 // WithConfigStore overrides the per-engine *config.Store. Tests that
 // need a code path conditional on runtime config (e.g. ACIToken set,
 // APIIngestEnabled = 1) construct a Store, call its Set* methods, and
@@ -244,6 +253,7 @@ func NewTestServer(t *testing.T, db *sql.DB, opts ...ServerOption) *TestServer {
 		StreamDir:          options.streamDir,
 		FrameDir:           options.frameDir,
 		LogsDir:            options.logsDir,
+		Storage:            options.storage,
 		BackupService:      backupSvc,
 		ConfigStore:        configStore,
 		RateLimiterService: rateLimiterSvc,
@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+)
+
+// JPEG markers and EXIF tags read by ReadImageMetadata.
+const (
+	jpegMarkerSOI  = 0xD8
+	jpegMarkerSOS  = 0xDA
+	jpegMarkerEOI  = 0xD9
+	jpegMarkerAPP1 = 0xE1
+	jpegMarkerAPPD = 0xED
+
+	exifTagOrientation      = 0x0112
+	exifTagExifIFD          = 0x8769
+	exifTagDateTimeOriginal = 0x9003
+
+	// exifLayoutDateTime is the EXIF timestamp format. It carries no zone;
+	// the camera's wall-clock time is taken as local time.
+	exifLayoutDateTime = "2006:01:02 15:04:05"
+)
+
+// exifHeader prefixes the TIFF structure inside an APP1 EXIF segment.
+var exifHeader = []byte("Exif\x00\x00")
+
+// errNotJPEG is returned by the JPEG segment walkers for other formats.
+var errNotJPEG = errors.New("not a JPEG")
+
+// ImageMetadata is the subset of EXIF data Isley acts on.
+type ImageMetadata struct {
+	// Orientation is the EXIF orientation (1–8); 1 means upright and is
+	// also reported when the tag is absent.
+	Orientation int
+	// DateTimeOriginal is when the photo was taken, or the zero time.
+	DateTimeOriginal time.Time
+}
+
+// ReadImageMetadata extracts the orientation and capture time from a
+// JPEG's EXIF block. It is best effort: non-JPEG data or a missing or
+// malformed EXIF block yields upright orientation and a zero time.
+func ReadImageMetadata(data []byte) ImageMetadata {
+	meta := ImageMetadata{Orientation: 1}
+	_ = walkJPEGSegments(data, func(marker byte, payload []byte) bool {
+		if marker == jpegMarkerAPP1 && bytes.HasPrefix(payload, exifHeader) {
+			parseEXIF(payload[len(exifHeader):], &meta)
+			return false
+		}
+		return true
+	})
+	return meta
+}
+
+// StripJPEGMetadata returns data without its EXIF, XMP and IPTC segments,
+// which carry GPS position, camera serials and the like. Image data,
+// JFIF and ICC colour profiles are copied through unchanged, so nothing
+// is re-encoded. Non-JPEG input is returned as-is.
+func StripJPEGMetadata(data []byte) []byte {
+	var out bytes.Buffer
+	out.Grow(len(data))
+	out.Write([]byte{0xFF, jpegMarkerSOI})
+	rest := 0
+	err := walkJPEGSegments(data, func(marker byte, payload []byte) bool {
+		if marker == jpegMarkerSOS {
+			// Everything from the scan header on is image data.
+			rest = len(data) - len(payload) - 4
+			return false
+		}
+		if marker == jpegMarkerAPP1 || marker == jpegMarkerAPPD {
+			return true
+		}
+		out.Write([]byte{0xFF, marker, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)})
+		out.Write(payload)
+		return true
+	})
+	if err != nil || rest <= 0 {
+		return data
+	}
+	out.Write(data[rest:])
+	return out.Bytes()
+}
+
+// walkJPEGSegments calls fn for each marker segment of a JPEG up to and
+// including the start of scan, stopping early when fn returns false. For
+// SOS the payload runs to the end of data.
+func walkJPEGSegments(data []byte, fn func(marker byte, payload []byte) bool) error {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != jpegMarkerSOI {
+		return errNotJPEG
+	}
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return errNotJPEG
+		}
+		marker := data[pos+1]
+		if marker == 0xFF {
+			// Fill byte before a marker.
+			pos++
+			continue
+		}
+		if marker == jpegMarkerEOI {
+			return nil
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			return errNotJPEG
+		}
+		if marker == jpegMarkerSOS {
+			fn(marker, data[pos+4:])
+			return nil
+		}
+		if !fn(marker, data[pos+4:pos+2+length]) {
+			return nil
+		}
+		pos += 2 + length
+	}
+	return errNotJPEG
+}
+
+// parseEXIF reads the orientation from IFD0 and the capture time from
+// the EXIF sub-IFD of a TIFF structure.
+func parseEXIF(tiff []byte, meta *ImageMetadata) {
+	if len(tiff) < 8 {
+		return
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return
+	}
+	ifd0 := order.Uint32(tiff[4:])
+	var exifIFD uint32
+	readIFD(tiff, order, ifd0, func(tag, typ uint16, count uint32, value []byte) {
+		switch tag {
+		case exifTagOrientation:
+			if o := int(order.Uint16(value)); o >= 1 && o <= 8 {
+				meta.Orientation = o
+			}
+		case exifTagExifIFD:
+			exifIFD = order.Uint32(value)
+		}
+	})
+	if exifIFD == 0 {
+		return
+	}
+	readIFD(tiff, order, exifIFD, func(tag, typ uint16, count uint32, value []byte) {
+		if tag != exifTagDateTimeOriginal || count < uint32(len(exifLayoutDateTime)) {
+			return
+		}
+		off := order.Uint32(value)
+		if uint64(off)+uint64(len(exifLayoutDateTime)) > uint64(len(tiff)) {
+			return
+		}
+		raw := strings.TrimSpace(string(tiff[off : off+uint32(len(exifLayoutDateTime))]))
+		if t, err := time.ParseInLocation(exifLayoutDateTime, raw, time.Local); err == nil {
+			meta.DateTimeOriginal = t
+		}
+	})
+}
+
+// readIFD calls fn with each 12-byte entry of the IFD at offset. value is
+// the entry's 4-byte value-or-offset field.
+func readIFD(tiff []byte, order binary.ByteOrder, offset uint32, fn func(tag, typ uint16, count uint32, value []byte)) {
+	if uint64(offset)+2 > uint64(len(tiff)) {
+		return
+	}
+	n := int(order.Uint16(tiff[offset:]))
+	entries := tiff[offset+2:]
+	for i := 0; i < n && (i+1)*12 <= len(entries); i++ {
+		e := entries[i*12 : (i+1)*12]
+		fn(order.Uint16(e), order.Uint16(e[2:]), order.Uint32(e[4:]), e[8:12])
+	}
+}
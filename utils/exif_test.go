@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exifJPEG returns a w×h JPEG whose left half is red and right half blue,
+// carrying an EXIF block with the given orientation, a DateTimeOriginal
+// of taken (EXIF layout) and a GPS IFD holding a latitude reference.
+func exifJPEG(t *testing.T, w, h, orientation int, taken string) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var enc bytes.Buffer
+	require.NoError(t, jpeg.Encode(&enc, img, &jpeg.Options{Quality: 95}))
+
+	// Little-endian TIFF: IFD0 (orientation, EXIF and GPS pointers) at 8,
+	// the EXIF IFD at 50, the GPS IFD at 68 and the date string at 86.
+	le := binary.LittleEndian
+	tiff := make([]byte, 86)
+	copy(tiff, "II")
+	le.PutUint16(tiff[2:], 42)
+	le.PutUint32(tiff[4:], 8)
+	entry := func(at int, tag, typ uint16, count, value uint32) {
+		le.PutUint16(tiff[at:], tag)
+		le.PutUint16(tiff[at+2:], typ)
+		le.PutUint32(tiff[at+4:], count)
+		le.PutUint32(tiff[at+8:], value)
+	}
+	le.PutUint16(tiff[8:], 3)
+	entry(10, exifTagOrientation, 3, 1, uint32(orientation))
+	entry(22, exifTagExifIFD, 4, 1, 50)
+	entry(34, 0x8825, 4, 1, 68)
+	le.PutUint16(tiff[50:], 1)
+	entry(52, exifTagDateTimeOriginal, 2, 20, 86)
+	le.PutUint16(tiff[68:], 1)
+	entry(70, 0x0001, 2, 2, uint32('N'))
+	tiff = append(tiff, append([]byte(taken), 0)...)
+
+	app1 := append(append([]byte{}, exifHeader...), tiff...)
+	out := []byte{0xFF, jpegMarkerSOI, 0xFF, jpegMarkerAPP1, byte((len(app1) + 2) >> 8), byte(len(app1) + 2)}
+	out = append(out, app1...)
+	return append(out, enc.Bytes()[2:]...)
+}
+
+// ---------------------------------------------------------------------------
+// ReadImageMetadata
+// ---------------------------------------------------------------------------
+
+func TestReadImageMetadata(t *testing.T) {
+	t.Parallel()
+	meta := ReadImageMetadata(exifJPEG(t, 8, 4, 6, "2026:03:14 09:26:53"))
+	assert.Equal(t, 6, meta.Orientation)
+	assert.Equal(t, time.Date(2026, 3, 14, 9, 26, 53, 0, time.Local), meta.DateTimeOriginal)
+}
+
+func TestReadImageMetadata_WithoutEXIF(t *testing.T) {
+	t.Parallel()
+	for name, data := range map[string][]byte{
+		"png":       pngBytes(t),
+		"truncated": {0xFF, 0xD8, 0xFF},
+		"garbage":   []byte("not an image"),
+	} {
+		meta := ReadImageMetadata(data)
+		assert.Equal(t, 1, meta.Orientation, name)
+		assert.True(t, meta.DateTimeOriginal.IsZero(), name)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// StripJPEGMetadata
+// ---------------------------------------------------------------------------
+
+func TestStripJPEGMetadata(t *testing.T) {
+	t.Parallel()
+	data := exifJPEG(t, 8, 4, 1, "2026:03:14 09:26:53")
+	stripped := StripJPEGMetadata(data)
+
+	assert.NotContains(t, string(stripped), "Exif\x00\x00")
+	assert.Equal(t, ImageMetadata{Orientation: 1}, ReadImageMetadata(stripped))
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(stripped))
+	require.NoError(t, err, "image data must survive untouched")
+	assert.Equal(t, 8, cfg.Width)
+
+	png := pngBytes(t)
+	assert.Equal(t, png, StripJPEGMetadata(png), "other formats pass through")
+}
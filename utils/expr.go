@@ -0,0 +1,388 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MaxExpressionLength caps the source length of a virtual-sensor expression.
+const MaxExpressionLength = 500
+
+// maxExpressionDepth bounds parser recursion so a pathological input such as
+// "((((...))))" cannot exhaust the stack.
+const maxExpressionDepth = 32
+
+// exprFuncs lists the functions an expression may call, keyed by name with
+// the accepted argument count (-1 = one or more).
+var exprFuncs = map[string]int{
+	"abs":   1,
+	"sqrt":  1,
+	"exp":   1,
+	"ln":    1,
+	"log10": 1,
+	"round": 1,
+	"min":   -1,
+	"max":   -1,
+}
+
+// Expression is a compiled arithmetic expression over sensor variables.
+//
+// The grammar is deliberately tiny — numbers, variables, + - * / ^, unary
+// minus, parentheses and the functions in exprFuncs — and is evaluated by a
+// tree walk, never by handing text to a scripting engine. Variables are
+// written s<ID> (e.g. "s12") and resolve to the latest reading of that
+// sensor.
+type Expression struct {
+	src  string
+	root exprNode
+	vars []string
+}
+
+type exprNode interface {
+	eval(vars map[string]float64) (float64, error)
+}
+
+type exprNum float64
+
+type exprVar string
+
+type exprUnary struct {
+	x exprNode
+}
+
+type exprBinary struct {
+	op   byte
+	l, r exprNode
+}
+
+type exprCall struct {
+	name string
+	args []exprNode
+}
+
+func (n exprNum) eval(map[string]float64) (float64, error) { return float64(n), nil }
+
+func (n exprVar) eval(vars map[string]float64) (float64, error) {
+	v, ok := vars[string(n)]
+	if !ok {
+		return 0, fmt.Errorf("no value for %s", string(n))
+	}
+	return v, nil
+}
+
+func (n exprUnary) eval(vars map[string]float64) (float64, error) {
+	v, err := n.x.eval(vars)
+	return -v, err
+}
+
+func (n exprBinary) eval(vars map[string]float64) (float64, error) {
+	l, err := n.l.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	r, err := n.r.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case '+':
+		return l + r, nil
+	case '-':
+		return l - r, nil
+	case '*':
+		return l * r, nil
+	case '/':
+		if r == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return l / r, nil
+	case '^':
+		return math.Pow(l, r), nil
+	}
+	return 0, fmt.Errorf("unknown operator %q", n.op)
+}
+
+func (n exprCall) eval(vars map[string]float64) (float64, error) {
+	args := make([]float64, len(n.args))
+	for i, a := range n.args {
+		v, err := a.eval(vars)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = v
+	}
+	switch n.name {
+	case "abs":
+		return math.Abs(args[0]), nil
+	case "sqrt":
+		return math.Sqrt(args[0]), nil
+	case "exp":
+		return math.Exp(args[0]), nil
+	case "ln":
+		return math.Log(args[0]), nil
+	case "log10":
+		return math.Log10(args[0]), nil
+	case "round":
+		return math.Round(args[0]), nil
+	case "min":
+		m := args[0]
+		for _, v := range args[1:] {
+			m = math.Min(m, v)
+		}
+		return m, nil
+	case "max":
+		m := args[0]
+		for _, v := range args[1:] {
+			m = math.Max(m, v)
+		}
+		return m, nil
+	}
+	return 0, fmt.Errorf("unknown function %s", n.name)
+}
+
+// CompileExpression parses src into an Expression. It rejects anything
+// outside the grammar, so a compiled expression can be evaluated without
+// further checks.
+func CompileExpression(src string) (*Expression, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, fmt.Errorf("expression is required")
+	}
+	if len(src) > MaxExpressionLength {
+		return nil, fmt.Errorf("expression exceeds maximum length of %d characters", MaxExpressionLength)
+	}
+	p := &exprParser{src: src, seen: map[string]bool{}}
+	root, err := p.parseSum(0)
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.src[p.pos], p.pos+1)
+	}
+	vars := make([]string, 0, len(p.seen))
+	for v := range p.seen {
+		vars = append(vars, v)
+	}
+	sort.Strings(vars)
+	return &Expression{src: src, root: root, vars: vars}, nil
+}
+
+// String returns the source text the expression was compiled from.
+func (e *Expression) String() string { return e.src }
+
+// Vars returns the variable names referenced by the expression, sorted.
+func (e *Expression) Vars() []string { return e.vars }
+
+// SensorIDs returns the sensor IDs referenced through s<ID> variables.
+func (e *Expression) SensorIDs() []int {
+	ids := make([]int, 0, len(e.vars))
+	for _, v := range e.vars {
+		if id, err := strconv.Atoi(v[1:]); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// Eval evaluates the expression against vars. A missing variable, a
+// division by zero or a non-finite result is reported as an error so the
+// caller can skip the reading instead of storing NaN.
+func (e *Expression) Eval(vars map[string]float64) (float64, error) {
+	v, err := e.root.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("expression result is not a finite number")
+	}
+	return v, nil
+}
+
+type exprParser struct {
+	src  string
+	pos  int
+	seen map[string]bool
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *exprParser) peek() byte {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// parseSum handles + and - (lowest precedence, left associative).
+func (p *exprParser) parseSum(depth int) (exprNode, error) {
+	if depth > maxExpressionDepth {
+		return nil, fmt.Errorf("expression is nested too deeply")
+	}
+	left, err := p.parseProduct(depth)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseProduct(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = exprBinary{op: op, l: left, r: right}
+	}
+}
+
+// parseProduct handles * and /.
+func (p *exprParser) parseProduct(depth int) (exprNode, error) {
+	left, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = exprBinary{op: op, l: left, r: right}
+	}
+}
+
+// parseUnary handles a leading minus or plus.
+func (p *exprParser) parseUnary(depth int) (exprNode, error) {
+	if depth > maxExpressionDepth {
+		return nil, fmt.Errorf("expression is nested too deeply")
+	}
+	switch p.peek() {
+	case '-':
+		p.pos++
+		x, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return exprUnary{x: x}, nil
+	case '+':
+		p.pos++
+		return p.parseUnary(depth + 1)
+	}
+	return p.parsePower(depth)
+}
+
+// parsePower handles ^, which is right associative and binds tighter than
+// unary minus on its left operand: -2^2 = -4.
+func (p *exprParser) parsePower(depth int) (exprNode, error) {
+	base, err := p.parseAtom(depth)
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != '^' {
+		return base, nil
+	}
+	p.pos++
+	exp, err := p.parseUnary(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+	return exprBinary{op: '^', l: base, r: exp}, nil
+}
+
+func (p *exprParser) parseAtom(depth int) (exprNode, error) {
+	ch := p.peek()
+	switch {
+	case ch == 0:
+		return nil, fmt.Errorf("unexpected end of expression")
+	case ch == '(':
+		p.pos++
+		x, err := p.parseSum(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return x, nil
+	case ch == '.' || (ch >= '0' && ch <= '9'):
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] == '.' || (p.src[p.pos] >= '0' && p.src[p.pos] <= '9')) {
+			p.pos++
+		}
+		v, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", p.src[start:p.pos])
+		}
+		return exprNum(v), nil
+	case unicode.IsLetter(rune(ch)):
+		start := p.pos
+		for p.pos < len(p.src) && (unicode.IsLetter(rune(p.src[p.pos])) || unicode.IsDigit(rune(p.src[p.pos]))) {
+			p.pos++
+		}
+		name := strings.ToLower(p.src[start:p.pos])
+		if p.peek() == '(' {
+			return p.parseCall(name, depth)
+		}
+		if !isSensorVar(name) {
+			return nil, fmt.Errorf("unknown name %q (sensor variables are written s<ID>)", name)
+		}
+		p.seen[name] = true
+		return exprVar(name), nil
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", ch, p.pos+1)
+}
+
+func (p *exprParser) parseCall(name string, depth int) (exprNode, error) {
+	arity, ok := exprFuncs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q", name)
+	}
+	p.pos++ // consume '('
+	var args []exprNode
+	if p.peek() != ')' {
+		for {
+			arg, err := p.parseSum(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.peek() != ',' {
+				break
+			}
+			p.pos++
+		}
+	}
+	if p.peek() != ')' {
+		return nil, fmt.Errorf("missing closing parenthesis after %s arguments", name)
+	}
+	p.pos++
+	if (arity == -1 && len(args) == 0) || (arity > 0 && len(args) != arity) {
+		return nil, fmt.Errorf("wrong number of arguments to %s", name)
+	}
+	return exprCall{name: name, args: args}, nil
+}
+
+// isSensorVar reports whether name has the s<ID> form.
+func isSensorVar(name string) bool {
+	if len(name) < 2 || name[0] != 's' {
+		return false
+	}
+	id, err := strconv.Atoi(name[1:])
+	return err == nil && id > 0
+}
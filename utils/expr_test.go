@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileExpression_Evaluates(t *testing.T) {
+	t.Parallel()
+
+	vars := map[string]float64{"s1": 20, "s2": 60, "s10": 4}
+	cases := []struct {
+		src  string
+		want float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"-2^2", -4},
+		{"2^3^2", 512},
+		{"(s1 + s2) / 2", 40},
+		{"S1 * 9/5 + 32", 68},
+		{"max(s1, s2, s10) - min(s1, s10)", 56},
+		{"sqrt(s10) + abs(-1) + round(2.6)", 6},
+		{".5 * s10", 2},
+	}
+	for _, tc := range cases {
+		t.Run(tc.src, func(t *testing.T) {
+			t.Parallel()
+			expr, err := CompileExpression(tc.src)
+			require.NoError(t, err)
+			got, err := expr.Eval(vars)
+			require.NoError(t, err)
+			assert.InDelta(t, tc.want, got, 1e-9)
+		})
+	}
+}
+
+func TestCompileExpression_Rejects(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"empty":            "  ",
+		"unknown name":     "temp * 2",
+		"unknown function": "os(1)",
+		"bad arity":        "sqrt(1, 2)",
+		"no args":          "max()",
+		"unbalanced":       "(1 + 2",
+		"trailing":         "1 + 2)",
+		"dangling op":      "1 +",
+		"bad number":       "1.2.3",
+		"zero sensor":      "s0 + 1",
+		"too deep":         strings.Repeat("(", 40) + "1" + strings.Repeat(")", 40),
+		"too long":         strings.Repeat("1+", MaxExpressionLength) + "1",
+	}
+	for name, src := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := CompileExpression(src)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestExpression_SensorIDs(t *testing.T) {
+	t.Parallel()
+
+	expr, err := CompileExpression("s12 - s3 + s12 * 2")
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 12}, expr.SensorIDs())
+}
+
+func TestExpression_EvalErrors(t *testing.T) {
+	t.Parallel()
+
+	expr, err := CompileExpression("s1 / s2")
+	require.NoError(t, err)
+
+	_, err = expr.Eval(map[string]float64{"s1": 1})
+	assert.Error(t, err, "missing variable")
+
+	_, err = expr.Eval(map[string]float64{"s1": 1, "s2": 0})
+	assert.Error(t, err, "division by zero")
+
+	neg, err := CompileExpression("sqrt(s1)")
+	require.NoError(t, err)
+	_, err = neg.Eval(map[string]float64{"s1": -1})
+	assert.Error(t, err, "NaN results are rejected")
+}
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// frameArchiveLayout names archived frames after their UTC capture time.
+// The layout sorts lexically in capture order, so directory listings
+// double as a timeline.
+const frameArchiveLayout = "20060102T150405Z"
+
+// ArchivedFrame is one timestamped frame in a stream's archive.
+type ArchivedFrame struct {
+	Path       string
+	CapturedAt time.Time
+	Size       int64
+}
+
+// FrameArchiveDir returns the per-stream archive directory under the
+// grabber's frame root.
+func FrameArchiveDir(frameDir string, streamID uint) string {
+	return filepath.Join(frameDir, "archive", fmt.Sprintf("stream_%d", streamID))
+}
+
+// ArchiveFrame copies the freshly grabbed frame at src into dir, named
+// by capturedAt.
+func ArchiveFrame(src, dir string, capturedAt time.Time) (string, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	dst := filepath.Join(dir, capturedAt.UTC().Format(frameArchiveLayout)+".jpg")
+	if err := CopyFile(src, dst); err != nil {
+		return "", err
+	}
+	return dst, nil
+}
+
+// CopyFile copies src to dst, removing a partial dst on failure. Frames
+// are copied rather than hard-linked because the grabber rewrites the
+// latest frame in place.
+func CopyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+// ListArchivedFrames returns the frames in dir captured within
+// [start, end], oldest first. A zero start or end leaves that side
+// unbounded. Files that don't follow the archive naming are ignored.
+func ListArchivedFrames(dir string, start, end time.Time) ([]ArchivedFrame, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var frames []ArchivedFrame
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".jpg") {
+			continue
+		}
+		ts, err := time.Parse(frameArchiveLayout, strings.TrimSuffix(e.Name(), ".jpg"))
+		if err != nil {
+			continue
+		}
+		if (!start.IsZero() && ts.Before(start)) || (!end.IsZero() && ts.After(end)) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		frames = append(frames, ArchivedFrame{Path: filepath.Join(dir, e.Name()), CapturedAt: ts, Size: info.Size()})
+	}
+	sort.Slice(frames, func(i, j int) bool { return frames[i].CapturedAt.Before(frames[j].CapturedAt) })
+	return frames, nil
+}
+
+// PruneFrameArchive deletes the oldest frames in dir until at most
+// maxFrames remain and they total no more than maxBytes. A limit of
+// zero disables that budget. Returns the number of frames removed.
+func PruneFrameArchive(dir string, maxFrames int, maxBytes int64) (int, error) {
+	if maxFrames <= 0 && maxBytes <= 0 {
+		return 0, nil
+	}
+	frames, err := ListArchivedFrames(dir, time.Time{}, time.Time{})
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, f := range frames {
+		total += f.Size
+	}
+	removed := 0
+	for _, f := range frames {
+		overCount := maxFrames > 0 && len(frames)-removed > maxFrames
+		overSize := maxBytes > 0 && total > maxBytes
+		if !overCount && !overSize {
+			break
+		}
+		if err := os.Remove(f.Path); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		total -= f.Size
+		removed++
+	}
+	return removed, nil
+}
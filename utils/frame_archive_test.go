@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedArchive archives n frames of size bytes each, one minute apart
+// starting at base, and returns the archive directory.
+func seedArchive(t *testing.T, n, size int, base time.Time) string {
+	t.Helper()
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "latest.jpg")
+	require.NoError(t, os.WriteFile(src, make([]byte, size), 0o644))
+	dir := FrameArchiveDir(tmp, 7)
+	for i := 0; i < n; i++ {
+		_, err := ArchiveFrame(src, dir, base.Add(time.Duration(i)*time.Minute))
+		require.NoError(t, err)
+	}
+	return dir
+}
+
+// ---------------------------------------------------------------------------
+// ArchiveFrame / ListArchivedFrames
+// ---------------------------------------------------------------------------
+
+func TestArchiveFrame_NamesByUTCTime(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	dir := seedArchive(t, 1, 10, base)
+
+	frames, err := ListArchivedFrames(dir, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, frames, 1)
+	assert.Equal(t, "20260301T110000Z.jpg", filepath.Base(frames[0].Path))
+	assert.True(t, frames[0].CapturedAt.Equal(base))
+	assert.EqualValues(t, 10, frames[0].Size)
+}
+
+func TestListArchivedFrames_FiltersRangeAndForeignFiles(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	dir := seedArchive(t, 5, 1, base)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.jpg"), nil, 0o644))
+
+	frames, err := ListArchivedFrames(dir, base.Add(time.Minute), base.Add(3*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, frames, 3)
+	assert.True(t, frames[0].CapturedAt.Equal(base.Add(time.Minute)))
+	assert.True(t, frames[2].CapturedAt.Equal(base.Add(3*time.Minute)))
+}
+
+func TestListArchivedFrames_MissingDirIsEmpty(t *testing.T) {
+	t.Parallel()
+	frames, err := ListArchivedFrames(filepath.Join(t.TempDir(), "nope"), time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, frames)
+}
+
+// ---------------------------------------------------------------------------
+// PruneFrameArchive
+// ---------------------------------------------------------------------------
+
+func TestPruneFrameArchive_ByCountKeepsNewest(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	dir := seedArchive(t, 5, 1, base)
+
+	removed, err := PruneFrameArchive(dir, 2, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, removed)
+
+	frames, err := ListArchivedFrames(dir, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, frames, 2)
+	assert.True(t, frames[0].CapturedAt.Equal(base.Add(3*time.Minute)))
+}
+
+func TestPruneFrameArchive_BySize(t *testing.T) {
+	t.Parallel()
+	dir := seedArchive(t, 4, 100, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	removed, err := PruneFrameArchive(dir, 0, 250)
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+}
+
+func TestPruneFrameArchive_NoBudgetIsNoOp(t *testing.T) {
+	t.Parallel()
+	dir := seedArchive(t, 3, 1, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	removed, err := PruneFrameArchive(dir, 0, 0)
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+}
+
+// ---------------------------------------------------------------------------
+// Timelapse rendering
+// ---------------------------------------------------------------------------
+
+func TestTimelapseFFmpegArgs(t *testing.T) {
+	t.Parallel()
+	mp4 := strings.Join(timelapseFFmpegArgs("/stage", ".jpg", "/out.mp4", 12, TimelapseFormatMP4), " ")
+	assert.Contains(t, mp4, "-framerate 12")
+	assert.Contains(t, mp4, filepath.Join("/stage", "frame_%06d.jpg"))
+	assert.Contains(t, mp4, "libx264")
+	assert.True(t, strings.HasSuffix(mp4, "/out.mp4"))
+
+	webm := strings.Join(timelapseFFmpegArgs("/stage", ".png", "/out.webm", 24, TimelapseFormatWebM), " ")
+	assert.Contains(t, webm, "libvpx-vp9")
+	assert.NotContains(t, webm, "libx264")
+}
+
+func TestRenderTimelapse_RejectsUnknownFormat(t *testing.T) {
+	t.Parallel()
+	err := RenderTimelapse(context.Background(), t.TempDir(), ".jpg", "out.gif", 10, "gif")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported")
+}
+
+func TestRenderTimelapse_ReportsMissingFFmpeg(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err == nil {
+		t.Skip("ffmpeg is installed")
+	}
+	t.Parallel()
+	err := RenderTimelapse(context.Background(), t.TempDir(), ".jpg", "out.mp4", 10, TimelapseFormatMP4)
+	assert.ErrorIs(t, err, ErrFFmpegNotFound)
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+// CalendarEvent is one all-day event in an iCalendar feed.
+type CalendarEvent struct {
+	// UID must stay the same across fetches so calendar apps update the
+	// event instead of adding a copy.
+	UID         string
+	Date        time.Time
+	Summary     string
+	Description string
+	Category    string
+	URL         string
+}
+
+const (
+	icalDate     = "20060102"
+	icalDateTime = "20060102T150405Z"
+	// icalLineOctets is the longest content line RFC 5545 allows before
+	// it must be folded.
+	icalLineOctets = 75
+)
+
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// icalLine writes one content line, folding it at icalLineOctets without
+// splitting a UTF-8 sequence. The space that starts each continuation
+// counts toward its length.
+func icalLine(w *bufio.Writer, line string) {
+	limit := icalLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		limit = icalLineOctets - 1
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+// WriteCalendar writes events as an RFC 5545 VCALENDAR named name.
+// stamp is recorded as each event's DTSTAMP.
+func WriteCalendar(out io.Writer, name string, events []CalendarEvent, stamp time.Time) error {
+	w := bufio.NewWriter(out)
+	icalLine(w, "BEGIN:VCALENDAR")
+	icalLine(w, "VERSION:2.0")
+	icalLine(w, "PRODID:-//Isley//Grow Calendar//EN")
+	icalLine(w, "CALSCALE:GREGORIAN")
+	icalLine(w, "METHOD:PUBLISH")
+	icalLine(w, "X-WR-CALNAME:"+icalEscaper.Replace(name))
+	dtstamp := stamp.UTC().Format(icalDateTime)
+	for _, e := range events {
+		icalLine(w, "BEGIN:VEVENT")
+		icalLine(w, "UID:"+e.UID)
+		icalLine(w, "DTSTAMP:"+dtstamp)
+		icalLine(w, "DTSTART;VALUE=DATE:"+e.Date.Format(icalDate))
+		icalLine(w, "DTEND;VALUE=DATE:"+e.Date.AddDate(0, 0, 1).Format(icalDate))
+		icalLine(w, "SUMMARY:"+icalEscaper.Replace(e.Summary))
+		if e.Description != "" {
+			icalLine(w, "DESCRIPTION:"+icalEscaper.Replace(e.Description))
+		}
+		if e.Category != "" {
+			icalLine(w, "CATEGORIES:"+icalEscaper.Replace(e.Category))
+		}
+		if e.URL != "" {
+			icalLine(w, "URL:"+e.URL)
+		}
+		icalLine(w, "TRANSP:TRANSPARENT")
+		icalLine(w, "END:VEVENT")
+	}
+	icalLine(w, "END:VCALENDAR")
+	return w.Flush()
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCalendar(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("Blüte ", 30)
+	events := []CalendarEvent{
+		{
+			UID:         "status-1@isley",
+			Date:        time.Date(2026, 12, 31, 12, 0, 0, 0, time.Local),
+			Summary:     "Kush: Flip, then; wait",
+			Description: "line one\nline two \\ done",
+			Category:    "Milestone",
+			URL:         "http://isley.local/plant/1",
+		},
+		{UID: "status-2@isley", Date: time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local), Summary: long},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, WriteCalendar(&buf, "Isley", events, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)))
+	out := buf.String()
+
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), icalLineOctets, "line %q must be folded", line)
+	}
+
+	unfolded := strings.ReplaceAll(out, "\r\n ", "")
+	assert.Contains(t, unfolded, "DTSTAMP:20260102T030405Z\r\n")
+	assert.Contains(t, unfolded, "DTSTART;VALUE=DATE:20261231\r\nDTEND;VALUE=DATE:20270101\r\n", "all-day events end the next day")
+	assert.Contains(t, unfolded, `SUMMARY:Kush: Flip\, then\; wait`+"\r\n")
+	assert.Contains(t, unfolded, `DESCRIPTION:line one\nline two \\ done`+"\r\n")
+	assert.Contains(t, unfolded, "CATEGORIES:Milestone\r\n")
+	assert.Contains(t, unfolded, "URL:http://isley.local/plant/1\r\n")
+	assert.Contains(t, unfolded, "SUMMARY:"+long+"\r\n", "folding keeps multi-byte characters whole")
+	assert.Equal(t, 1, strings.Count(out, "DESCRIPTION:"), "empty properties are left out")
+}
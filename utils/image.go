@@ -2,6 +2,7 @@ package utils
 
 import (
 	"bytes"
+	"context"
 	"embed"
 	"fmt"
 	"image"
@@ -51,8 +52,10 @@ const (
 )
 
 // Shared HTTP clients for image operations — reuse enables connection pooling.
+// Stream captures through imageClient are bounded per request instead, by
+// the stream's timeout or httpTimeoutLong; see getWithAuth.
 var (
-	imageClient     = &http.Client{Timeout: httpTimeoutLong}
+	imageClient     = &http.Client{}
 	thumbnailClient = &http.Client{Timeout: httpTimeoutMedium}
 )
 
@@ -478,9 +481,20 @@ func ListLogosHandler(c *gin.Context) {
 // to prevent memory exhaustion from malicious or malformed responses.
 const maxFrameSize = 50 * 1024 * 1024 // 50 MB
 
+// GrabWebcamImage captures a single frame from rawURL into outputPath
+// using the default capture options and no credentials.
 func GrabWebcamImage(rawURL string, outputPath string) error {
+	return GrabStreamImage(rawURL, outputPath, GrabOptions{})
+}
+
+// GrabStreamImage captures a single frame from rawURL into outputPath.
+// HTTP(S) snapshot, MJPEG and HLS URLs are fetched natively; rtsp://,
+// rtsps:// and rtmp:// streams are decoded by ffmpeg. Credentials in
+// opts are sent as HTTP basic auth or embedded in the URL handed to
+// ffmpeg, and are never logged.
+func GrabStreamImage(rawURL string, outputPath string, opts GrabOptions) error {
 	logger.Log.WithFields(logrus.Fields{
-		"url":        rawURL,
+		"url":        RedactStreamURL(rawURL),
 		"outputPath": outputPath,
 	}).Info("Capturing image from webcam")
 
@@ -493,9 +507,9 @@ func GrabWebcamImage(rawURL string, outputPath string) error {
 
 	switch scheme {
 	case "http", "https":
-		return grabHTTPFrame(rawURL, outputPath)
-	case "rtsp", "rtmp":
-		return fmt.Errorf("protocol %s requires ffmpeg which is not installed; use an HTTP/HTTPS snapshot URL instead", scheme)
+		return grabHTTPFrame(rawURL, outputPath, opts)
+	case "rtsp", "rtsps", "rtmp":
+		return grabFFmpegFrame(parsedURL, outputPath, opts)
 	default:
 		return fmt.Errorf("disallowed URL scheme: %s", scheme)
 	}
@@ -515,14 +529,17 @@ var hlsThumbnailPaths = []string{
 //   - Direct image URLs (snapshot endpoints that return JPEG/PNG)
 //   - MJPEG streams (multipart/x-mixed-replace) where the first frame is extracted
 //   - HLS streams (.m3u8) where we fall back to a server thumbnail endpoint
-func grabHTTPFrame(rawURL string, outputPath string) error {
+func grabHTTPFrame(rawURL string, outputPath string, opts GrabOptions) error {
 	// Detect HLS playlist URLs before making any request — these will never
 	// return an image directly, so jump straight to thumbnail fallback.
 	if isHLSURL(rawURL) {
-		return grabHLSThumbnail(rawURL, outputPath)
+		return grabHLSThumbnail(rawURL, outputPath, opts)
 	}
 
-	resp, err := imageClient.Get(rawURL)
+	if opts.Timeout <= 0 {
+		opts.Timeout = httpTimeoutLong
+	}
+	resp, err := getWithAuth(imageClient, rawURL, opts)
 	if err != nil {
 		return fmt.Errorf("failed to fetch stream: %w", err)
 	}
@@ -542,7 +559,7 @@ func grabHTTPFrame(rawURL string, outputPath string) error {
 	// If the server returned an HLS playlist content type, try thumbnails
 	if strings.Contains(contentType, "mpegurl") || strings.Contains(contentType, "apple.mpegurl") {
 		resp.Body.Close()
-		return grabHLSThumbnail(rawURL, outputPath)
+		return grabHLSThumbnail(rawURL, outputPath, opts)
 	}
 
 	// Treat everything else as a direct image (snapshot URL)
@@ -565,6 +582,43 @@ func grabHTTPFrame(rawURL string, outputPath string) error {
 	return nil
 }
 
+// getWithAuth issues a GET with HTTP basic auth when opts carries a
+// username. A non-zero opts.Timeout bounds the request, body included, on
+// top of the client's own timeout, so it can't stretch a thumbnail probe.
+func getWithAuth(client *http.Client, rawURL string, opts GrabOptions) (*http.Response, error) {
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if opts.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if opts.Username != "" {
+		req.SetBasicAuth(opts.Username, opts.Password)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = cancelOnClose{resp.Body, cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases a request's context once its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
 // isHLSURL returns true if the URL path looks like an HLS playlist.
 func isHLSURL(rawURL string) bool {
 	lower := strings.ToLower(rawURL)
@@ -574,7 +628,7 @@ func isHLSURL(rawURL string) bool {
 // grabHLSThumbnail attempts to fetch a thumbnail image from the same origin
 // as an HLS stream URL. Streaming servers like Owncast serve a preview
 // thumbnail at well-known paths alongside the HLS endpoint.
-func grabHLSThumbnail(hlsURL string, outputPath string) error {
+func grabHLSThumbnail(hlsURL string, outputPath string, opts GrabOptions) error {
 	parsed, err := url.Parse(hlsURL)
 	if err != nil {
 		return fmt.Errorf("invalid HLS URL: %w", err)
@@ -586,7 +640,7 @@ func grabHLSThumbnail(hlsURL string, outputPath string) error {
 		thumbURL := baseURL + thumbPath
 		logger.Log.WithField("url", thumbURL).Debug("Trying HLS thumbnail fallback")
 
-		resp, err := thumbnailClient.Get(thumbURL)
+		resp, err := getWithAuth(thumbnailClient, thumbURL, opts)
 		if err != nil {
 			continue
 		}
@@ -26,6 +26,7 @@ import (
 	"strings"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -247,10 +248,10 @@ func TestGrabWebcamImage_RejectsInvalidURL(t *testing.T) {
 	silenceImageLogger()
 
 	cases := []string{
-		"not://a-real-url",     // unsupported scheme
-		"://no-scheme",         // url.Parse error
-		"http://",              // no host
-		"rtsp://example.com/x", // disallowed even though syntactically valid
+		"not://a-real-url",    // unsupported scheme
+		"://no-scheme",        // url.Parse error
+		"http://",             // no host
+		"ftp://example.com/x", // disallowed even though syntactically valid
 	}
 	for _, raw := range cases {
 		t.Run(raw, func(t *testing.T) {
@@ -285,6 +286,59 @@ func TestGrabWebcamImage_DirectSnapshotHappyPath(t *testing.T) {
 	assert.Equal(t, payload, got, "the saved bytes must match the server response")
 }
 
+// TestGrabStreamImage_HTTPBasicAuth verifies that stream credentials are
+// sent as HTTP basic auth to snapshot endpoints.
+func TestGrabStreamImage_HTTPBasicAuth(t *testing.T) {
+	t.Parallel()
+
+	silenceImageLogger()
+
+	payload := pngBytes(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(payload)
+	}))
+	t.Cleanup(srv.Close)
+
+	out := filepath.Join(t.TempDir(), "snap.png")
+	err := GrabWebcamImage(srv.URL+"/snapshot.png", out)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HTTP 401")
+
+	require.NoError(t, GrabStreamImage(srv.URL+"/snapshot.png", out, GrabOptions{Username: "admin", Password: "secret"}))
+	got, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, payload, got)
+}
+
+// TestGrabStreamImage_HTTPTimeout verifies that a stream's timeout bounds
+// a snapshot request without touching the shared clients.
+func TestGrabStreamImage_HTTPTimeout(t *testing.T) {
+	t.Parallel()
+
+	silenceImageLogger()
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	start := time.Now()
+	err := GrabStreamImage(srv.URL+"/snapshot.png", filepath.Join(t.TempDir(), "snap.png"), GrabOptions{Timeout: 200 * time.Millisecond})
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second, "the stream timeout cuts the request short")
+	assert.Equal(t, httpTimeoutMedium, thumbnailClient.Timeout, "the thumbnail probe keeps its own timeout")
+}
+
 // TestGrabWebcamImage_NonImageResponseRejected verifies that even with a
 // 200 response, a non-image body is rejected before being persisted.
 func TestGrabWebcamImage_NonImageResponseRejected(t *testing.T) {
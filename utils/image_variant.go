@@ -0,0 +1,276 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Responsive image variants generated for every plant photo. Each is a
+// JPEG scaled to fit within its bound on the longest edge; smaller
+// originals are re-encoded at their own size.
+const (
+	ImageVariantThumb  = "thumb"
+	ImageVariantMedium = "medium"
+
+	// ImageVariantDirName is the directory, beside the originals, that
+	// holds generated variants. It can always be rebuilt, so backups may
+	// leave it out.
+	ImageVariantDirName = "variants"
+
+	imageVariantQuality = 82
+	// imageRewriteQuality is used when an original has to be re-encoded
+	// to bake in its orientation.
+	imageRewriteQuality = 92
+)
+
+// imageVariantBounds maps each variant to its longest-edge size in pixels.
+var imageVariantBounds = map[string]int{
+	ImageVariantThumb:  400,
+	ImageVariantMedium: 1280,
+}
+
+// IsImageVariant reports whether name is a known variant size.
+func IsImageVariant(name string) bool {
+	_, ok := imageVariantBounds[name]
+	return ok
+}
+
+// ImageVariantNames lists every variant size, in a stable order.
+func ImageVariantNames() []string {
+	return []string{ImageVariantThumb, ImageVariantMedium}
+}
+
+// ImageVariantPath returns where the given variant of the image at
+// original is stored: variants/<name>_<variant>.jpg beside the original.
+func ImageVariantPath(original, variant string) string {
+	base := strings.TrimSuffix(filepath.Base(original), filepath.Ext(original))
+	return filepath.Join(filepath.Dir(original), ImageVariantDirName, base+"_"+variant+".jpg")
+}
+
+// RemoveImageVariants deletes every generated variant of original.
+func RemoveImageVariants(original string) {
+	for variant := range imageVariantBounds {
+		os.Remove(ImageVariantPath(original, variant))
+	}
+}
+
+// EnsureImageVariant returns the path of the requested variant of
+// original, generating it first when it is missing or older than the
+// original. Existing images get their variants this way on first view.
+func EnsureImageVariant(original, variant string) (string, error) {
+	bound, ok := imageVariantBounds[variant]
+	if !ok {
+		return "", fmt.Errorf("unknown image variant %q", variant)
+	}
+	path := ImageVariantPath(original, variant)
+	src, err := os.Stat(original)
+	if err != nil {
+		return "", err
+	}
+	if dst, err := os.Stat(path); err == nil && !dst.ModTime().Before(src.ModTime()) {
+		return path, nil
+	}
+
+	img, err := decodeUpright(original)
+	if err != nil {
+		return "", err
+	}
+	if err := writeJPEGAtomic(path, fitImage(img, bound), imageVariantQuality); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// GenerateImageVariants builds every variant of original, decoding it
+// only once. Failures are returned but leave the original untouched.
+func GenerateImageVariants(original string) error {
+	img, err := decodeUpright(original)
+	if err != nil {
+		return err
+	}
+
+	// Scale the medium variant first and derive the thumbnail from it,
+	// which is far cheaper than scaling a full-size photo twice.
+	medium := fitImage(img, imageVariantBounds[ImageVariantMedium])
+	if err := writeJPEGAtomic(ImageVariantPath(original, ImageVariantMedium), medium, imageVariantQuality); err != nil {
+		return err
+	}
+	thumb := fitImage(medium, imageVariantBounds[ImageVariantThumb])
+	return writeJPEGAtomic(ImageVariantPath(original, ImageVariantThumb), thumb, imageVariantQuality)
+}
+
+// EncodeImageVariants builds every variant of the image in data and
+// returns the encoded JPEGs keyed by variant name. It is used when the
+// original is not on local disk, e.g. with object storage.
+func EncodeImageVariants(data []byte) (map[string][]byte, error) {
+	img, err := decodeUprightBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	medium := fitImage(img, imageVariantBounds[ImageVariantMedium])
+	thumb := fitImage(medium, imageVariantBounds[ImageVariantThumb])
+	out := make(map[string][]byte, len(imageVariantBounds))
+	for variant, scaled := range map[string]image.Image{ImageVariantMedium: medium, ImageVariantThumb: thumb} {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: imageVariantQuality}); err != nil {
+			return nil, err
+		}
+		out[variant] = buf.Bytes()
+	}
+	return out, nil
+}
+
+// decodeUpright decodes the image at path, within the usual size limits,
+// and applies its EXIF orientation.
+func decodeUpright(path string) (image.Image, error) {
+	if err := validateImageFile(path); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return OrientImage(img, ReadImageMetadata(data).Orientation), nil
+}
+
+// decodeUprightBytes is decodeUpright for an image already in memory.
+func decodeUprightBytes(data []byte) (image.Image, error) {
+	if len(data) > maxImageFileSize {
+		return nil, fmt.Errorf("image too large: %d bytes (max %d)", len(data), maxImageFileSize)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode image header: %w", err)
+	}
+	if cfg.Width > maxImageDimension || cfg.Height > maxImageDimension {
+		return nil, fmt.Errorf("image dimensions %dx%d exceed maximum %d", cfg.Width, cfg.Height, maxImageDimension)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return OrientImage(img, ReadImageMetadata(data).Orientation), nil
+}
+
+// NormalizeUploadedImage prepares an uploaded photo for storage. When
+// strip is set the EXIF, XMP and IPTC metadata (GPS position included)
+// are removed; an image that relied on its EXIF orientation is rotated
+// upright and re-encoded first so it still displays correctly. Without
+// strip the bytes are kept as uploaded. Only JPEGs carry metadata this
+// way; other formats pass through unchanged.
+func NormalizeUploadedImage(data []byte, strip bool) ([]byte, error) {
+	if !strip {
+		return data, nil
+	}
+	if orientation := ReadImageMetadata(data).Orientation; orientation != 1 {
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, OrientImage(img, orientation), &jpeg.Options{Quality: imageRewriteQuality}); err != nil {
+			return nil, err
+		}
+		// A fresh encode carries no metadata at all.
+		return buf.Bytes(), nil
+	}
+	return StripJPEGMetadata(data), nil
+}
+
+// OrientImage returns img transformed so that an image with the given
+// EXIF orientation displays upright. Orientation 1 (or any unknown
+// value) returns img unchanged.
+func OrientImage(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	w, h := b.Dx(), b.Dy()
+
+	// Orientations 5–8 swap width and height.
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored horizontally
+				dx, dy = w-1-x, y
+			case 3: // rotated 180°
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				dx, dy = x, h-1-y
+			case 5: // mirrored along the main diagonal
+				dx, dy = y, x
+			case 6: // needs a 90° clockwise turn
+				dx, dy = h-1-y, x
+			case 7: // mirrored along the anti-diagonal
+				dx, dy = h-1-y, w-1-x
+			case 8: // needs a 90° counter-clockwise turn
+				dx, dy = y, w-1-x
+			}
+			si := src.PixOffset(x, y)
+			di := dst.PixOffset(dx, dy)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
+
+// fitImage scales img down to fit within bound pixels on its longest
+// edge, flattening any transparency onto white for JPEG output.
+func fitImage(img image.Image, bound int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > bound || h > bound {
+		if w >= h {
+			w, h = bound, max(1, h*bound/b.Dx())
+		} else {
+			w, h = max(1, w*bound/b.Dy()), bound
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Over, nil)
+	return dst
+}
+
+// writeJPEGAtomic encodes img to path via a temporary file, so readers
+// never see a half-written variant.
+func writeJPEGAtomic(path string, img image.Image, quality int) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".variant-*.jpg")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := jpeg.Encode(tmp, img, &jpeg.Options{Quality: quality}); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
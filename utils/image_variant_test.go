@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeJPEGFile decodes the JPEG at path.
+func decodeJPEGFile(t *testing.T, path string) image.Image {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	return img
+}
+
+// isBlue reports whether the pixel at (x, y) is predominantly blue.
+func isBlue(img image.Image, x, y int) bool {
+	r, _, b, _ := img.At(x, y).RGBA()
+	return b > r
+}
+
+// ---------------------------------------------------------------------------
+// OrientImage
+// ---------------------------------------------------------------------------
+
+func TestOrientImage(t *testing.T) {
+	t.Parallel()
+	// 4×2 source: column x holds value x in the red channel, row y in green.
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			i := src.PixOffset(x, y)
+			src.Pix[i], src.Pix[i+1], src.Pix[i+3] = uint8(x), uint8(y), 255
+		}
+	}
+	origin := func(img image.Image, x, y int) [2]uint8 {
+		p := img.(*image.RGBA).RGBAAt(x, y)
+		return [2]uint8{p.R, p.G}
+	}
+
+	assert.Same(t, src, OrientImage(src, 1).(*image.RGBA))
+
+	// The top-left output pixel comes from a different source corner for
+	// each orientation; 5–8 also swap the dimensions.
+	cases := map[int]struct {
+		size    image.Point
+		topLeft [2]uint8
+	}{
+		2: {image.Pt(4, 2), [2]uint8{3, 0}},
+		3: {image.Pt(4, 2), [2]uint8{3, 1}},
+		4: {image.Pt(4, 2), [2]uint8{0, 1}},
+		5: {image.Pt(2, 4), [2]uint8{0, 0}},
+		6: {image.Pt(2, 4), [2]uint8{0, 1}},
+		7: {image.Pt(2, 4), [2]uint8{3, 1}},
+		8: {image.Pt(2, 4), [2]uint8{3, 0}},
+	}
+	for orientation, tc := range cases {
+		got := OrientImage(src, orientation)
+		assert.Equal(t, tc.size, got.Bounds().Size(), "orientation %d", orientation)
+		assert.Equal(t, tc.topLeft, origin(got, 0, 0), "orientation %d", orientation)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Variants
+// ---------------------------------------------------------------------------
+
+func TestGenerateImageVariants(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	original := filepath.Join(dir, "plant_1_image_0_1.jpg")
+	// 1600×800, tagged as needing a 90° clockwise turn.
+	require.NoError(t, os.WriteFile(original, exifJPEG(t, 1600, 800, 6, "2026:03:14 09:26:53"), 0o644))
+
+	require.NoError(t, GenerateImageVariants(original))
+
+	medium := decodeJPEGFile(t, filepath.Join(dir, "variants", "plant_1_image_0_1_medium.jpg"))
+	assert.Equal(t, image.Pt(640, 1280), medium.Bounds().Size(), "rotated upright, longest edge 1280")
+	thumb := decodeJPEGFile(t, ImageVariantPath(original, ImageVariantThumb))
+	assert.Equal(t, image.Pt(200, 400), thumb.Bounds().Size())
+	// The red left half of the sensor image ends up on top once rotated.
+	assert.False(t, isBlue(thumb, 100, 20))
+	assert.True(t, isBlue(thumb, 100, 380))
+
+	RemoveImageVariants(original)
+	_, err := os.Stat(ImageVariantPath(original, ImageVariantThumb))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestEncodeImageVariants(t *testing.T) {
+	t.Parallel()
+	variants, err := EncodeImageVariants(exifJPEG(t, 1600, 800, 6, "2026:03:14 09:26:53"))
+	require.NoError(t, err)
+	require.Len(t, variants, len(ImageVariantNames()))
+
+	decode := func(data []byte) image.Image {
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		require.NoError(t, err)
+		return img
+	}
+	assert.Equal(t, image.Pt(640, 1280), decode(variants[ImageVariantMedium]).Bounds().Size(), "rotated upright")
+	assert.Equal(t, image.Pt(200, 400), decode(variants[ImageVariantThumb]).Bounds().Size())
+
+	_, err = EncodeImageVariants([]byte("not an image"))
+	assert.Error(t, err)
+}
+
+func TestEnsureImageVariant(t *testing.T) {
+	t.Parallel()
+	original := filepath.Join(t.TempDir(), "small.png")
+	require.NoError(t, os.WriteFile(original, pngBytes(t), 0o644))
+
+	_, err := EnsureImageVariant(original, "huge")
+	require.Error(t, err)
+
+	path, err := EnsureImageVariant(original, ImageVariantMedium)
+	require.NoError(t, err)
+	assert.Equal(t, image.Pt(1, 1), decodeJPEGFile(t, path).Bounds().Size(), "small images are never upscaled")
+
+	// A stale variant is rebuilt once the original changes.
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(path, old, old))
+	require.NoError(t, os.WriteFile(original, exifJPEG(t, 20, 10, 1, "2026:03:14 09:26:53"), 0o644))
+	path, err = EnsureImageVariant(original, ImageVariantMedium)
+	require.NoError(t, err)
+	assert.Equal(t, image.Pt(20, 10), decodeJPEGFile(t, path).Bounds().Size())
+}
+
+// ---------------------------------------------------------------------------
+// NormalizeUploadedImage
+// ---------------------------------------------------------------------------
+
+func TestNormalizeUploadedImage(t *testing.T) {
+	t.Parallel()
+	upright := exifJPEG(t, 20, 10, 1, "2026:03:14 09:26:53")
+	rotated := exifJPEG(t, 20, 10, 6, "2026:03:14 09:26:53")
+
+	kept, err := NormalizeUploadedImage(rotated, false)
+	require.NoError(t, err)
+	assert.Equal(t, rotated, kept, "without stripping the upload is stored as-is")
+
+	stripped, err := NormalizeUploadedImage(upright, true)
+	require.NoError(t, err)
+	assert.NotContains(t, string(stripped), "Exif\x00\x00")
+	assert.Equal(t, len(upright)-len(stripped), bytes.Index(upright, []byte{0xFF, 0xDB})-bytes.Index(stripped, []byte{0xFF, 0xDB}),
+		"an upright photo only loses its metadata segment")
+
+	turned, err := NormalizeUploadedImage(rotated, true)
+	require.NoError(t, err)
+	assert.NotContains(t, string(turned), "Exif\x00\x00")
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(turned))
+	require.NoError(t, err)
+	assert.Equal(t, [2]int{10, 20}, [2]int{cfg.Width, cfg.Height}, "orientation is baked in before EXIF is dropped")
+}
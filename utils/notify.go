@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Notification is a message sent through the notification webhook.
+type Notification struct {
+	Title   string
+	Message string
+}
+
+// webhookPayload carries the notification under the field names the
+// common chat webhooks read: text for Slack and Mattermost, content for
+// Discord. Generic receivers get title and message.
+type webhookPayload struct {
+	Title   string `json:"title"`
+	Message string `json:"message"`
+	Text    string `json:"text"`
+	Content string `json:"content"`
+}
+
+// SendWebhook posts n as JSON to url. Any 2xx response counts as
+// delivered.
+func SendWebhook(ctx context.Context, client *http.Client, url string, n Notification) error {
+	text := n.Title
+	if n.Message != "" {
+		text += "\n" + n.Message
+	}
+	body, err := json.Marshal(webhookPayload{Title: n.Title, Message: n.Message, Text: text, Content: text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendWebhook(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]string
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		if got["title"] == "fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	err := SendWebhook(context.Background(), server.Client(), server.URL, Notification{Title: "Due", Message: "- Water"})
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", contentType)
+	assert.Equal(t, "Due", got["title"])
+	assert.Equal(t, "- Water", got["message"])
+	assert.Equal(t, "Due\n- Water", got["text"], "Slack reads text")
+	assert.Equal(t, "Due\n- Water", got["content"], "Discord reads content")
+
+	err = SendWebhook(context.Background(), server.Client(), server.URL, Notification{Title: "fail"})
+	assert.ErrorContains(t, err, "502")
+}
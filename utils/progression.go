@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io/fs"
+	"isley/logger"
+
+	"github.com/fogleman/gg"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+)
+
+const (
+	// DefaultLabelFont is used for progression strip labels when no font
+	// is chosen.
+	DefaultLabelFont = "fonts/Anton-Regular.ttf"
+
+	// MaxProgressionFrames caps how many tiles one strip may hold. With the
+	// tile sizes below the widest strip stays under maxImageDimension, so
+	// it can still go through ProcessImageWithTextOverlay.
+	MaxProgressionFrames = 32
+
+	progressionTileHeight  = 360
+	progressionTileWidth   = 480 // widest a tile may get
+	progressionLabelHeight = 56
+	progressionGap         = 8
+)
+
+var (
+	// ErrInvalidFont is returned for a font that isn't embedded.
+	ErrInvalidFont = errors.New("invalid font")
+	// ErrInvalidLogoPath is returned for a logo outside uploads/logos.
+	ErrInvalidLogoPath = errors.New("invalid logo path")
+	// ErrInvalidTextColor is returned for a text colour that isn't hex.
+	ErrInvalidTextColor = errors.New("invalid text color")
+)
+
+// progressionBackground fills the gaps and label band of a strip.
+var progressionBackground = color.RGBA{R: 0x1a, G: 0x1a, B: 0x1a, A: 0xff}
+
+// ProgressionFrame is one tile of a progression strip.
+type ProgressionFrame struct {
+	ImagePath string
+	Label     string // drawn beneath the photo, e.g. "Week 3 · 2026-03-14"
+}
+
+// ProgressionStripRequest describes a progression strip export. Title and
+// Logo are optional and are applied through the regular overlay pipeline
+// once the tiles have been laid out.
+type ProgressionStripRequest struct {
+	Frames     []ProgressionFrame
+	OutputPath string
+	Font       string // embedded font path; DefaultLabelFont when empty
+	Title      string // drawn top-left
+	Logo       string // path under uploads/logos, drawn top-right
+	TextColor  string // hex colour for the title; white when empty
+}
+
+// HasFont reports whether path names one of the embedded fonts.
+func HasFont(path string) bool {
+	fi, err := fs.Stat(embeddedFonts, path)
+	return err == nil && !fi.IsDir()
+}
+
+// ComposeProgressionStrip lays the frames out left to right, each scaled
+// to a common height with its label underneath, and saves the result as
+// a PNG. A title or logo is then added with ProcessImageWithTextOverlay.
+func ComposeProgressionStrip(req ProgressionStripRequest) error {
+	fieldLogger := logger.Log.WithFields(logrus.Fields{
+		"outputPath": req.OutputPath,
+		"frames":     len(req.Frames),
+	})
+
+	if len(req.Frames) == 0 || len(req.Frames) > MaxProgressionFrames {
+		return fmt.Errorf("progression strip needs 1-%d frames, got %d", MaxProgressionFrames, len(req.Frames))
+	}
+	if req.Font == "" {
+		req.Font = DefaultLabelFont
+	}
+	if !HasFont(req.Font) {
+		return ErrInvalidFont
+	}
+	if req.Logo != "" && !isPathWithinDir(req.Logo, "./uploads/logos") {
+		return ErrInvalidLogoPath
+	}
+	textColor := color.Color(color.White)
+	if req.TextColor != "" {
+		parsed, err := parseHexColor(req.TextColor)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidTextColor, err)
+		}
+		textColor = parsed
+	}
+
+	tiles := make([]image.Image, 0, len(req.Frames))
+	width := progressionGap
+	for _, frame := range req.Frames {
+		img, err := decodeUpright(frame.ImagePath)
+		if err != nil {
+			fieldLogger.WithError(err).Error("Failed to load progression frame")
+			return err
+		}
+		tile := fitTile(img)
+		tiles = append(tiles, tile)
+		width += tile.Bounds().Dx() + progressionGap
+	}
+	height := progressionGap + progressionTileHeight + progressionLabelHeight
+
+	face, err := loadFontFace(req.Font, progressionLabelHeight*0.45)
+	if err != nil {
+		return err
+	}
+	dc := gg.NewContext(width, height)
+	dc.SetColor(progressionBackground)
+	dc.Clear()
+	dc.SetFontFace(face)
+	dc.SetColor(color.White)
+	x := progressionGap
+	for i, tile := range tiles {
+		w := tile.Bounds().Dx()
+		dc.DrawImage(tile, x, progressionGap)
+		dc.DrawStringAnchored(req.Frames[i].Label, float64(x)+float64(w)/2,
+			float64(progressionGap+progressionTileHeight)+progressionLabelHeight/2, 0.5, 0.35)
+		x += w + progressionGap
+	}
+	if err := dc.SavePNG(req.OutputPath); err != nil {
+		fieldLogger.WithError(err).Error("Failed to save progression strip")
+		return fmt.Errorf("failed to save progression strip: %w", err)
+	}
+
+	if req.Title == "" && req.Logo == "" {
+		return nil
+	}
+	overlay := TextOverlayRequest{ImagePath: req.OutputPath, OutputPath: req.OutputPath}
+	if req.Title != "" {
+		overlay.TextObjects = append(overlay.TextObjects, TextObject{
+			Text:        req.Title,
+			Corner:      "top-left",
+			FontPath:    req.Font,
+			FontColor:   textColor,
+			ShadowColor: color.Black,
+			FontScale:   1.6,
+		})
+	}
+	if req.Logo != "" {
+		overlay.ImageObjects = append(overlay.ImageObjects, ImageObject{
+			ImagePath: req.Logo,
+			Corner:    "top-right",
+			Opacity:   0.8,
+		})
+	}
+	return ProcessImageWithTextOverlay(overlay)
+}
+
+// fitTile scales img to the strip's tile height, keeping its aspect
+// ratio but never exceeding the maximum tile width.
+func fitTile(img image.Image) image.Image {
+	b := img.Bounds()
+	w := max(1, b.Dx()*progressionTileHeight/max(1, b.Dy()))
+	h := progressionTileHeight
+	if w > progressionTileWidth {
+		w, h = progressionTileWidth, max(1, b.Dy()*progressionTileWidth/max(1, b.Dx()))
+	}
+	tile := image.NewRGBA(image.Rect(0, 0, w, progressionTileHeight))
+	draw.Draw(tile, tile.Bounds(), image.NewUniform(progressionBackground), image.Point{}, draw.Src)
+	top := (progressionTileHeight - h) / 2
+	draw.CatmullRom.Scale(tile, image.Rect(0, top, w, top+h), img, b, draw.Over, nil)
+	return tile
+}
+
+// loadFontFace opens an embedded font at the given size in points.
+func loadFontFace(path string, size float64) (font.Face, error) {
+	data, err := embeddedFonts.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded font: %w", err)
+	}
+	parsed, err := opentype.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse font data: %w", err)
+	}
+	return opentype.NewFace(parsed, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+}
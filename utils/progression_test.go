@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFrame saves a w×h JPEG under dir and returns its path.
+func writeFrame(t *testing.T, dir, name string, w, h int) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, exifJPEG(t, w, h, 1, "2026:03:14 09:26:53"), 0o644))
+	return path
+}
+
+// decodePNGFile decodes the PNG at path.
+func decodePNGFile(t *testing.T, path string) image.Image {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	img, err := png.Decode(f)
+	require.NoError(t, err)
+	return img
+}
+
+// ---------------------------------------------------------------------------
+// ComposeProgressionStrip
+// ---------------------------------------------------------------------------
+
+func TestComposeProgressionStrip(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	out := filepath.Join(dir, "strip.png")
+	frames := []ProgressionFrame{
+		{ImagePath: writeFrame(t, dir, "portrait.jpg", 300, 400), Label: "Week 1 · 2026-03-01"},
+		{ImagePath: writeFrame(t, dir, "wide.jpg", 1600, 400), Label: "Week 2 · 2026-03-08"},
+	}
+
+	require.NoError(t, ComposeProgressionStrip(ProgressionStripRequest{Frames: frames, OutputPath: out}))
+
+	// Portrait scales to 270 wide at the tile height; the panorama is
+	// capped at the maximum tile width.
+	want := image.Pt(8+270+8+480+8, 8+360+56)
+	assert.Equal(t, want, decodePNGFile(t, out).Bounds().Size())
+
+	// A title goes through the overlay pipeline without changing the size.
+	require.NoError(t, ComposeProgressionStrip(ProgressionStripRequest{
+		Frames:     frames,
+		OutputPath: out,
+		Title:      "Plant 1 · OG",
+		TextColor:  "#00FF00",
+	}))
+	assert.Equal(t, want, decodePNGFile(t, out).Bounds().Size())
+}
+
+func TestComposeProgressionStrip_Rejects(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	out := filepath.Join(dir, "strip.png")
+	frames := []ProgressionFrame{{ImagePath: writeFrame(t, dir, "a.jpg", 40, 30), Label: "Week 1"}}
+
+	cases := map[string]struct {
+		req  ProgressionStripRequest
+		want error
+	}{
+		"font":  {ProgressionStripRequest{Frames: frames, Font: "../go.mod"}, ErrInvalidFont},
+		"logo":  {ProgressionStripRequest{Frames: frames, Logo: "/etc/passwd"}, ErrInvalidLogoPath},
+		"color": {ProgressionStripRequest{Frames: frames, TextColor: "green"}, ErrInvalidTextColor},
+	}
+	for name, tc := range cases {
+		tc.req.OutputPath = out
+		assert.ErrorIs(t, ComposeProgressionStrip(tc.req), tc.want, name)
+	}
+
+	assert.Error(t, ComposeProgressionStrip(ProgressionStripRequest{OutputPath: out}), "no frames")
+	_, err := os.Stat(out)
+	assert.True(t, os.IsNotExist(err), "nothing is written for a rejected request")
+}
@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"isley/logger"
+)
+
+// RTSP transports FFmpeg can be asked to use. TCP is the default because
+// it survives NAT and lossy Wi-Fi far better than UDP.
+const (
+	RTSPTransportTCP = "tcp"
+	RTSPTransportUDP = "udp"
+)
+
+const (
+	// DefaultGrabTimeout bounds a single capture when the stream doesn't
+	// set its own timeout.
+	DefaultGrabTimeout = 15 * time.Second
+	// ffmpegGrabGrace is added to the capture timeout before the ffmpeg
+	// process itself is killed, so its own socket timeout fires first and
+	// produces a useful error message.
+	ffmpegGrabGrace = 5 * time.Second
+	// redactedSecret replaces passwords in logged URLs and ffmpeg output.
+	redactedSecret = "xxxxx"
+)
+
+// GrabOptions are the per-stream capture settings passed to
+// GrabStreamImage. The zero value captures without credentials over
+// TCP with DefaultGrabTimeout.
+type GrabOptions struct {
+	Username      string
+	Password      string
+	RTSPTransport string
+	Timeout       time.Duration
+}
+
+// timeout returns the effective capture timeout.
+func (o GrabOptions) timeout() time.Duration {
+	if o.Timeout > 0 {
+		return o.Timeout
+	}
+	return DefaultGrabTimeout
+}
+
+// RedactStreamURL returns rawURL with any password in its userinfo
+// replaced, for logging. Unparseable input is returned unchanged.
+func RedactStreamURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return parsed.Redacted()
+}
+
+// SplitStreamCredentials strips the userinfo from rawURL and returns the
+// bare URL along with the username and password it carried, so they can
+// be stored separately. URLs without userinfo are returned unchanged.
+func SplitStreamCredentials(rawURL string) (bare, username, password string) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.User == nil {
+		return rawURL, "", ""
+	}
+	username = parsed.User.Username()
+	password, _ = parsed.User.Password()
+	parsed.User = nil
+	return parsed.String(), username, password
+}
+
+// rtspFFmpegArgs builds the ffmpeg invocation that reads one frame from
+// source into the JPEG at output. RTSP gets the transport and a socket
+// timeout (in microseconds); RTMP only understands the generic
+// read/write timeout, since its own -timeout option means "listen".
+func rtspFFmpegArgs(source *url.URL, output string, opts GrabOptions) []string {
+	micros := strconv.FormatInt(opts.timeout().Microseconds(), 10)
+	args := []string{"-hide_banner", "-loglevel", "error", "-y"}
+	switch strings.ToLower(source.Scheme) {
+	case "rtsp", "rtsps":
+		transport := RTSPTransportTCP
+		// RTSP over TLS is TCP-only.
+		if opts.RTSPTransport == RTSPTransportUDP && strings.EqualFold(source.Scheme, "rtsp") {
+			transport = RTSPTransportUDP
+		}
+		args = append(args, "-rtsp_transport", transport, "-timeout", micros)
+	default:
+		args = append(args, "-rw_timeout", micros)
+	}
+	return append(args,
+		"-i", withCredentials(source, opts).String(),
+		"-frames:v", "1", "-update", "1", "-q:v", "2",
+		"-f", "image2", output,
+	)
+}
+
+// withCredentials returns a copy of source carrying opts' username and
+// password, or source itself when no username is set.
+func withCredentials(source *url.URL, opts GrabOptions) *url.URL {
+	if opts.Username == "" {
+		return source
+	}
+	u := *source
+	if opts.Password != "" {
+		u.User = url.UserPassword(opts.Username, opts.Password)
+	} else {
+		u.User = url.User(opts.Username)
+	}
+	return &u
+}
+
+// redactFFmpegOutput removes the password, raw and URL-escaped, from
+// ffmpeg's stderr, which may echo the input URL.
+func redactFFmpegOutput(msg string, opts GrabOptions) string {
+	if opts.Password == "" {
+		return msg
+	}
+	msg = strings.ReplaceAll(msg, opts.Password, redactedSecret)
+	return strings.ReplaceAll(msg, url.UserPassword("", opts.Password).String()[1:], redactedSecret)
+}
+
+// grabFFmpegFrame captures one frame from an RTSP or RTMP stream with
+// ffmpeg. The frame is written beside outputPath first and only moved
+// into place once it decodes as an image, so a failed grab never
+// replaces the previous frame.
+func grabFFmpegFrame(source *url.URL, outputPath string, opts GrabOptions) error {
+	bin, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return ErrFFmpegNotFound
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(outputPath), ".grab-*.jpg")
+	if err != nil {
+		return fmt.Errorf("failed to create temp frame: %w", err)
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+	defer os.Remove(tmpPath)
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout()+ffmpegGrabGrace)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, bin, rtspFFmpegArgs(source, tmpPath, opts)...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("ffmpeg timed out after %s", opts.timeout()+ffmpegGrabGrace)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("ffmpeg failed: %w: %s", err, redactFFmpegOutput(msg, opts))
+		}
+		return fmt.Errorf("ffmpeg failed: %w", err)
+	}
+
+	f, err := os.Open(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to read captured frame: %w", err)
+	}
+	_, _, err = image.DecodeConfig(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("ffmpeg output is not a valid image: %w", err)
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		return fmt.Errorf("failed to write image: %w", err)
+	}
+
+	logger.Log.WithField("outputPath", outputPath).Info("Image captured successfully from RTSP/RTMP stream")
+	return nil
+}
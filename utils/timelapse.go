@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Output containers the timelapse builder can produce.
+const (
+	TimelapseFormatMP4  = "mp4"
+	TimelapseFormatWebM = "webm"
+)
+
+// ErrFFmpegNotFound is returned by RenderTimelapse and RTSP/RTMP frame
+// capture when no ffmpeg binary is on PATH. HTTP capture is native Go,
+// but encoding video and decoding camera streams are not.
+var ErrFFmpegNotFound = errors.New("ffmpeg is not installed; timelapse rendering and RTSP/RTMP capture require ffmpeg on PATH")
+
+// IsVideoPath reports whether path names one of the timelapse video
+// containers, so galleries can render it with <video> instead of <img>.
+func IsVideoPath(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == "."+TimelapseFormatMP4 || ext == "."+TimelapseFormatWebM
+}
+
+// TimelapseFrameName returns the staged file name for the index'th frame
+// of a timelapse. RenderTimelapse reads frames back in index order.
+func TimelapseFrameName(index int, ext string) string {
+	return fmt.Sprintf("frame_%06d%s", index, ext)
+}
+
+// timelapseFFmpegArgs builds the ffmpeg invocation that encodes the
+// numbered frames in stageDir into output. The scale filter rounds the
+// frame size down to even dimensions, which both encoders require.
+func timelapseFFmpegArgs(stageDir, ext, output string, fps int, format string) []string {
+	args := []string{
+		"-hide_banner", "-loglevel", "error", "-y",
+		"-framerate", strconv.Itoa(fps),
+		"-i", filepath.Join(stageDir, "frame_%06d"+ext),
+		"-vf", "scale=trunc(iw/2)*2:trunc(ih/2)*2",
+	}
+	if format == TimelapseFormatWebM {
+		args = append(args, "-c:v", "libvpx-vp9", "-b:v", "0", "-crf", "35")
+	} else {
+		args = append(args, "-c:v", "libx264", "-pix_fmt", "yuv420p", "-movflags", "+faststart")
+	}
+	return append(args, output)
+}
+
+// RenderTimelapse encodes the frames staged in stageDir (named by
+// TimelapseFrameName with extension ext) into an MP4 or WebM at output.
+func RenderTimelapse(ctx context.Context, stageDir, ext, output string, fps int, format string) error {
+	if format != TimelapseFormatMP4 && format != TimelapseFormatWebM {
+		return fmt.Errorf("unsupported timelapse format %q", format)
+	}
+	bin, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return ErrFFmpegNotFound
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, bin, timelapseFFmpegArgs(stageDir, ext, output, fps, format)...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("ffmpeg failed: %w: %s", err, msg)
+		}
+		return fmt.Errorf("ffmpeg failed: %w", err)
+	}
+	return nil
+}
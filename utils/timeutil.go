@@ -7,6 +7,7 @@ const (
 	LayoutDateTime      = "01/02/2006 03:04 PM" // Human-readable display
 	LayoutDateTimeLocal = "2006-01-02T15:04:05" // HTML datetime-local input
 	LayoutDB            = "2006-01-02 15:04:05" // Raw DB string queries
+	LayoutClock         = "15:04"               // HTML time input, daily windows
 )
 
 // IsZeroDate reports whether t is the zero/null sentinel (zero value or 1970-01-01).
@@ -30,3 +31,26 @@ func AsLocal(t time.Time) time.Time {
 	return time.Date(t.Year(), t.Month(), t.Day(),
 		t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local)
 }
+
+// InDailyWindow reports whether t's wall-clock time falls inside the daily
+// window [start, end), both given as LayoutClock strings. A window whose
+// end is earlier than its start wraps midnight (e.g. 18:00–06:00). A blank
+// or unparseable bound, or start == end, leaves the window unrestricted.
+func InDailyWindow(t time.Time, start, end string) bool {
+	s, err1 := time.Parse(LayoutClock, start)
+	e, err2 := time.Parse(LayoutClock, end)
+	if err1 != nil || err2 != nil {
+		return true
+	}
+	from := s.Hour()*60 + s.Minute()
+	to := e.Hour()*60 + e.Minute()
+	now := t.Hour()*60 + t.Minute()
+	switch {
+	case from == to:
+		return true
+	case from < to:
+		return now >= from && now < to
+	default:
+		return now >= from || now < to
+	}
+}
@@ -85,3 +85,31 @@ func TestLayoutConstants_AreParseable(t *testing.T) {
 		})
 	}
 }
+
+func TestInDailyWindow(t *testing.T) {
+	t.Parallel()
+	at := func(h, m int) time.Time { return time.Date(2026, 4, 25, h, m, 0, 0, time.Local) }
+	cases := []struct {
+		name       string
+		now        time.Time
+		start, end string
+		want       bool
+	}{
+		{"blank window is unrestricted", at(3, 0), "", "", true},
+		{"half-blank window is unrestricted", at(3, 0), "06:00", "", true},
+		{"equal bounds are unrestricted", at(3, 0), "06:00", "06:00", true},
+		{"inside daytime window", at(12, 0), "06:00", "18:00", true},
+		{"start is inclusive", at(6, 0), "06:00", "18:00", true},
+		{"end is exclusive", at(18, 0), "06:00", "18:00", false},
+		{"before daytime window", at(5, 59), "06:00", "18:00", false},
+		{"overnight window late evening", at(23, 0), "18:00", "06:00", true},
+		{"overnight window early morning", at(2, 30), "18:00", "06:00", true},
+		{"outside overnight window", at(12, 0), "18:00", "06:00", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.want, InDailyWindow(tc.now, tc.start, tc.end))
+		})
+	}
+}
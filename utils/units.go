@@ -0,0 +1,222 @@
+package utils
+
+import (
+	"math"
+	"strings"
+)
+
+// Unit systems for the units.system setting. UnitSystemNative (also the
+// empty value) leaves readings in whatever unit their source reported.
+const (
+	UnitSystemNative   = "native"
+	UnitSystemMetric   = "metric"
+	UnitSystemImperial = "imperial"
+)
+
+// PressureUnits and WeightUnits are the values accepted for the
+// units.pressure and units.weight settings. An empty value follows the
+// unit system.
+var (
+	PressureUnits = []string{"hPa", "kPa", "inHg", "mmHg"}
+	WeightUnits   = []string{"g", "oz", "lb"}
+)
+
+// UnitPrefs is the display unit preference. Stored values never change;
+// readings, chart series, measurements and harvest weights are converted
+// on the way out.
+type UnitPrefs struct {
+	System   string `json:"system"`
+	Pressure string `json:"pressure"`
+	Weight   string `json:"weight"`
+}
+
+// unit kinds a conversion stays within.
+const (
+	kindTemperature = "temperature"
+	kindPressure    = "pressure"
+	kindLength      = "length"
+	kindRainRate    = "rain_rate"
+	kindSpeed       = "speed"
+	kindWeight      = "weight"
+	kindVolume      = "volume"
+)
+
+// unitDef converts one unit to its kind's base unit: base = v*scale + offset.
+type unitDef struct {
+	kind   string
+	unit   string // canonical spelling
+	scale  float64
+	offset float64
+	metric bool
+}
+
+// knownUnits maps the spellings sources report to their definitions. Base
+// units are °C, hPa, mm, mm/hr, km/h, g and ml.
+//
+// kPa is deliberately missing: every kPa reading Isley stores is a VPD,
+// which is quoted in kPa whatever the unit system, so it isn't treated as
+// a barometric pressure to convert.
+var knownUnits = func() map[string]unitDef {
+	defs := []struct {
+		def     unitDef
+		aliases []string
+	}{
+		{unitDef{kindTemperature, "°C", 1, 0, true}, []string{"°C", "C", "degC", "℃"}},
+		{unitDef{kindTemperature, "°F", 5.0 / 9.0, -32 * 5.0 / 9.0, false}, []string{"°F", "F", "degF", "℉"}},
+		{unitDef{kindPressure, "hPa", 1, 0, true}, []string{"hPa", "mbar", "mb"}},
+		{unitDef{kindPressure, "inHg", 33.8638866667, 0, false}, []string{"inHg"}},
+		{unitDef{kindPressure, "mmHg", 1.33322387415, 0, true}, []string{"mmHg"}},
+		{unitDef{kindLength, "mm", 1, 0, true}, []string{"mm"}},
+		{unitDef{kindLength, "cm", 10, 0, true}, []string{"cm"}},
+		{unitDef{kindLength, "m", 1000, 0, true}, []string{"m"}},
+		{unitDef{kindLength, "in", 25.4, 0, false}, []string{"in", "inch", "inches"}},
+		{unitDef{kindLength, "ft", 304.8, 0, false}, []string{"ft", "feet"}},
+		{unitDef{kindRainRate, "mm/hr", 1, 0, true}, []string{"mm/hr", "mm/h"}},
+		{unitDef{kindRainRate, "in/hr", 25.4, 0, false}, []string{"in/hr", "in/h"}},
+		{unitDef{kindSpeed, "km/h", 1, 0, true}, []string{"km/h", "kph", "kmh"}},
+		{unitDef{kindSpeed, "m/s", 3.6, 0, true}, []string{"m/s"}},
+		{unitDef{kindSpeed, "mph", 1.609344, 0, false}, []string{"mph"}},
+		{unitDef{kindWeight, "g", 1, 0, true}, []string{"g", "grams"}},
+		{unitDef{kindWeight, "kg", 1000, 0, true}, []string{"kg"}},
+		{unitDef{kindWeight, "oz", 28.349523125, 0, false}, []string{"oz"}},
+		{unitDef{kindWeight, "lb", 453.59237, 0, false}, []string{"lb", "lbs"}},
+		{unitDef{kindVolume, "ml", 1, 0, true}, []string{"ml", "mL"}},
+		{unitDef{kindVolume, "L", 1000, 0, true}, []string{"L", "l"}},
+		{unitDef{kindVolume, "fl oz", 29.5735295625, 0, false}, []string{"fl oz", "floz"}},
+		{unitDef{kindVolume, "gal", 3785.411784, 0, false}, []string{"gal"}},
+	}
+	out := make(map[string]unitDef)
+	for _, d := range defs {
+		for _, a := range d.aliases {
+			out[a] = d.def
+		}
+	}
+	return out
+}()
+
+// kpaTarget lets barometric readings be shown in kPa when the pressure
+// setting asks for it, even though kPa readings are never converted.
+var kpaTarget = unitDef{kindPressure, "kPa", 10, 0, true}
+
+// crossTargets is the unit a reading moves to when its unit belongs to the
+// other system, keyed by kind and then by whether the target is metric.
+// Readings already in the preferred system keep their unit, so a rain gauge
+// in mm isn't rescaled to cm just because heights are.
+var crossTargets = map[string]map[bool]string{
+	kindTemperature: {true: "°C", false: "°F"},
+	kindPressure:    {true: "hPa", false: "inHg"},
+	kindLength:      {true: "cm", false: "in"},
+	kindRainRate:    {true: "mm/hr", false: "in/hr"},
+	kindSpeed:       {true: "km/h", false: "mph"},
+	kindWeight:      {true: "g", false: "oz"},
+	kindVolume:      {true: "L", false: "gal"},
+}
+
+// Valid reports whether every field holds an accepted value.
+func (p UnitPrefs) Valid() bool {
+	switch p.System {
+	case "", UnitSystemNative, UnitSystemMetric, UnitSystemImperial:
+	default:
+		return false
+	}
+	return (p.Pressure == "" || containsString(PressureUnits, p.Pressure)) &&
+		(p.Weight == "" || containsString(WeightUnits, p.Weight))
+}
+
+// IsNative reports whether p leaves every reading as reported.
+func (p UnitPrefs) IsNative() bool {
+	return (p.System == "" || p.System == UnitSystemNative) && p.Pressure == "" && p.Weight == ""
+}
+
+// WeightUnit is the unit harvest weights are shown in. Weights are stored in
+// grams, so the native system shows grams.
+func (p UnitPrefs) WeightUnit() string {
+	if p.Weight != "" {
+		return p.Weight
+	}
+	if p.System == UnitSystemImperial {
+		return "oz"
+	}
+	return "g"
+}
+
+// GramsPerWeightUnit is how many grams one WeightUnit holds, for forms that
+// accept a weight in the display unit and submit grams.
+func (p UnitPrefs) GramsPerWeightUnit() float64 {
+	return knownUnits[p.WeightUnit()].scale
+}
+
+// DisplayWeight converts a stored weight in grams to WeightUnit.
+func (p UnitPrefs) DisplayWeight(grams float64) float64 {
+	return roundUnitValue(grams / p.GramsPerWeightUnit())
+}
+
+// Convert converts a reading from the unit its source reported to the
+// preferred unit, returning the new value and unit. Units it doesn't know
+// (%, µS/cm, kPa, ...) come back unchanged.
+func (p UnitPrefs) Convert(value float64, unit string) (float64, string) {
+	target := p.targetUnit(unit)
+	if target == "" {
+		return value, unit
+	}
+	from, to := knownUnits[strings.TrimSpace(unit)], knownUnits[target]
+	if target == "kPa" {
+		to = kpaTarget
+	}
+	if from.unit == to.unit {
+		// Same unit, maybe spelled differently ("F" vs "°F"); report the
+		// canonical spelling so mixed sources label alike.
+		return value, to.unit
+	}
+	base := value*from.scale + from.offset
+	return roundUnitValue((base - to.offset) / to.scale), to.unit
+}
+
+// ConvertUnit returns the unit Convert would report for unit, without a
+// value, so chart axes and headers can be labelled up front.
+func (p UnitPrefs) ConvertUnit(unit string) string {
+	_, out := p.Convert(0, unit)
+	return out
+}
+
+// targetUnit picks the unit a reading in unit should be shown in, or ""
+// to leave it alone.
+func (p UnitPrefs) targetUnit(unit string) string {
+	def, ok := knownUnits[strings.TrimSpace(unit)]
+	if !ok {
+		return ""
+	}
+	if def.kind == kindPressure && p.Pressure != "" {
+		return p.Pressure
+	}
+	if def.kind == kindWeight && p.Weight != "" {
+		return p.Weight
+	}
+	var wantMetric bool
+	switch p.System {
+	case UnitSystemMetric:
+		wantMetric = true
+	case UnitSystemImperial:
+		wantMetric = false
+	default:
+		return ""
+	}
+	if def.metric == wantMetric {
+		return def.unit
+	}
+	return crossTargets[def.kind][wantMetric]
+}
+
+// roundUnitValue trims float noise from converted values.
+func roundUnitValue(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
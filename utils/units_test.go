@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUnitPrefsConvert(t *testing.T) {
+	t.Parallel()
+	metric := UnitPrefs{System: UnitSystemMetric}
+	imperial := UnitPrefs{System: UnitSystemImperial}
+	cases := []struct {
+		name      string
+		prefs     UnitPrefs
+		value     float64
+		unit      string
+		wantValue float64
+		wantUnit  string
+	}{
+		{"native leaves °F alone", UnitPrefs{}, 77, "°F", 77, "°F"},
+		{"metric converts °F", metric, 77, "°F", 25, "°C"},
+		{"metric canonicalises bare C", metric, 25, "C", 25, "°C"},
+		{"imperial converts °C", imperial, 25, "°C", 77, "°F"},
+		{"metric converts inHg", metric, 29.92, "inHg", 1013.207, "hPa"},
+		{"explicit pressure unit", UnitPrefs{Pressure: "kPa"}, 29.92, "inHg", 101.321, "kPa"},
+		{"VPD kPa is never converted", imperial, 1.2, "kPa", 1.2, "kPa"},
+		{"metric keeps mm rain", metric, 4, "mm", 4, "mm"},
+		{"metric converts inches to cm", metric, 10, "in", 25.4, "cm"},
+		{"metric converts rain rate", metric, 1, "in/hr", 25.4, "mm/hr"},
+		{"imperial converts km/h", imperial, 16.09344, "km/h", 10, "mph"},
+		{"explicit weight unit", UnitPrefs{Weight: "lb"}, 453.59237, "g", 1, "lb"},
+		{"unknown unit passes through", metric, 55, "%", 55, "%"},
+	}
+	for _, tc := range cases {
+		gotValue, gotUnit := tc.prefs.Convert(tc.value, tc.unit)
+		if math.Abs(gotValue-tc.wantValue) > 1e-3 || gotUnit != tc.wantUnit {
+			t.Errorf("%s: Convert(%v, %q) = %v %q, want %v %q",
+				tc.name, tc.value, tc.unit, gotValue, gotUnit, tc.wantValue, tc.wantUnit)
+		}
+	}
+}
+
+func TestUnitPrefsValid(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		prefs UnitPrefs
+		want  bool
+	}{
+		{UnitPrefs{}, true},
+		{UnitPrefs{System: UnitSystemImperial, Pressure: "inHg", Weight: "oz"}, true},
+		{UnitPrefs{System: "nautical"}, false},
+		{UnitPrefs{Pressure: "psi"}, false},
+		{UnitPrefs{Weight: "kg"}, false},
+	}
+	for _, tc := range cases {
+		if got := tc.prefs.Valid(); got != tc.want {
+			t.Errorf("%+v.Valid() = %v, want %v", tc.prefs, got, tc.want)
+		}
+	}
+}
+
+func TestUnitPrefsWeight(t *testing.T) {
+	t.Parallel()
+	if got := (UnitPrefs{}).WeightUnit(); got != "g" {
+		t.Errorf("native weight unit = %q, want g", got)
+	}
+	imperial := UnitPrefs{System: UnitSystemImperial}
+	if got := imperial.WeightUnit(); got != "oz" {
+		t.Errorf("imperial weight unit = %q, want oz", got)
+	}
+	if got := imperial.DisplayWeight(283.49523125); math.Abs(got-10) > 1e-9 {
+		t.Errorf("DisplayWeight(283.5g) = %v, want 10", got)
+	}
+	lb := UnitPrefs{System: UnitSystemMetric, Weight: "lb"}
+	if got := lb.GramsPerWeightUnit(); got != 453.59237 {
+		t.Errorf("GramsPerWeightUnit(lb) = %v", got)
+	}
+}
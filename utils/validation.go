@@ -60,6 +60,18 @@ func ValidateDate(field, value string) error {
 	return fmt.Errorf("%s is not a valid date", field)
 }
 
+// ValidateClock checks a LayoutClock ("HH:MM") string from an
+// <input type=time> control. Empty input is allowed.
+func ValidateClock(field, value string) error {
+	if value == "" {
+		return nil
+	}
+	if _, err := time.Parse(LayoutClock, value); err != nil {
+		return fmt.Errorf("%s must be a time in HH:MM format", field)
+	}
+	return nil
+}
+
 // ValidateFiniteFloat64 checks that a float64 value is finite (not NaN or Inf).
 func ValidateFiniteFloat64(field string, value float64) error {
 	if math.IsNaN(value) {
@@ -118,7 +130,7 @@ func NormalizeWebURL(rawURL string) string {
 }
 
 // ValidateStreamURL checks that a URL string is well-formed and uses an
-// acceptable scheme (http or https, or rtsp/rtmp for camera streams).
+// acceptable scheme (http or https, or rtsp/rtsps/rtmp for camera streams).
 func ValidateStreamURL(rawURL string) error {
 	if strings.TrimSpace(rawURL) == "" {
 		return fmt.Errorf("URL is required")
@@ -131,10 +143,10 @@ func ValidateStreamURL(rawURL string) error {
 		return fmt.Errorf("URL is not valid")
 	}
 	switch strings.ToLower(parsed.Scheme) {
-	case "http", "https", "rtsp", "rtmp":
+	case "http", "https", "rtsp", "rtsps", "rtmp":
 		// acceptable schemes
 	default:
-		return fmt.Errorf("URL scheme must be http, https, rtsp, or rtmp")
+		return fmt.Errorf("URL scheme must be http, https, rtsp, rtsps, or rtmp")
 	}
 	if parsed.Host == "" {
 		return fmt.Errorf("URL must include a host")
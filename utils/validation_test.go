@@ -98,6 +98,19 @@ func TestValidateFiniteFloat64(t *testing.T) {
 	}
 }
 
+func TestValidateClock(t *testing.T) {
+	t.Parallel()
+	assert.NoError(t, ValidateClock("active_start", ""))
+	assert.NoError(t, ValidateClock("active_start", "06:30"))
+	assert.NoError(t, ValidateClock("active_start", "23:59"))
+	for _, bad := range []string{"24:00", "6pm", "06:60", "0630"} {
+		err := ValidateClock("active_start", bad)
+		if assert.Error(t, err, bad) {
+			assert.Contains(t, err.Error(), "HH:MM")
+		}
+	}
+}
+
 func TestValidateStreamURL(t *testing.T) {
 	t.Parallel()
 	cases := []struct {
@@ -109,6 +122,7 @@ func TestValidateStreamURL(t *testing.T) {
 		{"http allowed", "http://example.com/stream", false, ""},
 		{"https allowed", "https://example.com/stream", false, ""},
 		{"rtsp allowed", "rtsp://example.com/stream", false, ""},
+		{"rtsps allowed", "rtsps://example.com/stream", false, ""},
 		{"rtmp allowed", "rtmp://example.com/stream", false, ""},
 		{"uppercase scheme allowed", "HTTPS://example.com/stream", false, ""},
 		{"empty rejected", "", true, "required"},
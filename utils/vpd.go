@@ -29,3 +29,35 @@ func VPD(airTempC, rhPct, leafOffsetC float64) float64 {
 	esAir := saturationVaporPressure(airTempC)
 	return esLeaf - esAir*rhPct/100.0
 }
+
+// CelsiusToFahrenheit converts a temperature from Celsius to Fahrenheit.
+func CelsiusToFahrenheit(c float64) float64 {
+	return c*9.0/5.0 + 32.0
+}
+
+// DewPoint returns the dew point in °C for an air temperature in °C and a
+// relative humidity in percent, using the Magnus formula with the
+// Alduchov–Eskridge coefficients (accurate to ~0.4°C between -40 and 50°C).
+func DewPoint(airTempC, rhPct float64) float64 {
+	const a, b = 17.625, 243.04
+	if rhPct <= 0 {
+		rhPct = 0.01
+	}
+	gamma := math.Log(rhPct/100.0) + a*airTempC/(b+airTempC)
+	return b * gamma / (a - gamma)
+}
+
+// AbsoluteHumidity returns the water vapour density in g/m³ for an air
+// temperature in °C and a relative humidity in percent.
+//
+// Formula:
+//
+//	AH = es(T) * rh/100 * 1000 / (Rv * (T + 273.15))
+//
+// with es in Pa and Rv = 461.5 J/(kg·K), the specific gas constant of
+// water vapour.
+func AbsoluteHumidity(airTempC, rhPct float64) float64 {
+	const rv = 461.5
+	ePa := saturationVaporPressure(airTempC) * 1000.0 * rhPct / 100.0
+	return ePa / (rv * (airTempC + 273.15)) * 1000.0
+}
@@ -65,3 +65,38 @@ func TestVPD(t *testing.T) {
 		})
 	}
 }
+
+func TestDewPoint(t *testing.T) {
+	t.Parallel()
+	// Reference values from the NOAA dew point calculator.
+	cases := []struct {
+		tempC, rh, want float64
+	}{
+		{25.0, 50.0, 13.9},
+		{20.0, 100.0, 20.0},
+		{30.0, 70.0, 23.9},
+	}
+	for _, tc := range cases {
+		got := DewPoint(tc.tempC, tc.rh)
+		if math.Abs(got-tc.want) > 0.2 {
+			t.Errorf("DewPoint(%v, %v) = %.2f, want %.1f", tc.tempC, tc.rh, got, tc.want)
+		}
+	}
+}
+
+func TestAbsoluteHumidity(t *testing.T) {
+	t.Parallel()
+	// 25°C / 50% RH holds about 11.5 g/m³; saturated air at 20°C about 17.3 g/m³.
+	cases := []struct {
+		tempC, rh, want float64
+	}{
+		{25.0, 50.0, 11.5},
+		{20.0, 100.0, 17.3},
+	}
+	for _, tc := range cases {
+		got := AbsoluteHumidity(tc.tempC, tc.rh)
+		if math.Abs(got-tc.want) > 0.2 {
+			t.Errorf("AbsoluteHumidity(%v, %v) = %.2f, want %.1f", tc.tempC, tc.rh, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package watcher
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"isley/config"
+	"isley/handlers"
+	"isley/logger"
+	"isley/utils"
+)
+
+// dailyCheckInterval is how often a daily job checks whether its
+// configured time of day has passed.
+const dailyCheckInterval = time.Minute
+
+// dailySchedule decides when a once-a-day job is due: after a time of
+// day in the app timezone, and only if the date stored under
+// LastRunSetting isn't already today, so a restart doesn't repeat a day.
+// DailyPhotos and TaskReminders both run on one.
+type dailySchedule struct {
+	DB             *sql.DB
+	Store          *config.Store
+	LastRunSetting string
+}
+
+// runDaily calls check once per dailyCheckInterval until ctx is
+// cancelled, skipping while a restore is in progress or enabled reports
+// false. Settings are read on every check so enabling a job or moving
+// its time takes effect without a restart. name labels the start and
+// stop log lines.
+func runDaily(ctx context.Context, name string, enabled func() bool, check func()) {
+	logger.Log.Info("Started " + name)
+	for {
+		if !config.RestoreInProgress.Load() && enabled() {
+			check()
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Log.Info(name + " shutting down")
+			return
+		case <-time.After(dailyCheckInterval):
+		}
+	}
+}
+
+// due returns now in the app timezone and whether the job should run:
+// at (utils.LayoutClock) has passed today and no run is recorded for
+// today.
+func (s dailySchedule) due(now time.Time, at string) (time.Time, bool, error) {
+	local := now.In(handlers.AppTimeLocation(s.Store.Timezone()))
+	clock, err := time.Parse(utils.LayoutClock, at)
+	if err != nil {
+		return local, false, err
+	}
+	if local.Hour()*60+local.Minute() < clock.Hour()*60+clock.Minute() {
+		return local, false, nil
+	}
+	last, err := handlers.GetSetting(s.DB, s.LastRunSetting)
+	if err != nil {
+		return local, false, err
+	}
+	return local, last != local.Format(utils.LayoutDate), nil
+}
+
+// done records local's date as the last completed run.
+func (s dailySchedule) done(local time.Time) error {
+	return handlers.UpdateSetting(s.DB, nil, s.LastRunSetting, local.Format(utils.LayoutDate))
+}
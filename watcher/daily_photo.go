@@ -0,0 +1,223 @@
+package watcher
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"isley/config"
+	"isley/logger"
+	"isley/model/types"
+	"isley/storage"
+	"isley/utils"
+)
+
+const (
+	// dailyPhotoMaxFrameAge is how old a stream's latest frame may be and
+	// still count as current. Older frames mean the camera is offline or
+	// held back by its schedule, so the zone is skipped for the day.
+	dailyPhotoMaxFrameAge = 2 * time.Hour
+	// dailyPhotoLastRunSetting records the app-timezone date of the last
+	// completed run so a restart doesn't photograph the same day twice.
+	dailyPhotoLastRunSetting = "daily_photo_last_run"
+	// dailyPhotoImageOrder matches the order assigned to uploaded images.
+	dailyPhotoImageOrder = 100
+)
+
+// DailyPhotos copies the latest frame from each zone's camera into the
+// gallery of every living plant in that zone once a day, giving each
+// plant a photo journal without manual uploads. It is opt-in via the
+// daily_photo_enabled setting and runs at daily_photo_time in the app
+// timezone. Constructed by main alongside the Grabber, whose frames it
+// reads from FrameDir.
+type DailyPhotos struct {
+	DB        *sql.DB
+	Store     *config.Store
+	FrameDir  string
+	UploadDir string
+	// Files stores the copied photos. Nil means local disk under
+	// UploadDir.
+	Files storage.Store
+	Now   func() time.Time
+}
+
+// NewDailyPhotos returns a DailyPhotos wired to the supplied database,
+// per-engine *config.Store and resolved frame and upload directories.
+func NewDailyPhotos(db *sql.DB, store *config.Store, frameDir, uploadDir string) *DailyPhotos {
+	return &DailyPhotos{
+		DB:        db,
+		Store:     store,
+		FrameDir:  frameDir,
+		UploadDir: uploadDir,
+		Now:       time.Now,
+	}
+}
+
+// Run checks once a minute whether the day's photos are due until ctx
+// is cancelled.
+func (d *DailyPhotos) Run(ctx context.Context) {
+	runDaily(ctx, "Daily Plant Photos",
+		func() bool { return d.Store.DailyPhotoEnabled() == 1 },
+		func() { d.runIfDue(d.Now()) })
+}
+
+func (d *DailyPhotos) schedule() dailySchedule {
+	return dailySchedule{DB: d.DB, Store: d.Store, LastRunSetting: dailyPhotoLastRunSetting}
+}
+
+// runIfDue takes the day's photos once the configured time has passed,
+// unless they were already taken today.
+func (d *DailyPhotos) runIfDue(now time.Time) {
+	fieldLogger := logger.Log.WithField("func", "DailyPhotos.runIfDue")
+	schedule := d.schedule()
+	local, due, err := schedule.due(now, d.Store.DailyPhotoTime())
+	if err != nil {
+		fieldLogger.WithError(err).Warn("Failed to check daily photo schedule")
+		return
+	}
+	if !due {
+		return
+	}
+
+	saved, err := d.Capture(local)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to take daily plant photos")
+		return
+	}
+	if err := schedule.done(local); err != nil {
+		fieldLogger.WithError(err).Error("Failed to record daily photo run")
+	}
+	fieldLogger.Infof("Saved %d daily plant photos", saved)
+}
+
+// dailyPhotoPlant is a living plant eligible for a daily photo.
+type dailyPhotoPlant struct {
+	id       int
+	zoneID   uint
+	startDT  time.Time
+	status   string
+	statusDT time.Time
+}
+
+// Capture copies the current frame of each zone's camera into the gallery
+// of every living plant in that zone and returns the number of photos
+// saved. now, in the app timezone, dates the photos and their
+// descriptions. Zones without a camera or with only stale frames are
+// skipped.
+func (d *DailyPhotos) Capture(now time.Time) (int, error) {
+	plants, err := d.livingPlants()
+	if err != nil {
+		return 0, err
+	}
+	frames := map[uint]string{}
+	for _, stream := range d.Store.Streams() {
+		if _, ok := frames[stream.ZoneID]; ok {
+			continue
+		}
+		if frame := d.currentFrame(stream, now); frame != "" {
+			frames[stream.ZoneID] = frame
+		}
+	}
+
+	saved := 0
+	for _, plant := range plants {
+		frame, ok := frames[plant.zoneID]
+		if !ok {
+			continue
+		}
+		if err := d.savePhoto(plant, frame, now); err != nil {
+			logger.Log.WithError(err).WithField("plant", plant.id).Error("Failed to save daily plant photo")
+			continue
+		}
+		saved++
+	}
+	return saved, nil
+}
+
+// currentFrame returns the path of the stream's latest frame, or "" when
+// there is none or it is older than dailyPhotoMaxFrameAge.
+func (d *DailyPhotos) currentFrame(stream types.Stream, now time.Time) string {
+	path := filepath.Join(d.FrameDir, fmt.Sprintf("stream_%d_latest.jpg", stream.ID))
+	info, err := os.Stat(path)
+	if err != nil || info.Size() == 0 {
+		return ""
+	}
+	if now.Sub(info.ModTime()) > dailyPhotoMaxFrameAge {
+		logger.Log.WithField("stream", stream.Name).Debug("Latest frame too old for daily photo")
+		return ""
+	}
+	return path
+}
+
+// livingPlants returns every plant whose current status is active,
+// together with that status and when it began.
+func (d *DailyPhotos) livingPlants() ([]dailyPhotoPlant, error) {
+	rows, err := d.DB.Query(`SELECT p.id, p.zone_id, p.start_dt, ps.status, psl.date
+		FROM plant p
+		JOIN plant_status_log psl ON psl.plant_id = p.id
+		JOIN plant_status ps ON ps.id = psl.status_id
+		WHERE ps.active = 1 AND p.zone_id IS NOT NULL
+		  AND psl.id = (SELECT id FROM plant_status_log WHERE plant_id = p.id ORDER BY date DESC, id DESC LIMIT 1)
+		ORDER BY p.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var plants []dailyPhotoPlant
+	for rows.Next() {
+		var p dailyPhotoPlant
+		if err := rows.Scan(&p.id, &p.zoneID, &p.startDT, &p.status, &p.statusDT); err != nil {
+			return nil, err
+		}
+		p.startDT = utils.AsLocal(p.startDT)
+		p.statusDT = utils.AsLocal(p.statusDT)
+		plants = append(plants, p)
+	}
+	return plants, rows.Err()
+}
+
+// savePhoto copies frame into the plant's gallery.
+func (d *DailyPhotos) savePhoto(plant dailyPhotoPlant, frame string, now time.Time) error {
+	fileName := fmt.Sprintf("plant_%d_daily_%d.jpg", plant.id, now.UnixNano())
+	savePath := filepath.Join(d.UploadDir, "plants", fileName)
+	files := d.Files
+	if files == nil {
+		files = storage.NewLocal(d.UploadDir)
+	}
+	ctx := context.Background()
+	key := storage.KeyForPath(d.UploadDir, savePath)
+	if err := storage.PutFile(ctx, files, key, frame); err != nil {
+		return err
+	}
+	if _, err := d.DB.Exec(`INSERT INTO plant_images (plant_id, image_path, image_description, image_order, image_date)
+		VALUES ($1, $2, $3, $4, $5)`,
+		plant.id, savePath, dailyPhotoDescription(plant, now), dailyPhotoImageOrder, utils.AsLocal(now)); err != nil {
+		files.Delete(ctx, key)
+		return err
+	}
+	return nil
+}
+
+// dailyPhotoDescription captions a daily photo with the plant's age and
+// how long it has been in its current status, e.g. "Day 34 / Week 5 Flower".
+func dailyPhotoDescription(plant dailyPhotoPlant, now time.Time) string {
+	day := calendarDaysBetween(plant.startDT, now) + 1
+	week := calendarDaysBetween(plant.statusDT, now)/7 + 1
+	return fmt.Sprintf("Day %d / Week %d %s", day, week, plant.status)
+}
+
+// calendarDaysBetween counts the calendar dates from a to b by their
+// wall-clock dates, so DST shifts and times of day don't skew the count.
+func calendarDaysBetween(a, b time.Time) int {
+	from := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(to.Sub(from).Hours() / 24)
+	if days < 0 {
+		return 0
+	}
+	return days
+}
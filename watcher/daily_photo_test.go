@@ -0,0 +1,164 @@
+package watcher
+
+// Tests for the daily plant photo job: which plants get a photo, how it
+// is captioned, and that runIfDue fires once per day after the
+// configured time.
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"isley/config"
+	"isley/model/types"
+	"isley/tests/testutil"
+)
+
+// dailyPhotoFixture is a DB with one camera zone holding a flowering and
+// a dead plant, and a camera-less zone holding another flowering plant.
+type dailyPhotoFixture struct {
+	db          *sql.DB
+	photos      *DailyPhotos
+	frame       string
+	floweringID int
+}
+
+func newDailyPhotoFixture(t *testing.T) dailyPhotoFixture {
+	t.Helper()
+	silenceWatcherLogger()
+	db := testutil.NewTestDB(t)
+
+	camZone := testutil.SeedZone(t, db, "Tent")
+	bareZone := testutil.SeedZone(t, db, "Closet")
+	strainID := testutil.SeedStrain(t, db, testutil.SeedBreeder(t, db, "Breeder"), "Strain")
+	flowering := testutil.SeedPlant(t, db, "Flowering", strainID, camZone)
+	dead := testutil.SeedPlant(t, db, "Dead", strainID, camZone)
+	elsewhere := testutil.SeedPlant(t, db, "Elsewhere", strainID, bareZone)
+	for _, p := range []struct {
+		id     int
+		status string
+	}{{flowering, "Flower"}, {dead, "Dead"}, {elsewhere, "Flower"}} {
+		testutil.MustExec(t, db, `INSERT INTO plant_status_log (plant_id, status_id, date)
+			SELECT $1, id, '2026-01-01' FROM plant_status WHERE status = $2`, p.id, p.status)
+	}
+
+	frameDir := t.TempDir()
+	frame := filepath.Join(frameDir, "stream_7_latest.jpg")
+	require.NoError(t, os.WriteFile(frame, pngBytes(t), 0o644))
+
+	store := config.NewStore()
+	store.SetStreams([]types.Stream{{ID: 7, Name: "Tent Cam", ZoneID: uint(camZone)}})
+	return dailyPhotoFixture{
+		db:          db,
+		photos:      NewDailyPhotos(db, store, frameDir, t.TempDir()),
+		frame:       frame,
+		floweringID: flowering,
+	}
+}
+
+// plantPhotos returns the description and path of each gallery image.
+func plantPhotos(t *testing.T, db *sql.DB) map[int][2]string {
+	t.Helper()
+	rows, err := db.Query(`SELECT plant_id, image_description, image_path FROM plant_images`)
+	require.NoError(t, err)
+	defer rows.Close()
+	out := map[int][2]string{}
+	for rows.Next() {
+		var id int
+		var desc, path string
+		require.NoError(t, rows.Scan(&id, &desc, &path))
+		out[id] = [2]string{desc, path}
+	}
+	return out
+}
+
+// ---------------------------------------------------------------------------
+// Capture
+// ---------------------------------------------------------------------------
+
+func TestDailyPhotos_CaptureCopiesZoneFrameForLivingPlants(t *testing.T) {
+	t.Parallel()
+	fx := newDailyPhotoFixture(t)
+
+	now := time.Now()
+	saved, err := fx.photos.Capture(now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, saved, "only the living plant in the camera zone gets a photo")
+
+	photos := plantPhotos(t, fx.db)
+	require.Contains(t, photos, fx.floweringID)
+	assert.Regexp(t, `^Day \d+ / Week \d+ Flower$`, photos[fx.floweringID][0])
+	path := photos[fx.floweringID][1]
+	assert.NotEqual(t, fx.frame, path, "the plant must own its own copy")
+	got, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, pngBytes(t), got)
+}
+
+func TestDailyPhotos_CaptureSkipsStaleFrames(t *testing.T) {
+	t.Parallel()
+	fx := newDailyPhotoFixture(t)
+
+	old := time.Now().Add(-dailyPhotoMaxFrameAge - time.Minute)
+	require.NoError(t, os.Chtimes(fx.frame, old, old))
+
+	saved, err := fx.photos.Capture(time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, saved)
+	assert.Empty(t, plantPhotos(t, fx.db))
+}
+
+func TestDailyPhotoDescription(t *testing.T) {
+	t.Parallel()
+	plant := dailyPhotoPlant{
+		startDT:  time.Date(2026, 1, 1, 18, 0, 0, 0, time.Local),
+		statusDT: time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local),
+		status:   "Flower",
+	}
+	now := time.Date(2026, 2, 3, 9, 0, 0, 0, time.Local)
+	assert.Equal(t, "Day 34 / Week 5 Flower", dailyPhotoDescription(plant, now))
+
+	plant.statusDT = time.Date(2026, 2, 3, 0, 0, 0, 0, time.Local)
+	assert.Equal(t, "Day 34 / Week 1 Flower", dailyPhotoDescription(plant, now), "first day of a status is week 1")
+}
+
+// ---------------------------------------------------------------------------
+// runIfDue
+// ---------------------------------------------------------------------------
+
+func TestDailyPhotos_RunIfDueOncePerDay(t *testing.T) {
+	t.Parallel()
+	fx := newDailyPhotoFixture(t)
+	fx.photos.Store.SetTimezone("UTC")
+	fx.photos.Store.SetDailyPhotoTime("09:30")
+
+	count := func() int {
+		var n int
+		require.NoError(t, fx.db.QueryRow(`SELECT COUNT(*) FROM plant_images`).Scan(&n))
+		return n
+	}
+	touch := func(at time.Time) {
+		require.NoError(t, os.Chtimes(fx.frame, at, at))
+	}
+
+	day := time.Date(2026, 2, 3, 9, 29, 0, 0, time.UTC)
+	touch(day)
+	fx.photos.runIfDue(day)
+	assert.Zero(t, count(), "not due before the configured time")
+
+	fx.photos.runIfDue(day.Add(time.Minute))
+	assert.Equal(t, 1, count())
+
+	fx.photos.runIfDue(day.Add(3 * time.Hour / 2))
+	assert.Equal(t, 1, count(), "already taken today")
+
+	next := day.Add(24 * time.Hour).Add(time.Minute)
+	touch(next)
+	fx.photos.runIfDue(next)
+	assert.Equal(t, 2, count(), "due again the next day")
+}
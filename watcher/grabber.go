@@ -2,20 +2,34 @@ package watcher
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"path/filepath"
 	"sync"
 	"time"
 
 	"isley/config"
+	"isley/handlers"
 	"isley/logger"
 	"isley/model/types"
+	"isley/storage"
 	"isley/utils"
 )
 
 const (
 	maxBackoffMultiplier      = 10 // cap at 10× the normal interval
 	defaultStreamGrabInterval = 60 // seconds between stream image captures
+
+	// streamScheduleTick is how often Run checks which streams are due.
+	// Per-stream intervals are honoured to within one tick.
+	streamScheduleTick = 5 * time.Second
+	// streamConditionRecheck bounds how long a stream held back by its
+	// active window or light condition waits before being checked again.
+	streamConditionRecheck = time.Minute
+	// lightReadingMaxAge is how old a light sensor's latest reading may be
+	// and still gate captures. An older one says nothing about whether
+	// the lights are on now, so the stream is held back.
+	lightReadingMaxAge = 15 * time.Minute
 )
 
 // Grabber runs the stream-image capture loop. One instance per running
@@ -24,64 +38,198 @@ const (
 // per-stream backoff counter and other per-iteration state stay
 // isolated.
 //
+// Each stream is scheduled independently: it has its own next-due time
+// derived from its grab interval (falling back to the global setting),
+// and is only captured inside its active window and, when configured,
+// while its light sensor reads above the threshold. DB is only needed
+// for that light condition and may be nil in tests that don't use it.
+// Captures run in their own goroutine, at most one per stream, so a
+// camera that hangs until its grab timeout doesn't hold up the others.
+//
 // Production callers must resolve frameDir via app.ResolvePathDefaults
 // before calling NewGrabber; an empty frameDir is a programmer error
 // and Run panics fast so the bug surfaces at the call site rather than
 // after the goroutine has been running for hours writing to the
 // process CWD.
 type Grabber struct {
+	DB       *sql.DB
 	Store    *config.Store
 	FrameDir string
+	// Files, when set, receives a copy of each stream's latest frame
+	// under "streams/<file>" so it is served from object storage. Frames
+	// are always written to FrameDir first; nil keeps them there only.
+	Files storage.Store
+	Now   func() time.Time
 
-	mu      sync.Mutex
-	backoff map[uint]int
+	mu       sync.Mutex
+	backoff  map[uint]int
+	nextDue  map[uint]time.Time
+	inFlight map[uint]bool
+	captures sync.WaitGroup
 }
 
-// NewGrabber returns a Grabber wired to the supplied per-engine
-// *config.Store and resolved frame directory.
-func NewGrabber(store *config.Store, frameDir string) *Grabber {
+// NewGrabber returns a Grabber wired to the supplied database,
+// per-engine *config.Store and resolved frame directory.
+func NewGrabber(db *sql.DB, store *config.Store, frameDir string) *Grabber {
 	return &Grabber{
+		DB:       db,
 		Store:    store,
 		FrameDir: frameDir,
+		Now:      time.Now,
 		backoff:  map[uint]int{},
+		nextDue:  map[uint]time.Time{},
+		inFlight: map[uint]bool{},
 	}
 }
 
 // Run drives the stream-image capture loop until ctx is cancelled. It
 // reads enabled/interval/streams from the per-engine *config.Store on
-// every iteration so runtime settings changes take effect without
-// restarting the goroutine.
+// every tick so runtime settings changes take effect without
+// restarting the goroutine. On shutdown it waits for captures still in
+// flight, which each end within their grab timeout.
 func (g *Grabber) Run(ctx context.Context) {
 	if g.FrameDir == "" {
 		panic("watcher.Grabber.Run: FrameDir must be non-empty (resolve via app.ResolvePathDefaults)")
 	}
 	logger.Log.Info("Started Stream Grabber")
-	interval := defaultStreamGrabInterval
 
 	for {
 		if !config.RestoreInProgress.Load() && g.Store.StreamGrabEnabled() == 1 {
-			for _, stream := range g.Store.Streams() {
-				g.processStream(stream)
-			}
-		}
-		if grab := g.Store.StreamGrabInterval(); grab > 0 {
-			interval = grab
+			g.tick(g.Now())
 		}
 
-		// Wait for either the grab interval or context cancellation
+		// Wait for the next scheduling tick or context cancellation
 		select {
 		case <-ctx.Done():
 			logger.Log.Info("Stream Grabber shutting down")
+			g.wait()
 			return
-		case <-time.After(time.Duration(interval) * time.Second):
+		case <-time.After(streamScheduleTick):
+		}
+	}
+}
+
+// tick starts a capture of every stream that is due at now and schedules
+// its next attempt. Streams seen for the first time are due immediately.
+// The next attempt is scheduled from now, before the capture runs, so a
+// slow capture doesn't push the stream's schedule back; a stream whose
+// previous capture is still running waits for the next tick.
+func (g *Grabber) tick(now time.Time) {
+	streams := g.Store.Streams()
+	seen := make(map[uint]bool, len(streams))
+	for _, stream := range streams {
+		seen[stream.ID] = true
+		g.mu.Lock()
+		due, ok := g.nextDue[stream.ID]
+		busy := g.inFlight[stream.ID]
+		g.mu.Unlock()
+		if busy || ok && now.Before(due) {
+			continue
+		}
+
+		interval := g.streamInterval(stream)
+		allowed := g.captureAllowed(stream, now)
+		next := now.Add(interval)
+		if !allowed && interval > streamConditionRecheck {
+			next = now.Add(streamConditionRecheck)
+		}
+		g.mu.Lock()
+		g.nextDue[stream.ID] = next
+		if allowed {
+			g.inFlight[stream.ID] = true
+		}
+		g.mu.Unlock()
+
+		if allowed {
+			g.captures.Add(1)
+			go func(stream types.Stream) {
+				defer g.captures.Done()
+				defer func() {
+					g.mu.Lock()
+					delete(g.inFlight, stream.ID)
+					g.mu.Unlock()
+				}()
+				g.processStream(stream)
+			}(stream)
 		}
 	}
+
+	// Forget deleted streams so a re-used ID starts fresh.
+	g.mu.Lock()
+	for id := range g.nextDue {
+		if !seen[id] {
+			delete(g.nextDue, id)
+		}
+	}
+	g.mu.Unlock()
+}
+
+// wait blocks until every capture started so far has finished.
+func (g *Grabber) wait() {
+	g.captures.Wait()
+}
+
+// streamInterval resolves a stream's capture interval: its own setting,
+// then the global stream grab interval, then the built-in default.
+func (g *Grabber) streamInterval(stream types.Stream) time.Duration {
+	seconds := defaultStreamGrabInterval
+	if stream.GrabInterval > 0 {
+		seconds = stream.GrabInterval
+	} else if grab := g.Store.StreamGrabInterval(); grab > 0 {
+		seconds = grab
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// captureAllowed reports whether the stream's active window and light
+// condition permit a capture at now.
+func (g *Grabber) captureAllowed(stream types.Stream, now time.Time) bool {
+	fieldLogger := logger.Log.WithField("stream", stream.Name)
+	if !utils.InDailyWindow(now.In(handlers.AppTimeLocation(g.Store.Timezone())), stream.ActiveStart, stream.ActiveEnd) {
+		fieldLogger.Debug("Outside active window, skipping stream grab")
+		return false
+	}
+	if stream.LightSensorID == nil || stream.LightThreshold == nil {
+		return true
+	}
+	value, at, ok := g.latestReading(*stream.LightSensorID)
+	if !ok {
+		fieldLogger.Debug("No light sensor reading, skipping stream grab")
+		return false
+	}
+	if now.Sub(at) > lightReadingMaxAge {
+		fieldLogger.Debugf("Light reading from %s is stale, skipping stream grab", at.Format(time.RFC3339))
+		return false
+	}
+	if value <= *stream.LightThreshold {
+		fieldLogger.Debugf("Light reading %.2f not above %.2f, skipping stream grab", value, *stream.LightThreshold)
+		return false
+	}
+	return true
+}
+
+// latestReading returns the newest stored value for sensorID and when it
+// was recorded.
+func (g *Grabber) latestReading(sensorID uint) (float64, time.Time, bool) {
+	if g.DB == nil {
+		return 0, time.Time{}, false
+	}
+	var value float64
+	var at time.Time
+	err := g.DB.QueryRow("SELECT value, create_dt FROM sensor_data WHERE sensor_id = $1 ORDER BY create_dt DESC, id DESC LIMIT 1", sensorID).Scan(&value, &at)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			logger.Log.WithError(err).WithField("sensor", sensorID).Warn("Failed to read light sensor")
+		}
+		return 0, time.Time{}, false
+	}
+	return value, at, true
 }
 
 // processStream handles one stream's grab attempt, reading and
-// writing the per-stream backoff counter under g.mu. The mutex
-// acquisition is short because each stream is independent;
-// serialising across streams within one iteration is fine.
+// writing the per-stream backoff counter under g.mu. The backoff
+// counts the stream's own scheduled cycles, so a slow camera backs off
+// in multiples of its own interval.
 func (g *Grabber) processStream(stream types.Stream) {
 	// If this stream has consecutive failures, skip cycles proportionally
 	g.mu.Lock()
@@ -104,7 +252,7 @@ func (g *Grabber) processStream(stream types.Stream) {
 	latestSavePath := filepath.Join(g.FrameDir, latestFileName)
 	utils.CreateFolderIfNotExists(g.FrameDir)
 
-	if err := utils.GrabWebcamImage(stream.URL, latestSavePath); err != nil {
+	if err := utils.GrabStreamImage(stream.URL, latestSavePath, grabOptions(stream)); err != nil {
 		// Record failure and set skip cycles for exponential backoff
 		g.mu.Lock()
 		next := g.backoff[stream.ID] + 1
@@ -121,5 +269,42 @@ func (g *Grabber) processStream(stream types.Stream) {
 		delete(g.backoff, stream.ID)
 		g.mu.Unlock()
 		logger.Log.WithField("stream", stream.Name).Debug("Stream image saved")
+		if g.Files != nil {
+			if err := storage.PutFile(context.Background(), g.Files, "streams/"+latestFileName, latestSavePath); err != nil {
+				logger.Log.WithField("stream", stream.Name).WithError(err).Warn("Failed to publish stream image to storage")
+			}
+		}
+		if stream.ArchiveEnabled {
+			g.archiveFrame(stream, latestSavePath)
+		}
+	}
+}
+
+// archiveFrame keeps a timestamped copy of the frame just written to
+// latestPath and prunes the stream's archive back within its budget.
+// Archive failures are logged but never count against the stream's
+// grab backoff — the live frame was captured fine.
+func (g *Grabber) archiveFrame(stream types.Stream, latestPath string) {
+	fieldLogger := logger.Log.WithField("stream", stream.Name)
+	dir := utils.FrameArchiveDir(g.FrameDir, stream.ID)
+	if _, err := utils.ArchiveFrame(latestPath, dir, time.Now()); err != nil {
+		fieldLogger.WithError(err).Warn("Failed to archive stream frame")
+		return
+	}
+	removed, err := utils.PruneFrameArchive(dir, stream.ArchiveMaxFrames, int64(stream.ArchiveMaxMB)*1024*1024)
+	if err != nil {
+		fieldLogger.WithError(err).Warn("Failed to prune stream frame archive")
+	} else if removed > 0 {
+		fieldLogger.Debugf("Pruned %d archived frames", removed)
+	}
+}
+
+// grabOptions returns the credentials and FFmpeg options for stream.
+func grabOptions(stream types.Stream) utils.GrabOptions {
+	return utils.GrabOptions{
+		Username:      stream.Username,
+		Password:      stream.Password,
+		RTSPTransport: stream.RTSPTransport,
+		Timeout:       time.Duration(stream.GrabTimeout) * time.Second,
 	}
 }
@@ -14,14 +14,18 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"isley/config"
 	"isley/model/types"
+	"isley/tests/testutil"
+	"isley/utils"
 )
 
 // TestGrabber_HappyPathWritesFrame exercises the success path: a
@@ -44,7 +48,7 @@ func TestGrabber_HappyPathWritesFrame(t *testing.T) {
 	store.SetStreams([]types.Stream{{ID: streamID, Name: "Tent A Cam", URL: srv.URL + "/snap.png"}})
 	store.SetStreamGrabEnabled(1)
 
-	g := NewGrabber(store, frameDir)
+	g := NewGrabber(nil, store, frameDir)
 	runGrabOnce(t, g)
 
 	saved := filepath.Join(frameDir, "stream_101_latest.jpg")
@@ -74,7 +78,7 @@ func TestGrabber_FailureIncrementsBackoff(t *testing.T) {
 	store.SetStreams([]types.Stream{{ID: streamID, Name: "Bad Cam", URL: srv.URL + "/feed"}})
 	store.SetStreamGrabEnabled(1)
 
-	g := NewGrabber(store, frameDir)
+	g := NewGrabber(nil, store, frameDir)
 	runGrabOnce(t, g)
 
 	got, ok := g.backoff[streamID]
@@ -100,7 +104,7 @@ func TestGrabber_RespectsStreamGrabDisabled(t *testing.T) {
 	store.SetStreams([]types.Stream{{ID: 104, Name: "Disabled Test", URL: srv.URL + "/snap.png"}})
 	store.SetStreamGrabEnabled(0) // disabled
 
-	g := NewGrabber(store, frameDir)
+	g := NewGrabber(nil, store, frameDir)
 	runGrabOnce(t, g)
 
 	assert.Zero(t, atomic.LoadInt32(&hits))
@@ -126,10 +130,231 @@ func TestGrabber_BackoffSkipsCycles(t *testing.T) {
 	store.SetStreams([]types.Stream{{ID: streamID, Name: "Cooldown", URL: srv.URL + "/snap.png"}})
 	store.SetStreamGrabEnabled(1)
 
-	g := NewGrabber(store, frameDir)
+	g := NewGrabber(nil, store, frameDir)
 	g.backoff[streamID] = 3 // 3 skip cycles remaining
 	runGrabOnce(t, g)
 
 	assert.Zero(t, atomic.LoadInt32(&hits), "stream with backoff > 1 should not be requested this cycle")
 	assert.Equal(t, 2, g.backoff[streamID], "backoff counter must decrement by 1")
 }
+
+// TestGrabber_ArchivesFramesWithinBudget seeds two old frames into an
+// archive with a two-frame budget, runs one grab, and asserts the new
+// frame was kept and the oldest seeded frame was pruned.
+func TestGrabber_ArchivesFramesWithinBudget(t *testing.T) {
+	t.Parallel()
+	silenceWatcherLogger()
+	frameDir := t.TempDir()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(pngBytes(t))
+	}))
+	t.Cleanup(srv.Close)
+
+	stream := types.Stream{ID: 105, Name: "Archive Cam", URL: srv.URL + "/snap.png",
+		ArchiveEnabled: true, ArchiveMaxFrames: 2}
+	dir := utils.FrameArchiveDir(frameDir, stream.ID)
+	seed := filepath.Join(frameDir, "seed.jpg")
+	require.NoError(t, os.WriteFile(seed, pngBytes(t), 0o644))
+	oldest := time.Now().Add(-2 * time.Hour)
+	for _, ts := range []time.Time{oldest, oldest.Add(time.Hour)} {
+		_, err := utils.ArchiveFrame(seed, dir, ts)
+		require.NoError(t, err)
+	}
+
+	g := NewGrabber(nil, config.NewStore(), frameDir)
+	g.processStream(stream)
+
+	frames, err := utils.ListArchivedFrames(dir, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, frames, 2)
+	assert.True(t, frames[0].CapturedAt.After(oldest), "oldest frame should have been pruned")
+	assert.WithinDuration(t, time.Now(), frames[1].CapturedAt, time.Minute)
+}
+
+// TestGrabber_NoArchiveWhenDisabled confirms the default leaves only the
+// latest frame on disk.
+func TestGrabber_NoArchiveWhenDisabled(t *testing.T) {
+	t.Parallel()
+	silenceWatcherLogger()
+	frameDir := t.TempDir()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(pngBytes(t))
+	}))
+	t.Cleanup(srv.Close)
+
+	stream := types.Stream{ID: 106, Name: "Plain Cam", URL: srv.URL + "/snap.png"}
+	g := NewGrabber(nil, config.NewStore(), frameDir)
+	g.processStream(stream)
+
+	_, err := os.Stat(filepath.Join(frameDir, "archive"))
+	assert.True(t, os.IsNotExist(err), "archive dir must not be created for a non-archiving stream")
+}
+
+// pathCountingCam serves a valid PNG on every path and counts requests
+// per path, so one server can stand in for several cameras.
+func pathCountingCam(t *testing.T) (*httptest.Server, func(path string) int) {
+	t.Helper()
+	var mu sync.Mutex
+	hits := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+		w.Header().Set("Content-Type", "image/png")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(pngBytes(t))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func(path string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return hits[path]
+	}
+}
+
+// TestGrabber_SchedulesStreamsIndependently drives tick with a fake
+// clock: a 10s stream is captured again before a stream that falls back
+// to the 60s global interval.
+func TestGrabber_SchedulesStreamsIndependently(t *testing.T) {
+	t.Parallel()
+	silenceWatcherLogger()
+	srv, hits := pathCountingCam(t)
+
+	store := config.NewStore()
+	store.SetStreamGrabInterval(60)
+	store.SetStreams([]types.Stream{
+		{ID: 201, Name: "Fast", URL: srv.URL + "/fast.png", GrabInterval: 10},
+		{ID: 202, Name: "Global", URL: srv.URL + "/global.png"},
+	})
+	g := NewGrabber(nil, store, t.TempDir())
+
+	t0 := time.Date(2026, 4, 25, 12, 0, 0, 0, time.UTC)
+	g.tick(t0)
+	g.wait()
+	assert.Equal(t, 1, hits("/fast.png"))
+	assert.Equal(t, 1, hits("/global.png"))
+
+	g.tick(t0.Add(5 * time.Second))
+	g.wait()
+	assert.Equal(t, 1, hits("/fast.png"), "not due again until its interval elapses")
+
+	g.tick(t0.Add(15 * time.Second))
+	g.wait()
+	assert.Equal(t, 2, hits("/fast.png"))
+	assert.Equal(t, 1, hits("/global.png"))
+
+	g.tick(t0.Add(61 * time.Second))
+	g.wait()
+	assert.Equal(t, 3, hits("/fast.png"))
+	assert.Equal(t, 2, hits("/global.png"))
+}
+
+// TestGrabber_SkipsOutsideActiveWindow confirms the active window is
+// evaluated in the configured timezone and that a held-back stream is
+// rechecked within a minute rather than after its full interval.
+func TestGrabber_SkipsOutsideActiveWindow(t *testing.T) {
+	t.Parallel()
+	silenceWatcherLogger()
+	srv, hits := pathCountingCam(t)
+
+	store := config.NewStore()
+	store.SetTimezone("America/New_York")
+	store.SetStreams([]types.Stream{{ID: 203, Name: "Day Cam", URL: srv.URL + "/day.png",
+		GrabInterval: 3600, ActiveStart: "06:00", ActiveEnd: "18:00"}})
+	g := NewGrabber(nil, store, t.TempDir())
+
+	// 10:00 UTC is 06:00 in New York during DST; 09:59 UTC is before it.
+	t0 := time.Date(2026, 6, 1, 9, 59, 0, 0, time.UTC)
+	g.tick(t0)
+	g.wait()
+	assert.Zero(t, hits("/day.png"), "lights-off capture must be skipped")
+
+	g.tick(t0.Add(time.Minute))
+	g.wait()
+	assert.Equal(t, 1, hits("/day.png"), "stream is rechecked a minute later, not an hour")
+}
+
+// TestGrabber_LightCondition gates capture on the light sensor's latest
+// reading exceeding the threshold.
+func TestGrabber_LightCondition(t *testing.T) {
+	t.Parallel()
+	silenceWatcherLogger()
+	srv, hits := pathCountingCam(t)
+
+	db := testutil.NewTestDB(t)
+	sensorID := uint(testutil.SeedSensor(t, db, "EcoWitt", "gw1100", "light"))
+	threshold := 100.0
+
+	store := config.NewStore()
+	store.SetStreams([]types.Stream{{ID: 204, Name: "Lit Cam", URL: srv.URL + "/lit.png",
+		GrabInterval: 10, LightSensorID: &sensorID, LightThreshold: &threshold}})
+	g := NewGrabber(db, store, t.TempDir())
+
+	t0 := time.Now()
+	g.tick(t0)
+	g.wait()
+	assert.Zero(t, hits("/lit.png"), "no reading yet means no capture")
+
+	testutil.MustExec(t, db, `INSERT INTO sensor_data (sensor_id, value) VALUES ($1, $2)`, sensorID, 4.0)
+	g.tick(t0.Add(10 * time.Second))
+	g.wait()
+	assert.Zero(t, hits("/lit.png"), "reading at or below the threshold means lights off")
+
+	testutil.MustExec(t, db, `INSERT INTO sensor_data (sensor_id, value) VALUES ($1, $2)`, sensorID, 850.0)
+	g.tick(t0.Add(20 * time.Second))
+	g.wait()
+	assert.Equal(t, 1, hits("/lit.png"))
+
+	testutil.MustExec(t, db, `UPDATE sensor_data SET create_dt = $1 WHERE sensor_id = $2`,
+		time.Now().UTC().Add(-2*lightReadingMaxAge).Format("2006-01-02 15:04:05"), sensorID)
+	g.tick(t0.Add(30 * time.Second))
+	g.wait()
+	assert.Equal(t, 1, hits("/lit.png"), "a stale reading says nothing about the lights now")
+}
+
+// TestGrabber_HungStreamDoesNotBlockOthers runs a camera that hangs
+// alongside a working one: the working camera keeps its schedule, and
+// the hung one is not grabbed again while its capture is still running.
+func TestGrabber_HungStreamDoesNotBlockOthers(t *testing.T) {
+	t.Parallel()
+	silenceWatcherLogger()
+	srv, hits := pathCountingCam(t)
+
+	release := make(chan struct{})
+	var hungHits int32
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hungHits, 1)
+		<-release
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(hung.Close)
+
+	store := config.NewStore()
+	store.SetStreams([]types.Stream{
+		{ID: 205, Name: "Hung", URL: hung.URL + "/hung.png", GrabInterval: 10, GrabTimeout: 30},
+		{ID: 206, Name: "Fine", URL: srv.URL + "/fine.png", GrabInterval: 10},
+	})
+	g := NewGrabber(nil, store, t.TempDir())
+
+	t0 := time.Date(2026, 4, 25, 12, 0, 0, 0, time.UTC)
+	g.tick(t0)
+	require.Eventually(t, func() bool { return hits("/fine.png") == 1 }, 5*time.Second, 10*time.Millisecond,
+		"the working camera is captured while the hung one waits")
+	g.mu.Lock()
+	due := g.nextDue[205]
+	g.mu.Unlock()
+	assert.Equal(t, t0.Add(10*time.Second), due, "the next attempt is scheduled when the capture starts")
+
+	g.tick(t0.Add(10 * time.Second))
+	require.Eventually(t, func() bool { return hits("/fine.png") == 2 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hungHits), "a stream is never captured twice at once")
+
+	close(release)
+	g.wait()
+}
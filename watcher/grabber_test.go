@@ -51,8 +51,9 @@ func silenceWatcherLogger() {
 
 // runGrabOnce drives a *Grabber's Run loop with a short ctx timeout so
 // one iteration runs and the function returns cleanly via ctx.Done.
-// The supplied grabber's store is forced to a 60s grab interval so the
-// second iteration cannot start before the ctx expires.
+// The first tick captures every stream; the supplied grabber's store is
+// forced to a 60s grab interval so no stream is due again before the
+// ctx expires.
 func runGrabOnce(t *testing.T, g *Grabber) {
 	t.Helper()
 	g.Store.SetStreamGrabInterval(60) // seconds — well past the ctx deadline
@@ -109,7 +110,7 @@ func TestGrabber_RespectsRestoreInProgress(t *testing.T) {
 	store.SetStreamGrabEnabled(1)
 	config.RestoreInProgress.Store(true) // block the iteration body
 
-	g := NewGrabber(store, t.TempDir())
+	g := NewGrabber(nil, store, t.TempDir())
 	runGrabOnce(t, g)
 
 	assert.Zero(t, atomic.LoadInt32(&hits), "no HTTP hits should occur while RestoreInProgress is true")
@@ -31,64 +31,18 @@ func (w *Watcher) RefreshHourlyRollups() error {
 // is false it only processes the last 25 hours (overlap by 1 hour to
 // catch late-arriving data).
 func (w *Watcher) runRollup(fullBackfill bool) error {
-	var query string
-	if model.IsPostgres() {
-		query = buildPostgresRollupQuery(fullBackfill)
-	} else {
-		query = buildSQLiteRollupQuery(fullBackfill)
+	where := ""
+	if !fullBackfill {
+		where = "sd.create_dt > datetime('now', '-25 hours')"
+		if model.IsPostgres() {
+			where = "sd.create_dt > NOW() - INTERVAL '25 hours'"
+		}
 	}
 
-	if _, err := w.DB.Exec(query); err != nil {
+	if _, err := w.DB.Exec(model.HourlyRollupQuery(where)); err != nil {
 		return fmt.Errorf("rollup: aggregation query failed: %w", err)
 	}
 
 	w.Logger.Info("Hourly rollup refresh completed")
 	return nil
 }
-
-func buildSQLiteRollupQuery(fullBackfill bool) string {
-	whereClause := ""
-	if !fullBackfill {
-		whereClause = "WHERE sd.create_dt > datetime('now', '-25 hours')"
-	}
-
-	return fmt.Sprintf(`
-		INSERT OR REPLACE INTO sensor_data_hourly (sensor_id, bucket, min_val, max_val, avg_val, sample_count)
-		SELECT
-			sd.sensor_id,
-			strftime('%%Y-%%m-%%d %%H:00:00', sd.create_dt) AS bucket,
-			MIN(sd.value),
-			MAX(sd.value),
-			AVG(sd.value),
-			COUNT(*)
-		FROM sensor_data sd
-		%s
-		GROUP BY sd.sensor_id, strftime('%%Y-%%m-%%d %%H:00:00', sd.create_dt)
-	`, whereClause)
-}
-
-func buildPostgresRollupQuery(fullBackfill bool) string {
-	whereClause := ""
-	if !fullBackfill {
-		whereClause = "WHERE sd.create_dt > NOW() - INTERVAL '25 hours'"
-	}
-
-	return fmt.Sprintf(`
-		INSERT INTO sensor_data_hourly (sensor_id, bucket, min_val, max_val, avg_val, sample_count)
-		SELECT
-			sd.sensor_id,
-			date_trunc('hour', sd.create_dt) AS bucket,
-			MIN(sd.value),
-			MAX(sd.value),
-			AVG(sd.value),
-			COUNT(*)
-		FROM sensor_data sd
-		%s
-		GROUP BY sd.sensor_id, date_trunc('hour', sd.create_dt)
-		ON CONFLICT (sensor_id, bucket) DO UPDATE SET
-			min_val      = EXCLUDED.min_val,
-			max_val      = EXCLUDED.max_val,
-			avg_val      = EXCLUDED.avg_val,
-			sample_count = EXCLUDED.sample_count
-	`, whereClause)
-}
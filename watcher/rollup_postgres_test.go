@@ -1,9 +1,9 @@
 //go:build integration_postgres
 
 // Postgres-flavored rollup tests. The default-build counterparts in
-// rollup_test.go exercise the SQLite branch of buildSQLiteRollupQuery
+// rollup_test.go exercise the SQLite branch of model.HourlyRollupQuery
 // against an in-memory SQLite. This file mirrors them against a real
-// PostgreSQL so buildPostgresRollupQuery (date_trunc, INTERVAL '25 hours',
+// PostgreSQL so its Postgres branch (date_trunc, INTERVAL '25 hours',
 // ON CONFLICT DO UPDATE) is exercised end-to-end against the dialect it
 // targets — not just asserted to be the chosen branch.
 //
@@ -68,7 +68,7 @@ func hourlyBucketPG(t *testing.T, db *sql.DB, sensorID int, bucket string) (minV
 
 // TestRefreshHourlyRollups_FullBackfillEmptyTable_Postgres mirrors the
 // SQLite full-backfill test against real PG. Verifies that the
-// buildPostgresRollupQuery's date_trunc('hour', ...) correctly produces
+// Postgres rollup query's date_trunc('hour', ...) correctly produces
 // one bucket per distinct (sensor, hour) and that ON CONFLICT does not
 // fire on the first run.
 func TestRefreshHourlyRollups_FullBackfillEmptyTable_Postgres(t *testing.T) {
@@ -0,0 +1,138 @@
+package watcher
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"isley/config"
+	"isley/handlers"
+	"isley/logger"
+	"isley/model/types"
+	"isley/utils"
+)
+
+const (
+	// taskReminderLastRunSetting records the app-timezone date of the
+	// last reminder so a restart doesn't send the same day twice.
+	taskReminderLastRunSetting = "task_reminder_last_run"
+	// taskReminderMaxItems caps the tasks listed in one reminder.
+	taskReminderMaxItems = 25
+)
+
+// TaskReminders posts the day's overdue and due tasks to the
+// notification webhook once a day at task_reminder_time in the app
+// timezone. Nothing is sent while no webhook is configured or when no
+// task is due. Constructed by main alongside the Grabber and
+// DailyPhotos.
+type TaskReminders struct {
+	DB    *sql.DB
+	Store *config.Store
+	HTTP  *http.Client
+	Now   func() time.Time
+}
+
+// NewTaskReminders returns a TaskReminders wired to the supplied
+// database and per-engine *config.Store.
+func NewTaskReminders(db *sql.DB, store *config.Store) *TaskReminders {
+	return &TaskReminders{
+		DB:    db,
+		Store: store,
+		HTTP:  &http.Client{Timeout: httpClientTimeout},
+		Now:   time.Now,
+	}
+}
+
+// Run checks once a minute whether the day's reminder is due until ctx
+// is cancelled.
+func (r *TaskReminders) Run(ctx context.Context) {
+	runDaily(ctx, "Task Reminders",
+		func() bool { return r.Store.NotifyWebhookURL() != "" },
+		func() { r.runIfDue(ctx, r.Now()) })
+}
+
+func (r *TaskReminders) schedule() dailySchedule {
+	return dailySchedule{DB: r.DB, Store: r.Store, LastRunSetting: taskReminderLastRunSetting}
+}
+
+// runIfDue sends the day's reminder once the configured time has passed,
+// unless it was already sent today. A failed send is retried on the next
+// check.
+func (r *TaskReminders) runIfDue(ctx context.Context, now time.Time) {
+	fieldLogger := logger.Log.WithField("func", "TaskReminders.runIfDue")
+	schedule := r.schedule()
+	local, due, err := schedule.due(now, r.Store.TaskReminderTime())
+	if err != nil {
+		fieldLogger.WithError(err).Warn("Failed to check task reminder schedule")
+		return
+	}
+	if !due {
+		return
+	}
+
+	sent, err := r.Send(ctx, local)
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to send task reminder")
+		return
+	}
+	if err := schedule.done(local); err != nil {
+		fieldLogger.WithError(err).Error("Failed to record task reminder run")
+	}
+	fieldLogger.Infof("Sent task reminder for %d tasks", sent)
+}
+
+// Send posts a reminder listing the tasks overdue or due on today's date
+// and returns how many it listed. No reminder is sent when nothing is
+// due.
+func (r *TaskReminders) Send(ctx context.Context, today time.Time) (int, error) {
+	due, err := handlers.LoadDueTasks(r.DB, today, 0)
+	if err != nil {
+		return 0, err
+	}
+	if len(due) == 0 {
+		return 0, nil
+	}
+	n := taskReminder(due)
+	if err := utils.SendWebhook(ctx, r.HTTP, r.Store.NotifyWebhookURL(), n); err != nil {
+		return 0, err
+	}
+	return len(due), nil
+}
+
+// taskReminder formats the due list as a notification, one line per
+// task.
+func taskReminder(due []types.DueTask) utils.Notification {
+	overdue := 0
+	lines := make([]string, 0, len(due))
+	for i, d := range due {
+		if d.Overdue() {
+			overdue++
+		}
+		if i >= taskReminderMaxItems {
+			continue
+		}
+		target := d.TargetName
+		if d.PlantName != "" {
+			target = d.PlantName
+		}
+		line := "- " + d.Title
+		if target != "" {
+			line += " (" + target + ")"
+		}
+		if d.Overdue() {
+			line += fmt.Sprintf(" - %d days overdue", d.DaysOverdue)
+		}
+		lines = append(lines, line)
+	}
+	if extra := len(due) - taskReminderMaxItems; extra > 0 {
+		lines = append(lines, fmt.Sprintf("...and %d more", extra))
+	}
+	title := fmt.Sprintf("Isley: %d tasks due today", len(due)-overdue)
+	if overdue > 0 {
+		title += fmt.Sprintf(", %d overdue", overdue)
+	}
+	return utils.Notification{Title: title, Message: strings.Join(lines, "\n")}
+}
@@ -0,0 +1,128 @@
+package watcher
+
+// Tests for the task reminder job: what a reminder lists, that nothing
+// is sent when nothing is due, and that runIfDue sends once per day
+// after the configured time.
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"isley/config"
+	"isley/tests/testutil"
+	"isley/utils"
+)
+
+// webhookRecorder is an httptest webhook that keeps what it was sent.
+type webhookRecorder struct {
+	mu       sync.Mutex
+	payloads []map[string]string
+	status   int
+}
+
+func (w *webhookRecorder) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	var payload map[string]string
+	_ = json.NewDecoder(r.Body).Decode(&payload)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.payloads = append(w.payloads, payload)
+	if w.status != 0 {
+		rw.WriteHeader(w.status)
+	}
+}
+
+func (w *webhookRecorder) sent() []map[string]string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]map[string]string(nil), w.payloads...)
+}
+
+// newTaskReminderFixture returns reminders posting to a recording
+// webhook, and a living plant named "Young" to hang tasks on.
+func newTaskReminderFixture(t *testing.T) (*sql.DB, *TaskReminders, *webhookRecorder, int) {
+	t.Helper()
+	silenceWatcherLogger()
+	db := testutil.NewTestDB(t)
+	zoneID := testutil.SeedZone(t, db, "Tent")
+	plantID := testutil.SeedPlant(t, db, "Young", testutil.SeedStrain(t, db, testutil.SeedBreeder(t, db, "B"), "S"), zoneID)
+	testutil.MustExec(t, db, `INSERT INTO plant_status_log (plant_id, status_id, date)
+		SELECT $1, id, $2 FROM plant_status WHERE status = 'Veg'`, plantID, testutil.DaysAgo(30))
+
+	hook := &webhookRecorder{}
+	webhook := httptest.NewServer(hook)
+	t.Cleanup(webhook.Close)
+	store := config.NewStore()
+	store.SetNotifyWebhookURL(webhook.URL)
+	return db, NewTaskReminders(db, store), hook, plantID
+}
+
+// addDueTask adds a one-off task for plantID due on date.
+func addDueTask(t *testing.T, db *sql.DB, title string, plantID int, date time.Time) {
+	t.Helper()
+	testutil.MustExec(t, db, `INSERT INTO tasks (title, plant_id, schedule, due_date) VALUES ($1, $2, 'once', $3)`,
+		title, plantID, date.Format(utils.LayoutDate))
+}
+
+func TestTaskReminders_SendListsDueTasks(t *testing.T) {
+	t.Parallel()
+
+	db, reminders, hook, plantID := newTaskReminderFixture(t)
+	now := time.Now()
+
+	sent, err := reminders.Send(context.Background(), now)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+	assert.Empty(t, hook.sent(), "nothing is sent when nothing is due")
+
+	addDueTask(t, db, "Check trichomes", plantID, now.AddDate(0, 0, -2))
+	addDueTask(t, db, "Rotate pots", plantID, now)
+	addDueTask(t, db, "Next week", plantID, now.AddDate(0, 0, 3))
+	sent, err = reminders.Send(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent, "only overdue and today's tasks are sent")
+	payloads := hook.sent()
+	require.Len(t, payloads, 1)
+	assert.Equal(t, "Isley: 1 tasks due today, 1 overdue", payloads[0]["title"])
+	assert.Contains(t, payloads[0]["message"], "- Check trichomes (Young) - 2 days overdue")
+	assert.Contains(t, payloads[0]["message"], "- Rotate pots (Young)")
+	assert.NotContains(t, payloads[0]["message"], "Next week")
+	assert.Contains(t, payloads[0]["content"], "Rotate pots", "chat webhooks get the whole text")
+
+	hook.mu.Lock()
+	hook.status = http.StatusInternalServerError
+	hook.mu.Unlock()
+	_, err = reminders.Send(context.Background(), now)
+	assert.Error(t, err, "a rejected reminder is reported so it is retried")
+}
+
+func TestTaskReminders_RunIfDueOncePerDay(t *testing.T) {
+	t.Parallel()
+
+	db, reminders, hook, plantID := newTaskReminderFixture(t)
+	reminders.Store.SetTimezone("UTC")
+	reminders.Store.SetTaskReminderTime("08:00")
+	day := time.Date(2030, 3, 10, 7, 59, 0, 0, time.UTC)
+	addDueTask(t, db, "Water", plantID, day)
+	ctx := context.Background()
+
+	reminders.runIfDue(ctx, day)
+	assert.Empty(t, hook.sent(), "nothing is sent before the configured time")
+
+	reminders.runIfDue(ctx, day.Add(time.Minute))
+	assert.Len(t, hook.sent(), 1)
+	reminders.runIfDue(ctx, day.Add(2*time.Hour))
+	assert.Len(t, hook.sent(), 1, "the reminder is sent once a day")
+	assert.Equal(t, 1, testutil.CountRows(t, db, `SELECT COUNT(*) FROM settings WHERE name = $1 AND value = '2030-03-10'`, taskReminderLastRunSetting))
+
+	reminders.runIfDue(ctx, day.AddDate(0, 0, 1).Add(time.Minute))
+	assert.Len(t, hook.sent(), 2, "the next day sends again")
+}
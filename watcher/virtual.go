@@ -0,0 +1,300 @@
+package watcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"isley/model/types"
+	"isley/utils"
+)
+
+// minVirtualInputAge is the floor for how old an input reading may be before
+// a virtual sensor ignores it. The effective window is three polling
+// intervals, so one missed poll doesn't blank every derived sensor.
+const minVirtualInputAge = 5 * time.Minute
+
+// virtualReading is the latest value of one input sensor.
+type virtualReading struct {
+	value float64
+	unit  string
+	at    time.Time
+}
+
+// virtualDef is one row of virtual_sensors joined with its output sensor.
+type virtualDef struct {
+	id         int
+	sensorID   int
+	unit       string
+	function   string
+	inputs     []int
+	expression string
+	conversion string
+}
+
+// computeVirtualSensors evaluates every virtual sensor and stores one reading
+// for each that has fresh inputs. It runs once per poll cycle, after device
+// polling and computeZoneVPD, so derived values see this cycle's readings.
+//
+// Definitions are evaluated in id order and each result is visible to the
+// ones after it, so a virtual sensor may read another as long as the input
+// was created first.
+func (w *Watcher) computeVirtualSensors(_ context.Context) {
+	fieldLogger := w.Logger.WithField("func", "computeVirtualSensors")
+
+	defs, err := w.loadVirtualSensors()
+	if err != nil {
+		fieldLogger.WithError(err).Error("Failed to load virtual sensors")
+		return
+	}
+	if len(defs) == 0 {
+		return
+	}
+
+	now := w.Now().UTC()
+	maxAge := 3 * w.PollingInterval()
+	if maxAge < minVirtualInputAge {
+		maxAge = minVirtualInputAge
+	}
+
+	latest := map[int]*virtualReading{}
+	lookup := func(id int) (virtualReading, bool) {
+		r, ok := latest[id]
+		if !ok {
+			r = w.latestReading(id)
+			latest[id] = r
+		}
+		if r == nil || now.Sub(r.at) > maxAge {
+			return virtualReading{}, false
+		}
+		return *r, true
+	}
+
+	for _, d := range defs {
+		value, err := w.evalVirtualSensor(d, lookup, now, maxAge)
+		if err != nil {
+			fieldLogger.WithFields(logrus.Fields{
+				"sensor_id": d.sensorID,
+				"function":  d.function,
+			}).WithError(err).Debug("Skipping virtual sensor this cycle")
+			continue
+		}
+		if _, err := w.DB.Exec("INSERT INTO sensor_data (sensor_id, value) VALUES ($1, $2)", d.sensorID, value); err != nil {
+			fieldLogger.WithError(err).WithField("sensor_id", d.sensorID).Error("Failed to insert virtual sensor data")
+			continue
+		}
+		latest[d.sensorID] = &virtualReading{value: value, unit: d.unit, at: now}
+	}
+}
+
+// loadVirtualSensors reads every virtual sensor definition.
+func (w *Watcher) loadVirtualSensors() ([]virtualDef, error) {
+	rows, err := w.DB.Query(`
+		SELECT v.id, v.sensor_id, s.unit, v.function_name, v.inputs, v.expression, v.conversion
+		FROM virtual_sensors v
+		JOIN sensors s ON s.id = v.sensor_id
+		ORDER BY v.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var defs []virtualDef
+	for rows.Next() {
+		var d virtualDef
+		var inputs string
+		if err := rows.Scan(&d.id, &d.sensorID, &d.unit, &d.function, &inputs, &d.expression, &d.conversion); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(inputs), &d.inputs); err != nil {
+			w.Logger.WithError(err).WithField("virtual_sensor_id", d.id).Warn("Invalid virtual sensor inputs, skipping")
+			continue
+		}
+		defs = append(defs, d)
+	}
+	return defs, rows.Err()
+}
+
+// latestReading returns the newest reading of sensorID, or nil if it has none.
+func (w *Watcher) latestReading(sensorID int) *virtualReading {
+	var r virtualReading
+	err := w.DB.QueryRow(`
+		SELECT sd.value, s.unit, sd.create_dt
+		FROM sensor_data sd
+		JOIN sensors s ON s.id = sd.sensor_id
+		WHERE sd.sensor_id = $1
+		ORDER BY sd.create_dt DESC, sd.id DESC LIMIT 1`, sensorID).Scan(&r.value, &r.unit, &r.at)
+	if err != nil {
+		return nil
+	}
+	r.at = r.at.UTC()
+	return &r
+}
+
+// evalVirtualSensor computes one virtual sensor's value from its inputs.
+func (w *Watcher) evalVirtualSensor(d virtualDef, lookup func(int) (virtualReading, bool), now time.Time, maxAge time.Duration) (float64, error) {
+	need := func(n int) ([]virtualReading, error) {
+		if len(d.inputs) < n {
+			return nil, fmt.Errorf("needs %d inputs, has %d", n, len(d.inputs))
+		}
+		out := make([]virtualReading, n)
+		for i := 0; i < n; i++ {
+			r, ok := lookup(d.inputs[i])
+			if !ok {
+				return nil, fmt.Errorf("no recent reading for sensor %d", d.inputs[i])
+			}
+			out[i] = r
+		}
+		return out, nil
+	}
+
+	switch d.function {
+	case types.VirtualDewPoint, types.VirtualAbsHumidity:
+		in, err := need(2)
+		if err != nil {
+			return 0, err
+		}
+		fahrenheit := isFahrenheit(in[0].unit)
+		tempC := in[0].value
+		if fahrenheit {
+			tempC = utils.FahrenheitToCelsius(tempC)
+		}
+		if d.function == types.VirtualAbsHumidity {
+			return roundVirtual(utils.AbsoluteHumidity(tempC, in[1].value)), nil
+		}
+		dp := utils.DewPoint(tempC, in[1].value)
+		if fahrenheit {
+			dp = utils.CelsiusToFahrenheit(dp)
+		}
+		return roundVirtual(dp), nil
+
+	case types.VirtualDLI:
+		if len(d.inputs) < 1 {
+			return 0, fmt.Errorf("needs a PAR input")
+		}
+		return w.dailyLightIntegral(d.inputs[0], now, maxAge)
+
+	case types.VirtualMean, types.VirtualMin, types.VirtualMax:
+		var vals []float64
+		for _, id := range d.inputs {
+			if r, ok := lookup(id); ok {
+				vals = append(vals, r.value)
+			}
+		}
+		if len(vals) == 0 {
+			return 0, fmt.Errorf("no recent readings from any input")
+		}
+		out := vals[0]
+		for _, v := range vals[1:] {
+			switch d.function {
+			case types.VirtualMean:
+				out += v
+			case types.VirtualMin:
+				out = math.Min(out, v)
+			case types.VirtualMax:
+				out = math.Max(out, v)
+			}
+		}
+		if d.function == types.VirtualMean {
+			out /= float64(len(vals))
+		}
+		return roundVirtual(out), nil
+
+	case types.VirtualConvert:
+		conv, ok := types.UnitConversions[d.conversion]
+		if !ok {
+			return 0, fmt.Errorf("unknown conversion %q", d.conversion)
+		}
+		in, err := need(1)
+		if err != nil {
+			return 0, err
+		}
+		return roundVirtual(in[0].value*conv.Scale + conv.Offset), nil
+
+	case types.VirtualExpression:
+		expr, err := utils.CompileExpression(d.expression)
+		if err != nil {
+			return 0, err
+		}
+		vars := map[string]float64{}
+		for _, id := range expr.SensorIDs() {
+			r, ok := lookup(id)
+			if !ok {
+				return 0, fmt.Errorf("no recent reading for sensor %d", id)
+			}
+			vars["s"+strconv.Itoa(id)] = r.value
+		}
+		v, err := expr.Eval(vars)
+		if err != nil {
+			return 0, err
+		}
+		return roundVirtual(v), nil
+	}
+	return 0, fmt.Errorf("unknown function %q", d.function)
+}
+
+// dailyLightIntegral integrates a PAR sensor (µmol/m²/s) from local midnight
+// to now with the trapezoid rule and returns mol/m²/d so far today. Gaps
+// longer than maxAge are treated as no data rather than interpolated, so an
+// offline sensor doesn't inflate the total.
+func (w *Watcher) dailyLightIntegral(sensorID int, now time.Time, maxAge time.Duration) (float64, error) {
+	local := now.In(time.Local)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.Local).UTC()
+
+	rows, err := w.DB.Query(`
+		SELECT value, create_dt FROM sensor_data
+		WHERE sensor_id = $1 AND create_dt >= $2
+		ORDER BY create_dt`, sensorID, midnight.Format(utils.LayoutDB))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var (
+		total   float64
+		prevVal float64
+		prevAt  time.Time
+		n       int
+	)
+	for rows.Next() {
+		var v float64
+		var at time.Time
+		if err := rows.Scan(&v, &at); err != nil {
+			return 0, err
+		}
+		if v < 0 {
+			v = 0
+		}
+		if n > 0 {
+			if dt := at.Sub(prevAt); dt > 0 && dt <= maxAge {
+				total += (v + prevVal) / 2 * dt.Seconds()
+			}
+		}
+		prevVal, prevAt = v, at
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("no PAR readings today")
+	}
+	return roundVirtual(total / 1e6), nil
+}
+
+// isFahrenheit matches computeZoneVPD's rule: a temperature unit without a
+// "C" is treated as Fahrenheit.
+func isFahrenheit(unit string) bool {
+	return !strings.Contains(unit, "C")
+}
+
+// roundVirtual trims float noise from computed values before they're stored.
+func roundVirtual(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
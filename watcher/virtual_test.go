@@ -0,0 +1,146 @@
+package watcher
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"isley/tests/testutil"
+)
+
+// seedVirtualSensor creates a virtual output sensor plus its definition and
+// returns the output sensor id.
+func seedVirtualSensor(t *testing.T, db *sql.DB, function, inputs, expression, conversion string) int {
+	t.Helper()
+	out := testutil.SeedSensor(t, db, "virtual", "virtual", function)
+	testutil.MustExec(t, db,
+		`INSERT INTO virtual_sensors (sensor_id, function_name, inputs, expression, conversion) VALUES ($1, $2, $3, $4, $5)`,
+		out, function, inputs, expression, conversion,
+	)
+	return out
+}
+
+func latestValue(t *testing.T, db *sql.DB, sensorID int) float64 {
+	t.Helper()
+	var v float64
+	require.NoError(t, db.QueryRow(
+		`SELECT value FROM sensor_data WHERE sensor_id = $1 ORDER BY id DESC LIMIT 1`, sensorID,
+	).Scan(&v))
+	return v
+}
+
+// ---------------------------------------------------------------------------
+// computeVirtualSensors
+// ---------------------------------------------------------------------------
+
+func TestComputeVirtualSensors_DewPointKeepsFahrenheit(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	temp := seedSensor(t, db, "test", "dev", "temp")
+	hum := seedSensor(t, db, "test", "dev", "hum")
+	testutil.MustExec(t, db, `UPDATE sensors SET unit = '°F' WHERE id = $1`, temp)
+	testutil.MustExec(t, db, `UPDATE sensors SET unit = '%' WHERE id = $1`, hum)
+
+	now := time.Now().UTC()
+	insertReadingAt(t, db, temp, 77.0, now.Add(-time.Minute)) // 25°C
+	insertReadingAt(t, db, hum, 50.0, now.Add(-time.Minute))
+
+	dp := seedVirtualSensor(t, db, "dew_point", `[`+strconv.Itoa(temp)+`,`+strconv.Itoa(hum)+`]`, "", "")
+
+	w := newTestWatcher(t, db)
+	w.computeVirtualSensors(context.Background())
+
+	// 25°C / 50% → 13.9°C dew point → ~57°F.
+	assert.InDelta(t, 57.0, latestValue(t, db, dp), 0.5)
+}
+
+func TestComputeVirtualSensors_MeanIgnoresStaleInputs(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	a := seedSensor(t, db, "test", "dev", "a")
+	b := seedSensor(t, db, "test", "dev", "b")
+	c := seedSensor(t, db, "test", "dev", "c")
+
+	now := time.Now().UTC()
+	insertReadingAt(t, db, a, 20.0, now.Add(-time.Minute))
+	insertReadingAt(t, db, b, 24.0, now.Add(-time.Minute))
+	insertReadingAt(t, db, c, 90.0, now.Add(-2*time.Hour)) // offline probe
+
+	mean := seedVirtualSensor(t, db, "mean", `[`+strconv.Itoa(a)+`,`+strconv.Itoa(b)+`,`+strconv.Itoa(c)+`]`, "", "")
+	hi := seedVirtualSensor(t, db, "max", `[`+strconv.Itoa(a)+`,`+strconv.Itoa(b)+`,`+strconv.Itoa(c)+`]`, "", "")
+
+	w := newTestWatcher(t, db)
+	w.computeVirtualSensors(context.Background())
+
+	assert.InDelta(t, 22.0, latestValue(t, db, mean), 0.0001)
+	assert.InDelta(t, 24.0, latestValue(t, db, hi), 0.0001)
+}
+
+func TestComputeVirtualSensors_SkipsWhenAllInputsStale(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	a := seedSensor(t, db, "test", "dev", "a")
+	insertReadingAt(t, db, a, 20.0, time.Now().UTC().Add(-time.Hour))
+
+	conv := seedVirtualSensor(t, db, "convert", `[`+strconv.Itoa(a)+`]`, "", "c_to_f")
+
+	w := newTestWatcher(t, db)
+	w.computeVirtualSensors(context.Background())
+
+	assert.Zero(t, countSensorData(t, db, conv))
+}
+
+func TestComputeVirtualSensors_ExpressionChainsVirtualInputs(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	a := seedSensor(t, db, "test", "dev", "a")
+	insertReadingAt(t, db, a, 20.0, time.Now().UTC().Add(-time.Minute))
+
+	// The conversion is created first, so the expression sees this cycle's value.
+	conv := seedVirtualSensor(t, db, "convert", `[`+strconv.Itoa(a)+`]`, "", "c_to_f")
+	expr := seedVirtualSensor(t, db, "expression", `[`+strconv.Itoa(conv)+`]`, "s"+strconv.Itoa(conv)+" - 32", "")
+
+	w := newTestWatcher(t, db)
+	w.computeVirtualSensors(context.Background())
+
+	assert.InDelta(t, 68.0, latestValue(t, db, conv), 0.0001)
+	assert.InDelta(t, 36.0, latestValue(t, db, expr), 0.0001)
+}
+
+func TestComputeVirtualSensors_DLIIntegratesSinceMidnight(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	par := seedSensor(t, db, "test", "dev", "par")
+
+	// A fixed clock an hour and a half after local midnight keeps every
+	// reading inside "today" regardless of when the test runs.
+	local := time.Now()
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.Local)
+	now := midnight.Add(90 * time.Minute)
+
+	// 1000 µmol/m²/s held for one hour in one-minute steps = 3.6 mol/m².
+	start := midnight.Add(30 * time.Minute)
+	for i := 0; i <= 60; i++ {
+		insertReadingAt(t, db, par, 1000, start.Add(time.Duration(i)*time.Minute).UTC())
+	}
+	// Yesterday's light must not count.
+	insertReadingAt(t, db, par, 1000, midnight.Add(-time.Hour).UTC())
+
+	dli := seedVirtualSensor(t, db, "dli", `[`+strconv.Itoa(par)+`]`, "", "")
+
+	w := newTestWatcher(t, db)
+	w.Now = func() time.Time { return now }
+	w.computeVirtualSensors(context.Background())
+
+	assert.InDelta(t, 3.6, latestValue(t, db, dli), 0.001)
+}
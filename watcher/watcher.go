@@ -107,7 +107,8 @@ func New(db *sql.DB, store *config.Store) *Watcher {
 //
 // Each iteration:
 //  1. If a backup restore is in progress, do nothing this cycle.
-//  2. Otherwise poll AC Infinity and EcoWitt according to enabled flags.
+//  2. Otherwise poll AC Infinity and EcoWitt according to enabled flags,
+//     then compute zone VPD and virtual sensors from the fresh readings.
 //  3. Run prune and rollup if their tickers have fired.
 //  4. Sleep for PollingInterval, or return early if ctx is cancelled.
 func (w *Watcher) Run(ctx context.Context) {
@@ -140,6 +141,7 @@ func (w *Watcher) Run(ctx context.Context) {
 			}
 
 			w.computeZoneVPD(ctx)
+			w.computeVirtualSensors(ctx)
 
 			select {
 			case <-pruneTicker.C:
@@ -306,9 +308,12 @@ func (w *Watcher) PollACI(ctx context.Context, token string) {
 // sensor_data. Sensors that are not pre-registered in the sensors table
 // are silently skipped — that's how the UI lets users opt in to
 // tracking specific sensors. Non-numeric values are also silently
-// skipped after logging.
+// skipped after logging. The sensor's active calibration is applied
+// before the value is stored, and readings its type's filter rejects
+// are dropped or quarantined by model.FilterReading.
 func (w *Watcher) addSensorData(source string, device string, key string, value string) {
-	if _, err := strconv.ParseFloat(value, 64); err != nil {
+	raw, err := strconv.ParseFloat(value, 64)
+	if err != nil {
 		w.Logger.WithFields(logrus.Fields{
 			"source": source,
 			"device": device,
@@ -320,7 +325,7 @@ func (w *Watcher) addSensorData(source string, device string, key string, value
 	}
 
 	var sensorID int
-	err := w.DB.QueryRow("SELECT id FROM sensors WHERE source = $1 AND device = $2 AND type = $3", source, device, key).Scan(&sensorID)
+	err = w.DB.QueryRow("SELECT id FROM sensors WHERE source = $1 AND device = $2 AND type = $3", source, device, key).Scan(&sensorID)
 	if err != nil {
 		w.Logger.WithFields(logrus.Fields{
 			"source": source,
@@ -330,10 +335,14 @@ func (w *Watcher) addSensorData(source string, device string, key string, value
 		return
 	}
 
-	if _, err := w.DB.Exec("INSERT INTO sensor_data (sensor_id, value) VALUES ($1, $2)", sensorID, value); err != nil {
+	calibrated := model.ActiveCalibration(w.DB, sensorID).Apply(raw)
+	if reason := model.FilterReading(w.DB, sensorID, calibrated, w.Now()); reason != "" {
+		return
+	}
+	if _, err := w.DB.Exec("INSERT INTO sensor_data (sensor_id, value) VALUES ($1, $2)", sensorID, calibrated); err != nil {
 		w.Logger.WithFields(logrus.Fields{
 			"sensorID": sensorID,
-			"value":    value,
+			"value":    calibrated,
 			"error":    err,
 		}).Error("Error writing sensor data to database")
 	}
@@ -351,15 +360,18 @@ func (w *Watcher) PruneSensorData() error {
 		return nil
 	}
 
-	var pruneQuery string
-	if model.IsPostgres() {
-		pruneQuery = fmt.Sprintf("DELETE FROM sensor_data WHERE create_dt < NOW() - INTERVAL '%d days'", days)
-	} else {
-		pruneQuery = fmt.Sprintf("DELETE FROM sensor_data WHERE create_dt < datetime('now', 'localtime', '-%d days')", days)
-	}
-	if _, err := w.DB.Exec(pruneQuery); err != nil {
-		w.Logger.WithError(err).Error("Error pruning sensor data")
-		return err
+	// Quarantined readings follow the same retention as stored ones.
+	for _, table := range []string{"sensor_data", "sensor_data_quarantine"} {
+		var pruneQuery string
+		if model.IsPostgres() {
+			pruneQuery = fmt.Sprintf("DELETE FROM %s WHERE create_dt < NOW() - INTERVAL '%d days'", table, days)
+		} else {
+			pruneQuery = fmt.Sprintf("DELETE FROM %s WHERE create_dt < datetime('now', 'localtime', '-%d days')", table, days)
+		}
+		if _, err := w.DB.Exec(pruneQuery); err != nil {
+			w.Logger.WithError(err).WithField("table", table).Error("Error pruning sensor data")
+			return err
+		}
 	}
 	// rolling_averages is not pruned — it's a trigger-maintained cache with only
 	// one row per sensor, so it stays small and self-maintaining.
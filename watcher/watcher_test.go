@@ -101,6 +101,44 @@ func TestAddSensorData_WritesRow(t *testing.T) {
 	assert.InDelta(t, 23.5, v, 0.0001)
 }
 
+func TestAddSensorData_AppliesLatestCalibration(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	id := seedSensor(t, db, "test", "dev1", "temp")
+	testutil.MustExec(t, db, `INSERT INTO sensor_calibrations (sensor_id, scale, offset_value) VALUES ($1, 2, 0)`, id)
+	testutil.MustExec(t, db, `INSERT INTO sensor_calibrations (sensor_id, scale, offset_value) VALUES ($1, 1, -1.5)`, id)
+
+	w := newTestWatcher(t, db)
+	w.addSensorData("test", "dev1", "temp", "77.3")
+
+	var v float64
+	require.NoError(t, db.QueryRow(`SELECT value FROM sensor_data WHERE sensor_id = $1`, id).Scan(&v))
+	assert.InDelta(t, 75.8, v, 0.0001)
+}
+
+func TestAddSensorData_QuarantinesFilteredReading(t *testing.T) {
+	t.Parallel()
+
+	db := testutil.NewTestDB(t)
+	id := seedSensor(t, db, "test", "dev1", "humidity")
+	testutil.MustExec(t, db, `INSERT INTO sensor_filters (sensor_type, min_value, max_value, quarantine) VALUES ('humidity', 1, 100, TRUE)`)
+
+	w := newTestWatcher(t, db)
+	w.addSensorData("test", "dev1", "humidity", "0")
+	w.addSensorData("test", "dev1", "humidity", "55")
+
+	assert.Equal(t, 1, countSensorData(t, db, id), "only the in-range reading is stored")
+
+	var reason string
+	require.NoError(t, db.QueryRow(`SELECT reason FROM sensor_data_quarantine WHERE sensor_id = $1`, id).Scan(&reason))
+	assert.Equal(t, "below_min", reason)
+
+	var rejected int
+	require.NoError(t, db.QueryRow(`SELECT rejected_count FROM sensor_filters`).Scan(&rejected))
+	assert.Equal(t, 1, rejected)
+}
+
 func TestAddSensorData_RejectsNonNumeric(t *testing.T) {
 	t.Parallel()
 
@@ -4,6 +4,11 @@ document.addEventListener("DOMContentLoaded", () => {
     const deleteActivityButton = document.getElementById("deleteActivity");
     const activityTypeSelect = document.getElementById("editActivityType");
     const measurementsContainer = document.getElementById("editActivityMeasurements");
+    const feedingContainer = document.getElementById("editActivityFeeding");
+    // Only the plant page lists feeds with its activities (the activity log
+    // doesn't), so the feed section is only offered, and only sent back,
+    // when the row tells us whether it is a feed.
+    let feedingEditable = false;
 
     // Format a Date as a local datetime-local string (YYYY-MM-DDTHH:MM:SS)
     // without converting to UTC (unlike toISOString which shifts timezone).
@@ -17,7 +22,18 @@ document.addEventListener("DOMContentLoaded", () => {
         await activityMetrics.renderInputs(measurementsContainer, links, existingValues);
     }
 
-    activityTypeSelect.addEventListener("change", () => updateMeasurementInputs());
+    async function updateFeedingInputs(existingFeeding) {
+        if (feedingEditable && feedingForm.isFeedingSelected(activityTypeSelect)) {
+            await feedingForm.render(feedingContainer, existingFeeding);
+        } else {
+            feedingForm.clear(feedingContainer);
+        }
+    }
+
+    activityTypeSelect.addEventListener("change", () => {
+        updateMeasurementInputs();
+        updateFeedingInputs();
+    });
 
     document.querySelectorAll(".activity-row").forEach(row => {
         row.addEventListener("click", (e) => {
@@ -42,6 +58,9 @@ document.addEventListener("DOMContentLoaded", () => {
             }
             updateMeasurementInputs(existingValues);
 
+            feedingEditable = Object.prototype.hasOwnProperty.call(activityData, "is_feeding");
+            updateFeedingInputs(activityData.feeding);
+
             editActivityModal.show();
         });
     });
@@ -56,6 +75,11 @@ document.addEventListener("DOMContentLoaded", () => {
             note: document.getElementById("editActivityNote").value,
             measurements: activityMetrics.collectValues(measurementsContainer),
         };
+        if (feedingEditable) {
+            // An empty feed clears a previously recorded one, e.g. when the
+            // activity is changed to something that isn't a feeding.
+            payload.feeding = feedingForm.collect(feedingContainer) || { nutrients: [] };
+        }
 
         fetch("/plantActivity/edit", {
             method: "POST",
@@ -4,18 +4,47 @@ document.addEventListener("DOMContentLoaded", () => {
     const activityDateInput = document.getElementById("activityDate");
     const activitySelect = document.getElementById("activityName");
     const measurementsContainer = document.getElementById("addActivityMeasurements");
+    const feedingContainer = document.getElementById("addActivityFeeding");
 
     async function updateMeasurementInputs() {
         const links = activityMetrics.getLinksFromSelect(activitySelect);
         await activityMetrics.renderInputs(measurementsContainer, links);
     }
 
-    activitySelect.addEventListener("change", updateMeasurementInputs);
+    // Feeds get the recipe the plant's schedule calls for on the chosen day.
+    async function updateFeedingInputs() {
+        if (!feedingForm.isFeedingSelected(activitySelect)) {
+            feedingForm.clear(feedingContainer);
+            return;
+        }
+        await feedingForm.render(feedingContainer);
+
+        const plantId = document.getElementById("plantId").value;
+        const day = activityDateInput.value.slice(0, 10);
+        try {
+            const resp = await fetch(`/plant/${plantId}/feeding-plan?date=${encodeURIComponent(day)}`);
+            if (!resp.ok) return;
+            const plan = await resp.json();
+            if (plan.recipe) {
+                const source = `${plan.schedule_name} · ${plan.status} · ${uiMessages.t('title_week')} ${plan.week}`;
+                feedingForm.setRecipe(feedingContainer, plan.recipe.id, source);
+            }
+        } catch (e) {
+            console.error("Error loading feeding plan:", e);
+        }
+    }
+
+    activitySelect.addEventListener("change", () => {
+        updateMeasurementInputs();
+        updateFeedingInputs();
+    });
+    activityDateInput.addEventListener("change", updateFeedingInputs);
 
     // Set default date/time when the modal is shown
     addActivityModal.addEventListener("show.bs.modal", () => {
         formHelpers.setDateTimeNow("activityDate");
         updateMeasurementInputs();
+        updateFeedingInputs();
     });
 
     form.addEventListener("submit", (e) => {
@@ -34,6 +63,7 @@ document.addEventListener("DOMContentLoaded", () => {
             note: activityNote,
             date: date,
             measurements: activityMetrics.collectValues(measurementsContainer),
+            feeding: feedingForm.collect(feedingContainer),
         };
 
         // Send POST request to /plantActivity
@@ -17,6 +17,13 @@ document.addEventListener("DOMContentLoaded", () => {
     const addPlantModal = document.getElementById("addPlantModal");
     const isClone = document.getElementById("isClone");
     const decrementSeedCount = document.getElementById("decrementSeedCount");
+    const seedLots = seedLotPicker.attach({
+        wrapper: document.getElementById("seedLotPicker"),
+        lotSelect: document.getElementById("seedLotSelect"),
+        germinationWrapper: document.getElementById("germinationPicker"),
+        germinationSelect: document.getElementById("germinationSelect"),
+        decrement: decrementSeedCount,
+    });
 
     // Initialize autocomplete on strain and zone selects
     const zoneAC = new IsleyAutocomplete(zoneSelect, {
@@ -52,16 +59,24 @@ document.addEventListener("DOMContentLoaded", () => {
         parentPlantDropdown.classList.add("d-none");
     };
 
+    // Clones don't come from a seed lot.
     isClone.addEventListener("change", () => {
         if (isClone.checked) {
             parentPlantDropdown.classList.remove("d-none");
+            seedLots.reset();
         } else {
             parentPlantDropdown.classList.add("d-none");
             parentPlantSelect.innerHTML = '<option value="0">{{ .lcl.title_none }}</option>';
+            seedLots.load(strainSelect.value);
         }
     });
 
     strainSelect.addEventListener("change", () => {
+        if (isClone.checked) {
+            seedLots.reset();
+        } else {
+            seedLots.load(strainSelect.value);
+        }
         if (strainSelect.value === "new") {
             parentPlantDropdown.classList.add("d-none");
             parentPlantSelect.innerHTML = '<option value="0">{{ .lcl.title_none }}</option>';
@@ -111,6 +126,7 @@ document.addEventListener("DOMContentLoaded", () => {
     addPlantModal.addEventListener("show.bs.modal", () => {
         resetZoneSelection();
         resetStrainSelection();
+        seedLots.reset();
     });
 
     addPlantForm.addEventListener("submit", (e) => {
@@ -130,6 +146,7 @@ document.addEventListener("DOMContentLoaded", () => {
             clone: isClone.checked ? 1 : 0,
             parent_id: parseInt(parentPlantSelect.value, 10),
             decrement_seed_count: decrementSeedCount.checked,
+            ...seedLots.selection(),
         };
         fetch("/plants", {
             method: "POST",
@@ -0,0 +1,74 @@
+document.addEventListener("DOMContentLoaded", () => {
+    const page = document.getElementById("breedingPage");
+    if (!page) return;
+
+    function send(method, url, body) {
+        const options = { method, headers: { "Content-Type": "application/json" } };
+        if (body !== undefined) options.body = JSON.stringify(body);
+        return fetch(url, options)
+            .then(response => response.json().catch(() => ({})).then(data => {
+                if (!response.ok) throw new Error(data.error || response.statusText);
+                return data;
+            }))
+            .then(() => window.location.reload())
+            .catch(error => uiMessages.showToast(error.message, "danger"));
+    }
+
+    const today = formHelpers.formatDateTimeLocal(new Date()).slice(0, 10);
+
+    const pollinationForm = document.getElementById("pollinationForm");
+    if (pollinationForm) {
+        const f = pollinationForm.elements;
+        if (!f.pollination_date.value) f.pollination_date.value = today;
+        pollinationForm.addEventListener("submit", event => {
+            event.preventDefault();
+            send("POST", "/pollinations", {
+                female_plant_id: parseInt(f.female_plant_id.value, 10),
+                male_plant_id: parseInt(f.male_plant_id.value, 10) || null,
+                pollen_source: f.pollen_source.value,
+                pollination_date: f.pollination_date.value,
+                generation: f.generation.value,
+                project_id: parseInt(f.project_id.value, 10) || null,
+                notes: f.notes.value,
+            });
+        });
+    }
+
+    const projectForm = document.getElementById("breedingProjectForm");
+    if (projectForm) {
+        projectForm.addEventListener("submit", event => {
+            event.preventDefault();
+            const f = projectForm.elements;
+            send("POST", "/breeding/projects", { name: f.name.value, goal: f.goal.value });
+        });
+    }
+
+    // Harvested seeds go under an existing breeder, or a new one typed in
+    // when "add new breeder" is picked.
+    page.querySelectorAll(".seed-harvest-form").forEach(form => {
+        const f = form.elements;
+        if (!f.harvest_date.value) f.harvest_date.value = today;
+        const newBreeder = () => f.breeder_id.value === "new";
+        f.breeder_id.addEventListener("change", () => f.new_breeder.classList.toggle("d-none", !newBreeder()));
+        f.new_breeder.classList.toggle("d-none", !newBreeder());
+        form.addEventListener("submit", event => {
+            event.preventDefault();
+            send("POST", `/pollinations/${form.dataset.id}/harvest`, {
+                strain_name: f.strain_name.value,
+                breeder_id: newBreeder() ? null : parseInt(f.breeder_id.value, 10),
+                new_breeder: newBreeder() ? f.new_breeder.value : "",
+                seeds: parseInt(f.seeds.value, 10),
+                harvest_date: f.harvest_date.value,
+                feminized: f.feminized.value === "true",
+            });
+        });
+    });
+
+    page.querySelectorAll(".breeding-delete").forEach(button => {
+        button.addEventListener("click", async () => {
+            const message = button.dataset.confirm === "project" ? page.dataset.deleteProjectConfirm : page.dataset.deletePollinationConfirm;
+            if (!await uiMessages.showConfirm(message)) return;
+            send("DELETE", button.dataset.url);
+        });
+    });
+});
@@ -0,0 +1,59 @@
+document.addEventListener("DOMContentLoaded", () => {
+    const modal = document.getElementById("compareImagesModal");
+    if (!modal) return;
+
+    const leftSelect = document.getElementById("compareLeft");
+    const rightSelect = document.getElementById("compareRight");
+    const sideView = document.getElementById("compareSide");
+    const sliderView = document.getElementById("compareSlider");
+    const sideLeft = document.getElementById("compareSideLeft");
+    const sideRight = document.getElementById("compareSideRight");
+    const sliderLeft = document.getElementById("compareSliderLeft");
+    const sliderRight = document.getElementById("compareSliderRight");
+    const sliderHandle = document.getElementById("compareSliderHandle");
+    const sliderRange = document.getElementById("compareSliderRange");
+
+    const mediumSrc = id => `/plant/images/${encodeURIComponent(id)}/medium`;
+
+    function render() {
+        const left = mediumSrc(leftSelect.value);
+        const right = mediumSrc(rightSelect.value);
+        sideLeft.src = left;
+        sideRight.src = right;
+        sliderLeft.src = left;
+        sliderRight.src = right;
+    }
+
+    function setSlider(value) {
+        // Clip the "before" photo so it covers the left value% of the frame.
+        sliderLeft.style.clipPath = `inset(0 ${100 - value}% 0 0)`;
+        sliderHandle.style.left = `${value}%`;
+    }
+
+    function setMode(mode) {
+        sideView.classList.toggle("d-none", mode !== "side");
+        sliderView.classList.toggle("d-none", mode !== "slider");
+    }
+
+    // Callers may preselect a pair by setting data-left / data-right on the
+    // modal before it opens; otherwise the oldest and newest photos are
+    // compared (options are listed newest first).
+    modal.addEventListener("show.bs.modal", () => {
+        const options = leftSelect.options;
+        if (!options.length) return;
+        leftSelect.value = modal.dataset.left || options[options.length - 1].value;
+        rightSelect.value = modal.dataset.right || options[0].value;
+        delete modal.dataset.left;
+        delete modal.dataset.right;
+        render();
+    });
+
+    leftSelect.addEventListener("change", render);
+    rightSelect.addEventListener("change", render);
+    sliderRange.addEventListener("input", () => setSlider(parseInt(sliderRange.value, 10)));
+    document.querySelectorAll('input[name="compareMode"]').forEach(radio => {
+        radio.addEventListener("change", () => setMode(radio.value));
+    });
+
+    setSlider(parseInt(sliderRange.value, 10));
+});
@@ -10,16 +10,20 @@ document.addEventListener("DOMContentLoaded", () => {
     const text2dropdown = document.getElementById("text2Dropdown");
     const prevButton = document.getElementById("prevImage");
     const nextButton = document.getElementById("nextImage");
+    const tagsInput = document.getElementById("imageTagsInput");
+    const saveTagsButton = document.getElementById("saveImageTags");
 
     let currentImageIndex = 0;
     const images = Array.from(document.querySelectorAll(".thumbnail-img"));
 
     function loadImage(index) {
         const img = images[index];
-        modalImage.src = img.dataset.image;
+        // Show the medium variant; decoration still works on the original.
+        modalImage.src = img.dataset.medium || img.dataset.image;
         originalImageSrc.src = img.dataset.image;
         modalDateInput.value = img.dataset.date;
         imageIdInput.value = img.dataset.id;
+        if (tagsInput) tagsInput.value = img.dataset.tags || "";
 
         const plantStartDate = new Date(modalStartDate.value);
         const modalDate = new Date(img.dataset.date);
@@ -77,8 +81,50 @@ document.addEventListener("DOMContentLoaded", () => {
     prevButton.addEventListener("click", showPrevImage);
     nextButton.addEventListener("click", showNextImage);
 
+    // Tags are edited as a comma-separated list; known tags are offered
+    // as suggestions.
+    if (saveTagsButton) {
+        fetch("/image-tags")
+            .then((response) => response.json())
+            .then((tags) => {
+                const options = document.getElementById("imageTagOptions");
+                tags.forEach((tag) => {
+                    const option = document.createElement("option");
+                    option.value = tag.name;
+                    options.appendChild(option);
+                });
+            })
+            .catch((error) => console.error("Error loading image tags:", error));
+
+        saveTagsButton.addEventListener("click", () => {
+            const img = images[currentImageIndex];
+            const tags = tagsInput.value.split(",").map((t) => t.trim()).filter(Boolean);
+            const request = fetch(`/plant/images/${img.dataset.id}/tags`, {
+                method: "PUT",
+                headers: { "Content-Type": "application/json" },
+                body: JSON.stringify({ tags }),
+            })
+                .then((response) => response.json().then((data) => ({ ok: response.ok, data })))
+                .then(({ ok, data }) => {
+                    if (!ok) {
+                        uiMessages.showToast(data.error || uiMessages.t('error_saving_image_tags'), 'danger');
+                        return;
+                    }
+                    img.dataset.tags = data.tags.map((t) => t.name).join(", ");
+                    tagsInput.value = img.dataset.tags;
+                    uiMessages.showToast(data.message, 'success');
+                })
+                .catch((error) => {
+                    console.error("Error saving image tags:", error);
+                    uiMessages.showToast(uiMessages.t('error_saving_image_tags'), 'danger');
+                });
+            formHelpers.withLoading(saveTagsButton, request);
+        });
+    }
+
     // Add arrow key navigation
     document.addEventListener("keydown", (e) => {
+        if (e.target === tagsInput) return; // arrows move the caret while typing tags
         if (imageModal.classList.contains("show")) { // Only respond if modal is open
             if (e.key === "ArrowLeft") {
                 showPrevImage(); // Left arrow key
@@ -240,6 +286,15 @@ document.addEventListener("DOMContentLoaded", () => {
     // Attach delete-image button listener (replaces inline onclick)
     const delBtn = document.getElementById("btnDeleteImage");
     if (delBtn) delBtn.addEventListener("click", confirmDeleteImage);
+
+    // Timelapse videos don't open the modal; their cards carry their own
+    // delete button that reuses the same confirm flow.
+    document.querySelectorAll(".gallery-video-delete").forEach((btn) => {
+        btn.addEventListener("click", () => {
+            imageIdInput.value = btn.dataset.id;
+            confirmDeleteImage();
+        });
+    });
 });
 
 function confirmDeleteImage() {
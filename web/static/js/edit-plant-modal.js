@@ -71,7 +71,7 @@ document.addEventListener("DOMContentLoaded", () => {
             } : null,
             clone: document.getElementById("cloneCheckbox").checked,
             start_date: startDate,
-            harvest_weight: parseFloat(harvestWeight.value),
+            harvest_weight: formHelpers.weightToGrams(harvestWeight),
         };
 
         fetch("/plant", {
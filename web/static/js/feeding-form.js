@@ -0,0 +1,223 @@
+/**
+ * Shared feed section for the activity modals: a recipe picker, the volume
+ * mixed and the amount of each product, scaled from the recipe's per-litre
+ * amounts. The amounts stay editable, and whatever they hold is what gets
+ * recorded.
+ *
+ * Recipes are fetched from GET /nutrients/recipes on first use.
+ */
+const feedingForm = (() => {
+    let _recipesCache = null;
+
+    async function loadRecipes() {
+        if (_recipesCache) return _recipesCache;
+        try {
+            const resp = await fetch('/nutrients/recipes');
+            _recipesCache = resp.ok ? await resp.json() : [];
+        } catch (e) {
+            _recipesCache = [];
+        }
+        return _recipesCache;
+    }
+
+    /**
+     * Whether the activity picked in select is flagged as a feeding.
+     * @param {HTMLSelectElement} select
+     * @returns {boolean}
+     */
+    function isFeedingSelected(select) {
+        const opt = select.options[select.selectedIndex];
+        return !!opt && opt.dataset.feeding === 'true';
+    }
+
+    function round(v) {
+        return Math.round(v * 100) / 100;
+    }
+
+    function amountRow(productId, label, value) {
+        const row = document.createElement('div');
+        row.className = 'input-group input-group-sm mb-1';
+
+        const name = document.createElement('span');
+        name.className = 'input-group-text feed-product-name';
+        name.textContent = label;
+
+        const input = document.createElement('input');
+        input.type = 'number';
+        input.step = 'any';
+        input.min = '0';
+        input.className = 'form-control';
+        input.dataset.productId = productId;
+        input.setAttribute('aria-label', label);
+        if (value !== undefined && value !== null) input.value = value;
+
+        row.appendChild(name);
+        row.appendChild(input);
+        return row;
+    }
+
+    function recipeById(container, id) {
+        return (container._recipes || []).find(r => r.id === id);
+    }
+
+    // Rebuilds the product rows from the picked recipe, scaled to the volume.
+    function rescale(container) {
+        const recipe = recipeById(container, parseInt(container._recipeSelect.value, 10));
+        const volume = parseFloat(container._volumeInput.value);
+        const amounts = container._amounts;
+        const target = container._target;
+        amounts.innerHTML = '';
+        target.textContent = '';
+        if (!recipe) return;
+
+        recipe.items.forEach(item => {
+            const value = isNaN(volume) ? '' : round(item.amount_per_l * volume);
+            amounts.appendChild(amountRow(item.product_id, `${item.product_name} (${item.unit})`, value));
+        });
+        const targets = [];
+        if (recipe.target_ec !== null) targets.push(`EC ${recipe.target_ec}`);
+        if (recipe.target_ph !== null) targets.push(`pH ${recipe.target_ph}`);
+        if (targets.length) target.textContent = `${uiMessages.t('feed_target')}: ${targets.join(' · ')}`;
+    }
+
+    /**
+     * Render the feed section into container.
+     * @param {HTMLElement} container - The DOM element to render into.
+     * @param {Object} [feeding] - A recorded feed ({recipe_id, volume_l, nutrients}) to pre-fill (edit mode).
+     */
+    async function render(container, feeding) {
+        container.innerHTML = '';
+        const recipes = await loadRecipes();
+        container._recipes = recipes;
+
+        const wrap = document.createElement('fieldset');
+        wrap.className = 'feed-section mb-3';
+        const legend = document.createElement('legend');
+        legend.className = 'form-label fs-6';
+        legend.textContent = uiMessages.t('feed_title');
+        wrap.appendChild(legend);
+
+        const row = document.createElement('div');
+        row.className = 'row g-2 mb-2';
+
+        const recipeCol = document.createElement('div');
+        recipeCol.className = 'col-7';
+        const recipeSelect = document.createElement('select');
+        recipeSelect.className = 'form-select form-select-sm';
+        recipeSelect.setAttribute('aria-label', uiMessages.t('feed_recipe'));
+        const none = document.createElement('option');
+        none.value = '';
+        none.textContent = uiMessages.t('feed_no_recipe');
+        recipeSelect.appendChild(none);
+        recipes.forEach(r => {
+            const opt = document.createElement('option');
+            opt.value = r.id;
+            opt.textContent = r.name;
+            recipeSelect.appendChild(opt);
+        });
+        recipeCol.appendChild(recipeSelect);
+
+        const volumeCol = document.createElement('div');
+        volumeCol.className = 'col-5';
+        const volumeGroup = document.createElement('div');
+        volumeGroup.className = 'input-group input-group-sm';
+        const volumeInput = document.createElement('input');
+        volumeInput.type = 'number';
+        volumeInput.step = 'any';
+        volumeInput.min = '0';
+        volumeInput.className = 'form-control';
+        volumeInput.placeholder = uiMessages.t('feed_volume');
+        volumeInput.setAttribute('aria-label', uiMessages.t('feed_volume'));
+        const litres = document.createElement('span');
+        litres.className = 'input-group-text';
+        litres.textContent = 'L';
+        volumeGroup.appendChild(volumeInput);
+        volumeGroup.appendChild(litres);
+        volumeCol.appendChild(volumeGroup);
+
+        row.appendChild(recipeCol);
+        row.appendChild(volumeCol);
+        wrap.appendChild(row);
+
+        const plan = document.createElement('div');
+        plan.className = 'small text-muted mb-1';
+        const amounts = document.createElement('div');
+        const target = document.createElement('div');
+        target.className = 'small text-muted';
+        wrap.appendChild(plan);
+        wrap.appendChild(amounts);
+        wrap.appendChild(target);
+        container.appendChild(wrap);
+
+        container._recipeSelect = recipeSelect;
+        container._volumeInput = volumeInput;
+        container._amounts = amounts;
+        container._target = target;
+        container._plan = plan;
+
+        recipeSelect.addEventListener('change', () => rescale(container));
+        volumeInput.addEventListener('input', () => rescale(container));
+
+        if (feeding) {
+            if (feeding.recipe_id !== null && recipeById(container, feeding.recipe_id)) {
+                recipeSelect.value = feeding.recipe_id;
+            }
+            if (feeding.volume_l !== null) volumeInput.value = feeding.volume_l;
+            // Show what was recorded rather than rescaling the recipe, which
+            // may have changed since.
+            (feeding.nutrients || []).forEach(n => {
+                if (n.product_id === null) return;
+                amounts.appendChild(amountRow(n.product_id, `${n.name} (${n.unit})`, n.amount));
+            });
+        }
+    }
+
+    /**
+     * Pick a recipe (e.g. the one the plant's schedule calls for) and note
+     * where it came from.
+     * @param {HTMLElement} container
+     * @param {number} recipeId
+     * @param {string} [source] - Text shown above the amounts.
+     */
+    function setRecipe(container, recipeId, source) {
+        if (!container._recipeSelect || !recipeById(container, recipeId)) return;
+        container._recipeSelect.value = recipeId;
+        container._plan.textContent = source || '';
+        rescale(container);
+    }
+
+    /**
+     * Read the feed section back as the feeding payload, or null when it
+     * isn't rendered.
+     * @param {HTMLElement} container
+     * @returns {Object|null}
+     */
+    function collect(container) {
+        if (!container._recipeSelect) return null;
+        const recipeId = parseInt(container._recipeSelect.value, 10);
+        const volume = parseFloat(container._volumeInput.value);
+        const nutrients = [];
+        container._amounts.querySelectorAll('input[data-product-id]').forEach(input => {
+            const amount = parseFloat(input.value);
+            if (!isNaN(amount) && amount > 0) {
+                nutrients.push({ product_id: parseInt(input.dataset.productId, 10), amount: amount });
+            }
+        });
+        return {
+            recipe_id: isNaN(recipeId) ? null : recipeId,
+            volume_l: isNaN(volume) ? null : volume,
+            nutrients: nutrients,
+        };
+    }
+
+    /**
+     * Remove the feed section.
+     * @param {HTMLElement} container
+     */
+    function clear(container) {
+        container.innerHTML = '';
+        container._recipeSelect = null;
+    }
+
+    return { isFeedingSelected, render, setRecipe, collect, clear };
+})();
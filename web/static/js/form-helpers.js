@@ -82,5 +82,20 @@
         if (el) el.value = formatDateTimeLocal(new Date());
     }
 
-    window.formHelpers = { withLoading, setFieldError, clearFieldError, clearFormErrors, formatDateTimeLocal, setDateTimeNow };
+    /**
+     * Read a harvest weight input shown in the configured weight unit and
+     * return grams. The input carries data-grams (the stored value) and
+     * data-grams-per-unit; an untouched input returns data-grams as-is so
+     * saving other fields doesn't drift the weight through rounding.
+     */
+    function weightToGrams(input) {
+        if (!input) return 0;
+        if (input.value === input.defaultValue && input.dataset.grams !== undefined) {
+            return parseFloat(input.dataset.grams) || 0;
+        }
+        const factor = parseFloat(input.dataset.gramsPerUnit) || 1;
+        return (parseFloat(input.value) || 0) * factor;
+    }
+
+    window.formHelpers = { withLoading, setFieldError, clearFieldError, clearFormErrors, formatDateTimeLocal, setDateTimeNow, weightToGrams };
 })();
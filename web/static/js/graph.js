@@ -67,36 +67,71 @@ document.addEventListener("DOMContentLoaded", () => {
         return 'day';
     };
 
-    const fetchAndRenderData = (queryParams) => {
-        Promise.all(sensorIds.map(id =>
-            retryFetch(`/sensorData?sensor=${id}&${queryParams}`)
-                .then(data => {
-                    // Cache sensor names from data
-                    if (data.length > 0 && data[0].sensor_name) {
-                        sensorNamesCache[id] = data[0].sensor_name;
-                    }
-                    return { id, data };
-                })
-        ))
-        .then(results => {
-            const datasets = results.map((result, index) => {
-                const formattedData = result.data.map(item => ({
-                    x: new Date(item.create_dt),
-                    y: item.value
-                }));
+    // Target bucket count for server-side downsampling: roughly one bucket
+    // per two horizontal pixels, clamped server-side.
+    const chartPoints = () => Math.max(100, Math.round(ctx.canvas.clientWidth / 2));
 
+    const fetchAndRenderData = (queryParams) => {
+        retryFetch(`/sensorData/chart?sensors=${sensorIds.join(',')}&${queryParams}&points=${chartPoints()}`)
+        .then(resp => {
+            const timestamps = resp.timestamps.map(t => new Date(t));
+            const datasets = [];
+            const units = [];
+
+            resp.series.forEach((series, index) => {
+                sensorNamesCache[series.sensor_id] = series.name;
+                const unit = series.unit || '';
+                if (!units.includes(unit)) units.push(unit);
+                const axisID = units.indexOf(unit) === 0 ? 'y' : `y${units.indexOf(unit)}`;
                 const hue = (index * 60) % 360;
-
-                return {
-                    label: `Sensor: ${sensorNamesCache[result.id] || 'Unknown'}`,
-                    data: formattedData,
+                const toPoints = (values) => values.map((v, i) => ({ x: timestamps[i], y: v }));
+
+                // Min/max envelope: the max line fills down to the min line
+                // pushed just before it.
+                datasets.push({
+                    label: `${series.name} (min)`,
+                    data: toPoints(series.min),
+                    yAxisID: axisID,
+                    borderWidth: 0,
+                    pointRadius: 0,
+                    fill: false,
+                    envelope: true,
+                });
+                datasets.push({
+                    label: `${series.name} (max)`,
+                    data: toPoints(series.max),
+                    yAxisID: axisID,
+                    borderWidth: 0,
+                    pointRadius: 0,
+                    backgroundColor: `hsl(${hue} 70% 50% / 0.15)`,
+                    fill: '-1',
+                    envelope: true,
+                });
+                datasets.push({
+                    label: unit ? `${series.name} (${unit})` : series.name,
+                    data: toPoints(series.values),
+                    yAxisID: axisID,
                     borderColor: `hsl(${hue} 70% 50%)`,
                     backgroundColor: `hsl(${hue} 70% 90% / 0.6)`,
                     borderWidth: 2,
-                    pointRadius: 2,
+                    pointRadius: 0,
                     pointHoverRadius: 5,
                     tension: 0.3,
-                    spanGaps: true,
+                    spanGaps: false,
+                });
+            });
+
+            document.getElementById('sensorName').textContent =
+                resp.series.map(s => s.name).join(', ');
+
+            // One y axis per distinct unit so e.g. temperature, humidity and
+            // VPD can share a chart without flattening each other.
+            const yScales = {};
+            units.forEach((unit, i) => {
+                yScales[i === 0 ? 'y' : `y${i}`] = {
+                    position: i % 2 === 0 ? 'left' : 'right',
+                    title: { display: true, text: unit || 'Sensor Value' },
+                    grid: { color: 'rgba(255,255,255,0.03)', drawOnChartArea: i === 0 },
                 };
             });
 
@@ -116,11 +151,15 @@ document.addEventListener("DOMContentLoaded", () => {
                     plugins: {
                         legend: {
                             position: 'top',
-                            labels: { usePointStyle: true }
+                            labels: {
+                                usePointStyle: true,
+                                filter: (item, data) => !data.datasets[item.datasetIndex].envelope,
+                            }
                         },
                         tooltip: {
                             mode: 'index',
                             intersect: false,
+                            filter: (item) => !item.dataset.envelope && item.parsed.y !== null,
                             callbacks: {
                                 title: (items) => {
                                     if (!items || items.length === 0) return '';
@@ -161,10 +200,7 @@ document.addEventListener("DOMContentLoaded", () => {
                             ticks: { autoSkip: true, maxRotation: 0, autoSkipPadding: 10 },
                             grid: { color: 'rgba(255,255,255,0.05)' }
                         },
-                        y: {
-                            title: { display: true, text: 'Sensor Value' },
-                            grid: { color: 'rgba(255,255,255,0.03)' },
-                        }
+                        ...yScales,
                     }
                 }
             });
@@ -0,0 +1,126 @@
+document.addEventListener("DOMContentLoaded", () => {
+    const page = document.getElementById("harvestPage");
+    if (!page) return;
+
+    const plantId = page.dataset.plantId;
+    const gramsPerUnit = parseFloat(page.dataset.gramsPerUnit) || 1;
+
+    // Weights are typed in the display unit and stored in grams.
+    function grams(value) {
+        return Math.round(parseFloat(value) * gramsPerUnit * 1000) / 1000;
+    }
+
+    // Reads a number input, returning null when it is left empty.
+    function optionalNumber(input, isWeight) {
+        if (!input || input.value.trim() === "") return null;
+        return isWeight ? grams(input.value) : parseFloat(input.value);
+    }
+
+    function send(method, url, body) {
+        const options = { method, headers: { "Content-Type": "application/json" } };
+        if (body !== undefined) options.body = JSON.stringify(body);
+        return fetch(url, options)
+            .then(response => response.json().catch(() => ({})).then(data => {
+                if (!response.ok) throw new Error(data.error || response.statusText);
+                return data;
+            }))
+            .then(() => window.location.reload())
+            .catch(error => uiMessages.showToast(error.message, "danger"));
+    }
+
+    const harvestForm = document.getElementById("harvestForm");
+    if (harvestForm) {
+        harvestForm.addEventListener("submit", event => {
+            event.preventDefault();
+            const f = harvestForm.elements;
+            send("PUT", `/plant/${plantId}/harvest`, {
+                harvest_date: f.harvest_date.value,
+                wet_weight: optionalNumber(f.wet_weight, true),
+                trim_weight: optionalNumber(f.trim_weight, true),
+                dry_weight: optionalNumber(f.dry_weight, true),
+                light_watts: optionalNumber(f.light_watts, false),
+                canopy_area: optionalNumber(f.canopy_area, false),
+                dry_temp_sensor_id: parseInt(f.dry_temp_sensor_id.value, 10) || null,
+                dry_humidity_sensor_id: parseInt(f.dry_humidity_sensor_id.value, 10) || null,
+                notes: f.notes.value,
+            });
+        });
+    }
+
+    const deleteButton = document.getElementById("harvestDeleteBtn");
+    if (deleteButton) {
+        deleteButton.addEventListener("click", async () => {
+            if (!await uiMessages.showConfirm(page.dataset.deleteConfirm)) return;
+            send("DELETE", `/plant/${plantId}/harvest`);
+        });
+    }
+
+    document.querySelectorAll(".harvest-delete").forEach(button => {
+        button.addEventListener("click", async () => {
+            if (button.dataset.confirm === "jar" && !await uiMessages.showConfirm(page.dataset.deleteJarConfirm)) return;
+            send("DELETE", button.dataset.url);
+        });
+    });
+
+    const dryLogForm = document.getElementById("dryLogForm");
+    if (dryLogForm) {
+        dryLogForm.addEventListener("submit", event => {
+            event.preventDefault();
+            const f = dryLogForm.elements;
+            send("POST", `/plant/${plantId}/harvest/dry-logs`, {
+                date: f.date.value,
+                weight: grams(f.weight.value),
+                notes: f.notes.value,
+            });
+        });
+    }
+
+    const jarForm = document.getElementById("jarForm");
+    if (jarForm) {
+        jarForm.addEventListener("submit", event => {
+            event.preventDefault();
+            const f = jarForm.elements;
+            send("POST", `/plant/${plantId}/harvest/jars`, {
+                name: f.name.value,
+                sealed_date: f.sealed_date.value,
+                weight: optionalNumber(f.weight, true),
+                notes: f.notes.value,
+            });
+        });
+    }
+
+    document.querySelectorAll(".jar-log-form").forEach(form => {
+        form.addEventListener("submit", event => {
+            event.preventDefault();
+            const f = form.elements;
+            send("POST", `/harvest/jars/${form.dataset.jarId}/logs`, {
+                date: f.date.value,
+                burped: f.burped.checked,
+                humidity: optionalNumber(f.humidity, false),
+                notes: f.notes.value,
+            });
+        });
+    });
+
+    const yieldForm = document.getElementById("yieldForm");
+    if (yieldForm) {
+        const rows = document.getElementById("yieldRows");
+        const template = document.getElementById("yieldRowTemplate");
+
+        rows.addEventListener("click", event => {
+            const remove = event.target.closest(".yield-remove");
+            if (remove) remove.closest(".yield-row").remove();
+        });
+        document.getElementById("yieldAddBtn").addEventListener("click", () => {
+            rows.appendChild(template.content.cloneNode(true));
+        });
+        yieldForm.addEventListener("submit", event => {
+            event.preventDefault();
+            const yields = Array.from(rows.querySelectorAll(".yield-row")).map(row => ({
+                grade: row.querySelector("[name=grade]").value,
+                weight: grams(row.querySelector("[name=weight]").value),
+            }));
+            send("PUT", `/plant/${plantId}/harvest/yields`, { yields });
+        });
+    }
+});
@@ -4,18 +4,31 @@ document.addEventListener("DOMContentLoaded", () => {
     const activityMultiDateInput = document.getElementById("activityMultiDate");
     const activitySelect = document.getElementById("activityMultiName");
     const measurementsContainer = document.getElementById("multiActivityMeasurements");
+    const feedingContainer = document.getElementById("multiActivityFeeding");
 
     async function updateMeasurementInputs() {
         const links = activityMetrics.getLinksFromSelect(activitySelect);
         await activityMetrics.renderInputs(measurementsContainer, links);
     }
 
-    activitySelect.addEventListener("change", updateMeasurementInputs);
+    async function updateFeedingInputs() {
+        if (feedingForm.isFeedingSelected(activitySelect)) {
+            await feedingForm.render(feedingContainer);
+        } else {
+            feedingForm.clear(feedingContainer);
+        }
+    }
+
+    activitySelect.addEventListener("change", () => {
+        updateMeasurementInputs();
+        updateFeedingInputs();
+    });
 
     // Set default date/time when the modal is shown
     addMultiPlantActivityModal.addEventListener("show.bs.modal", () => {
         formHelpers.setDateTimeNow("activityMultiDate");
         updateMeasurementInputs();
+        updateFeedingInputs();
     });
 
     form.addEventListener("submit", (e) => {
@@ -31,6 +44,7 @@ document.addEventListener("DOMContentLoaded", () => {
             note: document.getElementById("activityMultiNote").value,
             date: document.getElementById("activityMultiDate").value,
             measurements: activityMetrics.collectValues(measurementsContainer),
+            feeding: feedingForm.collect(feedingContainer),
         };
 
         // Send POST request to backend
@@ -0,0 +1,151 @@
+document.addEventListener("DOMContentLoaded", () => {
+    const page = document.getElementById("nutrientsPage");
+    if (!page) return;
+
+    function send(method, url, body) {
+        const options = { method, headers: { "Content-Type": "application/json" } };
+        if (body !== undefined) options.body = JSON.stringify(body);
+        return fetch(url, options)
+            .then(response => response.json().catch(() => ({})).then(data => {
+                if (!response.ok) throw new Error(data.error || response.statusText);
+                return data;
+            }));
+    }
+
+    function fail(error) {
+        uiMessages.showToast(error.message, "danger");
+    }
+
+    // Reads a number input, returning null when it is left empty.
+    function optionalNumber(input) {
+        if (input.value.trim() === "") return null;
+        return parseFloat(input.value);
+    }
+
+    // Appends a row cloned from the form's <template> and fills in values,
+    // keyed by the name of each field.
+    function addRow(form, values) {
+        const rows = form.querySelector(".nutrient-rows");
+        const template = document.getElementById(rows.dataset.template);
+        const row = template.content.firstElementChild.cloneNode(true);
+        Object.entries(values || {}).forEach(([name, value]) => {
+            const field = row.querySelector(`[name=${name}]`);
+            if (field) field.value = value;
+        });
+        rows.appendChild(row);
+    }
+
+    function clearRows(form) {
+        const rows = form.querySelector(".nutrient-rows");
+        if (rows) rows.innerHTML = "";
+    }
+
+    function rowValues(form, parse) {
+        return Array.from(form.querySelectorAll(".nutrient-row")).map(parse);
+    }
+
+    // Each form turns its fields into the request body.
+    const payloads = {
+        productForm: f => ({
+            name: f.name.value,
+            brand: f.brand.value,
+            n: parseFloat(f.n.value) || 0,
+            p: parseFloat(f.p.value) || 0,
+            k: parseFloat(f.k.value) || 0,
+            dose_per_l: optionalNumber(f.dose_per_l),
+            unit: f.unit.value,
+            notes: f.notes.value,
+        }),
+        recipeForm: (f, form) => ({
+            name: f.name.value,
+            target_ec: optionalNumber(f.target_ec),
+            target_ph: optionalNumber(f.target_ph),
+            notes: f.notes.value,
+            items: rowValues(form, row => ({
+                product_id: parseInt(row.querySelector("[name=product_id]").value, 10),
+                amount_per_l: parseFloat(row.querySelector("[name=amount_per_l]").value) || 0,
+            })),
+        }),
+        scheduleForm: (f, form) => ({
+            name: f.name.value,
+            notes: f.notes.value,
+            weeks: rowValues(form, row => ({
+                status_id: parseInt(row.querySelector("[name=status_id]").value, 10),
+                week: parseInt(row.querySelector("[name=week]").value, 10) || 0,
+                recipe_id: parseInt(row.querySelector("[name=recipe_id]").value, 10) || 0,
+            })),
+        }),
+    };
+
+    // Each form is filled from the JSON of the row being edited.
+    const fillers = {
+        productForm: (f, data) => {
+            f.name.value = data.name;
+            f.brand.value = data.brand;
+            f.n.value = data.n;
+            f.p.value = data.p;
+            f.k.value = data.k;
+            f.dose_per_l.value = data.dose_per_l === null ? "" : data.dose_per_l;
+            f.unit.value = data.unit;
+            f.notes.value = data.notes;
+        },
+        recipeForm: (f, data, form) => {
+            f.name.value = data.name;
+            f.target_ec.value = data.target_ec === null ? "" : data.target_ec;
+            f.target_ph.value = data.target_ph === null ? "" : data.target_ph;
+            f.notes.value = data.notes;
+            data.items.forEach(item => addRow(form, { product_id: item.product_id, amount_per_l: item.amount_per_l }));
+        },
+        scheduleForm: (f, data, form) => {
+            f.name.value = data.name;
+            f.notes.value = data.notes;
+            data.weeks.forEach(w => addRow(form, { status_id: w.status_id, week: w.week, recipe_id: w.recipe_id }));
+        },
+    };
+
+    document.querySelectorAll(".nutrient-form").forEach(form => {
+        form.addEventListener("submit", event => {
+            event.preventDefault();
+            const f = form.elements;
+            const id = f.id.value;
+            const method = id ? "PUT" : "POST";
+            const url = id ? `${form.dataset.url}/${id}` : form.dataset.url;
+            send(method, url, payloads[form.id](f, form))
+                .then(() => window.location.reload())
+                .catch(fail);
+        });
+
+        // Clearing the form also leaves edit mode.
+        form.addEventListener("reset", () => {
+            clearRows(form);
+            form.elements.id.value = "";
+        });
+
+        const addButton = form.querySelector(".nutrient-add-row");
+        if (addButton) addButton.addEventListener("click", () => addRow(form));
+        form.addEventListener("click", event => {
+            const remove = event.target.closest(".nutrient-remove-row");
+            if (remove) remove.closest(".nutrient-row").remove();
+        });
+    });
+
+    document.querySelectorAll(".nutrient-edit").forEach(button => {
+        button.addEventListener("click", () => {
+            const form = document.getElementById(button.dataset.form);
+            const data = JSON.parse(button.dataset.json);
+            form.reset();
+            form.elements.id.value = data.id;
+            fillers[form.id](form.elements, data, form);
+            form.scrollIntoView({ behavior: "smooth", block: "center" });
+        });
+    });
+
+    document.querySelectorAll(".nutrient-delete").forEach(button => {
+        button.addEventListener("click", async () => {
+            if (!await uiMessages.showConfirm(page.dataset.deleteConfirm)) return;
+            send("DELETE", button.dataset.url)
+                .then(() => window.location.reload())
+                .catch(fail);
+        });
+    });
+});
@@ -0,0 +1,62 @@
+document.addEventListener("DOMContentLoaded", () => {
+    const page = document.getElementById("phenoHuntPage");
+    if (!page) return;
+
+    function send(method, url, body) {
+        const options = { method, headers: { "Content-Type": "application/json" } };
+        if (body !== undefined) options.body = JSON.stringify(body);
+        return fetch(url, options)
+            .then(response => response.json().catch(() => ({})).then(data => {
+                if (!response.ok) throw new Error(data.error || response.statusText);
+                return data;
+            }))
+            .then(() => window.location.reload())
+            .catch(error => uiMessages.showToast(error.message, "danger"));
+    }
+
+    // An empty score box clears the plant's score for that criterion.
+    page.querySelectorAll(".pheno-save").forEach(button => {
+        button.addEventListener("click", () => {
+            const plantId = button.dataset.plantId;
+            const scores = [...page.querySelectorAll(`.pheno-score[data-plant-id="${plantId}"]`)].map(input => ({
+                criterion_id: parseInt(input.dataset.criterionId, 10),
+                score: parseInt(input.value, 10) || null,
+            }));
+            send("PUT", `/plant/${plantId}/pheno-scores`, { scores });
+        });
+    });
+
+    const trackClones = document.getElementById("phenoTrackClones");
+    page.querySelectorAll(".pheno-keeper").forEach(button => {
+        button.addEventListener("click", () => {
+            send("PUT", `/plant/${button.dataset.plantId}/pheno-keeper`, {
+                keeper: button.dataset.keeper !== "true",
+                track_clones: trackClones ? trackClones.checked : false,
+            });
+        });
+    });
+
+    page.querySelectorAll(".pheno-criterion-form").forEach(form => {
+        form.addEventListener("submit", event => {
+            event.preventDefault();
+            const f = form.elements;
+            const body = {
+                name: f.name.value,
+                weight: parseFloat(f.weight.value),
+                sort_order: parseInt(f.sort_order.value, 10) || 0,
+            };
+            if (form.dataset.id) {
+                send("PUT", `/pheno/criteria/${form.dataset.id}`, body);
+            } else {
+                send("POST", "/pheno/criteria", body);
+            }
+        });
+    });
+
+    page.querySelectorAll(".pheno-criterion-delete").forEach(button => {
+        button.addEventListener("click", async () => {
+            if (!await uiMessages.showConfirm(page.dataset.deleteCriterionConfirm)) return;
+            send("DELETE", button.dataset.url);
+        });
+    });
+});
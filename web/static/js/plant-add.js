@@ -10,6 +10,13 @@ document.addEventListener("DOMContentLoaded", () => {
     const parentPlantSelect = document.getElementById("parentPlantSelect");
     const isClone = document.getElementById("isClone");
     const plantPreview = document.getElementById("plantPreview");
+    const seedLots = seedLotPicker.attach({
+        wrapper: document.getElementById("seedLotPicker"),
+        lotSelect: document.getElementById("seedLotSelect"),
+        germinationWrapper: document.getElementById("germinationPicker"),
+        germinationSelect: document.getElementById("germinationSelect"),
+        decrement: document.getElementById("decrementSeedCount"),
+    });
 
     // Initialize autocomplete on strain and zone selects
     const zoneAC = new IsleyAutocomplete(zoneSelect, {
@@ -27,6 +34,7 @@ document.addEventListener("DOMContentLoaded", () => {
     // Show/Hide New Strain Card
     strainSelect.addEventListener("change", () => {
         newStrainCard.classList.toggle("d-none", strainSelect.value !== "new");
+        if (!isClone.checked) seedLots.load(strainSelect.value);
 
         // Load parent plants for selected strain
         if (strainSelect.value !== "new" && strainSelect.value) {
@@ -51,9 +59,14 @@ document.addEventListener("DOMContentLoaded", () => {
         newBreederInput.classList.toggle("d-none", breederSelect.value !== "new");
     });
 
-    // Show/Hide Parent Plant Dropdown
+    // Show/Hide Parent Plant Dropdown. Clones don't come from a seed lot.
     isClone.addEventListener("change", () => {
         parentPlantDropdown.classList.toggle("d-none", !isClone.checked);
+        if (isClone.checked) {
+            seedLots.reset();
+        } else {
+            seedLots.load(strainSelect.value);
+        }
     });
 
     // Live preview update
@@ -125,6 +138,7 @@ document.addEventListener("DOMContentLoaded", () => {
             clone: isClone.checked ? 1 : 0,
             parent_id: parseInt(parentPlantSelect.value, 10),
             decrement_seed_count: document.getElementById("decrementSeedCount").checked,
+            ...seedLots.selection(),
         };
 
         fetch("/plants", {
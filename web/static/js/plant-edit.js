@@ -62,7 +62,7 @@ document.addEventListener("DOMContentLoaded", () => {
             } : null,
             clone: document.getElementById("cloneCheckbox").checked,
             start_date: document.getElementById("startDate").value,
-            harvest_weight: parseFloat(document.getElementById("harvestWeight").value) || 0,
+            harvest_weight: formHelpers.weightToGrams(document.getElementById("harvestWeight")),
         };
 
         fetch("/plant", {
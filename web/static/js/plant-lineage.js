@@ -0,0 +1,101 @@
+document.addEventListener("DOMContentLoaded", () => {
+    const card = document.getElementById("cloneFamily");
+    if (!card) return;
+
+    const plantId = card.dataset.plantId;
+    const tree = document.getElementById("cloneTree");
+    const empty = document.getElementById("cloneTreeEmpty");
+    const stats = document.getElementById("cloneStats");
+
+    // One list item per plant, its cuttings nested below it. The plant
+    // being viewed is shown in bold rather than as a link.
+    function node(plant) {
+        const item = document.createElement("li");
+        item.className = "py-1";
+        const label = document.createElement(plant.id === Number(plantId) ? "strong" : "a");
+        if (label.tagName === "A") label.href = `/plant/${plant.id}`;
+        label.textContent = plant.name;
+        item.appendChild(label);
+
+        const detail = document.createElement("span");
+        detail.className = "text-muted ms-1";
+        detail.textContent = [plant.status, `${card.dataset.generationLabel} ${plant.generation}`].filter(Boolean).join(" · ");
+        if (!plant.living) item.classList.add("text-muted");
+        item.appendChild(detail);
+
+        if (plant.keeper) {
+            const badge = document.createElement("span");
+            badge.className = "badge bg-success ms-1";
+            badge.textContent = card.dataset.keeperLabel;
+            item.appendChild(badge);
+        }
+        if (plant.children && plant.children.length) {
+            const list = document.createElement("ul");
+            list.className = "list-unstyled ps-3 ms-1 border-start";
+            plant.children.forEach(child => list.appendChild(node(child)));
+            item.appendChild(list);
+        }
+        return item;
+    }
+
+    fetch(`/plant/${encodeURIComponent(plantId)}/lineage`)
+        .then(response => response.ok ? response.json() : Promise.reject(response))
+        .then(family => {
+            if (!family.root.children.length) {
+                empty.classList.remove("d-none");
+                return;
+            }
+            tree.appendChild(node(family.root));
+            const mother = family.mother;
+            if (mother.clones_taken) {
+                const parts = [
+                    `${card.dataset.takenLabel}: ${mother.clones_taken}`,
+                    `${card.dataset.failedLabel}: ${mother.clones_failed}`,
+                    `${card.dataset.rateLabel}: ${Math.round(mother.success_rate * 100)}%`,
+                ];
+                stats.textContent = parts.join(" · ");
+                stats.classList.remove("d-none");
+            }
+        })
+        .catch(error => console.error("Error loading clone family:", error));
+
+    function send(method, url, body) {
+        return fetch(url, {
+            method,
+            headers: { "Content-Type": "application/json" },
+            body: JSON.stringify(body),
+        }).then(response => response.json().catch(() => ({})).then(data => {
+            if (!response.ok) throw new Error(data.error || response.statusText);
+            return data;
+        }));
+    }
+
+    const keeper = document.getElementById("plantKeeper");
+    if (keeper) {
+        keeper.addEventListener("change", () => {
+            send("PUT", `/plant/${plantId}/keeper`, { keeper: keeper.checked })
+                .then(data => uiMessages.showToast(data.message, "success"))
+                .catch(error => {
+                    keeper.checked = !keeper.checked;
+                    uiMessages.showToast(error.message, "danger");
+                });
+        });
+    }
+
+    const form = document.getElementById("takeClonesForm");
+    if (!form) return;
+    form.addEventListener("submit", event => {
+        event.preventDefault();
+        const f = form.elements;
+        send("POST", `/plant/${plantId}/clones`, {
+            count: parseInt(f.count.value, 10),
+            date: f.date.value,
+            zone_id: parseInt(f.zone_id.value, 10),
+            status_id: parseInt(f.status_id.value, 10),
+            name_prefix: f.name_prefix.value,
+            note: f.note.value,
+        })
+            .then(() => window.location.reload())
+            .catch(error => uiMessages.showToast(error.message, "danger"));
+    });
+});
@@ -0,0 +1,116 @@
+document.addEventListener("DOMContentLoaded", () => {
+    const section = document.getElementById("progression");
+    if (!section) return;
+
+    const plantId = section.dataset.plantId;
+    const loggedIn = section.dataset.loggedIn === "true";
+    const weekLabel = section.dataset.weekLabel || "Week";
+    const strip = document.getElementById("progressionStrip");
+    const compareModal = document.getElementById("compareImagesModal");
+
+    function tile(week) {
+        const figure = document.createElement("figure");
+        figure.className = "progression-tile mb-0";
+
+        if (week.image) {
+            const img = document.createElement("img");
+            img.src = `/plant/images/${week.image.id}/thumb`;
+            img.alt = week.image.image_description || "";
+            img.loading = "lazy";
+            img.className = "progression-thumb";
+            // Open the comparison with this week as the "before" photo.
+            img.addEventListener("click", () => {
+                compareModal.dataset.left = week.image.id;
+                bootstrap.Modal.getOrCreateInstance(compareModal).show();
+            });
+            figure.appendChild(img);
+        } else {
+            const empty = document.createElement("div");
+            empty.className = "progression-empty";
+            empty.textContent = section.dataset.noPhotoLabel;
+            figure.appendChild(empty);
+        }
+
+        const caption = document.createElement("figcaption");
+        caption.className = "small text-muted mt-1";
+        caption.textContent = `${weekLabel} ${week.week}`;
+        if (week.image) {
+            caption.textContent += ` · ${new Date(week.image.image_date).toLocaleDateString()}`;
+        }
+        figure.appendChild(caption);
+
+        if (loggedIn && week.image) {
+            const label = document.createElement("label");
+            label.className = "form-check-label small";
+            const check = document.createElement("input");
+            check.type = "checkbox";
+            check.className = "form-check-input me-1 progression-include";
+            check.value = week.week;
+            check.checked = true;
+            label.append(check, section.dataset.includeLabel);
+            figure.appendChild(label);
+        }
+        return figure;
+    }
+
+    fetch(`/plant/${encodeURIComponent(plantId)}/progression`)
+        .then(response => response.ok ? response.json() : Promise.reject(response))
+        .then(data => {
+            const weeks = data.weeks || [];
+            if (!weeks.some(week => week.image)) return;
+            weeks.forEach(week => strip.appendChild(tile(week)));
+            section.classList.remove("d-none");
+        })
+        .catch(error => console.error("Error loading progression:", error));
+
+    if (!loggedIn) return;
+
+    const exportPanel = document.getElementById("progressionExport");
+    const exportButton = document.getElementById("progressionExportBtn");
+    const exportLink = document.getElementById("progressionExportLink");
+    const logoDropdown = document.getElementById("progressionLogo");
+
+    exportPanel.addEventListener("show.bs.collapse", () => {
+        if (logoDropdown.dataset.loaded) return;
+        logoDropdown.dataset.loaded = "true";
+        fetch("/listLogos")
+            .then(response => response.json())
+            .then(data => {
+                (data.logos || []).forEach(logo => {
+                    logoDropdown.add(new Option(logo.split("/").pop(), logo));
+                });
+            });
+    });
+
+    exportButton.addEventListener("click", () => {
+        const weeks = Array.from(strip.querySelectorAll(".progression-include:checked"))
+            .map(check => parseInt(check.value, 10));
+        if (!weeks.length) {
+            uiMessages.showToast(uiMessages.t('api_no_progression_images'), 'warning');
+            return;
+        }
+        exportLink.classList.add("d-none");
+        formHelpers.withLoading(exportButton,
+            fetch(`/plant/${encodeURIComponent(plantId)}/progression/export`, {
+                method: "POST",
+                headers: { "Content-Type": "application/json" },
+                body: JSON.stringify({
+                    weeks,
+                    title: document.getElementById("progressionTitle").value,
+                    logo: logoDropdown.value,
+                }),
+            })
+                .then(response => response.json().then(data => ({ ok: response.ok, data })))
+                .then(({ ok, data }) => {
+                    if (!ok) throw new Error(data.error);
+                    exportLink.href = "/" + data.outputPath.replace(/^\/+/, "") + "?" + Date.now();
+                    exportLink.classList.remove("d-none");
+                    uiMessages.showToast(data.message, 'success');
+                })
+                .catch(error => {
+                    console.error("Error exporting progression:", error);
+                    uiMessages.showToast(error.message || uiMessages.t('error_exporting_progression'), 'danger');
+                })
+        );
+    });
+});
@@ -0,0 +1,71 @@
+document.addEventListener("DOMContentLoaded", () => {
+    function send(method, url, body) {
+        const options = { method, headers: { "Content-Type": "application/json" } };
+        if (body !== undefined) options.body = JSON.stringify(body);
+        return fetch(url, options)
+            .then(response => response.json().catch(() => ({})).then(data => {
+                if (!response.ok) throw new Error(data.error || response.statusText);
+                return data;
+            }));
+    }
+
+    function fail(error) {
+        uiMessages.showToast(error.message, "danger");
+    }
+
+    // Reads a number input, returning null when it is left empty.
+    function optionalNumber(input) {
+        if (input.value.trim() === "") return null;
+        return parseFloat(input.value);
+    }
+
+    const runForm = document.getElementById("runForm");
+    if (runForm) {
+        runForm.addEventListener("submit", event => {
+            event.preventDefault();
+            const f = runForm.elements;
+            send(runForm.dataset.method, runForm.dataset.url, {
+                name: f.name.value,
+                zone_id: parseInt(f.zone_id.value, 10) || null,
+                start_date: f.start_date.value,
+                flip_date: f.flip_date.value,
+                end_date: f.end_date.value,
+                light_watts: optionalNumber(f.light_watts),
+                canopy_area: optionalNumber(f.canopy_area),
+                notes: f.notes.value,
+            })
+                .then(data => {
+                    // A new run opens its page so plants can be assigned.
+                    if (data.id) window.location.href = `/runs/${data.id}`;
+                    else window.location.reload();
+                })
+                .catch(fail);
+        });
+    }
+
+    const page = document.getElementById("runPage");
+    if (!page || !page.dataset.runId) return;
+    const runId = page.dataset.runId;
+
+    const deleteButton = document.getElementById("runDeleteBtn");
+    if (deleteButton) {
+        deleteButton.addEventListener("click", async () => {
+            if (!await uiMessages.showConfirm(page.dataset.deleteConfirm)) return;
+            send("DELETE", `/runs/${runId}`)
+                .then(() => { window.location.href = "/runs"; })
+                .catch(fail);
+        });
+    }
+
+    const plantsForm = document.getElementById("runPlantsForm");
+    if (plantsForm) {
+        plantsForm.addEventListener("submit", event => {
+            event.preventDefault();
+            const plantIds = Array.from(plantsForm.querySelectorAll("input[name=plant_id]:checked"))
+                .map(input => parseInt(input.value, 10));
+            send("PUT", `/runs/${runId}/plants`, { plant_ids: plantIds })
+                .then(() => window.location.reload())
+                .catch(fail);
+        });
+    }
+});
@@ -0,0 +1,71 @@
+// Seed lot picker for the add-plant forms. It lists the chosen strain's
+// seed lots and, once a lot is picked, its germination attempts with
+// sprouted seeds still to plant. A plant started from a lot takes its seed
+// from the lot, so the "decrement seed count" checkbox is hidden meanwhile.
+(function () {
+    function option(value, label) {
+        const opt = document.createElement("option");
+        opt.value = value;
+        opt.textContent = label;
+        return opt;
+    }
+
+    // Seeds of an attempt that sprouted (or are still germinating) and
+    // haven't been started as plants yet.
+    function waiting(attempt) {
+        const ready = attempt.sprouted === null ? attempt.seeds : attempt.sprouted;
+        return ready - attempt.plants_started;
+    }
+
+    function attach({ wrapper, lotSelect, germinationWrapper, germinationSelect, decrement }) {
+        let lots = [];
+        const noLot = lotSelect.options[0].textContent;
+        const newSeed = germinationSelect.options[0].textContent;
+        const decrementRow = decrement ? decrement.closest(".form-check") : null;
+
+        function showGerminations() {
+            const lot = lots.find(l => String(l.id) === lotSelect.value);
+            const attempts = lot ? lot.attempts.filter(a => waiting(a) > 0) : [];
+            germinationSelect.replaceChildren(option(0, newSeed));
+            attempts.forEach(a => germinationSelect.appendChild(option(a.id,
+                `${a.start_date.slice(0, 10)} · ${waiting(a)} ${uiMessages.t("germination_waiting")}`)));
+            germinationWrapper.classList.toggle("d-none", attempts.length === 0);
+            if (decrementRow) decrementRow.classList.toggle("d-none", Boolean(lot));
+        }
+
+        lotSelect.addEventListener("change", showGerminations);
+
+        function reset() {
+            lots = [];
+            lotSelect.replaceChildren(option(0, noLot));
+            wrapper.classList.add("d-none");
+            showGerminations();
+        }
+
+        function load(strainId) {
+            reset();
+            if (!strainId || strainId === "new") return;
+            fetch(`/strains/${encodeURIComponent(strainId)}/seed-lots`)
+                .then(response => response.ok ? response.json() : [])
+                .then(data => {
+                    lots = data.filter(l => l.seeds_left > 0 || l.attempts.some(a => waiting(a) > 0));
+                    lots.forEach(l => lotSelect.appendChild(option(l.id,
+                        `${l.source || uiMessages.t("seed_lot_no_source")} · ${uiMessages.t("seed_seeds_left")}: ${l.seeds_left}`)));
+                    wrapper.classList.toggle("d-none", lots.length === 0);
+                })
+                .catch(() => {});
+        }
+
+        // The seed_lot_id and germination_id to send with the new plant.
+        function selection() {
+            return {
+                seed_lot_id: parseInt(lotSelect.value, 10) || null,
+                germination_id: parseInt(germinationSelect.value, 10) || null,
+            };
+        }
+
+        return { load, reset, selection };
+    }
+
+    window.seedLotPicker = { attach };
+})();
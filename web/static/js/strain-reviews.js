@@ -0,0 +1,44 @@
+document.addEventListener("DOMContentLoaded", () => {
+    const container = document.getElementById("strainReviews");
+    if (!container) return;
+
+    function send(method, url, body) {
+        const options = { method, headers: { "Content-Type": "application/json" } };
+        if (body !== undefined) options.body = JSON.stringify(body);
+        return fetch(url, options)
+            .then(response => response.json().catch(() => ({})).then(data => {
+                if (!response.ok) throw new Error(data.error || response.statusText);
+                return data;
+            }))
+            .then(() => window.location.reload())
+            .catch(error => uiMessages.showToast(error.message, "danger"));
+    }
+
+    // Tags are typed as a comma separated list.
+    function tags(value) {
+        return value.split(",").map(tag => tag.trim()).filter(tag => tag !== "");
+    }
+
+    const form = document.getElementById("reviewForm");
+    if (form) {
+        form.addEventListener("submit", event => {
+            event.preventDefault();
+            const f = form.elements;
+            send("POST", `/plant/${form.dataset.plantId}/reviews`, {
+                review_date: f.review_date.value,
+                rating: parseInt(f.rating.value, 10),
+                potency: parseInt(f.potency.value, 10) || null,
+                flavor_tags: tags(f.flavor_tags.value),
+                effect_tags: tags(f.effect_tags.value),
+                notes: f.notes.value,
+            });
+        });
+    }
+
+    container.querySelectorAll(".review-delete").forEach(button => {
+        button.addEventListener("click", async () => {
+            if (!await uiMessages.showConfirm(container.dataset.deleteConfirm)) return;
+            send("DELETE", button.dataset.url);
+        });
+    });
+});
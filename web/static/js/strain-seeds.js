@@ -0,0 +1,70 @@
+document.addEventListener("DOMContentLoaded", () => {
+    const card = document.getElementById("seedLots");
+    if (!card) return;
+
+    const strainId = card.dataset.strainId;
+
+    function send(method, url, body) {
+        const options = { method, headers: { "Content-Type": "application/json" } };
+        if (body !== undefined) options.body = JSON.stringify(body);
+        return fetch(url, options)
+            .then(response => response.json().catch(() => ({})).then(data => {
+                if (!response.ok) throw new Error(data.error || response.statusText);
+                return data;
+            }))
+            .then(() => window.location.reload())
+            .catch(error => uiMessages.showToast(error.message, "danger"));
+    }
+
+    const lotForm = document.getElementById("seedLotForm");
+    if (lotForm) {
+        lotForm.addEventListener("submit", event => {
+            event.preventDefault();
+            const f = lotForm.elements;
+            send("POST", `/strains/${strainId}/seed-lots`, {
+                source: f.source.value,
+                purchase_date: f.purchase_date.value,
+                pack_size: parseInt(f.pack_size.value, 10),
+                cost: f.cost.value.trim() === "" ? null : parseFloat(f.cost.value),
+                feminized: f.feminized.value === "true",
+                notes: f.notes.value,
+            });
+        });
+    }
+
+    card.querySelectorAll(".germination-form").forEach(form => {
+        const f = form.elements;
+        if (!f.start_date.value) f.start_date.value = formHelpers.formatDateTimeLocal(new Date()).slice(0, 10);
+        form.addEventListener("submit", event => {
+            event.preventDefault();
+            send("POST", `/seed-lots/${form.dataset.lotId}/germinations`, {
+                start_date: f.start_date.value,
+                seeds: parseInt(f.seeds.value, 10),
+                method: f.method.value,
+            });
+        });
+    });
+
+    // Recording an outcome keeps the attempt's other details as they are.
+    card.querySelectorAll(".germination-outcome-form").forEach(form => {
+        form.addEventListener("submit", event => {
+            event.preventDefault();
+            const f = form.elements;
+            send("PUT", `/germinations/${form.dataset.id}`, {
+                start_date: form.dataset.startDate,
+                method: form.dataset.method,
+                notes: form.dataset.notes,
+                sprouted: parseInt(f.sprouted.value, 10),
+                sprout_date: f.sprout_date.value,
+            });
+        });
+    });
+
+    card.querySelectorAll(".seed-delete").forEach(button => {
+        button.addEventListener("click", async () => {
+            const message = button.dataset.confirm === "lot" ? card.dataset.deleteLotConfirm : card.dataset.deleteAttemptConfirm;
+            if (!await uiMessages.showConfirm(message)) return;
+            send("DELETE", button.dataset.url);
+        });
+    });
+});
@@ -0,0 +1,113 @@
+document.addEventListener("DOMContentLoaded", () => {
+    const page = document.getElementById("tasksPage");
+    if (!page) return;
+
+    function send(method, url, body) {
+        const options = { method, headers: { "Content-Type": "application/json" } };
+        if (body !== undefined) options.body = JSON.stringify(body);
+        return fetch(url, options)
+            .then(response => response.json().catch(() => ({})).then(data => {
+                if (!response.ok) throw new Error(data.error || response.statusText);
+                return data;
+            }));
+    }
+
+    function fail(error) {
+        uiMessages.showToast(error.message, "danger");
+    }
+
+    // Reads a number input or select, returning null when it is empty.
+    function optionalInt(input) {
+        if (input.value.trim() === "") return null;
+        return parseInt(input.value, 10);
+    }
+
+    document.querySelectorAll(".task-complete").forEach(button => {
+        button.addEventListener("click", () => {
+            const plantID = parseInt(button.dataset.plant, 10);
+            button.disabled = true;
+            send("POST", button.dataset.url, plantID ? { plant_id: plantID } : {})
+                .then(() => window.location.reload())
+                .catch(error => {
+                    button.disabled = false;
+                    fail(error);
+                });
+        });
+    });
+
+    document.querySelectorAll(".task-delete").forEach(button => {
+        button.addEventListener("click", async () => {
+            if (!await uiMessages.showConfirm(page.dataset.deleteConfirm)) return;
+            send("DELETE", button.dataset.url)
+                .then(() => window.location.reload())
+                .catch(fail);
+        });
+    });
+
+    const form = document.getElementById("taskForm");
+    if (!form) return;
+    const f = form.elements;
+
+    // Only the target select of the chosen kind and the fields of the
+    // chosen schedule are shown.
+    function showFields() {
+        form.querySelectorAll(".task-target").forEach(select => {
+            select.classList.toggle("d-none", select.dataset.kind !== f.target_kind.value);
+        });
+        form.querySelectorAll(".task-schedule-field").forEach(field => {
+            field.classList.toggle("d-none", !field.dataset.schedules.split(" ").includes(f.schedule.value));
+        });
+    }
+    f.target_kind.addEventListener("change", showFields);
+    f.schedule.addEventListener("change", showFields);
+    showFields();
+
+    form.addEventListener("submit", event => {
+        event.preventDefault();
+        const body = {
+            title: f.title.value,
+            notes: f.notes.value,
+            activity_id: optionalInt(f.activity_id),
+            schedule: f.schedule.value,
+            due_date: f.due_date.value,
+            interval_days: optionalInt(f.interval_days),
+            stage_status_id: optionalInt(f.stage_status_id),
+            stage_day: optionalInt(f.stage_day),
+        };
+        body[f.target_kind.value] = optionalInt(f[f.target_kind.value]);
+        const id = f.id.value;
+        send(id ? "PUT" : "POST", id ? `/tasks/${id}` : "/tasks", body)
+            .then(() => window.location.reload())
+            .catch(fail);
+    });
+
+    // Clearing the form also leaves edit mode.
+    form.addEventListener("reset", () => {
+        f.id.value = "";
+        setTimeout(showFields);
+    });
+
+    document.querySelectorAll(".task-edit").forEach(button => {
+        button.addEventListener("click", () => {
+            const data = JSON.parse(button.dataset.json);
+            form.reset();
+            f.id.value = data.id;
+            f.title.value = data.title;
+            f.notes.value = data.notes;
+            f.activity_id.value = data.activity_id || "";
+            ["plant_id", "zone_id", "grow_run_id"].forEach(kind => {
+                if (data[kind]) {
+                    f.target_kind.value = kind;
+                    f[kind].value = data[kind];
+                }
+            });
+            f.schedule.value = data.schedule;
+            f.due_date.value = data.due_date ? data.due_date.substring(0, 10) : "";
+            f.interval_days.value = data.interval_days || "";
+            if (data.stage_status_id) f.stage_status_id.value = data.stage_status_id;
+            f.stage_day.value = data.stage_day || "";
+            showFields();
+            form.scrollIntoView({ behavior: "smooth", block: "center" });
+        });
+    });
+});